@@ -7,7 +7,7 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"github.com/kwilteam/kwil-db/core/crypto"
+	"github.com/kwilteam/kwil-db/node"
 )
 
 const keyExplain = "The `key` command provides subcommands for private key generation and inspection."
@@ -26,8 +26,10 @@ func KeyCmd() *cobra.Command {
 	return keyCmd
 }
 
-func privKeyInfo(privateKey []byte, keyType crypto.KeyType) *PrivateKeyInfo {
-	priv, err := crypto.UnmarshalPrivateKey(privateKey, keyType)
+// privKeyInfo describes a secp256k1 or ed25519 private key, with the key type
+// determined from the key's length.
+func privKeyInfo(privateKey []byte) *PrivateKeyInfo {
+	priv, err := node.UnmarshalNodeKey(privateKey)
 	if err != nil {
 		return &PrivateKeyInfo{PrivateKeyHex: "<invalid>"}
 	}
@@ -3,6 +3,7 @@ package key
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"fmt"
 	"os"
 
 	"github.com/kwilteam/kwil-db/app/shared/display"
@@ -16,12 +17,15 @@ var (
 kwild key gen --key-file ./priv_key
 
 # Generate a raw private key
-kwild key gen --raw`
+kwild key gen --raw
+
+# Generate an ed25519 key
+kwild key gen --key-type ed25519`
 )
 
 func GenCmd() *cobra.Command {
 	var raw bool // if true, output hex private key only
-	var out string
+	var out, keyType string
 
 	cmd := &cobra.Command{
 		Use:     "gen",
@@ -30,7 +34,7 @@ func GenCmd() *cobra.Command {
 		Example: genExample,
 		Args:    cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			privKey, err := generatePrivateKey()
+			privKey, err := generatePrivateKey(keyType)
 			if err != nil {
 				return display.PrintErr(cmd, err)
 			}
@@ -58,11 +62,21 @@ func GenCmd() *cobra.Command {
 
 	cmd.Flags().BoolVarP(&raw, "raw", "R", false, "just print the private key hex without other encodings, public key, or node ID")
 	cmd.Flags().StringVarP(&out, "key-file", "o", "", "file to which the new private key is written (stdout by default)")
+	cmd.Flags().StringVarP(&keyType, "key-type", "t", "secp256k1", "type of key to generate (secp256k1 or ed25519)")
 
 	return cmd
 }
 
-func generatePrivateKey( /* TODO: key type */ ) (crypto.PrivateKey, error) {
-	privKey, _, err := crypto.GenerateSecp256k1Key(rand.Reader)
+func generatePrivateKey(keyType string) (crypto.PrivateKey, error) {
+	var privKey crypto.PrivateKey
+	var err error
+	switch keyType {
+	case "secp256k1":
+		privKey, _, err = crypto.GenerateSecp256k1Key(rand.Reader)
+	case "ed25519":
+		privKey, _, err = crypto.GenerateEd25519Key(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", keyType)
+	}
 	return privKey, err
 }
@@ -8,7 +8,6 @@ import (
 	"os"
 
 	"github.com/kwilteam/kwil-db/app/shared/display"
-	"github.com/kwilteam/kwil-db/core/crypto"
 
 	"github.com/spf13/cobra"
 )
@@ -16,7 +15,8 @@ import (
 var (
 	infoLong = `Display information about a private key.
 
-The private key can either be passed as a key file path, or as a hex-encoded string.`
+The private key can either be passed as a key file path, or as a hex-encoded string.
+The key may be a 32 byte secp256k1 key or a 64 byte ed25519 key.`
 
 	infoExample = `# Using a key file
 kwild key info --key-file ~/.kwild/private_key
@@ -42,13 +42,13 @@ func InfoCmd() *cobra.Command {
 				if err != nil {
 					return display.PrintErr(cmd, fmt.Errorf("private key not valid hex: %w", err))
 				}
-				return display.PrintCmd(cmd, privKeyInfo(key, crypto.KeyTypeSecp256k1))
+				return display.PrintCmd(cmd, privKeyInfo(key))
 			} else if privkeyFile != "" {
 				key, err := readKeyFile(privkeyFile)
 				if err != nil {
 					return display.PrintErr(cmd, err)
 				}
-				return display.PrintCmd(cmd, privKeyInfo(key, crypto.KeyTypeSecp256k1))
+				return display.PrintCmd(cmd, privKeyInfo(key))
 			}
 
 			cmd.Usage()
@@ -37,6 +37,8 @@ import (
 	"github.com/kwilteam/kwil-db/node/services/jsonrpc/chainsvc"
 	"github.com/kwilteam/kwil-db/node/services/jsonrpc/funcsvc"
 	"github.com/kwilteam/kwil-db/node/services/jsonrpc/usersvc"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 func buildServer(ctx context.Context, d *coreDependencies) *server {
@@ -65,7 +67,7 @@ func buildServer(ctx context.Context, d *coreDependencies) *server {
 	e := buildEngine(d, db)
 
 	// Mempool
-	mp := mempool.New()
+	mp := buildMempool(d)
 
 	// accounts
 	accounts := buildAccountStore(ctx, d, db)
@@ -117,10 +119,31 @@ func buildServer(ctx context.Context, d *coreDependencies) *server {
 		// The admin service uses a client-style signer rather than just a private
 		// key because it is used to sign transactions and provide an Identity for
 		// account information (nonce and balance).
-		txSigner := &auth.EthPersonalSigner{Key: *d.privKey.(*crypto.Secp256k1PrivateKey)}
-		jsonAdminSvc := adminsvc.NewService(db, node, bp, vs, nil, txSigner, d.cfg,
-			d.genesisCfg.ChainID, adminServerLogger)
-		jsonRPCAdminServer = buildJRPCAdminServer(d)
+		txSigner := auth.GetUserSigner(d.privKey)
+		adminOpts := []adminsvc.Opt{
+			adminsvc.WithLeader(d.genesisCfg.Leader),
+			adminsvc.WithTimeout(d.cfg.Admin.Timeout),
+			adminsvc.WithKeyRotation(filepath.Join(d.rootDir, rotatedKeyFile), d.privKey.Type()),
+		}
+		if d.cfg.Admin.RequireSignature {
+			allowed, err := adminSigners(d.cfg.Admin.AllowedSigners)
+			if err != nil {
+				failBuild(err, "invalid admin allowed signers")
+			}
+			adminOpts = append(adminOpts, adminsvc.WithSignedRequests(d.privKey.Public(), allowed...))
+			if d.cfg.Admin.OpenReads {
+				adminOpts = append(adminOpts, adminsvc.WithOpenReads())
+			}
+		}
+		var serverOpts []rpcserver.Opt
+		if d.cfg.Admin.Metrics {
+			reg := prometheus.NewRegistry()
+			adminOpts = append(adminOpts, adminsvc.WithMetrics(reg))
+			serverOpts = append(serverOpts, rpcserver.WithMetricsEndpoint(reg))
+		}
+		jsonAdminSvc := adminsvc.NewService(db, node, bp, vs, node, txSigner, d.cfg,
+			d.genesisCfg.ChainID, adminServerLogger, adminOpts...)
+		jsonRPCAdminServer = buildJRPCAdminServer(d, serverOpts...)
 		jsonRPCAdminServer.RegisterSvc(jsonAdminSvc)
 		jsonRPCAdminServer.RegisterSvc(jsonRPCTxSvc)
 		jsonRPCAdminServer.RegisterSvc(&funcsvc.Service{})
@@ -159,6 +182,21 @@ func buildDB(ctx context.Context, d *coreDependencies, closers *closeFuncs) *pg.
 	return db
 }
 
+func buildMempool(d *coreDependencies) *mempool.Mempool {
+	priorityTypes := make([]ktypes.PayloadType, len(d.cfg.Mempool.PriorityTypes))
+	for i, pt := range d.cfg.Mempool.PriorityTypes {
+		priorityTypes[i] = ktypes.PayloadType(pt)
+		if !priorityTypes[i].Valid() {
+			failBuild(nil, fmt.Sprintf("invalid mempool priority payload type %q", pt))
+		}
+	}
+
+	return mempool.New(mempool.WithMaxSize(d.cfg.Mempool.MaxSize),
+		mempool.WithMaxAccountSize(d.cfg.Mempool.MaxAccountSize),
+		mempool.WithPriorityTypes(priorityTypes...),
+		mempool.WithAccountTurns(d.cfg.Mempool.AccountTurnTxs))
+}
+
 func buildBlockStore(d *coreDependencies, closers *closeFuncs) *store.BlockStore {
 	blkStrDir := filepath.Join(d.rootDir, "blockstore")
 	bs, err := store.NewBlockStore(blkStrDir)
@@ -231,7 +269,7 @@ func buildBlockProcessor(ctx context.Context, d *coreDependencies, db *pg.DB, tx
 
 func buildConsensusEngine(_ context.Context, d *coreDependencies, db *pg.DB,
 	mempool *mempool.Mempool, bs *store.BlockStore, bp *blockprocessor.BlockProcessor, valSet map[string]ktypes.Validator) *consensus.ConsensusEngine {
-	leaderPubKey, err := crypto.UnmarshalSecp256k1PublicKey(d.genesisCfg.Leader)
+	leaderPubKey, err := node.UnmarshalNodePubKey(d.genesisCfg.Leader)
 	if err != nil {
 		failBuild(err, "failed to parse leader public key")
 	}
@@ -273,7 +311,28 @@ func buildNode(d *coreDependencies, mp *mempool.Mempool, bs *store.BlockStore, c
 		DBConfig:    &d.cfg.DB,
 	}
 
-	node, err := node.NewNode(nc)
+	var opts []node.Option
+	if d.cfg.P2P.PSKFile != "" {
+		psk, err := node.LoadPSK(rootedPath(d.cfg.P2P.PSKFile, d.rootDir))
+		if err != nil {
+			failBuild(err, "failed to load P2P pre-shared key")
+		}
+		opts = append(opts, node.WithPSK(psk))
+	}
+	if d.cfg.Mempool.Persist {
+		opts = append(opts, node.WithMempoolPersistence())
+	}
+	if d.cfg.Consensus.Archive {
+		opts = append(opts, node.WithArchive())
+	}
+	if d.cfg.P2P.RequestRate > 0 {
+		opts = append(opts, node.WithPeerRateLimit(node.PeerRateLimit{
+			Rate:  d.cfg.P2P.RequestRate,
+			Burst: max(d.cfg.P2P.RequestBurst, 1),
+		}))
+	}
+
+	node, err := node.NewNode(nc, opts...)
 	if err != nil {
 		failBuild(err, "failed to create node")
 	}
@@ -335,6 +394,8 @@ func buildSnapshotStore(d *coreDependencies) *snapshotter.SnapshotStore {
 		RecurringHeight: d.cfg.Snapshots.RecurringHeight,
 		Enable:          d.cfg.Snapshots.Enable,
 		DBConfig:        &d.cfg.DB,
+		ChunkSize:       int64(d.cfg.Snapshots.ChunkSize),
+		Format:          d.cfg.Snapshots.Format,
 	}
 
 	if err := os.MkdirAll(snapshotDir, 0755); err != nil {
@@ -349,7 +410,11 @@ func buildSnapshotStore(d *coreDependencies) *snapshotter.SnapshotStore {
 	return ss
 }
 
-func buildJRPCAdminServer(d *coreDependencies) *rpcserver.Server {
+// rotatedKeyFile is the file in the root directory where the admin service
+// saves the new key of a validator key rotation.
+const rotatedKeyFile = "rotated_key"
+
+func buildJRPCAdminServer(d *coreDependencies, extraOpts ...rpcserver.Opt) *rpcserver.Server {
 	var wantTLS bool
 	addr := d.cfg.Admin.ListenAddress
 	host, port, err := net.SplitHostPort(addr)
@@ -408,6 +473,7 @@ func buildJRPCAdminServer(d *coreDependencies) *rpcserver.Server {
 	// general, only mutual TLS. It could be a simpler alternative to mutual
 	// TLS, or just coupled with TLS termination on a local reverse proxy.
 	opts = append(opts, rpcserver.WithServerInfo(&adminsvc.SpecInfo))
+	opts = append(opts, extraOpts...)
 	svcLogger := d.logger.New("ADMINRPC")
 	jsonRPCAdminServer, err := rpcserver.NewServer(addr, svcLogger, opts...)
 	if err != nil {
@@ -417,6 +483,24 @@ func buildJRPCAdminServer(d *coreDependencies) *rpcserver.Server {
 	return jsonRPCAdminServer
 }
 
+// adminSigners decodes the hex-encoded ed25519 public keys that are allowed to
+// sign admin requests.
+func adminSigners(signers []string) ([]*crypto.Ed25519PublicKey, error) {
+	keys := make([]*crypto.Ed25519PublicKey, 0, len(signers))
+	for _, signer := range signers {
+		pubKey, err := hex.DecodeString(signer)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signer %q: %w", signer, err)
+		}
+		key, err := crypto.UnmarshalEd25519PublicKey(pubKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signer %q: %w", signer, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
 func loadTLSCertificate(keyFile, certFile, hostname string) (*tls.Certificate, error) {
 	keyExists, certExists := fileExists(keyFile), fileExists(certFile)
 	if certExists != keyExists { // one but not both
@@ -0,0 +1,177 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/kwilteam/kwil-db/app/shared/bind"
+	"github.com/kwilteam/kwil-db/config"
+
+	"github.com/knadh/koanf/parsers/toml/v2"
+	"github.com/knadh/koanf/providers/rawbytes"
+	"github.com/knadh/koanf/v2"
+	gotoml "github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+)
+
+// ConfigError is a problem with a setting in a config file.
+type ConfigError struct {
+	// Line is the line of the config file with the problem, or zero if the
+	// setting is not in the file, such as a missing required setting.
+	Line int
+	// Key is the config key with the problem, such as "p2p.port".
+	Key string
+	Msg string
+}
+
+func (e ConfigError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.Key, e.Msg)
+	}
+	return fmt.Sprintf("line %d: %s: %s", e.Line, e.Key, e.Msg)
+}
+
+// ValidateConfigFile checks a TOML config file without starting a node. The
+// file is merged over the defaults and decoded in the same way as the config
+// used by a node (see ActiveConfig). It returns the unknown keys, invalid
+// values, and missing required settings. The error is only for a file that
+// cannot be read or parsed as TOML, which is a *gotoml.DecodeError with the
+// position of a syntax error.
+func ValidateConfigFile(path string, defaults *config.Config) ([]ConfigError, error) {
+	bts, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]any
+	if err = gotoml.Unmarshal(bts, &m); err != nil {
+		return nil, err
+	}
+	lines := keyLines(bts)
+
+	kDefaults := koanf.New(".")
+	if err = bind.BindDefaultsTo(defaults, koanfTag, kDefaults); err != nil {
+		return nil, err
+	}
+	kFile := koanf.New(".")
+	if err = kFile.Load(rawbytes.Provider(bts), toml.Parser()); err != nil {
+		return nil, err
+	}
+
+	var problems []ConfigError
+	keys := kFile.Keys()
+	slices.SortFunc(keys, func(a, b string) int {
+		return lines[a] - lines[b]
+	})
+	for _, key := range keys {
+		if !kDefaults.Exists(key) {
+			problems = append(problems, ConfigError{lines[key], key, "unknown key"})
+			continue
+		}
+		// Decode each setting alone so that an invalid value is attributed to
+		// its key.
+		kOne := koanf.New(".")
+		if err = kOne.Set(key, kFile.Get(key)); err != nil {
+			return nil, err
+		}
+		var cfg config.Config
+		if err = kOne.UnmarshalWithConf("", &cfg, koanf.UnmarshalConf{Tag: koanfTag}); err != nil {
+			problems = append(problems, ConfigError{lines[key], key, decodeErrMsg(err)})
+		}
+	}
+	if len(problems) > 0 {
+		return problems, nil // required settings are checked with valid values
+	}
+
+	if err = kDefaults.Merge(kFile); err != nil {
+		return nil, err
+	}
+	var cfg config.Config
+	if err = kDefaults.UnmarshalWithConf("", &cfg, koanf.UnmarshalConf{Tag: koanfTag}); err != nil {
+		return nil, err
+	}
+	for _, req := range requiredSettings {
+		if req.missing(&cfg) {
+			problems = append(problems, ConfigError{lines[req.key], req.key, req.msg})
+		}
+	}
+
+	return problems, nil
+}
+
+// requiredSettings are the settings that a node cannot start without, possibly
+// depending on other settings.
+var requiredSettings = []struct {
+	key     string
+	msg     string
+	missing func(*config.Config) bool
+}{
+	{"db.host", "required", func(c *config.Config) bool { return c.DB.Host == "" }},
+	{"db.port", "required", func(c *config.Config) bool { return c.DB.Port == "" }},
+	{"db.user", "required", func(c *config.Config) bool { return c.DB.User == "" }},
+	{"db.dbname", "required", func(c *config.Config) bool { return c.DB.DBName == "" }},
+	{"rpc.listen", "required", func(c *config.Config) bool { return c.RPC.ListenAddress == "" }},
+	{"admin.listen", "required when the admin service is enabled", func(c *config.Config) bool {
+		return c.Admin.Enable && c.Admin.ListenAddress == ""
+	}},
+	{"state_sync.trusted_providers", "required when state sync is enabled", func(c *config.Config) bool {
+		return c.StateSync.Enable && len(c.StateSync.TrustedProviders) == 0
+	}},
+}
+
+// decodeErrMsg returns the cause of a decoding error without the decoder's
+// description of where it happened, since the key is reported separately.
+func decodeErrMsg(err error) string {
+	msg := err.Error()
+	if i := strings.LastIndex(msg, "': "); i != -1 {
+		msg = msg[i+3:]
+	}
+	return strings.TrimSpace(msg)
+}
+
+// keyLines returns the line of each key in a TOML document, by its full dotted
+// path. The document must be valid TOML.
+func keyLines(data []byte) map[string]int {
+	lines := make(map[string]int)
+	var table []string
+	p := unstable.Parser{}
+	p.Reset(data)
+	for p.NextExpression() {
+		expr := p.Expression()
+		var parts []string
+		var line int
+		for it := expr.Key(); it.Next(); {
+			node := it.Node()
+			parts = append(parts, string(node.Data))
+			line = p.Shape(node.Raw).Start.Line
+		}
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			table = parts
+		case unstable.KeyValue:
+			parts = append(slices.Clone(table), parts...)
+		}
+		if key := strings.Join(parts, "."); lines[key] == 0 {
+			lines[key] = line
+		}
+	}
+	if err := p.Error(); err != nil {
+		// Not expected after a successful decode. The lines are best effort.
+		bind.Debugf("failed to parse config for key lines: %v", err)
+	}
+	return lines
+}
+
+// IsSyntaxError reports whether an error from ValidateConfigFile is a TOML
+// syntax error, and if so, its line and column.
+func IsSyntaxError(err error) (line, col int, ok bool) {
+	var decErr *gotoml.DecodeError
+	if !errors.As(err, &decErr) {
+		return 0, 0, false
+	}
+	line, col = decErr.Position()
+	return line, col, true
+}
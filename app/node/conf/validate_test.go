@@ -0,0 +1,122 @@
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/config"
+)
+
+func TestValidateConfigFile(t *testing.T) {
+	writeConfig := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), config.ConfigFileName)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	t.Run("default config", func(t *testing.T) {
+		cfg := config.DefaultConfig()
+		bts, err := cfg.ToTOML()
+		require.NoError(t, err)
+		problems, err := ValidateConfigFile(writeConfig(t, string(bts)), cfg)
+		require.NoError(t, err)
+		assert.Empty(t, problems)
+	})
+
+	t.Run("valid settings", func(t *testing.T) {
+		path := writeConfig(t, `
+log_level = "debug"
+
+[p2p]
+port = 6601
+grace_period = "30s"
+
+[admin]
+timeout = "1m"
+`)
+		problems, err := ValidateConfigFile(path, config.DefaultConfig())
+		require.NoError(t, err)
+		assert.Empty(t, problems)
+	})
+
+	t.Run("bad duration", func(t *testing.T) {
+		path := writeConfig(t, `
+[p2p]
+grace_period = "30 seconds"
+
+[admin]
+timeout = "5x"
+`)
+		problems, err := ValidateConfigFile(path, config.DefaultConfig())
+		require.NoError(t, err)
+		require.Len(t, problems, 2)
+		assert.Equal(t, 3, problems[0].Line)
+		assert.Equal(t, "p2p.grace_period", problems[0].Key)
+		assert.Contains(t, problems[0].Msg, "duration")
+		assert.Equal(t, 6, problems[1].Line)
+		assert.Equal(t, "admin.timeout", problems[1].Key)
+	})
+
+	t.Run("bad log level", func(t *testing.T) {
+		path := writeConfig(t, `log_level = "loud"`)
+		problems, err := ValidateConfigFile(path, config.DefaultConfig())
+		require.NoError(t, err)
+		require.Len(t, problems, 1)
+		assert.Equal(t, ConfigError{Line: 1, Key: "log_level", Msg: problems[0].Msg}, problems[0])
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		path := writeConfig(t, `
+[p2p]
+port = 6601
+prot = 6602
+
+[bogus]
+enable = true
+`)
+		problems, err := ValidateConfigFile(path, config.DefaultConfig())
+		require.NoError(t, err)
+		assert.Equal(t, []ConfigError{
+			{Line: 4, Key: "p2p.prot", Msg: "unknown key"},
+			{Line: 7, Key: "bogus.enable", Msg: "unknown key"},
+		}, problems)
+		assert.Equal(t, "line 4: p2p.prot: unknown key", problems[0].Error())
+	})
+
+	t.Run("missing required", func(t *testing.T) {
+		path := writeConfig(t, `
+[db]
+host = ""
+
+[state_sync]
+enable = true
+`)
+		problems, err := ValidateConfigFile(path, config.DefaultConfig())
+		require.NoError(t, err)
+		assert.Equal(t, []ConfigError{
+			{Line: 3, Key: "db.host", Msg: "required"},
+			{Line: 0, Key: "state_sync.trusted_providers", Msg: "required when state sync is enabled"},
+		}, problems)
+		assert.Equal(t, "state_sync.trusted_providers: required when state sync is enabled", problems[1].Error())
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		path := writeConfig(t, "[p2p\nport = 6601\n")
+		_, err := ValidateConfigFile(path, config.DefaultConfig())
+		require.Error(t, err)
+		line, _, ok := IsSyntaxError(err)
+		assert.True(t, ok)
+		assert.Equal(t, 1, line)
+	})
+
+	t.Run("no file", func(t *testing.T) {
+		_, err := ValidateConfigFile(filepath.Join(t.TempDir(), "nope.toml"), config.DefaultConfig())
+		assert.ErrorIs(t, err, os.ErrNotExist)
+		_, _, ok := IsSyntaxError(err)
+		assert.False(t, ok)
+	})
+}
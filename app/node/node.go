@@ -11,7 +11,6 @@ import (
 	"path/filepath"
 
 	"github.com/kwilteam/kwil-db/config"
-	"github.com/kwilteam/kwil-db/core/crypto"
 	"github.com/kwilteam/kwil-db/core/log"
 	"github.com/kwilteam/kwil-db/node"
 	"github.com/kwilteam/kwil-db/node/consensus"
@@ -39,7 +38,7 @@ type server struct {
 	jsonRPCAdminServer *rpcserver.Server
 }
 
-func runNode(ctx context.Context, rootDir string, cfg *config.Config) error {
+func runNode(ctx context.Context, rootDir string, cfg *config.Config, keySrc nodeKeySource) error {
 	// Writing to stdout and a log file.  TODO: config outputs
 	rot, err := log.NewRotatorWriter(filepath.Join(rootDir, "kwild.log"), 10_000, 0)
 	if err != nil {
@@ -68,11 +67,12 @@ func runNode(ctx context.Context, rootDir string, cfg *config.Config) error {
 		return fmt.Errorf("failed to load genesis config: %w", err)
 	}
 
-	privKey, err := crypto.UnmarshalSecp256k1PrivateKey(cfg.PrivateKey)
+	privKey, keyFrom, err := keySrc.load(cfg.PrivateKey)
 	if err != nil {
 		return err
 	}
 	pubKey := privKey.Public().Bytes()
+	logger.Info("Loaded the node private key", "source", keyFrom)
 
 	logger.Info("Parsing the pubkey", "key", hex.EncodeToString(pubKey))
 
@@ -161,6 +161,10 @@ func (s *server) Start(ctx context.Context) error {
 			s.cancelCtxFunc() // Ensure all services are stopped
 			return err
 		}
+		// The node may be stopped without the server context being
+		// canceled, such as with a shutdown request from the admin service.
+		s.log.Info("node stopped, shutting down the server")
+		s.cancelCtxFunc()
 		return nil
 	})
 
@@ -0,0 +1,103 @@
+package node
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/kwilteam/kwil-db/core/crypto"
+	"github.com/kwilteam/kwil-db/node"
+)
+
+// maxNodeKeyInput limits how much is read when the node key is read from
+// stdin. An encoded key is less than 100 bytes.
+const maxNodeKeyInput = 1 << 10
+
+// nodeKeySource specifies where to get the node's private key other than the
+// config. The sources in order of precedence are: stdin, the environment
+// variable, and the config's privkey setting. The key from stdin or the
+// environment is hex or base64 encoded.
+//
+// Errors never include the key or part of it, and neither should logs.
+type nodeKeySource struct {
+	stdin  io.Reader // if set, the key is read from it, e.g. os.Stdin
+	envVar string    // if set, the name of the environment variable with the key
+}
+
+// load gets the node's private key from the source with the highest
+// precedence that is set, or the config's key otherwise. It also returns a
+// description of where the key came from.
+func (s nodeKeySource) load(cfgKey []byte) (crypto.PrivateKey, string, error) {
+	switch {
+	case s.stdin != nil:
+		const from = "stdin"
+		bts, err := io.ReadAll(io.LimitReader(s.stdin, maxNodeKeyInput+1))
+		if err != nil {
+			return nil, from, fmt.Errorf("failed to read node private key from %s: %w", from, err)
+		}
+		if len(bts) > maxNodeKeyInput {
+			return nil, from, fmt.Errorf("node private key from %s is too long", from)
+		}
+		key, err := decodeNodeKey(bts)
+		if err != nil {
+			return nil, from, fmt.Errorf("invalid node private key from %s: %w", from, err)
+		}
+		return key, from, nil
+
+	case s.envVar != "":
+		from := "environment variable " + s.envVar
+		val, ok := os.LookupEnv(s.envVar)
+		if !ok || val == "" {
+			return nil, from, fmt.Errorf("node private key %s is not set", from)
+		}
+		key, err := decodeNodeKey([]byte(val))
+		if err != nil {
+			return nil, from, fmt.Errorf("invalid node private key from %s: %w", from, err)
+		}
+		return key, from, nil
+
+	default:
+		const from = "config"
+		if len(cfgKey) == 0 {
+			return nil, from, errors.New("no node private key in the config")
+		}
+		key, err := node.UnmarshalNodeKey(cfgKey)
+		if err != nil {
+			return nil, from, fmt.Errorf("invalid node private key from %s: %w", from, err)
+		}
+		return key, from, nil
+	}
+}
+
+// errKeyEncoding is returned for a node key that is neither hex nor base64. The
+// decoders' own errors are not used since they may include part of the key.
+var errKeyEncoding = errors.New("not hex or base64 encoded")
+
+// decodeNodeKey decodes a hex or base64 encoded node private key, ignoring
+// surrounding whitespace such as a trailing newline. Hex is tried first since
+// a hex string may also be valid base64.
+func decodeNodeKey(enc []byte) (crypto.PrivateKey, error) {
+	enc = bytes.TrimSpace(enc)
+	if len(enc) == 0 {
+		return nil, errors.New("empty")
+	}
+	data := make([]byte, hex.DecodedLen(len(enc)))
+	if _, err := hex.Decode(data, enc); err == nil {
+		if key, err := node.UnmarshalNodeKey(data); err == nil {
+			return key, nil
+		}
+	}
+	for _, b64 := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding} {
+		data = make([]byte, b64.DecodedLen(len(enc)))
+		n, err := b64.Decode(data, enc)
+		if err != nil {
+			continue
+		}
+		return node.UnmarshalNodeKey(data[:n])
+	}
+	return nil, errKeyEncoding
+}
@@ -0,0 +1,99 @@
+package node
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/core/crypto"
+)
+
+func TestNodeKeySource(t *testing.T) {
+	cfgKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+	envKey, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	stdinKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+
+	const envVar = "KWIL_TEST_NODE_KEY"
+	t.Setenv(envVar, base64.StdEncoding.EncodeToString(envKey.Bytes()))
+	stdin := func() *strings.Reader {
+		return strings.NewReader(hex.EncodeToString(stdinKey.Bytes()) + "\n")
+	}
+
+	t.Run("config", func(t *testing.T) {
+		key, from, err := nodeKeySource{}.load(cfgKey.Bytes())
+		require.NoError(t, err)
+		assert.True(t, key.Equals(cfgKey))
+		assert.Equal(t, "config", from)
+	})
+
+	t.Run("env", func(t *testing.T) {
+		key, from, err := nodeKeySource{envVar: envVar}.load(cfgKey.Bytes())
+		require.NoError(t, err)
+		assert.True(t, key.Equals(envKey))
+		assert.Equal(t, "environment variable "+envVar, from)
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		key, from, err := nodeKeySource{stdin: stdin(), envVar: envVar}.load(cfgKey.Bytes())
+		require.NoError(t, err)
+		assert.True(t, key.Equals(stdinKey))
+		assert.Equal(t, "stdin", from)
+	})
+
+	t.Run("env not set", func(t *testing.T) {
+		_, _, err := nodeKeySource{envVar: "KWIL_TEST_NO_SUCH_KEY"}.load(cfgKey.Bytes())
+		assert.ErrorContains(t, err, "not set")
+	})
+
+	t.Run("no config key", func(t *testing.T) {
+		_, _, err := nodeKeySource{}.load(nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid encoding", func(t *testing.T) {
+		secret := "not-a-key!" + strings.Repeat("z", 30)
+		_, _, err := nodeKeySource{stdin: strings.NewReader(secret)}.load(nil)
+		require.ErrorIs(t, err, errKeyEncoding)
+		assert.NotContains(t, err.Error(), secret[:4])
+	})
+
+	t.Run("invalid length", func(t *testing.T) {
+		_, _, err := nodeKeySource{stdin: strings.NewReader("abcd")}.load(nil)
+		assert.ErrorContains(t, err, "length")
+	})
+
+	t.Run("too long", func(t *testing.T) {
+		long := strings.Repeat("a", maxNodeKeyInput+1)
+		_, _, err := nodeKeySource{stdin: strings.NewReader(long)}.load(nil)
+		assert.ErrorContains(t, err, "too long")
+	})
+}
+
+func TestDecodeNodeKey(t *testing.T) {
+	key, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+	raw := key.Bytes()
+
+	for name, enc := range map[string]string{
+		"hex":        hex.EncodeToString(raw),
+		"hex spaces": "  " + hex.EncodeToString(raw) + "\r\n",
+		"base64":     base64.StdEncoding.EncodeToString(raw),
+		"raw base64": base64.RawStdEncoding.EncodeToString(raw),
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := decodeNodeKey([]byte(enc))
+			require.NoError(t, err)
+			assert.True(t, got.Equals(key))
+		})
+	}
+
+	_, err = decodeNodeKey([]byte("  \n"))
+	assert.Error(t, err)
+}
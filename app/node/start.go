@@ -12,6 +12,8 @@ import (
 )
 
 func StartCmd() *cobra.Command {
+	var keyStdin bool
+	var keyEnv string
 	cmd := &cobra.Command{
 		Use:               "start",
 		Short:             "start the node (default command)",
@@ -40,7 +42,13 @@ func StartCmd() *cobra.Command {
 				return string(rawToml)
 			}))
 
-			return runNode(cmd.Context(), rootDir, cfg)
+			var keySrc nodeKeySource
+			if keyStdin {
+				keySrc.stdin = cmd.InOrStdin()
+			}
+			keySrc.envVar = keyEnv
+
+			return runNode(cmd.Context(), rootDir, cfg, keySrc)
 		},
 	}
 
@@ -49,6 +57,11 @@ func StartCmd() *cobra.Command {
 	defaultCfg := custom.DefaultConfig() // not config.DefaultConfig(), so custom command config is used
 	bind.SetFlagsFromStruct(cmd.Flags(), defaultCfg)
 
+	// The node private key may come from somewhere other than the config,
+	// such as a secret manager, in which case it is hex or base64 encoded.
+	cmd.Flags().BoolVar(&keyStdin, "privkey-stdin", false, "read the node private key from stdin, taking precedence over --privkey-env and privkey")
+	cmd.Flags().StringVar(&keyEnv, "privkey-env", "", "name of an environment variable with the node private key, taking precedence over privkey")
+
 	cmd.SetVersionTemplate(custom.BinaryConfig.NodeCmd + " {{printf \"version %s\" .Version}}\n")
 
 	return cmd
@@ -0,0 +1,61 @@
+package node
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kwilteam/kwil-db/app/custom"
+	"github.com/kwilteam/kwil-db/app/node/conf"
+	"github.com/kwilteam/kwil-db/app/shared/bind"
+	"github.com/kwilteam/kwil-db/config"
+
+	"github.com/spf13/cobra"
+)
+
+var validateConfigLong = `Check a node config file for errors without starting the node. Unknown keys, invalid values such as malformed durations, and missing required settings are reported with their line in the file. The command exits with an error if there are any problems.
+
+The file is merged over the default config, as when the node starts. If no file is given, the config file in the root directory is checked.`
+
+func ValidateConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate-config [file]",
+		Short: "Check a node config file for errors",
+		Long:  validateConfigLong,
+		Example: custom.BinaryConfig.NodeCmd + ` validate-config -r ~/.kwil2
+` + custom.BinaryConfig.NodeCmd + ` validate-config ./kwil.toml`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var path string
+			if len(args) == 1 {
+				path = args[0]
+			} else {
+				rootDir, err := bind.RootDir(cmd)
+				if err != nil {
+					return err // the parent command needs to set a persistent flag named "root"
+				}
+				path = filepath.Join(rootDir, config.ConfigFileName)
+			}
+
+			// not config.DefaultConfig(), so custom command config is used
+			problems, err := conf.ValidateConfigFile(path, custom.DefaultConfig())
+			if err != nil {
+				if line, col, ok := conf.IsSyntaxError(err); ok {
+					return fmt.Errorf("invalid TOML in %s at line %d, column %d: %w", path, line, col, err)
+				}
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			for _, problem := range problems {
+				fmt.Fprintln(out, problem)
+			}
+			if len(problems) > 0 {
+				return fmt.Errorf("config file %s has %d problem(s)", path, len(problems))
+			}
+			fmt.Fprintf(out, "Config file %s is valid\n", path)
+			return nil
+		},
+	}
+
+	return cmd
+}
@@ -55,6 +55,7 @@ func RootCmd() *cobra.Command {
 	// There is a virtual "node" command grouping, but no actual "node" command yet.
 	cmd.AddCommand(node.StartCmd())
 	cmd.AddCommand(node.PrintConfigCmd())
+	cmd.AddCommand(node.ValidateConfigCmd())
 	cmd.AddCommand(rpc.NewAdminCmd())
 	cmd.AddCommand(validator.NewValidatorsCmd())
 	cmd.AddCommand(setup.SetupCmd())
@@ -0,0 +1,182 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	types "github.com/kwilteam/kwil-db/core/types/admin"
+	"github.com/spf13/cobra"
+)
+
+var (
+	addrBookLong = `The address book commands export the node's known peers, and import them into another node.`
+
+	exportPeersLong = `Export the node's address book, including the addresses, supported protocols, and last seen time of each known peer. The address book is written to the given file, or printed if no file is given.`
+
+	exportPeersExample = `# Export the address book to a file
+kwild admin addrbook export peers.json --rpcserver /tmp/kwild.socket`
+
+	importPeersLong = `Import peers from a file created by the export command into the node's address book. Peers that are already known, and the node's own ID, are skipped.`
+
+	importPeersExample = `# Import the address book from a file
+kwild admin addrbook import peers.json --rpcserver /tmp/kwild.socket`
+
+	reloadAddrBookLong = `Reload the node's address book file. Use this after editing the address book file while the node is running, such as to add seed peers. Peers in the file that are not already known to the node are added.`
+
+	reloadAddrBookExample = `# Reload the node's address book file
+kwild admin addrbook reload --rpcserver /tmp/kwild.socket`
+)
+
+func addrBookCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "addrbook",
+		Short: "Export or import the node's address book.",
+		Long:  addrBookLong,
+	}
+
+	cmd.AddCommand(
+		exportPeersCmd(),
+		importPeersCmd(),
+		reloadAddrBookCmd(),
+	)
+
+	return cmd
+}
+
+func exportPeersCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "export [file]",
+		Short:   "Export the node's address book.",
+		Long:    exportPeersLong,
+		Example: exportPeersExample,
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			client, err := AdminSvcClient(ctx, cmd)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			peers, err := client.ExportPeers(ctx)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			if len(args) == 0 {
+				return display.PrintCmd(cmd, &knownPeersMsg{peers: peers})
+			}
+
+			if err = writePeersFile(args[0], peers); err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			return display.PrintCmd(cmd, display.RespString(fmt.Sprintf("Exported %d peers to %s", len(peers), args[0])))
+		},
+	}
+
+	BindRPCFlags(cmd)
+
+	return cmd
+}
+
+func importPeersCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "import <file>",
+		Short:   "Import peers into the node's address book.",
+		Long:    importPeersLong,
+		Example: importPeersExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			client, err := AdminSvcClient(ctx, cmd)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			peers, err := readPeersFile(args[0])
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			added, err := client.ImportPeers(ctx, peers)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			return display.PrintCmd(cmd, display.RespString(fmt.Sprintf("Imported %d of %d peers", added, len(peers))))
+		},
+	}
+
+	BindRPCFlags(cmd)
+
+	return cmd
+}
+
+func reloadAddrBookCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "reload",
+		Short:   "Reload the node's address book file.",
+		Long:    reloadAddrBookLong,
+		Example: reloadAddrBookExample,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			client, err := AdminSvcClient(ctx, cmd)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			added, err := client.ReloadAddrBook(ctx)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			return display.PrintCmd(cmd, display.RespString(fmt.Sprintf("Added %d peers from the address book", added)))
+		},
+	}
+
+	BindRPCFlags(cmd)
+
+	return cmd
+}
+
+// writePeersFile writes the peers to a file in the address book format.
+func writePeersFile(path string, peers []*types.KnownPeer) error {
+	bts, err := json.MarshalIndent(peers, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, bts, 0644)
+}
+
+// readPeersFile reads peers from a file in the address book format.
+func readPeersFile(path string) ([]*types.KnownPeer, error) {
+	bts, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var peers []*types.KnownPeer
+	if err = json.Unmarshal(bts, &peers); err != nil {
+		return nil, fmt.Errorf("invalid address book file %s: %w", path, err)
+	}
+	return peers, nil
+}
+
+// knownPeersMsg is a wrapper around the []*types.KnownPeer type that
+// implements the MsgFormatter interface.
+type knownPeersMsg struct {
+	peers []*types.KnownPeer
+}
+
+var _ display.MsgFormatter = (*knownPeersMsg)(nil)
+
+func (p *knownPeersMsg) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.peers)
+}
+
+func (p *knownPeersMsg) MarshalText() ([]byte, error) {
+	return json.MarshalIndent(p.peers, "", "  ")
+}
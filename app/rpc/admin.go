@@ -2,12 +2,15 @@ package rpc
 
 import (
 	"context"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/kwilteam/kwil-db/app/shared/bind"
+	"github.com/kwilteam/kwil-db/core/crypto"
 	adminclient "github.com/kwilteam/kwil-db/node/admin"
 	"github.com/spf13/cobra"
 )
@@ -27,6 +30,7 @@ func BindRPCFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().String("pass", "", "admin server password (alternative to mTLS with tlskey/tlscert). May be set in ~/.kwil-admin/rpc-admin-pass instead.")
 	cmd.PersistentFlags().String("tlskey", "auth.key", "kwil-admin's TLS key file to establish a mTLS (authenticated) connection")
 	cmd.PersistentFlags().String("tlscert", "auth.cert", "kwil-admin's TLS certificate file for server to authenticate us")
+	cmd.PersistentFlags().String("signkey", "", "file with a hex-encoded ed25519 or secp256k1 private key to sign requests, if the server requires signed requests")
 }
 
 // GetRPCServerFlag returns the RPC flag from the given command.
@@ -62,9 +66,33 @@ func AdminSvcClient(ctx context.Context, cmd *cobra.Command) (*adminclient.Admin
 		adminOpts = append(adminOpts, adminclient.WithPass(pass))
 	}
 
+	if keyFile, err := cmd.Flags().GetString("signkey"); err != nil {
+		return nil, err
+	} else if keyFile != "" {
+		signer, err := loadSignKey(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		adminOpts = append(adminOpts, adminclient.WithSigner(signer))
+	}
+
 	return adminclient.NewClient(ctx, rpcServer, adminOpts...)
 }
 
+// loadSignKey loads a private key with which to sign admin requests. The key
+// type is determined by the key length, as with a node key.
+func loadSignKey(keyFile string) (crypto.PrivateKey, error) {
+	keyHex, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	keyBts, err := hex.DecodeString(strings.TrimSpace(string(keyHex)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid key file %s: %w", keyFile, err)
+	}
+	return crypto.ParsePrivateKey(keyBts)
+}
+
 // getTLSFlags returns the TLS flags from the given command.
 func getTLSFlags(cmd *cobra.Command) (kwildTLSCertFile, clientTLSKeyFile, clientTLSCertFile string, err error) {
 	kwildTLSCertFile, err = cmd.Flags().GetString("authrpc-cert")
@@ -20,6 +20,9 @@ func NewAdminCmd() *cobra.Command {
 		versionCmd(),
 		statusCmd(),
 		peersCmd(),
+		addrBookCmd(),
+		shutdownCmd(),
+		resetCmd(),
 		genAuthKeyCmd(),
 	)
 
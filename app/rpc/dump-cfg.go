@@ -13,10 +13,14 @@ import (
 var (
 	dumpCfgLong    = `Gets the current config from the node.`
 	dumpCfgExample = `# Get the current config from the node.
-kwil-admin node dump-config --rpcserver /tmp/kwild.socket`
+kwil-admin node dump-config --rpcserver /tmp/kwild.socket
+
+# Get the config as canonical JSON to diff it against another node's.
+kwil-admin node dump-config --canonical --rpcserver /tmp/kwild.socket`
 )
 
 func dumpCfgCmd() *cobra.Command {
+	var canonical bool
 	cmd := &cobra.Command{
 		Use:     "dump-config",
 		Short:   "Gets the current config from the node.",
@@ -30,6 +34,14 @@ func dumpCfgCmd() *cobra.Command {
 				return display.PrintErr(cmd, err)
 			}
 
+			if canonical {
+				bts, err := client.GetConfigJSON(ctx)
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+				return display.PrintCmd(cmd, &cfgJSONMsg{json: bts})
+			}
+
 			bts, err := client.GetConfig(ctx)
 			if err != nil {
 				return display.PrintErr(cmd, err)
@@ -46,6 +58,7 @@ func dumpCfgCmd() *cobra.Command {
 	}
 
 	BindRPCFlags(cmd)
+	cmd.Flags().BoolVar(&canonical, "canonical", false, "print the config as canonical JSON with sorted keys, suitable for diffing")
 
 	return cmd
 }
@@ -70,3 +83,25 @@ func (c *cfgMsg) MarshalJSON() ([]byte, error) {
 func (c *cfgMsg) MarshalText() ([]byte, error) {
 	return c.cfg.ToTOML()
 }
+
+// cfgJSONMsg is the canonical JSON config. The text output is the document
+// exactly as returned by the node so that it may be diffed between nodes.
+type cfgJSONMsg struct {
+	json []byte
+}
+
+var _ display.MsgFormatter = (*cfgJSONMsg)(nil)
+
+func (c *cfgJSONMsg) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		OK     bool            `json:"ok"`
+		Config json.RawMessage `json:"config"`
+	}{
+		OK:     true,
+		Config: c.json,
+	})
+}
+
+func (c *cfgJSONMsg) MarshalText() ([]byte, error) {
+	return c.json, nil
+}
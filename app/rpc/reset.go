@@ -0,0 +1,64 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/spf13/cobra"
+)
+
+var (
+	resetLong = `Reset the node to the given height, which must not be above its best block. The node rejects new transactions during the reset.
+
+At the best block, the node aborts any block that it is executing and returns its state to the last committed block. This is used to recover a node that is stuck executing a block. If the node is the leader, the validators are also told to reset, and the leader proposes a new block.
+
+Below the best block, the node stops and removes the blocks above the height from its block store. This is used to recover from a bad block. The node cannot roll back its application state, so that must be restored to the height, such as from a snapshot, before the node is restarted. It then syncs the removed blocks again.
+
+The ` + "`--confirm`" + ` flag is required.`
+
+	resetExample = `# Abort the block after height 1200, the best block
+kwild admin reset 1200 --confirm --rpcserver /tmp/kwild.socket
+
+# Stop the node and remove the blocks above height 1100
+kwild admin reset 1100 --confirm --rpcserver /tmp/kwild.socket`
+)
+
+func resetCmd() *cobra.Command {
+	var confirm bool
+	cmd := &cobra.Command{
+		Use:     "reset <height>",
+		Short:   "Reset the node to a height, aborting the block in progress or removing later blocks.",
+		Long:    resetLong,
+		Example: resetExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			height, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				return display.PrintErr(cmd, fmt.Errorf("invalid height: %w", err))
+			}
+			if !confirm {
+				return display.PrintErr(cmd, errors.New("the --confirm flag is required to reset the node"))
+			}
+
+			ctx := context.Background()
+			client, err := AdminSvcClient(ctx, cmd)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			if err = client.ResetToHeight(ctx, height, confirm); err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			return display.PrintCmd(cmd, display.RespString(fmt.Sprintf("Node reset to height %d", height)))
+		},
+	}
+
+	cmd.Flags().BoolVar(&confirm, "confirm", false, "confirm the reset of the node's state")
+	BindRPCFlags(cmd)
+
+	return cmd
+}
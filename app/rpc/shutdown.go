@@ -0,0 +1,42 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/spf13/cobra"
+)
+
+var (
+	shutdownLong = `Gracefully shut down the node. The node stops accepting new transactions, waits for any block that is being committed, saves its address book, and then stops. The command returns once the node has begun shutting down.`
+
+	shutdownExample = `# Shut down the node
+kwild admin shutdown --rpcserver /tmp/kwild.socket`
+)
+
+func shutdownCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "shutdown",
+		Short:   "Gracefully shut down the node.",
+		Long:    shutdownLong,
+		Example: shutdownExample,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := context.Background()
+			client, err := AdminSvcClient(ctx, cmd)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			if err = client.Shutdown(ctx); err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			return display.PrintCmd(cmd, display.RespString("Node is shutting down"))
+		},
+	}
+
+	BindRPCFlags(cmd)
+
+	return cmd
+}
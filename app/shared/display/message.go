@@ -4,7 +4,9 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"strings"
 
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
 	"github.com/kwilteam/kwil-db/core/types"
 )
 
@@ -44,7 +46,7 @@ Log: %s`, h.Hash.Hex(),
 		heightStatus(h.QueryResp.Msg),
 		h.QueryResp.Msg.Height,
 		h.QueryResp.Msg.Result.Log,
-	),
+	) + formatEvents(h.QueryResp.Msg.Result.Events),
 	), nil
 }
 
@@ -72,6 +74,42 @@ func (h RespTxHash) MarshalText() ([]byte, error) {
 	return []byte("TxHash: " + h.Hex()), nil
 }
 
+// RespTxEstimate is used to represent the estimated cost of a transaction that
+// was built and signed, but not broadcast. It implements the MsgFormatter
+// interface.
+type RespTxEstimate clientType.TxEstimate
+
+func (e *RespTxEstimate) MarshalJSON() ([]byte, error) {
+	fee := "0"
+	if e.Fee != nil {
+		fee = e.Fee.String()
+	}
+	return json.Marshal(struct {
+		TxHash    string `json:"tx_hash"`
+		Fee       string `json:"fee"`
+		Nonce     uint64 `json:"nonce"`
+		Broadcast bool   `json:"broadcast"`
+	}{
+		TxHash:    hex.EncodeToString(e.TxHash[:]),
+		Fee:       fee,
+		Nonce:     e.Nonce,
+		Broadcast: false,
+	})
+}
+
+func (e *RespTxEstimate) MarshalText() ([]byte, error) {
+	fee := "0"
+	if e.Fee != nil {
+		fee = e.Fee.String()
+	}
+	return []byte(fmt.Sprintf(`Estimated fee: %s
+Nonce: %d
+TxHash: %s
+WARNING: dry run, the transaction was NOT sent`, fee, e.Nonce, hex.EncodeToString(e.TxHash[:]))), nil
+}
+
+var _ MsgFormatter = (*RespTxEstimate)(nil)
+
 // RespString is used to represent a string in cli
 // It implements the MsgFormatter interface
 type RespString string
@@ -131,6 +169,23 @@ func heightStatus(res *types.TxQueryResponse) string {
 	return status
 }
 
+// formatEvents renders transaction events with one line per event, listing
+// the event type followed by its attributes as key=value pairs.
+func formatEvents(events []types.Event) string {
+	if len(events) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\nEvents:")
+	for _, evt := range events {
+		sb.WriteString("\n\t" + evt.Type)
+		for _, attr := range evt.Attributes {
+			fmt.Fprintf(&sb, " %s=%q", attr.Key, attr.Value)
+		}
+	}
+	return sb.String()
+}
+
 func (r *RespTxQuery) MarshalText() ([]byte, error) {
 	msg := fmt.Sprintf(`Transaction ID: %s
 Status: %s
@@ -141,6 +196,7 @@ Log: %s`,
 		r.Msg.Height,
 		r.Msg.Result.Log,
 	)
+	msg += formatEvents(r.Msg.Result.Events)
 
 	// Always try to serialize to verify hash, but only show raw if requested.
 	if r.Msg.Tx == nil {
@@ -131,6 +131,27 @@ func getExampleTxQueryResponse() *types.TxQueryResponse {
 	}
 }
 
+func Test_RespTxEstimate(t *testing.T) {
+	resp := &RespTxEstimate{
+		TxHash: types.Hash{1, 2, 3, 4},
+		Fee:    big.NewInt(4200),
+		Nonce:  7,
+	}
+	expectJSON := `{"tx_hash":"0102030400000000000000000000000000000000000000000000000000000000","fee":"4200","nonce":7,"broadcast":false}`
+	expectText := `Estimated fee: 4200
+Nonce: 7
+TxHash: 0102030400000000000000000000000000000000000000000000000000000000
+WARNING: dry run, the transaction was NOT sent`
+
+	outText, err := resp.MarshalText()
+	assert.NoError(t, err, "MarshalText should not return error")
+	assert.Equal(t, expectText, string(outText), "MarshalText should return expected text")
+
+	outJSON, err := resp.MarshalJSON()
+	assert.NoError(t, err, "MarshalJSON should not return error")
+	assert.Equal(t, expectJSON, string(outJSON), "MarshalJSON should return expected json")
+}
+
 func Example_respTxQuery_text() {
 	Print(&RespTxQuery{Msg: getExampleTxQueryResponse(), WithRaw: true}, nil, "text")
 	// Transaction ID: 31303234
@@ -233,3 +254,39 @@ func Test_TxHashAndExecResponse(t *testing.T) {
 	assert.NoError(t, err, "MarshalJSON should not return error")
 	assert.Equal(t, expectJSON, string(outJSON), "MarshalJSON should return expected json")
 }
+
+func Test_RespTxQuery_Events(t *testing.T) {
+	qr := getExampleTxQueryResponse()
+	qr.Tx = nil
+	qr.Result.Events = []types.Event{
+		{Type: "transfer", Attributes: []types.EventAttribute{
+			{Key: "from", Value: "alice"},
+			{Key: "amount", Value: "10"},
+		}},
+		{Type: "user_created", Attributes: []types.EventAttribute{
+			{Key: "name", Value: "foo bar"},
+		}},
+	}
+	resp := &RespTxQuery{Msg: qr}
+
+	expectJSON := `{"hash":"0102030400000000000000000000000000000000000000000000000000000000","height":10,"tx":null,"result":{"code":0,"gas":10,"log":"This is log","events":[{"type":"transfer","attributes":[{"key":"from","value":"alice"},{"key":"amount","value":"10"}]},{"type":"user_created","attributes":[{"key":"name","value":"foo bar"}]}]}}`
+	expectText := `Transaction ID: 0102030400000000000000000000000000000000000000000000000000000000
+Status: success
+Height: 10
+Log: This is log
+Events:
+	transfer from="alice" amount="10"
+	user_created name="foo bar"`
+
+	outText, err := resp.MarshalText()
+	assert.NoError(t, err, "MarshalText should not return error")
+	assert.Equal(t, expectText, string(outText), "MarshalText should return expected text")
+
+	outJSON, err := resp.MarshalJSON()
+	assert.NoError(t, err, "MarshalJSON should not return error")
+	assert.Equal(t, expectJSON, string(outJSON), "MarshalJSON should return expected json")
+
+	execText, err := NewTxHashAndExecResponse(qr).MarshalText()
+	assert.NoError(t, err, "MarshalText should not return error")
+	assert.Contains(t, string(execText), "Events:\n\ttransfer from=\"alice\" amount=\"10\"\n\tuser_created name=\"foo bar\"")
+}
@@ -6,7 +6,7 @@ import (
 	"github.com/kwilteam/kwil-db/app/rpc"
 )
 
-const validatorsLong = "The validators command provides functions for creating and broadcasting validator-related transactions (join/approve/leave), and retrieving information on the current validators and join requests."
+const validatorsLong = "The validators command provides functions for creating and broadcasting validator-related transactions (join/approve/leave/rotate-key), and retrieving information on the current validators and join requests."
 
 func NewValidatorsCmd() *cobra.Command {
 	validatorsCmd := &cobra.Command{
@@ -22,6 +22,7 @@ func NewValidatorsCmd() *cobra.Command {
 		approveCmd(),
 		removeCmd(),
 		leaveCmd(),
+		rotateKeyCmd(),
 		listJoinRequestsCmd(),
 	)
 
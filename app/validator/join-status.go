@@ -18,15 +18,17 @@ import (
 )
 
 var (
-	joinStatusLong    = `Query the status of a pending validator join request.`
-	joinStatusExample = `# Query the status of a pending validator join request, by hex public key
+	joinStatusLong = `Query the status of a validator join request. If there is no pending
+request, the outcome of the most recent request (approved, rejected, or
+expired) is shown.`
+	joinStatusExample = `# Query the status of a validator join request, by hex public key
 kwil-admin validators join-status 6ecaca8e9394c939a858c2c7b47acb1db26a96d7ab38bd702fa3820c5034e9d0`
 )
 
 func joinStatusCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "join-status <joiner>",
-		Short:   "Query the status of a pending validator join request.",
+		Short:   "Query the status of a validator join request.",
 		Long:    joinStatusLong,
 		Example: joinStatusExample,
 		Args:    cobra.ExactArgs(1),
@@ -46,7 +48,7 @@ func joinStatusCmd() *cobra.Command {
 			data, err := clt.JoinStatus(ctx, pubkeyBts)
 			if err != nil {
 				if errors.Is(err, client.ErrNotFound) {
-					return display.PrintErr(cmd, errors.New("no active join request for that validator"))
+					return display.PrintErr(cmd, errors.New("no join request for that validator"))
 				}
 				return display.PrintErr(cmd, err)
 			}
@@ -67,6 +69,7 @@ type respValJoinStatus struct {
 type respValJoinRequest struct {
 	Candidate string   `json:"candidate"`
 	Power     int64    `json:"power"`
+	Status    string   `json:"status"`
 	Board     []string `json:"board"`
 	Approved  []bool   `json:"approved"`
 }
@@ -75,6 +78,7 @@ func (r *respValJoinStatus) MarshalJSON() ([]byte, error) {
 	joinReq := &respValJoinRequest{
 		Candidate: fmt.Sprintf("%x", r.Data.Candidate),
 		Power:     r.Data.Power,
+		Status:    string(r.Data.Status),
 		Board:     make([]string, len(r.Data.Board)),
 		Approved:  r.Data.Approved,
 	}
@@ -86,6 +90,17 @@ func (r *respValJoinStatus) MarshalJSON() ([]byte, error) {
 }
 
 func (r *respValJoinStatus) MarshalText() ([]byte, error) {
+	var msg bytes.Buffer
+	msg.WriteString(fmt.Sprintf("Candidate: %x\n", r.Data.Candidate))
+	msg.WriteString(fmt.Sprintf("Requested Power: %d\n", r.Data.Power))
+	msg.WriteString(fmt.Sprintf("Expiration Height: %d\n", r.Data.ExpiresAt))
+	if r.Data.Status != "" { // older nodes only report pending requests
+		msg.WriteString(fmt.Sprintf("Status: %s\n", r.Data.Status))
+	}
+	if r.Data.Status != "" && r.Data.Status != types.JoinRequestPending {
+		return msg.Bytes(), nil // no approvals for resolved requests
+	}
+
 	approved := 0
 	for _, a := range r.Data.Approved {
 		if a {
@@ -95,11 +110,6 @@ func (r *respValJoinStatus) MarshalText() ([]byte, error) {
 
 	needed := int(math.Ceil(float64(len(r.Data.Board)) * 2 / 3))
 
-	var msg bytes.Buffer
-	msg.WriteString(fmt.Sprintf("Candidate: %x\n", r.Data.Candidate))
-	msg.WriteString(fmt.Sprintf("Requested Power: %d\n", r.Data.Power))
-	msg.WriteString(fmt.Sprintf("Expiration Height: %d\n", r.Data.ExpiresAt))
-
 	msg.WriteString(fmt.Sprintf("%d Approvals Received (%d needed):\n", approved, needed))
 
 	for i := range r.Data.Board {
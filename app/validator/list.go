@@ -2,9 +2,11 @@ package validator
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 
 	"github.com/spf13/cobra"
 
@@ -56,14 +58,30 @@ type respValSets struct {
 type valInfo struct {
 	PubKey string `json:"pubkey"`
 	Power  int64  `json:"power"`
+	Role   string `json:"role"`
+}
+
+// sorted returns the validators ordered by power, descending, with ties
+// ordered by pubkey so that the output is stable.
+func (r *respValSets) sorted() []*types.Validator {
+	vals := slices.Clone(r.Data)
+	slices.SortStableFunc(vals, func(a, b *types.Validator) int {
+		if c := cmp.Compare(b.Power, a.Power); c != 0 {
+			return c
+		}
+		return bytes.Compare(a.PubKey, b.PubKey)
+	})
+	return vals
 }
 
 func (r *respValSets) MarshalJSON() ([]byte, error) {
-	valInfos := make([]valInfo, len(r.Data))
-	for i, v := range r.Data {
+	vals := r.sorted()
+	valInfos := make([]valInfo, len(vals))
+	for i, v := range vals {
 		valInfos[i] = valInfo{
 			PubKey: fmt.Sprintf("%x", v.PubKey),
 			Power:  v.Power,
+			Role:   v.Role,
 		}
 	}
 
@@ -71,14 +89,18 @@ func (r *respValSets) MarshalJSON() ([]byte, error) {
 }
 
 func (r *respValSets) MarshalText() ([]byte, error) {
+	vals := r.sorted()
+
 	var msg bytes.Buffer
 	msg.WriteString("Current validator set:\n")
-	for i, v := range r.Data {
-		msg.WriteString(fmt.Sprintf("% 3d. %s", i, v))
-		if i != len(r.Data)-1 {
-			msg.WriteString("\n")
-		}
+	msg.WriteString(" Role      |   Power | Public key\n")
+	msg.WriteString("-----------+---------+------------")
+	var total int64
+	for _, v := range vals {
+		msg.WriteString(fmt.Sprintf("\n %-9s | % 7d | %x", v.Role, v.Power, v.PubKey))
+		total += v.Power
 	}
+	msg.WriteString(fmt.Sprintf("\nTotal power: %d (%d validators)", total, len(vals)))
 
 	return msg.Bytes(), nil
 }
@@ -0,0 +1,40 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/kwilteam/kwil-db/core/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_respValSets(t *testing.T) {
+	resp := &respValSets{Data: []*types.Validator{
+		{Role: "validator", PubKey: []byte{0x02, 0x01}, Power: 1},
+		{Role: "leader", PubKey: []byte{0x01, 0x02}, Power: 10},
+		{Role: "validator", PubKey: []byte{0x01, 0x01}, Power: 10},
+	}}
+
+	expectText := `Current validator set:
+ Role      |   Power | Public key
+-----------+---------+------------
+ validator |      10 | 0101
+ leader    |      10 | 0102
+ validator |       1 | 0201
+Total power: 21 (3 validators)`
+	expectJSON := `[{"pubkey":"0101","power":10,"role":"validator"},` +
+		`{"pubkey":"0102","power":10,"role":"leader"},` +
+		`{"pubkey":"0201","power":1,"role":"validator"}]`
+
+	outText, err := resp.MarshalText()
+	assert.NoError(t, err)
+	assert.Equal(t, expectText, string(outText))
+
+	outJSON, err := resp.MarshalJSON()
+	assert.NoError(t, err)
+	assert.Equal(t, expectJSON, string(outJSON))
+
+	// the input order is unchanged
+	assert.Equal(t, "validator", resp.Data[0].Role)
+	assert.EqualValues(t, 1, resp.Data[0].Power)
+}
@@ -0,0 +1,84 @@
+package validator
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/rpc"
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+var (
+	rotateKeyLong = `A current validator may replace its key with a new one using the ` + "`rotate-key`" + ` command. The node generates the new key, saves it to a file in its root directory, and broadcasts a request to replace its validator key, which the other validators must approve.
+
+The node keeps using its current key until it is restarted with the new one. Once the key update is approved, set the node's private key to the contents of the key file and restart it. The leader's key cannot be rotated. The ` + "`--confirm`" + ` flag is required.
+
+The key file is never overwritten. If it already exists from an earlier rotation, the request fails, and the operator must move the old key file elsewhere before rotating the key again.`
+
+	rotateKeyExample = `# Request to replace the validator's key
+kwil-admin validators rotate-key --confirm`
+)
+
+func rotateKeyCmd() *cobra.Command {
+	var confirm bool
+	cmd := &cobra.Command{
+		Use:     "rotate-key",
+		Short:   "A current validator may replace its key with a new one using the `rotate-key` command.",
+		Long:    rotateKeyLong,
+		Example: rotateKeyExample,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !confirm {
+				return display.PrintErr(cmd, errors.New("the --confirm flag is required to rotate the validator key"))
+			}
+
+			ctx := context.Background()
+
+			clt, err := rpc.AdminSvcClient(ctx, cmd)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			txHash, newPubKey, keyFile, err := clt.RotateKey(ctx, confirm)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			return display.PrintCmd(cmd, &rotateKeyMsg{
+				TxHash:    txHash,
+				NewPubKey: newPubKey,
+				KeyFile:   keyFile,
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&confirm, "confirm", false, "confirm the rotation of the validator key")
+
+	return cmd
+}
+
+// rotateKeyMsg is the result of a key rotation request.
+type rotateKeyMsg struct {
+	TxHash    types.Hash     `json:"tx_hash"`
+	NewPubKey types.HexBytes `json:"new_pubkey"`
+	KeyFile   string         `json:"key_file"`
+}
+
+var _ display.MsgFormatter = (*rotateKeyMsg)(nil)
+
+func (r *rotateKeyMsg) MarshalJSON() ([]byte, error) {
+	type msg rotateKeyMsg // avoid recursion
+	return json.Marshal((*msg)(r))
+}
+
+func (r *rotateKeyMsg) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("TxHash: %s\nNew public key: %s\nKey file: %s\n"+
+		"Restart the node with the new key once the key update is approved.",
+		r.TxHash, hex.EncodeToString(r.NewPubKey), r.KeyFile)), nil
+}
@@ -49,6 +49,7 @@ func NewCmdAccount() *cobra.Command {
 		idCmd,
 		balanceCmd(),
 		trCmd,
+		signCmd(),
 	)
 
 	trCmd.Flags().Int64VarP(&nonceOverride, "nonce", "N", -1, "nonce override (-1 means request from server)")
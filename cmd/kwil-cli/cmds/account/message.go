@@ -31,6 +31,42 @@ Nonce: %d
 	return []byte(msg), nil
 }
 
+// respSig is a message signature, with the signer and message hash.
+type respSig struct {
+	Signature   []byte
+	AuthType    string
+	Identity    []byte
+	Identifier  string
+	MessageHash types.Hash
+}
+
+func (r *respSig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Signature   string     `json:"signature"`
+		AuthType    string     `json:"auth_type"`
+		Identity    string     `json:"identity"`
+		Identifier  string     `json:"identifier"`
+		MessageHash types.Hash `json:"message_hash"`
+	}{
+		Signature:   hex.EncodeToString(r.Signature),
+		AuthType:    r.AuthType,
+		Identity:    hex.EncodeToString(r.Identity),
+		Identifier:  r.Identifier,
+		MessageHash: r.MessageHash,
+	})
+}
+
+func (r *respSig) MarshalText() ([]byte, error) {
+	msg := fmt.Sprintf(`Signature: %x
+Auth type: %s
+Signer identity: %x
+Signer identifier: %s
+Message hash: %s
+`, r.Signature, r.AuthType, r.Identity, r.Identifier, r.MessageHash)
+
+	return []byte(msg), nil
+}
+
 /*xxx
 type respAccount struct {
 	// Identifier string `json:"identifier"`
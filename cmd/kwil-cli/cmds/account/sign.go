@@ -0,0 +1,121 @@
+package account
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/config"
+	"github.com/kwilteam/kwil-db/core/crypto"
+	"github.com/kwilteam/kwil-db/core/crypto/auth"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	signLong = `Signs a message with the configured private key.
+
+The message is given as an argument, or read from a file with the ` + "`--path`" + ` flag.
+The signature is created for the authentication type given with ` + "`--auth-type`" + `,
+which must be supported by the configured key. This may be used to debug
+authentication issues by checking the signature against another implementation.`
+
+	signExample = `# Sign a message with Ethereum personal sign
+kwil-cli account sign "hello kwil"
+
+# Sign the contents of a file with a plain secp256k1 signature
+kwil-cli account sign --path ./msg.txt --auth-type secp256k1`
+)
+
+func signCmd() *cobra.Command {
+	var filePath, authType string
+	cmd := &cobra.Command{
+		Use:     "sign [<message>]",
+		Short:   "Sign a message with the configured private key.",
+		Long:    signLong,
+		Example: signExample,
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var msg []byte
+			switch {
+			case filePath != "" && len(args) > 0:
+				return display.PrintErr(cmd, errors.New("provide either a message or a file path, not both"))
+			case filePath != "":
+				var err error
+				msg, err = os.ReadFile(filePath)
+				if err != nil {
+					return display.PrintErr(cmd, fmt.Errorf("failed to read message file: %w", err))
+				}
+			case len(args) > 0:
+				msg = []byte(args[0])
+			default:
+				return display.PrintErr(cmd, errors.New("no message provided"))
+			}
+
+			conf, err := config.ActiveConfig()
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+			if conf.PrivateKey == nil {
+				return display.PrintErr(cmd, errors.New("no private key configured"))
+			}
+
+			resp, err := signMessage(conf.PrivateKey, authType, msg)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+			return display.PrintCmd(cmd, resp)
+		},
+	}
+
+	cmd.Flags().StringVarP(&filePath, "path", "p", "", "path to a file containing the message to sign")
+	cmd.Flags().StringVar(&authType, "auth-type", auth.EthPersonalSignAuth, "authentication type of the signature (secp256k1_ep, secp256k1, or ed25519)")
+
+	return cmd
+}
+
+// signMessage signs a message with a key for the given authentication type.
+// The authentication type must have a registered authenticator, and must be
+// compatible with the key type.
+func signMessage(key crypto.PrivateKey, authType string, msg []byte) (*respSig, error) {
+	authenticator := auth.GetAuthenticator(authType)
+	if authenticator == nil {
+		return nil, fmt.Errorf("unknown auth type %q", authType)
+	}
+
+	var signer auth.Signer
+	switch key := key.(type) {
+	case *crypto.Secp256k1PrivateKey:
+		switch authType {
+		case auth.EthPersonalSignAuth:
+			signer = &auth.EthPersonalSigner{Key: *key}
+		case auth.Secp256k1Auth:
+			signer = &auth.Secp256k1Signer{Secp256k1PrivateKey: *key}
+		}
+	case *crypto.Ed25519PrivateKey:
+		if authType == auth.Ed25519Auth {
+			signer = &auth.Ed25519Signer{Ed25519PrivateKey: *key}
+		}
+	}
+	if signer == nil {
+		return nil, fmt.Errorf("auth type %q is not supported by the private key type", authType)
+	}
+
+	sig, err := signer.Sign(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign message: %w", err)
+	}
+	ident, err := authenticator.Identifier(signer.Identity())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signer identifier: %w", err)
+	}
+
+	return &respSig{
+		Signature:   sig.Data,
+		AuthType:    sig.Type,
+		Identity:    signer.Identity(),
+		Identifier:  ident,
+		MessageHash: types.HashBytes(msg),
+	}, nil
+}
@@ -0,0 +1,90 @@
+package account
+
+import (
+	"testing"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/core/crypto"
+	"github.com/kwilteam/kwil-db/core/crypto/auth"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignMessage(t *testing.T) {
+	secpKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+	edKey, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+
+	msg := []byte("hello kwil")
+
+	for _, tc := range []struct {
+		name     string
+		key      crypto.PrivateKey
+		authType string
+		wantErr  bool
+	}{
+		{"eth personal sign", secpKey, auth.EthPersonalSignAuth, false},
+		{"secp256k1", secpKey, auth.Secp256k1Auth, false},
+		{"ed25519", edKey, auth.Ed25519Auth, false},
+		{"ed25519 with secp256k1 key", secpKey, auth.Ed25519Auth, true},
+		{"secp256k1_ep with ed25519 key", edKey, auth.EthPersonalSignAuth, true},
+		{"unknown auth type", secpKey, "rsa", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := signMessage(tc.key, tc.authType, msg)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.authType, resp.AuthType)
+			require.Equal(t, types.HashBytes(msg), resp.MessageHash)
+
+			authenticator := auth.GetAuthenticator(resp.AuthType)
+			require.NoError(t, authenticator.Verify(resp.Identity, msg, resp.Signature))
+			require.Error(t, authenticator.Verify(resp.Identity, []byte("other"), resp.Signature))
+
+			ident, err := authenticator.Identifier(resp.Identity)
+			require.NoError(t, err)
+			require.Equal(t, ident, resp.Identifier)
+		})
+	}
+}
+
+func Example_respSig_text() {
+	display.Print(&respSig{
+		Signature:   []byte{0xaa, 0xbb, 0xcc},
+		AuthType:    auth.EthPersonalSignAuth,
+		Identity:    []byte{0x01, 0x02},
+		Identifier:  "0x0102",
+		MessageHash: types.Hash{0xff},
+	}, nil, "text")
+	// Output:
+	// Signature: aabbcc
+	// Auth type: secp256k1_ep
+	// Signer identity: 0102
+	// Signer identifier: 0x0102
+	// Message hash: ff00000000000000000000000000000000000000000000000000000000000000
+}
+
+func Example_respSig_json() {
+	display.Print(&respSig{
+		Signature:   []byte{0xaa, 0xbb, 0xcc},
+		AuthType:    auth.EthPersonalSignAuth,
+		Identity:    []byte{0x01, 0x02},
+		Identifier:  "0x0102",
+		MessageHash: types.Hash{0xff},
+	}, nil, "json")
+	// Output:
+	// {
+	//   "result": {
+	//     "signature": "aabbcc",
+	//     "auth_type": "secp256k1_ep",
+	//     "identity": "0102",
+	//     "identifier": "0x0102",
+	//     "message_hash": "ff00000000000000000000000000000000000000000000000000000000000000"
+	//   },
+	//   "error": ""
+	// }
+}
@@ -92,11 +92,14 @@ func batchCmd() *cobra.Command {
 					return display.PrintErr(cmd, fmt.Errorf("error creating action inputs: %w", err))
 				}
 
-				txHash, err := cl.Execute(ctx, dbid, strings.ToLower(action), tuples,
-					clientType.WithNonce(nonceOverride), clientType.WithSyncBroadcast(syncBcast))
+				opts, est := writeTxOpts()
+				txHash, err := cl.Execute(ctx, dbid, strings.ToLower(action), tuples, opts...)
 				if err != nil {
 					return display.PrintErr(cmd, fmt.Errorf("error executing action: %w", err))
 				}
+				if est != nil {
+					return display.PrintCmd(cmd, (*display.RespTxEstimate)(est))
+				}
 				// If sycnBcast, and we have a txHash (error or not), do a query-tx.
 				if len(txHash) != 0 && syncBcast {
 					time.Sleep(500 * time.Millisecond) // otherwise it says not found at first
@@ -2,6 +2,8 @@ package database
 
 import (
 	"github.com/spf13/cobra"
+
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
 )
 
 var (
@@ -14,6 +16,7 @@ var (
 
 	nonceOverride int64
 	syncBcast     bool
+	dryRun        bool
 )
 
 func NewCmdDatabase() *cobra.Command {
@@ -40,7 +43,20 @@ func NewCmdDatabase() *cobra.Command {
 	for _, cmd := range writeCmds {
 		cmd.Flags().Int64VarP(&nonceOverride, "nonce", "N", -1, "nonce override (-1 means request from server)")
 		cmd.Flags().BoolVar(&syncBcast, "sync", false, "synchronous broadcast (wait for it to be included in a block)")
+		cmd.Flags().BoolVar(&dryRun, "dry-run", false, "build and sign the transaction and print its estimated fee, but do not broadcast it")
 	}
 
 	return dbCmd
 }
+
+// writeTxOpts returns the options for the transaction of a write command. If
+// --dry-run was given, the returned estimate is set by the client instead of
+// broadcasting the transaction, otherwise it is nil.
+func writeTxOpts() ([]clientType.TxOpt, *clientType.TxEstimate) {
+	opts := []clientType.TxOpt{clientType.WithNonce(nonceOverride), clientType.WithSyncBroadcast(syncBcast)}
+	if !dryRun {
+		return opts, nil
+	}
+	est := new(clientType.TxEstimate)
+	return append(opts, clientType.WithDryRun(est)), est
+}
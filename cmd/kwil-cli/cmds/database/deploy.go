@@ -76,11 +76,14 @@ func deployCmd() *cobra.Command {
 					db.Name = overrideName
 				}
 
-				txHash, err := cl.DeployDatabase(ctx, db, clientType.WithNonce(nonceOverride),
-					clientType.WithSyncBroadcast(syncBcast))
+				opts, est := writeTxOpts()
+				txHash, err := cl.DeployDatabase(ctx, db, opts...)
 				if err != nil {
 					return display.PrintErr(cmd, fmt.Errorf("failed to deploy database: %w", err))
 				}
+				if est != nil {
+					return display.PrintCmd(cmd, (*display.RespTxEstimate)(est))
+				}
 				// If sycnBcast, and we have a txHash (error or not), do a query-tx.
 				if len(txHash) != 0 && syncBcast {
 					time.Sleep(500 * time.Millisecond) // otherwise it says not found at first
@@ -35,11 +35,14 @@ func dropCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return client.DialClient(cmd.Context(), cmd, 0, func(ctx context.Context, cl clientType.Client, conf *config.KwilCliConfig) error {
 				var err error
-				txHash, err := cl.DropDatabase(ctx, args[0], clientType.WithNonce(nonceOverride),
-					clientType.WithSyncBroadcast(syncBcast))
+				opts, est := writeTxOpts()
+				txHash, err := cl.DropDatabase(ctx, args[0], opts...)
 				if err != nil {
 					return display.PrintErr(cmd, fmt.Errorf("error dropping database: %w", err))
 				}
+				if est != nil {
+					return display.PrintCmd(cmd, (*display.RespTxEstimate)(est))
+				}
 				// If sycnBcast, and we have a txHash (error or not), do a query-tx.
 				if len(txHash) != 0 && syncBcast {
 					time.Sleep(500 * time.Millisecond) // otherwise it says not found at first
@@ -64,11 +64,14 @@ func executeCmd() *cobra.Command {
 
 				// Could actually just directly pass nonce to the client method,
 				// but those methods don't need tx details in the inputs.
-				txHash, err := cl.Execute(ctx, dbid, action, inputs,
-					clientType.WithNonce(nonceOverride), clientType.WithSyncBroadcast(syncBcast))
+				opts, est := writeTxOpts()
+				txHash, err := cl.Execute(ctx, dbid, action, inputs, opts...)
 				if err != nil {
 					return display.PrintErr(cmd, fmt.Errorf("error executing database: %w", err))
 				}
+				if est != nil {
+					return display.PrintCmd(cmd, (*display.RespTxEstimate)(est))
+				}
 				// If sycnBcast, and we have a txHash (error or not), do a query-tx.
 				if len(txHash) != 0 && syncBcast {
 					time.Sleep(500 * time.Millisecond) // otherwise it says not found at first
@@ -188,4 +188,9 @@ type NetworkParameters struct {
 	// MaxVotesPerTx is the maximum number of votes that can be included in a
 	// single transaction.
 	MaxVotesPerTx int64
+
+	// MinFee is the minimum fee that a transaction must offer to be accepted
+	// into mempool. It is ignored when gas costs are disabled. A nil MinFee is
+	// no minimum.
+	MinFee *big.Int
 }
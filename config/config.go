@@ -2,6 +2,7 @@ package config
 
 import (
 	"encoding/json"
+	"math/big"
 	"os"
 	"time"
 
@@ -55,6 +56,9 @@ type GenesisConfig struct {
 	// MaxVotesPerTx is the maximum number of votes that can be included in a
 	// single transaction.
 	MaxVotesPerTx int64 `json:"max_votes_per_tx"`
+	// MinFee is the minimum fee that a transaction must offer to be accepted
+	// into mempool when gas costs are enabled.
+	MinFee *big.Int `json:"min_fee,omitempty"`
 	// StateHash is the hash of the initial state of the chain, used when bootstrapping
 	// the chain with a network snapshot.
 	StateHash []byte `json:"state_hash"`
@@ -110,16 +114,37 @@ func DefaultConfig() *Config {
 		LogFormat: log.FormatUnstructured,
 		// Private key is empty by default.
 		P2P: PeerConfig{
-			IP:        "0.0.0.0",
-			Port:      6600,
-			Pex:       true,
-			BootNodes: []string{},
+			IP:               "0.0.0.0",
+			Port:             6600,
+			Pex:              true,
+			BootNodes:        []string{},
+			LowWatermark:     20,
+			HighWatermark:    40,
+			GracePeriod:      Duration(time.Minute),
+			PeerRetention:    Duration(7 * 24 * time.Hour),
+			EvictionInterval: Duration(10 * time.Minute),
+			RequestBurst:     20,
 		},
 		Consensus: ConsensusConfig{
 			ProposeTimeout: 1000 * time.Millisecond,
 			MaxBlockSize:   50_000_000,
 			MaxTxsPerBlock: 20_000,
 		},
+		Mempool: MempoolConfig{
+			MaxSize:        200_000_000,
+			MaxAccountSize: 20_000_000,
+			PriorityTypes: []string{
+				types.PayloadTypeValidatorJoin.String(),
+				types.PayloadTypeValidatorLeave.String(),
+				types.PayloadTypeValidatorRemove.String(),
+				types.PayloadTypeValidatorApprove.String(),
+				types.PayloadTypeValidatorVoteIDs.String(),
+				types.PayloadTypeCreateResolution.String(),
+				types.PayloadTypeApproveResolution.String(),
+				types.PayloadTypeDeleteResolution.String(),
+			},
+			AccountTurnTxs: 10,
+		},
 		DB: DBConfig{
 			Host:          "127.0.0.1",
 			Port:          "5432",
@@ -138,17 +163,20 @@ func DefaultConfig() *Config {
 			ChallengeRateLimit: 10,
 		},
 		Admin: AdminConfig{
-			Enable:        true,
-			ListenAddress: "/tmp/kwil2-admin.socket",
-			Pass:          "",
-			NoTLS:         false,
-			TLSCertFile:   "admin.cert",
-			TLSKeyFile:    "admin.key",
+			Enable:         true,
+			ListenAddress:  "/tmp/kwil2-admin.socket",
+			Pass:           "",
+			NoTLS:          false,
+			TLSCertFile:    "admin.cert",
+			TLSKeyFile:     "admin.key",
+			AllowedSigners: []string{},
+			Timeout:        30 * time.Second,
 		},
 		Snapshots: SnapshotConfig{
 			Enable:          false,
 			RecurringHeight: 14400,
 			MaxSnapshots:    3,
+			ChunkSize:       16e6 - 4096,
 		},
 		StateSync: StateSyncConfig{
 			Enable:           false,
@@ -185,6 +213,7 @@ type Config struct {
 	P2P PeerConfig `koanf:"p2p" toml:"p2p"`
 
 	Consensus ConsensusConfig `koanf:"consensus" toml:"consensus"`
+	Mempool   MempoolConfig   `koanf:"mempool" toml:"mempool"`
 	DB        DBConfig        `koanf:"db" toml:"db"`
 	RPC       RPCConfig       `koanf:"rpc" toml:"rpc"`
 	Admin     AdminConfig     `koanf:"admin" toml:"admin"`
@@ -194,10 +223,20 @@ type Config struct {
 
 // PeerConfig corresponds to the [peer] section of the config.
 type PeerConfig struct {
-	IP        string   `koanf:"ip" toml:"ip" comment:"ip to listen on for P2P connections"`
-	Port      uint64   `koanf:"port" toml:"port" comment:"port to listen on for P2P connections"`
-	Pex       bool     `koanf:"pex" toml:"pex" comment:"enable peer exchange"`
-	BootNodes []string `koanf:"bootnodes" toml:"bootnodes" comment:"bootnodes to connect to on startup"`
+	IP               string   `koanf:"ip" toml:"ip" comment:"ip to listen on for P2P connections"`
+	Port             uint64   `koanf:"port" toml:"port" comment:"port to listen on for P2P connections"`
+	Pex              bool     `koanf:"pex" toml:"pex" comment:"enable peer exchange"`
+	BootNodes        []string `koanf:"bootnodes" toml:"bootnodes" comment:"bootnodes to connect to on startup"`
+	ListenAddrs      []string `koanf:"listen_addrs" toml:"listen_addrs" comment:"multiaddrs to listen on for P2P connections, such as /ip6/::/tcp/6600 or /ip4/0.0.0.0/tcp/6601/ws, used instead of ip and port if set"`
+	Websocket        bool     `koanf:"websocket" toml:"websocket" comment:"enable the websocket transport, required for /ws listen addresses and peers"`
+	LowWatermark     int      `koanf:"low_watermark" toml:"low_watermark" comment:"number of connections to maintain, and to trim down to when above the high watermark"`
+	HighWatermark    int      `koanf:"high_watermark" toml:"high_watermark" comment:"number of connections above which the least useful are trimmed, or 0 for no limit"`
+	GracePeriod      Duration `koanf:"grace_period" toml:"grace_period" comment:"how long a new connection is exempt from trimming"`
+	PSKFile          string   `koanf:"psk_file" toml:"psk_file" comment:"path to a pre-shared key file for a private network, which only nodes with the same key may join"`
+	PeerRetention    Duration `koanf:"peer_retention" toml:"peer_retention" comment:"how long a disconnected peer is kept in the address book before it is removed"`
+	EvictionInterval Duration `koanf:"peer_eviction_interval" toml:"peer_eviction_interval" comment:"how often disconnected peers older than peer_retention are removed from the address book"`
+	RequestRate      float64  `koanf:"request_rate" toml:"request_rate" comment:"sustained number of block and transaction requests per second served to each peer, or 0 for no limit"`
+	RequestBurst     int      `koanf:"request_burst" toml:"request_burst" comment:"number of requests that a peer may make at once when request_rate is set"`
 
 	// ListenAddr string // "127.0.0.1:6600"
 }
@@ -228,9 +267,24 @@ type ConsensusConfig struct {
 	ProposeTimeout time.Duration `koanf:"propose_timeout" toml:"propose_timeout" comment:"timeout for proposing a block"`
 	MaxBlockSize   uint64        `koanf:"max_block_size" toml:"max_block_size" comment:"max size of a block in bytes"`
 	MaxTxsPerBlock uint64        `koanf:"max_txs_per_block" toml:"max_txs_per_block" comment:"max number of transactions per block"`
+	Archive        bool          `koanf:"archive" toml:"archive" comment:"run as an archive node that stores and serves all blocks without taking part in consensus"`
 	// ? reannounce intervals?
 }
 
+type MempoolConfig struct {
+	MaxSize        int64 `koanf:"max_size" toml:"max_size" comment:"max total size of transactions in mempool in bytes, 0 for no limit"`
+	MaxAccountSize int64 `koanf:"max_account_size" toml:"max_account_size" comment:"max size of one account's transactions in mempool in bytes, 0 for no limit"`
+	// PriorityTypes are the payload types of transactions that are selected
+	// for blocks ahead of all others, such as validator and governance
+	// transactions.
+	PriorityTypes []string `koanf:"priority_types" toml:"priority_types" comment:"payload types of transactions in the mempool priority lane"`
+	Persist       bool     `koanf:"persist" toml:"persist" comment:"save unconfirmed transactions on shutdown and restore them on startup"`
+	// AccountTurnTxs is the number of an account's transactions selected for
+	// a block before the next account's turn, so that one account cannot fill
+	// a block while others wait.
+	AccountTurnTxs int `koanf:"account_turn_txs" toml:"account_turn_txs" comment:"max transactions from one account in each turn when accounts take turns filling a block, 0 to select in the order received"`
+}
+
 type RPCConfig struct {
 	ListenAddress      string        `koanf:"listen" toml:"listen"`
 	Timeout            time.Duration `koanf:"timeout" toml:"timeout"`
@@ -247,19 +301,36 @@ type AdminConfig struct {
 	NoTLS         bool   `koanf:"notls" toml:"notls"`
 	TLSCertFile   string `koanf:"cert" toml:"cert"`
 	TLSKeyFile    string `koanf:"key" toml:"key"`
+	// RequireSignature requires that admin requests be signed by the node's
+	// key or one of the AllowedSigners, which are hex-encoded ed25519 public
+	// keys.
+	RequireSignature bool     `koanf:"require_signature" toml:"require_signature"`
+	AllowedSigners   []string `koanf:"allowed_signers" toml:"allowed_signers"`
+	// OpenReads exempts read-only methods such as status and health from
+	// RequireSignature.
+	OpenReads bool `koanf:"open_reads" toml:"open_reads"`
+	// Metrics records Prometheus metrics for the admin RPC methods, which are
+	// served at the /metrics path of the admin service.
+	Metrics bool `koanf:"metrics" toml:"metrics"`
+	// Timeout is the time limit of each read-only admin RPC method call, such
+	// as status or peers. Methods that make changes are not limited. Zero
+	// disables the limit.
+	Timeout time.Duration `koanf:"timeout" toml:"timeout"`
 }
 
 type SnapshotConfig struct {
 	Enable          bool   `koanf:"enable" toml:"enable"`
 	RecurringHeight uint64 `koanf:"recurring_height" toml:"recurring_height"`
 	MaxSnapshots    uint64 `koanf:"max_snapshots" toml:"max_snapshots"`
+	ChunkSize       uint64 `koanf:"chunk_size" toml:"chunk_size"`
+	Format          uint32 `koanf:"format" toml:"format"`
 }
 
 type StateSyncConfig struct {
 	Enable           bool     `koanf:"enable" toml:"enable"`
 	TrustedProviders []string `koanf:"trusted_providers" toml:"trusted_providers"`
 
-	DiscoveryTimeout time.Duration `koanf:"discovery_timeout" toml:"discovery_time"`
+	DiscoveryTimeout time.Duration `koanf:"discovery_timeout" toml:"discovery_timeout"`
 	MaxRetries       uint64        `koanf:"max_retries" toml:"max_retries"`
 }
 
@@ -268,6 +339,28 @@ func (nc Config) ToTOML() ([]byte, error) {
 	return toml.Marshal(nc)
 }
 
+// ToCanonicalJSON encodes the config as JSON in a canonical form so that the
+// configs of different nodes may be compared byte-for-byte or with a line
+// diff. The keys are the same as in the TOML document, objects have their keys
+// sorted, and each field is on its own line. Arrays keep their order.
+func (nc Config) ToCanonicalJSON() ([]byte, error) {
+	bts, err := nc.ToTOML()
+	if err != nil {
+		return nil, err
+	}
+	// Going through a map uses the toml tags and the TOML encoding of the
+	// field types, and encoding/json sorts map keys.
+	var m map[string]any
+	if err = toml.Unmarshal(bts, &m); err != nil {
+		return nil, err
+	}
+	bts, err = json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(bts, '\n'), nil
+}
+
 func (nc *Config) FromTOML(b []byte) error {
 	return toml.Unmarshal(b, &nc)
 }
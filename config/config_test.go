@@ -249,3 +249,52 @@ func TestLoadConfigErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestToCanonicalJSON(t *testing.T) {
+	a, b := DefaultConfig(), DefaultConfig()
+
+	aJSON, err := a.ToCanonicalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bJSON, err := b.ToCanonicalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(aJSON) != string(bJSON) {
+		t.Fatalf("equal configs produced different JSON:\n%s\n%s", aJSON, bJSON)
+	}
+
+	// Encoding must be stable across calls.
+	again, err := a.ToCanonicalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(aJSON) != string(again) {
+		t.Fatal("repeated encoding produced different JSON")
+	}
+
+	// A single differing field should produce a single differing line.
+	b.P2P.Port = 6601
+	bJSON, err = b.ToCanonicalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	aLines := strings.Split(string(aJSON), "\n")
+	bLines := strings.Split(string(bJSON), "\n")
+	if len(aLines) != len(bLines) {
+		t.Fatalf("line count changed: %d != %d", len(aLines), len(bLines))
+	}
+	var diff []string
+	for i := range aLines {
+		if aLines[i] != bLines[i] {
+			diff = append(diff, bLines[i])
+		}
+	}
+	if len(diff) != 1 {
+		t.Fatalf("expected one differing line, got %d: %v", len(diff), diff)
+	}
+	if want := `"port": 6601`; !strings.Contains(diff[0], want) {
+		t.Errorf("differing line %q does not contain %q", diff[0], want)
+	}
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kwilteam/kwil-db/core/crypto"
+	rpcclient "github.com/kwilteam/kwil-db/core/rpc/client"
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+// blockPollWait is how long each block header request asks the node to wait
+// for the next block before it must be requested again.
+const blockPollWait = 5 * time.Second
+
+// BlockSubscription is a stream of committed block headers created by
+// SubscribeBlocks. Headers are sent on C in order of increasing height, each
+// height exactly once, with no gaps. C is closed when the subscription's
+// context is canceled or the node cannot be reached, after which Err reports
+// the reason.
+//
+// A subscription that ends does not resume on its own. To continue without
+// missing blocks, subscribe again from the height of the last header received.
+// That header will be delivered again, so delivery across subscriptions is
+// at-least-once.
+type BlockSubscription struct {
+	C <-chan *types.BlockHeader
+
+	err error
+}
+
+// Err returns the error that ended the subscription. It must only be called
+// after C is closed. If the subscription's context was canceled, the context's
+// error is returned.
+func (s *BlockSubscription) Err() error {
+	return s.err
+}
+
+// SubscribeBlocks streams the headers of committed blocks, beginning with the
+// block at fromHeight. If fromHeight is <= 0, the stream begins with the first
+// block committed after the subscription is created. The subscription long
+// polls the node for each height, so an idle chain does not generate a steady
+// stream of requests.
+//
+// The caller should receive from C promptly, since no new blocks are requested
+// while a header is waiting to be received.
+func (c *Client) SubscribeBlocks(ctx context.Context, fromHeight int64) (*BlockSubscription, error) {
+	if fromHeight <= 0 {
+		height, err := c.BestHeight(ctx)
+		if err != nil {
+			return nil, err
+		}
+		fromHeight = height + 1
+	}
+
+	ch := make(chan *types.BlockHeader)
+	sub := &BlockSubscription{C: ch}
+
+	go func() {
+		defer close(ch)
+		for height := fromHeight; ; {
+			_, hdr, err := c.txClient.BlockHeader(ctx, height, blockPollWait)
+			if err != nil {
+				if ctx.Err() != nil {
+					sub.err = ctx.Err()
+					return
+				}
+				if errors.Is(err, rpcclient.ErrNotFound) {
+					continue // not yet committed
+				}
+				sub.err = err
+				return
+			}
+
+			select {
+			case ch <- hdr:
+				height++
+			case <-ctx.Done():
+				sub.err = ctx.Err()
+				return
+			}
+		}
+	}()
+
+	return sub, nil
+}
+
+// GetBlock retrieves the block at the given height, and the app hash after it
+// was executed. If height is <= 0, the latest block is retrieved. The block
+// hash computed from the header must match the hash reported by the node. Use
+// VerifyBlock to also check the block's transactions and leader signature.
+func (c *Client) GetBlock(ctx context.Context, height int64) (*types.Block, types.Hash, error) {
+	hash, blk, appHash, err := c.txClient.Block(ctx, height)
+	if err != nil {
+		return nil, types.Hash{}, err
+	}
+	if blk.Header == nil {
+		return nil, types.Hash{}, errors.New("block has no header")
+	}
+	if height > 0 && blk.Header.Height != height {
+		return nil, types.Hash{}, fmt.Errorf("requested block %d, got block %d", height, blk.Header.Height)
+	}
+	if computed := blk.Hash(); computed != hash {
+		return nil, types.Hash{}, fmt.Errorf("block hash mismatch: node reported %v, header hashes to %v", hash, computed)
+	}
+	return blk, appHash, nil
+}
+
+// VerifyBlock checks the integrity of a block. The transactions must match the
+// count and merkle root in the header, and the block must be signed by the
+// leader, as is verified by validators before accepting a block. Since the
+// signature is of the block hash, which is computed from the header, any
+// change to the header invalidates the signature.
+func VerifyBlock(blk *types.Block, leader crypto.PublicKey) error {
+	if blk.Header == nil {
+		return errors.New("block has no header")
+	}
+	if int(blk.Header.NumTxns) != len(blk.Txns) {
+		return fmt.Errorf("transaction count mismatch: header has %d, block has %d",
+			blk.Header.NumTxns, len(blk.Txns))
+	}
+	if root := blk.MerkleRoot(); root != blk.Header.MerkleRoot {
+		return fmt.Errorf("merkle root mismatch: header has %v, transactions hash to %v",
+			blk.Header.MerkleRoot, root)
+	}
+	valid, err := blk.VerifySignature(leader)
+	if err != nil {
+		return fmt.Errorf("invalid leader signature for block %v: %w", blk.Hash(), err)
+	}
+	if !valid {
+		return fmt.Errorf("block %v is not signed by the leader", blk.Hash())
+	}
+	return nil
+}
@@ -4,12 +4,14 @@ package client
 
 import (
 	"context"
+	"crypto/ed25519"
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"math/big"
 	"net/url"
+	"strings"
 	"time"
 
 	clientType "github.com/kwilteam/kwil-db/core/client/types"
@@ -41,6 +43,10 @@ type Client struct {
 	noWarnings bool // silence warning logs
 
 	authCallRPC bool
+
+	schemas *schemaCache // nil if schema caching is disabled
+
+	parseSchema func([]byte) (*types.Schema, error) // nil if not configured
 }
 
 // SvcClient is a trapdoor to access the underlying
@@ -52,6 +58,23 @@ func (c *Client) SvcClient() user.TxSvcClient {
 
 var _ clientType.Client = (*Client)(nil)
 
+// ErrChainIDMismatch is returned by NewClient and WrapClient when the chain ID
+// of the remote host differs from the configured chain ID, indicating that the
+// client is connected to the wrong network. Use errors.As to detect it.
+type ErrChainIDMismatch struct {
+	Expected string // the configured chain ID
+	Actual   string // the chain ID of the remote host
+}
+
+func (e *ErrChainIDMismatch) Error() string {
+	return fmt.Sprintf("remote host chain ID %q != client configured %q", e.Actual, e.Expected)
+}
+
+// Is makes the error match ErrChainMismatch.
+func (e *ErrChainIDMismatch) Is(target error) bool {
+	return target == ErrChainMismatch
+}
+
 // NewClient creates a Kwil client. The target should be a URL (for an
 // http.Client). It by default communicates with target via HTTP; chain ID of the
 // remote host will be verified against the chain ID passed in.
@@ -96,7 +119,8 @@ func NewClient(ctx context.Context, target string, options *clientType.Options)
 }
 
 // WrapClient wraps a TxSvcClient with a Kwil client.
-// It provides a way to use a custom rpc client with the Kwil client.
+// It provides a way to use a custom rpc client with the Kwil client. With the
+// Offline option, client may be nil.
 func WrapClient(ctx context.Context, client user.TxSvcClient, options *clientType.Options) (*Client, error) {
 	clientOptions := clientType.DefaultOptions()
 	clientOptions.Apply(options)
@@ -109,43 +133,44 @@ func WrapClient(ctx context.Context, client user.TxSvcClient, options *clientTyp
 		noWarnings:        clientOptions.Silence,
 		skipVerifyChainID: clientOptions.SkipVerifyChainID,
 		skipHealthcheck:   clientOptions.SkipHealthcheck,
+		parseSchema:       clientOptions.SchemaParser,
+	}
+	if clientOptions.SchemaCacheTTL > 0 {
+		c.schemas = newSchemaCache(clientOptions.SchemaCacheTTL)
 	}
 
-	var remoteChainID string
-
-	if c.skipHealthcheck {
-		health, err := c.Health(ctx)
-		// NOTE: we ignore all errors from c.Health call since we ignore health check
-		if err == nil {
-			// this is v09 API, we just take the result.
-			c.authCallRPC = health.Mode == types.ModePrivate
-			remoteChainID = health.ChainID
-
-			// NOTE: since original health check only log, why not ?
-			if health.Healthy {
-				c.logger.Warnf("node reports that it is not healthy: %v", health)
-			}
-		} else {
-			// fall back to v08 API
-			chainInfo, err := c.ChainInfo(ctx)
-			if err != nil {
-				return nil, fmt.Errorf("failed to retrieve the node's chain info: %w", err)
-			}
-
-			remoteChainID = chainInfo.ChainID
+	if clientOptions.Offline {
+		if c.chainID == "" {
+			return nil, errors.New("chain ID is required for an offline client")
 		}
-	} else {
-		health, err := c.Health(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("failed to retrieve the node's health: %w", err)
+		if c.txClient == nil {
+			c.txClient = offlineTxSvc{}
 		}
+		return c, nil
+	}
+	if client == nil {
+		return nil, errors.New("a transport is required unless the client is offline")
+	}
 
-		if health.Healthy {
-			c.logger.Warnf("node reports that it is not healthy: %v", health)
+	var remoteChainID string
+	var err error
+	delay := clientOptions.DialRetryDelay
+	for attempt := 1; ; attempt++ {
+		remoteChainID, err = c.remoteChainID(ctx)
+		if err == nil || attempt >= clientOptions.DialAttempts {
+			break
 		}
-
-		c.authCallRPC = health.Mode == types.ModePrivate
-		remoteChainID = health.ChainID
+		c.logger.Warn("failed to retrieve the node's chain info, retrying",
+			"attempt", attempt, "delay", delay, "error", err)
+		select {
+		case <-ctx.Done():
+			return nil, errors.Join(err, ctx.Err())
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	if c.chainID == "" { // always use chain ID from remote host
@@ -161,13 +186,52 @@ func WrapClient(ctx context.Context, client user.TxSvcClient, options *clientTyp
 				c.logger.Warn("chain ID is set, skip check against remote chain ID", "chainID", c.chainID)
 			}
 		} else if remoteChainID != c.chainID {
-			return nil, fmt.Errorf("remote host chain ID %q != client configured %q", remoteChainID, c.chainID)
+			return nil, &ErrChainIDMismatch{Expected: c.chainID, Actual: remoteChainID}
 		}
 	}
 
 	return c, nil
 }
 
+// remoteChainID retrieves the chain ID of the remote node, preferring the
+// health endpoint, which also indicates if the node is in private mode.
+func (c *Client) remoteChainID(ctx context.Context) (string, error) {
+	if c.skipHealthcheck {
+		health, err := c.Health(ctx)
+		// NOTE: we ignore all errors from c.Health call since we ignore health check
+		if err == nil {
+			// this is v09 API, we just take the result.
+			c.authCallRPC = health.Mode == types.ModePrivate
+
+			// NOTE: since original health check only log, why not ?
+			if health.Healthy {
+				c.logger.Warnf("node reports that it is not healthy: %v", health)
+			}
+			return health.ChainID, nil
+		}
+
+		// fall back to v08 API
+		chainInfo, err := c.ChainInfo(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to retrieve the node's chain info: %w", err)
+		}
+
+		return chainInfo.ChainID, nil
+	}
+
+	health, err := c.Health(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve the node's health: %w", err)
+	}
+
+	if health.Healthy {
+		c.logger.Warnf("node reports that it is not healthy: %v", health)
+	}
+
+	c.authCallRPC = health.Mode == types.ModePrivate
+	return health.ChainID, nil
+}
+
 // PrivateMode returns if it the client has connected to an RPC server that is
 // running in "private" mode where call requests require authentication. In
 // addition, queries are expected to be denied, and no verbose transaction
@@ -184,6 +248,62 @@ func syncBcastFlag(syncBcast bool) rpcclient.BroadcastWait {
 	return syncFlag
 }
 
+// ErrTxTooLarge is returned when a transaction exceeds the size limit set with
+// the WithMaxTxSize option.
+var ErrTxTooLarge = errors.New("transaction too large")
+
+// ErrTxChainID is returned when broadcasting a transaction that was created
+// for a different chain than the client's, which would only be rejected by the
+// node. It matches ErrChainMismatch.
+var ErrTxChainID = fmt.Errorf("transaction %w", ErrChainMismatch)
+
+// checkTx applies the guardrails in txOpts to a transaction before broadcast.
+func (c *Client) checkTx(tx *types.Transaction, txOpts *clientType.TxOptions) error {
+	if tx.Body != nil && tx.Body.ChainID != "" && tx.Body.ChainID != c.chainID {
+		return fmt.Errorf("%w: transaction chain ID %q, client chain ID %q", ErrTxChainID, tx.Body.ChainID, c.chainID)
+	}
+	if txOpts.MaxTxSize > 0 {
+		rawTx, err := tx.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		if size := int64(len(rawTx)); size > txOpts.MaxTxSize {
+			return fmt.Errorf("%w: %d bytes exceeds the limit of %d", ErrTxTooLarge, size, txOpts.MaxTxSize)
+		}
+	}
+	if txOpts.FeeWarning != nil && tx.Body.Fee != nil && tx.Body.Fee.Cmp(txOpts.FeeWarning) > 0 && !c.noWarnings {
+		c.logger.Warn("transaction fee exceeds the warning threshold",
+			"fee", tx.Body.Fee.String(), "threshold", txOpts.FeeWarning.String())
+	}
+	return nil
+}
+
+// broadcast broadcasts a transaction with the broadcast options in txOpts.
+func (c *Client) broadcast(ctx context.Context, tx *types.Transaction, txOpts *clientType.TxOptions) (types.Hash, error) {
+	if err := c.checkTx(tx, txOpts); err != nil {
+		return types.Hash{}, err
+	}
+
+	if txOpts.DryRun != nil {
+		txHash, err := tx.Hash()
+		if err != nil {
+			return types.Hash{}, err
+		}
+		*txOpts.DryRun = clientType.TxEstimate{
+			TxHash: txHash,
+			Fee:    tx.Body.Fee,
+			Nonce:  tx.Body.Nonce,
+		}
+		return txHash, nil
+	}
+
+	var opts []rpcclient.BroadcastOption
+	if txOpts.ExpectedNonce != nil {
+		opts = append(opts, rpcclient.WithExpectedNonce(*txOpts.ExpectedNonce))
+	}
+	return c.txClient.Broadcast(ctx, tx, syncBcastFlag(txOpts.SyncBcast), opts...)
+}
+
 // Transfer transfers balance to a given address.
 func (c *Client) Transfer(ctx context.Context, to []byte, amount *big.Int, opts ...clientType.TxOpt) (types.Hash, error) {
 	// Get account balance to ensure we can afford the transfer, and use the
@@ -213,25 +333,104 @@ func (c *Client) Transfer(ctx context.Context, to []byte, amount *big.Int, opts
 	c.logger.Debug("transfer", "to", hex.EncodeToString(to),
 		"amount", amount.String())
 
-	return c.txClient.Broadcast(ctx, tx, syncBcastFlag(txOpts.SyncBcast))
+	return c.broadcast(ctx, tx, txOpts)
 }
 
 // ChainInfo get the current blockchain information like chain ID and best block
-// height/hash.
+// height/hash. Since the chain ID does not change, use ChainID to get it
+// without a request to the node, or BestHeight for just the height.
 func (c *Client) ChainInfo(ctx context.Context) (*types.ChainInfo, error) {
 	return c.txClient.ChainInfo(ctx)
 }
 
-// GetSchema gets a schema by dbid.
+// BestHeight returns the height of the node's best block. The height is
+// always requested from the node since it changes with each block.
+func (c *Client) BestHeight(ctx context.Context) (int64, error) {
+	info, err := c.txClient.ChainInfo(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return int64(info.BlockHeight), nil
+}
+
+// GetSchema gets a schema by dbid. If the client was created with a
+// SchemaCacheTTL, a cached schema may be returned, and it should not be
+// modified.
 func (c *Client) GetSchema(ctx context.Context, dbid string) (*types.Schema, error) {
+	if ds, ok := c.schemas.get(dbid); ok {
+		return ds, nil
+	}
+
 	ds, err := c.txClient.GetSchema(ctx, dbid)
 	if err != nil {
 		return nil, err
 	}
 
+	c.schemas.put(dbid, ds)
+
 	return ds, nil
 }
 
+// InvalidateSchema removes a schema from the client's schema cache, if it is
+// enabled, so that the next GetSchema for the dbid retrieves it from the node.
+// This is done automatically for databases deployed or dropped with this
+// client.
+func (c *Client) InvalidateSchema(dbid string) {
+	c.schemas.remove(dbid)
+}
+
+// GetActions gets the signatures of the actions and procedures of a schema,
+// including the private ones, which are marked as not Public. The schema is
+// retrieved with GetSchema, so a cached schema may be used.
+func (c *Client) GetActions(ctx context.Context, dbid string) ([]clientType.ActionSignature, error) {
+	schema, err := c.GetSchema(ctx, dbid)
+	if err != nil {
+		return nil, err
+	}
+
+	sigs := make([]clientType.ActionSignature, 0, len(schema.Actions)+len(schema.Procedures))
+	for _, act := range schema.Actions {
+		sig := clientType.ActionSignature{
+			Name:       act.Name,
+			Parameters: make([]clientType.ActionParameter, len(act.Parameters)),
+			Public:     act.Public,
+		}
+		for i, param := range act.Parameters {
+			sig.Parameters[i] = clientType.ActionParameter{Name: param}
+		}
+		setModifiers(&sig, act.Modifiers)
+		sigs = append(sigs, sig)
+	}
+	for _, proc := range schema.Procedures {
+		sig := clientType.ActionSignature{
+			Name:       proc.Name,
+			Procedure:  true,
+			Parameters: make([]clientType.ActionParameter, len(proc.Parameters)),
+			Public:     proc.Public,
+		}
+		for i, param := range proc.Parameters {
+			sig.Parameters[i] = clientType.ActionParameter{Name: param.Name, Type: param.Type}
+		}
+		setModifiers(&sig, proc.Modifiers)
+		sigs = append(sigs, sig)
+	}
+	return sigs, nil
+}
+
+// setModifiers sets the access flags of an action signature from its modifiers.
+func setModifiers(sig *clientType.ActionSignature, mods []types.Modifier) {
+	for _, mod := range mods {
+		switch types.Modifier(strings.ToUpper(mod.String())) {
+		case types.ModifierView:
+			sig.View = true
+		case types.ModifierAuthenticated:
+			sig.Authenticated = true
+		case types.ModifierOwner:
+			sig.Owner = true
+		}
+	}
+}
+
 // DeployDatabase deploys a database. TODO: remove
 func (c *Client) DeployDatabase(ctx context.Context, schema *types.Schema, opts ...clientType.TxOpt) (types.Hash, error) {
 	txOpts := clientType.GetTxOpts(opts)
@@ -244,7 +443,37 @@ func (c *Client) DeployDatabase(ctx context.Context, schema *types.Schema, opts
 		"signature_type", tx.Signature.Type,
 		"signature", base64.StdEncoding.EncodeToString(tx.Signature.Data),
 		"fee", tx.Body.Fee.String(), "nonce", tx.Body.Nonce)
-	return c.txClient.Broadcast(ctx, tx, syncBcastFlag(txOpts.SyncBcast))
+
+	res, err := c.broadcast(ctx, tx, txOpts)
+	if err != nil {
+		return types.Hash{}, err
+	}
+
+	c.InvalidateSchema(utils.GenerateDBID(schema.Name, c.Signer.Identity()))
+
+	return res, nil
+}
+
+// ErrNoSchemaParser is returned by DeployDatabaseFromKuneiform if the client
+// was created without the SchemaParser option.
+var ErrNoSchemaParser = errors.New("no schema parser configured")
+
+// DeployDatabaseFromKuneiform parses Kuneiform source with the configured
+// SchemaParser, validates the resulting schema, and deploys it. Parse errors
+// are returned before a transaction is created, and include the position of
+// the offending source.
+func (c *Client) DeployDatabaseFromKuneiform(ctx context.Context, src []byte, opts ...clientType.TxOpt) (types.Hash, error) {
+	if c.parseSchema == nil {
+		return types.Hash{}, ErrNoSchemaParser
+	}
+	schema, err := c.parseSchema(src)
+	if err != nil {
+		return types.Hash{}, fmt.Errorf("parse schema: %w", err)
+	}
+	if err = schema.Clean(); err != nil {
+		return types.Hash{}, fmt.Errorf("invalid schema: %w", err)
+	}
+	return c.DeployDatabase(ctx, schema, opts...)
 }
 
 // DropDatabase drops a database by name, using the configured signer to derive
@@ -261,6 +490,12 @@ func (c *Client) DropDatabaseID(ctx context.Context, dbid string, opts ...client
 	}
 
 	txOpts := clientType.GetTxOpts(opts)
+	if txOpts.CheckOwner {
+		if err := c.checkOwned(ctx, dbid); err != nil {
+			return types.Hash{}, err
+		}
+	}
+
 	tx, err := c.newTx(ctx, identifier, txOpts)
 	if err != nil {
 		return types.Hash{}, err
@@ -271,19 +506,82 @@ func (c *Client) DropDatabaseID(ctx context.Context, dbid string, opts ...client
 		"signature", base64.StdEncoding.EncodeToString(tx.Signature.Data),
 		"fee", tx.Body.Fee.String(), "nonce", tx.Body.Nonce)
 
-	res, err := c.txClient.Broadcast(ctx, tx, syncBcastFlag(txOpts.SyncBcast))
+	res, err := c.broadcast(ctx, tx, txOpts)
 	if err != nil {
 		return types.Hash{}, err
 	}
 
+	c.InvalidateSchema(dbid)
+
+	if txOpts.Confirm != nil && txOpts.DryRun == nil {
+		if err = c.confirmDrop(ctx, dbid, res, txOpts.Confirm); err != nil {
+			return res, err
+		}
+	}
+
 	return res, nil
 }
 
+// ErrNotOwned is returned by a database drop with the WithOwnerCheck option if
+// the database does not exist or is not owned by the signer.
+var ErrNotOwned = errors.New("no such database owned by you")
+
+// checkOwned checks that the database exists and is owned by the signer.
+func (c *Client) checkOwned(ctx context.Context, dbid string) error {
+	if c.Signer == nil {
+		return fmt.Errorf("signer must be set to check database ownership")
+	}
+	owned, err := c.txClient.ListDatabases(ctx, c.Signer.Identity())
+	if err != nil {
+		return fmt.Errorf("list owned databases: %w", err)
+	}
+	for _, ds := range owned {
+		if ds.DBID == dbid {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrNotOwned, dbid)
+}
+
+// confirmDropInterval is the interval at which a drop transaction's status is
+// queried when it is to be confirmed.
+const confirmDropInterval = time.Second
+
+// confirmDrop waits for a drop transaction to be included in a block, and then
+// checks that the database no longer exists.
+func (c *Client) confirmDrop(ctx context.Context, dbid string, txHash types.Hash, res *clientType.DropResult) error {
+	*res = clientType.DropResult{TxHash: txHash}
+
+	txRes, err := c.WaitTx(ctx, txHash, confirmDropInterval)
+	if err != nil {
+		return fmt.Errorf("wait for drop transaction: %w", err)
+	}
+	if txRes.Result != nil && txRes.Result.Code != uint32(types.CodeOk) {
+		return fmt.Errorf("drop transaction failed with code %d: %s", txRes.Result.Code, txRes.Result.Log)
+	}
+
+	_, err = c.txClient.GetSchema(ctx, dbid)
+	switch {
+	case errors.Is(err, rpcclient.ErrNotFound):
+		res.Removed = true
+	case err != nil:
+		return fmt.Errorf("check for dropped database: %w", err)
+	}
+
+	return nil
+}
+
 // Execute executes a procedure or action.
 // It returns the receipt, as well as outputs which is the decoded body of the receipt.
 // It can take any number of inputs, and if multiple tuples of inputs are passed,
-// it will execute them in the same transaction.
+// it will execute them in the same transaction. Every tuple must have the same
+// number of values, which must match the procedure's or action's parameters if
+// its schema is cached, or no transaction is created.
 func (c *Client) Execute(ctx context.Context, dbid string, procedure string, tuples [][]any, opts ...clientType.TxOpt) (types.Hash, error) {
+	if err := c.checkTuples(dbid, procedure, tuples); err != nil {
+		return types.Hash{}, err
+	}
+
 	encodedTuples := make([][]*types.EncodedValue, len(tuples))
 	for i, tuple := range tuples {
 		encoded, err := encodeTuple(tuple)
@@ -311,7 +609,54 @@ func (c *Client) Execute(ctx context.Context, dbid string, procedure string, tup
 		"signature", base64.StdEncoding.EncodeToString(tx.Signature.Data),
 		"fee", tx.Body.Fee.String(), "nonce", tx.Body.Nonce)
 
-	return c.txClient.Broadcast(ctx, tx, syncBcastFlag(txOpts.SyncBcast))
+	return c.broadcast(ctx, tx, txOpts)
+}
+
+// checkTuples checks that the tuples for an execution all have the same
+// number of values, and that it is the number of parameters of the procedure
+// or action if the schema is cached. A malformed batch is otherwise only
+// rejected by the node after it is priced and broadcast.
+func (c *Client) checkTuples(dbid, procedure string, tuples [][]any) error {
+	if len(tuples) == 0 {
+		return nil
+	}
+	if numParams, ok := c.cachedNumParams(dbid, procedure); ok {
+		for i, tuple := range tuples {
+			if len(tuple) != numParams {
+				return fmt.Errorf("tuple %d has %d values, but %q takes %d parameters",
+					i, len(tuple), procedure, numParams)
+			}
+		}
+		return nil
+	}
+	for i, tuple := range tuples[1:] {
+		if len(tuple) != len(tuples[0]) {
+			return fmt.Errorf("tuple %d has %d values, but tuple 0 has %d",
+				i+1, len(tuple), len(tuples[0]))
+		}
+	}
+	return nil
+}
+
+// cachedNumParams returns the number of parameters of a procedure or action in
+// the cached schema for dbid. It returns false if the schema is not cached or
+// does not have the procedure, in which case the node will validate it.
+func (c *Client) cachedNumParams(dbid, procedure string) (int, bool) {
+	schema, ok := c.schemas.get(dbid)
+	if !ok {
+		return 0, false
+	}
+	for _, proc := range schema.Procedures {
+		if proc.Name == procedure {
+			return len(proc.Parameters), true
+		}
+	}
+	for _, act := range schema.Actions {
+		if act.Name == procedure {
+			return len(act.Parameters), true
+		}
+	}
+	return 0, false
 }
 
 // DEPRECATED: Use Call instead.
@@ -376,12 +721,44 @@ func (c *Client) Query(ctx context.Context, dbid string, query string) (*clientT
 	return clientType.NewRecordsFromMaps(res), nil
 }
 
+// QueryBatch runs several ad-hoc SQL queries in one round trip to the node.
+// The records and errors of each query are returned in the same order as the
+// queries, so that one failing query does not fail the others. The returned
+// error is for the batch as a whole, such as when the node does not support
+// batch requests.
+func (c *Client) QueryBatch(ctx context.Context, queries []*user.Query) ([]*clientType.Records, []error, error) {
+	res, errs, err := c.txClient.QueryBatch(ctx, queries)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	records := make([]*clientType.Records, len(res))
+	for i := range res {
+		if errs[i] == nil {
+			records[i] = clientType.NewRecordsFromMaps(res[i])
+		}
+	}
+	return records, errs, nil
+}
+
 // ListDatabases lists databases belonging to an owner.
 // If no owner is passed, it will list all databases.
 func (c *Client) ListDatabases(ctx context.Context, owner []byte) ([]*types.DatasetIdentifier, error) {
 	return c.txClient.ListDatabases(ctx, owner)
 }
 
+// ListDatabasesDetailed lists a page of the databases belonging to an owner,
+// with a summary of each schema. If owner is nil, it lists all databases. The
+// databases are ordered by DBID, and a zero limit returns all of them after the
+// offset. The total number of matching databases is also returned, so that
+// the number of pages is known.
+func (c *Client) ListDatabasesDetailed(ctx context.Context, owner []byte, limit, offset int64) ([]*types.DatasetDetails, int64, error) {
+	if limit < 0 || offset < 0 {
+		return nil, 0, errors.New("limit and offset must not be negative")
+	}
+	return c.txClient.ListDatabasesDetailed(ctx, owner, limit, offset)
+}
+
 // Ping pings the remote host.
 func (c *Client) Ping(ctx context.Context) (string, error) {
 	return c.txClient.Ping(ctx)
@@ -393,6 +770,56 @@ func (c *Client) GetAccount(ctx context.Context, acctID []byte, status types.Acc
 	return c.txClient.GetAccount(ctx, acctID, status)
 }
 
+// GetAccountByAddress gets account info by an account address string, which is
+// either a 0x-prefixed Ethereum address or a hex-encoded public key (ed25519
+// or compressed secp256k1). These are the identifiers given by the respective
+// authenticators. If status is AccountStatusPending, it will include the
+// pending info.
+func (c *Client) GetAccountByAddress(ctx context.Context, addr string, status types.AccountStatus) (*types.Account, error) {
+	acctID, err := accountIDFromAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetAccount(ctx, acctID, status)
+}
+
+// accountIDFromAddress parses an account address string into the account
+// identifier bytes. This reverses the Identifier method of the authenticators,
+// which is used to verify the result.
+func accountIDFromAddress(addr string) ([]byte, error) {
+	var authenticator auth.Authenticator
+	hexID := addr
+	if after, ok := strings.CutPrefix(addr, "0x"); ok {
+		authenticator, hexID = auth.EthSecp256k1Authenticator{}, after
+	} else {
+		switch len(addr) {
+		case 2 * ed25519.PublicKeySize:
+			authenticator = auth.Ed25519Authenticator{}
+		case 2 * secp256k1CompressedPubKeySize:
+			authenticator = auth.Secp25k1Authenticator{}
+		}
+	}
+	if authenticator == nil {
+		return nil, fmt.Errorf("unrecognized account address %q: expected a 0x-prefixed Ethereum address or a hex ed25519 or secp256k1 public key", addr)
+	}
+
+	acctID, err := hex.DecodeString(hexID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid account address %q: %w", addr, err)
+	}
+	ident, err := authenticator.Identifier(acctID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid account address %q: %w", addr, err)
+	}
+	if !strings.EqualFold(ident, addr) { // e.g. eth addresses may be checksummed
+		return nil, fmt.Errorf("invalid account address %q", addr)
+	}
+	return acctID, nil
+}
+
+// secp256k1CompressedPubKeySize is the size of a compressed secp256k1 public key.
+const secp256k1CompressedPubKeySize = 33
+
 // encodeTuple encodes a tuple for usage in a transaction.
 func encodeTuple(tup []any) ([]*types.EncodedValue, error) {
 	encoded := make([]*types.EncodedValue, 0, len(tup))
@@ -435,7 +862,9 @@ func (c *Client) WaitTx(ctx context.Context, txHash types.Hash, interval time.Du
 	}
 }
 
-// ChainID returns the configured chain ID.
+// ChainID returns the chain ID used by the client. This is the configured
+// chain ID, or if none was configured, the chain ID that was retrieved from the
+// node when the client was created. It does not make a request to the node.
 func (c *Client) ChainID() string {
 	return c.chainID
 }
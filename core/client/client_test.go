@@ -0,0 +1,1297 @@
+package client
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
+	"github.com/kwilteam/kwil-db/core/crypto"
+	"github.com/kwilteam/kwil-db/core/crypto/auth"
+	"github.com/kwilteam/kwil-db/core/log"
+	rpcclient "github.com/kwilteam/kwil-db/core/rpc/client"
+	"github.com/kwilteam/kwil-db/core/rpc/client/user"
+	userClient "github.com/kwilteam/kwil-db/core/rpc/client/user/jsonrpc"
+	jsonrpc "github.com/kwilteam/kwil-db/core/rpc/json"
+	userjson "github.com/kwilteam/kwil-db/core/rpc/json/user"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/core/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// mockTxSvcClient is a user.TxSvcClient for testing. Methods that are not
+// overridden by a func field will panic.
+type mockTxSvcClient struct {
+	user.TxSvcClient
+
+	health          func(ctx context.Context) (*types.Health, error)
+	broadcast       func(ctx context.Context, tx *types.Transaction, opts ...rpcclient.BroadcastOption) (types.Hash, error)
+	txQuery         func(ctx context.Context, txHash types.Hash) (*types.TxQueryResponse, error)
+	getSchema       func(ctx context.Context, dbid string) (*types.Schema, error)
+	chainInfo       func(ctx context.Context) (*types.ChainInfo, error)
+	blockHdr        func(ctx context.Context, height int64, wait time.Duration) (types.Hash, *types.BlockHeader, error)
+	block           func(ctx context.Context, height int64) (types.Hash, *types.Block, types.Hash, error)
+	account         func(ctx context.Context, acctID []byte, status types.AccountStatus) (*types.Account, error)
+	estimate        func(ctx context.Context, tx *types.Transaction) (*big.Int, error)
+	listDBs         func(ctx context.Context, owner []byte) ([]*types.DatasetIdentifier, error)
+	listDBsDetailed func(ctx context.Context, owner []byte, limit, offset int64) ([]*types.DatasetDetails, int64, error)
+}
+
+func (m *mockTxSvcClient) Health(ctx context.Context) (*types.Health, error) {
+	return m.health(ctx)
+}
+
+func (m *mockTxSvcClient) GetAccount(ctx context.Context, pubKey []byte, status types.AccountStatus) (*types.Account, error) {
+	if m.account != nil {
+		return m.account(ctx, pubKey, status)
+	}
+	return &types.Account{}, nil
+}
+
+func (m *mockTxSvcClient) EstimateCost(ctx context.Context, tx *types.Transaction) (*big.Int, error) {
+	if m.estimate != nil {
+		return m.estimate(ctx, tx)
+	}
+	return big.NewInt(0), nil
+}
+
+func (m *mockTxSvcClient) Broadcast(ctx context.Context, tx *types.Transaction, _ rpcclient.BroadcastWait, opts ...rpcclient.BroadcastOption) (types.Hash, error) {
+	return m.broadcast(ctx, tx, opts...)
+}
+
+func (m *mockTxSvcClient) TxQuery(ctx context.Context, txHash types.Hash) (*types.TxQueryResponse, error) {
+	return m.txQuery(ctx, txHash)
+}
+
+func (m *mockTxSvcClient) GetSchema(ctx context.Context, dbid string) (*types.Schema, error) {
+	return m.getSchema(ctx, dbid)
+}
+
+func (m *mockTxSvcClient) ListDatabases(ctx context.Context, owner []byte) ([]*types.DatasetIdentifier, error) {
+	return m.listDBs(ctx, owner)
+}
+
+func (m *mockTxSvcClient) ListDatabasesDetailed(ctx context.Context, owner []byte, limit, offset int64) ([]*types.DatasetDetails, int64, error) {
+	return m.listDBsDetailed(ctx, owner, limit, offset)
+}
+
+func (m *mockTxSvcClient) ChainInfo(ctx context.Context) (*types.ChainInfo, error) {
+	return m.chainInfo(ctx)
+}
+
+func (m *mockTxSvcClient) BlockHeader(ctx context.Context, height int64, wait time.Duration) (types.Hash, *types.BlockHeader, error) {
+	return m.blockHdr(ctx, height, wait)
+}
+
+func (m *mockTxSvcClient) Block(ctx context.Context, height int64) (types.Hash, *types.Block, types.Hash, error) {
+	return m.block(ctx, height)
+}
+
+func healthyNode(chainID string) func(context.Context) (*types.Health, error) {
+	return func(context.Context) (*types.Health, error) {
+		return &types.Health{ChainInfo: types.ChainInfo{ChainID: chainID}}, nil
+	}
+}
+
+func TestWrapClientDialRetry(t *testing.T) {
+	const chainID = "kwil-test-chain"
+	errUnavailable := errors.New("connection refused")
+
+	// failingNode fails the first numFails calls, and then succeeds.
+	failingNode := func(numFails int, calls *int) func(context.Context) (*types.Health, error) {
+		return func(ctx context.Context) (*types.Health, error) {
+			*calls++
+			if *calls <= numFails {
+				return nil, errUnavailable
+			}
+			return healthyNode(chainID)(ctx)
+		}
+	}
+
+	t.Run("retry until success", func(t *testing.T) {
+		var calls int
+		cl, err := WrapClient(context.Background(),
+			&mockTxSvcClient{health: failingNode(2, &calls)},
+			&clientType.Options{
+				DialAttempts:   3,
+				DialRetryDelay: time.Millisecond,
+				Silence:        true,
+			})
+		require.NoError(t, err)
+		require.Equal(t, 3, calls)
+		require.Equal(t, chainID, cl.ChainID())
+	})
+
+	t.Run("default single attempt", func(t *testing.T) {
+		var calls int
+		_, err := WrapClient(context.Background(),
+			&mockTxSvcClient{health: failingNode(2, &calls)}, nil)
+		require.ErrorIs(t, err, errUnavailable)
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("attempts exhausted", func(t *testing.T) {
+		var calls int
+		_, err := WrapClient(context.Background(),
+			&mockTxSvcClient{health: failingNode(5, &calls)},
+			&clientType.Options{
+				DialAttempts:   3,
+				DialRetryDelay: time.Millisecond,
+			})
+		require.ErrorIs(t, err, errUnavailable)
+		require.Equal(t, 3, calls)
+	})
+}
+
+func TestWrapClientChainIDMismatch(t *testing.T) {
+	const chainID, remoteChainID = "kwil-test-chain", "kwil-other-chain"
+	mock := &mockTxSvcClient{health: healthyNode(remoteChainID)}
+
+	_, err := WrapClient(context.Background(), mock, &clientType.Options{ChainID: chainID})
+	var mismatch *ErrChainIDMismatch
+	require.ErrorAs(t, err, &mismatch)
+	require.Equal(t, chainID, mismatch.Expected)
+	require.Equal(t, remoteChainID, mismatch.Actual)
+	require.ErrorIs(t, err, ErrChainMismatch)
+
+	// The remote chain ID is trusted if none is configured.
+	cl, err := WrapClient(context.Background(), mock, &clientType.Options{Silence: true})
+	require.NoError(t, err)
+	require.Equal(t, remoteChainID, cl.ChainID())
+
+	// The check may be skipped.
+	cl, err = WrapClient(context.Background(), mock, &clientType.Options{
+		ChainID:           chainID,
+		SkipVerifyChainID: true,
+		Silence:           true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, chainID, cl.ChainID())
+}
+
+func TestDryRun(t *testing.T) {
+	const chainID = "kwil-test-chain"
+	privKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+
+	fee := big.NewInt(1234)
+	mock := &mockTxSvcClient{
+		health: healthyNode(chainID),
+		account: func(context.Context, []byte, types.AccountStatus) (*types.Account, error) {
+			return &types.Account{Identifier: []byte{1}, Nonce: 6}, nil
+		},
+		estimate: func(context.Context, *types.Transaction) (*big.Int, error) {
+			return fee, nil
+		},
+		broadcast: func(context.Context, *types.Transaction, ...rpcclient.BroadcastOption) (types.Hash, error) {
+			t.Fatal("dry run transaction was broadcast")
+			return types.Hash{}, nil
+		},
+	}
+
+	cl, err := WrapClient(context.Background(), mock, &clientType.Options{
+		Signer:  auth.GetUserSigner(privKey),
+		ChainID: chainID,
+	})
+	require.NoError(t, err)
+
+	var est clientType.TxEstimate
+	hash, err := cl.Execute(context.Background(), "xdbid", "act", nil, clientType.WithDryRun(&est))
+	require.NoError(t, err)
+	require.Equal(t, est.TxHash, hash)
+	require.NotEqual(t, types.Hash{}, hash)
+	require.Equal(t, fee, est.Fee)
+	require.Equal(t, uint64(7), est.Nonce)
+
+	// Dropping with confirmation does not wait for the unsent transaction.
+	var res clientType.DropResult
+	_, err = cl.DropDatabase(context.Background(), "testdb", clientType.WithDryRun(&est),
+		clientType.WithConfirm(&res))
+	require.NoError(t, err)
+	require.False(t, res.Removed)
+}
+
+func TestOfflineClient(t *testing.T) {
+	const chainID = "kwil-test-chain"
+	privKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+	signer := auth.GetUserSigner(privKey)
+
+	_, err = WrapClient(context.Background(), nil, &clientType.Options{
+		Offline: true,
+		Signer:  signer,
+	})
+	require.Error(t, err, "chain ID should be required")
+
+	cl, err := WrapClient(context.Background(), nil, &clientType.Options{
+		Offline: true,
+		ChainID: chainID,
+		Signer:  signer,
+	})
+	require.NoError(t, err)
+	require.Equal(t, chainID, cl.ChainID())
+
+	schema := &types.Schema{Name: "testdb"}
+	tx, err := cl.NewSignedTx(context.Background(), schema, &clientType.TxOptions{
+		Nonce: 3,
+		Fee:   big.NewInt(100),
+	})
+	require.NoError(t, err)
+	require.Equal(t, types.PayloadTypeDeploySchema, tx.Body.PayloadType)
+	require.Equal(t, chainID, tx.Body.ChainID)
+	require.Equal(t, uint64(3), tx.Body.Nonce)
+	require.Equal(t, big.NewInt(100), tx.Body.Fee)
+	require.Equal(t, signer.Identity(), []byte(tx.Sender))
+	require.NotEmpty(t, tx.Signature.Data)
+
+	// The nonce and fee cannot be retrieved from a node.
+	_, err = cl.NewSignedTx(context.Background(), schema, &clientType.TxOptions{Fee: big.NewInt(100)})
+	require.ErrorIs(t, err, ErrOffline)
+	_, err = cl.NewSignedTx(context.Background(), schema, &clientType.TxOptions{Nonce: 3})
+	require.ErrorIs(t, err, ErrOffline)
+
+	// Broadcasting fails clearly, but a dry run does not need the node.
+	_, err = cl.DeployDatabase(context.Background(), schema,
+		clientType.WithNonce(3), clientType.WithFee(big.NewInt(100)))
+	require.ErrorIs(t, err, ErrOffline)
+
+	var est clientType.TxEstimate
+	hash, err := cl.DeployDatabase(context.Background(), schema,
+		clientType.WithNonce(3), clientType.WithFee(big.NewInt(100)), clientType.WithDryRun(&est))
+	require.NoError(t, err)
+	txHash, err := tx.Hash()
+	require.NoError(t, err)
+	require.Equal(t, txHash, hash)
+
+	_, err = cl.GetSchema(context.Background(), "xdbid")
+	require.ErrorIs(t, err, ErrOffline)
+
+	// With a transport, an offline client still does not contact the node
+	// when created. Any call on this mock would panic.
+	_, err = WrapClient(context.Background(), &mockTxSvcClient{}, &clientType.Options{
+		Offline: true,
+		ChainID: chainID,
+	})
+	require.NoError(t, err)
+}
+
+func TestEd25519SeedSigner(t *testing.T) {
+	const chainID = "kwil-test-chain"
+	seed, err := hex.DecodeString("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	require.NoError(t, err)
+	signer, err := auth.NewEd25519SignerFromSeed(seed)
+	require.NoError(t, err)
+	require.Equal(t, auth.Ed25519Auth, signer.AuthType())
+	// public key for the RFC 8032 test 1 secret key
+	require.Equal(t, "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a",
+		hex.EncodeToString(signer.Identity()))
+
+	_, err = auth.NewEd25519SignerFromSeed(seed[:31])
+	require.Error(t, err)
+
+	var sent *types.Transaction
+	mock := &mockTxSvcClient{
+		health: healthyNode(chainID),
+		broadcast: func(_ context.Context, tx *types.Transaction, _ ...rpcclient.BroadcastOption) (types.Hash, error) {
+			sent = tx
+			return types.Hash{1}, nil
+		},
+	}
+	cl, err := WrapClient(context.Background(), mock, &clientType.Options{
+		Signer:  signer,
+		ChainID: chainID,
+	})
+	require.NoError(t, err)
+
+	_, err = cl.Execute(context.Background(), "xdbid", "act", nil)
+	require.NoError(t, err)
+	require.NotNil(t, sent)
+	require.Equal(t, auth.Ed25519Auth, sent.Signature.Type)
+	require.Equal(t, signer.Identity(), []byte(sent.Sender))
+
+	msg, err := sent.SerializeMsg()
+	require.NoError(t, err)
+	require.NoError(t, auth.Ed25519Authenticator{}.Verify(sent.Sender, msg, sent.Signature.Data))
+
+	// The signature does not verify for a modified transaction.
+	sent.Body.Nonce++
+	msg, err = sent.SerializeMsg()
+	require.NoError(t, err)
+	require.Error(t, auth.Ed25519Authenticator{}.Verify(sent.Sender, msg, sent.Signature.Data))
+}
+
+func TestBroadcastChainID(t *testing.T) {
+	const chainID = "kwil-test-chain"
+	privKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+	signer := auth.GetUserSigner(privKey)
+
+	var sent int
+	mock := &mockTxSvcClient{
+		health: healthyNode(chainID),
+		broadcast: func(context.Context, *types.Transaction, ...rpcclient.BroadcastOption) (types.Hash, error) {
+			sent++
+			return types.Hash{1}, nil
+		},
+	}
+	cl, err := WrapClient(context.Background(), mock, &clientType.Options{
+		Signer:  signer,
+		ChainID: chainID,
+	})
+	require.NoError(t, err)
+
+	newTx := func(chainID string) *types.Transaction {
+		tx, err := types.CreateTransaction(&types.Transfer{}, chainID, 1)
+		require.NoError(t, err)
+		tx.Body.Fee = big.NewInt(0)
+		require.NoError(t, tx.Sign(signer))
+		return tx
+	}
+
+	hash, err := cl.Broadcast(context.Background(), newTx(chainID))
+	require.NoError(t, err)
+	require.Equal(t, types.Hash{1}, hash)
+	require.Equal(t, 1, sent)
+
+	_, err = cl.Broadcast(context.Background(), newTx("kwil-other-chain"))
+	require.ErrorIs(t, err, ErrTxChainID)
+	require.ErrorIs(t, err, ErrChainMismatch)
+	require.ErrorContains(t, err, "kwil-other-chain")
+	require.Equal(t, 1, sent, "transaction for another chain was broadcast")
+}
+
+func TestGetActions(t *testing.T) {
+	const chainID = "kwil-test-chain"
+	var schemaLookups int
+	mock := &mockTxSvcClient{
+		health: healthyNode(chainID),
+		getSchema: func(context.Context, string) (*types.Schema, error) {
+			schemaLookups++
+			return &types.Schema{
+				Name: "testdb",
+				Actions: []*types.Action{
+					{Name: "get_user", Parameters: []string{"$id"}, Public: true,
+						Modifiers: []types.Modifier{types.ModifierView}},
+					{Name: "add_user", Parameters: []string{"$id", "$name"}, Public: true,
+						Modifiers: []types.Modifier{types.ModifierAuthenticated, types.ModifierOwner}},
+				},
+				Procedures: []*types.Procedure{{Name: "helper", Parameters: []*types.ProcedureParameter{
+					{Name: "$n", Type: types.IntType},
+				}}},
+			}, nil
+		},
+	}
+	cl, err := WrapClient(context.Background(), mock, &clientType.Options{
+		ChainID:        chainID,
+		SchemaCacheTTL: time.Minute,
+	})
+	require.NoError(t, err)
+
+	sigs, err := cl.GetActions(context.Background(), "xdbid")
+	require.NoError(t, err)
+	require.Equal(t, []clientType.ActionSignature{
+		{
+			Name:       "get_user",
+			Parameters: []clientType.ActionParameter{{Name: "$id"}},
+			Public:     true,
+			View:       true,
+		},
+		{
+			Name:          "add_user",
+			Parameters:    []clientType.ActionParameter{{Name: "$id"}, {Name: "$name"}},
+			Public:        true,
+			Authenticated: true,
+			Owner:         true,
+		},
+		{
+			Name:       "helper",
+			Procedure:  true,
+			Parameters: []clientType.ActionParameter{{Name: "$n", Type: types.IntType}},
+		},
+	}, sigs)
+
+	// The schema is cached.
+	_, err = cl.GetActions(context.Background(), "xdbid")
+	require.NoError(t, err)
+	require.Equal(t, 1, schemaLookups)
+}
+
+func TestDropDatabaseConfirm(t *testing.T) {
+	const chainID = "kwil-test-chain"
+	privKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+	signer := auth.GetUserSigner(privKey)
+	dbid := utils.GenerateDBID("testdb", signer.Identity())
+
+	// The mock node drops the database when the transaction is mined.
+	var dropped bool
+	txHash := types.Hash{1, 2, 3}
+	mock := &mockTxSvcClient{
+		health: healthyNode(chainID),
+		broadcast: func(_ context.Context, tx *types.Transaction, _ ...rpcclient.BroadcastOption) (types.Hash, error) {
+			require.Equal(t, types.PayloadTypeDropSchema, tx.Body.PayloadType)
+			return txHash, nil
+		},
+		txQuery: func(_ context.Context, hash types.Hash) (*types.TxQueryResponse, error) {
+			require.Equal(t, txHash, hash)
+			dropped = true
+			return &types.TxQueryResponse{
+				Hash:   hash,
+				Height: 10,
+				Result: &types.TxResult{Code: uint32(types.CodeOk)},
+			}, nil
+		},
+		getSchema: func(_ context.Context, id string) (*types.Schema, error) {
+			require.Equal(t, dbid, id)
+			if dropped {
+				return nil, rpcclient.ErrNotFound
+			}
+			return &types.Schema{Name: "testdb"}, nil
+		},
+	}
+
+	cl, err := WrapClient(context.Background(), mock, &clientType.Options{
+		Signer:  signer,
+		ChainID: chainID,
+	})
+	require.NoError(t, err)
+
+	t.Run("without confirm", func(t *testing.T) {
+		dropped = false
+		hash, err := cl.DropDatabase(context.Background(), "testdb")
+		require.NoError(t, err)
+		require.Equal(t, txHash, hash)
+		require.False(t, dropped) // did not wait for the tx
+	})
+
+	t.Run("confirmed", func(t *testing.T) {
+		dropped = false
+		var res clientType.DropResult
+		hash, err := cl.DropDatabase(context.Background(), "testdb", clientType.WithConfirm(&res))
+		require.NoError(t, err)
+		require.Equal(t, txHash, hash)
+		require.Equal(t, txHash, res.TxHash)
+		require.True(t, res.Removed)
+	})
+
+	t.Run("not removed", func(t *testing.T) {
+		dropped = false
+		mock.getSchema = func(context.Context, string) (*types.Schema, error) {
+			return &types.Schema{Name: "testdb"}, nil
+		}
+		var res clientType.DropResult
+		_, err := cl.DropDatabase(context.Background(), "testdb", clientType.WithConfirm(&res))
+		require.NoError(t, err)
+		require.Equal(t, txHash, res.TxHash)
+		require.False(t, res.Removed)
+	})
+}
+
+func TestDropDatabaseOwnerCheck(t *testing.T) {
+	const chainID = "kwil-test-chain"
+	privKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+	signer := auth.GetUserSigner(privKey)
+	ownedID := utils.GenerateDBID("owned", signer.Identity())
+
+	var broadcasts int
+	mock := &mockTxSvcClient{
+		health: healthyNode(chainID),
+		broadcast: func(context.Context, *types.Transaction, ...rpcclient.BroadcastOption) (types.Hash, error) {
+			broadcasts++
+			return types.Hash{1}, nil
+		},
+		listDBs: func(_ context.Context, owner []byte) ([]*types.DatasetIdentifier, error) {
+			require.Equal(t, []byte(signer.Identity()), owner)
+			return []*types.DatasetIdentifier{{Name: "owned", Owner: owner, DBID: ownedID}}, nil
+		},
+	}
+
+	cl, err := WrapClient(context.Background(), mock, &clientType.Options{
+		Signer:  signer,
+		ChainID: chainID,
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	t.Run("owned", func(t *testing.T) {
+		broadcasts = 0
+		_, err := cl.DropDatabase(ctx, "owned", clientType.WithOwnerCheck())
+		require.NoError(t, err)
+		require.Equal(t, 1, broadcasts)
+	})
+
+	t.Run("not owned", func(t *testing.T) {
+		broadcasts = 0
+		// Another owner's database, with a DBID derived from their identity.
+		otherID := utils.GenerateDBID("owned", []byte("someone else"))
+		_, err := cl.DropDatabaseID(ctx, otherID, clientType.WithOwnerCheck())
+		require.ErrorIs(t, err, ErrNotOwned)
+		require.Zero(t, broadcasts)
+	})
+
+	t.Run("nonexistent", func(t *testing.T) {
+		broadcasts = 0
+		_, err := cl.DropDatabase(ctx, "nope", clientType.WithOwnerCheck())
+		require.ErrorIs(t, err, ErrNotOwned)
+		require.Zero(t, broadcasts)
+	})
+
+	t.Run("no check", func(t *testing.T) {
+		broadcasts = 0
+		_, err := cl.DropDatabase(ctx, "nope")
+		require.NoError(t, err)
+		require.Equal(t, 1, broadcasts)
+	})
+
+	t.Run("list fails", func(t *testing.T) {
+		broadcasts = 0
+		mock.listDBs = func(context.Context, []byte) ([]*types.DatasetIdentifier, error) {
+			return nil, errors.New("node unavailable")
+		}
+		_, err := cl.DropDatabase(ctx, "owned", clientType.WithOwnerCheck())
+		require.Error(t, err)
+		require.NotErrorIs(t, err, ErrNotOwned)
+		require.Zero(t, broadcasts)
+	})
+}
+
+func TestDeployDatabaseFromKuneiform(t *testing.T) {
+	const chainID = "kwil-test-chain"
+	privKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+
+	// The stub parser accepts "database <name>;" and otherwise reports an
+	// error with a position, as the Kuneiform parser does.
+	parser := func(src []byte) (*types.Schema, error) {
+		var name string
+		if _, err := fmt.Sscanf(string(src), "database %s", &name); err != nil || !strings.HasSuffix(name, ";") {
+			return nil, errors.New("(syntax) 1:0 - 1:8: unexpected input")
+		}
+		return &types.Schema{Name: strings.TrimSuffix(name, ";")}, nil
+	}
+
+	var deployed *types.Schema
+	mock := &mockTxSvcClient{
+		health: healthyNode(chainID),
+		broadcast: func(_ context.Context, tx *types.Transaction, _ ...rpcclient.BroadcastOption) (types.Hash, error) {
+			require.Equal(t, types.PayloadTypeDeploySchema, tx.Body.PayloadType)
+			deployed = new(types.Schema)
+			require.NoError(t, deployed.UnmarshalBinary(tx.Body.Payload))
+			return types.Hash{1}, nil
+		},
+	}
+	opts := &clientType.Options{
+		Signer:  auth.GetUserSigner(privKey),
+		ChainID: chainID,
+	}
+	cl, err := WrapClient(context.Background(), mock, opts)
+	require.NoError(t, err)
+
+	_, err = cl.DeployDatabaseFromKuneiform(context.Background(), []byte("database testdb;"))
+	require.ErrorIs(t, err, ErrNoSchemaParser)
+
+	opts.SchemaParser = parser
+	cl, err = WrapClient(context.Background(), mock, opts)
+	require.NoError(t, err)
+
+	t.Run("valid", func(t *testing.T) {
+		deployed = nil
+		hash, err := cl.DeployDatabaseFromKuneiform(context.Background(), []byte("database TestDB;"))
+		require.NoError(t, err)
+		require.Equal(t, types.Hash{1}, hash)
+		require.Equal(t, "testdb", deployed.Name) // cleaned
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		deployed = nil
+		_, err := cl.DeployDatabaseFromKuneiform(context.Background(), []byte("databse testdb;"))
+		require.ErrorContains(t, err, "1:0 - 1:8")
+		require.Nil(t, deployed)
+	})
+
+	t.Run("invalid schema", func(t *testing.T) {
+		deployed = nil
+		_, err := cl.DeployDatabaseFromKuneiform(context.Background(), []byte("database test-db;"))
+		require.ErrorContains(t, err, "invalid schema")
+		require.Nil(t, deployed)
+	})
+}
+
+func TestExecuteTupleArity(t *testing.T) {
+	const chainID = "kwil-test-chain"
+	privKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+	signer := auth.GetUserSigner(privKey)
+	dbid := utils.GenerateDBID("testdb", signer.Identity())
+
+	var broadcasts int
+	mock := &mockTxSvcClient{
+		health: healthyNode(chainID),
+		broadcast: func(context.Context, *types.Transaction, ...rpcclient.BroadcastOption) (types.Hash, error) {
+			broadcasts++
+			return types.Hash{1}, nil
+		},
+		getSchema: func(context.Context, string) (*types.Schema, error) {
+			return &types.Schema{
+				Name:    "testdb",
+				Actions: []*types.Action{{Name: "act", Parameters: []string{"$a", "$b"}}},
+				Procedures: []*types.Procedure{{Name: "proc", Parameters: []*types.ProcedureParameter{
+					{Name: "$a", Type: types.IntType},
+				}}},
+			}, nil
+		},
+	}
+
+	cl, err := WrapClient(context.Background(), mock, &clientType.Options{
+		Signer:         signer,
+		ChainID:        chainID,
+		SchemaCacheTTL: time.Minute,
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+	opts := []clientType.TxOpt{clientType.WithNonce(1), clientType.WithFee(big.NewInt(0))}
+
+	t.Run("mismatched tuples", func(t *testing.T) {
+		broadcasts = 0
+		_, err := cl.Execute(ctx, dbid, "act", [][]any{{1, 2}, {3, 4}, {5}}, opts...)
+		require.ErrorContains(t, err, "tuple 2 has 1 values, but tuple 0 has 2")
+		require.Zero(t, broadcasts)
+	})
+
+	t.Run("valid batch", func(t *testing.T) {
+		broadcasts = 0
+		_, err := cl.Execute(ctx, dbid, "act", [][]any{{1, 2}, {3, 4}, {5, 6}}, opts...)
+		require.NoError(t, err)
+		require.Equal(t, 1, broadcasts)
+	})
+
+	_, err = cl.GetSchema(ctx, dbid) // cache it
+	require.NoError(t, err)
+
+	t.Run("parameter count", func(t *testing.T) {
+		broadcasts = 0
+		_, err := cl.Execute(ctx, dbid, "act", [][]any{{1, 2, 3}, {4, 5, 6}}, opts...)
+		require.ErrorContains(t, err, `tuple 0 has 3 values, but "act" takes 2 parameters`)
+		_, err = cl.Execute(ctx, dbid, "proc", [][]any{{1}, {2, 3}}, opts...)
+		require.ErrorContains(t, err, `tuple 1 has 2 values, but "proc" takes 1 parameters`)
+		require.Zero(t, broadcasts)
+	})
+
+	t.Run("valid batch with cached schema", func(t *testing.T) {
+		broadcasts = 0
+		_, err := cl.Execute(ctx, dbid, "act", [][]any{{1, 2}, {3, 4}}, opts...)
+		require.NoError(t, err)
+		_, err = cl.Execute(ctx, dbid, "proc", [][]any{{1}, {2}, {3}}, opts...)
+		require.NoError(t, err)
+		// not in the schema, so left to the node
+		_, err = cl.Execute(ctx, dbid, "other", [][]any{{1}, {2}}, opts...)
+		require.NoError(t, err)
+		require.Equal(t, 3, broadcasts)
+	})
+}
+
+func TestGetSchemaCache(t *testing.T) {
+	const chainID = "kwil-test-chain"
+	privKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+	signer := auth.GetUserSigner(privKey)
+	dbid := utils.GenerateDBID("testdb", signer.Identity())
+
+	var fetches int
+	mock := &mockTxSvcClient{
+		health: healthyNode(chainID),
+		broadcast: func(context.Context, *types.Transaction, ...rpcclient.BroadcastOption) (types.Hash, error) {
+			return types.Hash{1}, nil
+		},
+		getSchema: func(_ context.Context, id string) (*types.Schema, error) {
+			require.Equal(t, dbid, id)
+			fetches++
+			return &types.Schema{Name: "testdb"}, nil
+		},
+	}
+
+	cl, err := WrapClient(context.Background(), mock, &clientType.Options{
+		Signer:         signer,
+		ChainID:        chainID,
+		SchemaCacheTTL: time.Minute,
+	})
+	require.NoError(t, err)
+
+	now := time.Now()
+	cl.schemas.now = func() time.Time { return now }
+
+	t.Run("hit", func(t *testing.T) {
+		fetches = 0
+		schema, err := cl.GetSchema(context.Background(), dbid)
+		require.NoError(t, err)
+		require.Equal(t, "testdb", schema.Name)
+		schema2, err := cl.GetSchema(context.Background(), dbid)
+		require.NoError(t, err)
+		require.Same(t, schema, schema2)
+		require.Equal(t, 1, fetches)
+	})
+
+	t.Run("ttl expiry", func(t *testing.T) {
+		fetches = 0
+		now = now.Add(time.Minute)
+		_, err := cl.GetSchema(context.Background(), dbid)
+		require.NoError(t, err)
+		require.Equal(t, 1, fetches)
+		_, err = cl.GetSchema(context.Background(), dbid)
+		require.NoError(t, err)
+		require.Equal(t, 1, fetches)
+	})
+
+	t.Run("invalidate", func(t *testing.T) {
+		fetches = 0
+		cl.InvalidateSchema(dbid)
+		_, err := cl.GetSchema(context.Background(), dbid)
+		require.NoError(t, err)
+		require.Equal(t, 1, fetches)
+	})
+
+	t.Run("invalidated by drop", func(t *testing.T) {
+		fetches = 0
+		_, err := cl.DropDatabase(context.Background(), "testdb")
+		require.NoError(t, err)
+		_, err = cl.GetSchema(context.Background(), dbid)
+		require.NoError(t, err)
+		require.Equal(t, 1, fetches)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		cl, err := WrapClient(context.Background(), mock, &clientType.Options{
+			Signer:  signer,
+			ChainID: chainID,
+		})
+		require.NoError(t, err)
+		fetches = 0
+		for range 2 {
+			_, err = cl.GetSchema(context.Background(), dbid)
+			require.NoError(t, err)
+		}
+		require.Equal(t, 2, fetches)
+		cl.InvalidateSchema(dbid) // no-op
+	})
+}
+
+func TestChainIDCachedBestHeightLive(t *testing.T) {
+	const chainID = "kwil-test-chain"
+	var healthCalls, infoCalls int
+	mock := &mockTxSvcClient{
+		health: func(ctx context.Context) (*types.Health, error) {
+			healthCalls++
+			return healthyNode(chainID)(ctx)
+		},
+		chainInfo: func(context.Context) (*types.ChainInfo, error) {
+			infoCalls++
+			return &types.ChainInfo{ChainID: chainID, BlockHeight: uint64(10 + infoCalls)}, nil
+		},
+	}
+
+	cl, err := WrapClient(context.Background(), mock, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, healthCalls)
+
+	for range 3 {
+		require.Equal(t, chainID, cl.ChainID())
+	}
+	require.Equal(t, 1, healthCalls)
+	require.Zero(t, infoCalls)
+
+	for i := range 3 {
+		height, err := cl.BestHeight(context.Background())
+		require.NoError(t, err)
+		require.EqualValues(t, 11+i, height)
+	}
+	require.Equal(t, 3, infoCalls)
+	require.Equal(t, 1, healthCalls)
+
+	mock.chainInfo = func(context.Context) (*types.ChainInfo, error) {
+		return nil, errors.New("unavailable")
+	}
+	_, err = cl.BestHeight(context.Background())
+	require.Error(t, err)
+	require.Equal(t, chainID, cl.ChainID())
+}
+
+func TestGetAccountByAddress(t *testing.T) {
+	var gotID []byte
+	mock := &mockTxSvcClient{
+		health: healthyNode("kwil-test-chain"),
+		account: func(_ context.Context, acctID []byte, status types.AccountStatus) (*types.Account, error) {
+			require.Equal(t, types.AccountStatusPending, status)
+			gotID = acctID
+			return &types.Account{Identifier: acctID, Nonce: 1}, nil
+		},
+	}
+	cl, err := WrapClient(context.Background(), mock, &clientType.Options{Silence: true})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	t.Run("eth address", func(t *testing.T) {
+		ethAddr, err := hex.DecodeString("c89d42189f0450c2b2c3c61f58ec5d628176a1e7")
+		require.NoError(t, err)
+		for _, addr := range []string{
+			"0xc89d42189f0450c2b2c3c61f58ec5d628176a1e7",
+			"0xc89D42189f0450C2b2c3c61f58Ec5d628176A1E7", // checksummed
+		} {
+			acct, err := cl.GetAccountByAddress(ctx, addr, types.AccountStatusPending)
+			require.NoError(t, err)
+			require.Equal(t, ethAddr, gotID)
+			require.EqualValues(t, 1, acct.Nonce)
+		}
+	})
+
+	t.Run("ed25519 pubkey", func(t *testing.T) {
+		_, pub, err := crypto.GenerateEd25519Key(nil)
+		require.NoError(t, err)
+		_, err = cl.GetAccountByAddress(ctx, hex.EncodeToString(pub.Bytes()), types.AccountStatusPending)
+		require.NoError(t, err)
+		require.Equal(t, pub.Bytes(), gotID)
+	})
+
+	t.Run("unrecognized", func(t *testing.T) {
+		gotID = nil
+		for _, addr := range []string{
+			"",
+			"0x1234",
+			"0xzz9d42189f0450c2b2c3c61f58ec5d628176a1e7",
+			"c89d42189f0450c2b2c3c61f58ec5d628176a1e7", // eth address without 0x
+			"not an address",
+		} {
+			_, err := cl.GetAccountByAddress(ctx, addr, types.AccountStatusPending)
+			require.Error(t, err, addr)
+		}
+		require.Nil(t, gotID)
+	})
+}
+
+func TestSubscribeBlocks(t *testing.T) {
+	// newChain returns a mock node at height 2 that commits a block on every
+	// other request for a future height, returning not found in between, as
+	// a node would after its long-poll wait expires.
+	newChain := func() *mockTxSvcClient {
+		var mtx sync.Mutex
+		var calls int
+		bestHeight := int64(2)
+		return &mockTxSvcClient{
+			chainInfo: func(context.Context) (*types.ChainInfo, error) {
+				mtx.Lock()
+				defer mtx.Unlock()
+				return &types.ChainInfo{BlockHeight: uint64(bestHeight)}, nil
+			},
+			blockHdr: func(ctx context.Context, height int64, _ time.Duration) (types.Hash, *types.BlockHeader, error) {
+				mtx.Lock()
+				defer mtx.Unlock()
+				if height > bestHeight {
+					calls++
+					if calls%2 == 1 {
+						return types.Hash{}, nil, rpcclient.ErrNotFound
+					}
+					bestHeight++
+				}
+				hdr := &types.BlockHeader{Height: height}
+				return hdr.Hash(), hdr, nil
+			},
+		}
+	}
+
+	t.Run("from latest", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		cl := &Client{txClient: newChain()}
+		sub, err := cl.SubscribeBlocks(ctx, 0)
+		require.NoError(t, err)
+
+		for i := range int64(3) {
+			hdr := <-sub.C
+			require.Equal(t, 3+i, hdr.Height)
+		}
+
+		cancel()
+		for range sub.C { // drain until closed
+		}
+		require.ErrorIs(t, sub.Err(), context.Canceled)
+	})
+
+	t.Run("from height", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		cl := &Client{txClient: newChain()}
+		sub, err := cl.SubscribeBlocks(ctx, 1)
+		require.NoError(t, err)
+
+		for i := range int64(5) {
+			hdr := <-sub.C
+			require.Equal(t, 1+i, hdr.Height)
+		}
+	})
+
+	t.Run("transport error", func(t *testing.T) {
+		errUnavailable := errors.New("connection refused")
+		cl := &Client{txClient: &mockTxSvcClient{
+			blockHdr: func(context.Context, int64, time.Duration) (types.Hash, *types.BlockHeader, error) {
+				return types.Hash{}, nil, errUnavailable
+			},
+		}}
+
+		sub, err := cl.SubscribeBlocks(context.Background(), 1)
+		require.NoError(t, err)
+
+		_, ok := <-sub.C
+		require.False(t, ok)
+		require.ErrorIs(t, sub.Err(), errUnavailable)
+	})
+}
+
+func TestGetBlock(t *testing.T) {
+	leader, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+	other, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+
+	appHash := types.Hash{7, 7, 7}
+	newBlock := func(t *testing.T) *types.Block {
+		blk := types.NewBlock(3, types.Hash{1}, types.Hash{2}, types.Hash{3},
+			time.UnixMilli(1700000000000), [][]byte{[]byte("tx1"), []byte("tx2")})
+		require.NoError(t, blk.Sign(leader))
+		return blk
+	}
+	// serve returns a client of a node that reports the block with the hash
+	// of the block as it was signed, even if it was tampered with afterward.
+	serve := func(blk *types.Block, tamper func(*types.Block)) *Client {
+		hash := blk.Hash()
+		if tamper != nil {
+			tamper(blk)
+		}
+		return &Client{txClient: &mockTxSvcClient{
+			block: func(_ context.Context, height int64) (types.Hash, *types.Block, types.Hash, error) {
+				if height != blk.Header.Height && height > 0 {
+					return types.Hash{}, nil, types.Hash{}, rpcclient.ErrNotFound
+				}
+				return hash, blk, appHash, nil
+			},
+		}}
+	}
+	ctx := context.Background()
+
+	t.Run("valid", func(t *testing.T) {
+		cl := serve(newBlock(t), nil)
+		blk, gotAppHash, err := cl.GetBlock(ctx, 3)
+		require.NoError(t, err)
+		require.Equal(t, appHash, gotAppHash)
+		require.EqualValues(t, 3, blk.Header.Height)
+		require.NoError(t, VerifyBlock(blk, leader.Public()))
+
+		_, _, err = cl.GetBlock(ctx, 0) // latest
+		require.NoError(t, err)
+		_, _, err = cl.GetBlock(ctx, 4)
+		require.ErrorIs(t, err, rpcclient.ErrNotFound)
+	})
+
+	t.Run("wrong leader", func(t *testing.T) {
+		blk, _, err := serve(newBlock(t), nil).GetBlock(ctx, 3)
+		require.NoError(t, err)
+		err = VerifyBlock(blk, other.Public())
+		require.ErrorContains(t, err, "not signed by the leader")
+	})
+
+	t.Run("tampered header", func(t *testing.T) {
+		cl := serve(newBlock(t), func(blk *types.Block) {
+			blk.Header.Timestamp = blk.Header.Timestamp.Add(time.Second)
+		})
+		_, _, err := cl.GetBlock(ctx, 3)
+		require.ErrorContains(t, err, "block hash mismatch")
+	})
+
+	t.Run("tampered header and hash", func(t *testing.T) {
+		blk := newBlock(t)
+		blk.Header.Timestamp = blk.Header.Timestamp.Add(time.Second)
+		blk, _, err := serve(blk, nil).GetBlock(ctx, 3)
+		require.NoError(t, err) // consistent, but...
+		require.ErrorContains(t, VerifyBlock(blk, leader.Public()), "not signed by the leader")
+	})
+
+	t.Run("tampered transactions", func(t *testing.T) {
+		cl := serve(newBlock(t), func(blk *types.Block) {
+			blk.Txns[1] = []byte("tx3")
+		})
+		blk, _, err := cl.GetBlock(ctx, 3)
+		require.NoError(t, err) // the header is intact
+		require.ErrorContains(t, VerifyBlock(blk, leader.Public()), "merkle root mismatch")
+
+		blk.Txns = blk.Txns[:1]
+		require.ErrorContains(t, VerifyBlock(blk, leader.Public()), "transaction count mismatch")
+	})
+
+	t.Run("wrong height", func(t *testing.T) {
+		cl := &Client{txClient: &mockTxSvcClient{
+			block: func(context.Context, int64) (types.Hash, *types.Block, types.Hash, error) {
+				blk := newBlock(t)
+				return blk.Hash(), blk, appHash, nil
+			},
+		}}
+		_, _, err := cl.GetBlock(ctx, 5)
+		require.ErrorContains(t, err, "requested block 5, got block 3")
+	})
+}
+
+func TestExpectedNonceBroadcast(t *testing.T) {
+	const chainID = "kwil-test-chain"
+	privKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+	signer := auth.GetUserSigner(privKey)
+
+	// The mock node rejects a conditional broadcast if the account's confirmed
+	// nonce has advanced past the expected nonce, as another process sharing
+	// the account would cause.
+	var acctNonce int64
+	mock := &mockTxSvcClient{
+		health: healthyNode(chainID),
+		broadcast: func(_ context.Context, tx *types.Transaction, opts ...rpcclient.BroadcastOption) (types.Hash, error) {
+			bcastOpts := &rpcclient.BroadcastOpts{}
+			for _, opt := range opts {
+				opt(bcastOpts)
+			}
+			if bcastOpts.ExpectedNonce != nil && acctNonce > *bcastOpts.ExpectedNonce {
+				return types.Hash{}, types.ErrNonceRaced
+			}
+			return tx.Hash()
+		},
+	}
+
+	cl, err := WrapClient(context.Background(), mock, &clientType.Options{
+		Signer:  signer,
+		ChainID: chainID,
+	})
+	require.NoError(t, err)
+
+	execute := func(opts ...clientType.TxOpt) error {
+		opts = append(opts, clientType.WithFee(big.NewInt(0)))
+		_, err := cl.Execute(context.Background(), "dbid", "action", nil, opts...)
+		return err
+	}
+
+	t.Run("nonce unchanged", func(t *testing.T) {
+		acctNonce = 4
+		err := execute(clientType.WithNonce(5), clientType.WithExpectedNonce(4))
+		require.NoError(t, err)
+	})
+
+	t.Run("nonce advanced", func(t *testing.T) {
+		acctNonce = 5
+		err := execute(clientType.WithNonce(5), clientType.WithExpectedNonce(4))
+		require.ErrorIs(t, err, types.ErrNonceRaced)
+	})
+
+	t.Run("unconditional", func(t *testing.T) {
+		acctNonce = 5
+		err := execute(clientType.WithNonce(5))
+		require.NoError(t, err)
+	})
+}
+
+func TestTxGuardrails(t *testing.T) {
+	const chainID = "kwil-test-chain"
+	privKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+
+	var broadcasts int
+	mock := &mockTxSvcClient{
+		health: healthyNode(chainID),
+		estimate: func(context.Context, *types.Transaction) (*big.Int, error) {
+			return big.NewInt(500), nil
+		},
+		broadcast: func(_ context.Context, tx *types.Transaction, _ ...rpcclient.BroadcastOption) (types.Hash, error) {
+			broadcasts++
+			return tx.Hash()
+		},
+	}
+
+	var logs strings.Builder
+	cl, err := WrapClient(context.Background(), mock, &clientType.Options{
+		Signer:  auth.GetUserSigner(privKey),
+		ChainID: chainID,
+		Logger:  log.New(log.WithWriter(&logs), log.WithFormat(log.FormatUnstructured)),
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	bigArgs := [][]any{{strings.Repeat("x", 2000)}}
+
+	t.Run("over-size rejected locally", func(t *testing.T) {
+		broadcasts = 0
+		_, err := cl.Execute(ctx, "dbid", "action", bigArgs, clientType.WithMaxTxSize(1000))
+		require.ErrorIs(t, err, ErrTxTooLarge)
+		require.Zero(t, broadcasts)
+	})
+
+	t.Run("normal tx passes", func(t *testing.T) {
+		broadcasts = 0
+		_, err := cl.Execute(ctx, "dbid", "action", bigArgs, clientType.WithMaxTxSize(10000))
+		require.NoError(t, err)
+		_, err = cl.Execute(ctx, "dbid", "action", nil, clientType.WithMaxTxSize(1000))
+		require.NoError(t, err)
+		require.Equal(t, 2, broadcasts)
+		require.NotContains(t, logs.String(), "fee exceeds")
+	})
+
+	t.Run("fee warning", func(t *testing.T) {
+		broadcasts = 0
+		_, err := cl.Execute(ctx, "dbid", "action", nil, clientType.WithFeeWarning(big.NewInt(1000)))
+		require.NoError(t, err)
+		require.NotContains(t, logs.String(), "fee exceeds")
+
+		_, err = cl.Execute(ctx, "dbid", "action", nil, clientType.WithFeeWarning(big.NewInt(100)))
+		require.NoError(t, err)
+		require.Contains(t, logs.String(), "fee exceeds the warning threshold")
+		require.Equal(t, 2, broadcasts) // a warning does not block the broadcast
+	})
+}
+
+func TestNewClientNilLogger(t *testing.T) {
+	// The node is unhealthy and the client has no chain ID, so the client
+	// logs warnings while connecting.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req jsonrpc.Request
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		health := &types.Health{ChainInfo: types.ChainInfo{ChainID: "kwil-test-chain"}}
+		resp, err := jsonrpc.NewResponse(req.ID, health)
+		require.NoError(t, err)
+		require.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	defer srv.Close()
+
+	require.NotPanics(t, func() {
+		cl, err := NewClient(context.Background(), srv.URL, &clientType.Options{Logger: nil})
+		require.NoError(t, err)
+		require.Equal(t, "kwil-test-chain", cl.ChainID())
+	})
+
+	require.NotPanics(t, func() {
+		rpc := rpcclient.NewJSONRPCClient(&url.URL{Scheme: "http", Host: srv.Listener.Addr().String()},
+			rpcclient.WithLogger(nil))
+		var health types.Health
+		require.NoError(t, rpc.CallMethod(context.Background(), "user.health", struct{}{}, &health))
+	})
+}
+
+func TestListDatabasesDetailed(t *testing.T) {
+	owner := []byte("owner")
+	all := []*types.DatasetDetails{
+		{Name: "a", Owner: owner, DBID: "x01", Tables: 1, Actions: 2},
+		{Name: "b", Owner: owner, DBID: "x02", Tables: 3, Procedures: 1},
+		{Name: "c", Owner: owner, DBID: "x03"},
+	}
+
+	// The mock node serves pages of the datasets, like user.databases_detailed.
+	mock := &mockTxSvcClient{
+		listDBsDetailed: func(_ context.Context, o []byte, limit, offset int64) ([]*types.DatasetDetails, int64, error) {
+			require.Equal(t, owner, o)
+			page := all[min(offset, int64(len(all))):]
+			if limit > 0 && limit < int64(len(page)) {
+				page = page[:limit]
+			}
+			return page, int64(len(all)), nil
+		},
+	}
+	cl := &Client{txClient: mock}
+	ctx := context.Background()
+
+	t.Run("first page", func(t *testing.T) {
+		dbs, total, err := cl.ListDatabasesDetailed(ctx, owner, 2, 0)
+		require.NoError(t, err)
+		require.EqualValues(t, 3, total)
+		require.Equal(t, all[:2], dbs)
+		require.Equal(t, 3, dbs[1].Tables)
+		require.Equal(t, 1, dbs[1].Procedures)
+	})
+
+	t.Run("offset", func(t *testing.T) {
+		dbs, total, err := cl.ListDatabasesDetailed(ctx, owner, 2, 2)
+		require.NoError(t, err)
+		require.EqualValues(t, 3, total)
+		require.Equal(t, all[2:], dbs)
+
+		dbs, _, err = cl.ListDatabasesDetailed(ctx, owner, 2, 3)
+		require.NoError(t, err)
+		require.Empty(t, dbs)
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		dbs, _, err := cl.ListDatabasesDetailed(ctx, owner, 0, 1)
+		require.NoError(t, err)
+		require.Equal(t, all[1:], dbs)
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		_, _, err := cl.ListDatabasesDetailed(ctx, owner, -1, 0)
+		require.Error(t, err)
+		_, _, err = cl.ListDatabasesDetailed(ctx, owner, 1, -1)
+		require.Error(t, err)
+	})
+}
+
+func TestQueryBatch(t *testing.T) {
+	// The mock node answers a batch of queries with the query text as the
+	// only value of each result, and fails the query "fail".
+	var batches int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqs []*jsonrpc.Request
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&reqs))
+		batches++
+		resps := make([]*jsonrpc.Response, len(reqs))
+		for i, req := range reqs {
+			require.Equal(t, "user.query", req.Method)
+			var q userjson.QueryRequest
+			require.NoError(t, json.Unmarshal(req.Params, &q))
+			if q.Query == "fail" {
+				resps[i] = jsonrpc.NewErrorResponse(req.ID, jsonrpc.NewError(jsonrpc.ErrorEngineDatasetNotFound, "dataset not found", nil))
+				continue
+			}
+			res, err := json.Marshal([]map[string]any{{"dbid": q.DBID, "query": q.Query}})
+			require.NoError(t, err)
+			resps[i], err = jsonrpc.NewResponse(req.ID, &userjson.QueryResponse{Result: res})
+			require.NoError(t, err)
+		}
+		slices.Reverse(resps) // responses are matched by ID, not order
+		require.NoError(t, json.NewEncoder(w).Encode(resps))
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	cl := &Client{txClient: userClient.NewClient(u)}
+
+	records, errs, err := cl.QueryBatch(context.Background(), []*user.Query{
+		{DBID: "db1", Query: "SELECT 1"},
+		{DBID: "db2", Query: "fail"},
+		{DBID: "db1", Query: "SELECT 2"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, batches) // one round trip
+	require.Len(t, records, 3)
+	require.Len(t, errs, 3)
+
+	require.NoError(t, errs[0])
+	require.Equal(t, []map[string]any{{"dbid": "db1", "query": "SELECT 1"}}, records[0].Export())
+	require.ErrorIs(t, errs[1], rpcclient.ErrNotFound)
+	require.Nil(t, records[1])
+	require.NoError(t, errs[2])
+	require.Equal(t, []map[string]any{{"dbid": "db1", "query": "SELECT 2"}}, records[2].Export())
+}
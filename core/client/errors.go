@@ -0,0 +1,27 @@
+package client
+
+import (
+	rpcclient "github.com/kwilteam/kwil-db/core/rpc/client"
+)
+
+// The errors returned by a Client may be detected using errors.Is with the
+// following, regardless of the transport used to reach the node. The error
+// codes of the transport are mapped to them when the node responds.
+var (
+	// ErrNotFound is returned when a requested database, transaction, block,
+	// or other item does not exist.
+	ErrNotFound = rpcclient.ErrNotFound
+	// ErrUnauthorized is returned when the node or gateway requires
+	// authentication that the client did not provide.
+	ErrUnauthorized = rpcclient.ErrUnauthorized
+	// ErrTimeout is returned when a request was not completed in time.
+	ErrTimeout = rpcclient.ErrTimeout
+	// ErrChainMismatch is returned when the node or a transaction is for a
+	// different chain than the client's. It is matched by ErrTxChainID,
+	// ErrChainIDMismatch, and a BroadcastError for the wrong chain.
+	ErrChainMismatch = rpcclient.ErrChainMismatch
+)
+
+// BroadcastError is returned when a node rejects a transaction. Use errors.As
+// to get its result code.
+type BroadcastError = rpcclient.BroadcastError
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	rpcclient "github.com/kwilteam/kwil-db/core/rpc/client"
+	"github.com/kwilteam/kwil-db/core/rpc/client/user"
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+// ErrOffline is returned by the methods of an offline Client that require a
+// connection to a node.
+var ErrOffline = errors.New("client is offline: no transport to a node")
+
+// offlineTxSvc is the transport of an offline Client that was created without
+// one. Every method fails with ErrOffline.
+type offlineTxSvc struct{}
+
+var _ user.TxSvcClient = offlineTxSvc{}
+
+func (offlineTxSvc) Broadcast(context.Context, *types.Transaction, rpcclient.BroadcastWait, ...rpcclient.BroadcastOption) (types.Hash, error) {
+	return types.Hash{}, ErrOffline
+}
+
+func (offlineTxSvc) Call(context.Context, *types.CallMessage, ...rpcclient.ActionCallOption) ([]map[string]any, []string, error) {
+	return nil, nil, ErrOffline
+}
+
+func (offlineTxSvc) ChainInfo(context.Context) (*types.ChainInfo, error) {
+	return nil, ErrOffline
+}
+
+func (offlineTxSvc) EstimateCost(context.Context, *types.Transaction) (*big.Int, error) {
+	return nil, ErrOffline
+}
+
+func (offlineTxSvc) GetAccount(context.Context, []byte, types.AccountStatus) (*types.Account, error) {
+	return nil, ErrOffline
+}
+
+func (offlineTxSvc) AccountNonces(context.Context, []byte) (int64, int64, error) {
+	return 0, 0, ErrOffline
+}
+
+func (offlineTxSvc) GetSchema(context.Context, string) (*types.Schema, error) {
+	return nil, ErrOffline
+}
+
+func (offlineTxSvc) ListDatabases(context.Context, []byte) ([]*types.DatasetIdentifier, error) {
+	return nil, ErrOffline
+}
+
+func (offlineTxSvc) ListDatabasesDetailed(context.Context, []byte, int64, int64) ([]*types.DatasetDetails, int64, error) {
+	return nil, 0, ErrOffline
+}
+
+func (offlineTxSvc) QueryBatch(context.Context, []*user.Query) ([][]map[string]any, []error, error) {
+	return nil, nil, ErrOffline
+}
+
+func (offlineTxSvc) Ping(context.Context) (string, error) {
+	return "", ErrOffline
+}
+
+func (offlineTxSvc) Query(context.Context, string, string) ([]map[string]any, error) {
+	return nil, ErrOffline
+}
+
+func (offlineTxSvc) TxQuery(context.Context, types.Hash) (*types.TxQueryResponse, error) {
+	return nil, ErrOffline
+}
+
+func (offlineTxSvc) BlockHeader(context.Context, int64, time.Duration) (types.Hash, *types.BlockHeader, error) {
+	return types.Hash{}, nil, ErrOffline
+}
+
+func (offlineTxSvc) Block(context.Context, int64) (types.Hash, *types.Block, types.Hash, error) {
+	return types.Hash{}, nil, types.Hash{}, ErrOffline
+}
+
+func (offlineTxSvc) ListMigrations(context.Context) ([]*types.Migration, error) {
+	return nil, ErrOffline
+}
+
+func (offlineTxSvc) GenesisState(context.Context) (*types.MigrationMetadata, error) {
+	return nil, ErrOffline
+}
+
+func (offlineTxSvc) GenesisSnapshotChunk(context.Context, uint64, uint32) ([]byte, error) {
+	return nil, ErrOffline
+}
+
+func (offlineTxSvc) MigrationStatus(context.Context) (*types.MigrationState, error) {
+	return nil, ErrOffline
+}
+
+func (offlineTxSvc) LoadChangeset(context.Context, int64, int64) ([]byte, error) {
+	return nil, ErrOffline
+}
+
+func (offlineTxSvc) ChangesetMetadata(context.Context, int64) (int64, []int64, error) {
+	return 0, nil, ErrOffline
+}
+
+func (offlineTxSvc) Challenge(context.Context) ([]byte, error) {
+	return nil, ErrOffline
+}
+
+func (offlineTxSvc) Health(context.Context) (*types.Health, error) {
+	return nil, ErrOffline
+}
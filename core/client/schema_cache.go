@@ -0,0 +1,71 @@
+package client
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+// schemaCache is a concurrency-safe cache of schemas keyed by dbid. The
+// methods of a nil *schemaCache are no-ops, so a Client with caching disabled
+// need not check.
+type schemaCache struct {
+	ttl time.Duration
+	now func() time.Time // for testing
+
+	mtx     sync.Mutex
+	entries map[string]schemaCacheEntry
+}
+
+type schemaCacheEntry struct {
+	schema  *types.Schema
+	expires time.Time
+}
+
+func newSchemaCache(ttl time.Duration) *schemaCache {
+	return &schemaCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]schemaCacheEntry),
+	}
+}
+
+// get returns the cached schema for dbid if it has not expired.
+func (sc *schemaCache) get(dbid string) (*types.Schema, bool) {
+	if sc == nil {
+		return nil, false
+	}
+	sc.mtx.Lock()
+	defer sc.mtx.Unlock()
+	entry, ok := sc.entries[dbid]
+	if !ok {
+		return nil, false
+	}
+	if !sc.now().Before(entry.expires) {
+		delete(sc.entries, dbid)
+		return nil, false
+	}
+	return entry.schema, true
+}
+
+func (sc *schemaCache) put(dbid string, schema *types.Schema) {
+	if sc == nil {
+		return
+	}
+	sc.mtx.Lock()
+	defer sc.mtx.Unlock()
+	sc.entries[dbid] = schemaCacheEntry{
+		schema:  schema,
+		expires: sc.now().Add(sc.ttl),
+	}
+}
+
+func (sc *schemaCache) remove(dbid string) {
+	if sc == nil {
+		return
+	}
+	sc.mtx.Lock()
+	defer sc.mtx.Unlock()
+	delete(sc.entries, dbid)
+}
@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	clientType "github.com/kwilteam/kwil-db/core/client/types"
@@ -19,6 +20,14 @@ func (c *Client) NewSignedTx(ctx context.Context, data types.Payload, txOpts *cl
 	return c.newTx(ctx, data, txOpts)
 }
 
+// Broadcast broadcasts a signed transaction, such as one created with
+// NewSignedTx. The transaction is not broadcast if its chain ID is not the
+// client's chain ID. Only the TxOpts that apply to broadcasting, not creating,
+// a transaction are used.
+func (c *Client) Broadcast(ctx context.Context, tx *types.Transaction, opts ...clientType.TxOpt) (types.Hash, error) {
+	return c.broadcast(ctx, tx, clientType.GetTxOpts(opts))
+}
+
 // newTx creates a new Transaction signed by the Client's Signer
 func (c *Client) newTx(ctx context.Context, data types.Payload, txOpts *clientType.TxOptions) (*types.Transaction, error) {
 	if c.Signer == nil {
@@ -35,6 +44,9 @@ func (c *Client) newTx(ctx context.Context, data types.Payload, txOpts *clientTy
 		// Get the latest nonce for the account, if it exists.
 		acc, err := c.txClient.GetAccount(ctx, c.Signer.Identity(), types.AccountStatusPending)
 		if err != nil {
+			if errors.Is(err, ErrOffline) {
+				return nil, fmt.Errorf("a nonce must be specified to create a transaction offline: %w", err)
+			}
 			return nil, err
 		}
 
@@ -58,6 +70,9 @@ func (c *Client) newTx(ctx context.Context, data types.Payload, txOpts *clientTy
 	if price == nil {
 		price, err = c.txClient.EstimateCost(ctx, tx)
 		if err != nil {
+			if errors.Is(err, ErrOffline) {
+				return nil, fmt.Errorf("a fee must be specified to create a transaction offline: %w", err)
+			}
 			return nil, fmt.Errorf("failed to estimate price: %w", err)
 		}
 	}
@@ -20,6 +20,7 @@ type Client interface {
 	Call(ctx context.Context, dbid string, procedure string, inputs []any) (*CallResult, error)
 	ChainID() string
 	ChainInfo(ctx context.Context) (*types.ChainInfo, error)
+	BestHeight(ctx context.Context) (int64, error)
 	DeployDatabase(ctx context.Context, payload *types.Schema, opts ...TxOpt) (types.Hash, error)
 	DropDatabase(ctx context.Context, name string, opts ...TxOpt) (types.Hash, error)
 	DropDatabaseID(ctx context.Context, dbid string, opts ...TxOpt) (types.Hash, error)
@@ -41,3 +42,52 @@ type CallResult struct {
 	Records *Records `json:"records"`
 	Logs    []string `json:"logs,omitempty"`
 }
+
+// TxEstimate is a transaction that was built and signed, but not broadcast,
+// because the WithDryRun option was used.
+type TxEstimate struct {
+	// TxHash is the hash the transaction would have if it were broadcast.
+	TxHash types.Hash `json:"tx_hash"`
+	// Fee is the estimated fee, as set on the transaction.
+	Fee *big.Int `json:"fee"`
+	// Nonce is the nonce used for the transaction.
+	Nonce uint64 `json:"nonce"`
+}
+
+// ActionSignature describes how an action or procedure of a schema is called.
+type ActionSignature struct {
+	// Name is the name of the action or procedure.
+	Name string `json:"name"`
+	// Procedure is true for a procedure, and false for an action.
+	Procedure bool `json:"procedure"`
+	// Parameters are the parameters in the order they are passed.
+	Parameters []ActionParameter `json:"parameters"`
+	// Public is false if it may only be called by other actions or procedures.
+	Public bool `json:"public"`
+	// View is true if it does not modify the database, and may be used with
+	// Call rather than Execute.
+	View bool `json:"view"`
+	// Authenticated is true if the caller must be identified, so that a call
+	// must be signed.
+	Authenticated bool `json:"authenticated"`
+	// Owner is true if only the owner of the database may use it.
+	Owner bool `json:"owner"`
+}
+
+// ActionParameter is a parameter of an action or procedure.
+type ActionParameter struct {
+	Name string `json:"name"`
+	// Type is the type of a procedure parameter. Action parameters are not
+	// typed, so it is nil for an action.
+	Type *types.DataType `json:"type,omitempty"`
+}
+
+// DropResult is the result of a database drop that was confirmed with the
+// WithConfirm option.
+type DropResult struct {
+	// TxHash is the hash of the drop transaction.
+	TxHash types.Hash `json:"tx_hash"`
+	// Removed indicates that the database was confirmed to no longer exist
+	// after the transaction was included in a block.
+	Removed bool `json:"removed"`
+}
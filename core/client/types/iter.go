@@ -8,7 +8,8 @@ import (
 	jsonUtil "github.com/kwilteam/kwil-db/core/utils/json"
 )
 
-// Records providers an iterator over a set of records.
+// Records providers an iterator over a set of records. Records is not safe for
+// concurrent use, except for iterating with Rows.
 type Records struct {
 	// index tracks the current row index for the iterator.
 	index int
@@ -46,6 +47,46 @@ func NewRecordsFromMaps(recs []map[string]any) *Records {
 	return NewRecords(records)
 }
 
+// Len returns the number of records.
+func (r *Records) Len() int {
+	return len(r.records)
+}
+
+// Iterator returns a new iterator over the records, starting before the first
+// record. The iterator is independent of the Next and Record methods of
+// Records.
+func (r *Records) Iterator() *RecordIterator {
+	return &RecordIterator{
+		recs:  r,
+		index: -1,
+	}
+}
+
+// RecordIterator iterates over a Records. Use Next to advance to each record,
+// including the first, and Record to access it.
+type RecordIterator struct {
+	recs  *Records
+	index int
+}
+
+// Next steps to the next Record, returning false if there are no more records.
+func (it *RecordIterator) Next() bool {
+	if it.index < it.recs.Len() {
+		it.index++
+	}
+	return it.index < it.recs.Len()
+}
+
+// Record returns the current Record. It returns an empty Record if Next has
+// not been called, or if Next has returned false.
+func (it *RecordIterator) Record() Record {
+	if it.index < 0 || it.index >= it.recs.Len() {
+		return Record{}
+	}
+
+	return *it.recs.records[it.index]
+}
+
 // Next steps to the next Record, returning false if there are no more records.
 // Next must be used prior to accessing the first record with the Record method.
 func (r *Records) Next() bool {
@@ -82,8 +123,8 @@ func (r *Records) Export() []map[string]any {
 
 	records := make([]map[string]any, len(r.records))
 
-	for i, record := range r.records {
-		records[i] = *record
+	for i := range r.records {
+		records[i] = *r.records[i]
 	}
 
 	return records
@@ -98,8 +139,8 @@ func (r *Records) ExportString() []map[string]string {
 
 	records := make([]map[string]string, len(r.records))
 
-	for i, record := range r.records {
-		records[i] = record.String()
+	for i := range r.records {
+		records[i] = r.records[i].String()
 	}
 
 	return records
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordsIterator(t *testing.T) {
+	maps := []map[string]any{
+		{"id": int64(1), "name": "a"},
+		{"id": int64(2), "name": "b"},
+		{"id": int64(3), "name": "c"},
+		{"id": int64(4), "name": "d"},
+	}
+
+	t.Run("all rows in order", func(t *testing.T) {
+		recs := NewRecordsFromMaps(maps)
+		require.Equal(t, len(maps), recs.Len())
+
+		it := recs.Iterator()
+		var i int
+		for it.Next() {
+			require.Equal(t, Record(maps[i]), it.Record())
+			i++
+		}
+		require.Equal(t, len(maps), i)
+		require.False(t, it.Next())
+
+		// The eager accessors are unaffected.
+		require.Equal(t, maps, recs.Export())
+	})
+
+	t.Run("out of bounds", func(t *testing.T) {
+		recs := NewRecordsFromMaps(maps)
+
+		it := recs.Iterator()
+		require.Equal(t, Record{}, it.Record()) // before Next
+
+		for it.Next() {
+		}
+		require.Equal(t, Record{}, it.Record()) // after the last record
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		recs := NewRecordsFromMaps(nil)
+		require.Zero(t, recs.Len())
+		require.False(t, recs.Iterator().Next())
+		require.Empty(t, recs.Export())
+	})
+}
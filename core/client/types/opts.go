@@ -3,9 +3,11 @@ package client
 import (
 	"math/big"
 	"net/http"
+	"time"
 
 	"github.com/kwilteam/kwil-db/core/crypto/auth"
 	"github.com/kwilteam/kwil-db/core/log"
+	"github.com/kwilteam/kwil-db/core/types"
 )
 
 // Options are options that can be set for the client
@@ -34,6 +36,38 @@ type Options struct {
 	// Silence silences warnings logged from the client.
 	Silence bool
 
+	// DialAttempts is the number of times to try retrieving the remote node's
+	// chain info when creating the client, which is useful when the client is
+	// started alongside a node that may not yet be ready. The default is a
+	// single attempt.
+	DialAttempts int
+
+	// DialRetryDelay is the delay before the first retry of a failed attempt to
+	// retrieve the remote node's chain info. The delay doubles with each
+	// subsequent retry. This is only used if DialAttempts is more than one.
+	DialRetryDelay time.Duration
+
+	// SchemaCacheTTL enables an in-memory cache of the schemas returned by
+	// GetSchema, with entries expiring after this duration. Schemas deployed
+	// or dropped with the same client are evicted automatically; use
+	// InvalidateSchema for changes made by others. The cache is disabled if
+	// this is zero.
+	SchemaCacheTTL time.Duration
+
+	// Offline creates the client without contacting a node, so that
+	// transactions may be built and signed without a connection, such as on an
+	// air-gapped machine. ChainID is required since it cannot be retrieved
+	// from a node. Transactions must be created with an explicit nonce and
+	// fee, e.g. with NewSignedTx. If there is no transport to a node, methods
+	// that need one return an error.
+	Offline bool
+
+	// SchemaParser parses Kuneiform source into a schema for
+	// DeployDatabaseFromKuneiform. The parser is not built into the client to
+	// keep its dependencies small; use Parse from the
+	// github.com/kwilteam/kwil-db/parse module.
+	SchemaParser func(src []byte) (*types.Schema, error)
+
 	// Conn is the http client to use.
 	Conn *http.Client
 }
@@ -60,18 +94,38 @@ func (c *Options) Apply(opts *Options) {
 		c.Conn = opts.Conn
 	}
 
+	if opts.DialAttempts > 0 {
+		c.DialAttempts = opts.DialAttempts
+	}
+
+	if opts.DialRetryDelay > 0 {
+		c.DialRetryDelay = opts.DialRetryDelay
+	}
+
+	if opts.SchemaCacheTTL > 0 {
+		c.SchemaCacheTTL = opts.SchemaCacheTTL
+	}
+
+	if opts.SchemaParser != nil {
+		c.SchemaParser = opts.SchemaParser
+	}
+
 	c.SkipVerifyChainID = opts.SkipVerifyChainID
 
 	c.SkipHealthcheck = opts.SkipHealthcheck
 
 	c.Silence = opts.Silence
+
+	c.Offline = opts.Offline
 }
 
 // DefaultOptions returns the default options for the client.
 func DefaultOptions() *Options {
 	return &Options{
-		Logger: log.DiscardLogger,
-		Conn:   &http.Client{},
+		Logger:         log.DiscardLogger,
+		Conn:           &http.Client{},
+		DialAttempts:   1,
+		DialRetryDelay: time.Second,
 	}
 }
 
@@ -80,6 +134,17 @@ type TxOptions struct {
 	Fee   *big.Int
 
 	SyncBcast bool // wait for mining on broadcast
+
+	Confirm *DropResult // confirm a database drop, storing the result here
+
+	CheckOwner bool // check that a database to drop exists and is owned by the signer
+
+	ExpectedNonce *int64 // reject if the confirmed account nonce advanced past this
+
+	DryRun *TxEstimate // build and sign, but do not broadcast, storing the estimate here
+
+	MaxTxSize  int64    // reject a serialized transaction larger than this many bytes, if positive
+	FeeWarning *big.Int // log a warning if the fee exceeds this, if non-nil
 }
 
 func GetTxOpts(opts []TxOpt) *TxOptions {
@@ -115,3 +180,63 @@ func WithSyncBroadcast(wait bool) TxOpt {
 		o.SyncBcast = wait
 	}
 }
+
+// WithExpectedNonce makes the broadcast conditional on the sender's confirmed
+// account nonce being no greater than nonce. If another transaction from the
+// account was committed first, the broadcast fails with types.ErrNonceRaced,
+// and the caller may refetch the account nonce and retry. This is typically
+// used with WithNonce(nonce+1) when several processes share an account.
+func WithExpectedNonce(nonce int64) TxOpt {
+	return func(o *TxOptions) {
+		o.ExpectedNonce = &nonce
+	}
+}
+
+// WithConfirm indicates that a database drop should wait for the transaction
+// to be included in a block, and then verify that the database no longer
+// exists. The outcome is stored in res. This only applies to DropDatabase and
+// DropDatabaseID.
+func WithConfirm(res *DropResult) TxOpt {
+	return func(o *TxOptions) {
+		o.Confirm = res
+	}
+}
+
+// WithOwnerCheck indicates that before a database drop is broadcast, the node
+// should be queried to check that the database exists and is owned by the
+// signer, so that a mistyped name fails without sending a transaction. This
+// only applies to DropDatabase and DropDatabaseID, and requires a connection
+// to a node.
+func WithOwnerCheck() TxOpt {
+	return func(o *TxOptions) {
+		o.CheckOwner = true
+	}
+}
+
+// WithMaxTxSize makes the client reject a transaction whose serialized size
+// exceeds size bytes, before it is broadcast, rather than have the node reject
+// it. This may be used to catch unexpectedly large schemas or action
+// arguments. The error is ErrTxTooLarge.
+func WithMaxTxSize(size int64) TxOpt {
+	return func(o *TxOptions) {
+		o.MaxTxSize = size
+	}
+}
+
+// WithFeeWarning makes the client log a warning if the transaction's fee, which
+// is estimated unless set with WithFee, exceeds threshold. The transaction is
+// still broadcast. No warning is logged if the client's warnings are silenced.
+func WithFeeWarning(threshold *big.Int) TxOpt {
+	return func(o *TxOptions) {
+		o.FeeWarning = threshold
+	}
+}
+
+// WithDryRun indicates that the transaction should be built and signed with an
+// estimated fee, but not broadcast. The estimate is stored in res, and the
+// returned hash is that of the unsent transaction. A signer is still required.
+func WithDryRun(res *TxEstimate) TxOpt {
+	return func(o *TxOptions) {
+		o.DryRun = res
+	}
+}
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"reflect"
+	"slices"
+)
+
+// Rows returns an iterator over the records as Rows, which may be used with a
+// range loop in Go 1.23+. The function has the signature of iter.Seq[Row].
+//
+// Unlike Next, Rows does not move the index of the Records, so a Records that
+// is no longer modified may be iterated with Rows from several goroutines at
+// once.
+//
+// The columns of every Row are the columns of the first record sorted by name.
+// They are not in the order of the query's columns, since a Record is a map
+// and does not keep that order.
+func (r *Records) Rows() func(yield func(Row) bool) {
+	return func(yield func(Row) bool) {
+		var cols []string
+		for i := range r.Len() {
+			rec := r.rowRecord(i)
+			if i == 0 {
+				cols = recordColumns(rec)
+			}
+			if !yield(Row{cols: cols, rec: rec}) {
+				return
+			}
+		}
+	}
+}
+
+// rowRecord returns the record at index i, or an empty Record if it is nil.
+func (r *Records) rowRecord(i int) Record {
+	if r.records[i] == nil {
+		return Record{}
+	}
+	return *r.records[i]
+}
+
+// recordColumns returns the sorted column names of a record.
+func recordColumns(rec Record) []string {
+	cols := make([]string, 0, len(rec))
+	for col := range rec {
+		cols = append(cols, col)
+	}
+	slices.Sort(cols)
+	return cols
+}
+
+// Row is a single record with typed accessors for its columns. The typed
+// accessors convert values in the same way as Records.Scan, and return false
+// if the column is missing, the value is NULL, or the value cannot be
+// converted.
+type Row struct {
+	cols []string
+	rec  Record
+}
+
+// Columns returns the column names of the row. The order is the same for all
+// rows from the same Records.
+func (r Row) Columns() []string {
+	return r.cols
+}
+
+// Values returns the values of the row in the order of Columns. A column that
+// is missing from this row has a nil value.
+func (r Row) Values() []any {
+	vals := make([]any, len(r.cols))
+	for i, col := range r.cols {
+		vals[i] = r.rec[col]
+	}
+	return vals
+}
+
+// Value returns the value of a column without conversion.
+func (r Row) Value(col string) (any, bool) {
+	val, ok := r.rec[col]
+	return val, ok
+}
+
+// Int returns the value of a column as an int64. A numeric string is parsed.
+func (r Row) Int(col string) (int64, bool) {
+	return rowValue[int64](r, col)
+}
+
+// String returns the value of a column as a string. A []byte is converted
+// directly.
+func (r Row) String(col string) (string, bool) {
+	return rowValue[string](r, col)
+}
+
+// Bytes returns the value of a column as a []byte. A string is decoded as
+// base64, which is how binary values are returned by a node.
+func (r Row) Bytes(col string) ([]byte, bool) {
+	return rowValue[[]byte](r, col)
+}
+
+func rowValue[T any](r Row, col string) (T, bool) {
+	var out T
+	val, ok := r.rec[col]
+	if !ok || val == nil {
+		return out, false
+	}
+	if err := convertValue(val, reflect.ValueOf(&out).Elem()); err != nil {
+		return out, false
+	}
+	return out, true
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// collectRows gathers the rows from Records.Rows.
+func collectRows(recs *Records) []Row {
+	var rows []Row
+	recs.Rows()(func(row Row) bool {
+		rows = append(rows, row)
+		return true
+	})
+	return rows
+}
+
+func TestRecordsRows(t *testing.T) {
+	maps := []map[string]any{
+		{"id": int64(1), "name": "a", "data": "AQID"},
+		{"id": "2", "name": []byte("b"), "data": []byte{4, 5}},
+		{"id": nil, "name": int64(3)},
+	}
+
+	t.Run("columns and values", func(t *testing.T) {
+		rows := collectRows(NewRecordsFromMaps(maps))
+		require.Len(t, rows, 3)
+		for _, row := range rows {
+			require.Equal(t, []string{"data", "id", "name"}, row.Columns())
+		}
+		require.Equal(t, []any{"AQID", int64(1), "a"}, rows[0].Values())
+		require.Equal(t, []any{nil, nil, int64(3)}, rows[2].Values())
+	})
+
+	t.Run("type coercion", func(t *testing.T) {
+		rows := collectRows(NewRecordsFromMaps(maps))
+
+		id, ok := rows[0].Int("id")
+		require.True(t, ok)
+		require.Equal(t, int64(1), id)
+		id, ok = rows[1].Int("id") // numeric string
+		require.True(t, ok)
+		require.Equal(t, int64(2), id)
+
+		name, ok := rows[1].String("name") // []byte
+		require.True(t, ok)
+		require.Equal(t, "b", name)
+
+		data, ok := rows[0].Bytes("data") // base64
+		require.True(t, ok)
+		require.Equal(t, []byte{1, 2, 3}, data)
+		data, ok = rows[1].Bytes("data")
+		require.True(t, ok)
+		require.Equal(t, []byte{4, 5}, data)
+
+		_, ok = rows[0].Int("name") // not numeric
+		require.False(t, ok)
+		_, ok = rows[2].String("name") // int is not a string
+		require.False(t, ok)
+		_, ok = rows[2].Int("id") // NULL
+		require.False(t, ok)
+	})
+
+	t.Run("missing columns", func(t *testing.T) {
+		rows := collectRows(NewRecordsFromMaps(maps))
+		_, ok := rows[2].Bytes("data")
+		require.False(t, ok)
+		_, ok = rows[0].Value("nope")
+		require.False(t, ok)
+		_, ok = rows[0].String("nope")
+		require.False(t, ok)
+	})
+
+	t.Run("early termination", func(t *testing.T) {
+		var n int
+		NewRecordsFromMaps(maps).Rows()(func(Row) bool {
+			n++
+			return n < 2
+		})
+		require.Equal(t, 2, n)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		require.Empty(t, collectRows(NewRecordsFromMaps(nil)))
+		require.Empty(t, collectRows(NewRecordsFromMaps([]map[string]any{})))
+		require.Empty(t, collectRows(NewRecords(nil)))
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		recs := NewRecordsFromMaps(maps)
+		results := make([][]Row, 4)
+		var wg sync.WaitGroup
+		for i := range results {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i] = collectRows(recs)
+			}()
+		}
+		wg.Wait()
+
+		for _, rows := range results {
+			require.Len(t, rows, len(maps))
+		}
+	})
+}
@@ -0,0 +1,275 @@
+package client
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ScanOutputs converts the records into a slice of T, which must be a struct
+// type. See Records.Scan for how columns are mapped to the struct fields.
+func ScanOutputs[T any](recs *Records) ([]T, error) {
+	var out []T
+	if err := recs.Scan(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Scan converts the records into the slice pointed to by dest, which must be a
+// pointer to a slice of structs or of pointers to structs. The slice is
+// replaced with one element per record.
+//
+// Each exported struct field is populated from the column named by its kwil
+// tag, e.g. `kwil:"column_name"`, or from the column matching the field name
+// (case-insensitively) if it has no tag. A field tagged `kwil:"-"` is skipped.
+// Columns without a corresponding field are ignored, but a field without a
+// corresponding column is an error.
+//
+// Values are converted to the field type where this does not lose information,
+// such as int64 to int32 if in range, or a numeric string to an int. A []byte
+// field accepts a base64 string, which is how binary values are returned by a
+// node. A NULL value may only be scanned into a pointer, slice, map, or
+// interface field, which is then set to nil.
+func (r *Records) Scan(dest any) error {
+	ptr := reflect.ValueOf(dest)
+	if ptr.Kind() != reflect.Pointer || ptr.IsNil() || ptr.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("destination must be a non-nil pointer to a slice, got %T", dest)
+	}
+	slice := ptr.Elem()
+
+	elemType := slice.Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("destination slice element must be a struct or struct pointer, got %s", elemType)
+	}
+	fields := structColumns(structType)
+
+	out := reflect.MakeSlice(slice.Type(), r.Len(), r.Len())
+	for i := range r.Len() {
+		elem := out.Index(i)
+		if elemType.Kind() == reflect.Pointer {
+			elem.Set(reflect.New(structType))
+			elem = elem.Elem()
+		}
+
+		rec := *r.records[i]
+		for _, f := range fields {
+			val, ok := rec[f.column]
+			if !ok {
+				val, ok = rec[findColumn(rec, f.column)]
+			}
+			if !ok {
+				return fmt.Errorf("record %d: missing column %q for field %s", i, f.column, f.name)
+			}
+			if err := convertValue(val, elem.Field(f.index)); err != nil {
+				return fmt.Errorf("record %d: column %q: %w", i, f.column, err)
+			}
+		}
+	}
+
+	slice.Set(out)
+	return nil
+}
+
+// structColumn is a struct field and the column that populates it.
+type structColumn struct {
+	index  int
+	name   string
+	column string
+}
+
+// structColumns returns the fields of a struct type that are populated from
+// columns, with the column name from the kwil tag or the field name.
+func structColumns(t reflect.Type) []structColumn {
+	var fields []structColumn
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		column := f.Name
+		if tag, ok := f.Tag.Lookup("kwil"); ok {
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				column = tag
+			}
+		}
+		fields = append(fields, structColumn{index: i, name: f.Name, column: column})
+	}
+	return fields
+}
+
+// findColumn returns the column in the record that matches name
+// case-insensitively, or name if there is none.
+func findColumn(rec Record, name string) string {
+	for col := range rec {
+		if strings.EqualFold(col, name) {
+			return col
+		}
+	}
+	return name
+}
+
+// convertValue sets dst to val, converting it to the type of dst.
+func convertValue(val any, dst reflect.Value) error {
+	if val == nil {
+		switch dst.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+			dst.SetZero()
+			return nil
+		}
+		return fmt.Errorf("cannot scan NULL into %s", dst.Type())
+	}
+
+	if dst.Kind() == reflect.Pointer {
+		v := reflect.New(dst.Type().Elem())
+		if err := convertValue(val, v.Elem()); err != nil {
+			return err
+		}
+		dst.Set(v)
+		return nil
+	}
+
+	src := reflect.ValueOf(val)
+	if src.Type().AssignableTo(dst.Type()) {
+		dst.Set(src)
+		return nil
+	}
+
+	mismatch := fmt.Errorf("cannot convert %T to %s", val, dst.Type())
+
+	switch dst.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		switch {
+		case src.CanInt():
+			n = src.Int()
+		case src.CanUint():
+			u := src.Uint()
+			if u > 1<<63-1 {
+				return fmt.Errorf("value %d overflows %s", u, dst.Type())
+			}
+			n = int64(u)
+		case src.Kind() == reflect.String:
+			var err error
+			if n, err = strconv.ParseInt(src.String(), 10, 64); err != nil {
+				return errors.Join(mismatch, err)
+			}
+		default:
+			return mismatch
+		}
+		if dst.OverflowInt(n) {
+			return fmt.Errorf("value %d overflows %s", n, dst.Type())
+		}
+		dst.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n uint64
+		switch {
+		case src.CanUint():
+			n = src.Uint()
+		case src.CanInt():
+			i := src.Int()
+			if i < 0 {
+				return fmt.Errorf("value %d overflows %s", i, dst.Type())
+			}
+			n = uint64(i)
+		case src.Kind() == reflect.String:
+			var err error
+			if n, err = strconv.ParseUint(src.String(), 10, 64); err != nil {
+				return errors.Join(mismatch, err)
+			}
+		default:
+			return mismatch
+		}
+		if dst.OverflowUint(n) {
+			return fmt.Errorf("value %d overflows %s", n, dst.Type())
+		}
+		dst.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch {
+		case src.CanFloat():
+			f = src.Float()
+		case src.CanInt():
+			f = float64(src.Int())
+		case src.CanUint():
+			f = float64(src.Uint())
+		case src.Kind() == reflect.String:
+			var err error
+			if f, err = strconv.ParseFloat(src.String(), 64); err != nil {
+				return errors.Join(mismatch, err)
+			}
+		default:
+			return mismatch
+		}
+		dst.SetFloat(f)
+
+	case reflect.Bool:
+		switch src.Kind() {
+		case reflect.Bool:
+			dst.SetBool(src.Bool())
+		case reflect.String:
+			b, err := strconv.ParseBool(src.String())
+			if err != nil {
+				return errors.Join(mismatch, err)
+			}
+			dst.SetBool(b)
+		default:
+			return mismatch
+		}
+
+	case reflect.String:
+		switch v := val.(type) {
+		case string:
+			dst.SetString(v)
+		case []byte:
+			dst.SetString(string(v))
+		default:
+			return mismatch
+		}
+
+	case reflect.Slice:
+		if dst.Type().Elem().Kind() == reflect.Uint8 {
+			switch v := val.(type) {
+			case []byte:
+				dst.SetBytes(v)
+			case string:
+				b, err := base64.StdEncoding.DecodeString(v)
+				if err != nil {
+					return errors.Join(mismatch, err)
+				}
+				dst.SetBytes(b)
+			default:
+				return mismatch
+			}
+			return nil
+		}
+
+		if src.Kind() != reflect.Slice {
+			return mismatch
+		}
+		out := reflect.MakeSlice(dst.Type(), src.Len(), src.Len())
+		for i := range src.Len() {
+			if err := convertValue(src.Index(i).Interface(), out.Index(i)); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+		dst.Set(out)
+
+	default:
+		return mismatch
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanOutputs(t *testing.T) {
+	type user struct {
+		ID      int32   `kwil:"id"`
+		Name    string  `kwil:"name"`
+		Active  bool    `kwil:"is_active"`
+		Avatar  []byte  `kwil:"avatar"`
+		Bio     *string `kwil:"bio"`
+		Age     *int64  `kwil:"age"`
+		Country string  // no tag, uses the field name
+		Ignored string  `kwil:"-"`
+	}
+
+	bio := "hello"
+	age := int64(42)
+	avatar := []byte{0xde, 0xad, 0xbe, 0xef}
+
+	// values as decoded from a node's JSON response
+	maps := []map[string]any{
+		{
+			"id":        int64(1),
+			"name":      "alice",
+			"is_active": true,
+			"avatar":    base64.StdEncoding.EncodeToString(avatar),
+			"bio":       bio,
+			"age":       age,
+			"country":   "NZ",
+			"extra":     "ignored",
+		},
+		{
+			"id":        int64(2),
+			"name":      "bob",
+			"is_active": false,
+			"avatar":    nil,
+			"bio":       nil,
+			"age":       nil,
+			"Country":   "CA",
+		},
+	}
+
+	want := []user{
+		{ID: 1, Name: "alice", Active: true, Avatar: avatar, Bio: &bio, Age: &age, Country: "NZ"},
+		{ID: 2, Name: "bob", Country: "CA"},
+	}
+
+	t.Run("generic", func(t *testing.T) {
+		users, err := ScanOutputs[user](NewRecordsFromMaps(maps))
+		require.NoError(t, err)
+		require.Equal(t, want, users)
+	})
+
+	t.Run("scan into pointers", func(t *testing.T) {
+		var users []*user
+		err := NewRecordsFromMaps(maps).Scan(&users)
+		require.NoError(t, err)
+		require.Len(t, users, 2)
+		require.Equal(t, want[0], *users[0])
+		require.Equal(t, want[1], *users[1])
+	})
+
+	t.Run("numeric string", func(t *testing.T) {
+		type row struct {
+			N uint16 `kwil:"n"`
+		}
+		rows, err := ScanOutputs[row](NewRecordsFromMaps([]map[string]any{{"n": "65535"}}))
+		require.NoError(t, err)
+		require.Equal(t, []row{{N: 65535}}, rows)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		users, err := ScanOutputs[user](NewRecordsFromMaps(nil))
+		require.NoError(t, err)
+		require.Empty(t, users)
+	})
+
+	t.Run("missing column", func(t *testing.T) {
+		_, err := ScanOutputs[user](NewRecordsFromMaps([]map[string]any{{"id": int64(1)}}))
+		require.ErrorContains(t, err, `missing column "name"`)
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+		type row struct {
+			Active bool `kwil:"active"`
+		}
+		_, err := ScanOutputs[row](NewRecordsFromMaps([]map[string]any{{"active": int64(1)}}))
+		require.ErrorContains(t, err, `column "active": cannot convert int64 to bool`)
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		type row struct {
+			N int8 `kwil:"n"`
+		}
+		_, err := ScanOutputs[row](NewRecordsFromMaps([]map[string]any{{"n": int64(300)}}))
+		require.ErrorContains(t, err, "overflows int8")
+	})
+
+	t.Run("null into non-nullable", func(t *testing.T) {
+		type row struct {
+			N int64 `kwil:"n"`
+		}
+		_, err := ScanOutputs[row](NewRecordsFromMaps([]map[string]any{{"n": nil}}))
+		require.ErrorContains(t, err, "cannot scan NULL into int64")
+	})
+
+	t.Run("invalid destination", func(t *testing.T) {
+		var users []user
+		require.Error(t, NewRecordsFromMaps(maps).Scan(users))
+		var ints []int
+		require.Error(t, NewRecordsFromMaps(maps).Scan(&ints))
+	})
+}
@@ -244,6 +244,17 @@ type Ed25519Signer struct {
 
 var _ Signer = (*Ed25519Signer)(nil)
 
+// NewEd25519SignerFromSeed creates an Ed25519Signer from a 32 byte ed25519
+// seed. Transactions signed with it have the Ed25519Auth signature type, and
+// the sender is the ed25519 public key.
+func NewEd25519SignerFromSeed(seed []byte) (*Ed25519Signer, error) {
+	key, err := crypto.Ed25519PrivateKeyFromSeed(seed)
+	if err != nil {
+		return nil, err
+	}
+	return &Ed25519Signer{Ed25519PrivateKey: *key}, nil
+}
+
 // Sign signs the given message(not hashed) according to standard signature scheme.
 // It does not apply any special digests to the message.
 func (e *Ed25519Signer) Sign(msg []byte) (*Signature, error) {
@@ -160,3 +160,39 @@ func UnmarshalPrivateKey(data []byte, keyType KeyType) (PrivateKey, error) {
 		return nil, fmt.Errorf("invalid key type %v", keyType)
 	}
 }
+
+// The key type of a raw secp256k1 or ed25519 key may be determined from its
+// length, since the encodings of the two types have different sizes.
+const (
+	secp256k1PrivKeyLen       = 32
+	ed25519PrivKeyLen         = 64 // seed and public key
+	ed25519PubKeyLen          = 32
+	secp256k1PubKeyLen        = 33 // compressed
+	secp256k1PubKeyLenUncompr = 65
+)
+
+// ParsePrivateKey decodes the raw bytes of a private key of unknown type,
+// which may be a 32 byte secp256k1 key or a 64 byte ed25519 key.
+func ParsePrivateKey(data []byte) (PrivateKey, error) {
+	switch len(data) {
+	case secp256k1PrivKeyLen:
+		return UnmarshalSecp256k1PrivateKey(data)
+	case ed25519PrivKeyLen:
+		return UnmarshalEd25519PrivateKey(data)
+	default:
+		return nil, fmt.Errorf("invalid private key length %d", len(data))
+	}
+}
+
+// ParsePublicKey decodes the raw bytes of a public key of unknown type, which
+// may be a compressed or uncompressed secp256k1 key, or an ed25519 key.
+func ParsePublicKey(data []byte) (PublicKey, error) {
+	switch len(data) {
+	case secp256k1PubKeyLen, secp256k1PubKeyLenUncompr:
+		return UnmarshalSecp256k1PublicKey(data)
+	case ed25519PubKeyLen:
+		return UnmarshalEd25519PublicKey(data)
+	default:
+		return nil, fmt.Errorf("invalid public key length %d", len(data))
+	}
+}
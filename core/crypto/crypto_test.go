@@ -444,3 +444,36 @@ func TestHandlePanic(t *testing.T) {
 		})
 	}
 }
+
+func TestParseKey(t *testing.T) {
+	secpKey, _, err := GenerateSecp256k1Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edKey, _, err := GenerateEd25519Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []PrivateKey{secpKey, edKey} {
+		key, err := ParsePrivateKey(want.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !KeyEquals(key, want) {
+			t.Errorf("private key of type %v not decoded correctly", want.Type())
+		}
+		pub, err := ParsePublicKey(want.Public().Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !KeyEquals(pub, want.Public()) {
+			t.Errorf("public key of type %v not decoded correctly", want.Type())
+		}
+	}
+	if _, err = ParsePrivateKey(make([]byte, 48)); err == nil {
+		t.Error("expected error for invalid private key length")
+	}
+	if _, err = ParsePublicKey(make([]byte, 48)); err == nil {
+		t.Error("expected error for invalid public key length")
+	}
+}
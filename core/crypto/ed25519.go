@@ -153,3 +153,16 @@ func UnmarshalEd25519PrivateKey(data []byte) (*Ed25519PrivateKey, error) {
 		k: ed25519.PrivateKey(data),
 	}, nil
 }
+
+// Ed25519PrivateKeyFromSeed returns the private key for a 32 byte ed25519
+// seed, which is the form of the key that many wallets export. See RFC 8032.
+func Ed25519PrivateKeyFromSeed(seed []byte) (*Ed25519PrivateKey, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("expected ed25519 seed size to be %d, got %d",
+			ed25519.SeedSize, len(seed))
+	}
+
+	return &Ed25519PrivateKey{
+		k: ed25519.NewKeyFromSeed(seed),
+	}, nil
+}
@@ -168,3 +168,22 @@ func TestUnmarshalEd25519Keys(t *testing.T) {
 		t.Error("UnmarshalEd25519PrivateKey() should fail with invalid size")
 	}
 }
+
+func TestEd25519PrivateKeyFromSeed(t *testing.T) {
+	priv, _, _ := GenerateEd25519Key(rand.Reader)
+	seed := priv.Bytes()[:32] // the seed is the first half of the key
+
+	key, err := Ed25519PrivateKeyFromSeed(seed)
+	if err != nil {
+		t.Fatalf("Ed25519PrivateKeyFromSeed() error = %v", err)
+	}
+	if !priv.Equals(key) {
+		t.Error("private key from seed does not match original")
+	}
+
+	for _, size := range []int{0, 31, 33, 64} {
+		if _, err = Ed25519PrivateKeyFromSeed(make([]byte, size)); err == nil {
+			t.Errorf("Ed25519PrivateKeyFromSeed() should fail with seed size %d", size)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package admin
 
 import (
 	"context"
+	"math/big"
 
 	"github.com/kwilteam/kwil-db/core/types"
 	adminTypes "github.com/kwilteam/kwil-db/core/types/admin"
@@ -19,18 +20,65 @@ type AdminClient interface {
 	Status(ctx context.Context) (*adminTypes.Status, error)
 	Version(ctx context.Context) (string, error)
 	ListPendingJoins(ctx context.Context) ([]*types.JoinRequest, error)
+	// RotateKey makes the node generate a new key and request that it replace
+	// the node's validator key. It returns the transaction hash, the new
+	// public key, and the file on the node where the new private key was
+	// saved. The node rejects the request unless confirm is true.
+	RotateKey(ctx context.Context, confirm bool) (txHash types.Hash, newPubKey []byte, keyFile string, err error)
 
 	// GetConfig gets the current config from the node.
-	// It returns the config serialized as JSON.
+	// It returns the config serialized as TOML.
 	GetConfig(ctx context.Context) ([]byte, error)
 
+	// GetConfigJSON gets the current config from the node as canonical JSON,
+	// which may be diffed with that of other nodes.
+	GetConfigJSON(ctx context.Context) ([]byte, error)
+
+	// Shutdown requests a graceful shutdown of the node. It returns once the
+	// node has begun shutting down.
+	Shutdown(ctx context.Context) error
+	// ResetToHeight resets the node to the given height, which must not be
+	// above its best block. At the best block, any block that the node is
+	// executing is aborted. Below it, the node stops and removes the blocks
+	// above the height. The node rejects the request unless confirm is true.
+	ResetToHeight(ctx context.Context, height int64, confirm bool) error
+
 	AddPeer(ctx context.Context, peerID string) error
 	RemovePeer(ctx context.Context, peerID string) error
 	ListPeers(ctx context.Context) ([]string, error)
 
+	// ExportPeers gets all peers in the node's address book.
+	ExportPeers(ctx context.Context) ([]*adminTypes.KnownPeer, error)
+	// ImportPeers adds peers to the node's address book, skipping any that
+	// are already known. It returns the number of peers added.
+	ImportPeers(ctx context.Context, peers []*adminTypes.KnownPeer) (int, error)
+	// PeerProtocols lists all protocols supported by a peer, and the
+	// protocols required by the node that the peer does not support.
+	PeerProtocols(ctx context.Context, peerID string) (supported, missing []string, err error)
+	// ReloadAddrBook makes the node re-read its address book file, and
+	// returns the number of new peers that were added.
+	ReloadAddrBook(ctx context.Context) (int, error)
+	// PeerMetrics gets the node's peer connection statistics.
+	PeerMetrics(ctx context.Context) (*adminTypes.PeerMetrics, error)
+	// PendingDials lists the node's in-progress outbound dials to peers.
+	PendingDials(ctx context.Context) ([]*adminTypes.PendingDial, error)
+	// CancelDial cancels the node's in-progress dials to a peer, returning
+	// false if the peer was not being dialed.
+	CancelDial(ctx context.Context, peerID string) (bool, error)
+	// ReconnectPeer closes the node's connection to a peer and dials it again
+	// immediately, returning the peer's resulting connectedness.
+	ReconnectPeer(ctx context.Context, peerID string) (string, error)
+	// Protocols lists the protocols that the node requires of its peers, and
+	// all of the protocols that it supports.
+	Protocols(ctx context.Context) (required, supported []string, err error)
+
 	// Resolutions
 	CreateResolution(ctx context.Context, resolution []byte, resolutionType string) (types.Hash, error)
 	ApproveResolution(ctx context.Context, resolutionID *types.UUID) (types.Hash, error)
 	// DeleteResolution(ctx context.Context, resolutionID *types.UUID) (types.Hash, error)
 	ResolutionStatus(ctx context.Context, resolutionID *types.UUID) (*types.PendingResolution, error)
+
+	// Transfer transfers an amount from the node's account to another
+	// account, returning the hash of the broadcasted transaction.
+	Transfer(ctx context.Context, to []byte, amount *big.Int) (types.Hash, error)
 }
@@ -2,6 +2,7 @@ package adminclient
 
 import (
 	"context"
+	"math/big"
 	"net/url"
 	"time"
 
@@ -67,7 +68,8 @@ func (cl *Client) Join(ctx context.Context) (types.Hash, error) {
 }
 
 // JoinStatus returns the status of an active join request for the validator
-// identified by the public key.
+// identified by the public key. If there is no active request, the status of
+// the most recently resolved request is returned.
 func (cl *Client) JoinStatus(ctx context.Context, pubkey []byte) (*types.JoinRequest, error) {
 	cmd := &adminjson.JoinStatusRequest{
 		PubKey: pubkey,
@@ -136,6 +138,10 @@ func (cl *Client) Status(ctx context.Context) (*adminTypes.Status, error) {
 	if err != nil {
 		return nil, err
 	}
+	var startTime time.Time
+	if res.StartTime != 0 {
+		startTime = time.UnixMilli(res.StartTime)
+	}
 	// TODO: convert!
 	return &adminTypes.Status{
 		Node: res.Node,
@@ -151,6 +157,9 @@ func (cl *Client) Status(ctx context.Context) (*adminTypes.Status, error) {
 			PubKey: res.Validator.PubKey,
 			Power:  res.Validator.Power,
 		},
+		NumPeers:  res.NumPeers,
+		StartTime: startTime,
+		Uptime:    time.Duration(res.Uptime) * time.Millisecond,
 	}, nil
 }
 
@@ -177,7 +186,7 @@ func (cl *Client) ListPendingJoins(ctx context.Context) ([]*types.JoinRequest, e
 }
 
 // GetConfig gets the current config from the node.
-// It returns the config serialized as JSON.
+// It returns the config serialized as TOML.
 func (cl *Client) GetConfig(ctx context.Context) ([]byte, error) {
 	cmd := &adminjson.GetConfigRequest{}
 	res := &adminjson.GetConfigResponse{}
@@ -188,6 +197,58 @@ func (cl *Client) GetConfig(ctx context.Context) ([]byte, error) {
 	return res.Config, err
 }
 
+// GetConfigJSON gets the current config from the node as canonical JSON, with
+// sorted keys and stable formatting, so that the configs of nodes may be
+// diffed.
+func (cl *Client) GetConfigJSON(ctx context.Context) ([]byte, error) {
+	cmd := &adminjson.GetConfigJSONRequest{}
+	res := &adminjson.GetConfigJSONResponse{}
+	err := cl.CallMethod(ctx, string(adminjson.MethodConfigJSON), cmd, res)
+	if err != nil {
+		return nil, err
+	}
+	return res.Config, err
+}
+
+// Shutdown requests a graceful shutdown of the node. It returns once the node
+// has begun shutting down.
+func (cl *Client) Shutdown(ctx context.Context) error {
+	cmd := &adminjson.ShutdownRequest{}
+	res := &adminjson.ShutdownResponse{}
+	return cl.CallMethod(ctx, string(adminjson.MethodShutdown), cmd, res)
+}
+
+// ResetToHeight resets the node to the given height, which must not be above
+// its best block. At the best block, any block that the node is executing is
+// aborted. Below it, the node stops and removes the blocks above the height,
+// and its application state must be restored to the height before it is
+// restarted. The request is rejected by the node unless confirm is true.
+func (cl *Client) ResetToHeight(ctx context.Context, height int64, confirm bool) error {
+	cmd := &adminjson.ResetToHeightRequest{
+		Height:  height,
+		Confirm: confirm,
+	}
+	res := &adminjson.ResetToHeightResponse{}
+	return cl.CallMethod(ctx, string(adminjson.MethodResetToHeight), cmd, res)
+}
+
+// RotateKey makes the node generate a new key and broadcast a request to
+// replace its validator key with it. It returns the hash of the broadcasted
+// transaction, the new public key, and the file on the node where the new
+// private key was saved. The request is rejected by the node unless confirm is
+// true.
+func (cl *Client) RotateKey(ctx context.Context, confirm bool) (types.Hash, []byte, string, error) {
+	cmd := &adminjson.RotateKeyRequest{
+		Confirm: confirm,
+	}
+	res := &adminjson.RotateKeyResponse{}
+	err := cl.CallMethod(ctx, string(adminjson.MethodValRotateKey), cmd, res)
+	if err != nil {
+		return types.Hash{}, nil, "", err
+	}
+	return res.TxHash, res.NewPubKey, res.KeyFile, nil
+}
+
 // Ping just tests RPC connectivity. The expected response is "pong".
 func (cl *Client) Ping(ctx context.Context) (string, error) {
 	cmd := &userjson.PingRequest{
@@ -230,6 +291,124 @@ func (cl *Client) ListPeers(ctx context.Context) ([]string, error) {
 	return res.Peers, err
 }
 
+// ExportPeers gets all peers in the node's address book.
+func (cl *Client) ExportPeers(ctx context.Context) ([]*adminTypes.KnownPeer, error) {
+	cmd := &adminjson.ExportPeersRequest{}
+	res := &adminjson.ExportPeersResponse{}
+	err := cl.CallMethod(ctx, string(adminjson.MethodExportPeers), cmd, res)
+	if err != nil {
+		return nil, err
+	}
+	return res.Peers, nil
+}
+
+// ImportPeers adds peers to the node's address book. Peers that are already
+// known, or that are the node itself, are skipped. The number of peers added
+// is returned.
+func (cl *Client) ImportPeers(ctx context.Context, peers []*adminTypes.KnownPeer) (int, error) {
+	cmd := &adminjson.ImportPeersRequest{
+		Peers: peers,
+	}
+	res := &adminjson.ImportPeersResponse{}
+	err := cl.CallMethod(ctx, string(adminjson.MethodImportPeers), cmd, res)
+	if err != nil {
+		return 0, err
+	}
+	return res.Added, nil
+}
+
+// PeerMetrics gets the node's peer connection statistics, including the number
+// of connected and known peers, and counts of connection events.
+func (cl *Client) PeerMetrics(ctx context.Context) (*adminTypes.PeerMetrics, error) {
+	cmd := &adminjson.PeerMetricsRequest{}
+	res := &adminjson.PeerMetricsResponse{}
+	err := cl.CallMethod(ctx, string(adminjson.MethodPeerMetrics), cmd, res)
+	if err != nil {
+		return nil, err
+	}
+	return res.Metrics, nil
+}
+
+// PendingDials lists the node's outbound dials to peers that are in progress,
+// oldest first.
+func (cl *Client) PendingDials(ctx context.Context) ([]*adminTypes.PendingDial, error) {
+	cmd := &adminjson.PendingDialsRequest{}
+	res := &adminjson.PendingDialsResponse{}
+	err := cl.CallMethod(ctx, string(adminjson.MethodPendingDials), cmd, res)
+	if err != nil {
+		return nil, err
+	}
+	return res.Dials, nil
+}
+
+// CancelDial cancels the node's in-progress dials to a peer. A reconnection
+// attempt that is canceled is not retried. It returns false if the node was
+// not dialing the peer.
+func (cl *Client) CancelDial(ctx context.Context, peerID string) (bool, error) {
+	cmd := &adminjson.PeerRequest{
+		PeerID: peerID,
+	}
+	res := &adminjson.CancelDialResponse{}
+	err := cl.CallMethod(ctx, string(adminjson.MethodCancelDial), cmd, res)
+	if err != nil {
+		return false, err
+	}
+	return res.Canceled, nil
+}
+
+// ReconnectPeer closes the node's connection to a peer, if any, and dials it
+// again immediately. It returns the peer's resulting connectedness, such as
+// "Connected".
+func (cl *Client) ReconnectPeer(ctx context.Context, peerID string) (string, error) {
+	cmd := &adminjson.PeerRequest{
+		PeerID: peerID,
+	}
+	res := &adminjson.ReconnectPeerResponse{}
+	err := cl.CallMethod(ctx, string(adminjson.MethodReconnectPeer), cmd, res)
+	if err != nil {
+		return "", err
+	}
+	return res.Connectedness, nil
+}
+
+// Protocols lists the protocols that the node requires of its peers, and all
+// of the protocols that it supports.
+func (cl *Client) Protocols(ctx context.Context) (required, supported []string, err error) {
+	cmd := &adminjson.ProtocolsRequest{}
+	res := &adminjson.ProtocolsResponse{}
+	err = cl.CallMethod(ctx, string(adminjson.MethodProtocols), cmd, res)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res.Required, res.Supported, nil
+}
+
+// PeerProtocols lists all protocols supported by a peer, and the protocols
+// required by the node that the peer does not support.
+func (cl *Client) PeerProtocols(ctx context.Context, peerID string) (supported, missing []string, err error) {
+	cmd := &adminjson.PeerRequest{
+		PeerID: peerID,
+	}
+	res := &adminjson.PeerProtocolsResponse{}
+	err = cl.CallMethod(ctx, string(adminjson.MethodPeerProtocols), cmd, res)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res.Protocols, res.Missing, nil
+}
+
+// ReloadAddrBook makes the node re-read its address book file, and returns the
+// number of new peers that were added.
+func (cl *Client) ReloadAddrBook(ctx context.Context) (int, error) {
+	cmd := &adminjson.ReloadAddrBookRequest{}
+	res := &adminjson.ReloadAddrBookResponse{}
+	err := cl.CallMethod(ctx, string(adminjson.MethodReloadAddrBook), cmd, res)
+	if err != nil {
+		return 0, err
+	}
+	return res.Added, nil
+}
+
 // Create Resolution broadcasts a resolution to the network.
 func (cl *Client) CreateResolution(ctx context.Context, resolution []byte, resolutionType string) (types.Hash, error) {
 	cmd := &adminjson.CreateResolutionRequest{
@@ -281,3 +460,19 @@ func (cl *Client) ResolutionStatus(ctx context.Context, resolutionID *types.UUID
 	}
 	return res.Status, nil
 }
+
+// Transfer transfers an amount from the node's account to another account.
+// This may be used by an operator to fund accounts on a network without any
+// other source of tokens. The transaction hash is returned.
+func (cl *Client) Transfer(ctx context.Context, to []byte, amount *big.Int) (types.Hash, error) {
+	cmd := &adminjson.TransferRequest{
+		To:     to,
+		Amount: amount.String(),
+	}
+	res := &userjson.BroadcastResponse{}
+	err := cl.CallMethod(ctx, string(adminjson.MethodTransfer), cmd, res)
+	if err != nil {
+		return types.Hash{}, err
+	}
+	return res.TxHash, nil
+}
@@ -3,9 +3,13 @@ package client
 import (
 	"errors"
 	"fmt"
+
+	"github.com/kwilteam/kwil-db/core/types"
 )
 
-// The following errors may be detected by consumers using errors.Is.
+// The following errors may be detected by consumers using errors.Is. The
+// JSON-RPC error codes and HTTP statuses returned by a node or gateway are
+// mapped to them by the client, so that callers need not inspect the codes.
 var (
 	// ErrUnauthorized is returned when the client is not authenticated
 	// It is the equivalent of http status code 401
@@ -14,6 +18,12 @@ var (
 	ErrMethodNotFound = errors.New("method not found")
 	ErrInvalidRequest = errors.New("invalid request")
 	ErrNotAllowed     = errors.New("not allowed")
+	// ErrTimeout is returned when a request was not completed in time, either
+	// by the node or in transport.
+	ErrTimeout = errors.New("timeout")
+	// ErrChainMismatch is returned when a transaction or a node is for a
+	// different chain than expected.
+	ErrChainMismatch = errors.New("chain ID mismatch")
 )
 
 // RPCError is a common error type used by any RPC client implementation to
@@ -28,3 +38,43 @@ type RPCError struct {
 func (err RPCError) Error() string {
 	return fmt.Sprintf("err code = %d, msg = %v", err.Code, err.Msg)
 }
+
+// BroadcastError is the structured error returned by a client when a node
+// rejects a transaction, providing the transaction's result code so that the
+// caller may distinguish causes such as an invalid nonce or an insufficient
+// balance. It may be detected using errors.As. For the common codes, it also
+// wraps the corresponding error from the core/types package, such as
+// types.ErrInvalidNonce, which may be detected using errors.Is. A transaction
+// for the wrong chain is also an ErrChainMismatch.
+type BroadcastError struct {
+	TxCode  types.TxCode
+	Hash    string // may be empty if the node could not deserialize the tx
+	Message string
+}
+
+func (err *BroadcastError) Error() string {
+	return fmt.Sprintf("broadcast error: code = %d, hash = %s, msg = %s", err.TxCode, err.Hash, err.Message)
+}
+
+func (err *BroadcastError) Unwrap() error {
+	switch err.TxCode {
+	case types.CodeWrongChain:
+		return types.ErrWrongChain
+	case types.CodeInvalidNonce:
+		return types.ErrInvalidNonce
+	case types.CodeInvalidAmount:
+		return types.ErrInvalidAmount
+	case types.CodeInsufficientBalance:
+		return types.ErrInsufficientBalance
+	case types.CodeInsufficientFee:
+		return types.ErrInsufficientFee
+	}
+	return nil
+}
+
+// Is reports whether the error matches the client's own sentinels, such as
+// ErrChainMismatch for a transaction for the wrong chain. The core/types
+// errors are matched through Unwrap.
+func (err *BroadcastError) Is(target error) bool {
+	return target == ErrChainMismatch && err.TxCode == types.CodeWrongChain
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	jsonrpc "github.com/kwilteam/kwil-db/core/rpc/json"
+	userjson "github.com/kwilteam/kwil-db/core/rpc/json/user"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientErrorBroadcast(t *testing.T) {
+	// broadcastErr simulates a node's error response for a rejected tx.
+	broadcastErr := func(t *testing.T, code types.TxCode) *jsonrpc.Error {
+		data, err := json.Marshal(&userjson.BroadcastError{
+			TxCode:  uint32(code),
+			Hash:    "abcd",
+			Message: "rejected",
+		})
+		require.NoError(t, err)
+		return jsonrpc.NewError(jsonrpc.ErrorTxExecFailure, "broadcast error", data)
+	}
+
+	tests := []struct {
+		name     string
+		code     types.TxCode
+		sentinel error
+	}{
+		{"invalid nonce", types.CodeInvalidNonce, types.ErrInvalidNonce},
+		{"insufficient balance", types.CodeInsufficientBalance, types.ErrInsufficientBalance},
+		{"wrong chain", types.CodeWrongChain, types.ErrWrongChain},
+		{"insufficient fee", types.CodeInsufficientFee, types.ErrInsufficientFee},
+		{"other", types.CodeUnknownError, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := clientError(broadcastErr(t, tt.code))
+
+			var berr *BroadcastError
+			require.ErrorAs(t, err, &berr)
+			require.Equal(t, tt.code, berr.TxCode)
+			require.Equal(t, "abcd", berr.Hash)
+			require.Equal(t, "rejected", berr.Message)
+
+			if tt.sentinel != nil {
+				require.ErrorIs(t, err, tt.sentinel)
+			}
+
+			// The RPC error is still available.
+			var rpcErr *RPCError
+			require.ErrorAs(t, err, &rpcErr)
+			require.Equal(t, int32(jsonrpc.ErrorTxExecFailure), rpcErr.Code)
+		})
+	}
+
+	t.Run("no data", func(t *testing.T) {
+		err := clientError(jsonrpc.NewError(jsonrpc.ErrorTxExecFailure, "broadcast error", nil))
+		var berr *BroadcastError
+		require.False(t, errors.As(err, &berr))
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+		err := clientError(jsonrpc.NewError(jsonrpc.ErrorTxExecFailure, "broadcast error", []byte(`"oops"`)))
+		var berr *BroadcastError
+		require.False(t, errors.As(err, &berr))
+		var rpcErr *RPCError
+		require.ErrorAs(t, err, &rpcErr)
+	})
+}
+
+func TestClientErrorSentinels(t *testing.T) {
+	sentinels := []error{ErrNotFound, ErrUnauthorized, ErrMethodNotFound, ErrInvalidRequest,
+		ErrNotAllowed, ErrTimeout, ErrChainMismatch}
+
+	tests := []struct {
+		code     jsonrpc.ErrorCode
+		sentinel error // nil if none
+	}{
+		{jsonrpc.ErrorEngineDatasetNotFound, ErrNotFound},
+		{jsonrpc.ErrorTxNotFound, ErrNotFound},
+		{jsonrpc.ErrorBlockNotFound, ErrNotFound},
+		{jsonrpc.ErrorKGWNotFound, ErrNotFound},
+		{jsonrpc.ErrorUnauthorized, ErrUnauthorized},
+		{jsonrpc.ErrorKGWNotAuthorized, ErrUnauthorized},
+		{jsonrpc.ErrorUnknownMethod, ErrMethodNotFound},
+		{jsonrpc.ErrorInvalidParams, ErrInvalidRequest},
+		{jsonrpc.ErrorKGWNotAllowed, ErrNotAllowed},
+		{jsonrpc.ErrorNoQueryWithPrivateRPC, ErrNotAllowed},
+		{jsonrpc.ErrorTimeout, ErrTimeout},
+		{jsonrpc.ErrorInternal, nil},
+		{jsonrpc.ErrorEngineInternal, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.code), func(t *testing.T) {
+			err := clientError(jsonrpc.NewError(tt.code, "oops", nil))
+			for _, sentinel := range sentinels {
+				require.Equal(t, sentinel == tt.sentinel, errors.Is(err, sentinel), sentinel.Error())
+			}
+
+			var rpcErr *RPCError
+			require.ErrorAs(t, err, &rpcErr)
+			require.Equal(t, int32(tt.code), rpcErr.Code)
+		})
+	}
+
+	t.Run("wrong chain", func(t *testing.T) {
+		data, err := json.Marshal(&userjson.BroadcastError{TxCode: uint32(types.CodeWrongChain)})
+		require.NoError(t, err)
+		err = clientError(jsonrpc.NewError(jsonrpc.ErrorTxExecFailure, "broadcast error", data))
+		require.ErrorIs(t, err, ErrChainMismatch)
+		require.ErrorIs(t, err, types.ErrWrongChain)
+	})
+}
@@ -7,17 +7,23 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
 	"reflect"
 	"strconv"
 	"sync/atomic"
+	"time"
 
+	"github.com/kwilteam/kwil-db/core/crypto"
 	"github.com/kwilteam/kwil-db/core/log"
 	jsonrpc "github.com/kwilteam/kwil-db/core/rpc/json"
+	userjson "github.com/kwilteam/kwil-db/core/rpc/json/user"
+	"github.com/kwilteam/kwil-db/core/types"
 )
 
 // JSONRPCClient will use the commands to make certain requests
@@ -48,6 +54,7 @@ type JSONRPCClient struct {
 	log      log.Logger
 
 	basicAuthHdr string
+	signer       crypto.PrivateKey
 
 	reqID atomic.Uint64
 }
@@ -66,6 +73,9 @@ func NewJSONRPCClient(url *url.URL, opts ...RPCClientOpts) *JSONRPCClient {
 	for _, opt := range opts {
 		opt(clientOpts)
 	}
+	if clientOpts.keepAlive != nil {
+		clientOpts.client = keepAliveClient(clientOpts.client, clientOpts.keepAlive)
+	}
 
 	var basicAuthHdr string
 	if clientOpts.pass != "" { // user is ignored on server verification
@@ -77,6 +87,7 @@ func NewJSONRPCClient(url *url.URL, opts ...RPCClientOpts) *JSONRPCClient {
 		conn:         clientOpts.client,
 		log:          clientOpts.log,
 		basicAuthHdr: basicAuthHdr,
+		signer:       clientOpts.signer,
 	}
 }
 
@@ -86,11 +97,79 @@ type clientOptions struct {
 	client *http.Client
 	log    log.Logger
 	pass   string
+	signer crypto.PrivateKey
+
+	keepAlive *keepAlive
+}
+
+type keepAlive struct {
+	period      time.Duration
+	idleTimeout time.Duration
+}
+
+const (
+	// DefaultKeepAlivePeriod is the TCP keep-alive period used by WithKeepAlive
+	// if none is given.
+	DefaultKeepAlivePeriod = 15 * time.Second
+	// DefaultIdleConnTimeout is the idle connection timeout used by
+	// WithKeepAlive if none is given.
+	DefaultIdleConnTimeout = 60 * time.Second
+)
+
+// WithKeepAlive sets the TCP keep-alive period of the connections to the
+// server, so that a dead connection is detected promptly, and how long an idle
+// connection is kept for reuse, which should be less than the idle timeout of
+// any NAT or load balancer in between. A non-positive duration uses the
+// default. The HTTP client's transport is cloned and not modified, but a
+// client from WithHTTPClient that does not use an *http.Transport is used as
+// is.
+func WithKeepAlive(period, idleTimeout time.Duration) RPCClientOpts {
+	return func(c *clientOptions) {
+		if period <= 0 {
+			period = DefaultKeepAlivePeriod
+		}
+		if idleTimeout <= 0 {
+			idleTimeout = DefaultIdleConnTimeout
+		}
+		c.keepAlive = &keepAlive{period, idleTimeout}
+	}
+}
+
+// keepAliveClient returns a copy of the HTTP client with a transport that uses
+// the keep-alive settings.
+func keepAliveClient(client *http.Client, ka *keepAlive) *http.Client {
+	var tr *http.Transport
+	switch t := client.Transport.(type) {
+	case nil:
+		tr = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		tr = t.Clone()
+	default:
+		return client
+	}
+
+	tr.DialContext = ka.dialer().DialContext
+	tr.IdleConnTimeout = ka.idleTimeout
+
+	c := *client
+	c.Transport = tr
+	return &c
+}
+
+func (ka *keepAlive) dialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: ka.period,
+	}
 }
 
-func WithLogger(log log.Logger) RPCClientOpts {
+// WithLogger sets the client's logger. A nil logger discards all logs.
+func WithLogger(logger log.Logger) RPCClientOpts {
 	return func(c *clientOptions) {
-		c.log = log
+		if logger == nil {
+			logger = log.DiscardLogger
+		}
+		c.log = logger
 	}
 }
 
@@ -100,6 +179,14 @@ func WithPass(pass string) RPCClientOpts {
 	}
 }
 
+// WithSigner signs every request with the given key, for servers that require
+// authenticated requests.
+func WithSigner(signer crypto.PrivateKey) RPCClientOpts {
+	return func(c *clientOptions) {
+		c.signer = signer
+	}
+}
+
 func WithHTTPClient(client *http.Client) RPCClientOpts {
 	return func(c *clientOptions) {
 		c.client = client
@@ -137,35 +224,141 @@ func (cl *JSONRPCClient) CallMethod(ctx context.Context, method string, cmd, res
 		return err
 	}
 
+	resp := &jsonrpc.Response{}
+	httpErr, err := cl.post(ctx, request, resp)
+	if err != nil {
+		return err
+	}
+
+	return decodeResponse(resp, httpErr, res)
+}
+
+// BatchCall is one call of a batch request made with Batch. The Method, Params,
+// and Result are as for CallMethod.
+type BatchCall struct {
+	Method string
+	Params any
+	Result any
+}
+
+// Batch makes several JSON-RPC requests to the server in one http request. The
+// results are unmarshalled into the Result of each call, and the error of each
+// call is returned in the same order as the calls. The returned error is
+// non-nil if the batch as a whole failed, such as when the server does not
+// support batch requests.
+func (cl *JSONRPCClient) Batch(ctx context.Context, calls []BatchCall) ([]error, error) {
+	if len(calls) == 0 {
+		return nil, nil
+	}
+
+	reqs := make([]*jsonrpc.Request, len(calls))
+	idx := make(map[string]int, len(calls)) // request ID => call index
+	for i, call := range calls {
+		if rtp := reflect.TypeOf(call.Result); rtp == nil || rtp.Kind() != reflect.Ptr {
+			return nil, fmt.Errorf("result of call %d must be a pointer", i)
+		}
+		params, err := json.Marshal(call.Params)
+		if err != nil {
+			return nil, fmt.Errorf("call %d: %w", i, err)
+		}
+		id := cl.nextReqID()
+		reqs[i] = jsonrpc.NewRequest(id, call.Method, params)
+		idx[id] = i
+	}
+
+	request, err := json.Marshal(reqs)
+	if err != nil {
+		return nil, err
+	}
+
+	var body json.RawMessage
+	httpErr, err := cl.post(ctx, request, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	var resps []*jsonrpc.Response
+	if err = json.Unmarshal(body, &resps); err != nil {
+		// A single response instead of an array is an error for the whole
+		// batch, e.g. from a server that does not support batches.
+		resp := &jsonrpc.Response{}
+		if json.Unmarshal(body, resp) == nil && resp.Error != nil {
+			return nil, clientError(resp.Error)
+		}
+		if httpErr != nil {
+			return nil, httpErr
+		}
+		return nil, fmt.Errorf("invalid JSON-RPC batch response: %w", err)
+	}
+
+	errs := make([]error, len(calls))
+	done := make([]bool, len(calls))
+	for _, resp := range resps {
+		if resp == nil {
+			continue
+		}
+		id, _ := resp.ID.(string)
+		i, ok := idx[id]
+		if !ok || done[i] {
+			continue
+		}
+		done[i] = true
+		errs[i] = decodeResponse(resp, nil, calls[i].Result)
+	}
+	for i := range calls {
+		if !done[i] {
+			errs[i] = errors.New("no response in batch")
+		}
+	}
+
+	return errs, nil
+}
+
+// post sends a JSON-RPC request body to the server, and decodes the response
+// body into resp. Since the http status code is mostly ignored in favor of
+// structured errors in the response, an error based on it is returned as
+// httpErr for the caller to use if the response does not describe an error.
+func (cl *JSONRPCClient) post(ctx context.Context, request []byte, resp any) (httpErr, err error) {
 	// Build and perform the http request.
 	requestReader := bytes.NewReader(request)
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost,
 		cl.endpoint, requestReader)
 	if err != nil {
-		return fmt.Errorf("failed to construct new http request: %w", err)
+		return nil, fmt.Errorf("failed to construct new http request: %w", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
 	if cl.basicAuthHdr != "" {
 		httpReq.Header.Set("Authorization", cl.basicAuthHdr) // httpReq.SetBasicAuth("user", cl.pass)
 	}
+	if cl.signer != nil {
+		if err = cl.signRequest(httpReq, request); err != nil {
+			return nil, fmt.Errorf("failed to sign request: %w", err)
+		}
+	}
 
 	httpResponse, err := cl.conn.Do(httpReq)
 	if err != nil {
-		return fmt.Errorf("http post failed: %w", err)
+		if isTimeout(err) {
+			return nil, fmt.Errorf("http post failed: %w: %w", ErrTimeout, err)
+		}
+		return nil, fmt.Errorf("http post failed: %w", err)
 	}
 	defer httpResponse.Body.Close()
 
 	// For the most part we ignore the http status code in favor of structured
 	// errors in the response, but in case we cannot decode any response body,
 	// get an error based on the http status code.
-	var httpErr error
 	switch status := httpResponse.StatusCode; status {
 	case http.StatusOK: // expected with nil resp.Error
 	case http.StatusUnauthorized:
 		httpErr = ErrUnauthorized
 	case http.StatusNotFound:
 		httpErr = ErrNotFound
+	case http.StatusForbidden:
+		httpErr = ErrNotAllowed
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		httpErr = ErrTimeout
 	case http.StatusInternalServerError:
 		httpErr = errors.New("server error")
 	default:
@@ -174,15 +367,30 @@ func (cl *JSONRPCClient) CallMethod(ctx context.Context, method string, cmd, res
 		}
 	}
 
-	resp := &jsonrpc.Response{}
 	err = json.NewDecoder(httpResponse.Body).Decode(resp)
 	if err != nil {
 		if httpErr != nil {
-			return httpErr
+			return httpErr, httpErr
 		}
-		return fmt.Errorf("failed to decode response: %w", errors.Join(err, httpErr))
+		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	return httpErr, nil
+}
+
+// isTimeout reports whether an http request failed because its context
+// deadline or the client's timeout was exceeded.
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// decodeResponse returns the error of a JSON-RPC response, or unmarshals its
+// result into res.
+func decodeResponse(resp *jsonrpc.Response, httpErr error, res any) error {
 	if resp.Error != nil {
 		return clientError(resp.Error)
 	} // any not OK http status code should have
@@ -194,17 +402,27 @@ func (cl *JSONRPCClient) CallMethod(ctx context.Context, method string, cmd, res
 		return fmt.Errorf("invalid JSON-RPC response")
 	}
 
-	// if resp.ID != id {
-	// 	fmt.Printf("got id %v, expected %v\n", resp.ID, id)
-	// } // who cares, this is http post
-
-	if err = json.Unmarshal(resp.Result, res); err != nil {
+	if err := json.Unmarshal(resp.Result, res); err != nil {
 		return fmt.Errorf("failed to decode result as response: %w", errors.Join(err, httpErr))
 	}
 
 	return nil
 }
 
+// signRequest sets the signature headers of an http request with the given
+// body.
+func (cl *JSONRPCClient) signRequest(httpReq *http.Request, body []byte) error {
+	timestamp := time.Now().UnixMilli()
+	sig, err := cl.signer.Sign(jsonrpc.SignedRequestMessage(timestamp, body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set(jsonrpc.HeaderSigner, hex.EncodeToString(cl.signer.Public().Bytes()))
+	httpReq.Header.Set(jsonrpc.HeaderSignature, hex.EncodeToString(sig))
+	httpReq.Header.Set(jsonrpc.HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	return nil
+}
+
 // clientError joins a jsonrpc.Error with a client.RPCError and any appropriate
 // named error kind like ErrNotFound, ErrUnauthorized, etc. based on the code.
 func clientError(jsonRPCErr *jsonrpc.Error) error {
@@ -215,12 +433,32 @@ func clientError(jsonRPCErr *jsonrpc.Error) error {
 	err := errors.Join(jsonRPCErr, rpcErr)
 
 	switch jsonRPCErr.Code {
-	case jsonrpc.ErrorEngineDatasetNotFound, jsonrpc.ErrorTxNotFound, jsonrpc.ErrorValidatorNotFound:
+	case jsonrpc.ErrorEngineDatasetNotFound, jsonrpc.ErrorTxNotFound, jsonrpc.ErrorValidatorNotFound,
+		jsonrpc.ErrorBlockNotFound, jsonrpc.ErrorKGWNotFound:
 		return errors.Join(ErrNotFound, err)
 	case jsonrpc.ErrorUnknownMethod:
 		return errors.Join(ErrMethodNotFound, err)
-	// case jsonrpc.ErrorUnauthorized: // not yet used on server
-	// 	return errors.Join(client.ErrUnauthorized, err)
+	case jsonrpc.ErrorUnauthorized, jsonrpc.ErrorKGWNotAuthorized:
+		return errors.Join(ErrUnauthorized, err)
+	case jsonrpc.ErrorKGWNotAllowed, jsonrpc.ErrorKGWMethodNotAllowed, jsonrpc.ErrorNoQueryWithPrivateRPC:
+		return errors.Join(ErrNotAllowed, err)
+	case jsonrpc.ErrorInvalidRequest, jsonrpc.ErrorInvalidParams:
+		return errors.Join(ErrInvalidRequest, err)
+	case jsonrpc.ErrorTimeout:
+		return errors.Join(ErrTimeout, err)
+	case jsonrpc.ErrorTxExecFailure:
+		if len(jsonRPCErr.Data) == 0 {
+			break
+		}
+		var berr userjson.BroadcastError
+		if jsonErr := json.Unmarshal(jsonRPCErr.Data, &berr); jsonErr != nil {
+			return errors.Join(jsonErr, err)
+		}
+		return errors.Join(&BroadcastError{
+			TxCode:  types.TxCode(berr.TxCode),
+			Hash:    berr.Hash,
+			Message: berr.Message,
+		}, err)
 	// case jsonrpc.ErrorInvalidSignature: // or leave this to core/client.Client to detect and report
 	// 	return errors.Join(client.ErrInvalidSignature, err)
 	default:
@@ -0,0 +1,197 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	jsonrpc "github.com/kwilteam/kwil-db/core/rpc/json"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithKeepAlive(t *testing.T) {
+	opts := &clientOptions{client: &http.Client{}}
+	WithKeepAlive(5*time.Second, 20*time.Second)(opts)
+	require.Equal(t, 5*time.Second, opts.keepAlive.dialer().KeepAlive)
+	require.Equal(t, 20*time.Second, opts.keepAlive.idleTimeout)
+
+	t.Run("defaults", func(t *testing.T) {
+		opts := &clientOptions{}
+		WithKeepAlive(0, -1)(opts)
+		require.Equal(t, DefaultKeepAlivePeriod, opts.keepAlive.dialer().KeepAlive)
+		require.Equal(t, DefaultIdleConnTimeout, opts.keepAlive.idleTimeout)
+	})
+
+	t.Run("transport", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"jsonrpc":"2.0","id":"1","result":{"message":"pong"}}`))
+		}))
+		defer srv.Close()
+		u, err := url.Parse(srv.URL)
+		require.NoError(t, err)
+
+		httpClient := &http.Client{Transport: &http.Transport{MaxIdleConns: 3}}
+		cl := NewJSONRPCClient(u, WithHTTPClient(httpClient), WithKeepAlive(5*time.Second, 20*time.Second))
+
+		tr, ok := cl.conn.Transport.(*http.Transport)
+		require.True(t, ok)
+		require.Equal(t, 20*time.Second, tr.IdleConnTimeout)
+		require.Equal(t, 3, tr.MaxIdleConns)    // cloned
+		require.NotSame(t, httpClient, cl.conn) // not modified
+		require.Zero(t, httpClient.Transport.(*http.Transport).IdleConnTimeout)
+
+		var res struct {
+			Message string `json:"message"`
+		}
+		require.NoError(t, cl.CallMethod(context.Background(), "user.ping", struct{}{}, &res))
+		require.Equal(t, "pong", res.Message)
+	})
+
+	t.Run("other round tripper", func(t *testing.T) {
+		httpClient := &http.Client{Transport: roundTripperFunc(http.DefaultTransport.RoundTrip)}
+		cl := NewJSONRPCClient(&url.URL{}, WithHTTPClient(httpClient), WithKeepAlive(0, 0))
+		require.Same(t, httpClient, cl.conn)
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestBatch(t *testing.T) {
+	// newClient makes a client of a server that responds to a batch with the
+	// given function, or with a parse error like a server that does not
+	// support batches if it is nil.
+	newClient := func(t *testing.T, respond func([]*jsonrpc.Request) any) *JSONRPCClient {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var reqs []*jsonrpc.Request
+			if respond == nil || json.NewDecoder(r.Body).Decode(&reqs) != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(jsonrpc.NewErrorResponse(-1, jsonrpc.NewError(jsonrpc.ErrorParse, "invalid request", nil)))
+				return
+			}
+			json.NewEncoder(w).Encode(respond(reqs))
+		}))
+		t.Cleanup(srv.Close)
+		u, err := url.Parse(srv.URL)
+		require.NoError(t, err)
+		return NewJSONRPCClient(u)
+	}
+	ctx := context.Background()
+
+	t.Run("echo", func(t *testing.T) {
+		cl := newClient(t, func(reqs []*jsonrpc.Request) any {
+			resps := make([]*jsonrpc.Response, len(reqs))
+			for i, req := range reqs {
+				if req.Method == "fail" {
+					resps[i] = jsonrpc.NewErrorResponse(req.ID, jsonrpc.NewError(jsonrpc.ErrorUnknownMethod, "nope", nil))
+					continue
+				}
+				resps[i] = &jsonrpc.Response{JSONRPC: "2.0", ID: req.ID, Result: req.Params}
+			}
+			return resps
+		})
+		var a, b, c string
+		errs, err := cl.Batch(ctx, []BatchCall{
+			{Method: "echo", Params: "a", Result: &a},
+			{Method: "fail", Params: "b", Result: &b},
+			{Method: "echo", Params: "c", Result: &c},
+		})
+		require.NoError(t, err)
+		require.Len(t, errs, 3)
+		require.NoError(t, errs[0])
+		require.ErrorIs(t, errs[1], ErrMethodNotFound)
+		require.NoError(t, errs[2])
+		require.Equal(t, "a", a)
+		require.Empty(t, b)
+		require.Equal(t, "c", c)
+	})
+
+	t.Run("missing response", func(t *testing.T) {
+		cl := newClient(t, func(reqs []*jsonrpc.Request) any {
+			return []*jsonrpc.Response{{JSONRPC: "2.0", ID: reqs[1].ID, Result: reqs[1].Params}}
+		})
+		var a, b string
+		errs, err := cl.Batch(ctx, []BatchCall{
+			{Method: "echo", Params: "a", Result: &a},
+			{Method: "echo", Params: "b", Result: &b},
+		})
+		require.NoError(t, err)
+		require.Error(t, errs[0])
+		require.NoError(t, errs[1])
+		require.Equal(t, "b", b)
+	})
+
+	t.Run("not supported", func(t *testing.T) {
+		cl := newClient(t, nil)
+		var a string
+		_, err := cl.Batch(ctx, []BatchCall{{Method: "echo", Params: "a", Result: &a}})
+		var rpcErr *jsonrpc.Error
+		require.ErrorAs(t, err, &rpcErr)
+		require.Equal(t, jsonrpc.ErrorParse, rpcErr.Code)
+	})
+
+	t.Run("result not a pointer", func(t *testing.T) {
+		cl := newClient(t, nil)
+		_, err := cl.Batch(ctx, []BatchCall{{Method: "echo", Result: ""}})
+		require.Error(t, err)
+	})
+}
+
+func TestTransportErrors(t *testing.T) {
+	newClient := func(t *testing.T, handler http.HandlerFunc) *JSONRPCClient {
+		srv := httptest.NewServer(handler)
+		t.Cleanup(srv.Close)
+		u, err := url.Parse(srv.URL)
+		require.NoError(t, err)
+		return NewJSONRPCClient(u)
+	}
+
+	// HTTP statuses without a JSON-RPC response body
+	for _, tt := range []struct {
+		status   int
+		sentinel error
+	}{
+		{http.StatusUnauthorized, ErrUnauthorized},
+		{http.StatusForbidden, ErrNotAllowed},
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusRequestTimeout, ErrTimeout},
+		{http.StatusGatewayTimeout, ErrTimeout},
+	} {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			cl := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			})
+			err := cl.CallMethod(context.Background(), "user.ping", struct{}{}, &struct{}{})
+			require.ErrorIs(t, err, tt.sentinel)
+		})
+	}
+
+	t.Run("deadline", func(t *testing.T) {
+		release := make(chan struct{})
+		cl := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		})
+		t.Cleanup(func() { close(release) }) // before the server is closed
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		err := cl.CallMethod(ctx, "user.ping", struct{}{}, &struct{}{})
+		require.ErrorIs(t, err, ErrTimeout)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		cl := newClient(t, func(w http.ResponseWriter, r *http.Request) {})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := cl.CallMethod(ctx, "user.ping", struct{}{}, &struct{}{})
+		require.ErrorIs(t, err, context.Canceled)
+		require.NotErrorIs(t, err, ErrTimeout)
+	})
+}
@@ -28,6 +28,25 @@ func WithAuthCookie(cookie *http.Cookie) ActionCallOption {
 	}
 }
 
+// BroadcastOpts is the options for broadcasting a transaction.
+type BroadcastOpts struct {
+	// ExpectedNonce, if set, is the sender's expected confirmed account nonce.
+	// The node rejects the transaction with ErrNonceRaced if the account's
+	// confirmed nonce has advanced past it, such as when another process
+	// sharing the account has had a transaction committed.
+	ExpectedNonce *int64
+}
+
+type BroadcastOption func(*BroadcastOpts)
+
+// WithExpectedNonce makes a broadcast conditional on the sender's confirmed
+// account nonce not having advanced past nonce (compare-and-set).
+func WithExpectedNonce(nonce int64) BroadcastOption {
+	return func(opts *BroadcastOpts) {
+		opts.ExpectedNonce = &nonce
+	}
+}
+
 // BroadcastWait is an argument type that indicates how long to wait when
 // broadcasting a transaction. The levels are async (do not wait for mempool
 // acceptance), sync (wait for mempool acceptance), and commit (wait for it to
@@ -4,11 +4,11 @@ package jsonrpc
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
 	"net/url"
+	"time"
 
 	rpcclient "github.com/kwilteam/kwil-db/core/rpc/client"
 	"github.com/kwilteam/kwil-db/core/rpc/client/user"
@@ -45,35 +45,25 @@ func (cl *Client) Ping(ctx context.Context) (string, error) {
 	return res.Message, nil
 }
 
-func (cl *Client) Broadcast(ctx context.Context, tx *types.Transaction, sync rpcclient.BroadcastWait) (types.Hash, error) {
+func (cl *Client) Broadcast(ctx context.Context, tx *types.Transaction, sync rpcclient.BroadcastWait, opts ...rpcclient.BroadcastOption) (types.Hash, error) {
+	bcastOpts := &rpcclient.BroadcastOpts{}
+	for _, opt := range opts {
+		opt(bcastOpts)
+	}
+
 	cmd := &userjson.BroadcastRequest{
-		Tx:   tx,
-		Sync: (*userjson.BroadcastSync)(&sync),
+		Tx:            tx,
+		Sync:          (*userjson.BroadcastSync)(&sync),
+		ExpectedNonce: bcastOpts.ExpectedNonce,
 	}
 	res := &userjson.BroadcastResponse{}
 	err := cl.CallMethod(ctx, string(userjson.MethodBroadcast), cmd, res)
 	if err != nil {
 		var jsonRPCErr *jsonrpc.Error
-		if errors.As(err, &jsonRPCErr) && jsonRPCErr.Code == jsonrpc.ErrorTxExecFailure && len(jsonRPCErr.Data) > 0 {
-			var berr userjson.BroadcastError
-			jsonErr := json.Unmarshal(jsonRPCErr.Data, &berr)
-			if jsonErr != nil {
-				return types.Hash{}, errors.Join(jsonErr, err)
-			}
-
-			err = errors.Join(berr, err)
-
-			switch types.TxCode(berr.TxCode) {
-			case types.CodeWrongChain:
-				return types.Hash{}, errors.Join(types.ErrWrongChain, err)
-			case types.CodeInvalidNonce:
-				return types.Hash{}, errors.Join(types.ErrInvalidNonce, err)
-			case types.CodeInvalidAmount:
-				return types.Hash{}, errors.Join(types.ErrInvalidAmount, err)
-			case types.CodeInsufficientBalance:
-				return types.Hash{}, errors.Join(types.ErrInsufficientBalance, err)
-			}
+		if errors.As(err, &jsonRPCErr) && jsonRPCErr.Code == jsonrpc.ErrorTxNonceRaced {
+			return types.Hash{}, errors.Join(types.ErrNonceRaced, err)
 		}
+		// A rejected transaction is a *rpcclient.BroadcastError.
 		return types.Hash{}, err
 	}
 	return res.TxHash, nil
@@ -182,6 +172,24 @@ func (cl *Client) ListDatabases(ctx context.Context, ownerPubKey []byte) ([]*typ
 	return res.Databases, nil
 }
 
+// ListDatabasesDetailed lists a page of the databases belonging to an owner,
+// or of all databases if the owner is empty, ordered by DBID. A zero limit
+// returns all databases after the offset. The total number of matching
+// databases is also returned.
+func (cl *Client) ListDatabasesDetailed(ctx context.Context, ownerPubKey []byte, limit, offset int64) ([]*types.DatasetDetails, int64, error) {
+	cmd := &userjson.ListDatabasesDetailedRequest{
+		Owner:  ownerPubKey,
+		Limit:  limit,
+		Offset: offset,
+	}
+	res := &userjson.ListDatabasesDetailedResponse{}
+	err := cl.CallMethod(ctx, string(userjson.MethodDatabasesDetailed), cmd, res)
+	if err != nil {
+		return nil, 0, err
+	}
+	return res.Databases, res.Total, nil
+}
+
 func (cl *Client) Query(ctx context.Context, dbid, query string) ([]map[string]any, error) {
 	cmd := &userjson.QueryRequest{
 		DBID:  dbid,
@@ -195,6 +203,37 @@ func (cl *Client) Query(ctx context.Context, dbid, query string) ([]map[string]a
 	return jsonUtil.UnmarshalMapWithoutFloat[[]map[string]any](res.Result)
 }
 
+// QueryBatch runs several queries in one batch request. The results and errors
+// of each query are in the same order as the queries. The returned error is for
+// the batch as a whole.
+func (cl *Client) QueryBatch(ctx context.Context, queries []*user.Query) ([][]map[string]any, []error, error) {
+	calls := make([]rpcclient.BatchCall, len(queries))
+	for i, q := range queries {
+		calls[i] = rpcclient.BatchCall{
+			Method: string(userjson.MethodQuery),
+			Params: &userjson.QueryRequest{
+				DBID:  q.DBID,
+				Query: q.Query,
+			},
+			Result: &userjson.QueryResponse{},
+		}
+	}
+	errs, err := cl.Batch(ctx, calls)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results := make([][]map[string]any, len(queries))
+	for i, call := range calls {
+		if errs[i] != nil {
+			continue
+		}
+		res := call.Result.(*userjson.QueryResponse)
+		results[i], errs[i] = jsonUtil.UnmarshalMapWithoutFloat[[]map[string]any](res.Result)
+	}
+	return results, errs, nil
+}
+
 func (cl *Client) TxQuery(ctx context.Context, txHash types.Hash) (*types.TxQueryResponse, error) {
 	cmd := &userjson.TxQueryRequest{
 		TxHash: txHash,
@@ -208,6 +247,59 @@ func (cl *Client) TxQuery(ctx context.Context, txHash types.Hash) (*types.TxQuer
 	return res, nil
 }
 
+// AccountNonces gets the confirmed nonce of an account, and the highest nonce
+// of its unconfirmed transactions that follow it without a gap. The next
+// transaction from the account should use pending+1.
+func (cl *Client) AccountNonces(ctx context.Context, acctID []byte) (confirmed, pending int64, err error) {
+	cmd := &userjson.AccountNoncesRequest{
+		Identifier: acctID,
+	}
+	res := &userjson.AccountNoncesResponse{}
+	err = cl.CallMethod(ctx, string(userjson.MethodAccountNonces), cmd, res)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return res.Confirmed, res.Pending, nil
+}
+
+// BlockHeader gets the header of the block at the given height. If the block
+// does not exist yet, the server waits up to the wait duration for it before
+// returning an error satisfying errors.Is(err, rpcclient.ErrNotFound).
+func (cl *Client) BlockHeader(ctx context.Context, height int64, wait time.Duration) (types.Hash, *types.BlockHeader, error) {
+	cmd := &userjson.BlockHeaderRequest{
+		Height: height,
+		Wait:   wait.Milliseconds(),
+	}
+	res := &userjson.BlockHeaderResponse{}
+	err := cl.CallMethod(ctx, string(userjson.MethodBlockHeader), cmd, res)
+	if err != nil {
+		return types.Hash{}, nil, err
+	}
+
+	return res.Hash, res.Header, nil
+}
+
+// Block gets the block at the given height, with its hash and the app hash
+// after it was executed. If the block does not exist, the error satisfies
+// errors.Is(err, rpcclient.ErrNotFound).
+func (cl *Client) Block(ctx context.Context, height int64) (types.Hash, *types.Block, types.Hash, error) {
+	cmd := &userjson.BlockRequest{
+		Height: height,
+	}
+	res := &userjson.BlockResponse{}
+	err := cl.CallMethod(ctx, string(userjson.MethodBlock), cmd, res)
+	if err != nil {
+		return types.Hash{}, nil, types.Hash{}, err
+	}
+
+	blk, err := types.DecodeBlock(res.Block)
+	if err != nil {
+		return types.Hash{}, nil, types.Hash{}, fmt.Errorf("invalid block: %w", err)
+	}
+	return res.Hash, blk, res.AppHash, nil
+}
+
 // ListMigrations lists all migrations that have been proposed that are still in the pending state.
 func (cl *Client) ListMigrations(ctx context.Context) ([]*types.Migration, error) {
 	cmd := &userjson.ListMigrationsRequest{}
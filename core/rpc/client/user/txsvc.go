@@ -5,24 +5,38 @@ package user
 import (
 	"context"
 	"math/big"
+	"time"
 
 	"github.com/kwilteam/kwil-db/core/rpc/client"
 	"github.com/kwilteam/kwil-db/core/types"
 )
 
+// Query is a SQL query of a database, as used by TxSvcClient.QueryBatch.
+type Query struct {
+	DBID  string
+	Query string
+}
+
 // TxSvcClient is the interface for a txsvc client.
 // The txsvc is the main service for end users to interact with a Kwil network.
 type TxSvcClient interface {
-	Broadcast(ctx context.Context, tx *types.Transaction, sync client.BroadcastWait) (types.Hash, error)
+	Broadcast(ctx context.Context, tx *types.Transaction, sync client.BroadcastWait, opts ...client.BroadcastOption) (types.Hash, error)
 	Call(ctx context.Context, msg *types.CallMessage, opts ...client.ActionCallOption) ([]map[string]any, []string, error)
 	ChainInfo(ctx context.Context) (*types.ChainInfo, error)
 	EstimateCost(ctx context.Context, tx *types.Transaction) (*big.Int, error)
 	GetAccount(ctx context.Context, pubKey []byte, status types.AccountStatus) (*types.Account, error)
+	AccountNonces(ctx context.Context, acctID []byte) (confirmed, pending int64, err error)
 	GetSchema(ctx context.Context, dbid string) (*types.Schema, error)
 	ListDatabases(ctx context.Context, ownerPubKey []byte) ([]*types.DatasetIdentifier, error)
+	ListDatabasesDetailed(ctx context.Context, ownerPubKey []byte, limit, offset int64) ([]*types.DatasetDetails, int64, error)
 	Ping(ctx context.Context) (string, error)
 	Query(ctx context.Context, dbid string, query string) ([]map[string]any, error)
+	// QueryBatch runs several queries in one request. The results and errors
+	// of each query are in the same order as the queries.
+	QueryBatch(ctx context.Context, queries []*Query) ([][]map[string]any, []error, error)
 	TxQuery(ctx context.Context, txHash types.Hash) (*types.TxQueryResponse, error)
+	BlockHeader(ctx context.Context, height int64, wait time.Duration) (types.Hash, *types.BlockHeader, error)
+	Block(ctx context.Context, height int64) (types.Hash, *types.Block, types.Hash, error)
 
 	// Migration methods
 	ListMigrations(ctx context.Context) ([]*types.Migration, error)
@@ -2,11 +2,15 @@
 // and response objects.
 package adminjson
 
-import "github.com/kwilteam/kwil-db/core/types"
+import (
+	"github.com/kwilteam/kwil-db/core/types"
+	adminTypes "github.com/kwilteam/kwil-db/core/types/admin"
+)
 
 type StatusRequest struct{}
 type PeersRequest struct{}
 type GetConfigRequest struct{}
+type GetConfigJSONRequest struct{}
 type ApproveRequest struct {
 	PubKey []byte `json:"pubkey"`
 }
@@ -27,6 +31,38 @@ type PeerRequest struct {
 
 type ListPeersRequest struct{}
 
+type ExportPeersRequest struct{}
+
+type ImportPeersRequest struct {
+	Peers []*adminTypes.KnownPeer `json:"peers"`
+}
+
+type ReloadAddrBookRequest struct{}
+
+type PeerMetricsRequest struct{}
+
+type PendingDialsRequest struct{}
+
+type ProtocolsRequest struct{}
+
+type ShutdownRequest struct{}
+
+// ResetToHeightRequest asks the node to reset to Height, which must not be
+// above its best block. At the best block, the node aborts any block in
+// progress. Below it, the node stops and removes the blocks above Height. Confirm
+// must be true, as a guard against accidental resets.
+type ResetToHeightRequest struct {
+	Height  int64 `json:"height"`
+	Confirm bool  `json:"confirm"`
+}
+
+// RotateKeyRequest asks a validator to generate a new key and broadcast a
+// transaction to replace its validator key with it. Confirm must be true, as a
+// guard against accidental rotations.
+type RotateKeyRequest struct {
+	Confirm bool `json:"confirm"`
+}
+
 type CreateResolutionRequest struct {
 	Resolution     []byte `json:"resolution"`
 	ResolutionType string `json:"resolution_type"`
@@ -43,3 +79,9 @@ type ApproveResolutionRequest struct {
 type ResolutionStatusRequest struct {
 	ResolutionID *types.UUID `json:"resolution_id"` // Id is the resolution ID
 }
+
+// TransferRequest is a request to transfer an amount from the node's account.
+type TransferRequest struct {
+	To     []byte `json:"to"`
+	Amount string `json:"amount"` // base 10 integer
+}
@@ -10,6 +10,9 @@ const (
 	MethodStatus            jsonrpc.Method = "admin.status"
 	MethodPeers             jsonrpc.Method = "admin.peers"
 	MethodConfig            jsonrpc.Method = "admin.config"
+	MethodConfigJSON        jsonrpc.Method = "admin.config_json"
+	MethodShutdown          jsonrpc.Method = "admin.shutdown"
+	MethodResetToHeight     jsonrpc.Method = "admin.reset_to_height"
 	MethodValApprove        jsonrpc.Method = "admin.val_approve"
 	MethodValJoin           jsonrpc.Method = "admin.val_join"
 	MethodValRemove         jsonrpc.Method = "admin.val_remove"
@@ -17,11 +20,22 @@ const (
 	MethodValJoinStatus     jsonrpc.Method = "admin.val_join_status"
 	MethodValList           jsonrpc.Method = "admin.val_list"
 	MethodValListJoins      jsonrpc.Method = "admin.val_list_joins"
+	MethodValRotateKey      jsonrpc.Method = "admin.val_rotate_key"
 	MethodAddPeer           jsonrpc.Method = "admin.add_peer"
 	MethodRemovePeer        jsonrpc.Method = "admin.remove_peer"
 	MethodListPeers         jsonrpc.Method = "admin.list_peers"
+	MethodExportPeers       jsonrpc.Method = "admin.export_peers"
+	MethodImportPeers       jsonrpc.Method = "admin.import_peers"
+	MethodPeerProtocols     jsonrpc.Method = "admin.peer_protocols"
+	MethodReloadAddrBook    jsonrpc.Method = "admin.reload_addrbook"
+	MethodPeerMetrics       jsonrpc.Method = "admin.peer_metrics"
+	MethodPendingDials      jsonrpc.Method = "admin.pending_dials"
+	MethodCancelDial        jsonrpc.Method = "admin.cancel_dial"
+	MethodReconnectPeer     jsonrpc.Method = "admin.reconnect_peer"
+	MethodProtocols         jsonrpc.Method = "admin.protocols"
 	MethodCreateResolution  jsonrpc.Method = "admin.create_resolution"
 	MethodApproveResolution jsonrpc.Method = "admin.approve_resolution"
 	MethodResolutionStatus  jsonrpc.Method = "admin.resolution_status"
+	MethodTransfer          jsonrpc.Method = "admin.transfer"
 	// MethodDeleteResolution  jsonrpc.Method = "admin.delete_resolution"
 )
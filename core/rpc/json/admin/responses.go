@@ -12,6 +12,9 @@ type StatusResponse struct {
 	Sync      *SyncInfo             `json:"sync,omitempty"`
 	Validator *Validator            `json:"validator,omitempty"`
 	Migration *types.MigrationState `json:"migration,omitempty"`
+	NumPeers  int                   `json:"num_peers"`
+	StartTime int64                 `json:"start_time,omitempty"` // epoch *milliseconds*
+	Uptime    int64                 `json:"uptime,omitempty"`     // milliseconds
 }
 
 type NodeInfo = adminTypes.NodeInfo
@@ -88,6 +91,12 @@ type GetConfigResponse struct {
 	Config []byte `json:"config,omitempty"`
 }
 
+// GetConfigJSONResponse contains the node's config in a canonical JSON form,
+// with sorted keys and stable formatting, for comparison with other nodes.
+type GetConfigJSONResponse struct {
+	Config []byte `json:"config,omitempty"`
+}
+
 type PeerResponse struct{}
 
 // List of peers in the node's whitelist.
@@ -96,6 +105,78 @@ type ListPeersResponse struct {
 	Peers []string `json:"peers,omitempty"`
 }
 
+// ExportPeersResponse contains all peers in the node's address book.
+type ExportPeersResponse struct {
+	Peers []*adminTypes.KnownPeer `json:"peers"`
+}
+
+// ImportPeersResponse reports how many of the requested peers were added to
+// the node's address book, and how many were skipped because they were
+// already known or refer to the node itself.
+type ImportPeersResponse struct {
+	Added   int `json:"added"`
+	Skipped int `json:"skipped"`
+}
+
+// ShutdownResponse acknowledges that the node has begun a graceful shutdown.
+type ShutdownResponse struct {
+	ShuttingDown bool `json:"shutting_down"`
+}
+
+// ResetToHeightResponse reports the height that the node was reset to.
+type ResetToHeightResponse struct {
+	Height int64 `json:"height"`
+}
+
+// RotateKeyResponse contains the hash of the validator key update transaction,
+// the new public key, and the file where the new private key was saved.
+type RotateKeyResponse struct {
+	TxHash    types.Hash     `json:"tx_hash"`
+	NewPubKey types.HexBytes `json:"new_pubkey"`
+	KeyFile   string         `json:"key_file"`
+}
+
+// ReloadAddrBookResponse reports how many peers were added to the peer store
+// from the address book file.
+type ReloadAddrBookResponse struct {
+	Added int `json:"added"`
+}
+
+// PeerMetricsResponse contains the node's peer connection statistics.
+type PeerMetricsResponse struct {
+	Metrics *adminTypes.PeerMetrics `json:"metrics"`
+}
+
+// PendingDialsResponse lists the node's outbound dials that are in progress.
+type PendingDialsResponse struct {
+	Dials []*adminTypes.PendingDial `json:"dials"`
+}
+
+// CancelDialResponse reports if any dials to the peer were canceled.
+type CancelDialResponse struct {
+	Canceled bool `json:"canceled"`
+}
+
+// ReconnectPeerResponse reports the connectedness of a peer after it was
+// reconnected, e.g. "Connected" or "NotConnected".
+type ReconnectPeerResponse struct {
+	Connectedness string `json:"connectedness"`
+}
+
+// ProtocolsResponse lists the protocols that the node requires of its peers,
+// and all of the protocols that it supports.
+type ProtocolsResponse struct {
+	Required  []string `json:"required"`
+	Supported []string `json:"supported"`
+}
+
+// PeerProtocolsResponse lists the protocols supported by a peer, and which of
+// the protocols required by the node the peer does not support.
+type PeerProtocolsResponse struct {
+	Protocols []string `json:"protocols"`
+	Missing   []string `json:"missing"`
+}
+
 type ResolutionStatusResponse struct {
 	Status *types.PendingResolution `json:"status,omitempty"`
 }
@@ -0,0 +1,26 @@
+package jsonrpc
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// HTTP headers used to sign a JSON-RPC request. The signer is the hex-encoded
+// public key of the signing key, the signature is the hex-encoded signature of
+// the message returned by SignedRequestMessage, and the timestamp is the time
+// of signing in unix milliseconds.
+const (
+	HeaderSigner    = "X-Kwil-Signer"
+	HeaderSignature = "X-Kwil-Signature"
+	HeaderTimestamp = "X-Kwil-Timestamp"
+)
+
+// SignedRequestMessage returns the message that is signed to authenticate a
+// JSON-RPC request. It commits to the signing time and the entire HTTP request
+// body, which includes the method, params, and ID.
+func SignedRequestMessage(timestamp int64, body []byte) []byte {
+	bodyHash := sha256.Sum256(body)
+	msg := make([]byte, 8, 8+len(bodyHash))
+	binary.BigEndian.PutUint64(msg, uint64(timestamp))
+	return append(msg, bodyHash[:]...)
+}
@@ -16,6 +16,7 @@ const (
 	// error, but a result structure fails to encode to JSON.
 	ErrorResultEncoding ErrorCode = -32000
 	ErrorTimeout        ErrorCode = -32001
+	ErrorUnauthorized   ErrorCode = -32002 // the request is not signed by an authorized key
 
 	// Application errors get the rest of the code space.
 
@@ -23,6 +24,7 @@ const (
 	ErrorTxExecFailure    ErrorCode = -201 // txCode != transactions.CodeOk
 	ErrorTxNotFound       ErrorCode = -202 // abci.ErrTxNotFound
 	ErrorTxPayloadInvalid ErrorCode = -203
+	ErrorTxNonceRaced     ErrorCode = -204 // account nonce advanced past the expected nonce
 
 	ErrorEngineInternal        ErrorCode = -300
 	ErrorEngineDatasetNotFound ErrorCode = -301
@@ -36,7 +38,8 @@ const (
 	ErrorIdentInternal ErrorCode = -600
 	ErrorIdentInvalid  ErrorCode = -601
 
-	ErrorNodeInternal ErrorCode = -700
+	ErrorNodeInternal  ErrorCode = -700
+	ErrorBlockNotFound ErrorCode = -701
 
 	ErrorValidatorsInternal ErrorCode = -800
 	ErrorValidatorNotFound  ErrorCode = -801
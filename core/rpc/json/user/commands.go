@@ -30,6 +30,12 @@ type AccountRequest struct {
 	Status     *AccountStatus `json:"status,omitempty" desc:"blockchain status (confirmed or unconfirmed)"` // Mapped to URL query parameter `status`.
 }
 
+// AccountNoncesRequest contains the request parameters for
+// MethodAccountNonces.
+type AccountNoncesRequest struct {
+	Identifier types.HexBytes `json:"identifier" desc:"account identifier"`
+}
+
 // AccountStatus is the type used to enumerate the different account status
 // options recognized in AccountRequest.
 type AccountStatus = types.AccountStatus
@@ -43,9 +49,12 @@ var (
 )
 
 // BroadcastRequest contains the request parameters for MethodBroadcast.
+// If ExpectedNonce is set, the transaction is rejected with ErrorTxNonceRaced
+// if the sender's confirmed account nonce has advanced past it.
 type BroadcastRequest struct {
-	Tx   *types.Transaction `json:"tx"`
-	Sync *BroadcastSync     `json:"sync,omitempty"`
+	Tx            *types.Transaction `json:"tx"`
+	Sync          *BroadcastSync     `json:"sync,omitempty"`
+	ExpectedNonce *int64             `json:"expected_nonce,omitempty"`
 }
 
 // BroadcastSync is the type used to enumerate the broadcast request
@@ -76,6 +85,15 @@ type ListDatabasesRequest struct {
 	Owner types.HexBytes `json:"owner,omitempty"`
 }
 
+// ListDatabasesDetailedRequest contains the request parameters for
+// MethodDatabasesDetailed. The databases are ordered by DBID, and Offset and
+// Limit select a page of them. A zero Limit returns all databases after Offset.
+type ListDatabasesDetailedRequest struct {
+	Owner  types.HexBytes `json:"owner,omitempty" desc:"owner identifier, or all owners if empty"`
+	Limit  int64          `json:"limit,omitempty" desc:"maximum number of databases to return, or all if zero"`
+	Offset int64          `json:"offset,omitempty" desc:"number of databases to skip"`
+}
+
 // PingRequest contains the request parameters for MethodPing.
 type PingRequest struct {
 	Message string `json:"message"`
@@ -97,6 +115,21 @@ type TxQueryRequest struct {
 	TxHash types.Hash `json:"tx_hash"`
 }
 
+// BlockHeaderRequest contains the request parameters for MethodBlockHeader.
+// If the block at Height does not yet exist, the server waits up to Wait
+// milliseconds for it to be committed before responding with
+// ErrorBlockNotFound. A Height <= 0 requests the latest block.
+type BlockHeaderRequest struct {
+	Height int64 `json:"height"`
+	Wait   int64 `json:"wait,omitempty"`
+}
+
+// BlockRequest contains the request parameters for MethodBlock. A Height <= 0
+// requests the latest block.
+type BlockRequest struct {
+	Height int64 `json:"height"`
+}
+
 // LoadChangesetsRequest contains the request parameters for MethodLoadChangesets.
 type ChangesetMetadataRequest struct {
 	Height int64 `json:"height"`
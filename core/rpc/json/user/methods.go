@@ -8,12 +8,16 @@ const (
 	MethodPing                  jsonrpc.Method = "user.ping"
 	MethodChainInfo             jsonrpc.Method = "user.chain_info"
 	MethodAccount               jsonrpc.Method = "user.account"
+	MethodAccountNonces         jsonrpc.Method = "user.account_nonces"
 	MethodBroadcast             jsonrpc.Method = "user.broadcast"
 	MethodCall                  jsonrpc.Method = "user.call"
 	MethodDatabases             jsonrpc.Method = "user.databases"
+	MethodDatabasesDetailed     jsonrpc.Method = "user.databases_detailed"
 	MethodPrice                 jsonrpc.Method = "user.estimate_price"
 	MethodQuery                 jsonrpc.Method = "user.query"
 	MethodTxQuery               jsonrpc.Method = "user.tx_query"
+	MethodBlockHeader           jsonrpc.Method = "user.block_header"
+	MethodBlock                 jsonrpc.Method = "user.block"
 	MethodSchema                jsonrpc.Method = "user.schema"
 	MethodMigrationStatus       jsonrpc.Method = "user.migration_status"
 	MethodListMigrations        jsonrpc.Method = "user.list_migrations"
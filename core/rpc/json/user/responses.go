@@ -23,6 +23,15 @@ type AccountResponse struct {
 	Nonce      int64          `json:"nonce"`
 }
 
+// AccountNoncesResponse contains the response object for MethodAccountNonces.
+// Confirmed is the nonce of the account's last transaction in a block, and
+// Pending is the highest nonce of its transactions in mempool that follow
+// Confirmed without a gap. The next transaction should use Pending+1.
+type AccountNoncesResponse struct {
+	Confirmed int64 `json:"confirmed"`
+	Pending   int64 `json:"pending"`
+}
+
 // BroadcastResponse contains the response object for MethodBroadcast.
 type BroadcastResponse struct {
 	TxHash types.Hash `json:"tx_hash,omitempty"`
@@ -62,6 +71,14 @@ type ListDatabasesResponse struct {
 // SchemaResponse contains the response object for MethodSchema.
 type DatasetInfo = types.DatasetIdentifier
 
+// ListDatabasesDetailedResponse contains the response object for
+// MethodDatabasesDetailed. Total is the number of matching databases, not
+// only those in this page.
+type ListDatabasesDetailedResponse struct {
+	Databases []*types.DatasetDetails `json:"databases"`
+	Total     int64                   `json:"total"`
+}
+
 // SchemaResponse contains the response object for MethodSchema.
 type PingResponse struct {
 	Message string `json:"message,omitempty"`
@@ -75,6 +92,20 @@ type EstimatePriceResponse struct {
 // TxQueryResponse contains the response object for MethodTxQuery.
 type TxQueryResponse = types.TxQueryResponse
 
+// BlockHeaderResponse contains the response object for MethodBlockHeader.
+type BlockHeaderResponse struct {
+	Hash   types.Hash         `json:"hash"`
+	Header *types.BlockHeader `json:"header"`
+}
+
+// BlockResponse contains the response object for MethodBlock. The Block is the
+// full binary serialization of the block, as with types.EncodeBlock.
+type BlockResponse struct {
+	Hash    types.Hash `json:"hash"`
+	Block   []byte     `json:"block"`
+	AppHash types.Hash `json:"app_hash"`
+}
+
 type ChangesetsResponse struct {
 	Changesets []byte `json:"changesets"`
 }
@@ -42,6 +42,9 @@ type Status struct {
 	Node      *NodeInfo      `json:"node"`
 	Sync      *SyncInfo      `json:"sync"`
 	Validator *ValidatorInfo `json:"validator"`
+	NumPeers  int            `json:"num_peers"`
+	StartTime time.Time      `json:"start_time"`
+	Uptime    time.Duration  `json:"uptime"`
 }
 
 // PeerInfo describes a connected peer node.
@@ -49,6 +52,44 @@ type PeerInfo struct {
 	NodeInfo   *NodeInfo `json:"node"`
 	Inbound    bool      `json:"inbound"`
 	RemoteAddr string    `json:"remote_addr"`
+	// ConnectedAt is the unix epoch *milliseconds* when the oldest open
+	// connection to the peer was opened, or zero if it is not known.
+	ConnectedAt int64 `json:"connected_at,omitempty"`
+	// ConnAge is the *milliseconds* since ConnectedAt when the peers were
+	// listed, which helps spot a peer with a flapping connection.
+	ConnAge int64 `json:"conn_age,omitempty"`
+}
+
+// KnownPeer describes a peer in the node's address book, which may or may not
+// be connected. The JSON encoding is compatible with the node's address book
+// file format.
+type KnownPeer struct {
+	ID     string   `json:"id"`
+	Addrs  []string `json:"addrs"`
+	Protos []string `json:"protos"`
+	// LastSeen is the unix epoch *seconds* when the peer was last known to be
+	// connected, or zero if it has not been seen.
+	LastSeen int64 `json:"last_seen,omitempty"`
+}
+
+// PeerMetrics is a snapshot of a node's peer connection statistics. The event
+// counts are totals since the node started.
+type PeerMetrics struct {
+	ConnectedPeers    int    `json:"connected_peers"`
+	KnownPeers        int    `json:"known_peers"`
+	Connects          uint64 `json:"connects"`
+	Disconnects       uint64 `json:"disconnects"`
+	ReconnectAttempts uint64 `json:"reconnect_attempts"`
+	FailedDials       uint64 `json:"failed_dials"`
+	Evictions         uint64 `json:"evictions"`        // stale peers removed from the address book
+	DeadConnections   uint64 `json:"dead_connections"` // closed after repeated failed pings
+}
+
+// PendingDial is an outbound dial to a peer that is in progress.
+type PendingDial struct {
+	PeerID string `json:"peer_id"`
+	// Started is the unix epoch *milliseconds* when the dial began.
+	Started int64 `json:"started"`
 }
 
 type MigrationInfo struct {
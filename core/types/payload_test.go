@@ -3,6 +3,7 @@ package types_test
 import (
 	"testing"
 
+	"github.com/kwilteam/kwil-db/core/crypto"
 	"github.com/kwilteam/kwil-db/core/types"
 
 	"github.com/stretchr/testify/assert"
@@ -59,3 +60,39 @@ func TestMarshalUnmarshalPayload(t *testing.T) {
 
 	assert.Equal(t, tp.val, tp2.val)
 }
+
+func TestValidatorKeyUpdate(t *testing.T) {
+	oldKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+	newKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+	oldPubKey := oldKey.Public().Bytes()
+
+	update, err := types.NewValidatorKeyUpdate(oldPubKey, newKey)
+	require.NoError(t, err)
+	require.Equal(t, newKey.Public().Bytes(), update.NewPubKey)
+	require.Equal(t, uint32(crypto.KeyTypeSecp256k1), update.KeyType)
+	require.NoError(t, update.Verify(oldPubKey))
+
+	data, err := update.MarshalBinary()
+	require.NoError(t, err)
+	payload, err := types.UnmarshalPayload(types.PayloadTypeValidatorKeyUpdate, data)
+	require.NoError(t, err)
+	require.Equal(t, update, payload)
+	require.True(t, types.PayloadTypeValidatorKeyUpdate.Valid())
+
+	// The signature is only for the update of the old key.
+	otherKey, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	require.Error(t, update.Verify(otherKey.Public().Bytes()))
+
+	// The new key must have signed it.
+	forged, err := types.NewValidatorKeyUpdate(oldPubKey, otherKey)
+	require.NoError(t, err)
+	forged.NewPubKey = update.NewPubKey
+	forged.KeyType = update.KeyType
+	require.Error(t, forged.Verify(oldPubKey))
+
+	update.KeyType = 9
+	require.Error(t, update.Verify(oldPubKey))
+}
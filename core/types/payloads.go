@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"strconv"
 
+	"github.com/kwilteam/kwil-db/core/crypto"
 	"github.com/kwilteam/kwil-db/core/types/decimal"
 	"github.com/kwilteam/kwil-db/core/types/serialize"
 )
@@ -36,6 +37,7 @@ const (
 	PayloadTypeValidatorJoin       PayloadType = "validator_join"
 	PayloadTypeValidatorLeave      PayloadType = "validator_leave"
 	PayloadTypeValidatorRemove     PayloadType = "validator_remove"
+	PayloadTypeValidatorKeyUpdate  PayloadType = "validator_key_update"
 	PayloadTypeValidatorApprove    PayloadType = "validator_approve"
 	PayloadTypeValidatorVoteIDs    PayloadType = "validator_vote_ids"
 	PayloadTypeValidatorVoteBodies PayloadType = "validator_vote_bodies"
@@ -54,6 +56,7 @@ var payloadConcreteTypes = map[PayloadType]Payload{
 	PayloadTypeValidatorApprove:    &ValidatorApprove{},
 	PayloadTypeValidatorRemove:     &ValidatorRemove{},
 	PayloadTypeValidatorLeave:      &ValidatorLeave{},
+	PayloadTypeValidatorKeyUpdate:  &ValidatorKeyUpdate{},
 	PayloadTypeTransfer:            &Transfer{},
 	PayloadTypeValidatorVoteIDs:    &ValidatorVoteIDs{},
 	PayloadTypeValidatorVoteBodies: &ValidatorVoteBodies{},
@@ -96,6 +99,7 @@ var payloadTypes = map[PayloadType]bool{
 	PayloadTypeValidatorJoin:       true,
 	PayloadTypeValidatorLeave:      true,
 	PayloadTypeValidatorRemove:     true,
+	PayloadTypeValidatorKeyUpdate:  true,
 	PayloadTypeValidatorApprove:    true,
 	PayloadTypeValidatorVoteIDs:    true,
 	PayloadTypeValidatorVoteBodies: true,
@@ -113,6 +117,7 @@ func (p PayloadType) Valid() bool {
 		PayloadTypeValidatorApprove,
 		PayloadTypeValidatorRemove,
 		PayloadTypeValidatorLeave,
+		PayloadTypeValidatorKeyUpdate,
 		PayloadTypeTransfer,
 		PayloadTypeCreateResolution,
 		PayloadTypeApproveResolution,
@@ -574,6 +579,68 @@ func (v *ValidatorLeave) MarshalBinary() ([]byte, error) {
 	return serialize.Encode(v)
 }
 
+// ValidatorKeyUpdate requests that the sending validator's key be replaced by
+// a new key with the same power. The other validators must approve the
+// request. Signature is the new key's signature of the sender's current key,
+// which proves that the sender has the new key.
+type ValidatorKeyUpdate struct {
+	NewPubKey []byte
+	KeyType   uint32 // a crypto.KeyType
+	Signature []byte
+}
+
+// NewValidatorKeyUpdate creates the payload to replace the validator key
+// oldPubKey with newKey, signing it with newKey.
+func NewValidatorKeyUpdate(oldPubKey []byte, newKey crypto.PrivateKey) (*ValidatorKeyUpdate, error) {
+	sig, err := newKey.Sign(validatorKeyUpdateMsg(oldPubKey))
+	if err != nil {
+		return nil, err
+	}
+	return &ValidatorKeyUpdate{
+		NewPubKey: newKey.Public().Bytes(),
+		KeyType:   uint32(newKey.Type()),
+		Signature: sig,
+	}, nil
+}
+
+// validatorKeyUpdateMsg is the message signed by the new key of a
+// ValidatorKeyUpdate.
+func validatorKeyUpdateMsg(oldPubKey []byte) []byte {
+	return append([]byte("kwil validator key update:"), oldPubKey...)
+}
+
+// Verify checks that the new key signed the update of oldPubKey, which should
+// be the sender of the transaction.
+func (v *ValidatorKeyUpdate) Verify(oldPubKey []byte) error {
+	newPubKey, err := crypto.UnmarshalPublicKey(v.NewPubKey, crypto.KeyType(v.KeyType))
+	if err != nil {
+		return fmt.Errorf("invalid new key: %w", err)
+	}
+	ok, err := newPubKey.Verify(validatorKeyUpdateMsg(oldPubKey), v.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	if !ok {
+		return errors.New("signature does not verify with the new key")
+	}
+	return nil
+}
+
+func (v *ValidatorKeyUpdate) Type() PayloadType {
+	return PayloadTypeValidatorKeyUpdate
+}
+
+var _ encoding.BinaryUnmarshaler = (*ValidatorKeyUpdate)(nil)
+var _ encoding.BinaryMarshaler = (*ValidatorKeyUpdate)(nil)
+
+func (v *ValidatorKeyUpdate) UnmarshalBinary(b []byte) error {
+	return serialize.Decode(b, v)
+}
+
+func (v *ValidatorKeyUpdate) MarshalBinary() ([]byte, error) {
+	return serialize.Encode(v)
+}
+
 // in the future, if/when we go to implement voting based on token weight (instead of validatorship),
 // we will create identical payloads as the VoteIDs and VoteBodies payloads, but with different types
 
@@ -1,8 +1,10 @@
 package types
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"math"
 )
 
@@ -19,6 +21,7 @@ const (
 	CodeInsufficientFee     TxCode = 7
 	CodeInvalidAmount       TxCode = 8
 	CodeInvalidSender       TxCode = 9
+	CodeInvalidReceiver     TxCode = 10
 
 	// engine-related error code
 	CodeInvalidSchema         TxCode = 100
@@ -39,6 +42,8 @@ var (
 	ErrInvalidNonce        = errors.New("invalid nonce")
 	ErrInvalidAmount       = errors.New("invalid amount")
 	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrInsufficientFee     = errors.New("insufficient fee")
+	ErrNonceRaced          = errors.New("account nonce advanced past expected nonce")
 )
 
 // TxResult is the result of a transaction execution on chain.
@@ -70,6 +75,9 @@ func (tr TxResult) MarshalBinary() ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
+		if len(evt) > math.MaxUint16 {
+			return nil, errors.New("event too large")
+		}
 		data = binary.BigEndian.AppendUint16(data, uint16(len(evt)))
 		data = append(data, evt...)
 	}
@@ -125,12 +133,70 @@ func (tr *TxResult) UnmarshalBinary(data []byte) error {
 	return nil
 }
 
-type Event struct{}
+// Event is a structured event emitted during the execution of a transaction,
+// such as by an action.
+type Event struct {
+	Type       string           `json:"type"`
+	Attributes []EventAttribute `json:"attributes,omitempty"`
+}
+
+// EventAttribute is a key-value pair describing an Event. Attributes are a
+// slice rather than a map so that their encoding is deterministic.
+type EventAttribute struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
 
 func (e Event) MarshalBinary() ([]byte, error) {
-	return []byte{}, nil
+	buf := new(bytes.Buffer)
+	if err := writeString(buf, e.Type); err != nil {
+		return nil, err
+	}
+	if err := binary.Write(buf, binary.LittleEndian, uint32(len(e.Attributes))); err != nil {
+		return nil, err
+	}
+	for _, attr := range e.Attributes {
+		if err := writeString(buf, attr.Key); err != nil {
+			return nil, err
+		}
+		if err := writeString(buf, attr.Value); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
 }
 
 func (e *Event) UnmarshalBinary(data []byte) error {
+	*e = Event{}
+	if len(data) == 0 { // an empty event, as encoded before events had fields
+		return nil
+	}
+
+	rd := bytes.NewReader(data)
+	var err error
+	if e.Type, err = readString(rd); err != nil {
+		return fmt.Errorf("failed to read event type: %w", err)
+	}
+	var numAttrs uint32
+	if err = binary.Read(rd, binary.LittleEndian, &numAttrs); err != nil {
+		return fmt.Errorf("failed to read number of event attributes: %w", err)
+	}
+	if int64(numAttrs)*8 > int64(rd.Len()) { // each attribute has two length prefixes
+		return fmt.Errorf("event attribute count %d exceeds data length", numAttrs)
+	}
+	if numAttrs > 0 {
+		e.Attributes = make([]EventAttribute, numAttrs)
+	}
+	for i := range e.Attributes {
+		if e.Attributes[i].Key, err = readString(rd); err != nil {
+			return fmt.Errorf("failed to read event attribute key: %w", err)
+		}
+		if e.Attributes[i].Value, err = readString(rd); err != nil {
+			return fmt.Errorf("failed to read event attribute value: %w", err)
+		}
+	}
+	if rd.Len() != 0 {
+		return errors.New("unexpected trailing data in event")
+	}
 	return nil
 }
@@ -2,6 +2,7 @@ package types
 
 import (
 	"encoding/binary"
+	"reflect"
 	"testing"
 )
 
@@ -61,6 +62,46 @@ func TestTxResultMarshalUnmarshal(t *testing.T) {
 		}
 	})
 
+	t.Run("with events", func(t *testing.T) {
+		tr := TxResult{
+			Code: 0,
+			Log:  "ok",
+			Events: []Event{
+				{Type: "transfer", Attributes: []EventAttribute{
+					{Key: "from", Value: "alice"},
+					{Key: "to", Value: ""},
+				}},
+				{Type: "empty"},
+				{},
+			},
+		}
+
+		data, err := tr.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var decoded TxResult
+		err = decoded.UnmarshalBinary(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(decoded.Events, tr.Events) {
+			t.Errorf("got events %v, want %v", decoded.Events, tr.Events)
+		}
+	})
+
+	t.Run("legacy empty event", func(t *testing.T) {
+		var evt Event
+		if err := evt.UnmarshalBinary(nil); err != nil {
+			t.Fatal(err)
+		}
+		if evt.Type != "" || evt.Attributes != nil {
+			t.Errorf("got event %v, want empty", evt)
+		}
+	})
+
 	t.Run("invalid data length", func(t *testing.T) {
 		data := make([]byte, 3)
 		var tr TxResult
@@ -32,13 +32,24 @@ type ChainInfo struct {
 // the account/owner fields in the user service.
 
 type JoinRequest struct {
-	Candidate []byte   `json:"candidate"`  // pubkey of the candidate validator
-	Power     int64    `json:"power"`      // the requested power
-	ExpiresAt int64    `json:"expires_at"` // the block height at which the join request expires
-	Board     [][]byte `json:"board"`      // slice of pubkeys of all the eligible voting validators
-	Approved  []bool   `json:"approved"`   // slice of bools indicating if the corresponding validator approved
+	Candidate []byte            `json:"candidate"`  // pubkey of the candidate validator
+	Power     int64             `json:"power"`      // the requested power
+	ExpiresAt int64             `json:"expires_at"` // the block height at which the join request expires
+	Board     [][]byte          `json:"board"`      // slice of pubkeys of all the eligible voting validators
+	Approved  []bool            `json:"approved"`   // slice of bools indicating if the corresponding validator approved
+	Status    JoinRequestStatus `json:"status"`     // the board and approvals are only given for pending requests
 }
 
+// JoinRequestStatus is the state of a validator join request.
+type JoinRequestStatus string
+
+const (
+	JoinRequestPending  JoinRequestStatus = "pending"
+	JoinRequestApproved JoinRequestStatus = "approved" // the candidate became a validator
+	JoinRequestRejected JoinRequestStatus = "rejected" // approved, but the validator could not be added
+	JoinRequestExpired  JoinRequestStatus = "expired"  // not approved before the expiration height
+)
+
 type Validator struct {
 	Role   string   `json:"role"`
 	PubKey HexBytes `json:"pubkey"`
@@ -63,6 +74,16 @@ type DatasetIdentifier struct {
 	DBID  string   `json:"dbid"`
 }
 
+// DatasetDetails describes a deployed dataset, with a summary of its schema.
+type DatasetDetails struct {
+	Name       string   `json:"name"`
+	Owner      HexBytes `json:"owner"`
+	DBID       string   `json:"dbid"`
+	Tables     int      `json:"tables"`
+	Actions    int      `json:"actions"`
+	Procedures int      `json:"procedures"`
+}
+
 // VotableEvent is an event that can be voted.
 // It contains an event type and a body.
 // An ID can be generated from the event type and body.
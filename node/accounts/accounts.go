@@ -1,6 +1,7 @@
 package accounts
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
 	"errors"
@@ -69,6 +70,20 @@ func (a *Accounts) GetAccount(ctx context.Context, tx sql.Executor, account []by
 	return acct, nil
 }
 
+// AccountExists checks if an account with the given identifier exists. Unlike
+// GetAccount, this distinguishes an account that does not exist from an
+// existing account with a zero balance and nonce.
+func (a *Accounts) AccountExists(ctx context.Context, tx sql.Executor, account []byte) (bool, error) {
+	_, err := a.getAccount(ctx, tx, account, false)
+	if err != nil {
+		if err == ErrAccountNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
 // getAccount retrieves the account with the given identifier.
 // If the account does not exist, it will return an error.
 // If uncommitted is true, it will check the in-memory cache for the account.
@@ -190,10 +205,14 @@ func (a *Accounts) ApplySpend(ctx context.Context, tx sql.Executor, account []by
 
 // Transfer transfers an amount from one account to another. If the from account does not have enough funds to transfer the amount,
 // it will fail. If the to account does not exist, it will be created. The amount must be greater than 0.
+// The from and to accounts must be different.
 func (a *Accounts) Transfer(ctx context.Context, db sql.TxMaker, from, to []byte, amt *big.Int) error {
 	if amt.Sign() < 0 {
 		return ErrNegativeTransfer
 	}
+	if bytes.Equal(from, to) {
+		return ErrSelfTransfer
+	}
 
 	tx, err := db.BeginTx(ctx)
 	if err != nil {
@@ -238,6 +238,28 @@ var acctsTestCases = []acctsTestCase{
 			verifyDBAccessCount(t, c, 2, skip)
 		},
 	},
+	{
+		name: "account exists",
+		fn: func(t *testing.T, db sql.DB, a *Accounts, c counter, skip bool) {
+			ctx := context.Background()
+
+			exists, err := a.AccountExists(ctx, db, account1)
+			require.NoError(t, err)
+			require.False(t, exists)
+
+			err = a.Credit(ctx, db, account1, big.NewInt(0))
+			require.NoError(t, err)
+
+			exists, err = a.AccountExists(ctx, db, account1)
+			require.NoError(t, err)
+			require.True(t, exists)
+
+			acc, err := a.GetAccount(ctx, db, account1)
+			require.NoError(t, err)
+			require.Zero(t, acc.Balance.Sign())
+			require.Zero(t, acc.Nonce)
+		},
+	},
 	{
 		name: "transfer negative amount",
 		fn: func(t *testing.T, db sql.DB, a *Accounts, c counter, skip bool) {
@@ -266,6 +288,24 @@ var acctsTestCases = []acctsTestCase{
 			verifyDBAccessCount(t, c, 1, skip) // acct2 is not accessed as the transfer is invalid
 		},
 	},
+	{
+		name: "transfer to self",
+		fn: func(t *testing.T, db sql.DB, a *Accounts, c counter, skip bool) {
+			ctx := context.Background()
+
+			err := a.Credit(ctx, db, account1, big.NewInt(100))
+			require.NoError(t, err)
+			verifyDBAccessCount(t, c, 1, skip)
+
+			err = a.Transfer(ctx, db, account1, account1, big.NewInt(50))
+			require.ErrorIs(t, err, ErrSelfTransfer)
+
+			acc, err := a.GetAccount(ctx, db, account1)
+			require.NoError(t, err)
+			require.Equal(t, big.NewInt(100), acc.Balance)
+			verifyDBAccessCount(t, c, 1, skip)
+		},
+	},
 	{
 		name: "get non existent account",
 		fn: func(t *testing.T, db sql.DB, a *Accounts, c counter, skip bool) {
@@ -14,6 +14,7 @@ var (
 	ErrAccountNotFound   = errors.New("account not found")
 	ErrNegativeBalance   = errors.New("negative balance not permitted")
 	ErrNegativeTransfer  = errors.New("negative transfer not permitted")
+	ErrSelfTransfer      = errors.New("transfer to self not permitted")
 )
 
 // errInsufficientFunds formats an error message for insufficient funds
@@ -14,6 +14,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/kwilteam/kwil-db/core/crypto"
 	"github.com/kwilteam/kwil-db/core/log"
 	rpcclient "github.com/kwilteam/kwil-db/core/rpc/client"
 	adminRpc "github.com/kwilteam/kwil-db/core/rpc/client/admin"
@@ -30,12 +31,16 @@ type AdminClient struct {
 
 	log log.Logger
 
-	pass string
+	pass   string
+	signer crypto.PrivateKey
 
 	// optional TLS files
 	kwildCertFile  string
 	clientKeyFile  string
 	clientCertFile string
+
+	// optional SHA-256 fingerprint of kwild's certificate, in hex
+	kwildCertFingerprint string
 }
 
 // AdminSvcClient is the txsvc client interface.
@@ -161,6 +166,20 @@ func NewClient(ctx context.Context, target string, opts ...Opt) (*AdminClient, e
 	// scheme dictates that. But append RootCAs and client Certificates if
 	// config has them.
 	tlsConfig := transport.DefaultClientTLSConfig()
+
+	if c.kwildCertFingerprint != "" {
+		if c.kwildCertFile != "" {
+			return nil, errors.New("a kwild cert file and cert fingerprint may not both be used")
+		}
+		fp, err := transport.ParseCertFingerprint(c.kwildCertFingerprint)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig, err = transport.NewClientTLSConfigFromFingerprint(fp)
+		if err != nil {
+			return nil, err
+		}
+	}
 	trans.TLSClientConfig = tlsConfig
 
 	// Set RootCAs if we have a kwild cert file.
@@ -189,6 +208,7 @@ func NewClient(ctx context.Context, target string, opts ...Opt) (*AdminClient, e
 		}),
 		rpcclient.WithLogger(c.log),
 		rpcclient.WithPass(c.pass),
+		rpcclient.WithSigner(c.signer),
 	)
 	c.adminSvcClient = cl
 
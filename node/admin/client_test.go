@@ -4,6 +4,14 @@
 package adminclient
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -113,3 +121,70 @@ func Test_prepareHTTPDialerURL(t *testing.T) {
 		})
 	}
 }
+
+func TestTLSFingerprint(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc":"2.0","id":"1","result":{"message":"pong"}}`))
+	}))
+	defer srv.Close()
+
+	fp := sha256.Sum256(srv.Certificate().Raw)
+	ctx := context.Background()
+
+	t.Run("matching fingerprint", func(t *testing.T) {
+		cl, err := NewClient(ctx, srv.URL, WithTLSFingerprint(hex.EncodeToString(fp[:])))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pong, err := cl.Ping(ctx)
+		if err != nil {
+			t.Fatalf("ping failed: %v", err)
+		}
+		if pong != "pong" {
+			t.Errorf("got %q, want %q", pong, "pong")
+		}
+	})
+
+	t.Run("mismatched fingerprint", func(t *testing.T) {
+		badFP := fp
+		badFP[0]++
+		cl, err := NewClient(ctx, srv.URL, WithTLSFingerprint(hex.EncodeToString(badFP[:])))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = cl.Ping(ctx)
+		if err == nil || !strings.Contains(err.Error(), "fingerprint") {
+			t.Fatalf("expected fingerprint mismatch error, got %v", err)
+		}
+	})
+
+	t.Run("no fingerprint", func(t *testing.T) {
+		cl, err := NewClient(ctx, srv.URL) // self-signed cert not trusted
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = cl.Ping(ctx); err == nil {
+			t.Fatal("expected certificate verification error")
+		}
+	})
+
+	t.Run("invalid fingerprint", func(t *testing.T) {
+		_, err := NewClient(ctx, srv.URL, WithTLSFingerprint("abcd"))
+		if err == nil {
+			t.Fatal("expected error for short fingerprint")
+		}
+	})
+
+	t.Run("with cert file", func(t *testing.T) {
+		certFile := filepath.Join(t.TempDir(), "kwild.cert")
+		if err := os.WriteFile(certFile, []byte{}, 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := NewClient(ctx, srv.URL, WithTLS(certFile, "", ""),
+			WithTLSFingerprint(hex.EncodeToString(fp[:])))
+		if err == nil {
+			t.Fatal("expected error using both a cert file and fingerprint")
+		}
+	})
+}
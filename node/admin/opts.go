@@ -1,6 +1,7 @@
 package adminclient
 
 import (
+	"github.com/kwilteam/kwil-db/core/crypto"
 	"github.com/kwilteam/kwil-db/core/log"
 )
 
@@ -34,3 +35,21 @@ func WithTLS(kwildCertFile, clientKeyFile, clientCertFile string) Opt {
 		c.clientCertFile = clientCertFile
 	}
 }
+
+// WithTLSFingerprint pins the admin service's TLS certificate by its
+// hex-encoded SHA-256 fingerprint, instead of verifying it with a kwild cert
+// file or the system's root CAs. Any other certificate is rejected. This may
+// not be used with a kwild cert file provided to WithTLS.
+func WithTLSFingerprint(fingerprint string) Opt {
+	return func(c *AdminClient) {
+		c.kwildCertFingerprint = fingerprint
+	}
+}
+
+// WithSigner specifies a key with which to sign requests, if signed requests
+// are required by the server.
+func WithSigner(signer crypto.PrivateKey) Opt {
+	return func(c *AdminClient) {
+		c.signer = signer
+	}
+}
@@ -1,12 +1,16 @@
 package node
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
 	"errors"
+	"fmt"
+	"maps"
 	"time"
 
 	ktypes "github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/peers"
 	"github.com/kwilteam/kwil-db/node/types"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -16,12 +20,22 @@ const (
 	blkReadLimit   = 300_000_000
 	blkGetTimeout  = 90 * time.Second
 	blkSendTimeout = 45 * time.Second
+
+	// maxBlkRangeCount is the most blocks served for a ProtocolIDBlockRange
+	// request, regardless of the requested count.
+	maxBlkRangeCount = 100
+	// blkRangeSizeLimit is the total size of the raw blocks after which no
+	// more blocks are added to a ProtocolIDBlockRange response. The first
+	// block is always sent, so a response may exceed this by up to the size
+	// of one block.
+	blkRangeSizeLimit = 50_000_000
+	blkRangeReadLimit = blkReadLimit + blkRangeSizeLimit + maxBlkRangeCount*rangeBlkOverhead
 )
 
 func (n *Node) blkGetStreamHandler(s network.Stream) {
 	defer s.Close()
 
-	s.SetReadDeadline(time.Now().Add(reqRWTimeout))
+	s.SetReadDeadline(time.Now().Add(n.timeouts.ReqRW))
 
 	var req blockHashReq
 	if _, err := req.ReadFrom(s); err != nil {
@@ -32,21 +46,25 @@ func (n *Node) blkGetStreamHandler(s network.Stream) {
 
 	blk, appHash, err := n.bki.Get(req.Hash)
 	if err != nil {
-		s.SetWriteDeadline(time.Now().Add(reqRWTimeout))
+		s.SetWriteDeadline(time.Now().Add(n.timeouts.ReqRW))
 		s.Write(noData) // don't have it
 	} else {
 		rawBlk := ktypes.EncodeBlock(blk)
-		s.SetWriteDeadline(time.Now().Add(blkSendTimeout))
-		binary.Write(s, binary.LittleEndian, blk.Header.Height)
-		s.Write(appHash[:])
-		s.Write(rawBlk)
+		s.SetWriteDeadline(time.Now().Add(n.timeouts.BlkSend))
+		w, flush := respWriter(s)
+		binary.Write(w, binary.LittleEndian, blk.Header.Height)
+		w.Write(appHash[:])
+		w.Write(rawBlk)
+		if err := flush(); err != nil {
+			n.log.Warn("failed to send block", "hash", req.Hash, "error", err)
+		}
 	}
 }
 
 func (n *Node) blkGetHeightStreamHandler(s network.Stream) {
 	defer s.Close()
 
-	s.SetReadDeadline(time.Now().Add(reqRWTimeout))
+	s.SetReadDeadline(time.Now().Add(n.timeouts.ReqRW))
 
 	var req blockHeightReq
 	if _, err := req.ReadFrom(s); err != nil {
@@ -57,28 +75,80 @@ func (n *Node) blkGetHeightStreamHandler(s network.Stream) {
 
 	hash, blk, appHash, err := n.bki.GetByHeight(req.Height)
 	if err != nil {
-		s.SetWriteDeadline(time.Now().Add(reqRWTimeout))
+		s.SetWriteDeadline(time.Now().Add(n.timeouts.ReqRW))
 		s.Write(noData) // don't have it
 	} else {
 		rawBlk := ktypes.EncodeBlock(blk) // blkHash := blk.Hash()
 		// maybe we remove hash from the protocol, was thinking receiver could
 		// hang up earlier depending...
-		s.SetWriteDeadline(time.Now().Add(blkSendTimeout))
-		s.Write(hash[:])
-		s.Write(appHash[:])
-		s.Write(rawBlk)
+		s.SetWriteDeadline(time.Now().Add(n.timeouts.BlkSend))
+		w, flush := respWriter(s)
+		w.Write(hash[:])
+		w.Write(appHash[:])
+		w.Write(rawBlk)
+		if err := flush(); err != nil {
+			n.log.Warn("failed to send block", "height", req.Height, "error", err)
+		}
+	}
+}
+
+// blkGetRangeStreamHandler serves consecutive blocks for a blockRangeReq,
+// stopping at the best height, at maxBlkRangeCount blocks, or when the
+// response reaches blkRangeSizeLimit.
+func (n *Node) blkGetRangeStreamHandler(s network.Stream) {
+	defer s.Close()
+
+	s.SetReadDeadline(time.Now().Add(n.timeouts.ReqRW))
+
+	var req blockRangeReq
+	if _, err := req.ReadFrom(s); err != nil {
+		n.log.Warn("Bad get block range request", "error", err) // Debug when we ship
+		return
+	}
+	n.log.Debug("Peer requested blocks", "start", req.Start, "count", req.Count)
+
+	best, _, _ := n.bki.Best()
+	end := min(req.Start+int64(min(req.Count, maxBlkRangeCount))-1, best)
+
+	w, flush := respWriter(s)
+	var sent, size int
+	for height := req.Start; height <= end; height++ {
+		hash, blk, appHash, err := n.bki.GetByHeight(height)
+		if err != nil {
+			break
+		}
+		rawBlk := ktypes.EncodeBlock(blk)
+		if sent > 0 && size+len(rawBlk) > blkRangeSizeLimit {
+			break
+		}
+		s.SetWriteDeadline(time.Now().Add(n.timeouts.BlkSend))
+		if _, err = (rangeBlk{Hash: hash, AppHash: appHash, Raw: rawBlk}).WriteTo(w); err != nil {
+			n.log.Debug("Failed to send block range", "height", height, "error", err)
+			return
+		}
+		sent++
+		size += len(rawBlk)
+	}
+
+	if sent == 0 {
+		s.SetWriteDeadline(time.Now().Add(n.timeouts.ReqRW))
+		s.Write(noData) // don't have any
+		return
+	}
+	if err := flush(); err != nil {
+		n.log.Debug("Failed to send block range", "start", req.Start, "error", err)
 	}
 }
 
 func (n *Node) blkAnnStreamHandler(s network.Stream) {
 	defer s.Close()
 
-	s.SetDeadline(time.Now().Add(blkGetTimeout + annRespTimeout + annWriteTimeout)) // combined
-	ctx, cancel := context.WithTimeout(context.Background(), blkGetTimeout)
+	s.SetDeadline(time.Now().Add(n.timeouts.BlkGet + n.timeouts.AnnResp + n.timeouts.AnnWrite)) // combined
+	ctx, cancel := context.WithTimeout(context.Background(), n.timeouts.BlkGet)
 	defer cancel()
 
-	var reqMsg blockAnnMsg
-	if _, err := reqMsg.ReadFrom(s); err != nil {
+	reqMsg := blockAnnMsg{maxSigLen: n.maxLeaderSigLen}
+	if err := readAnn(s, annTypeBlock, &reqMsg); err != nil {
 		n.log.Warn("bad blk ann request", "error", err)
 		return
 	}
@@ -157,6 +227,7 @@ func (n *Node) blkAnnStreamHandler(s network.Stream) {
 
 	// re-announce
 
+	n.discardPrefetched(height)
 	n.ce.NotifyBlockCommit(blk, appHash)
 	go func() {
 		n.announceRawBlk(context.Background(), blkHash, height, rawBlk, appHash, s.Conn().RemotePeer(), reqMsg.LeaderSig) // re-announce with the leader's signature
@@ -190,13 +261,14 @@ func (n *Node) announceRawBlk(ctx context.Context, blkHash types.Hash, height in
 			Height:    height,
 			AppHash:   appHash,
 			LeaderSig: blkSig,
+			maxSigLen: n.maxLeaderSigLen,
 		}.MarshalBinary()
 		if err != nil {
 			n.log.Error("Unable to marshal block announcement", "error", err)
 			continue
 		}
-		ann := contentAnn{cType: "block announce", ann: resID, content: rawBlk}
-		err = n.advertiseToPeer(ctx, peerID, ProtocolIDBlkAnn, ann, blkSendTimeout)
+		ann := contentAnn{cType: annTypeBlock, ann: resID, content: rawBlk}
+		err = n.advertiseToPeer(ctx, peerID, ProtocolIDBlkAnn, ann, n.timeouts.BlkSend)
 		if err != nil {
 			n.log.Warn("Failed to advertise block", "peer", peerID, "error", err)
 			continue
@@ -229,75 +301,156 @@ func (n *Node) getBlkWithRetry(ctx context.Context, blkHash types.Hash, baseDela
 }
 
 func (n *Node) getBlk(ctx context.Context, blkHash types.Hash) (int64, []byte, types.Hash, error) {
-	for _, peer := range n.peers() {
-		n.log.Infof("requesting block %v from %v", blkHash, peer)
-		t0 := time.Now()
-		resID, _ := blockHashReq{Hash: blkHash}.MarshalBinary()
-		resp, err := requestFrom(ctx, n.host, peer, resID, ProtocolIDBlock, blkReadLimit)
+	t0 := time.Now()
+	resID, _ := blockHashReq{Hash: blkHash}.MarshalBinary()
+	resp, peer, err := n.requestFromPeers(ctx, n.peers(), resID, ProtocolIDBlock, blkReadLimit,
+		func(resp []byte) error {
+			if len(resp) < 8+types.HashLen {
+				return errors.New("block response too short")
+			}
+			return nil
+		})
+	if err != nil {
 		if errors.Is(err, ErrNotFound) {
-			n.log.Info("block not available", "peer", peer, "hash", blkHash)
-			continue
-		}
-		if errors.Is(err, ErrNoResponse) {
-			n.log.Info("no response to block request", "peer", peer, "hash", blkHash)
-			continue
-		}
-		if err != nil {
-			n.log.Info("block request failed unexpectedly", "peer", peer, "hash", blkHash)
-			continue
-		}
-
-		if len(resp) < 8 {
-			n.log.Info("block response too short", "peer", peer, "hash", blkHash)
-			continue
+			return 0, nil, types.Hash{}, errors.Join(err, ErrBlkNotFound)
 		}
+		return 0, nil, types.Hash{}, err
+	}
 
-		n.log.Debug("Obtained content for block", "block", blkHash, "elapsed", time.Since(t0))
+	n.log.Debug("Obtained content for block", "block", blkHash, "peer", peer, "elapsed", time.Since(t0))
 
-		height := binary.LittleEndian.Uint64(resp[:8])
-		var appHash types.Hash
-		copy(appHash[:], resp[8:8+types.HashLen])
-		rawBlk := resp[8+types.HashLen:]
+	height := binary.LittleEndian.Uint64(resp[:8])
+	var appHash types.Hash
+	copy(appHash[:], resp[8:8+types.HashLen])
+	rawBlk := resp[8+types.HashLen:]
 
-		return int64(height), rawBlk, appHash, nil
-	}
-	return 0, nil, types.Hash{}, ErrBlkNotFound
+	return int64(height), rawBlk, appHash, nil
 }
 
+// getBlkHeight retrieves the block at a height from the peers. Since blocks
+// are usually requested in order while syncing, a range of blocks is requested
+// from peers that support it, and the blocks after the requested one are kept
+// for the following calls.
 func (n *Node) getBlkHeight(ctx context.Context, height int64) (types.Hash, types.Hash, []byte, error) {
-	for _, peer := range n.peers() {
-		n.log.Infof("requesting block number %d from %v", height, peer)
-		t0 := time.Now()
-		resID, _ := blockHeightReq{Height: height}.MarshalBinary()
-		resp, err := requestFrom(ctx, n.host, peer, resID, ProtocolIDBlockHeight, blkReadLimit)
+	if blk, ok := n.takePrefetched(height); ok {
+		return blk.Hash, blk.AppHash, blk.Raw, nil
+	}
+
+	t0 := time.Now()
+	blks, err := n.getBlkRange(ctx, height, maxBlkRangeCount)
+	if err == nil {
+		n.log.Info("obtained block range", "start", height, "count", len(blks), "elapsed", time.Since(t0))
+		n.setPrefetched(blks[1:])
+		return blks[0].Hash, blks[0].AppHash, blks[0].Raw, nil
+	}
+	if ctx.Err() != nil {
+		return types.Hash{}, types.Hash{}, nil, ctx.Err()
+	}
+
+	// Older peers, or no peer has the range.
+	resID, _ := blockHeightReq{Height: height}.MarshalBinary()
+	resp, peer, err := n.requestFromPeers(ctx, n.peers(), resID, ProtocolIDBlockHeight, blkReadLimit,
+		func(resp []byte) error {
+			if len(resp) < types.HashLen*2+1 {
+				return errors.New("block response too short")
+			}
+			return nil
+		})
+	if err != nil {
 		if errors.Is(err, ErrNotFound) {
-			n.log.Warnf("block not available on %v", peer)
-			continue
-		}
-		if errors.Is(err, ErrNoResponse) {
-			n.log.Warnf("no response to block request to %v", peer)
-			continue
-		}
-		if err != nil {
-			n.log.Warnf("unexpected error from %v: %v", peer, err)
-			continue
+			return types.Hash{}, types.Hash{}, nil, errors.Join(err, ErrBlkNotFound)
 		}
+		return types.Hash{}, types.Hash{}, nil, err
+	}
 
-		if len(resp) < types.HashLen+1 {
-			n.log.Warnf("block response too short")
-			continue
+	n.log.Info("obtained block contents", "height", height, "peer", peer, "elapsed", time.Since(t0))
+
+	var hash, appHash types.Hash
+	copy(hash[:], resp[:types.HashLen])
+	copy(appHash[:], resp[types.HashLen:types.HashLen*2])
+	rawBlk := resp[types.HashLen*2:]
+
+	return hash, appHash, rawBlk, nil
+}
+
+// getBlkRange requests up to count consecutive blocks beginning at start from
+// the peers that support ProtocolIDBlockRange. At least one block is returned
+// if there is no error, and the blocks are checked to have the expected
+// heights and hashes.
+func (n *Node) getBlkRange(ctx context.Context, start int64, count uint32) ([]rangeBlk, error) {
+	var rangePeers []peer.ID
+	for _, peerID := range n.peers() {
+		if ok, _ := peers.CheckProtocolSupport(ctx, n.host.Peerstore(), peerID, ProtocolIDBlockRange); ok {
+			rangePeers = append(rangePeers, peerID)
 		}
+	}
+	if len(rangePeers) == 0 {
+		return nil, ErrNotFound
+	}
 
-		n.log.Info("obtained block contents", "height", height, "elapsed", time.Since(t0))
+	var blks []rangeBlk
+	resID, _ := blockRangeReq{Start: start, Count: count}.MarshalBinary()
+	_, _, err := n.requestFromPeers(ctx, rangePeers, resID, ProtocolIDBlockRange, blkRangeReadLimit,
+		func(resp []byte) error {
+			var err error
+			blks, err = decodeBlkRange(resp)
+			if err != nil {
+				return err
+			}
+			if len(blks) == 0 || len(blks) > int(count) {
+				return fmt.Errorf("unexpected number of blocks %d", len(blks))
+			}
+			for i, blk := range blks {
+				hdr, err := ktypes.DecodeBlockHeader(bytes.NewReader(blk.Raw))
+				if err != nil {
+					return fmt.Errorf("invalid block: %w", err)
+				}
+				if hdr.Height != start+int64(i) {
+					return fmt.Errorf("unexpected block height %d, wanted %d", hdr.Height, start+int64(i))
+				}
+				if hdr.Hash() != blk.Hash {
+					return fmt.Errorf("unexpected hash for block %d", hdr.Height)
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return blks, nil
+}
 
-		var hash, appHash types.Hash
-		copy(hash[:], resp[:types.HashLen])
-		copy(appHash[:], resp[types.HashLen:types.HashLen*2])
-		rawBlk := resp[types.HashLen*2:]
+// takePrefetched removes and returns the prefetched block at a height.
+func (n *Node) takePrefetched(height int64) (rangeBlk, bool) {
+	n.prefetchMtx.Lock()
+	defer n.prefetchMtx.Unlock()
+	blk, ok := n.prefetched[height]
+	if ok {
+		delete(n.prefetched, height)
+	}
+	return blk, ok
+}
 
-		return hash, appHash, rawBlk, nil
+// setPrefetched replaces the prefetched blocks, which are consecutive.
+func (n *Node) setPrefetched(blks []rangeBlk) {
+	prefetched := make(map[int64]rangeBlk, len(blks))
+	for _, blk := range blks {
+		hdr, _ := ktypes.DecodeBlockHeader(bytes.NewReader(blk.Raw)) // checked by getBlkRange
+		prefetched[hdr.Height] = blk
 	}
-	return types.Hash{}, types.Hash{}, nil, ErrBlkNotFound
+	n.prefetchMtx.Lock()
+	n.prefetched = prefetched
+	n.prefetchMtx.Unlock()
+}
+
+// discardPrefetched drops the prefetched blocks at or below a height, which
+// are not needed once the block at the height is committed.
+func (n *Node) discardPrefetched(height int64) {
+	n.prefetchMtx.Lock()
+	defer n.prefetchMtx.Unlock()
+	maps.DeleteFunc(n.prefetched, func(h int64, _ rangeBlk) bool {
+		return h <= height
+	})
 }
 
 // BlockByHeight returns the block by height. If height <= 0, the latest block
@@ -38,10 +38,11 @@ type TxApp interface {
 	Commit() error
 	Rollback()
 	GenesisInit(ctx context.Context, db sql.DB, validators []*ktypes.Validator, genesisAccounts []*ktypes.Account, initialHeight int64, chain *common.ChainContext) error
-	ApplyMempool(ctx *common.TxContext, db sql.DB, tx *types.Transaction) error
+	ApplyMempool(ctx *common.TxContext, db sql.DB, tx *types.Transaction, recheck bool) error
+	ResetMempoolAccounts(acctIDs [][]byte)
 
 	Price(ctx context.Context, dbTx sql.DB, tx *ktypes.Transaction, chainContext *common.ChainContext) (*big.Int, error)
-	AccountInfo(ctx context.Context, dbTx sql.DB, identifier []byte, pending bool) (balance *big.Int, nonce int64, err error)
+	AccountInfo(ctx context.Context, dbTx sql.DB, identifier []byte, pending bool) (balance *big.Int, nonce int64, exists bool, err error)
 }
 
 // Question:
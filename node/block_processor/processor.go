@@ -154,6 +154,12 @@ func (bp *BlockProcessor) Rollback(ctx context.Context, height int64, appHash kt
 	return nil
 }
 
+// ResetMempoolAccounts discards the unconfirmed states of the given accounts
+// built by CheckTx, so that the accounts' transactions can be rechecked.
+func (bp *BlockProcessor) ResetMempoolAccounts(acctIDs [][]byte) {
+	bp.txapp.ResetMempoolAccounts(acctIDs)
+}
+
 func (bp *BlockProcessor) CheckTx(ctx context.Context, tx *ktypes.Transaction, recheck bool) error {
 	rawTx, err := tx.MarshalBinary()
 	if err != nil {
@@ -205,7 +211,7 @@ func (bp *BlockProcessor) CheckTx(ctx context.Context, tx *ktypes.Transaction, r
 		Signer:        tx.Sender,
 		Caller:        ident,
 		Authenticator: tx.Signature.Type,
-	}, readTx, tx)
+	}, readTx, tx, recheck)
 	if err != nil {
 		// do appropriate logging
 		bp.log.Info("Failed to apply the transaction to the mempool", "tx", hex.EncodeToString(txHash[:]), "err", err)
@@ -246,6 +252,7 @@ func (bp *BlockProcessor) InitChain(ctx context.Context) (int64, []byte, error)
 		DisabledGasCosts: genCfg.DisabledGasCosts,
 		// MigrationStatus : genesisCfg.MigrationStatus,
 		MaxVotesPerTx: genCfg.MaxVotesPerTx,
+		MinFee:        genCfg.MinFee,
 	}
 
 	if err := meta.StoreParams(ctx, genesisTx, networkParams); err != nil {
@@ -551,7 +558,7 @@ func (bp *BlockProcessor) Price(ctx context.Context, dbTx sql.DB, tx *ktypes.Tra
 	return bp.txapp.Price(ctx, dbTx, tx, bp.chainCtx)
 }
 
-func (bp *BlockProcessor) AccountInfo(ctx context.Context, db sql.DB, identifier []byte, pending bool) (balance *big.Int, nonce int64, err error) {
+func (bp *BlockProcessor) AccountInfo(ctx context.Context, db sql.DB, identifier []byte, pending bool) (balance *big.Int, nonce int64, exists bool, err error) {
 	return bp.txapp.AccountInfo(ctx, db, identifier, pending)
 }
 
@@ -32,6 +32,8 @@ type blockProp struct {
 	Stamp     int64
 	LeaderSig []byte
 	// Replacing *types.Hash
+
+	maxSigLen int // of LeaderSig, DefaultMaxLeaderSigLen if zero
 }
 
 func (bp blockProp) String() string {
@@ -43,6 +45,9 @@ var _ encoding.BinaryMarshaler = blockProp{}
 var _ encoding.BinaryMarshaler = (*blockProp)(nil)
 
 func (bp blockProp) MarshalBinary() ([]byte, error) {
+	if err := checkLeaderSigLen(uint64(len(bp.LeaderSig)), bp.maxSigLen); err != nil {
+		return nil, err
+	}
 	// 8 bytes for int64 + 2 hash lengths + 8 bytes for time stamp + len(sig) + sig
 	buf := make([]byte, 8+2*types.HashLen+8+8+len(bp.LeaderSig))
 	var c int
@@ -96,20 +101,12 @@ func (bp *blockProp) ReadFrom(r io.Reader) (int64, error) {
 		return n, err
 	}
 	n += 8
-	var sigLen int64
-	if err := binary.Read(r, binary.LittleEndian, &sigLen); err != nil {
-		return n, err
-	}
-	n += 8
-	if sigLen > 1000 { // TODO: smarter sanity check
-		return n, errors.New("invalid signature length")
-	}
-	bp.LeaderSig = make([]byte, sigLen)
-	nr, err = io.ReadFull(r, bp.LeaderSig)
+	sig, nSig, err := readLeaderSig(r, bp.maxSigLen)
+	n += nSig
 	if err != nil {
-		return int64(nr), err
+		return n, err
 	}
-	n += int64(nr)
+	bp.LeaderSig = sig
 	return n, nil
 }
 
@@ -159,6 +156,11 @@ func (bp *blockProp) WriteTo(w io.Writer) (int64, error) {
 }
 
 func (n *Node) announceBlkProp(ctx context.Context, blk *ktypes.Block) {
+	if n.archive {
+		n.log.Warn("Archive node does not announce block proposals", "height", blk.Header.Height)
+		return
+	}
+
 	rawBlk := ktypes.EncodeBlock(blk)
 	blkHash := blk.Hash()
 	height := blk.Header.Height
@@ -178,12 +180,12 @@ func (n *Node) announceBlkProp(ctx context.Context, blk *ktypes.Block) {
 			continue
 		}
 		prop := blockProp{Height: height, Hash: blkHash, PrevHash: blk.Header.PrevHash,
-			Stamp: blk.Header.Timestamp.UnixMilli(), LeaderSig: blk.Signature}
+			Stamp: blk.Header.Timestamp.UnixMilli(), LeaderSig: blk.Signature, maxSigLen: n.maxLeaderSigLen}
 		n.log.Debugf("advertising block proposal %s (height %d / txs %d) to peer %v", blkHash, height, len(blk.Txns), peerID)
 		// resID := annPropMsgPrefix + strconv.Itoa(int(height)) + ":" + prevHash + ":" + blkid
 		propID, _ := prop.MarshalBinary()
-		err := n.advertiseToPeer(ctx, peerID, ProtocolIDBlockPropose, contentAnn{prop.String(), propID, rawBlk},
-			blkSendTimeout)
+		err := n.advertiseToPeer(ctx, peerID, ProtocolIDBlockPropose, contentAnn{annTypeBlockProposal, propID, rawBlk},
+			n.timeouts.BlkSend)
 		if err != nil {
 			n.log.Infof(err.Error())
 			continue
@@ -212,8 +214,12 @@ func (n *Node) blkPropStreamHandler(s network.Stream) {
 	// 	return
 	// }
 
-	var prop blockProp
-	_, err := prop.ReadFrom(s)
+	if n.archive {
+		return // the committed block will be announced
+	}
+
+	prop := blockProp{maxSigLen: n.maxLeaderSigLen}
+	err := readAnn(s, annTypeBlockProposal, &prop)
 	if err != nil {
 		n.log.Warnf("invalid block proposal message: %v", err)
 		return
@@ -260,6 +266,12 @@ func (n *Node) blkPropStreamHandler(s network.Stream) {
 		return
 	}
 
+	// Block execution decides if the proposal is accepted, and the leader is
+	// told with an ACK or NACK, so this only explains a likely rejection.
+	if err := n.ce.ValidateProposal(context.Background(), blk); err != nil {
+		n.log.Warn("block proposal failed validation", "height", height, "hash", hash, "reason", err)
+	}
+
 	n.log.Info("processing block proposal", "height", height, "hash", hash)
 
 	n.ce.NotifyBlockProposal(blk)
@@ -270,6 +282,14 @@ func (n *Node) blkPropStreamHandler(s network.Stream) {
 // result back to the leader.
 func (n *Node) sendACK(ack bool, height int64, blkID types.Hash, appHash *types.Hash) error {
 	// n.log.Debugln("sending ACK", height, ack, blkID, appHash)
+	if n.archive {
+		n.log.Debug("Archive node does not send ACKs", "height", height)
+		return nil
+	}
+	if n.noGossip.Load() {
+		n.log.Warn("Gossip is unavailable, not sending ACK", "height", height)
+		return nil
+	}
 	n.ackChan <- types.AckRes{
 		ACK:     ack,
 		AppHash: appHash,
@@ -363,6 +383,10 @@ func (n *Node) startAckGossip(ctx context.Context, ps *pubsub.PubSub) error {
 
 func (n *Node) sendDiscoveryRequest() {
 	n.log.Debug("sending Discovery request")
+	if n.noGossip.Load() {
+		n.log.Warn("Gossip is unavailable, not sending discovery request")
+		return
+	}
 	n.discReq <- types.DiscoveryRequest{}
 }
 
@@ -515,6 +539,9 @@ func (n *Node) startDiscoveryResponseGossip(ctx context.Context, ps *pubsub.PubS
 }
 
 func (n *Node) sendReset(height int64) error {
+	if n.noGossip.Load() {
+		return errors.New("gossip is unavailable, cannot send consensus reset")
+	}
 	n.resetMsg <- types.ConsensusReset{
 		ToHeight: height,
 	}
@@ -586,6 +613,7 @@ func (n *Node) startConsensusResetGossip(ctx context.Context, ps *pubsub.PubSub)
 				fromPeerID, resetMsg.ReceivedFrom, resetMsg.Message.Data)
 
 			// source of the reset message should be the leader
+			n.setPrefetched(nil)
 			n.ce.NotifyResetState(reset.ToHeight)
 		}
 	}()
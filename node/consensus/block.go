@@ -3,11 +3,15 @@ package consensus
 import (
 	"context"
 	"fmt"
+	"time"
 
 	ktypes "github.com/kwilteam/kwil-db/core/types"
 	"github.com/kwilteam/kwil-db/node/types"
 )
 
+// waitCommitInterval is how often WaitCommit checks if a commit is in progress.
+const waitCommitInterval = 20 * time.Millisecond
+
 // TODO: should include consensus params hash
 func (ce *ConsensusEngine) validateBlock(blk *ktypes.Block) error {
 	// Validate if this is the correct block proposal to be processed.
@@ -48,10 +52,49 @@ func (ce *ConsensusEngine) CheckTx(ctx context.Context, tx *ktypes.Transaction)
 	return ce.blockProcessor.CheckTx(ctx, tx, false)
 }
 
+// RecheckAccounts discards the unconfirmed states of the senders' accounts and
+// rechecks their transactions in mempool, dropping any that are no longer
+// valid. This must be done when transactions that passed CheckTx are not added
+// to mempool or are evicted from it, since they were already applied to the
+// accounts' unconfirmed nonces and balances.
+func (ce *ConsensusEngine) RecheckAccounts(ctx context.Context, senders [][]byte) {
+	ce.mempoolMtx.Lock()
+	defer ce.mempoolMtx.Unlock()
+
+	ce.blockProcessor.ResetMempoolAccounts(senders)
+	ce.mempool.RecheckAccountTxs(ctx, senders, ce.blockProcessor.CheckTx)
+}
+
+// WaitCommit blocks until any block commit that is in progress is complete, or
+// the context is done. This is used to avoid interrupting a commit when
+// shutting down.
+func (ce *ConsensusEngine) WaitCommit(ctx context.Context) error {
+	// The mutex is held for the duration of commit. Poll it rather than
+	// blocking on Lock in a goroutine that could outlive the context.
+	ticker := time.NewTicker(waitCommitInterval)
+	defer ticker.Stop()
+	for {
+		if ce.mempoolMtx.TryLock() {
+			ce.mempoolMtx.Unlock()
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
 func (ce *ConsensusEngine) ConsensusParams() *ktypes.ConsensusParams {
 	return ce.blockProcessor.ConsensusParams()
 }
 
+// Validators returns the current validator set.
+func (ce *ConsensusEngine) Validators() []*ktypes.Validator {
+	return ce.blockProcessor.GetValidators()
+}
+
 func (ce *ConsensusEngine) executeBlock(ctx context.Context, blkProp *blockProposal) error {
 	defer func() {
 		ce.stateInfo.mtx.Lock()
@@ -0,0 +1,57 @@
+package consensus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kwilteam/kwil-db/node/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitCommit(t *testing.T) {
+	ce := &ConsensusEngine{}
+	ctx := context.Background()
+
+	// No commit in progress.
+	require.NoError(t, ce.WaitCommit(ctx))
+
+	// A commit in progress outlasts the context.
+	ce.mempoolMtx.Lock()
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, ce.WaitCommit(timeoutCtx), context.DeadlineExceeded)
+
+	// The commit completes while waiting.
+	time.AfterFunc(50*time.Millisecond, ce.mempoolMtx.Unlock)
+	require.NoError(t, ce.WaitCommit(ctx))
+}
+
+func TestResetState(t *testing.T) {
+	ce := &ConsensusEngine{resetChan: make(chan int64, 1)}
+	ce.stateInfo.height = 5
+	ce.role.Store(types.RoleValidator)
+
+	require.ErrorContains(t, ce.ResetState(6), "the last committed block is 5")
+	require.ErrorContains(t, ce.ResetState(4), "cannot be rolled back")
+	require.Empty(t, ce.resetChan)
+
+	t.Run("validator", func(t *testing.T) {
+		require.NoError(t, ce.ResetState(5))
+		select {
+		case height := <-ce.resetChan:
+			require.EqualValues(t, 5, height)
+		case <-time.After(time.Second):
+			t.Fatal("reset not sent to the event loop")
+		}
+	})
+
+	t.Run("leader", func(t *testing.T) {
+		ce.role.Store(types.RoleLeader)
+		var canceled bool
+		ce.blkExecCancelFn = func() { canceled = true }
+		require.NoError(t, ce.ResetState(5))
+		require.True(t, canceled, "block execution not canceled")
+	})
+}
@@ -51,8 +51,9 @@ type ConsensusEngine struct {
 	genesisAppHash types.Hash
 
 	// stores state machine state for the consensus engine
-	state  state
-	inSync atomic.Bool // set when the node is still catching up with the network during bootstrapping
+	state    state
+	inSync   atomic.Bool   // set when the node is still catching up with the network during bootstrapping
+	caughtUp chan struct{} // closed when the catchup during bootstrapping is complete
 
 	// copy of the minimal state info for the p2p layer usage.
 	stateInfo StateInfo
@@ -243,6 +244,7 @@ func New(cfg *Config) *ConsensusEngine {
 		resetChan:    make(chan int64, 1),
 		bestHeightCh: make(chan *discoveryMsg, 1),
 		newRound:     make(chan struct{}, 1),
+		caughtUp:     make(chan struct{}),
 		// interfaces
 		mempool:        cfg.Mempool,
 		blockStore:     cfg.BlockStore,
@@ -301,6 +303,12 @@ func (ce *ConsensusEngine) Start(ctx context.Context, proposerBroadcaster Propos
 	return nil
 }
 
+// CaughtUp returns a channel that is closed when the engine has caught up with
+// the network after Start, either from its block store or by block sync.
+func (ce *ConsensusEngine) CaughtUp() <-chan struct{} {
+	return ce.caughtUp
+}
+
 func (ce *ConsensusEngine) close() {
 	ce.state.mtx.Lock()
 	defer ce.state.mtx.Unlock()
@@ -485,6 +493,7 @@ func (ce *ConsensusEngine) catchup(ctx context.Context) error {
 
 	// Done with the catchup
 	ce.inSync.Store(false)
+	close(ce.caughtUp)
 
 	return nil
 }
@@ -697,6 +706,36 @@ func (ce *ConsensusEngine) resetBlockProp(ctx context.Context, height int64) {
 	}
 }
 
+// ResetState is used by an operator to abort the block that is in progress and
+// return the node's state to the last committed block, which must be at the
+// given height. Committed blocks cannot be rolled back. On the leader, this
+// cancels the execution of its block proposal, and the validators are told to
+// reset. Other nodes abort their execution of the current block proposal.
+func (ce *ConsensusEngine) ResetState(height int64) error {
+	ce.stateInfo.mtx.RLock()
+	lcHeight := ce.stateInfo.height
+	ce.stateInfo.mtx.RUnlock()
+
+	if height > lcHeight {
+		return fmt.Errorf("cannot reset to height %d, the last committed block is %d", height, lcHeight)
+	}
+	if height < lcHeight {
+		return fmt.Errorf("cannot reset to height %d, the committed blocks up to %d cannot be rolled back", height, lcHeight)
+	}
+
+	if ce.role.Load() == types.RoleLeader {
+		ce.cancelFnMtx.Lock()
+		if ce.blkExecCancelFn != nil {
+			ce.blkExecCancelFn()
+		}
+		ce.cancelFnMtx.Unlock()
+		return nil
+	}
+
+	go ce.sendResetMsg(height)
+	return nil
+}
+
 func (ce *ConsensusEngine) Role() types.Role {
 	return ce.role.Load().(types.Role)
 }
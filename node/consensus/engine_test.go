@@ -787,14 +787,16 @@ func (d *dummyTxApp) Rollback() {}
 func (d *dummyTxApp) GenesisInit(ctx context.Context, db sql.DB, validators []*ktypes.Validator, genesisAccounts []*ktypes.Account, initialHeight int64, chain *common.ChainContext) error {
 	return nil
 }
-func (d *dummyTxApp) AccountInfo(ctx context.Context, dbTx sql.DB, identifier []byte, pending bool) (balance *big.Int, nonce int64, err error) {
-	return big.NewInt(0), 0, nil
+func (d *dummyTxApp) AccountInfo(ctx context.Context, dbTx sql.DB, identifier []byte, pending bool) (balance *big.Int, nonce int64, exists bool, err error) {
+	return big.NewInt(0), 0, false, nil
 }
 
-func (d *dummyTxApp) ApplyMempool(ctx *common.TxContext, db sql.DB, tx *ktypes.Transaction) error {
+func (d *dummyTxApp) ApplyMempool(ctx *common.TxContext, db sql.DB, tx *ktypes.Transaction, recheck bool) error {
 	return nil
 }
 
+func (d *dummyTxApp) ResetMempoolAccounts([][]byte) {}
+
 type validatorStore struct {
 	valSet []*ktypes.Validator
 }
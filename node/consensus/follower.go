@@ -2,9 +2,11 @@ package consensus
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	ktypes "github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/ident"
 	"github.com/kwilteam/kwil-db/node/types"
 )
 
@@ -58,6 +60,86 @@ func (ce *ConsensusEngine) AcceptProposal(height int64, blkID, prevBlockID types
 	return true
 }
 
+// ValidateProposal checks a proposed block without executing it. It makes the
+// same checks as AcceptProposal, except for whether the node is busy with
+// another block, and it also checks the block's transactions: each must be
+// signed by its sender, the first of each sender's transactions must have the
+// nonce after the sender's account nonce, and the nonces of each sender's
+// transactions must be increasing. Unlike AcceptProposal, it returns an error that describes the
+// problem. The transaction checks are stricter than block execution, which
+// gives such transactions a failed result rather than rejecting the block, so
+// an error is only a diagnostic and the block should still be processed.
+func (ce *ConsensusEngine) ValidateProposal(ctx context.Context, blk *ktypes.Block) error {
+	if role := ce.role.Load(); role != types.RoleValidator {
+		return fmt.Errorf("proposals are only accepted by validators, node is %v", role)
+	}
+
+	ce.stateInfo.mtx.RLock()
+	lcHeight := ce.stateInfo.height
+	ce.stateInfo.mtx.RUnlock()
+
+	height := blk.Header.Height
+	if height != lcHeight+1 {
+		return fmt.Errorf("proposal is for height %d, expected %d", height, lcHeight+1)
+	}
+	if bestHeight, bestHash, _ := ce.blockStore.Best(); bestHeight == lcHeight && blk.Header.PrevHash != bestHash {
+		return fmt.Errorf("proposal follows block %s, expected %s", blk.Header.PrevHash, bestHash)
+	}
+
+	valid, err := blk.VerifySignature(ce.leader)
+	if err != nil {
+		return fmt.Errorf("failed to verify the leader signature: %w", err)
+	}
+	if !valid {
+		return errors.New("proposal is not signed by the leader")
+	}
+
+	if int(blk.Header.NumTxns) != len(blk.Txns) {
+		return fmt.Errorf("header has %d transactions, block has %d", blk.Header.NumTxns, len(blk.Txns))
+	}
+	if root := blk.MerkleRoot(); root != blk.Header.MerkleRoot {
+		return fmt.Errorf("header merkle root %s does not match the transactions, expected %s",
+			blk.Header.MerkleRoot, root)
+	}
+
+	// The last committed block is lcHeight, so the accounts are as of the
+	// block before the proposal.
+	readTx, err := ce.db.BeginReadTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read the accounts: %w", err)
+	}
+	defer readTx.Rollback(ctx)
+
+	nonces := make(map[string]uint64) // sender => last nonce
+	for i, rawTx := range blk.Txns {
+		tx := new(ktypes.Transaction)
+		if err := tx.UnmarshalBinary(rawTx); err != nil {
+			return fmt.Errorf("transaction %d is invalid: %w", i, err)
+		}
+		if err := ident.VerifyTransaction(tx); err != nil {
+			return fmt.Errorf("transaction %d has an invalid signature: %w", i, err)
+		}
+		sender := string(tx.Sender)
+		last, have := nonces[sender]
+		if !have {
+			_, acctNonce, _, err := ce.blockProcessor.AccountInfo(ctx, readTx, tx.Sender, false)
+			if err != nil {
+				return fmt.Errorf("failed to get the account of the sender of transaction %d: %w", i, err)
+			}
+			if tx.Body.Nonce != uint64(acctNonce)+1 {
+				return fmt.Errorf("transaction %d has nonce %d, but the sender's account nonce is %d, expected %d",
+					i, tx.Body.Nonce, acctNonce, acctNonce+1)
+			}
+		} else if tx.Body.Nonce <= last {
+			return fmt.Errorf("transaction %d has nonce %d, but the sender's previous transaction in the block has nonce %d",
+				i, tx.Body.Nonce, last)
+		}
+		nonces[sender] = tx.Body.Nonce
+	}
+
+	return nil
+}
+
 // AcceptCommit handles the blockAnnounce message from the leader.
 // This should be processed only if this is the next block to be committed by the node.
 // This also checks if the node should request the block from its peers. This can happen
@@ -0,0 +1,142 @@
+package consensus
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/kwilteam/kwil-db/core/crypto"
+	"github.com/kwilteam/kwil-db/core/crypto/auth"
+	"github.com/kwilteam/kwil-db/core/log"
+	ktypes "github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/store/memstore"
+	"github.com/kwilteam/kwil-db/node/types"
+	"github.com/kwilteam/kwil-db/node/types/sql"
+
+	"github.com/stretchr/testify/require"
+)
+
+// nonceDB is a DB for reading the accounts from a nonceProcessor.
+type nonceDB struct {
+	DB
+}
+
+func (nonceDB) BeginReadTx(context.Context) (sql.OuterReadTx, error) {
+	return nonceReadTx{}, nil
+}
+
+type nonceReadTx struct {
+	sql.OuterReadTx
+}
+
+func (nonceReadTx) Rollback(context.Context) error { return nil }
+
+// nonceProcessor is a BlockProcessor with fixed account nonces.
+type nonceProcessor struct {
+	BlockProcessor
+	nonces map[string]int64
+	err    error
+}
+
+func (bp *nonceProcessor) AccountInfo(_ context.Context, _ sql.DB, identifier []byte, _ bool) (*big.Int, int64, bool, error) {
+	if bp.err != nil {
+		return nil, 0, false, bp.err
+	}
+	nonce, exists := bp.nonces[string(identifier)]
+	return big.NewInt(0), nonce, exists, nil
+}
+
+func TestValidateProposal(t *testing.T) {
+	leaderKey, leaderPub, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+	otherKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+	userKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+	signer := auth.GetUserSigner(userKey)
+
+	bp := &nonceProcessor{nonces: map[string]int64{}}
+	ce := &ConsensusEngine{
+		leader:         leaderPub,
+		log:            log.DiscardLogger,
+		blockStore:     memstore.NewMemBS(),
+		db:             nonceDB{},
+		blockProcessor: bp,
+	}
+	ce.role.Store(types.RoleValidator)
+	ctx := context.Background()
+
+	newTx := func(nonce uint64) []byte {
+		tx, err := ktypes.CreateTransaction(&ktypes.Transfer{}, "kwil-test-chain", nonce)
+		require.NoError(t, err)
+		require.NoError(t, tx.Sign(signer))
+		rawTx, err := tx.MarshalBinary()
+		require.NoError(t, err)
+		return rawTx
+	}
+	newBlock := func(height int64, key crypto.PrivateKey, txns ...[]byte) *ktypes.Block {
+		blk := ktypes.NewBlock(height, types.Hash{}, types.Hash{}, types.Hash{}, time.Now(), txns)
+		require.NoError(t, blk.Sign(key))
+		return blk
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		require.NoError(t, ce.ValidateProposal(ctx, newBlock(1, leaderKey, newTx(1), newTx(2), newTx(4))))
+	})
+
+	t.Run("bad nonce", func(t *testing.T) {
+		err := ce.ValidateProposal(ctx, newBlock(1, leaderKey, newTx(1), newTx(2), newTx(2)))
+		require.ErrorContains(t, err, "transaction 2 has nonce 2, but the sender's previous transaction in the block has nonce 2")
+	})
+
+	t.Run("account nonce", func(t *testing.T) {
+		bp.nonces[string(signer.Identity())] = 5
+		defer delete(bp.nonces, string(signer.Identity()))
+
+		err := ce.ValidateProposal(ctx, newBlock(1, leaderKey, newTx(1), newTx(2)))
+		require.ErrorContains(t, err, "transaction 0 has nonce 1, but the sender's account nonce is 5, expected 6")
+		err = ce.ValidateProposal(ctx, newBlock(1, leaderKey, newTx(7)))
+		require.ErrorContains(t, err, "expected 6")
+		require.NoError(t, ce.ValidateProposal(ctx, newBlock(1, leaderKey, newTx(6), newTx(7))))
+	})
+
+	t.Run("account error", func(t *testing.T) {
+		bp.err = errors.New("db down")
+		defer func() { bp.err = nil }()
+		err := ce.ValidateProposal(ctx, newBlock(1, leaderKey, newTx(1)))
+		require.ErrorContains(t, err, "db down")
+	})
+
+	t.Run("bad tx signature", func(t *testing.T) {
+		rawTx := newTx(1)
+		rawTx[len(rawTx)-1]++ // the last byte of the sender
+		err := ce.ValidateProposal(ctx, newBlock(1, leaderKey, rawTx))
+		require.ErrorContains(t, err, "transaction 0 has an invalid signature")
+	})
+
+	t.Run("wrong height", func(t *testing.T) {
+		err := ce.ValidateProposal(ctx, newBlock(2, leaderKey))
+		require.ErrorContains(t, err, "proposal is for height 2, expected 1")
+	})
+
+	t.Run("not from the leader", func(t *testing.T) {
+		err := ce.ValidateProposal(ctx, newBlock(1, otherKey))
+		require.ErrorContains(t, err, "not signed by the leader")
+	})
+
+	t.Run("modified transactions", func(t *testing.T) {
+		blk := newBlock(1, leaderKey, newTx(1))
+		blk.Txns[0] = newTx(3)
+		err := ce.ValidateProposal(ctx, blk)
+		require.ErrorContains(t, err, "merkle root")
+	})
+
+	t.Run("not a validator", func(t *testing.T) {
+		ce.role.Store(types.RoleSentry)
+		defer ce.role.Store(types.RoleValidator)
+		err := ce.ValidateProposal(ctx, newBlock(1, leaderKey))
+		require.ErrorContains(t, err, "only accepted by validators")
+	})
+}
@@ -2,6 +2,7 @@ package consensus
 
 import (
 	"context"
+	"math/big"
 
 	"github.com/kwilteam/kwil-db/node/mempool"
 
@@ -24,6 +25,7 @@ type Mempool interface {
 	PeekN(maxSize int) []types.NamedTx
 	Remove(txid types.Hash)
 	RecheckTxs(ctx context.Context, checkFn mempool.CheckFn)
+	RecheckAccountTxs(ctx context.Context, senders [][]byte, checkFn mempool.CheckFn)
 }
 
 // BlockStore includes both txns and blocks
@@ -46,6 +48,8 @@ type BlockProcessor interface {
 	Close() error
 
 	CheckTx(ctx context.Context, tx *ktypes.Transaction, recheck bool) error
+	ResetMempoolAccounts(acctIDs [][]byte)
+	AccountInfo(ctx context.Context, db sql.DB, identifier []byte, pending bool) (balance *big.Int, nonce int64, exists bool, err error)
 
 	GetValidators() []*ktypes.Validator
 	ConsensusParams() *ktypes.ConsensusParams
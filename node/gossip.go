@@ -4,6 +4,7 @@ import (
 	"context"
 
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/host"
 )
 
 const (
@@ -13,6 +14,27 @@ const (
 	TopicDiscResp = "discovery_response"
 )
 
+// newGossipSub is the default pubsub constructor for a Node.
+func newGossipSub(ctx context.Context, h host.Host) (*pubsub.PubSub, error) {
+	return pubsub.NewGossipSub(ctx, h)
+}
+
+// startGossip starts the ACK, consensus reset, and discovery gossip.
+func (n *Node) startGossip(ctx context.Context, ps *pubsub.PubSub) error {
+	if !n.archive { // archive nodes neither send nor need ACKs
+		if err := n.startAckGossip(ctx, ps); err != nil {
+			return err
+		}
+	}
+	if err := n.startConsensusResetGossip(ctx, ps); err != nil {
+		return err
+	}
+	if err := n.startDiscoveryRequestGossip(ctx, ps); err != nil {
+		return err
+	}
+	return n.startDiscoveryResponseGossip(ctx, ps)
+}
+
 func subTopic(_ context.Context, ps *pubsub.PubSub, topic string) (*pubsub.Topic, *pubsub.Subscription, error) {
 	th, err := ps.Join(topic)
 	if err != nil {
@@ -14,6 +14,10 @@ type ConsensusEngine interface {
 	Role() types.Role // maybe: Role() (rol types.Role, power int64)
 
 	AcceptProposal(height int64, blkID, prevBlkID types.Hash, leaderSig []byte, timestamp int64) bool
+	// ValidateProposal checks a proposed block without executing it, returning
+	// an error that describes a problem with the block. It is for diagnostics
+	// only, since execution decides whether the block is accepted.
+	ValidateProposal(ctx context.Context, blk *ktypes.Block) error
 	NotifyBlockProposal(blk *ktypes.Block)
 
 	AcceptCommit(height int64, blkID types.Hash, appHash types.Hash, leaderSig []byte) bool
@@ -23,15 +27,34 @@ type ConsensusEngine interface {
 
 	NotifyResetState(height int64)
 
+	// ResetState aborts any block in progress and returns to the last
+	// committed block, which must be at the given height.
+	ResetState(height int64) error
+
 	NotifyDiscoveryMessage(validatorPK []byte, height int64)
 
 	Start(ctx context.Context, proposerBroadcaster consensus.ProposalBroadcaster,
 		blkAnnouncer consensus.BlkAnnouncer, ackBroadcaster consensus.AckBroadcaster,
 		blkRequester consensus.BlkRequester, stateResetter consensus.ResetStateBroadcaster, discoveryBroadcaster consensus.DiscoveryReqBroadcaster) error
 
+	// CaughtUp returns a channel that is closed when the engine has caught up
+	// with the network after Start.
+	CaughtUp() <-chan struct{}
+
 	CheckTx(ctx context.Context, tx *ktypes.Transaction) error
 
+	// RecheckAccounts discards the unconfirmed states of the senders' accounts
+	// and rechecks their transactions in mempool.
+	RecheckAccounts(ctx context.Context, senders [][]byte)
+
+	// WaitCommit blocks until any block commit that is in progress is
+	// complete, or the context is done.
+	WaitCommit(ctx context.Context) error
+
 	ConsensusParams() *ktypes.ConsensusParams
+
+	// Validators returns the current validator set.
+	Validators() []*ktypes.Validator
 }
 
 type SnapshotStore interface {
@@ -0,0 +1,115 @@
+// Package nodetest provides the test helpers that are shared by the tests of
+// the node package and the nodetesting harness. The node package's own tests
+// cannot import nodetesting, which imports node.
+package nodetest
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net"
+
+	"github.com/kwilteam/kwil-db/core/crypto"
+	ktypes "github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/consensus"
+	"github.com/kwilteam/kwil-db/node/types"
+
+	p2pcrypto "github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	mock "github.com/libp2p/go-libp2p/p2p/net/mock"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// blackholeIP6 is the prefix of the addresses of the mocknet hosts, which are
+// never dialed.
+var blackholeIP6 = net.ParseIP("100::")
+
+// NewHost adds a host with a new secp256k1 key to the mocknet, and returns it
+// with its key.
+func NewHost(mn mock.Mocknet) (host.Host, crypto.PrivateKey, error) {
+	p2pKey, _, err := p2pcrypto.GenerateSecp256k1Key(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	id, err := peer.IDFromPrivateKey(p2pKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	suffix := id
+	if len(id) > 8 {
+		suffix = id[len(id)-8:]
+	}
+	ip := append(net.IP{}, blackholeIP6...)
+	copy(ip[net.IPv6len-len(suffix):], suffix)
+	addr, err := ma.NewMultiaddr(fmt.Sprintf("/ip6/%s/tcp/4242", ip)) // e.g. /ip6/100::1bb1:760e:df55:9ed1/tcp/4242
+	if err != nil {
+		return nil, nil, err
+	}
+	h, err := mn.AddPeer(p2pKey, addr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	raw, err := p2pKey.Raw()
+	if err != nil {
+		return nil, nil, err
+	}
+	privKey, err := crypto.ParsePrivateKey(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	return h, privKey, nil
+}
+
+var closedChan = func() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}()
+
+// StubCE is a consensus engine that accepts all transactions, proposals, and
+// commits without producing blocks. Like the real engine, it runs until its
+// context is canceled, so the node keeps running.
+type StubCE struct {
+	NodeRole types.Role
+}
+
+func (ce *StubCE) Role() types.Role { return ce.NodeRole }
+
+func (ce *StubCE) AcceptProposal(int64, types.Hash, types.Hash, []byte, int64) bool { return true }
+
+func (ce *StubCE) ValidateProposal(context.Context, *ktypes.Block) error { return nil }
+
+func (ce *StubCE) NotifyBlockProposal(*ktypes.Block) {}
+
+func (ce *StubCE) AcceptCommit(int64, types.Hash, types.Hash, []byte) bool { return true }
+
+func (ce *StubCE) NotifyBlockCommit(*ktypes.Block, types.Hash) {}
+
+func (ce *StubCE) NotifyACK([]byte, types.AckRes) {}
+
+func (ce *StubCE) NotifyResetState(int64) {}
+
+func (ce *StubCE) ResetState(int64) error { return nil }
+
+func (ce *StubCE) NotifyDiscoveryMessage([]byte, int64) {}
+
+func (ce *StubCE) Start(ctx context.Context, _ consensus.ProposalBroadcaster,
+	_ consensus.BlkAnnouncer, _ consensus.AckBroadcaster, _ consensus.BlkRequester,
+	_ consensus.ResetStateBroadcaster, _ consensus.DiscoveryReqBroadcaster) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (ce *StubCE) CaughtUp() <-chan struct{} { return closedChan }
+
+func (ce *StubCE) CheckTx(context.Context, *ktypes.Transaction) error { return nil }
+
+func (ce *StubCE) RecheckAccounts(context.Context, [][]byte) {}
+
+func (ce *StubCE) WaitCommit(context.Context) error { return nil }
+
+func (ce *StubCE) ConsensusParams() *ktypes.ConsensusParams { return nil }
+
+func (ce *StubCE) Validators() []*ktypes.Validator { return nil }
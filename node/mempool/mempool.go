@@ -1,7 +1,11 @@
 package mempool
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"errors"
+	"fmt"
 	"slices"
 	"sync"
 
@@ -9,6 +13,15 @@ import (
 	"github.com/kwilteam/kwil-db/node/types"
 )
 
+var (
+	// ErrMempoolFull is returned by Store when there is no room for the
+	// transaction, even after evicting lower priority transactions.
+	ErrMempoolFull = errors.New("mempool is full")
+	// ErrAccountLimit is returned by Store when the sender's transactions in
+	// mempool would exceed the per-account size limit.
+	ErrAccountLimit = errors.New("account mempool limit exceeded")
+)
+
 // mempool is an index of unconfirmed transactions
 
 type Mempool struct {
@@ -17,15 +30,110 @@ type Mempool struct {
 	txQ      []types.NamedTx
 	fetching map[types.Hash]bool
 	// acctTxns map[string][]types.NamedTx
+
+	maxSize     int64 // max total bytes of all txns, no limit if <= 0
+	maxAcctSize int64 // max total bytes of one account's txns, no limit if <= 0
+
+	priorityTypes map[ktypes.PayloadType]bool // the priority lane
+
+	acctTurnTxs int // txns selected from an account in each turn, receive order if <= 0
+
+	size      int64                // total bytes of all txns
+	txSizes   map[types.Hash]int64 // serialized size of each tx
+	acctSizes map[string]int64     // total bytes of each account's txns
+}
+
+// DefaultPriorityTypes are the payload types of the validator and governance
+// transactions that are in the priority lane by default.
+var DefaultPriorityTypes = []ktypes.PayloadType{
+	ktypes.PayloadTypeValidatorJoin,
+	ktypes.PayloadTypeValidatorLeave,
+	ktypes.PayloadTypeValidatorRemove,
+	ktypes.PayloadTypeValidatorApprove,
+	ktypes.PayloadTypeValidatorVoteIDs,
+	ktypes.PayloadTypeCreateResolution,
+	ktypes.PayloadTypeApproveResolution,
+	ktypes.PayloadTypeDeleteResolution,
+}
+
+type options struct {
+	maxSize       int64
+	maxAcctSize   int64
+	priorityTypes []ktypes.PayloadType
+	acctTurnTxs   int
+}
+
+type Option func(*options)
+
+// WithMaxSize limits the total serialized size in bytes of the transactions
+// in mempool. When full, lower priority transactions are evicted to make room.
+func WithMaxSize(bytes int64) Option {
+	return func(o *options) {
+		o.maxSize = bytes
+	}
+}
+
+// WithMaxAccountSize limits the total serialized size in bytes of the
+// transactions in mempool from any one account.
+func WithMaxAccountSize(bytes int64) Option {
+	return func(o *options) {
+		o.maxAcctSize = bytes
+	}
+}
+
+// WithPriorityTypes sets the payload types of the transactions in the priority
+// lane, which are selected ahead of all other transactions, and are never
+// evicted to make room for other transactions. The default is
+// DefaultPriorityTypes. With no types, there is no priority lane.
+func WithPriorityTypes(payloadTypes ...ktypes.PayloadType) Option {
+	return func(o *options) {
+		o.priorityTypes = payloadTypes
+	}
 }
 
-func New() *Mempool {
+// WithAccountTurns makes the selection of transactions for a block fair to
+// all accounts. Instead of the order they were received, the accounts take
+// turns, each contributing up to n of its transactions in nonce order in each
+// turn, so that one account with many transactions cannot fill a block while
+// others wait. The accounts take turns in the order of their oldest
+// transaction. The priority lane is selected first regardless. If n <= 0,
+// transactions are selected in the order they were received.
+func WithAccountTurns(n int) Option {
+	return func(o *options) {
+		o.acctTurnTxs = n
+	}
+}
+
+func New(opts ...Option) *Mempool {
+	options := &options{
+		priorityTypes: DefaultPriorityTypes,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	priorityTypes := make(map[ktypes.PayloadType]bool, len(options.priorityTypes))
+	for _, pt := range options.priorityTypes {
+		priorityTypes[pt] = true
+	}
+
 	return &Mempool{
-		txns:     make(map[types.Hash]*ktypes.Transaction),
-		fetching: make(map[types.Hash]bool),
+		txns:          make(map[types.Hash]*ktypes.Transaction),
+		fetching:      make(map[types.Hash]bool),
+		maxSize:       options.maxSize,
+		maxAcctSize:   options.maxAcctSize,
+		priorityTypes: priorityTypes,
+		acctTurnTxs:   options.acctTurnTxs,
+		txSizes:       make(map[types.Hash]int64),
+		acctSizes:     make(map[string]int64),
 	}
 }
 
+// priority indicates if the transaction is in the priority lane.
+func (mp *Mempool) priority(tx *ktypes.Transaction) bool {
+	return mp.priorityTypes[tx.Body.PayloadType]
+}
+
 func (mp *Mempool) Have(txid types.Hash) bool { // this is racy
 	mp.mtx.RLock()
 	defer mp.mtx.RUnlock()
@@ -47,17 +155,89 @@ func (mp *Mempool) remove(txid types.Hash) {
 		return
 	}
 	mp.txQ = slices.Delete(mp.txQ, idx, idx+1) // remove txQ[idx]
+	mp.forget(txid)
+}
+
+// forget removes the tx from the index and the size accounting, but not from
+// the queue. To remove many transactions, forget each of them and then
+// dropForgotten once.
+func (mp *Mempool) forget(txid types.Hash) {
+	tx, have := mp.txns[txid]
+	if !have {
+		return
+	}
 	delete(mp.txns, txid)
+
+	sz := mp.txSizes[txid]
+	delete(mp.txSizes, txid)
+	mp.size -= sz
+
+	sender := string(tx.Sender)
+	if mp.acctSizes[sender] -= sz; mp.acctSizes[sender] <= 0 {
+		delete(mp.acctSizes, sender)
+	}
+}
+
+// dropForgotten removes the transactions that are no longer in the index from
+// the queue in a single pass.
+func (mp *Mempool) dropForgotten() {
+	mp.txQ = slices.DeleteFunc(mp.txQ, func(tx types.NamedTx) bool {
+		_, have := mp.txns[tx.Hash]
+		return !have
+	})
 }
 
-func (mp *Mempool) Store(txid types.Hash, tx *ktypes.Transaction) {
+// Store adds a transaction to mempool. If the mempool is full, lower priority
+// transactions are evicted to make room for it. ErrMempoolFull is returned if
+// it cannot be made to fit, and ErrAccountLimit is returned if it would exceed
+// the sender's limit. A nil tx removes the transaction with the given ID.
+func (mp *Mempool) Store(txid types.Hash, tx *ktypes.Transaction) error {
+	_, err := mp.Add(txid, tx)
+	return err
+}
+
+// Add is like Store, but it also returns the transactions that were evicted to
+// make room for the new one. Since the evicted transactions, or a rejected new
+// one, may have already been applied to the application's unconfirmed account
+// states when they were checked, the caller should recheck their senders'
+// remaining transactions with RecheckAccountTxs.
+func (mp *Mempool) Add(txid types.Hash, tx *ktypes.Transaction) (evicted []types.NamedTx, err error) {
 	mp.mtx.Lock()
 	defer mp.mtx.Unlock()
 	delete(mp.fetching, txid)
 
 	if tx == nil { // legacy semantics for removal
 		mp.remove(txid)
-		return
+		return nil, nil
+	}
+
+	if _, have := mp.txns[txid]; have {
+		return nil, nil
+	}
+
+	rawTx, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	sz := int64(len(rawTx))
+
+	sender := string(tx.Sender)
+	if mp.maxAcctSize > 0 && mp.acctSizes[sender]+sz > mp.maxAcctSize {
+		return nil, fmt.Errorf("%w: account has %d bytes pending, tx is %d bytes, limit is %d",
+			ErrAccountLimit, mp.acctSizes[sender], sz, mp.maxAcctSize)
+	}
+
+	if mp.maxSize > 0 && mp.size+sz > mp.maxSize {
+		evict, ok := mp.evictionCandidates(tx, mp.size+sz-mp.maxSize)
+		if !ok {
+			return nil, fmt.Errorf("%w: %d of %d bytes used, tx is %d bytes",
+				ErrMempoolFull, mp.size, mp.maxSize, sz)
+		}
+		for _, txid := range evict {
+			evicted = append(evicted, types.NamedTx{Hash: txid, Tx: mp.txns[txid]})
+			mp.forget(txid)
+		}
+		mp.dropForgotten()
 	}
 
 	mp.txns[txid] = tx
@@ -65,6 +245,65 @@ func (mp *Mempool) Store(txid types.Hash, tx *ktypes.Transaction) {
 		Hash: txid,
 		Tx:   tx,
 	})
+	mp.txSizes[txid] = sz
+	mp.acctSizes[sender] += sz
+	mp.size += sz
+
+	return evicted, nil
+}
+
+// evictionCandidates selects the transactions to evict to free at least need
+// bytes for the new transaction. The lowest priority transactions are those
+// with the largest nonce gap from the lowest nonce in mempool for the same
+// sender, and then the oldest. The transaction with the lowest nonce of each
+// sender is the next to be executed, and it is never evicted, nor are
+// transactions in the priority lane. If the new transaction would itself be
+// evicted, or enough room cannot be made, ok is false.
+func (mp *Mempool) evictionCandidates(newTx *ktypes.Transaction, need int64) (evict []types.Hash, ok bool) {
+	txns := append(slices.Clip(mp.txQ), types.NamedTx{Tx: newTx})
+
+	nextNonces := make(map[string]uint64, len(mp.acctSizes)+1)
+	for _, tx := range txns {
+		sender := string(tx.Tx.Sender)
+		if nonce, have := nextNonces[sender]; !have || tx.Tx.Body.Nonce < nonce {
+			nextNonces[sender] = tx.Tx.Body.Nonce
+		}
+	}
+
+	type candidate struct {
+		idx int // position in the queue, or len(txQ) for the new tx
+		gap uint64
+	}
+	var candidates []candidate
+	for i, tx := range txns {
+		gap := tx.Tx.Body.Nonce - nextNonces[string(tx.Tx.Sender)]
+		if gap == 0 || mp.priority(tx.Tx) {
+			continue // the sender's next tx, or in the priority lane
+		}
+		candidates = append(candidates, candidate{i, gap})
+	}
+
+	slices.SortFunc(candidates, func(a, b candidate) int {
+		if c := cmp.Compare(b.gap, a.gap); c != 0 {
+			return c // largest gap first
+		}
+		return cmp.Compare(a.idx, b.idx) // then oldest first
+	})
+
+	var freed int64
+	for _, c := range candidates {
+		if freed >= need {
+			break
+		}
+		if c.idx == len(mp.txQ) {
+			return nil, false // the new tx has the lowest priority
+		}
+		txid := mp.txQ[c.idx].Hash
+		evict = append(evict, txid)
+		freed += mp.txSizes[txid]
+	}
+
+	return evict, freed >= need
 }
 
 func (mp *Mempool) PreFetch(txid types.Hash) bool { // probably make node business
@@ -82,49 +321,189 @@ func (mp *Mempool) PreFetch(txid types.Hash) bool { // probably make node busine
 	return true // go get it
 }
 
+// Bytes returns the total serialized size of the transactions in mempool.
+func (mp *Mempool) Bytes() int64 {
+	mp.mtx.RLock()
+	defer mp.mtx.RUnlock()
+	return mp.size
+}
+
 func (mp *Mempool) Size() int {
 	mp.mtx.RLock()
 	defer mp.mtx.RUnlock()
 	return len(mp.txQ)
 }
 
+// ContiguousNonce returns the highest nonce of the sender's transactions in
+// mempool that follow the confirmed nonce without a gap. For example, with
+// confirmed nonce 0 and transactions with nonces 1, 2, and 4 in mempool, it is
+// 2, and the sender's next transaction should use nonce 3. It is the confirmed
+// nonce if the sender has no transaction with nonce confirmed+1.
+func (mp *Mempool) ContiguousNonce(sender []byte, confirmed uint64) uint64 {
+	mp.mtx.RLock()
+	defer mp.mtx.RUnlock()
+
+	pending := make(map[uint64]bool)
+	for _, tx := range mp.txQ {
+		if bytes.Equal(tx.Tx.Sender, sender) {
+			pending[tx.Tx.Body.Nonce] = true
+		}
+	}
+
+	nonce := confirmed
+	for pending[nonce+1] {
+		nonce++
+	}
+	return nonce
+}
+
 func (mp *Mempool) Get(txid types.Hash) *ktypes.Transaction {
 	mp.mtx.RLock()
 	defer mp.mtx.RUnlock()
 	return mp.txns[txid]
 }
 
-// ReapN extracts the first n transactions in the queue
+// ordered returns the queue in the order that transactions should be selected
+// for a block. The transactions in the priority lane come first, each preceded
+// by any of the same sender's transactions with lower nonces so that the
+// sender's nonce order is respected. The remaining transactions follow in the
+// order they were received, or with the accounts taking turns if set with
+// WithAccountTurns.
+func (mp *Mempool) ordered() []types.NamedTx {
+	if len(mp.priorityTypes) == 0 || !slices.ContainsFunc(mp.txQ, func(tx types.NamedTx) bool {
+		return mp.priority(tx.Tx)
+	}) {
+		return mp.takeTurns(mp.txQ)
+	}
+
+	txns := make([]types.NamedTx, 0, len(mp.txQ))
+	selected := make(map[types.Hash]bool)
+	sel := func(tx types.NamedTx) {
+		txns = append(txns, tx)
+		selected[tx.Hash] = true
+	}
+
+	for _, tx := range mp.txQ {
+		if !mp.priority(tx.Tx) || selected[tx.Hash] {
+			continue
+		}
+		for _, prev := range mp.txQ {
+			if !selected[prev.Hash] && bytes.Equal(prev.Tx.Sender, tx.Tx.Sender) &&
+				prev.Tx.Body.Nonce < tx.Tx.Body.Nonce {
+				sel(prev)
+			}
+		}
+		sel(tx)
+	}
+
+	var rest []types.NamedTx
+	for _, tx := range mp.txQ {
+		if !selected[tx.Hash] {
+			rest = append(rest, tx)
+		}
+	}
+
+	return append(txns, mp.takeTurns(rest)...)
+}
+
+// takeTurns orders the transactions with the accounts taking turns, each
+// contributing up to acctTurnTxs of its transactions in nonce order in each
+// turn. The accounts take turns in the order of their first transaction in
+// txns. The transactions are returned unchanged if acctTurnTxs is not set.
+func (mp *Mempool) takeTurns(txns []types.NamedTx) []types.NamedTx {
+	if mp.acctTurnTxs <= 0 {
+		return txns
+	}
+
+	var senders []string
+	acctTxns := make(map[string][]types.NamedTx)
+	for _, tx := range txns {
+		sender := string(tx.Tx.Sender)
+		if _, have := acctTxns[sender]; !have {
+			senders = append(senders, sender)
+		}
+		acctTxns[sender] = append(acctTxns[sender], tx)
+	}
+	for _, sender := range senders {
+		slices.SortStableFunc(acctTxns[sender], func(a, b types.NamedTx) int {
+			return cmp.Compare(a.Tx.Body.Nonce, b.Tx.Body.Nonce)
+		})
+	}
+
+	turns := make([]types.NamedTx, 0, len(txns))
+	for len(turns) < len(txns) {
+		for _, sender := range senders {
+			pending := acctTxns[sender]
+			n := min(mp.acctTurnTxs, len(pending))
+			turns = append(turns, pending[:n]...)
+			acctTxns[sender] = pending[n:]
+		}
+	}
+	return turns
+}
+
+// ReapN extracts the first n transactions in the queue, with the transactions
+// in the priority lane first.
 func (mp *Mempool) ReapN(n int) []types.NamedTx {
 	mp.mtx.Lock()
 	defer mp.mtx.Unlock()
-	n = min(n, len(mp.txQ))
-	txns := slices.Clone(mp.txQ[:n])
-	mp.txQ = mp.txQ[n:]
+	ordered := mp.ordered()
+	n = min(n, len(ordered))
+	txns := slices.Clone(ordered[:n])
 	for _, tx := range txns {
-		delete(mp.txns, tx.Hash)
+		mp.forget(tx.Hash)
 	}
+	mp.dropForgotten()
 	return txns
 }
 
+// PeekN returns the first n transactions in the queue without removing them,
+// with the transactions in the priority lane first.
 func (mp *Mempool) PeekN(n int) []types.NamedTx {
 	mp.mtx.RLock()
 	defer mp.mtx.RUnlock()
-	n = min(n, len(mp.txns))
+	ordered := mp.ordered()
+	n = min(n, len(ordered))
 	txns := make([]types.NamedTx, n)
-	copy(txns, mp.txQ)
+	copy(txns, ordered)
 	return txns
 }
 
 type CheckFn func(ctx context.Context, tx *ktypes.Transaction, recheck bool) error
 
+// RecheckTxs checks each transaction in the queue with fn, in order, and
+// removes the ones that fail. This should be done after a block is committed
+// to drop transactions that are no longer valid, such as those with nonces
+// consumed by the block.
 func (mp *Mempool) RecheckTxs(ctx context.Context, fn CheckFn) {
-	mp.mtx.RLock()
-	defer mp.mtx.RUnlock()
+	mp.mtx.Lock()
+	defer mp.mtx.Unlock()
 
 	for _, tx := range mp.txQ {
 		if err := fn(ctx, tx.Tx, true); err != nil {
-			mp.remove(tx.Hash)
+			mp.forget(tx.Hash)
+		}
+	}
+	mp.dropForgotten()
+}
+
+// RecheckAccountTxs is like RecheckTxs, but only for the transactions from the
+// given senders. This should be done after discarding the unconfirmed states
+// of the senders' accounts, such as when one of their transactions that was
+// already checked is rejected or evicted by Add.
+func (mp *Mempool) RecheckAccountTxs(ctx context.Context, senders [][]byte, fn CheckFn) {
+	mp.mtx.Lock()
+	defer mp.mtx.Unlock()
+
+	for _, tx := range mp.txQ {
+		if !slices.ContainsFunc(senders, func(sender []byte) bool {
+			return bytes.Equal(sender, tx.Tx.Sender)
+		}) {
+			continue
+		}
+		if err := fn(ctx, tx.Tx, true); err != nil {
+			mp.forget(tx.Hash)
 		}
 	}
+	mp.dropForgotten()
 }
@@ -1,6 +1,8 @@
 package mempool
 
 import (
+	"context"
+	"errors"
 	"math/big"
 	"testing"
 
@@ -120,3 +122,246 @@ func Test_MempoolReapN(t *testing.T) {
 	zeroReap := m.ReapN(0)
 	assert.Empty(t, zeroReap)
 }
+
+func txSize(t *testing.T, tx *ktypes.Transaction) int64 {
+	rawTx, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return int64(len(rawTx))
+}
+
+func Test_MempoolSizeEviction(t *testing.T) {
+	txA1, txA2, txA3 := newTx(1, "A"), newTx(2, "A"), newTx(3, "A")
+	txB1, txB2 := newTx(1, "B"), newTx(2, "B")
+	sz := txSize(t, txA1) // all the same size
+
+	// Room for four txns.
+	m := New(WithMaxSize(4 * sz))
+
+	assert.NoError(t, m.Store(types.Hash{1}, txA1))
+	assert.NoError(t, m.Store(types.Hash{2}, txA2))
+	assert.NoError(t, m.Store(types.Hash{3}, txA3))
+	assert.NoError(t, m.Store(types.Hash{4}, txB1))
+	assert.Equal(t, 4*sz, m.Bytes())
+
+	// A3 has the largest nonce gap, so it is evicted for B2.
+	evicted, err := m.Add(types.Hash{5}, txB2)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.NamedTx{{Hash: types.Hash{3}, Tx: txA3}}, evicted)
+	assert.Equal(t, 4, m.Size())
+	assert.Equal(t, 4*sz, m.Bytes())
+	assert.False(t, m.Have(types.Hash{3}))
+
+	// A4 would have the largest nonce gap, so it is rejected rather than
+	// evicting anything.
+	err = m.Store(types.Hash{6}, newTx(4, "A"))
+	assert.ErrorIs(t, err, ErrMempoolFull)
+	assert.Equal(t, 4, m.Size())
+
+	// C1 is the next tx for C. A2 and B2 have the same gap, but A2 is older.
+	assert.NoError(t, m.Store(types.Hash{7}, newTx(1, "C")))
+	assert.False(t, m.Have(types.Hash{2}))
+	assert.True(t, m.Have(types.Hash{5}))
+
+	// D1 evicts B2, and then only the next txns of each account remain, which
+	// are never evicted.
+	assert.NoError(t, m.Store(types.Hash{8}, newTx(1, "D")))
+	assert.False(t, m.Have(types.Hash{5}))
+	err = m.Store(types.Hash{9}, newTx(1, "E"))
+	assert.ErrorIs(t, err, ErrMempoolFull)
+	for _, txid := range []types.Hash{{1}, {4}, {7}, {8}} {
+		assert.True(t, m.Have(txid))
+	}
+
+	// Reaping frees space.
+	m.ReapN(2)
+	assert.Equal(t, 2*sz, m.Bytes())
+	assert.NoError(t, m.Store(types.Hash{9}, newTx(1, "E")))
+	assert.Equal(t, 3*sz, m.Bytes())
+}
+
+func Test_MempoolRecheckAccountTxs(t *testing.T) {
+	m := New()
+	for i, tx := range []*ktypes.Transaction{
+		newTx(1, "A"), newTx(1, "B"), newTx(2, "A"), newTx(2, "B"), newTx(1, "C"),
+	} {
+		assert.NoError(t, m.Store(types.Hash{byte(i + 1)}, tx))
+	}
+
+	// Only A's and C's txns are rechecked, and A2 fails.
+	m.RecheckAccountTxs(context.Background(), [][]byte{[]byte("A"), []byte("C")},
+		func(_ context.Context, tx *ktypes.Transaction, recheck bool) error {
+			assert.True(t, recheck)
+			assert.NotEqual(t, "B", string(tx.Sender))
+			if tx.Body.Nonce == 2 {
+				return errors.New("invalid nonce")
+			}
+			return nil
+		})
+	assert.Equal(t, 4, m.Size())
+	assert.False(t, m.Have(types.Hash{3}))
+	assert.True(t, m.Have(types.Hash{4}))
+}
+
+func Test_MempoolAccountLimit(t *testing.T) {
+	sz := txSize(t, newTx(1, "A"))
+
+	// Room for two txns per account.
+	m := New(WithMaxAccountSize(2 * sz))
+
+	assert.NoError(t, m.Store(types.Hash{1}, newTx(1, "A")))
+	assert.NoError(t, m.Store(types.Hash{2}, newTx(2, "A")))
+	err := m.Store(types.Hash{3}, newTx(3, "A"))
+	assert.ErrorIs(t, err, ErrAccountLimit)
+	assert.False(t, m.Have(types.Hash{3}))
+
+	// Other accounts are not affected.
+	assert.NoError(t, m.Store(types.Hash{4}, newTx(1, "B")))
+
+	// Removing one of A's txns makes room for another.
+	m.Remove(types.Hash{1})
+	assert.NoError(t, m.Store(types.Hash{3}, newTx(3, "A")))
+	assert.Equal(t, 3, m.Size())
+	assert.Equal(t, 3*sz, m.Bytes())
+}
+
+func Test_MempoolPriorityLane(t *testing.T) {
+	newFeeTx := func(nonce uint64, sender string, fee int64) *ktypes.Transaction {
+		tx := newTx(nonce, sender)
+		tx.Body.Fee = big.NewInt(fee)
+		tx.Body.PayloadType = ktypes.PayloadTypeExecute
+		return tx
+	}
+	joinTx := newTx(1, "V")
+	joinTx.Body.PayloadType = ktypes.PayloadTypeValidatorJoin
+
+	m := New()
+	assert.NoError(t, m.Store(types.Hash{1}, newFeeTx(1, "A", 1000)))
+	assert.NoError(t, m.Store(types.Hash{2}, newFeeTx(1, "B", 1000)))
+	assert.NoError(t, m.Store(types.Hash{3}, newFeeTx(2, "A", 1000)))
+	assert.NoError(t, m.Store(types.Hash{4}, joinTx))
+
+	// With room for only two txns in the block, the join is selected first,
+	// despite arriving last with a lower fee.
+	txns := m.PeekN(2)
+	assert.Len(t, txns, 2)
+	assert.Equal(t, types.Hash{4}, txns[0].Hash)
+	assert.Equal(t, types.Hash{1}, txns[1].Hash)
+
+	// A resolution from A follows A's earlier txns to respect nonce order,
+	// but they are all ahead of B's tx.
+	resTx := newTx(3, "A")
+	resTx.Body.PayloadType = ktypes.PayloadTypeCreateResolution
+	assert.NoError(t, m.Store(types.Hash{5}, resTx))
+
+	txns = m.ReapN(4)
+	assert.Len(t, txns, 4)
+	assert.Equal(t, types.Hash{4}, txns[0].Hash)
+	assert.Equal(t, types.Hash{1}, txns[1].Hash)
+	assert.Equal(t, types.Hash{3}, txns[2].Hash)
+	assert.Equal(t, types.Hash{5}, txns[3].Hash)
+	assert.Equal(t, 1, m.Size())
+	assert.True(t, m.Have(types.Hash{2}))
+
+	// Without a priority lane, the txns are selected in the order received.
+	m = New(WithPriorityTypes())
+	assert.NoError(t, m.Store(types.Hash{1}, newFeeTx(1, "A", 1000)))
+	assert.NoError(t, m.Store(types.Hash{4}, joinTx))
+	txns = m.PeekN(1)
+	assert.Equal(t, types.Hash{1}, txns[0].Hash)
+}
+
+func Test_MempoolPriorityNotEvicted(t *testing.T) {
+	joinTx := newTx(2, "V")
+	joinTx.Body.PayloadType = ktypes.PayloadTypeValidatorJoin
+	sz := txSize(t, joinTx)
+
+	m := New(WithMaxSize(3 * sz))
+	assert.NoError(t, m.Store(types.Hash{1}, newTx(1, "V")))
+	assert.NoError(t, m.Store(types.Hash{2}, joinTx))
+	assert.NoError(t, m.Store(types.Hash{3}, newTx(1, "A")))
+
+	// The join is not an eviction candidate, although it has the same nonce
+	// gap as A2 and is older, so there is no room for A2.
+	err := m.Store(types.Hash{4}, newTx(2, "A"))
+	assert.ErrorIs(t, err, ErrMempoolFull)
+	assert.True(t, m.Have(types.Hash{2}))
+}
+
+func Test_MempoolContiguousNonce(t *testing.T) {
+	m := New()
+
+	// A has 1, 2, and 4 pending, B has only 5 pending.
+	for i, tx := range []*ktypes.Transaction{
+		newTx(4, "A"), newTx(1, "A"), newTx(2, "A"), newTx(5, "B"),
+	} {
+		assert.NoError(t, m.Store(types.Hash{byte(i + 1)}, tx))
+	}
+
+	assert.Equal(t, uint64(2), m.ContiguousNonce([]byte("A"), 0))
+	assert.Equal(t, uint64(2), m.ContiguousNonce([]byte("A"), 1))
+	assert.Equal(t, uint64(4), m.ContiguousNonce([]byte("A"), 3))
+	assert.Equal(t, uint64(3), m.ContiguousNonce([]byte("B"), 3)) // gap at 4
+	assert.Equal(t, uint64(5), m.ContiguousNonce([]byte("B"), 4))
+	assert.Equal(t, uint64(7), m.ContiguousNonce([]byte("C"), 7)) // none pending
+
+	// Filling the gap extends the run.
+	assert.NoError(t, m.Store(types.Hash{9}, newTx(3, "A")))
+	assert.Equal(t, uint64(4), m.ContiguousNonce([]byte("A"), 0))
+}
+
+func Test_MempoolAccountTurns(t *testing.T) {
+	m := New(WithAccountTurns(2))
+
+	// A floods the mempool before B's txns arrive, and A's arrive out of
+	// nonce order.
+	var h byte
+	store := func(nonce uint64, sender string) {
+		h++
+		assert.NoError(t, m.Store(types.Hash{h}, newTx(nonce, sender)))
+	}
+	store(2, "A")
+	store(1, "A")
+	for nonce := uint64(3); nonce <= 10; nonce++ {
+		store(nonce, "A")
+	}
+	for nonce := uint64(1); nonce <= 4; nonce++ {
+		store(nonce, "B")
+	}
+
+	type acctNonce struct {
+		sender string
+		nonce  uint64
+	}
+	selected := func(txns []types.NamedTx) []acctNonce {
+		var sel []acctNonce
+		for _, tx := range txns {
+			sel = append(sel, acctNonce{string(tx.Tx.Sender), tx.Tx.Body.Nonce})
+		}
+		return sel
+	}
+
+	// The accounts take turns with two txns each, in nonce order.
+	assert.Equal(t, []acctNonce{
+		{"A", 1}, {"A", 2}, {"B", 1}, {"B", 2},
+		{"A", 3}, {"A", 4}, {"B", 3}, {"B", 4},
+	}, selected(m.PeekN(8)))
+
+	// When B has no more txns, the rest are A's.
+	txns := m.ReapN(10)
+	assert.Equal(t, []acctNonce{
+		{"A", 1}, {"A", 2}, {"B", 1}, {"B", 2},
+		{"A", 3}, {"A", 4}, {"B", 3}, {"B", 4},
+		{"A", 5}, {"A", 6},
+	}, selected(txns))
+	assert.Equal(t, 4, m.Size())
+
+	// Without turns, A's txns fill the block in the order received.
+	m = New()
+	h = 0
+	store(1, "A")
+	store(2, "A")
+	store(1, "B")
+	assert.Equal(t, []acctNonce{{"A", 1}, {"A", 2}}, selected(m.PeekN(2)))
+}
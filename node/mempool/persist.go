@@ -0,0 +1,95 @@
+package mempool
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	ktypes "github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/types"
+)
+
+// SaveTxs writes transactions to a file, replacing any existing file, so that
+// they may be restored with LoadTxs, such as after a restart. Each transaction
+// is written with its serialized length.
+func SaveTxs(filePath string, txns []types.NamedTx) error {
+	f, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("creating temporary mempool file: %w", err)
+	}
+	tmpName := f.Name()
+	defer os.Remove(tmpName) // fails harmlessly after a successful rename
+
+	w := bufio.NewWriter(f)
+	for _, tx := range txns {
+		var rawTx []byte
+		if rawTx, err = tx.Tx.MarshalBinary(); err != nil {
+			break
+		}
+		if err = binary.Write(w, binary.LittleEndian, uint32(len(rawTx))); err != nil {
+			break
+		}
+		if _, err = w.Write(rawTx); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("writing mempool file: %w", err)
+	}
+
+	if err = os.Rename(tmpName, filePath); err != nil {
+		return fmt.Errorf("replacing mempool file: %w", err)
+	}
+	return nil
+}
+
+// LoadTxs reads the transactions written to a file by SaveTxs, in the same
+// order. If the file does not exist, there are no transactions.
+func LoadTxs(filePath string) ([]types.NamedTx, error) {
+	f, err := os.Open(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening mempool file: %w", err)
+	}
+	defer f.Close()
+
+	var txns []types.NamedTx
+	r := bufio.NewReader(f)
+	for {
+		var length uint32
+		err = binary.Read(r, binary.LittleEndian, &length)
+		if errors.Is(err, io.EOF) {
+			return txns, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading mempool file: %w", err)
+		}
+		rawTx := make([]byte, length)
+		if _, err = io.ReadFull(r, rawTx); err != nil {
+			return nil, fmt.Errorf("reading mempool file: %w", err)
+		}
+		tx := new(ktypes.Transaction)
+		if err = tx.UnmarshalBinary(rawTx); err != nil {
+			return nil, fmt.Errorf("invalid transaction in mempool file: %w", err)
+		}
+		txns = append(txns, types.NamedTx{
+			Hash: types.HashBytes(rawTx),
+			Tx:   tx,
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package mempool
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kwilteam/kwil-db/node/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveLoadTxs(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "mempool.bin")
+
+	t.Run("no file", func(t *testing.T) {
+		txns, err := LoadTxs(filePath)
+		require.NoError(t, err)
+		require.Empty(t, txns)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		var want []types.NamedTx
+		for i, sender := range []string{"A", "B", "A"} {
+			tx := newTx(uint64(i+1), sender)
+			rawTx, err := tx.MarshalBinary()
+			require.NoError(t, err)
+			want = append(want, types.NamedTx{Hash: types.HashBytes(rawTx), Tx: tx})
+		}
+		require.NoError(t, SaveTxs(filePath, want))
+
+		txns, err := LoadTxs(filePath)
+		require.NoError(t, err)
+		require.Len(t, txns, len(want))
+		for i, tx := range txns {
+			require.Equal(t, want[i].Hash, tx.Hash)
+			require.Equal(t, want[i].Tx.Body.Nonce, tx.Tx.Body.Nonce)
+			require.Equal(t, want[i].Tx.Sender, tx.Tx.Sender)
+		}
+
+		// Saving again replaces the file.
+		require.NoError(t, SaveTxs(filePath, want[:1]))
+		txns, err = LoadTxs(filePath)
+		require.NoError(t, err)
+		require.Len(t, txns, 1)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		data, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filePath, data[:len(data)-1], 0644))
+		_, err = LoadTxs(filePath)
+		require.Error(t, err)
+	})
+}
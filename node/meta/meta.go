@@ -4,10 +4,12 @@
 package meta
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"math/big"
 	"slices"
 
 	"github.com/kwilteam/kwil-db/common"
@@ -184,6 +186,11 @@ func StoreParams(ctx context.Context, db sql.TxMaker, params *common.NetworkPara
 		return err
 	}
 
+	_, err = tx.Execute(ctx, upsertParam, minFeeKey, minFeeBytes(params.MinFee))
+	if err != nil {
+		return err
+	}
+
 	return tx.Commit(ctx)
 }
 
@@ -224,8 +231,9 @@ func LoadParams(ctx context.Context, db sql.Executor) (*common.NetworkParameters
 		return nil, ErrParamsNotFound
 	}
 
-	if len(res.Rows) != 6 {
-		return nil, fmt.Errorf("internal bug: expected 6 rows, got %d", len(res.Rows))
+	// The min fee param is absent from stores created before it was added.
+	if len(res.Rows) != 6 && len(res.Rows) != 7 {
+		return nil, fmt.Errorf("internal bug: expected 6 or 7 rows, got %d", len(res.Rows))
 	}
 
 	params := &common.NetworkParameters{}
@@ -257,6 +265,10 @@ func LoadParams(ctx context.Context, db sql.Executor) (*common.NetworkParameters
 			params.MigrationStatus = types.MigrationStatus(value)
 		case maxVotesPerTx:
 			params.MaxVotesPerTx = int64(binary.LittleEndian.Uint64(value))
+		case minFeeKey:
+			if len(value) > 0 {
+				params.MinFee = new(big.Int).SetBytes(value)
+			}
 		default:
 			return nil, fmt.Errorf("internal bug: unknown param name: %s", param)
 		}
@@ -305,9 +317,21 @@ func diff(original, new *common.NetworkParameters) map[string][]byte {
 		d[maxVotesPerTx] = buf
 	}
 
+	if origFee, newFee := minFeeBytes(original.MinFee), minFeeBytes(new.MinFee); !bytes.Equal(origFee, newFee) {
+		d[minFeeKey] = newFee
+	}
+
 	return d
 }
 
+// minFeeBytes encodes the min fee param, with no bytes for no minimum.
+func minFeeBytes(minFee *big.Int) []byte {
+	if minFee == nil || minFee.Sign() <= 0 {
+		return []byte{}
+	}
+	return minFee.Bytes()
+}
+
 const (
 	maxBlockSizeKey = `max_block_size`
 	joinExpiryKey   = `join_expiry`
@@ -315,4 +339,5 @@ const (
 	disabledGasKey  = `disabled_gas_costs`
 	migrationStatus = `migration_status`
 	maxVotesPerTx   = `max_votes_per_tx`
+	minFeeKey       = `min_fee`
 )
@@ -16,34 +16,56 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/kwilteam/kwil-db/config"
 	"github.com/kwilteam/kwil-db/core/crypto"
 	"github.com/kwilteam/kwil-db/core/log"
 	ktypes "github.com/kwilteam/kwil-db/core/types"
 	adminTypes "github.com/kwilteam/kwil-db/core/types/admin"
 	chainTypes "github.com/kwilteam/kwil-db/core/types/chain"
+	"github.com/kwilteam/kwil-db/node/consensus"
 	"github.com/kwilteam/kwil-db/node/peers"
 	"github.com/kwilteam/kwil-db/node/types"
 
 	"github.com/libp2p/go-libp2p"
 	dht "github.com/libp2p/go-libp2p-kad-dht"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	p2pconnmgr "github.com/libp2p/go-libp2p/core/connmgr"
 	p2pcrypto "github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/core/pnet"
 	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/libp2p/go-libp2p/p2p/net/connmgr"
 	noise "github.com/libp2p/go-libp2p/p2p/security/noise"
 	"github.com/libp2p/go-libp2p/p2p/transport/tcp"
+	ws "github.com/libp2p/go-libp2p/p2p/transport/websocket"
 	"github.com/multiformats/go-multiaddr"
+	madns "github.com/multiformats/go-multiaddr-dns"
 	//libp2ptls "github.com/libp2p/go-libp2p/p2p/security/tls"
 )
 
 const (
-	blockTxCount    = 50 // for "mining"
+	blockTxCount    = 50      // for "mining"
+	dummyTxSize     = 123_000 // for broadcast
+	dummyTxInterval = 1 * time.Second
 	txReAnnInterval = 30 * time.Second
+
+	// shutdownCommitTimeout limits how long a graceful shutdown waits for a
+	// block commit that is in progress.
+	shutdownCommitTimeout = 30 * time.Second
+
+	// protectPeersInterval is how often the protected peers are updated for
+	// changes to the validator set.
+	protectPeersInterval = time.Minute
+
+	// archiveRoleInterval is how often an archive node checks that it has not
+	// become a validator.
+	archiveRoleInterval = 10 * time.Second
 )
 
 type peerManager interface {
@@ -51,6 +73,15 @@ type peerManager interface {
 	Start(context.Context) error
 	ConnectedPeers() []peers.PeerInfo
 	KnownPeers() ([]peers.PeerInfo, []peers.PeerInfo, []peers.PeerInfo)
+	AddPeer(peer.AddrInfo) (bool, error)
+	RemovePeer(peer.ID) error
+	ReloadAddrBook() (int, error)
+	SavePeers() error
+	Metrics() peers.Metrics
+	PendingDials() []peers.DialInfo
+	CancelDial(peer.ID) bool
+	Reconnect(context.Context, peer.ID) (network.Connectedness, error)
+	SetProtectedPeers([]peer.ID)
 }
 
 type Node struct {
@@ -76,9 +107,60 @@ type Node struct {
 	discReq  chan types.DiscoveryRequest  // from consensus engine, to gossip to leader for calculating best height of the validators during blocksync.
 	discResp chan types.DiscoveryResponse // from gossip, to consensus engine for calculating best height of the validators during blocksync.
 
+	newPubSub func(context.Context, host.Host) (*pubsub.PubSub, error)
+	// noGossip is set if the pubsub service failed to start, in which case the
+	// node is a follower using only the stream protocols.
+	noGossip atomic.Bool
+
 	wg        sync.WaitGroup
 	log       log.Logger
 	dhtCloser func() error
+	startTime time.Time
+	dummyTxs  *DummyTxConfig // creates dummy transactions if set (devnet mode)
+	timeouts  ProtocolTimeouts
+
+	// mempoolFile is where mempool is saved when stopped and restored from on
+	// startup, or empty if mempool is not persisted.
+	mempoolFile string
+
+	// reqLimiter limits the rate of resource requests served to each peer,
+	// or nil if there is no limit.
+	reqLimiter *peerLimiter
+
+	// archive is set for an archive node, which does not take part in
+	// consensus. See WithArchive.
+	archive bool
+
+	// maxLeaderSigLen is the maximum length of the leader's signature in the
+	// block proposal and announcement messages. See WithMaxLeaderSigLen.
+	maxLeaderSigLen int
+
+	// prefetched holds the blocks after the requested one from the last block
+	// range retrieved by getBlkHeight, by height.
+	prefetchMtx sync.Mutex
+	prefetched  map[int64]rangeBlk
+
+	rngMtx sync.Mutex
+	rng    *mrand2.Rand // for peer selection
+
+	// admitMtx serializes checking transactions and adding them to mempool,
+	// so that the unconfirmed account states match the mempool's contents.
+	admitMtx sync.Mutex
+
+	// draining is set when a graceful shutdown begins, after which new
+	// transactions are rejected and no transactions are gossiped.
+	draining atomic.Bool
+	stopMtx  sync.Mutex
+	stop     context.CancelFunc // cancels the context of Start
+	stopped  chan struct{}      // closed when Start returns
+	stopErr  error              // from closing the P2P services or removing blocks, set before stopped is closed
+
+	// resetting is set while ResetToHeight resets the node, during which new
+	// transactions are also rejected.
+	resetting atomic.Bool
+	// truncateHeight is the height above which Start removes the blocks from
+	// the block store once stopped, or -1. See ResetToHeight.
+	truncateHeight atomic.Int64
 }
 
 // NewNode creates a new node. The config struct is for required configuration,
@@ -96,10 +178,29 @@ func NewNode(cfg *Config, opts ...Option) (*Node, error) {
 
 	pubkey := cfg.PrivKey.Public()
 
-	var err error
+	dummyTxs, err := dummyTxConfig(options.dummyTxs)
+	if err != nil {
+		return nil, err
+	}
+
+	var timeouts ProtocolTimeouts
+	if options.timeouts != nil {
+		timeouts = *options.timeouts
+	}
+	timeouts = timeouts.withDefaults()
+
+	rndSrc := options.randSrc
+	if rndSrc == nil {
+		rndSrc = randSrc{}
+	}
+
 	host := options.host
 	if host == nil {
-		host, err = newHost(cfg.P2P.IP, cfg.P2P.Port, cfg.PrivKey)
+		cm, err := newConnManager(cfg.P2P)
+		if err != nil {
+			return nil, err
+		}
+		host, err = newHost(listenAddrs(cfg.P2P), cfg.P2P.Websocket, cfg.PrivKey, options.psk, cm)
 		if err != nil {
 			return nil, fmt.Errorf("cannot create host: %w", err)
 		}
@@ -112,10 +213,15 @@ func NewNode(cfg *Config, opts ...Option) (*Node, error) {
 		host, // tooo much, become minimal interface
 		func(ctx context.Context, peerID peer.ID) ([]peer.AddrInfo, error) {
 			return RequestPeers(ctx, host.ID(), host, logger)
-		}, RequiredStreamProtocols)
+		}, RequiredProtocols())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create peer manager: %w", err)
 	}
+	if cfg.P2P.LowWatermark > 0 {
+		pm.SetTargetConnections(cfg.P2P.LowWatermark)
+	}
+	pm.SetDisconnectRetention(time.Duration(cfg.P2P.PeerRetention))
+	pm.SetEvictionInterval(time.Duration(cfg.P2P.EvictionInterval))
 
 	// mode := dht.ModeClient
 	// if cfg.Snapshots.Enable {
@@ -165,16 +271,42 @@ func NewNode(cfg *Config, opts ...Option) (*Node, error) {
 		resetMsg:    make(chan ConsensusReset, 1),
 		discReq:     make(chan types.DiscoveryRequest, 1),
 		discResp:    make(chan types.DiscoveryResponse, 1),
+		newPubSub:   newGossipSub,
 		dhtCloser:   dht.Close,
+		startTime:   time.Now(),
+		stopped:     make(chan struct{}),
+		dummyTxs:    dummyTxs,
+		timeouts:    timeouts,
+		rng:         mrand2.New(rndSrc),
+		archive:     options.archive,
+
+		maxLeaderSigLen: options.maxLeaderSigLen,
+	}
+	if node.maxLeaderSigLen <= 0 {
+		node.maxLeaderSigLen = DefaultMaxLeaderSigLen
+	}
+	node.truncateHeight.Store(-1)
+	if options.peerRateLimit != nil {
+		node.reqLimiter = newPeerLimiter(*options.peerRateLimit)
+	}
+	if options.saveMempool {
+		node.mempoolFile = filepath.Join(cfg.RootDir, mempoolFileName)
 	}
 
 	host.SetStreamHandler(ProtocolIDTxAnn, node.txAnnStreamHandler)
 	host.SetStreamHandler(ProtocolIDBlkAnn, node.blkAnnStreamHandler)
-	host.SetStreamHandler(ProtocolIDBlock, node.blkGetStreamHandler)
-	host.SetStreamHandler(ProtocolIDBlockHeight, node.blkGetHeightStreamHandler)
-	host.SetStreamHandler(ProtocolIDTx, node.txGetStreamHandler)
+	host.SetStreamHandler(ProtocolIDBlkAnnTyped, node.blkAnnStreamHandler)
+	host.SetStreamHandler(ProtocolIDBlock, node.limitRequests(node.blkGetStreamHandler))
+	host.SetStreamHandler(ProtocolIDBlockGzip, node.limitRequests(node.blkGetStreamHandler))
+	host.SetStreamHandler(ProtocolIDBlockHeight, node.limitRequests(node.blkGetHeightStreamHandler))
+	host.SetStreamHandler(ProtocolIDBlockHeightGzip, node.limitRequests(node.blkGetHeightStreamHandler))
+	host.SetStreamHandler(ProtocolIDBlockRange, node.limitRequests(node.blkGetRangeStreamHandler))
+	host.SetStreamHandler(ProtocolIDBlockRangeGzip, node.limitRequests(node.blkGetRangeStreamHandler))
+	host.SetStreamHandler(ProtocolIDTx, node.limitRequests(node.txGetStreamHandler))
+	host.SetStreamHandler(peers.ProtocolIDPing, peers.PingStreamHandler)
 
 	host.SetStreamHandler(ProtocolIDBlockPropose, node.blkPropStreamHandler)
+	host.SetStreamHandler(ProtocolIDBlockProposeTyped, node.blkPropStreamHandler)
 	// host.SetStreamHandler(ProtocolIDACKProposal, node.blkAckStreamHandler)
 
 	if cfg.P2P.Pex {
@@ -188,6 +320,35 @@ func NewNode(cfg *Config, opts ...Option) (*Node, error) {
 	return node, nil
 }
 
+// dummyTxConfig applies the defaults to the devnet dummy transaction config,
+// and checks that the values are sane.
+func dummyTxConfig(cfg *DummyTxConfig) (*DummyTxConfig, error) {
+	if cfg == nil {
+		return nil, nil // not devnet mode
+	}
+	dummyTxs := *cfg
+	if dummyTxs.BlockTxCount == 0 {
+		dummyTxs.BlockTxCount = blockTxCount
+	}
+	if dummyTxs.TxSize == 0 {
+		dummyTxs.TxSize = dummyTxSize
+	}
+	if dummyTxs.Interval == 0 {
+		dummyTxs.Interval = dummyTxInterval
+	}
+
+	if dummyTxs.BlockTxCount < 0 {
+		return nil, fmt.Errorf("invalid dummy tx count %d", dummyTxs.BlockTxCount)
+	}
+	if dummyTxs.TxSize < 0 || dummyTxs.TxSize > consensus.MaxBlockSize {
+		return nil, fmt.Errorf("dummy tx size %d not in range (0, %d]", dummyTxs.TxSize, consensus.MaxBlockSize)
+	}
+	if dummyTxs.Interval < 0 {
+		return nil, fmt.Errorf("invalid dummy tx interval %v", dummyTxs.Interval)
+	}
+	return &dummyTxs, nil
+}
+
 func FormatPeerString(rawPubKey []byte, keyType crypto.KeyType, ip string, port int) string {
 	return fmt.Sprintf("%s#%d@%s", hex.EncodeToString(rawPubKey), keyType,
 		net.JoinHostPort(ip, strconv.Itoa(port)))
@@ -205,20 +366,21 @@ func addrs(h host.Host) []string {
 
 	addrs := make([]string, len(hosts))
 	for i, h := range hosts {
-		addrs[i] = fmt.Sprintf("%s:%d", h, ports[i])
+		addrs[i] = net.JoinHostPort(h, strconv.Itoa(ports[i])) // [ip6]:port
 	}
 	return addrs
 }
 
+// maddrs returns the host's full p2p multiaddrs, including the peer ID, for
+// all of its addresses and transports.
 func maddrs(h host.Host) []string {
-	hosts, ports, protocols := hostPort(h)
-	id := h.ID()
-	if len(hosts) == 0 {
+	p2pAddrs, err := peer.AddrInfoToP2pAddrs(&peer.AddrInfo{ID: h.ID(), Addrs: h.Addrs()})
+	if err != nil || len(p2pAddrs) == 0 {
 		return nil
 	}
-	addrs := make([]string, len(hosts))
-	for i, host := range hosts {
-		addrs[i] = fmt.Sprintf("/%s/%s/tcp/%d/p2p/%s", protocols[i], host, ports[i], id)
+	addrs := make([]string, len(p2pAddrs))
+	for i, addr := range p2pAddrs {
+		addrs[i] = addr.String()
 	}
 	return addrs
 }
@@ -235,25 +397,113 @@ func (n *Node) ID() string {
 	return n.host.ID().String()
 }
 
-// Start begins tx and block gossip, connects to any bootstrap peers, and begins
-// peer discovery.
-func (n *Node) Start(ctx context.Context, bootpeers ...string) error {
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
+// protectPeers protects the connections of the validators and the bootnodes
+// from being trimmed by the connection manager until ctx is done. The
+// validators are updated periodically since the validator set may change.
+func (n *Node) protectPeers(ctx context.Context, bootnodes []peer.ID) {
+	ticker := time.NewTicker(protectPeersInterval)
+	defer ticker.Stop()
 
-	n.host.Network().Notify(n.pm)
-	defer n.host.Network().StopNotify(n.pm)
+	for {
+		n.pm.SetProtectedPeers(append(slices.Clone(bootnodes), n.validatorPeerIDs()...))
 
-	ps, err := pubsub.NewGossipSub(ctx, n.host)
-	if err != nil {
-		return err
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchArchiveRole checks the role of an archive node until ctx is done,
+// logging an error if it becomes a validator or the leader, such as when it is
+// added to the validator set after Start.
+func (n *Node) watchArchiveRole(ctx context.Context) {
+	ticker := time.NewTicker(archiveRoleInterval)
+	defer ticker.Stop()
+
+	role := types.RoleSentry
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			role = n.checkArchiveRole(role)
+		}
+	}
+}
+
+// checkArchiveRole logs an error if the node's role has changed from prev to
+// validator or leader, and returns the current role. An archive node never
+// sends ACKs or block proposals, so it must be restarted without archive mode
+// to take part in consensus.
+func (n *Node) checkArchiveRole(prev types.Role) types.Role {
+	role := n.ce.Role()
+	if role != prev && role != types.RoleSentry {
+		n.log.Error("Archive node is now a validator but does not take part in consensus; restart it without archive mode", "role", role)
+	}
+	return role
+}
+
+// validatorPeerIDs returns the peer IDs of the current validators.
+func (n *Node) validatorPeerIDs() []peer.ID {
+	vals := n.ce.Validators()
+	peerIDs := make([]peer.ID, 0, len(vals))
+	for _, v := range vals {
+		pubkey, err := UnmarshalNodePubKey(v.PubKey)
+		if err != nil {
+			n.log.Warn("Invalid validator public key", "pubkey", v.PubKey, "error", err)
+			continue
+		}
+		peerIDStr, err := peers.PeerIDFromPubKey(pubkey)
+		if err != nil {
+			n.log.Warn("Cannot get validator peer ID", "pubkey", v.PubKey, "error", err)
+			continue
+		}
+		peerID, err := peer.Decode(peerIDStr)
+		if err != nil {
+			continue
+		}
+		peerIDs = append(peerIDs, peerID)
+	}
+	return peerIDs
+}
+
+// bootnodeIDs returns the peer IDs of the bootnodes. Invalid bootnodes are
+// skipped, since connectPeers reports them.
+func bootnodeIDs(bootpeers []string) []peer.ID {
+	var peerIDs []peer.ID
+	for _, bootpeer := range bootpeers {
+		addrs, err := peers.ConvertPeersToMultiAddr([]string{bootpeer})
+		if err != nil {
+			continue
+		}
+		info, err := peers.ParsePeerAddr(addrs[0])
+		if err != nil {
+			continue
+		}
+		peerIDs = append(peerIDs, info.ID)
 	}
+	return peerIDs
+}
 
+// connectPeers connects to the bootstrap peers, and then to the other peers in
+// the peer store, which includes the address book. If there are no peers to
+// connect to, a warning is logged since the node is isolated until another node
+// connects to it.
+func (n *Node) connectPeers(ctx context.Context, bootpeers []string) error {
 	bootpeersMA, err := peers.ConvertPeersToMultiAddr(bootpeers)
 	if err != nil {
 		return err
 	}
 
+	if len(bootpeersMA) == 0 && !slices.ContainsFunc(n.host.Peerstore().Peers(), func(p peer.ID) bool {
+		return p != n.host.ID()
+	}) {
+		n.log.Warn("No bootstrap peers or address book peers. This node is isolated until another node connects to it.")
+		return nil
+	}
+
 	// connect to bootstrap peers, if any
 	for i, peer := range bootpeersMA {
 		peerInfo, err := makePeerAddrInfo(peer)
@@ -261,7 +511,7 @@ func (n *Node) Start(ctx context.Context, bootpeers ...string) error {
 			n.log.Warnf("invalid bootnode address %v from setting %v", peer, bootpeers[i])
 			continue
 		}
-		err = connectPeerAddrInfo(ctx, peerInfo, n.host)
+		err = connectBootnode(ctx, peerInfo, n.host)
 		if err != nil {
 			n.log.Errorf("failed to connect to %v: %v", peer, err)
 			// Add it to the peer store anyway since this was specified as a
@@ -310,6 +560,58 @@ func (n *Node) Start(ctx context.Context, bootpeers ...string) error {
 		n.log.Infof("Connected to address book peer %v", peerID)
 	}
 
+	return nil
+}
+
+// Start begins tx and block gossip, connects to any bootstrap peers, and begins
+// peer discovery.
+func (n *Node) Start(ctx context.Context, bootpeers ...string) error {
+	defer close(n.stopped)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	n.stopMtx.Lock()
+	n.stop = cancel
+	n.stopMtx.Unlock()
+
+	if role := n.ce.Role(); n.archive && role != types.RoleSentry {
+		return fmt.Errorf("archive node cannot start as a %v", role)
+	}
+
+	n.host.Network().Notify(n.pm)
+	defer n.host.Network().StopNotify(n.pm)
+
+	ps, err := n.newPubSub(ctx, n.host)
+	if err != nil {
+		// Validators need gossip for ACKs, and the leader to receive them, but
+		// a follower can sync blocks and transactions with the stream
+		// protocols alone.
+		if role := n.ce.Role(); role != types.RoleSentry {
+			return fmt.Errorf("failed to start gossip as %v: %w", role, err)
+		}
+		n.log.Warn("Failed to start gossip, continuing as a follower with stream protocols only", "error", err)
+		n.noGossip.Store(true)
+	}
+
+	if err := n.connectPeers(ctx, bootpeers); err != nil {
+		return err
+	}
+
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		n.protectPeers(ctx, bootnodeIDs(bootpeers))
+	}()
+
+	if n.archive {
+		n.wg.Add(1)
+		go func() {
+			defer n.wg.Done()
+			n.watchArchiveRole(ctx)
+		}()
+	}
+
 	// Advertise the snapshotcatalog service if snapshots are enabled
 	// umm, but gotcha, if a node has previous snapshots but snapshots are disabled, these snapshots will be unusable.
 	if n.ss.Enabled() {
@@ -326,24 +628,11 @@ func (n *Node) Start(ctx context.Context, bootpeers ...string) error {
 		return err
 	}
 
-	if err := n.startAckGossip(ctx, ps); err != nil {
-		cancel()
-		return err
-	}
-
-	if err := n.startConsensusResetGossip(ctx, ps); err != nil {
-		cancel()
-		return err
-	}
-
-	if err := n.startDiscoveryRequestGossip(ctx, ps); err != nil {
-		cancel()
-		return err
-	}
-
-	if err := n.startDiscoveryResponseGossip(ctx, ps); err != nil {
-		cancel()
-		return err
+	if !n.noGossip.Load() {
+		if err := n.startGossip(ctx, ps); err != nil {
+			cancel()
+			return err
+		}
 	}
 
 	// custom stream-based gossip uses txAnnStreamHandler and announceTx.
@@ -372,25 +661,166 @@ func (n *Node) Start(ctx context.Context, bootpeers ...string) error {
 		n.pm.Start(ctx)
 	}()
 
+	// Restore the saved mempool once the consensus engine has caught up, so
+	// the transactions are checked against the current state rather than the
+	// state at the last shutdown. If the node stops first, the file is kept.
+	var mempoolRestored bool
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		select {
+		case <-n.ce.CaughtUp():
+			n.restoreMempool(ctx)
+			mempoolRestored = true
+		case <-ctx.Done():
+		}
+	}()
+
 	n.log.Info("Node started.")
 
 	<-ctx.Done()
 	n.log.Info("Stopping Node protocol handlers...")
 	n.wg.Wait()
 
+	if mempoolRestored {
+		n.saveMempool()
+	}
+
+	var closeErrs []error
+	if height := n.truncateHeight.Load(); height >= 0 {
+		// The consensus engine has stopped, so no blocks are being stored.
+		if err = n.bki.Truncate(height); err != nil {
+			n.log.Errorf("Failed to remove blocks above height %d: %v", height, err)
+			closeErrs = append(closeErrs, fmt.Errorf("failed to remove blocks above height %d: %w", height, err))
+		} else {
+			n.log.Warn("Removed blocks from the block store. Restore the application state to this height before restarting.", "height", height)
+		}
+	}
+
 	n.log.Info("Stopping P2P services...")
 
 	if err = n.dhtCloser(); err != nil {
-		n.log.Warn("Failed to cleanly stop the DHT service: %v", err)
+		n.log.Warnf("Failed to cleanly stop the DHT service: %v", err)
+		closeErrs = append(closeErrs, fmt.Errorf("failed to stop the DHT service: %w", err))
 	}
 	if err = n.host.Close(); err != nil {
-		n.log.Warn("Failed to cleanly stop P2P host: %v", err)
+		n.log.Warnf("Failed to cleanly stop P2P host: %v", err)
+		closeErrs = append(closeErrs, fmt.Errorf("failed to stop the P2P host: %w", err))
 	}
+	n.stopErr = errors.Join(closeErrs...)
 
 	n.log.Info("Node stopped.")
 	return nodeErr
 }
 
+// Shutdown gracefully stops the node. New transactions are rejected and
+// transaction gossip is stopped, any block commit that is in progress is given
+// time to complete, and the address book is saved. The node is then stopped,
+// which causes Start to return. Shutdown returns when the node has stopped or
+// the context is canceled. The block store is not closed, since the RPC
+// services may still be using it. It is closed by its owner once they stop.
+func (n *Node) Shutdown(ctx context.Context) error {
+	if !n.draining.CompareAndSwap(false, true) {
+		return errors.New("shutdown already in progress")
+	}
+	n.log.Info("Shutting down node, no longer accepting transactions")
+
+	commitCtx, cancel := context.WithTimeout(ctx, shutdownCommitTimeout)
+	err := n.ce.WaitCommit(commitCtx)
+	cancel()
+	if err != nil {
+		n.log.Warn("Stopping without waiting for block commit to complete", "error", err)
+	}
+
+	if err := n.pm.SavePeers(); err != nil {
+		n.log.Warn("Failed to save address book", "error", err)
+	}
+
+	return n.Stop(ctx)
+}
+
+// Stop stops a started node by canceling the context of Start, and waits for
+// Start to return or for ctx to be done. New transactions are rejected, and
+// gossip and the other protocols stop before the P2P services are closed. Any
+// error from closing the P2P services is returned. Stop may be called more
+// than once, and regardless of the context given to Start.
+//
+// Unlike Shutdown, Stop does not wait for a block commit in progress or save
+// the address book.
+func (n *Node) Stop(ctx context.Context) error {
+	n.stopMtx.Lock()
+	stop := n.stop
+	n.stopMtx.Unlock()
+	if stop == nil {
+		return errors.New("node not started")
+	}
+
+	n.draining.Store(true)
+	stop()
+
+	select {
+	case <-n.stopped:
+		return n.stopErr
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ResetToHeight is used by an operator to recover a node or network that is
+// stuck executing a block, or that committed a bad block. The height must not
+// be above the best block in the block store. New transactions are rejected
+// while the node is reset.
+//
+// At the best block, any block that is in progress is aborted, returning the
+// node's state to the last committed block, and the node then accepts
+// transactions again.
+//
+// Below the best block, the node waits for any block commit in progress and
+// stops, and the blocks above the height are then removed from the block
+// store. The node cannot roll back its application state, so that must be
+// restored to the height, such as from a snapshot, before the node is
+// restarted. Otherwise the node refuses to start since its application state
+// is ahead of its block store. Once restarted, it syncs the removed blocks
+// again.
+func (n *Node) ResetToHeight(ctx context.Context, height int64) error {
+	if height < 0 {
+		return fmt.Errorf("invalid height %d", height)
+	}
+	best, _, _ := n.bki.Best()
+	if height > best {
+		return fmt.Errorf("%w: height %d is above the best block %d", ErrFutureHeight, height, best)
+	}
+
+	// Stop accepting transactions before resetting.
+	if !n.resetting.CompareAndSwap(false, true) {
+		return errors.New("reset already in progress")
+	}
+	if n.draining.Load() {
+		n.resetting.Store(false)
+		return ErrShuttingDown
+	}
+
+	if height == best {
+		defer n.resetting.Store(false)
+		n.log.Warn("Resetting node state to the last committed block", "height", height)
+		n.setPrefetched(nil)
+		return n.ce.ResetState(height)
+	}
+
+	n.log.Warn("Stopping node to remove blocks above the reset height", "height", height, "best", best)
+	commitCtx, cancel := context.WithTimeout(ctx, shutdownCommitTimeout)
+	err := n.ce.WaitCommit(commitCtx)
+	cancel()
+	if err != nil {
+		n.resetting.Store(false)
+		return fmt.Errorf("block commit in progress: %w", err)
+	}
+
+	// Start removes the blocks once the consensus engine has stopped.
+	n.truncateHeight.Store(height)
+	return n.Stop(ctx)
+}
+
 // doStatesync attempts to perform statesync if the db is uninitialized.
 // It also initializes the blockstore with the initial block data at the
 // height of the discovered snapshot.
@@ -448,15 +878,173 @@ func (n *Node) Peers(context.Context) ([]*adminTypes.PeerInfo, error) {
 			addr = net.JoinHostPort(host, port)
 		}
 
+		var connectedAt int64
+		for _, conn := range conns {
+			if opened := conn.Stat().Opened; !opened.IsZero() &&
+				(connectedAt == 0 || opened.UnixMilli() < connectedAt) {
+				connectedAt = opened.UnixMilli()
+			}
+		}
+
 		peersInfo = append(peersInfo, &adminTypes.PeerInfo{
-			NodeInfo:   &adminTypes.NodeInfo{},
-			Inbound:    conns[0].Stat().Direction == network.DirInbound,
-			RemoteAddr: addr,
+			NodeInfo:    &adminTypes.NodeInfo{},
+			Inbound:     conns[0].Stat().Direction == network.DirInbound,
+			RemoteAddr:  addr,
+			ConnectedAt: connectedAt,
 		})
 	}
 	return peersInfo, nil
 }
 
+// AddPeer adds a peer to the node's address book and persists it. The peer is
+// specified by a full p2p multiaddress such as
+// /ip4/127.0.0.1/tcp/6600/p2p/16Uiu2HAm8iRUsTzYepLP8pdJL3645ACP7VBfZQ7yFbLfdb7WvkL7,
+// or by a bare peer ID if the node already knows the peer's addresses. If all
+// of the given addresses are already in the address book, the error is
+// peers.ErrPeerExists.
+func (n *Node) AddPeer(ctx context.Context, peerAddr string) error {
+	info, err := peers.ParsePeerAddr(peerAddr)
+	if err != nil {
+		return err
+	}
+	added, err := n.pm.AddPeer(*info)
+	if err != nil {
+		return err
+	}
+	if !added && len(info.Addrs) > 0 {
+		return peers.ErrPeerExists
+	}
+	return nil
+}
+
+// RemovePeer disconnects from the peer with the given ID, and removes it from
+// the node's address book.
+func (n *Node) RemovePeer(ctx context.Context, peerID string) error {
+	pid, err := peer.Decode(peerID)
+	if err != nil {
+		return fmt.Errorf("invalid peer ID %q: %w", peerID, err)
+	}
+	return n.pm.RemovePeer(pid)
+}
+
+// ListPeers returns the IDs of all peers in the node's address book.
+func (n *Node) ListPeers(context.Context) []string {
+	all, _, _ := n.pm.KnownPeers()
+	ids := make([]string, len(all))
+	for i, p := range all {
+		ids[i] = p.ID.String()
+	}
+	return ids
+}
+
+// KnownPeers returns all peers in the node's address book, connected first.
+func (n *Node) KnownPeers(context.Context) []*adminTypes.KnownPeer {
+	all, _, _ := n.pm.KnownPeers()
+	known := make([]*adminTypes.KnownPeer, len(all))
+	for i, p := range all {
+		known[i] = knownPeer(p)
+	}
+	return known
+}
+
+// PeerProtocols returns all of the protocols that a peer is known to support,
+// and the node's required protocols that the peer does not support.
+func (n *Node) PeerProtocols(_ context.Context, peerID string) (supported, missing []string, err error) {
+	pid, err := peer.Decode(peerID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid peer ID %q: %w", peerID, err)
+	}
+	ps := n.host.Peerstore()
+	protos, err := ps.GetProtocols(pid)
+	if err != nil {
+		return nil, nil, err
+	}
+	missingProtos, err := peers.MissingProtocols(ps, pid, requiredProtocols...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return protocol.ConvertToStrings(protos), protocol.ConvertToStrings(missingProtos), nil
+}
+
+// Protocols returns the stream protocols that the node requires of its peers,
+// and all of the protocols that it supports.
+func (n *Node) Protocols(context.Context) (required, supported []string) {
+	return protocol.ConvertToStrings(requiredProtocols), protocol.ConvertToStrings(SupportedProtocols())
+}
+
+// ReloadAddrBook adds any new peers from the node's address book file, and
+// returns the number of peers added.
+func (n *Node) ReloadAddrBook(context.Context) (int, error) {
+	return n.pm.ReloadAddrBook()
+}
+
+// PeerMetrics returns the node's peer counts and connection event counters.
+func (n *Node) PeerMetrics(context.Context) *adminTypes.PeerMetrics {
+	m := n.pm.Metrics()
+	return &adminTypes.PeerMetrics{
+		ConnectedPeers:    m.ConnectedPeers,
+		KnownPeers:        m.KnownPeers,
+		Connects:          m.Connects,
+		Disconnects:       m.Disconnects,
+		ReconnectAttempts: m.ReconnectAttempts,
+		FailedDials:       m.FailedDials,
+		Evictions:         m.Evictions,
+		DeadConnections:   m.DeadConnections,
+	}
+}
+
+// PendingDials returns the node's in-progress outbound dials, oldest first.
+func (n *Node) PendingDials(context.Context) []*adminTypes.PendingDial {
+	dials := n.pm.PendingDials()
+	pending := make([]*adminTypes.PendingDial, len(dials))
+	for i, d := range dials {
+		pending[i] = &adminTypes.PendingDial{
+			PeerID:  d.ID.String(),
+			Started: d.Started.UnixMilli(),
+		}
+	}
+	return pending
+}
+
+// CancelDial cancels the in-progress dials to the peer with the given ID. It
+// returns false if the peer was not being dialed.
+func (n *Node) CancelDial(_ context.Context, peerID string) (bool, error) {
+	pid, err := peer.Decode(peerID)
+	if err != nil {
+		return false, fmt.Errorf("invalid peer ID %q: %w", peerID, err)
+	}
+	return n.pm.CancelDial(pid), nil
+}
+
+// ReconnectPeer closes any connection to the peer with the given ID and dials
+// it again immediately. It returns the peer's connectedness after the dial.
+func (n *Node) ReconnectPeer(ctx context.Context, peerID string) (string, error) {
+	pid, err := peer.Decode(peerID)
+	if err != nil {
+		return "", fmt.Errorf("invalid peer ID %q: %w", peerID, err)
+	}
+	connectedness, err := n.pm.Reconnect(ctx, pid)
+	return connectedness.String(), err
+}
+
+func knownPeer(p peers.PeerInfo) *adminTypes.KnownPeer {
+	kp := &adminTypes.KnownPeer{
+		ID:     p.ID.String(),
+		Addrs:  make([]string, len(p.Addrs)),
+		Protos: make([]string, len(p.Protos)),
+	}
+	for i, addr := range p.Addrs {
+		kp.Addrs[i] = addr.String()
+	}
+	for i, proto := range p.Protos {
+		kp.Protos[i] = string(proto)
+	}
+	if !p.LastSeen.IsZero() {
+		kp.LastSeen = p.LastSeen.Unix()
+	}
+	return kp
+}
+
 func (n *Node) Status(ctx context.Context) (*adminTypes.Status, error) {
 	height, blkHash, appHash := n.bki.Best()
 	var addr string
@@ -482,9 +1070,16 @@ func (n *Node) Status(ctx context.Context) (*adminTypes.Status, error) {
 			PubKey: pkBytes,
 			// Power: 1,
 		},
+		StartTime: n.startTime,
 	}, nil
 }
 
+// ContiguousNonce returns the highest nonce of the account's transactions in
+// mempool that follow the confirmed nonce without a gap.
+func (n *Node) ContiguousNonce(acctID []byte, confirmed int64) int64 {
+	return int64(n.mp.ContiguousNonce(acctID, uint64(confirmed)))
+}
+
 func (n *Node) TxQuery(ctx context.Context, hash types.Hash, prove bool) (*ktypes.TxQueryResponse, error) {
 	tx, height, blkHash, blkIdx, err := n.bki.GetTx(hash)
 	if err != nil {
@@ -507,15 +1102,20 @@ func (n *Node) TxQuery(ctx context.Context, hash types.Hash, prove bool) (*ktype
 }
 
 func (n *Node) BroadcastTx(ctx context.Context, tx *ktypes.Transaction, _ /*sync TODO*/ uint8) (*ktypes.ResultBroadcastTx, error) {
+	if n.draining.Load() {
+		return nil, ErrShuttingDown
+	}
+	if n.resetting.Load() {
+		return nil, ErrResetting
+	}
+
 	rawTx, _ := tx.MarshalBinary()
 	txHash := types.HashBytes(rawTx)
 
-	if err := n.ce.CheckTx(ctx, tx); err != nil {
+	if err := n.admitTx(ctx, txHash, tx); err != nil {
 		return nil, err
 	}
 
-	n.mp.Store(txHash, tx)
-
 	n.log.Infof("broadcasting new tx %v", txHash)
 	n.announceTx(ctx, txHash, rawTx, n.host.ID())
 
@@ -566,7 +1166,9 @@ func (n *Node) ConsensusParams() *ktypes.ConsensusParams {
 	return n.ce.ConsensusParams()
 }
 
-var RequiredStreamProtocols = []protocol.ID{
+// requiredProtocols are the stream protocols that a peer must support for the
+// node to keep a connection with it.
+var requiredProtocols = []protocol.ID{
 	ProtocolIDDiscover,
 	ProtocolIDTx,
 	ProtocolIDTxAnn,
@@ -580,8 +1182,33 @@ var RequiredStreamProtocols = []protocol.ID{
 	ProtocolIDSnapshotMeta,
 }
 
+// optionalProtocols are the stream protocols that the node supports, but that
+// are not required of peers. Newer protocol versions and extensions go here so
+// that the node can still connect with older peers.
+var optionalProtocols = []protocol.ID{
+	ProtocolIDBlkAnnTyped,
+	ProtocolIDBlockProposeTyped,
+	ProtocolIDBlockRange,
+	ProtocolIDBlockGzip,
+	ProtocolIDBlockHeightGzip,
+	ProtocolIDBlockRangeGzip,
+	peers.ProtocolIDPing,
+}
+
+// RequiredProtocols returns the stream protocols that a peer must support to
+// remain connected to the node.
+func RequiredProtocols() []protocol.ID {
+	return slices.Clone(requiredProtocols)
+}
+
+// SupportedProtocols returns all of the stream protocols that the node
+// supports, which includes those returned by RequiredProtocols.
+func SupportedProtocols() []protocol.ID {
+	return slices.Concat(requiredProtocols, optionalProtocols)
+}
+
 func (n *Node) checkPeerProtos(ctx context.Context, peer peer.ID) error {
-	return peers.RequirePeerProtos(ctx, n.host.Peerstore(), peer, RequiredStreamProtocols...)
+	return peers.RequirePeerProtos(ctx, n.host.Peerstore(), peer, requiredProtocols...)
 }
 
 type randSrc struct{}
@@ -592,13 +1219,17 @@ func (randSrc) Uint64() uint64 {
 	return binary.LittleEndian.Uint64(b[:])
 }
 
-var rng = mrand2.New(randSrc{})
-
+// peers returns the connected peers in random order, so that requests are
+// spread among them. The peers are sorted before shuffling so that the order
+// depends only on the node's random source.
 func (n *Node) peers() []peer.ID {
 	peers := n.host.Network().Peers()
-	rng.Shuffle(len(peers), func(i, j int) {
+	slices.Sort(peers)
+	n.rngMtx.Lock()
+	n.rng.Shuffle(len(peers), func(i, j int) {
 		peers[i], peers[j] = peers[j], peers[i]
 	})
+	n.rngMtx.Unlock()
 	return peers
 }
 
@@ -612,14 +1243,87 @@ func NewKey(r io.Reader) crypto.PrivateKey {
 	return privKey
 }
 
-func newHost(ip string, port uint64, privKey crypto.PrivateKey) (host.Host, error) {
+// UnmarshalNodeKey decodes a node's private key, which may be a 32 byte
+// secp256k1 key or a 64 byte ed25519 key.
+func UnmarshalNodeKey(data []byte) (crypto.PrivateKey, error) {
+	key, err := crypto.ParsePrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node private key: %w", err)
+	}
+	return key, nil
+}
+
+// UnmarshalNodePubKey decodes a node's public key, which may be a compressed
+// or uncompressed secp256k1 key, or an ed25519 key.
+func UnmarshalNodePubKey(data []byte) (crypto.PublicKey, error) {
+	pub, err := crypto.ParsePublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node public key: %w", err)
+	}
+	return pub, nil
+}
+
+// LoadPSK reads a libp2p private network pre-shared key from a file in the
+// standard "/key/swarm/psk/1.0.0/" format, as created by tools such as
+// ipfs-swarm-key-gen.
+func LoadPSK(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	psk, err := pnet.DecodeV1PSK(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pre-shared key file %s: %w", path, err)
+	}
+	return psk, nil
+}
+
+// pskLen is the required length of a private network pre-shared key.
+const pskLen = 32
+
+// listenAddrs returns the multiaddrs on which to listen for P2P connections,
+// which are the configured ListenAddrs, or else the address from the IP and
+// Port settings.
+func listenAddrs(cfg *config.PeerConfig) []string {
+	if len(cfg.ListenAddrs) > 0 {
+		return cfg.ListenAddrs
+	}
+	proto := "ip4"
+	if ip := net.ParseIP(cfg.IP); ip != nil && ip.To4() == nil {
+		proto = "ip6"
+	}
+	return []string{fmt.Sprintf("/%s/%s/tcp/%d", proto, cfg.IP, cfg.Port)}
+}
+
+// newConnManager creates a connection manager that closes the least useful
+// connections, down to the low watermark, when there are more than the high
+// watermark. Connections younger than the grace period are not closed. It
+// returns nil if the high watermark is not set.
+func newConnManager(cfg *config.PeerConfig) (p2pconnmgr.ConnManager, error) {
+	if cfg.HighWatermark == 0 {
+		return nil, nil
+	}
+	if cfg.LowWatermark <= 0 || cfg.LowWatermark > cfg.HighWatermark {
+		return nil, fmt.Errorf("invalid connection watermarks: low %d, high %d",
+			cfg.LowWatermark, cfg.HighWatermark)
+	}
+	return connmgr.NewConnManager(cfg.LowWatermark, cfg.HighWatermark,
+		connmgr.WithGracePeriod(time.Duration(cfg.GracePeriod)))
+}
+
+// newHost creates a libp2p host listening on the given multiaddrs. The
+// websocket transport is added if websocket is true, which is required to
+// listen on or dial /ws addresses. The connection manager is optional.
+func newHost(listenAddrs []string, websocket bool, privKey crypto.PrivateKey, psk []byte,
+	cm p2pconnmgr.ConnManager) (host.Host, error) {
 	// convert to the libp2p crypto key type
 	var privKeyP2P p2pcrypto.PrivKey
 	var err error
 	switch kt := privKey.(type) {
 	case *crypto.Secp256k1PrivateKey:
 		privKeyP2P, err = p2pcrypto.UnmarshalSecp256k1PrivateKey(privKey.Bytes())
-	case *crypto.Ed25519PrivateKey: // TODO
+	case *crypto.Ed25519PrivateKey:
 		privKeyP2P, err = p2pcrypto.UnmarshalEd25519PrivateKey(privKey.Bytes())
 	default:
 		err = fmt.Errorf("unknown private key type %T", kt)
@@ -628,31 +1332,47 @@ func newHost(ip string, port uint64, privKey crypto.PrivateKey) (host.Host, erro
 		return nil, err
 	}
 
-	sourceMultiAddr, _ := multiaddr.NewMultiaddr(fmt.Sprintf("/ip4/%s/tcp/%d", ip, port))
-
-	// listenAddrs := libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0", "/ip4/0.0.0.0/tcp/0/ws")
+	if len(listenAddrs) == 0 {
+		return nil, errors.New("no listen addresses")
+	}
+	sourceMultiAddrs := make([]multiaddr.Multiaddr, len(listenAddrs))
+	for i, addr := range listenAddrs {
+		sourceMultiAddrs[i], err = multiaddr.NewMultiaddr(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid listen address %q: %w", addr, err)
+		}
+	}
 
 	// cg := peers.NewProtocolGater()
 
-	// The BasicConnManager can keep connections below an upper limit, dropping
-	// down to some lower limit (presumably to keep a dynamic peer list), but it
-	// won't try to initiation new connections to reach the min. Maybe this will
-	// be helpful later, so leaving as a comment:
-
-	// cm, err := connmgr.NewConnManager(60, 100, connmgr.WithGracePeriod(20*time.Minute)) // TODO: absorb this into peerman
-	// if err != nil {
-	// 	return nil, nil, err
-	// }
-
-	h, err := libp2p.New(
+	opts := []libp2p.Option{
 		libp2p.Transport(tcp.NewTCPTransport),
 		libp2p.Security(noise.ID, noise.New), // modified TLS based on node-ID
-		libp2p.ListenAddrs(sourceMultiAddr),
-		// listenAddrs,
+		libp2p.ListenAddrs(sourceMultiAddrs...),
 		libp2p.Identity(privKeyP2P),
 		// libp2p.ConnectionGater(cg),
-		// libp2p.ConnectionManager(cm),
-	) // libp2p.RandomIdentity, in-mem peer store, ...
+	} // libp2p.RandomIdentity, in-mem peer store, ...
+
+	// The connection manager only prunes connections. The PeerMan makes new
+	// connections when below its target, which is the low watermark.
+	if cm != nil {
+		opts = append(opts, libp2p.ConnectionManager(cm))
+	}
+
+	if websocket {
+		opts = append(opts, libp2p.Transport(ws.New))
+	}
+
+	if psk != nil {
+		// The connection to a peer with a different key fails before the
+		// security handshake completes.
+		if len(psk) != pskLen {
+			return nil, fmt.Errorf("pre-shared key must be %d bytes, got %d", pskLen, len(psk))
+		}
+		opts = append(opts, libp2p.PrivateNetwork(pnet.PSK(psk)))
+	}
+
+	h, err := libp2p.New(opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -662,15 +1382,29 @@ func newHost(ip string, port uint64, privKey crypto.PrivateKey) (host.Host, erro
 	return h, nil
 }
 
+// maHostProtocols are the multiaddr protocols that may provide the host of an
+// address, with the name used in a multiaddr string.
+var maHostProtocols = []struct {
+	code int
+	name string
+}{
+	{multiaddr.P_IP4, "ip4"},
+	{multiaddr.P_IP6, "ip6"},
+	{multiaddr.P_DNS, "dns"},
+	{multiaddr.P_DNS4, "dns4"},
+	{multiaddr.P_DNS6, "dns6"},
+}
+
+// maHostPort returns the host, TCP port, and host protocol (e.g. ip4 or dns4)
+// of a multiaddr. The host is empty if the address has no IP or DNS host.
 func maHostPort(addr multiaddr.Multiaddr) (host, port, protocol string) {
 	port, _ = addr.ValueForProtocol(multiaddr.P_TCP)
-	protocol = "ip4"
-	host, _ = addr.ValueForProtocol(multiaddr.P_IP4)
-	if host == "" {
-		host, _ = addr.ValueForProtocol(multiaddr.P_IP6)
-		protocol = "ip6"
+	for _, hp := range maHostProtocols {
+		if host, _ = addr.ValueForProtocol(hp.code); host != "" {
+			return host, port, hp.name
+		}
 	}
-	return
+	return "", port, ""
 }
 
 func hostPort(host host.Host) ([]string, []int, []string) {
@@ -679,6 +1413,9 @@ func hostPort(host host.Host) ([]string, []int, []string) {
 	var protocols []string              // ip4 or ip6
 	for _, addr := range host.Addrs() { // host.Network().ListenAddresses()
 		host, portStr, protocol := maHostPort(addr)
+		if host == "" {
+			continue // e.g. dnsaddr, which has no single host and port
+		}
 		port, _ := strconv.Atoi(portStr)
 		ports = append(ports, port)
 		addrStr = append(addrStr, host)
@@ -703,6 +1440,66 @@ func connectPeerAddrInfo(ctx context.Context, info *peer.AddrInfo, host host.Hos
 	return host.Connect(ctx, *info)
 }
 
+// connectBootnode resolves the bootnode's DNS addresses before connecting.
+// The unresolved addresses are what should be kept in the peerstore, so that a
+// seed node may change IP address.
+func connectBootnode(ctx context.Context, info *peer.AddrInfo, host host.Host) error {
+	resolved, err := resolvePeerAddrInfo(ctx, info)
+	if err != nil {
+		return err
+	}
+	return connectPeerAddrInfo(ctx, resolved, host)
+}
+
+// dnsResolver resolves the DNS components of peer addresses. It is a variable
+// so that tests may use a stub resolver.
+var dnsResolver = madns.DefaultResolver
+
+// maxDNSResolveDepth limits the rounds of resolution of DNS peer addresses,
+// since a dnsaddr record may itself contain DNS addresses.
+const maxDNSResolveDepth = 4
+
+// resolvePeerAddrInfo resolves any dns, dns4, dns6, and dnsaddr components of
+// the peer's addresses to IP addresses. Addresses from dnsaddr records for a
+// peer ID other than the peer's are discarded, so the peer ID is enforced. An
+// error is returned if no addresses for the peer remain.
+func resolvePeerAddrInfo(ctx context.Context, info *peer.AddrInfo) (*peer.AddrInfo, error) {
+	resolved := &peer.AddrInfo{ID: info.ID}
+	addrs := info.Addrs
+	for range maxDNSResolveDepth {
+		var next []multiaddr.Multiaddr
+		for _, addr := range addrs {
+			if !madns.Matches(addr) {
+				resolved.Addrs = append(resolved.Addrs, addr)
+				continue
+			}
+			rAddrs, err := dnsResolver.Resolve(ctx, addr)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve %v: %w", addr, err)
+			}
+			for _, rAddr := range rAddrs {
+				transport, id := peer.SplitAddr(rAddr)
+				if transport == nil || (id != "" && id != info.ID) {
+					continue // another peer in the dnsaddr records
+				}
+				next = append(next, transport)
+			}
+		}
+		if len(next) == 0 {
+			break
+		}
+		addrs = next
+	}
+
+	if len(resolved.Addrs) == 0 {
+		return nil, fmt.Errorf("no addresses resolved for peer %v", info.ID)
+	}
+	return resolved, nil
+}
+
+// connectPeer connects to the peer at a multiaddr, which must include the peer
+// ID, such as /ip4/127.0.0.1/tcp/6600/p2p/16Uiu2... or
+// /dnsaddr/seed.example.com/p2p/16Uiu2....
 func connectPeer(ctx context.Context, addr string, host host.Host) (*peer.AddrInfo, error) {
 	// Extract the peer ID and address info from the multiaddr.
 	info, err := makePeerAddrInfo(addr)
@@ -710,6 +1507,11 @@ func connectPeer(ctx context.Context, addr string, host host.Host) (*peer.AddrIn
 		return nil, err
 	}
 
+	info, err = resolvePeerAddrInfo(ctx, info)
+	if err != nil {
+		return nil, err
+	}
+
 	// Add the destination's peer multiaddress in the peerstore.
 	// This will be used during connection and stream creation by libp2p.
 	// host.Peerstore().AddAddrs(info.ID, info.Addrs, peerstore.PermanentAddrTTL)
@@ -371,14 +371,16 @@ func (d *dummyTxApp) GenesisInit(ctx context.Context, db sql.DB, validators []*k
 	return nil
 }
 
-func (d *dummyTxApp) AccountInfo(ctx context.Context, dbTx sql.DB, identifier []byte, pending bool) (*big.Int, int64, error) {
-	return big.NewInt(0), 0, nil
+func (d *dummyTxApp) AccountInfo(ctx context.Context, dbTx sql.DB, identifier []byte, pending bool) (*big.Int, int64, bool, error) {
+	return big.NewInt(0), 0, false, nil
 }
 
-func (d *dummyTxApp) ApplyMempool(ctx *common.TxContext, db sql.DB, tx *ktypes.Transaction) error {
+func (d *dummyTxApp) ApplyMempool(ctx *common.TxContext, db sql.DB, tx *ktypes.Transaction, recheck bool) error {
 	return nil
 }
 
+func (d *dummyTxApp) ResetMempoolAccounts([][]byte) {}
+
 type validatorStore struct {
 	valSet []*ktypes.Validator
 }
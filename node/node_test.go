@@ -2,69 +2,56 @@ package node
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto/rand"
 	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
+	mrand2 "math/rand/v2"
 	"net"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/kwilteam/kwil-db/config"
 	"github.com/kwilteam/kwil-db/core/crypto"
+	"github.com/kwilteam/kwil-db/core/crypto/auth"
 	"github.com/kwilteam/kwil-db/core/log"
 	ktypes "github.com/kwilteam/kwil-db/core/types"
 	"github.com/kwilteam/kwil-db/node/consensus"
+	"github.com/kwilteam/kwil-db/node/ident"
+	"github.com/kwilteam/kwil-db/node/internal/nodetest"
 	"github.com/kwilteam/kwil-db/node/mempool"
+	"github.com/kwilteam/kwil-db/node/peers"
 	"github.com/kwilteam/kwil-db/node/store/memstore"
 	"github.com/kwilteam/kwil-db/node/types"
 
-	p2pcrypto "github.com/libp2p/go-libp2p/core/crypto"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	p2pconnmgr "github.com/libp2p/go-libp2p/core/connmgr"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/core/protocol"
 	mock "github.com/libp2p/go-libp2p/p2p/net/mock"
 	ma "github.com/multiformats/go-multiaddr"
+	madns "github.com/multiformats/go-multiaddr-dns"
 )
 
-var blackholeIP6 = net.ParseIP("100::")
-
 func newTestHost(t *testing.T, mn mock.Mocknet) ([]byte, host.Host, error) {
-	privKey, _, err := p2pcrypto.GenerateSecp256k1Key(rand.Reader)
-	if err != nil {
-		t.Fatalf("Failed to generate private key: %v", err)
-	}
-	id, err := peer.IDFromPrivateKey(privKey)
-	if err != nil {
-		t.Fatalf("Failed to get private key: %v", err)
-	}
-	suffix := id
-	if len(id) > 8 {
-		suffix = id[len(id)-8:]
-	}
-	ip := append(net.IP{}, blackholeIP6...)
-	copy(ip[net.IPv6len-len(suffix):], suffix)
-	addr, err := ma.NewMultiaddr(fmt.Sprintf("/ip6/%s/tcp/4242", ip))
-	if err != nil {
-		t.Fatalf("Failed to create multiaddress: %v", err)
-	}
-	// t.Log(addr) // e.g. /ip6/100::1bb1:760e:df55:9ed1/tcp/4242
-	host, err := mn.AddPeer(privKey, addr)
+	host, privKey, err := nodetest.NewHost(mn)
 	if err != nil {
 		t.Fatalf("Failed to add peer to mocknet: %v", err)
 	}
-
-	pkBytes, err := privKey.Raw()
-	if err != nil {
-		t.Fatalf("Failed to get private key bytes: %v", err)
-	}
-	return pkBytes, host, nil
+	return privKey.Bytes(), host, nil
 }
 
 func fakeAppHash(height int64) types.Hash {
@@ -84,7 +71,7 @@ func createTestBlock(height int64, numTxns int) (*ktypes.Block, types.Hash) {
 func newGenesis(t *testing.T, nodekeys [][]byte) ([]crypto.PrivateKey, *config.GenesisConfig) {
 	var privKeys []crypto.PrivateKey
 	for _, nodekey := range nodekeys {
-		priv, err := crypto.UnmarshalSecp256k1PrivateKey(nodekey)
+		priv, err := UnmarshalNodeKey(nodekey)
 		if err != nil {
 			t.Fatalf("Failed to unmarshal private key: %v", err)
 		}
@@ -105,7 +92,7 @@ func newGenesis(t *testing.T, nodekeys [][]byte) ([]crypto.PrivateKey, *config.G
 }
 
 func setupStreamHandlers(t *testing.T, h host.Host) {
-	for _, proto := range RequiredStreamProtocols {
+	for _, proto := range RequiredProtocols() {
 		h.SetStreamHandler(proto, func(s network.Stream) {
 			t.Log("handling incoming stream for", proto)
 			s.Close()
@@ -127,6 +114,8 @@ type dummyCE struct {
 	blockCommitHandler func(blk *ktypes.Block, appHash types.Hash)
 	blockPropHandler   func(blk *ktypes.Block)
 	resetStateHandler  func(height int64)
+	resetHeight        int64 // from ResetState
+	validators         []*ktypes.Validator
 
 	// mtx     sync.Mutex
 	// gotACKs map[string]types.AckRes // from NotifyACK: string(validatorPK) -> AckRes
@@ -143,6 +132,10 @@ func (ce *dummyCE) AcceptProposal(height int64, blkID, prevBlkID types.Hash, lea
 	return !ce.rejectProp
 }
 
+func (ce *dummyCE) ValidateProposal(ctx context.Context, blk *ktypes.Block) error {
+	return nil
+}
+
 func (ce *dummyCE) AcceptCommit(height int64, blkID, appHash types.Hash, leaderSig []byte) bool {
 	return !ce.rejectCommit
 }
@@ -175,6 +168,11 @@ func (ce *dummyCE) NotifyResetState(height int64) {
 	}
 }
 
+func (ce *dummyCE) ResetState(height int64) error {
+	ce.resetHeight = height
+	return nil
+}
+
 func (ce *dummyCE) NotifyBlockProposal(blk *ktypes.Block) {
 	if ce.blockPropHandler != nil {
 		ce.blockPropHandler(blk)
@@ -188,14 +186,30 @@ func (ce *dummyCE) Role() types.Role {
 	return types.RoleLeader
 }
 
+func (ce *dummyCE) CaughtUp() <-chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}
+
 func (ce *dummyCE) CheckTx(ctx context.Context, tx *ktypes.Transaction) error {
 	return nil
 }
 
+func (ce *dummyCE) RecheckAccounts(ctx context.Context, senders [][]byte) {}
+
+func (ce *dummyCE) WaitCommit(ctx context.Context) error {
+	return nil
+}
+
 func (ce *dummyCE) ConsensusParams() *ktypes.ConsensusParams {
 	return nil
 }
 
+func (ce *dummyCE) Validators() []*ktypes.Validator {
+	return ce.validators
+}
+
 func (ce *dummyCE) Start(ctx context.Context, proposerBroadcaster consensus.ProposalBroadcaster,
 	blkAnnouncer consensus.BlkAnnouncer, ackBroadcaster consensus.AckBroadcaster,
 	blkRequester consensus.BlkRequester, stateResetter consensus.ResetStateBroadcaster, discReqBroadcaster consensus.DiscoveryReqBroadcaster) error {
@@ -379,7 +393,7 @@ func TestStreamsBlockFetch(t *testing.T) {
 				// t.Parallel()
 				unknownHash := types.Hash{1}
 				req, _ := blockHashReq{unknownHash}.MarshalBinary() // knownHash[:]
-				_, err := requestFrom(ctx, h2, h1.ID(), req, ProtocolIDBlock, 1e4)
+				_, err := requestFrom(ctx, h2, h1.ID(), req, ProtocolIDBlock, 1e4, txGetTimeout)
 				if err == nil {
 					t.Errorf("expected error but got none")
 				} else if !errors.Is(err, ErrNotFound) {
@@ -419,7 +433,7 @@ func TestStreamsBlockFetch(t *testing.T) {
 				// t.Parallel()
 				knownHash := blk1.Hash()
 				req, _ := blockHashReq{knownHash}.MarshalBinary() // knownHash[:]
-				resp, err := requestFrom(ctx, h2, h1.ID(), req, ProtocolIDBlock, 1e4)
+				resp, err := requestFrom(ctx, h2, h1.ID(), req, ProtocolIDBlock, 1e4, txGetTimeout)
 				if err != nil {
 					t.Errorf("ReadAll: %v", err)
 				} else if bytes.Equal(resp, noData) {
@@ -427,6 +441,76 @@ func TestStreamsBlockFetch(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "request by hash manually, compressed",
+			fn: func(t *testing.T) {
+				s, err := h2.NewStream(ctx, h1.ID(), ProtocolIDBlockGzip)
+				if err != nil {
+					t.Fatalf("Failed create new stream: %v", err)
+				}
+				defer s.Close()
+
+				knownHash := blk1.Hash()
+				_, err = s.Write(knownHash[:])
+				if err != nil {
+					t.Fatalf("Failed write to stream: %v", err)
+				}
+
+				b, err := io.ReadAll(s)
+				if err != nil {
+					t.Fatalf("ReadAll: %v", err)
+				}
+				zr, err := gzip.NewReader(bytes.NewReader(b))
+				if err != nil {
+					t.Fatalf("expected a gzip response: %v", err)
+				}
+				resp, err := io.ReadAll(zr)
+				if err != nil {
+					t.Fatalf("failed to decompress response: %v", err)
+				}
+				if !bytes.Equal(resp, blkResp(blk1, appHash1)) {
+					t.Error("decompressed response does not match the block")
+				}
+			},
+		},
+		{
+			name: "request by hash using requestFrom, compressed round trip",
+			fn: func(t *testing.T) {
+				// Record the protocol that node1 serves the request on.
+				negotiated := make(chan protocol.ID, 1)
+				handler := node1.limitRequests(node1.blkGetStreamHandler)
+				for _, proto := range []protocol.ID{ProtocolIDBlock, ProtocolIDBlockGzip} {
+					h1.SetStreamHandler(proto, func(s network.Stream) {
+						negotiated <- s.Protocol()
+						handler(s)
+					})
+				}
+				defer func() {
+					h1.SetStreamHandler(ProtocolIDBlock, handler)
+					h1.SetStreamHandler(ProtocolIDBlockGzip, handler)
+				}()
+
+				knownHash := blk1.Hash()
+				req, _ := blockHashReq{knownHash}.MarshalBinary()
+				resp, err := requestFrom(ctx, h2, h1.ID(), req, ProtocolIDBlock, 1e4, txGetTimeout)
+				if err != nil {
+					t.Fatalf("requestFrom: %v", err)
+				}
+				if proto := <-negotiated; proto != ProtocolIDBlockGzip {
+					t.Fatalf("expected protocol %s, got %s", ProtocolIDBlockGzip, proto)
+				}
+				if !bytes.Equal(resp, blkResp(blk1, appHash1)) {
+					t.Fatal("response does not match the block")
+				}
+				blk, err := ktypes.DecodeBlock(resp[8+types.HashLen:])
+				if err != nil {
+					t.Fatalf("failed to decode block: %v", err)
+				}
+				if blk.Hash() != knownHash {
+					t.Errorf("expected block %v, got %v", knownHash, blk.Hash())
+				}
+			},
+		},
 		{
 			name: "request by height manually, unknown",
 			fn: func(t *testing.T) {
@@ -457,7 +541,7 @@ func TestStreamsBlockFetch(t *testing.T) {
 				// t.Parallel()
 				var height int64
 				req, _ := blockHeightReq{height}.MarshalBinary()
-				_, err := requestFrom(ctx, h2, h1.ID(), req, ProtocolIDBlockHeight, 1e4)
+				_, err := requestFrom(ctx, h2, h1.ID(), req, ProtocolIDBlockHeight, 1e4, txGetTimeout)
 				if err == nil {
 					t.Errorf("expected error but got none")
 				} else if !errors.Is(err, ErrNotFound) {
@@ -495,7 +579,7 @@ func TestStreamsBlockFetch(t *testing.T) {
 				// t.Parallel()
 				var height int64 = 1
 				req, _ := blockHeightReq{height}.MarshalBinary()
-				resp, err := requestFrom(ctx, h2, h1.ID(), req, ProtocolIDBlockHeight, 1e4)
+				resp, err := requestFrom(ctx, h2, h1.ID(), req, ProtocolIDBlockHeight, 1e4, txGetTimeout)
 				if err != nil {
 					t.Errorf("ReadAll: %v", err)
 				} else if bytes.Equal(resp, noData) {
@@ -503,9 +587,2210 @@ func TestStreamsBlockFetch(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "request by height manually, compressed",
+			fn: func(t *testing.T) {
+				s, err := h2.NewStream(ctx, h1.ID(), ProtocolIDBlockHeightGzip)
+				if err != nil {
+					t.Fatalf("Failed create new stream: %v", err)
+				}
+				defer s.Close()
+
+				req, _ := blockHeightReq{1}.MarshalBinary()
+				if _, err = s.Write(req); err != nil {
+					t.Fatalf("Failed write to stream: %v", err)
+				}
+
+				b, err := io.ReadAll(s)
+				if err != nil {
+					t.Fatalf("ReadAll: %v", err)
+				}
+				resp, err := decompressResp(b, 1e4)
+				if err != nil {
+					t.Fatalf("expected a gzip response: %v", err)
+				}
+				blkHash := blk1.Hash()
+				want := append(append(blkHash[:], appHash1[:]...), ktypes.EncodeBlock(blk1)...)
+				if !bytes.Equal(resp, want) {
+					t.Error("decompressed response does not match the block")
+				}
+			},
+		},
+		{
+			name: "request by height manually, compressed, unknown",
+			fn: func(t *testing.T) {
+				s, err := h2.NewStream(ctx, h1.ID(), ProtocolIDBlockHeightGzip)
+				if err != nil {
+					t.Fatalf("Failed create new stream: %v", err)
+				}
+				defer s.Close()
+
+				req, _ := blockHeightReq{2}.MarshalBinary()
+				if _, err = s.Write(req); err != nil {
+					t.Fatalf("Failed write to stream: %v", err)
+				}
+
+				// noData is never compressed
+				b, err := io.ReadAll(s)
+				if err != nil {
+					t.Errorf("ReadAll: %v", err)
+				} else if !bytes.Equal(b, noData) {
+					t.Error("expected a no-data response, got", b)
+				}
+			},
+		},
+		{
+			name: "request range using requestFrom, compressed round trip",
+			fn: func(t *testing.T) {
+				negotiated := make(chan protocol.ID, 1)
+				handler := node1.limitRequests(node1.blkGetRangeStreamHandler)
+				for _, proto := range []protocol.ID{ProtocolIDBlockRange, ProtocolIDBlockRangeGzip} {
+					h1.SetStreamHandler(proto, func(s network.Stream) {
+						negotiated <- s.Protocol()
+						handler(s)
+					})
+				}
+				defer func() {
+					h1.SetStreamHandler(ProtocolIDBlockRange, handler)
+					h1.SetStreamHandler(ProtocolIDBlockRangeGzip, handler)
+				}()
+
+				req, _ := blockRangeReq{Start: 1, Count: 10}.MarshalBinary()
+				resp, err := requestFrom(ctx, h2, h1.ID(), req, ProtocolIDBlockRange, 1e4, txGetTimeout)
+				if err != nil {
+					t.Fatalf("requestFrom: %v", err)
+				}
+				if proto := <-negotiated; proto != ProtocolIDBlockRangeGzip {
+					t.Fatalf("expected protocol %s, got %s", ProtocolIDBlockRangeGzip, proto)
+				}
+				blks, err := decodeBlkRange(resp)
+				if err != nil {
+					t.Fatalf("failed to decode block range: %v", err)
+				}
+				if len(blks) != 1 || blks[0].Hash != blk1.Hash() || blks[0].AppHash != appHash1 {
+					t.Errorf("unexpected block range response: %v", blks)
+				}
+			},
+		},
+		{
+			name: "request range using requestFrom, compressed, unknown",
+			fn: func(t *testing.T) {
+				req, _ := blockRangeReq{Start: 2, Count: 10}.MarshalBinary()
+				_, err := requestFrom(ctx, h2, h1.ID(), req, ProtocolIDBlockRange, 1e4, txGetTimeout)
+				if !errors.Is(err, ErrNotFound) {
+					t.Errorf("expected ErrNotFound, got %v", err)
+				}
+			},
+		},
 	}
 
 	for _, tt := range testCases {
 		t.Run(tt.name, tt.fn)
 	}
 }
+
+// blkResp is the content of a response to a request for a block by hash.
+func blkResp(blk *ktypes.Block, appHash types.Hash) []byte {
+	resp := binary.LittleEndian.AppendUint64(nil, uint64(blk.Header.Height))
+	resp = append(resp, appHash[:]...)
+	return append(resp, ktypes.EncodeBlock(blk)...)
+}
+
+func TestRequestFromUncompressedPeer(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+
+	_, h1, _ := newTestHost(t, mn)
+	_, h2, _ := newTestHost(t, mn)
+
+	blk, appHash := createTestBlock(1, 2)
+	want := blkResp(blk, appHash)
+
+	// h1 is an older peer that only sends uncompressed blocks.
+	h1.SetStreamHandler(ProtocolIDBlock, func(s network.Stream) {
+		defer s.Close()
+		var req blockHashReq
+		if _, err := req.ReadFrom(s); err != nil {
+			t.Errorf("failed to read request: %v", err)
+			return
+		}
+		s.Write(want)
+	})
+
+	if err := mn.LinkAll(); err != nil {
+		t.Fatalf("Failed to link hosts: %v", err)
+	}
+	if err := mn.ConnectAllButSelf(); err != nil {
+		t.Fatalf("Failed to connect hosts: %v", err)
+	}
+
+	req, _ := blockHashReq{blk.Hash()}.MarshalBinary()
+	resp, err := requestFrom(context.Background(), h2, h1.ID(), req, ProtocolIDBlock, 1e4, txGetTimeout)
+	if err != nil {
+		t.Fatalf("requestFrom: %v", err)
+	}
+	if !bytes.Equal(resp, want) {
+		t.Error("response does not match the block")
+	}
+}
+
+func TestDecompressRespLimit(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(make([]byte, 1000))
+	zw.Close()
+
+	if _, err := decompressResp(buf.Bytes(), 999); err == nil {
+		t.Error("expected an error for a response over the limit")
+	}
+	resp, err := decompressResp(buf.Bytes(), 1000)
+	if err != nil {
+		t.Fatalf("decompressResp: %v", err)
+	}
+	if len(resp) != 1000 {
+		t.Errorf("expected 1000 bytes, got %d", len(resp))
+	}
+	if _, err = decompressResp([]byte("not gzip"), 1000); err == nil {
+		t.Error("expected an error for an invalid response")
+	}
+}
+
+// closeTrackingBS is a memory block store that records if it was closed.
+type closeTrackingBS struct {
+	*memstore.MemBS
+	closed atomic.Bool
+}
+
+func (bs *closeTrackingBS) Close() error {
+	bs.closed.Store(true)
+	return bs.MemBS.Close()
+}
+
+// blockingCE is a dummyCE that, like the real consensus engine, runs until its
+// context is canceled. WaitCommit blocks until the commitDone channel is
+// closed, simulating a commit in progress.
+type blockingCE struct {
+	dummyCE
+	commitDone chan struct{}
+}
+
+func (ce *blockingCE) Start(ctx context.Context, proposerBroadcaster consensus.ProposalBroadcaster,
+	blkAnnouncer consensus.BlkAnnouncer, ackBroadcaster consensus.AckBroadcaster,
+	blkRequester consensus.BlkRequester, stateResetter consensus.ResetStateBroadcaster, discReqBroadcaster consensus.DiscoveryReqBroadcaster) error {
+	ce.dummyCE.Start(ctx, proposerBroadcaster, blkAnnouncer, ackBroadcaster, blkRequester, stateResetter, discReqBroadcaster)
+	<-ctx.Done()
+	return nil
+}
+
+func (ce *blockingCE) WaitCommit(ctx context.Context) error {
+	select {
+	case <-ce.commitDone:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestNodeShutdown(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+
+	pk1, h1, err := newTestHost(t, mn)
+	if err != nil {
+		t.Fatalf("Failed to add peer to mocknet: %v", err)
+	}
+	_, h2, err := newTestHost(t, mn)
+	if err != nil {
+		t.Fatalf("Failed to add peer to mocknet: %v", err)
+	}
+	for _, proto := range RequiredProtocols() {
+		h2.SetStreamHandler(proto, func(s network.Stream) { s.Close() })
+	}
+
+	// Count the tx announcements received by the test host.
+	var txAnns atomic.Int32
+	h2.SetStreamHandler(ProtocolIDTxAnn, func(s network.Stream) {
+		txAnns.Add(1)
+		s.Close()
+	})
+
+	privKeys, _ := newGenesis(t, [][]byte{pk1})
+	defaultConfigSet := config.DefaultConfig()
+
+	bs := &closeTrackingBS{MemBS: memstore.NewMemBS()}
+	ce := &blockingCE{commitDone: make(chan struct{})}
+	mp := mempool.New()
+	cfg1 := &Config{
+		RootDir:     t.TempDir(),
+		PrivKey:     privKeys[0],
+		Logger:      log.DiscardLogger,
+		P2P:         &defaultConfigSet.P2P,
+		DBConfig:    &defaultConfigSet.DB,
+		Statesync:   &defaultConfigSet.StateSync,
+		Mempool:     mp,
+		BlockStore:  bs,
+		Snapshotter: newSnapshotStore(),
+		Consensus:   ce,
+	}
+	node1, err := NewNode(cfg1, WithHost(h1))
+	if err != nil {
+		t.Fatalf("Failed to create Node 1: %v", err)
+	}
+
+	ctx := context.Background()
+	startErr := make(chan error, 1)
+	go func() {
+		startErr <- node1.Start(ctx)
+	}()
+
+	if err := mn.LinkAll(); err != nil {
+		t.Fatalf("Failed to link hosts: %v", err)
+	}
+	if err := mn.ConnectAllButSelf(); err != nil {
+		t.Fatalf("Failed to connect hosts: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// Transactions are announced before shutdown.
+	node1.announceTx(ctx, types.Hash{1}, []byte("tx1"), h1.ID())
+	for deadline := time.Now().Add(2 * time.Second); txAnns.Load() == 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := txAnns.Load(); n != 1 {
+		t.Fatalf("expected 1 tx announcement before shutdown, got %d", n)
+	}
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		shutdownErr <- node1.Shutdown(ctx)
+	}()
+	time.Sleep(50 * time.Millisecond) // shutdown is now waiting for the commit
+
+	// While draining, new transactions are rejected and nothing is announced.
+	tx, err := ktypes.CreateTransaction(&ktypes.Transfer{}, "kwil-test-chain", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := node1.BroadcastTx(ctx, tx, 0); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("expected ErrShuttingDown from BroadcastTx, got %v", err)
+	}
+	if mp.Size() != 0 {
+		t.Errorf("expected empty mempool, got %d txns", mp.Size())
+	}
+	node1.announceTx(ctx, types.Hash{2}, []byte("tx2"), h1.ID())
+	time.Sleep(100 * time.Millisecond)
+	if n := txAnns.Load(); n != 1 {
+		t.Errorf("expected no tx announcements after shutdown, got %d", n-1)
+	}
+
+	close(ce.commitDone)
+
+	select {
+	case err := <-shutdownErr:
+		if err != nil {
+			t.Fatalf("Shutdown failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for shutdown")
+	}
+	if err := <-startErr; err != nil {
+		t.Errorf("Start returned error: %v", err)
+	}
+	// The block store is closed by its owner, after the RPC services stop.
+	if bs.closed.Load() {
+		t.Error("block store closed by Shutdown")
+	}
+}
+
+func TestNodeStop(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+
+	pk1, h1, err := newTestHost(t, mn)
+	if err != nil {
+		t.Fatalf("Failed to add peer to mocknet: %v", err)
+	}
+	_, h2, err := newTestHost(t, mn)
+	if err != nil {
+		t.Fatalf("Failed to add peer to mocknet: %v", err)
+	}
+	for _, proto := range RequiredProtocols() {
+		h2.SetStreamHandler(proto, func(s network.Stream) { s.Close() })
+	}
+
+	var txAnns atomic.Int32
+	h2.SetStreamHandler(ProtocolIDTxAnn, func(s network.Stream) {
+		txAnns.Add(1)
+		s.Close()
+	})
+
+	privKeys, _ := newGenesis(t, [][]byte{pk1})
+	defaultConfigSet := config.DefaultConfig()
+
+	bs := &closeTrackingBS{MemBS: memstore.NewMemBS()}
+	cfg1 := &Config{
+		RootDir:     t.TempDir(),
+		PrivKey:     privKeys[0],
+		Logger:      log.DiscardLogger,
+		P2P:         &defaultConfigSet.P2P,
+		DBConfig:    &defaultConfigSet.DB,
+		Statesync:   &defaultConfigSet.StateSync,
+		Mempool:     mempool.New(),
+		BlockStore:  bs,
+		Snapshotter: newSnapshotStore(),
+		Consensus:   &nodetest.StubCE{},
+	}
+	node1, err := NewNode(cfg1, WithHost(h1))
+	if err != nil {
+		t.Fatalf("Failed to create Node 1: %v", err)
+	}
+
+	// Stopping a node that was never started fails.
+	if err := node1.Stop(context.Background()); err == nil {
+		t.Fatal("expected an error stopping a node that is not started")
+	}
+
+	// Start's context is never canceled, so only Stop can stop the node.
+	ctx := context.Background()
+	startErr := make(chan error, 1)
+	go func() {
+		startErr <- node1.Start(ctx)
+	}()
+
+	if err := mn.LinkAll(); err != nil {
+		t.Fatalf("Failed to link hosts: %v", err)
+	}
+	if err := mn.ConnectAllButSelf(); err != nil {
+		t.Fatalf("Failed to connect hosts: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	node1.announceTx(ctx, types.Hash{1}, []byte("tx1"), h1.ID())
+	for deadline := time.Now().Add(2 * time.Second); txAnns.Load() == 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := txAnns.Load(); n != 1 {
+		t.Fatalf("expected 1 tx announcement before stop, got %d", n)
+	}
+
+	stopCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := node1.Stop(stopCtx); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+
+	// Start has returned by the time Stop does.
+	select {
+	case err := <-startErr:
+		if err != nil {
+			t.Errorf("Start returned error: %v", err)
+		}
+	default:
+		t.Fatal("Start did not return before Stop")
+	}
+
+	// Gossip has stopped.
+	node1.announceTx(ctx, types.Hash{2}, []byte("tx2"), h1.ID())
+	time.Sleep(100 * time.Millisecond)
+	if n := txAnns.Load(); n != 1 {
+		t.Errorf("expected no tx announcements after stop, got %d", n-1)
+	}
+	tx, err := ktypes.CreateTransaction(&ktypes.Transfer{}, "kwil-test-chain", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := node1.BroadcastTx(ctx, tx, 0); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("expected ErrShuttingDown from BroadcastTx, got %v", err)
+	}
+
+	// Stop does not close the block store, and may be called again.
+	if bs.closed.Load() {
+		t.Error("block store closed by Stop")
+	}
+	if err := node1.Stop(stopCtx); err != nil {
+		t.Errorf("second Stop failed: %v", err)
+	}
+}
+
+func TestNodeStartGossipFailure(t *testing.T) {
+	errGossip := errors.New("gossip failed")
+	startNode := func(t *testing.T, ce ConsensusEngine) (*Node, chan error) {
+		mn := mock.New()
+		t.Cleanup(func() { mn.Close() })
+		pk1, h1, _ := newTestHost(t, mn)
+
+		privKeys, _ := newGenesis(t, [][]byte{pk1})
+		defaultConfigSet := config.DefaultConfig()
+		node, err := NewNode(&Config{
+			RootDir:     t.TempDir(),
+			PrivKey:     privKeys[0],
+			Logger:      log.DiscardLogger,
+			P2P:         &defaultConfigSet.P2P,
+			DBConfig:    &defaultConfigSet.DB,
+			Statesync:   &defaultConfigSet.StateSync,
+			Mempool:     mempool.New(),
+			BlockStore:  memstore.NewMemBS(),
+			Snapshotter: newSnapshotStore(),
+			Consensus:   ce,
+		}, WithHost(h1))
+		if err != nil {
+			t.Fatalf("Failed to create node: %v", err)
+		}
+		node.newPubSub = func(context.Context, host.Host) (*pubsub.PubSub, error) {
+			return nil, errGossip
+		}
+
+		startErr := make(chan error, 1)
+		go func() {
+			startErr <- node.Start(context.Background())
+		}()
+		return node, startErr
+	}
+
+	t.Run("follower", func(t *testing.T) {
+		node, startErr := startNode(t, &nodetest.StubCE{NodeRole: types.RoleSentry})
+
+		select {
+		case err := <-startErr:
+			t.Fatalf("Start returned early: %v", err)
+		case <-time.After(200 * time.Millisecond):
+		}
+		if !node.noGossip.Load() {
+			t.Fatal("gossip not disabled")
+		}
+
+		// ACKs are dropped rather than blocking the consensus engine.
+		for range 3 {
+			if err := node.sendACK(true, 1, types.Hash{1}, nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := node.sendReset(1); err == nil {
+			t.Error("expected error sending a consensus reset without gossip")
+		}
+
+		if err := node.Shutdown(context.Background()); err != nil {
+			t.Fatalf("Shutdown failed: %v", err)
+		}
+		if err := <-startErr; err != nil {
+			t.Errorf("Start returned error: %v", err)
+		}
+	})
+
+	t.Run("validator", func(t *testing.T) {
+		_, startErr := startNode(t, &nodetest.StubCE{NodeRole: types.RoleValidator})
+		select {
+		case err := <-startErr:
+			if !errors.Is(err, errGossip) {
+				t.Errorf("expected gossip error, got %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("validator started without gossip")
+		}
+	})
+}
+
+func TestDevnetDummyTxs(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+
+	pk1, h1, err := newTestHost(t, mn)
+	if err != nil {
+		t.Fatalf("Failed to add peer to mocknet: %v", err)
+	}
+
+	privKeys, _ := newGenesis(t, [][]byte{pk1})
+	defaultConfigSet := config.DefaultConfig()
+
+	newNode := func(dummyTxs DummyTxConfig) (*Node, error) {
+		cfg := &Config{
+			RootDir:     t.TempDir(),
+			ChainID:     "kwil-test-chain",
+			PrivKey:     privKeys[0],
+			Logger:      log.DiscardLogger,
+			P2P:         &defaultConfigSet.P2P,
+			DBConfig:    &defaultConfigSet.DB,
+			Statesync:   &defaultConfigSet.StateSync,
+			Mempool:     mempool.New(),
+			BlockStore:  memstore.NewMemBS(),
+			Snapshotter: newSnapshotStore(),
+			Consensus:   &dummyCE{},
+		}
+		return NewNode(cfg, WithHost(h1), WithDevnet(dummyTxs))
+	}
+
+	for _, bad := range []DummyTxConfig{
+		{BlockTxCount: -1},
+		{TxSize: -1},
+		{TxSize: consensus.MaxBlockSize + 1},
+		{Interval: -time.Second},
+	} {
+		if _, err := newNode(bad); err == nil {
+			t.Errorf("expected error for dummy tx config %+v", bad)
+		}
+	}
+
+	const (
+		txCount  = 2
+		txSize   = 1000
+		interval = 50 * time.Millisecond
+		batches  = 3
+	)
+	node1, err := newNode(DummyTxConfig{BlockTxCount: txCount, TxSize: txSize, Interval: interval})
+	if err != nil {
+		t.Fatalf("Failed to create Node 1: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	start := time.Now()
+	node1.startTxAnns(ctx, time.Hour)
+	defer func() {
+		cancel()
+		node1.wg.Wait()
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for node1.mp.Size() < batches*txCount {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected at least %d dummy txns, have %d", batches*txCount, node1.mp.Size())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if elapsed := time.Since(start); elapsed < batches*interval {
+		t.Errorf("%d batches of dummy txns created in %v, expected interval of %v", batches, elapsed, interval)
+	}
+
+	for _, nt := range node1.mp.PeekN(batches * txCount) {
+		if len(nt.Tx.Body.Payload) != txSize {
+			t.Errorf("expected dummy tx payload size %d, got %d", txSize, len(nt.Tx.Body.Payload))
+		}
+	}
+}
+
+func TestPrivateNetworkPSK(t *testing.T) {
+	newPSK := func() []byte {
+		psk := make([]byte, pskLen)
+		if _, err := rand.Read(psk); err != nil {
+			t.Fatal(err)
+		}
+		return psk
+	}
+	newTestHost := func(psk []byte) host.Host {
+		privKey, _, err := crypto.GenerateSecp256k1Key(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h, err := newHost([]string{"/ip4/127.0.0.1/tcp/0"}, false, privKey, psk, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { h.Close() })
+		return h
+	}
+	connect := func(from, to host.Host) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		return from.Connect(ctx, peer.AddrInfo{ID: to.ID(), Addrs: to.Addrs()})
+	}
+
+	psk := newPSK()
+	h1, h2 := newTestHost(psk), newTestHost(psk)
+	if err := connect(h1, h2); err != nil {
+		t.Fatalf("hosts with the same PSK failed to connect: %v", err)
+	}
+
+	if err := connect(newTestHost(newPSK()), h1); err == nil {
+		t.Fatal("host with a different PSK connected")
+	}
+
+	if err := connect(newTestHost(nil), h1); err == nil {
+		t.Fatal("host without a PSK connected")
+	}
+
+	privKey, _, _ := crypto.GenerateSecp256k1Key(nil)
+	if _, err := newHost([]string{"/ip4/127.0.0.1/tcp/0"}, false, privKey, []byte("short"), nil); err == nil {
+		t.Fatal("expected error for invalid PSK length")
+	}
+}
+
+func TestLoadPSK(t *testing.T) {
+	psk := bytes.Repeat([]byte{0xab}, pskLen)
+	keyFile := t.TempDir() + "/swarm.key"
+	content := "/key/swarm/psk/1.0.0/\n/base16/\n" + fmt.Sprintf("%x", psk) + "\n"
+	if err := os.WriteFile(keyFile, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadPSK(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(psk, loaded) {
+		t.Fatalf("loaded PSK %x, want %x", loaded, psk)
+	}
+
+	if err := os.WriteFile(keyFile, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPSK(keyFile); err == nil {
+		t.Fatal("expected error for invalid key file")
+	}
+}
+
+func TestConnectPeerDNS(t *testing.T) {
+	newTestHost := func() host.Host {
+		privKey, _, err := crypto.GenerateSecp256k1Key(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h, err := newHost([]string{"/ip4/127.0.0.1/tcp/0"}, false, privKey, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { h.Close() })
+		return h
+	}
+	h1, h2, h3 := newTestHost(), newTestHost(), newTestHost()
+	_, port, _ := maHostPort(h2.Addrs()[0])
+
+	// The seed's dnsaddr records point to h2 by DNS name, and to another peer.
+	resolver, err := madns.NewResolver(madns.WithDefaultResolver(&madns.MockResolver{
+		IP: map[string][]net.IPAddr{
+			"node2.example.com": {{IP: net.ParseIP("127.0.0.1")}},
+		},
+		TXT: map[string][]string{
+			"_dnsaddr.seed.example.com": {
+				"dnsaddr=/dns4/node2.example.com/tcp/" + port + "/p2p/" + h2.ID().String(),
+				"dnsaddr=/ip4/10.0.0.1/tcp/6600/p2p/" + h3.ID().String(),
+			},
+		},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaultResolver := dnsResolver
+	dnsResolver = resolver
+	t.Cleanup(func() { dnsResolver = defaultResolver })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	info, err := connectPeer(ctx, "/dnsaddr/seed.example.com/p2p/"+h2.ID().String(), h1)
+	if err != nil {
+		t.Fatalf("failed to connect with dnsaddr: %v", err)
+	}
+	if info.ID != h2.ID() || len(info.Addrs) != 1 {
+		t.Fatalf("unexpected resolved peer info %v", info)
+	}
+	if h1.Network().Connectedness(h2.ID()) != network.Connected {
+		t.Fatal("not connected to peer")
+	}
+
+	// The only address for h3 in the dnsaddr records is unreachable, and h2's
+	// address is not used for h3.
+	_, err = connectPeer(ctx, "/dnsaddr/seed.example.com/p2p/"+h3.ID().String(), h1)
+	if err == nil {
+		t.Fatal("expected error connecting to h3")
+	}
+
+	// A dns4 address of h2 with the peer ID of h3 fails the handshake.
+	_, err = connectPeer(ctx, "/dns4/node2.example.com/tcp/"+port+"/p2p/"+h3.ID().String(), h1)
+	if err == nil {
+		t.Fatal("expected peer ID mismatch error")
+	}
+
+	// No peer ID
+	if _, err = connectPeer(ctx, "/dnsaddr/seed.example.com", h1); err == nil {
+		t.Fatal("expected error for address without a peer ID")
+	}
+}
+
+func TestMaHostPort(t *testing.T) {
+	tests := []struct {
+		addr                 string
+		host, port, protocol string
+	}{
+		{"/ip4/127.0.0.1/tcp/6600", "127.0.0.1", "6600", "ip4"},
+		{"/ip6/::1/tcp/6600", "::1", "6600", "ip6"},
+		{"/dns4/node.example.com/tcp/6600", "node.example.com", "6600", "dns4"},
+		{"/dns/node.example.com/tcp/6600", "node.example.com", "6600", "dns"},
+		{"/dnsaddr/seed.example.com", "", "", ""},
+	}
+	for _, tt := range tests {
+		host, port, protocol := maHostPort(ma.StringCast(tt.addr))
+		if host != tt.host || port != tt.port || protocol != tt.protocol {
+			t.Errorf("maHostPort(%s) = %s, %s, %s", tt.addr, host, port, protocol)
+		}
+	}
+}
+
+func TestListenAddrs(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.PeerConfig
+		want []string
+	}{
+		{"ip4", config.PeerConfig{IP: "0.0.0.0", Port: 6600}, []string{"/ip4/0.0.0.0/tcp/6600"}},
+		{"ip6", config.PeerConfig{IP: "::", Port: 6600}, []string{"/ip6/::/tcp/6600"}},
+		{"listen addrs override", config.PeerConfig{IP: "0.0.0.0", Port: 6600,
+			ListenAddrs: []string{"/ip4/0.0.0.0/tcp/6601", "/ip6/::/tcp/6601"}},
+			[]string{"/ip4/0.0.0.0/tcp/6601", "/ip6/::/tcp/6601"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := listenAddrs(&tt.cfg)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("listenAddrs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewHostMultipleListenAddrs(t *testing.T) {
+	privKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := newHost([]string{"/ip4/127.0.0.1/tcp/0", "/ip6/::1/tcp/0", "/ip4/127.0.0.1/tcp/0/ws"},
+		true, privKey, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	var hasIP4, hasIP6, hasWS bool
+	for _, addr := range h.Addrs() {
+		_, wsErr := addr.ValueForProtocol(ma.P_WS)
+		switch host, _, proto := maHostPort(addr); {
+		case wsErr == nil:
+			hasWS = true
+		case proto == "ip4" && host == "127.0.0.1":
+			hasIP4 = true
+		case proto == "ip6" && host == "::1":
+			hasIP6 = true
+		}
+	}
+	if !hasIP4 || !hasIP6 || !hasWS {
+		t.Fatalf("missing listen address in %v (ip4 %v, ip6 %v, ws %v)", h.Addrs(), hasIP4, hasIP6, hasWS)
+	}
+
+	// host:port strings bracket the IPv6 host
+	var bracketed bool
+	for _, addr := range addrs(h) {
+		if strings.HasPrefix(addr, "[::1]:") {
+			bracketed = true
+		}
+	}
+	if !bracketed {
+		t.Errorf("no bracketed IPv6 address in %v", addrs(h))
+	}
+
+	// the full multiaddrs keep the transport and peer ID
+	var wsMaddr bool
+	for _, addr := range maddrs(h) {
+		if strings.HasSuffix(addr, "/ws/p2p/"+h.ID().String()) {
+			wsMaddr = true
+		}
+	}
+	if !wsMaddr {
+		t.Errorf("no websocket multiaddr in %v", maddrs(h))
+	}
+
+	if _, err = newHost([]string{"/ip4/127.0.0.1/tcp/0/ws"}, false, privKey, nil, nil); err == nil {
+		t.Error("expected error listening on websocket address without the transport")
+	}
+}
+
+func TestPeersRandSource(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+	_, h, _ := newTestHost(t, mn)
+	for range 5 {
+		newTestHost(t, mn)
+	}
+	if err := mn.LinkAll(); err != nil {
+		t.Fatal(err)
+	}
+	if err := mn.ConnectAllButSelf(); err != nil {
+		t.Fatal(err)
+	}
+
+	sorted := h.Network().Peers()
+	slices.Sort(sorted)
+	if len(sorted) != 5 {
+		t.Fatalf("expected 5 peers, got %d", len(sorted))
+	}
+
+	// With a fixed source, the order is a known permutation of the sorted
+	// peers, regardless of the order in which the network lists them.
+	n := &Node{host: h, rng: mrand2.New(mrand2.NewPCG(1, 2)), timeouts: DefaultProtocolTimeouts()}
+	for _, perm := range [][]int{{1, 4, 2, 0, 3}, {2, 3, 4, 0, 1}} {
+		want := make([]peer.ID, len(perm))
+		for i, j := range perm {
+			want[i] = sorted[j]
+		}
+		if got := n.peers(); !slices.Equal(got, want) {
+			t.Fatalf("peers() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestRequestFromPeersFailover(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+	_, h, _ := newTestHost(t, mn)
+	_, hNotFound, _ := newTestHost(t, mn)
+	_, hHangup, _ := newTestHost(t, mn)
+	_, hServer, _ := newTestHost(t, mn)
+	if err := mn.LinkAll(); err != nil {
+		t.Fatal(err)
+	}
+	if err := mn.ConnectAllButSelf(); err != nil {
+		t.Fatal(err)
+	}
+
+	var blkHash, appHash types.Hash
+	blkHash[0], appHash[0] = 1, 2
+	rawBlk := []byte("block")
+
+	var served atomic.Int32
+	hNotFound.SetStreamHandler(ProtocolIDBlockHeight, func(s network.Stream) {
+		defer s.Close()
+		served.Add(1)
+		s.Write(noData)
+	})
+	hHangup.SetStreamHandler(ProtocolIDBlockHeight, func(s network.Stream) {
+		served.Add(1)
+		s.Close() // no response
+	})
+	hServer.SetStreamHandler(ProtocolIDBlockHeight, func(s network.Stream) {
+		defer s.Close()
+		served.Add(1)
+		var req blockHeightReq
+		if _, err := req.ReadFrom(s); err != nil || req.Height != 3 {
+			s.Write(noData)
+			return
+		}
+		s.Write(slices.Concat(blkHash[:], appHash[:], rawBlk))
+	})
+
+	n := &Node{host: h, log: log.DiscardLogger, rng: mrand2.New(mrand2.NewPCG(1, 2)),
+		timeouts: DefaultProtocolTimeouts()}
+	ctx := context.Background()
+	resID, _ := blockHeightReq{Height: 3}.MarshalBinary()
+
+	t.Run("third peer serves", func(t *testing.T) {
+		served.Store(0)
+		peers := []peer.ID{hNotFound.ID(), hHangup.ID(), hServer.ID()}
+		resp, from, err := n.requestFromPeers(ctx, peers, resID, ProtocolIDBlockHeight, blkReadLimit, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if from != hServer.ID() {
+			t.Errorf("response from %v, want %v", from, hServer.ID())
+		}
+		if want := slices.Concat(blkHash[:], appHash[:], rawBlk); !bytes.Equal(resp, want) {
+			t.Errorf("response %x, want %x", resp, want)
+		}
+		if served.Load() != 3 {
+			t.Errorf("expected 3 peers to be tried, got %d", served.Load())
+		}
+	})
+
+	t.Run("none serve", func(t *testing.T) {
+		peers := []peer.ID{hNotFound.ID(), hHangup.ID()}
+		_, _, err := n.requestFromPeers(ctx, peers, resID, ProtocolIDBlockHeight, blkReadLimit, nil)
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		served.Store(0)
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		peers := []peer.ID{hServer.ID()}
+		_, _, err := n.requestFromPeers(ctx, peers, resID, ProtocolIDBlockHeight, blkReadLimit, nil)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if served.Load() != 0 {
+			t.Error("peer was requested after the context was canceled")
+		}
+	})
+
+	t.Run("getBlkHeight", func(t *testing.T) {
+		// whatever the peer order, the block is obtained from the server
+		for range 3 {
+			hash, gotAppHash, raw, err := n.getBlkHeight(ctx, 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hash != blkHash || gotAppHash != appHash || !bytes.Equal(raw, rawBlk) {
+				t.Errorf("unexpected block %v, %v, %q", hash, gotAppHash, raw)
+			}
+		}
+		_, _, _, err := n.getBlkHeight(ctx, 4)
+		if !errors.Is(err, ErrBlkNotFound) {
+			t.Errorf("expected ErrBlkNotFound, got %v", err)
+		}
+	})
+}
+
+func TestProtocolTimeouts(t *testing.T) {
+	def := DefaultProtocolTimeouts()
+	got := ProtocolTimeouts{TxGet: time.Minute}.withDefaults()
+	if got.TxGet != time.Minute {
+		t.Errorf("TxGet = %v, want %v", got.TxGet, time.Minute)
+	}
+	got.TxGet = def.TxGet
+	if got != def {
+		t.Errorf("zero fields not defaulted: %+v", got)
+	}
+
+	// mocknet streams do not support deadlines, so use loopback hosts
+	newLoopbackHost := func() host.Host {
+		privKey, _, err := crypto.GenerateSecp256k1Key(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h, err := newHost([]string{"/ip4/127.0.0.1/tcp/0"}, false, privKey, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { h.Close() })
+		return h
+	}
+	h, hSlow := newLoopbackHost(), newLoopbackHost()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := h.Connect(ctx, peer.AddrInfo{ID: hSlow.ID(), Addrs: hSlow.Addrs()}); err != nil {
+		t.Fatal(err)
+	}
+
+	const delay = 300 * time.Millisecond
+	rawBlk := []byte("block")
+	hSlow.SetStreamHandler(ProtocolIDBlockHeight, func(s network.Stream) {
+		defer s.Close()
+		var req blockHeightReq
+		if _, err := req.ReadFrom(s); err != nil {
+			return
+		}
+		time.Sleep(delay)
+		var blkHash, appHash types.Hash
+		s.Write(slices.Concat(blkHash[:], appHash[:], rawBlk))
+	})
+
+	resID, _ := blockHeightReq{Height: 1}.MarshalBinary()
+	peers := []peer.ID{hSlow.ID()}
+	request := func(timeouts ProtocolTimeouts) (time.Duration, error) {
+		n := &Node{host: h, log: log.DiscardLogger, rng: mrand2.New(mrand2.NewPCG(1, 2)),
+			timeouts: timeouts.withDefaults()}
+		start := time.Now()
+		_, _, err := n.requestFromPeers(context.Background(), peers, resID,
+			ProtocolIDBlockHeight, blkReadLimit, nil)
+		return time.Since(start), err
+	}
+
+	elapsed, err := request(ProtocolTimeouts{TxGet: delay / 3})
+	if err == nil {
+		t.Fatal("expected the short timeout to give up on the slow peer")
+	}
+	if elapsed >= delay {
+		t.Errorf("short timeout waited %v, longer than the peer delay %v", elapsed, delay)
+	}
+
+	elapsed, err = request(ProtocolTimeouts{TxGet: 10 * delay})
+	if err != nil {
+		t.Fatalf("expected the long timeout to wait for the slow peer: %v", err)
+	}
+	if elapsed < delay {
+		t.Errorf("long timeout returned after %v, before the peer delay %v", elapsed, delay)
+	}
+}
+
+func TestConnManagerTrim(t *testing.T) {
+	newTestHost := func(cm p2pconnmgr.ConnManager) host.Host {
+		privKey, _, err := crypto.GenerateSecp256k1Key(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h, err := newHost([]string{"/ip4/127.0.0.1/tcp/0"}, false, privKey, nil, cm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { h.Close() })
+		return h
+	}
+
+	if _, err := newConnManager(&config.PeerConfig{LowWatermark: 5, HighWatermark: 4}); err == nil {
+		t.Fatal("expected error for low watermark above high watermark")
+	}
+	if cm, err := newConnManager(&config.PeerConfig{}); err != nil || cm != nil {
+		t.Fatalf("expected no connection manager without watermarks, got %v, %v", cm, err)
+	}
+
+	cm, err := newConnManager(&config.PeerConfig{LowWatermark: 2, HighWatermark: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := newTestHost(cm)
+
+	ctx := context.Background()
+	var remotes []host.Host
+	for range 6 {
+		r := newTestHost(nil)
+		if err := r.Connect(ctx, peer.AddrInfo{ID: h.ID(), Addrs: h.Addrs()}); err != nil {
+			t.Fatal(err)
+		}
+		remotes = append(remotes, r)
+	}
+	if n := len(h.Network().Peers()); n != 6 {
+		t.Fatalf("expected 6 connected peers, got %d", n)
+	}
+
+	// A protected peer is kept, while the others are trimmed to the low watermark.
+	protected := remotes[0].ID()
+	h.ConnManager().Protect(protected, "kwil")
+	h.ConnManager().TrimOpenConns(ctx)
+
+	peers := h.Network().Peers()
+	if len(peers) != 3 { // 2 unprotected + 1 protected
+		t.Fatalf("expected 3 connected peers after trim, got %d", len(peers))
+	}
+	if !slices.Contains(peers, protected) {
+		t.Error("protected peer was disconnected")
+	}
+}
+
+func TestAdvertiseToPeerTyped(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+	h, err := mn.GenPeer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := &Node{host: h, log: log.DiscardLogger, timeouts: DefaultProtocolTimeouts()}
+
+	ann, _ := blockAnnMsg{Hash: types.Hash{1}, Height: 2, LeaderSig: []byte{3}}.MarshalBinary()
+	content := []byte("block contents")
+
+	type result struct {
+		proto   protocol.ID
+		msg     blockAnnMsg
+		content []byte
+		err     error
+	}
+	// newReceiver creates a peer that handles the given protocols by reading
+	// the advertisement for cType, and requesting the content if it was read.
+	newReceiver := func(cType string, protos ...protocol.ID) (peer.ID, chan result) {
+		p, err := mn.GenPeer()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = mn.LinkAll(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = mn.ConnectPeers(h.ID(), p.ID()); err != nil {
+			t.Fatal(err)
+		}
+		res := make(chan result, 1)
+		for _, proto := range protos {
+			p.SetStreamHandler(proto, func(s network.Stream) {
+				defer s.Close()
+				r := result{proto: s.Protocol()}
+				if r.err = readAnn(s, cType, &r.msg); r.err == nil {
+					r.content, r.err = request(s, []byte(getMsg), 1000)
+				}
+				res <- r
+			})
+		}
+		return p.ID(), res
+	}
+	advertise := func(peerID peer.ID) {
+		err := n.advertiseToPeer(context.Background(), peerID, ProtocolIDBlkAnn,
+			contentAnn{cType: annTypeBlock, ann: ann, content: content}, time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	check := func(r result, wantProto protocol.ID) {
+		t.Helper()
+		if r.err != nil {
+			t.Fatal(r.err)
+		}
+		if r.proto != wantProto {
+			t.Errorf("negotiated protocol %v, want %v", r.proto, wantProto)
+		}
+		if r.msg.Hash != (types.Hash{1}) || r.msg.Height != 2 {
+			t.Errorf("unexpected announcement %+v", r.msg)
+		}
+		if !bytes.Equal(r.content, content) {
+			t.Errorf("got content %q, want %q", r.content, content)
+		}
+	}
+
+	t.Run("typed", func(t *testing.T) {
+		peerID, res := newReceiver(annTypeBlock, ProtocolIDBlkAnnTyped, ProtocolIDBlkAnn)
+		advertise(peerID)
+		check(<-res, ProtocolIDBlkAnnTyped)
+	})
+
+	t.Run("legacy peer", func(t *testing.T) {
+		peerID, res := newReceiver(annTypeBlock, ProtocolIDBlkAnn)
+		advertise(peerID)
+		check(<-res, ProtocolIDBlkAnn)
+	})
+
+	t.Run("unwanted type", func(t *testing.T) {
+		peerID, res := newReceiver(annTypeBlockProposal, ProtocolIDBlkAnnTyped)
+		advertise(peerID)
+		if r := <-res; !errors.Is(r.err, errUnwantedAnn) {
+			t.Errorf("got error %v, want %v", r.err, errUnwantedAnn)
+		}
+	})
+}
+
+func TestBlockRange(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+	_, h, _ := newTestHost(t, mn)
+	_, hServer, _ := newTestHost(t, mn)
+	if err := mn.LinkAll(); err != nil {
+		t.Fatal(err)
+	}
+	if err := mn.ConnectAllButSelf(); err != nil {
+		t.Fatal(err)
+	}
+
+	const tip = maxBlkRangeCount + 20
+	bs := memstore.NewMemBS()
+	for height := int64(1); height <= tip; height++ {
+		blk, appHash := createTestBlock(height, 1)
+		if err := bs.Store(blk, appHash); err != nil {
+			t.Fatal(err)
+		}
+	}
+	server := &Node{host: hServer, bki: bs, log: log.DiscardLogger, timeouts: DefaultProtocolTimeouts()}
+	var served atomic.Int32
+	hServer.SetStreamHandler(ProtocolIDBlockRange, func(s network.Stream) {
+		served.Add(1)
+		server.blkGetRangeStreamHandler(s)
+	})
+	hServer.SetStreamHandler(ProtocolIDBlockHeight, server.blkGetHeightStreamHandler)
+	if err := h.Peerstore().AddProtocols(hServer.ID(), ProtocolIDBlockRange, ProtocolIDBlockHeight); err != nil {
+		t.Fatal(err)
+	}
+
+	n := &Node{host: h, log: log.DiscardLogger, rng: mrand2.New(mrand2.NewPCG(1, 2)),
+		timeouts: DefaultProtocolTimeouts()}
+	ctx := context.Background()
+
+	checkRange := func(t *testing.T, blks []rangeBlk, start int64, count int) {
+		t.Helper()
+		if len(blks) != count {
+			t.Fatalf("got %d blocks, want %d", len(blks), count)
+		}
+		for i, blk := range blks {
+			height := start + int64(i)
+			wantBlk, wantAppHash := createTestBlock(height, 1)
+			if blk.Hash != wantBlk.Hash() || blk.AppHash != wantAppHash {
+				t.Errorf("unexpected hashes for block %d", height)
+			}
+			if !bytes.Equal(blk.Raw, ktypes.EncodeBlock(wantBlk)) {
+				t.Errorf("unexpected raw block %d", height)
+			}
+		}
+	}
+
+	t.Run("spanning the tip", func(t *testing.T) {
+		blks, err := n.getBlkRange(ctx, tip-2, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkRange(t, blks, tip-2, 3)
+	})
+
+	t.Run("capped", func(t *testing.T) {
+		blks, err := n.getBlkRange(ctx, 1, 10*maxBlkRangeCount)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkRange(t, blks, 1, maxBlkRangeCount)
+	})
+
+	t.Run("past the tip", func(t *testing.T) {
+		_, err := n.getBlkRange(ctx, tip+1, 10)
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+		_, _, _, err = n.getBlkHeight(ctx, tip+1)
+		if !errors.Is(err, ErrBlkNotFound) {
+			t.Errorf("expected ErrBlkNotFound, got %v", err)
+		}
+	})
+
+	t.Run("getBlkHeight prefetch", func(t *testing.T) {
+		served.Store(0)
+		for height := int64(1); height <= tip; height++ {
+			hash, appHash, raw, err := n.getBlkHeight(ctx, height)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRange(t, []rangeBlk{{hash, appHash, raw}}, height, 1)
+		}
+		if got := served.Load(); got != 2 { // maxBlkRangeCount, then the rest
+			t.Errorf("served %d range requests, want 2", got)
+		}
+	})
+
+	t.Run("prefetched blocks discarded", func(t *testing.T) {
+		served.Store(0)
+		if _, _, _, err := n.getBlkHeight(ctx, 1); err != nil {
+			t.Fatal(err)
+		}
+
+		// A committed height is no longer prefetched, but the next is.
+		n.discardPrefetched(2)
+		if _, ok := n.takePrefetched(2); ok {
+			t.Error("block 2 still prefetched after commit")
+		}
+		if _, _, _, err := n.getBlkHeight(ctx, 3); err != nil {
+			t.Fatal(err)
+		}
+		if got := served.Load(); got != 1 {
+			t.Errorf("served %d range requests, want 1", got)
+		}
+
+		// A reset discards the rest.
+		n.setPrefetched(nil)
+		if _, _, _, err := n.getBlkHeight(ctx, 4); err != nil {
+			t.Fatal(err)
+		}
+		if got := served.Load(); got != 2 {
+			t.Errorf("served %d range requests, want 2", got)
+		}
+	})
+}
+
+func TestNodeProtocolHandlers(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+	pk1, h1, err := newTestHost(t, mn)
+	if err != nil {
+		t.Fatalf("Failed to add peer to mocknet: %v", err)
+	}
+
+	privKeys, _ := newGenesis(t, [][]byte{pk1})
+	defaultConfigSet := config.DefaultConfig()
+	_, err = NewNode(&Config{
+		RootDir:     t.TempDir(),
+		PrivKey:     privKeys[0],
+		Logger:      log.DiscardLogger,
+		P2P:         &defaultConfigSet.P2P,
+		DBConfig:    &defaultConfigSet.DB,
+		Statesync:   &defaultConfigSet.StateSync,
+		Mempool:     mempool.New(),
+		BlockStore:  memstore.NewMemBS(),
+		Snapshotter: newSnapshotStore(),
+		Consensus:   &dummyCE{},
+	}, WithHost(h1))
+	if err != nil {
+		t.Fatalf("Failed to create node: %v", err)
+	}
+	// The gossipsub handler is registered when the node starts.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, err = newGossipSub(ctx, h1); err != nil {
+		t.Fatalf("Failed to create gossipsub: %v", err)
+	}
+
+	handled := h1.Mux().Protocols()
+	for _, proto := range SupportedProtocols() {
+		if !slices.Contains(handled, proto) {
+			t.Errorf("no stream handler for protocol %s", proto)
+		}
+	}
+	for _, proto := range RequiredProtocols() {
+		if !slices.Contains(SupportedProtocols(), proto) {
+			t.Errorf("required protocol %s is not in the supported protocols", proto)
+		}
+	}
+}
+
+func TestPeerRateLimit(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+	pk1, h1, err := newTestHost(t, mn)
+	if err != nil {
+		t.Fatalf("Failed to add peer to mocknet: %v", err)
+	}
+	var peers []host.Host // greedy, well-behaved, exempt
+	for range 3 {
+		_, h, err := newTestHost(t, mn)
+		if err != nil {
+			t.Fatalf("Failed to add peer to mocknet: %v", err)
+		}
+		peers = append(peers, h)
+	}
+	greedy, polite, exempt := peers[0], peers[1], peers[2]
+
+	privKeys, _ := newGenesis(t, [][]byte{pk1})
+	defaultConfigSet := config.DefaultConfig()
+	_, err = NewNode(&Config{
+		RootDir:     t.TempDir(),
+		PrivKey:     privKeys[0],
+		Logger:      log.DiscardLogger,
+		P2P:         &defaultConfigSet.P2P,
+		DBConfig:    &defaultConfigSet.DB,
+		Statesync:   &defaultConfigSet.StateSync,
+		Mempool:     mempool.New(),
+		BlockStore:  memstore.NewMemBS(),
+		Snapshotter: newSnapshotStore(),
+		Consensus:   &dummyCE{},
+	}, WithHost(h1), WithPeerRateLimit(PeerRateLimit{
+		Rate:   0.001,
+		Burst:  2,
+		Exempt: []peer.ID{exempt.ID()},
+	}))
+	if err != nil {
+		t.Fatalf("Failed to create node: %v", err)
+	}
+	if err = mn.LinkAll(); err != nil {
+		t.Fatalf("Failed to link hosts: %v", err)
+	}
+	for _, h := range peers {
+		if _, err = mn.ConnectPeers(h.ID(), h1.ID()); err != nil {
+			t.Fatalf("Failed to connect hosts: %v", err)
+		}
+	}
+
+	resID, err := newTxHashReq(types.Hash{1, 2, 3}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	request := func(h host.Host) error {
+		_, err := requestFrom(ctx, h, h1.ID(), resID, ProtocolIDTx, txReadLimit, time.Second)
+		return err
+	}
+
+	// The unknown tx is not found until the greedy peer exhausts its burst.
+	for i := range 2 {
+		if err = request(greedy); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("request %d: expected ErrNotFound, got %v", i, err)
+		}
+	}
+	if err = request(greedy); !errors.Is(err, ErrPeerBusy) {
+		t.Errorf("expected ErrPeerBusy, got %v", err)
+	}
+	if err = request(polite); !errors.Is(err, ErrNotFound) {
+		t.Errorf("well-behaved peer: expected ErrNotFound, got %v", err)
+	}
+	for i := range 4 {
+		if err = request(exempt); !errors.Is(err, ErrNotFound) {
+			t.Errorf("exempt peer request %d: expected ErrNotFound, got %v", i, err)
+		}
+	}
+
+	// A request that is only rate limited is not reported as not found.
+	greedyNode := &Node{host: greedy, log: log.DiscardLogger, timeouts: DefaultProtocolTimeouts()}
+	_, _, err = greedyNode.requestFromPeers(ctx, []peer.ID{h1.ID()}, resID, ProtocolIDTx, txReadLimit, nil)
+	if !errors.Is(err, ErrPeerBusy) || errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrPeerBusy, got %v", err)
+	}
+}
+
+// nonceCheckCE is a dummyCE that rejects transactions with nonces that are
+// already confirmed.
+// catchingUpCE is a dummyCE that never finishes catching up.
+type catchingUpCE struct {
+	dummyCE
+}
+
+func (ce *catchingUpCE) CaughtUp() <-chan struct{} {
+	return nil
+}
+
+type nonceCheckCE struct {
+	dummyCE
+	confirmed uint64
+}
+
+func (ce *nonceCheckCE) CheckTx(ctx context.Context, tx *ktypes.Transaction) error {
+	if tx.Body.Nonce <= ce.confirmed {
+		return fmt.Errorf("nonce %d already confirmed", tx.Body.Nonce)
+	}
+	return nil
+}
+
+// pendingNonceCE is a dummyCE that, like the application's mempool state,
+// tracks the next nonce of each sender as transactions are checked.
+type pendingNonceCE struct {
+	dummyCE
+	mp      *mempool.Mempool
+	pending map[string]uint64
+}
+
+func (ce *pendingNonceCE) CheckTx(ctx context.Context, tx *ktypes.Transaction) error {
+	if tx.Body.Nonce != ce.pending[string(tx.Sender)]+1 {
+		return fmt.Errorf("%w: got %d, expected %d", ktypes.ErrInvalidNonce,
+			tx.Body.Nonce, ce.pending[string(tx.Sender)]+1)
+	}
+	ce.pending[string(tx.Sender)] = tx.Body.Nonce
+	return nil
+}
+
+func (ce *pendingNonceCE) RecheckAccounts(ctx context.Context, senders [][]byte) {
+	for _, sender := range senders {
+		delete(ce.pending, string(sender))
+	}
+	ce.mp.RecheckAccountTxs(ctx, senders, func(ctx context.Context, tx *ktypes.Transaction, _ bool) error {
+		return ce.CheckTx(ctx, tx)
+	})
+}
+
+func TestBroadcastTxAccountLimit(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+	pk1, h1, err := newTestHost(t, mn)
+	if err != nil {
+		t.Fatalf("Failed to add peer to mocknet: %v", err)
+	}
+	privKeys, _ := newGenesis(t, [][]byte{pk1})
+	defaultConfigSet := config.DefaultConfig()
+
+	newTx := func(nonce uint64) (*ktypes.Transaction, types.Hash) {
+		tx, err := ktypes.CreateTransaction(&ktypes.Transfer{}, "kwil-test-chain", nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx.Signature = &auth.Signature{}
+		tx.Sender = []byte("sender")
+		rawTx, err := tx.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tx, types.HashBytes(rawTx)
+	}
+	tx1, hash1 := newTx(1)
+	tx2, _ := newTx(2)
+	tx3, _ := newTx(3)
+	rawTx, _ := tx1.MarshalBinary()
+
+	// The account may only have two txns in mempool.
+	mp := mempool.New(mempool.WithMaxAccountSize(2 * int64(len(rawTx))))
+	ce := &pendingNonceCE{mp: mp, pending: make(map[string]uint64)}
+	node, err := NewNode(&Config{
+		RootDir:     t.TempDir(),
+		PrivKey:     privKeys[0],
+		Logger:      log.DiscardLogger,
+		P2P:         &defaultConfigSet.P2P,
+		DBConfig:    &defaultConfigSet.DB,
+		Statesync:   &defaultConfigSet.StateSync,
+		Mempool:     mp,
+		BlockStore:  memstore.NewMemBS(),
+		Snapshotter: newSnapshotStore(),
+		Consensus:   ce,
+	}, WithHost(h1))
+	if err != nil {
+		t.Fatalf("Failed to create node: %v", err)
+	}
+	ctx := context.Background()
+
+	for _, tx := range []*ktypes.Transaction{tx1, tx2} {
+		if _, err := node.BroadcastTx(ctx, tx, 0); err != nil {
+			t.Fatalf("BroadcastTx failed: %v", err)
+		}
+	}
+	if _, err := node.BroadcastTx(ctx, tx3, 0); !errors.Is(err, mempool.ErrAccountLimit) {
+		t.Fatalf("expected ErrAccountLimit, got %v", err)
+	}
+	if next := ce.pending["sender"]; next != 2 {
+		t.Errorf("expected the rejected tx's nonce to be undone, pending nonce is %d", next)
+	}
+
+	// Once the first tx is mined, the account's next nonce is accepted.
+	mp.Remove(hash1)
+	if _, err := node.BroadcastTx(ctx, tx3, 0); err != nil {
+		t.Fatalf("BroadcastTx of the next nonce failed: %v", err)
+	}
+}
+
+func TestMempoolPersistence(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+	pk1, h1, err := newTestHost(t, mn)
+	if err != nil {
+		t.Fatalf("Failed to add peer to mocknet: %v", err)
+	}
+	privKeys, _ := newGenesis(t, [][]byte{pk1})
+	defaultConfigSet := config.DefaultConfig()
+	rootDir := t.TempDir()
+
+	newNode := func(mp *mempool.Mempool, ce ConsensusEngine) *Node {
+		node, err := NewNode(&Config{
+			RootDir:     rootDir,
+			PrivKey:     privKeys[0],
+			Logger:      log.DiscardLogger,
+			P2P:         &defaultConfigSet.P2P,
+			DBConfig:    &defaultConfigSet.DB,
+			Statesync:   &defaultConfigSet.StateSync,
+			Mempool:     mp,
+			BlockStore:  memstore.NewMemBS(),
+			Snapshotter: newSnapshotStore(),
+			Consensus:   ce,
+		}, WithHost(h1), WithMempoolPersistence())
+		if err != nil {
+			t.Fatalf("Failed to create node: %v", err)
+		}
+		return node
+	}
+
+	var hashes []types.Hash
+	mp := mempool.New()
+	for nonce := range uint64(3) {
+		tx, err := ktypes.CreateTransaction(&ktypes.Transfer{}, "kwil-test-chain", nonce+1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx.Signature = &auth.Signature{}
+		tx.Sender = []byte("sender")
+		rawTx, err := tx.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		hash := types.HashBytes(rawTx)
+		if err = mp.Store(hash, tx); err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, hash)
+	}
+
+	// Mempool is saved when the node stops, even without Shutdown.
+	ctx, cancel := context.WithCancel(context.Background())
+	startErr := make(chan error, 1)
+	go func() {
+		startErr <- newNode(mp, &nodetest.StubCE{}).Start(ctx)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-startErr:
+		if err != nil {
+			t.Fatalf("Start returned an error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start did not return after its context was canceled")
+	}
+
+	// A node that stops before catching up neither restores nor overwrites
+	// the saved mempool.
+	mp = mempool.New()
+	if err = newNode(mp, &catchingUpCE{}).Start(context.Background()); err != nil {
+		t.Fatalf("Start returned an error: %v", err)
+	}
+	if mp.Size() != 0 {
+		t.Errorf("mempool restored before catchup: %d txns", mp.Size())
+	}
+	saved, err := mempool.LoadTxs(filepath.Join(rootDir, mempoolFileName))
+	if err != nil {
+		t.Fatalf("saved mempool file not kept: %v", err)
+	}
+	if len(saved) != len(hashes) {
+		t.Errorf("expected %d saved txns, got %d", len(hashes), len(saved))
+	}
+
+	// The first tx's nonce is confirmed while the node is down.
+	mp = mempool.New()
+	node := newNode(mp, &nonceCheckCE{confirmed: 1})
+	node.restoreMempool(context.Background())
+	if mp.Size() != 2 {
+		t.Fatalf("expected 2 restored txns, got %d", mp.Size())
+	}
+	if mp.Have(hashes[0]) {
+		t.Errorf("tx with confirmed nonce was restored")
+	}
+	for i, tx := range mp.PeekN(2) {
+		if tx.Hash != hashes[i+1] {
+			t.Errorf("restored tx %d is %v, expected %v", i, tx.Hash, hashes[i+1])
+		}
+	}
+	if _, err = os.Stat(node.mempoolFile); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("saved mempool file not removed after restore: %v", err)
+	}
+
+	// Nothing to restore now.
+	mp = mempool.New()
+	newNode(mp, &dummyCE{}).restoreMempool(context.Background())
+	if mp.Size() != 0 {
+		t.Errorf("expected empty mempool, got %d txns", mp.Size())
+	}
+}
+
+func TestNodeEd25519Identity(t *testing.T) {
+	edKey, _, err := crypto.GenerateEd25519Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h1, err := newHost([]string{"/ip4/127.0.0.1/tcp/0"}, false, edKey, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h1.Close()
+
+	// The peer ID is derived from the ed25519 public key.
+	wantID, err := peers.PeerIDFromPubKey(edKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h1.ID().String() != wantID {
+		t.Fatalf("peer ID %v, expected %v", h1.ID(), wantID)
+	}
+	pub, err := peers.PubKeyFromPeerID(h1.ID().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !crypto.KeyEquals(pub, edKey.Public()) {
+		t.Fatal("public key from peer ID does not match")
+	}
+
+	privKeys, _ := newGenesis(t, [][]byte{edKey.Bytes()})
+	defaultConfigSet := config.DefaultConfig()
+	node, err := NewNode(&Config{
+		RootDir:     t.TempDir(),
+		PrivKey:     privKeys[0],
+		Logger:      log.DiscardLogger,
+		P2P:         &defaultConfigSet.P2P,
+		DBConfig:    &defaultConfigSet.DB,
+		Statesync:   &defaultConfigSet.StateSync,
+		Mempool:     mempool.New(),
+		BlockStore:  memstore.NewMemBS(),
+		Snapshotter: newSnapshotStore(),
+		Consensus:   &dummyCE{},
+	}, WithHost(h1))
+	if err != nil {
+		t.Fatalf("Failed to create node: %v", err)
+	}
+	if node.ID() != wantID {
+		t.Fatalf("node ID %v, expected %v", node.ID(), wantID)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, err = newGossipSub(ctx, h1); err != nil {
+		t.Fatalf("Failed to create gossipsub: %v", err)
+	}
+
+	// A secp256k1 peer and the ed25519 node each support the other's
+	// required protocols.
+	secpKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, err := newHost([]string{"/ip4/127.0.0.1/tcp/0"}, false, secpKey, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h2.Close()
+	setupStreamHandlers(t, h2)
+
+	if err = h2.Connect(ctx, peer.AddrInfo{ID: h1.ID(), Addrs: h1.Addrs()}); err != nil {
+		t.Fatalf("Failed to connect to ed25519 node: %v", err)
+	}
+	if err = peers.RequirePeerProtos(ctx, h2.Peerstore(), h1.ID(), RequiredProtocols()...); err != nil {
+		t.Errorf("ed25519 node does not support required protocols: %v", err)
+	}
+	// The node identifies the connecting peer asynchronously.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		err = node.checkPeerProtos(ctx, h2.ID())
+		if err == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Errorf("peer of ed25519 node does not support required protocols: %v", err)
+	}
+}
+
+// validatingCE is a dummyCE that, like the real consensus engine, reports
+// transactions with invalid signatures from ValidateProposal.
+type validatingCE struct {
+	dummyCE
+}
+
+func (ce *validatingCE) ValidateProposal(ctx context.Context, blk *ktypes.Block) error {
+	for i, rawTx := range blk.Txns {
+		tx := new(ktypes.Transaction)
+		if err := tx.UnmarshalBinary(rawTx); err != nil {
+			return err
+		}
+		if err := ident.VerifyTransaction(tx); err != nil {
+			return fmt.Errorf("transaction %d has an invalid signature: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func TestProposalWithInvalidTx(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+	pk1, h1, err := newTestHost(t, mn)
+	if err != nil {
+		t.Fatalf("Failed to add peer to mocknet: %v", err)
+	}
+	_, h2, err := newTestHost(t, mn)
+	if err != nil {
+		t.Fatalf("Failed to add peer to mocknet: %v", err)
+	}
+
+	props := make(chan *ktypes.Block, 1)
+	ce := &validatingCE{}
+	ce.Fake().SetBlockPropHandler(func(blk *ktypes.Block) {
+		props <- blk
+	})
+
+	privKeys, _ := newGenesis(t, [][]byte{pk1})
+	defaultConfigSet := config.DefaultConfig()
+	_, err = NewNode(&Config{
+		RootDir:     t.TempDir(),
+		PrivKey:     privKeys[0],
+		Logger:      log.DiscardLogger,
+		P2P:         &defaultConfigSet.P2P,
+		DBConfig:    &defaultConfigSet.DB,
+		Statesync:   &defaultConfigSet.StateSync,
+		Mempool:     mempool.New(),
+		BlockStore:  memstore.NewMemBS(),
+		Snapshotter: newSnapshotStore(),
+		Consensus:   ce,
+	}, WithHost(h1))
+	if err != nil {
+		t.Fatalf("Failed to create node: %v", err)
+	}
+	if err = mn.LinkAll(); err != nil {
+		t.Fatalf("Failed to link hosts: %v", err)
+	}
+	if _, err = mn.ConnectPeers(h2.ID(), h1.ID()); err != nil {
+		t.Fatalf("Failed to connect hosts: %v", err)
+	}
+
+	// A transaction whose sender did not sign it. Block execution gives it a
+	// failed result rather than rejecting the block.
+	tx, err := ktypes.CreateTransaction(&ktypes.Transfer{}, "kwil-test-chain", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.Sign(auth.GetUserSigner(privKeys[0])); err != nil {
+		t.Fatal(err)
+	}
+	tx.Body.Nonce++
+	rawTx, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	blk := ktypes.NewBlock(1, types.Hash{}, types.Hash{}, types.Hash{}, time.Now(), [][]byte{rawTx})
+	if err = ce.ValidateProposal(context.Background(), blk); err == nil {
+		t.Fatal("expected the transaction to fail validation")
+	}
+
+	ctx := context.Background()
+	leader := &Node{host: h2, log: log.DiscardLogger, timeouts: DefaultProtocolTimeouts()}
+	prop, _ := blockProp{Height: 1, Hash: blk.Hash(), PrevHash: blk.Header.PrevHash,
+		Stamp: blk.Header.Timestamp.UnixMilli(), LeaderSig: blk.Signature}.MarshalBinary()
+	err = leader.advertiseToPeer(ctx, h1.ID(), ProtocolIDBlockPropose,
+		contentAnn{annTypeBlockProposal, prop, ktypes.EncodeBlock(blk)}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case got := <-props:
+		if got.Hash() != blk.Hash() {
+			t.Errorf("expected proposal %v, got %v", blk.Hash(), got.Hash())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("block proposal was not processed")
+	}
+}
+
+func TestArchiveNode(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+	pk1, h1, err := newTestHost(t, mn)
+	if err != nil {
+		t.Fatalf("Failed to add peer to mocknet: %v", err)
+	}
+	_, h2, err := newTestHost(t, mn)
+	if err != nil {
+		t.Fatalf("Failed to add peer to mocknet: %v", err)
+	}
+
+	bs := memstore.NewMemBS()
+	props := make(chan *ktypes.Block, 1)
+	commits := make(chan *ktypes.Block, 1)
+	ce := &dummyCE{}
+	ce.Fake().SetBlockPropHandler(func(blk *ktypes.Block) {
+		props <- blk
+	})
+	ce.Fake().SetBlockCommitHandler(func(blk *ktypes.Block, appHash types.Hash) {
+		bs.Store(blk, appHash)
+		commits <- blk
+	})
+
+	privKeys, _ := newGenesis(t, [][]byte{pk1})
+	defaultConfigSet := config.DefaultConfig()
+	node, err := NewNode(&Config{
+		RootDir:     t.TempDir(),
+		PrivKey:     privKeys[0],
+		Logger:      log.DiscardLogger,
+		P2P:         &defaultConfigSet.P2P,
+		DBConfig:    &defaultConfigSet.DB,
+		Statesync:   &defaultConfigSet.StateSync,
+		Mempool:     mempool.New(),
+		BlockStore:  bs,
+		Snapshotter: newSnapshotStore(),
+		Consensus:   ce,
+	}, WithHost(h1), WithArchive())
+	if err != nil {
+		t.Fatalf("Failed to create node: %v", err)
+	}
+	if err = mn.LinkAll(); err != nil {
+		t.Fatalf("Failed to link hosts: %v", err)
+	}
+	if _, err = mn.ConnectPeers(h2.ID(), h1.ID()); err != nil {
+		t.Fatalf("Failed to connect hosts: %v", err)
+	}
+
+	// The dummyCE reports the leader role, which an archive node refuses.
+	if err := node.Start(context.Background()); err == nil {
+		t.Fatal("expected archive node with leader role to fail to start")
+	}
+
+	ctx := context.Background()
+	blk, appHash := createTestBlock(1, 2)
+	rawBlk := ktypes.EncodeBlock(blk)
+	leader := &Node{host: h2, log: log.DiscardLogger, timeouts: DefaultProtocolTimeouts()}
+
+	t.Run("no ACKs", func(t *testing.T) {
+		for range 3 { // would block on the ACK channel if not dropped
+			if err := node.sendACK(true, 1, blk.Hash(), &appHash); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if len(node.ackChan) != 0 {
+			t.Error("archive node queued an ACK for gossip")
+		}
+	})
+
+	t.Run("no proposals", func(t *testing.T) {
+		proposed := make(chan struct{}, 1)
+		h2.SetStreamHandler(ProtocolIDBlockPropose, func(s network.Stream) {
+			s.Close()
+			proposed <- struct{}{}
+		})
+		node.announceBlkProp(ctx, blk)
+		select {
+		case <-proposed:
+			t.Error("archive node announced a block proposal")
+		default:
+		}
+
+		prop, _ := blockProp{Height: 1, Hash: blk.Hash(), PrevHash: blk.Header.PrevHash,
+			Stamp: blk.Header.Timestamp.UnixMilli(), LeaderSig: blk.Signature}.MarshalBinary()
+		leader.advertiseToPeer(ctx, h1.ID(), ProtocolIDBlockPropose,
+			contentAnn{annTypeBlockProposal, prop, rawBlk}, time.Second) // the archive node hangs up
+	})
+
+	t.Run("stores announced blocks", func(t *testing.T) {
+		ann, _ := blockAnnMsg{Hash: blk.Hash(), Height: 1, AppHash: appHash, LeaderSig: blk.Signature}.MarshalBinary()
+		err := leader.advertiseToPeer(ctx, h1.ID(), ProtocolIDBlkAnn,
+			contentAnn{annTypeBlock, ann, rawBlk}, time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-commits:
+		case <-time.After(5 * time.Second):
+			t.Fatal("announced block not committed")
+		}
+		if !bs.Have(blk.Hash()) {
+			t.Error("announced block not stored")
+		}
+		select {
+		case <-props:
+			t.Error("archive node handled a block proposal")
+		default:
+		}
+	})
+}
+
+// roleCE is a dummyCE with a settable role.
+type roleCE struct {
+	dummyCE
+	role types.Role
+}
+
+func (ce *roleCE) Role() types.Role {
+	return ce.role
+}
+
+func TestCheckArchiveRole(t *testing.T) {
+	var logs bytes.Buffer
+	ce := &roleCE{role: types.RoleSentry}
+	node := &Node{ce: ce, archive: true,
+		log: log.New(log.WithWriter(&logs), log.WithFormat(log.FormatUnstructured))}
+
+	role := node.checkArchiveRole(types.RoleSentry)
+	if role != types.RoleSentry || logs.Len() != 0 {
+		t.Fatalf("unexpected role %v or log:\n%s", role, logs.String())
+	}
+
+	// Added to the validator set.
+	ce.role = types.RoleValidator
+	role = node.checkArchiveRole(role)
+	if role != types.RoleValidator {
+		t.Fatalf("expected validator role, got %v", role)
+	}
+	if !strings.Contains(logs.String(), "Archive node is now a validator") {
+		t.Fatalf("expected an error log, got:\n%s", logs.String())
+	}
+
+	// Logged once per change.
+	logs.Reset()
+	role = node.checkArchiveRole(role)
+	if logs.Len() != 0 {
+		t.Errorf("repeated error log:\n%s", logs.String())
+	}
+
+	ce.role = types.RoleSentry
+	node.checkArchiveRole(role)
+	if logs.Len() != 0 {
+		t.Errorf("unexpected log after removal from the validator set:\n%s", logs.String())
+	}
+}
+
+func TestConnectPeersIsolated(t *testing.T) {
+	newNode := func(t *testing.T, mn mock.Mocknet, logs *bytes.Buffer) (*Node, host.Host) {
+		pk1, h1, err := newTestHost(t, mn)
+		if err != nil {
+			t.Fatalf("Failed to add peer to mocknet: %v", err)
+		}
+		privKeys, _ := newGenesis(t, [][]byte{pk1})
+		defaultConfigSet := config.DefaultConfig()
+		node, err := NewNode(&Config{
+			RootDir:     t.TempDir(),
+			PrivKey:     privKeys[0],
+			Logger:      log.New(log.WithWriter(logs), log.WithFormat(log.FormatUnstructured)),
+			P2P:         &defaultConfigSet.P2P,
+			DBConfig:    &defaultConfigSet.DB,
+			Statesync:   &defaultConfigSet.StateSync,
+			Mempool:     mempool.New(),
+			BlockStore:  memstore.NewMemBS(),
+			Snapshotter: newSnapshotStore(),
+			Consensus:   &dummyCE{},
+		}, WithHost(h1))
+		if err != nil {
+			t.Fatalf("Failed to create node: %v", err)
+		}
+		return node, h1
+	}
+
+	t.Run("no peers", func(t *testing.T) {
+		mn := mock.New()
+		defer mn.Close()
+		var logs bytes.Buffer
+		node, _ := newNode(t, mn, &logs)
+
+		if err := node.connectPeers(context.Background(), nil); err != nil {
+			t.Fatal(err)
+		}
+		if n := strings.Count(logs.String(), "isolated"); n != 1 {
+			t.Errorf("expected one isolation warning, got %d:\n%s", n, logs.String())
+		}
+		if strings.Contains(logs.String(), "Connected") {
+			t.Errorf("unexpected connection log:\n%s", logs.String())
+		}
+	})
+
+	t.Run("unreachable peer", func(t *testing.T) {
+		mn := mock.New()
+		defer mn.Close()
+		var logs bytes.Buffer
+		node, h1 := newNode(t, mn, &logs)
+		_, h2, err := newTestHost(t, mn) // not linked, so not reachable
+		if err != nil {
+			t.Fatalf("Failed to add peer to mocknet: %v", err)
+		}
+		h1.Peerstore().AddAddrs(h2.ID(), h2.Addrs(), peerstore.PermanentAddrTTL)
+
+		if err := node.connectPeers(context.Background(), nil); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(logs.String(), "isolated") {
+			t.Errorf("unexpected isolation warning with an address book peer:\n%s", logs.String())
+		}
+		if strings.Contains(logs.String(), "Connected") {
+			t.Errorf("unexpected connection log:\n%s", logs.String())
+		}
+		if !strings.Contains(logs.String(), "Unable to connect") {
+			t.Errorf("expected connection failure log:\n%s", logs.String())
+		}
+	})
+}
+
+// runningCE is a dummyCE that, like the real engine, runs until its context
+// is canceled.
+type runningCE struct {
+	dummyCE
+}
+
+func (ce *runningCE) Start(ctx context.Context, _ consensus.ProposalBroadcaster,
+	_ consensus.BlkAnnouncer, _ consensus.AckBroadcaster, _ consensus.BlkRequester,
+	_ consensus.ResetStateBroadcaster, _ consensus.DiscoveryReqBroadcaster) error {
+	<-ctx.Done()
+	return nil
+}
+
+func TestNodeResetToHeight(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+	pk1, h1, err := newTestHost(t, mn)
+	if err != nil {
+		t.Fatalf("Failed to add peer to mocknet: %v", err)
+	}
+
+	bs := memstore.NewMemBS()
+	var blocks []*ktypes.Block
+	for height := int64(1); height <= 3; height++ {
+		blk, appHash := createTestBlock(height, 1)
+		if err := bs.Store(blk, appHash); err != nil {
+			t.Fatalf("Failed to store block: %v", err)
+		}
+		blocks = append(blocks, blk)
+	}
+
+	privKeys, _ := newGenesis(t, [][]byte{pk1})
+	defaultConfigSet := config.DefaultConfig()
+	ce := &runningCE{}
+	node, err := NewNode(&Config{
+		RootDir:     t.TempDir(),
+		PrivKey:     privKeys[0],
+		Logger:      log.DiscardLogger,
+		P2P:         &defaultConfigSet.P2P,
+		DBConfig:    &defaultConfigSet.DB,
+		Statesync:   &defaultConfigSet.StateSync,
+		Mempool:     mempool.New(),
+		BlockStore:  bs,
+		Snapshotter: newSnapshotStore(),
+		Consensus:   ce,
+	}, WithHost(h1))
+	if err != nil {
+		t.Fatalf("Failed to create node: %v", err)
+	}
+	ctx := context.Background()
+
+	tx, err := ktypes.CreateTransaction(&ktypes.Transfer{}, "kwil-test-chain", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Signature = &auth.Signature{}
+
+	if err := node.ResetToHeight(ctx, 4); !errors.Is(err, ErrFutureHeight) {
+		t.Errorf("expected ErrFutureHeight resetting to a future height, got %v", err)
+	}
+	if err := node.ResetToHeight(ctx, -1); err == nil {
+		t.Error("expected an error resetting to a negative height")
+	}
+	if ce.resetHeight != 0 {
+		t.Fatalf("consensus engine reset to height %d after a rejected reset", ce.resetHeight)
+	}
+
+	// Transactions are rejected during a reset, and a second reset fails.
+	node.resetting.Store(true)
+	if _, err := node.BroadcastTx(ctx, tx, 0); !errors.Is(err, ErrResetting) {
+		t.Errorf("expected ErrResetting broadcasting during a reset, got %v", err)
+	}
+	if err := node.ResetToHeight(ctx, 3); err == nil {
+		t.Error("expected an error resetting during a reset")
+	}
+	node.resetting.Store(false)
+
+	// At the best block, the block in progress is aborted.
+	if err := node.ResetToHeight(ctx, 3); err != nil {
+		t.Fatalf("ResetToHeight failed: %v", err)
+	}
+	if ce.resetHeight != 3 {
+		t.Errorf("expected consensus engine reset to height 3, got %d", ce.resetHeight)
+	}
+	if best, _, _ := bs.Best(); best != 3 {
+		t.Errorf("expected best block to remain at height 3, got %d", best)
+	}
+	if node.resetting.Load() {
+		t.Error("node still rejecting transactions after the reset")
+	}
+
+	// Below the best block, the node stops and the blocks above are removed.
+	startErr := make(chan error, 1)
+	go func() {
+		startErr <- node.Start(context.Background())
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	if err := node.ResetToHeight(ctx, 1); err != nil {
+		t.Fatalf("ResetToHeight failed: %v", err)
+	}
+	select {
+	case err := <-startErr:
+		if err != nil {
+			t.Fatalf("Start returned an error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("node did not stop for the reset")
+	}
+	if best, hash, _ := bs.Best(); best != 1 || hash != blocks[0].Hash() {
+		t.Errorf("expected best block at height 1, got %v at height %d", hash, best)
+	}
+	for _, blk := range blocks[1:] {
+		if bs.Have(blk.Hash()) {
+			t.Errorf("block at height %d not removed", blk.Header.Height)
+		}
+	}
+	if _, err := node.BroadcastTx(ctx, tx, 0); err == nil {
+		t.Error("expected transactions to be rejected after the reset")
+	}
+}
+
+func TestProtectedPeerIDs(t *testing.T) {
+	var vals []*ktypes.Validator
+	var wantIDs []peer.ID
+	for range 2 {
+		privKey, _, err := crypto.GenerateSecp256k1Key(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vals = append(vals, &ktypes.Validator{PubKey: privKey.Public().Bytes(), Power: 1})
+		peerID, err := peers.PeerIDFromPubKey(privKey.Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, err := peer.Decode(peerID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantIDs = append(wantIDs, id)
+	}
+	vals = append(vals, &ktypes.Validator{PubKey: []byte("bad"), Power: 1}) // skipped
+
+	n := &Node{ce: &dummyCE{validators: vals}, log: log.DiscardLogger}
+	if got := n.validatorPeerIDs(); !slices.Equal(got, wantIDs) {
+		t.Errorf("validator peer IDs %v, expected %v", got, wantIDs)
+	}
+
+	bootnode := fmt.Sprintf("%x#%d@127.0.0.1:6600", vals[0].PubKey, crypto.KeyTypeSecp256k1)
+	got := bootnodeIDs([]string{bootnode, "/ip4/127.0.0.1/tcp/6601/p2p/" + wantIDs[1].String(), "invalid"})
+	if !slices.Equal(got, wantIDs) {
+		t.Errorf("bootnode peer IDs %v, expected %v", got, wantIDs)
+	}
+}
@@ -0,0 +1,198 @@
+// Package nodetesting provides a harness for integration tests that run a
+// network of nodes in one process. The nodes use in-memory block stores and
+// mempools, and their hosts are connected over a libp2p mocknet instead of real
+// network connections.
+package nodetesting
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kwilteam/kwil-db/config"
+	"github.com/kwilteam/kwil-db/core/crypto"
+	"github.com/kwilteam/kwil-db/core/log"
+	"github.com/kwilteam/kwil-db/node"
+	"github.com/kwilteam/kwil-db/node/internal/nodetest"
+	"github.com/kwilteam/kwil-db/node/mempool"
+	"github.com/kwilteam/kwil-db/node/snapshotter"
+	"github.com/kwilteam/kwil-db/node/store/memstore"
+	"github.com/kwilteam/kwil-db/node/types"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	mock "github.com/libp2p/go-libp2p/p2p/net/mock"
+)
+
+// ChainID is the chain ID of the nodes in a Network.
+const ChainID = "kwil-test-chain"
+
+// stopTimeout limits how long the cleanup of a Network waits for each node to
+// stop.
+const stopTimeout = 10 * time.Second
+
+// Node is a running node in a Network, with the in-memory components it was
+// created with so that tests can drive and inspect it directly.
+type Node struct {
+	*node.Node
+
+	PrivKey    crypto.PrivateKey
+	Host       host.Host
+	Mempool    *mempool.Mempool
+	BlockStore *memstore.MemBS
+	Consensus  node.ConsensusEngine
+
+	startErr chan error
+}
+
+// Network is a set of nodes that are connected to each other over a mocknet.
+type Network struct {
+	Nodes []*Node
+
+	mn mock.Mocknet
+}
+
+type options struct {
+	newCE  func(i int) node.ConsensusEngine
+	logger log.Logger
+}
+
+type Option func(*options)
+
+// WithConsensus sets the function that creates the consensus engine of the
+// i'th node. By default, each node has a StubCE, and the first is the leader.
+func WithConsensus(newCE func(i int) node.ConsensusEngine) Option {
+	return func(o *options) {
+		o.newCE = newCE
+	}
+}
+
+// WithLogger sets the logger of the nodes. The logs of each node are prefixed
+// with its index. By default, nothing is logged.
+func WithLogger(logger log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// Start creates and starts n nodes that are all connected to each other. The
+// nodes are stopped, and the mocknet closed, when the test and its subtests
+// complete.
+func Start(t testing.TB, n int, opts ...Option) *Network {
+	t.Helper()
+
+	options := &options{
+		newCE: func(i int) node.ConsensusEngine {
+			if i == 0 {
+				return &StubCE{NodeRole: types.RoleLeader}
+			}
+			return &StubCE{NodeRole: types.RoleValidator}
+		},
+		logger: log.DiscardLogger,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	mn := mock.New()
+	nw := &Network{mn: mn}
+	t.Cleanup(nw.stop(t))
+
+	for i := range n {
+		nd, err := newNode(t, mn, i, options)
+		if err != nil {
+			t.Fatalf("failed to create node %d: %v", i, err)
+		}
+		nw.Nodes = append(nw.Nodes, nd)
+	}
+
+	for _, nd := range nw.Nodes {
+		go func() {
+			nd.startErr <- nd.Start(context.Background())
+		}()
+	}
+
+	if err := mn.LinkAll(); err != nil {
+		t.Fatalf("failed to link hosts: %v", err)
+	}
+	if err := mn.ConnectAllButSelf(); err != nil {
+		t.Fatalf("failed to connect hosts: %v", err)
+	}
+
+	return nw
+}
+
+// stop returns the cleanup function that stops the nodes and closes the
+// mocknet.
+func (nw *Network) stop(t testing.TB) func() {
+	return func() {
+		for i, nd := range nw.Nodes {
+			ctx, cancel := context.WithTimeout(context.Background(), stopTimeout)
+			if err := nd.Stop(ctx); err != nil {
+				t.Errorf("failed to stop node %d: %v", i, err)
+			} else if err = <-nd.startErr; err != nil {
+				t.Errorf("node %d failed: %v", i, err)
+			}
+			cancel()
+		}
+		if err := nw.mn.Close(); err != nil {
+			t.Errorf("failed to close mocknet: %v", err)
+		}
+	}
+}
+
+func newNode(t testing.TB, mn mock.Mocknet, i int, options *options) (*Node, error) {
+	h, privKey, err := nodetest.NewHost(mn)
+	if err != nil {
+		return nil, err
+	}
+
+	nd := &Node{
+		PrivKey:    privKey,
+		Host:       h,
+		Mempool:    mempool.New(),
+		BlockStore: memstore.NewMemBS(),
+		Consensus:  options.newCE(i),
+		startErr:   make(chan error, 1),
+	}
+
+	defaultCfg := config.DefaultConfig()
+	cfg := &node.Config{
+		RootDir:     t.TempDir(),
+		ChainID:     ChainID,
+		PrivKey:     privKey,
+		P2P:         &defaultCfg.P2P,
+		DBConfig:    &defaultCfg.DB,
+		Statesync:   &defaultCfg.StateSync,
+		Mempool:     nd.Mempool,
+		BlockStore:  nd.BlockStore,
+		Consensus:   nd.Consensus,
+		Snapshotter: noSnapshots{},
+		Logger:      options.logger.New(fmt.Sprintf("NODE%d", i)),
+	}
+	nd.Node, err = node.NewNode(cfg, node.WithHost(h))
+	if err != nil {
+		return nil, err
+	}
+	return nd, nil
+}
+
+// noSnapshots is a disabled snapshot store.
+type noSnapshots struct{}
+
+func (noSnapshots) Enabled() bool { return false }
+
+func (noSnapshots) GetSnapshot(uint64, uint32) *snapshotter.Snapshot { return nil }
+
+func (noSnapshots) ListSnapshots() []*snapshotter.Snapshot { return nil }
+
+func (noSnapshots) LoadSnapshotChunk(uint64, uint32, uint32) ([]byte, error) {
+	return nil, snapshotter.ErrSnapshotNotFound
+}
+
+// StubCE is a consensus engine that accepts all transactions, proposals, and
+// commits without producing blocks. Like the real engine, it runs until its
+// context is canceled, so the node keeps running.
+type StubCE = nodetest.StubCE
+
+var _ node.ConsensusEngine = (*StubCE)(nil)
@@ -0,0 +1,46 @@
+package nodetesting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kwilteam/kwil-db/core/crypto"
+	"github.com/kwilteam/kwil-db/core/crypto/auth"
+	ktypes "github.com/kwilteam/kwil-db/core/types"
+)
+
+func TestTxPropagation(t *testing.T) {
+	nw := Start(t, 3)
+	if len(nw.Nodes) != 3 {
+		t.Fatalf("expected 3 nodes, got %d", len(nw.Nodes))
+	}
+	time.Sleep(200 * time.Millisecond) // let the nodes start gossip
+
+	privKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := ktypes.CreateTransaction(&ktypes.Transfer{}, ChainID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.Sign(auth.GetUserSigner(privKey)); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := nw.Nodes[0].BroadcastTx(context.Background(), tx, 0)
+	if err != nil {
+		t.Fatalf("BroadcastTx failed: %v", err)
+	}
+
+	for i, nd := range nw.Nodes {
+		deadline := time.Now().Add(5 * time.Second)
+		for !nd.Mempool.Have(res.Hash) && time.Now().Before(deadline) {
+			time.Sleep(20 * time.Millisecond)
+		}
+		if !nd.Mempool.Have(res.Hash) {
+			t.Errorf("tx %v not in the mempool of node %d", res.Hash, i)
+		}
+	}
+}
@@ -2,9 +2,11 @@ package node
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"time"
 
 	"github.com/kwilteam/kwil-db/core/crypto"
@@ -19,7 +21,11 @@ import (
 func (n *Node) txAnnStreamHandler(s network.Stream) {
 	defer s.Close()
 
-	s.SetDeadline(time.Now().Add(txGetTimeout))
+	if n.draining.Load() || n.resetting.Load() {
+		return // not accepting new transactions
+	}
+
+	s.SetDeadline(time.Now().Add(n.timeouts.TxGet))
 
 	var ann txHashAnn
 	if _, err := ann.ReadFrom(s); err != nil {
@@ -74,10 +80,9 @@ func (n *Node) txAnnStreamHandler(s network.Stream) {
 
 	// store in mempool since it was not in tx index and thus not confirmed
 	ctx := context.Background()
-	if err := n.ce.CheckTx(ctx, &tx); err != nil {
-		n.log.Warnf("tx %v failed check: %v", txHash, err)
+	if err := n.admitTx(ctx, txHash, &tx); err != nil {
+		n.log.Warnf("tx %v not added to mempool: %v", txHash, err)
 	} else {
-		n.mp.Store(txHash, &tx)
 		fetched = true
 
 		// re-announce
@@ -86,6 +91,10 @@ func (n *Node) txAnnStreamHandler(s network.Stream) {
 }
 
 func (n *Node) announceTx(ctx context.Context, txHash types.Hash, rawTx []byte, from peer.ID) {
+	if n.draining.Load() {
+		return
+	}
+
 	peers := n.host.Network().Peers()
 	if len(peers) == 0 {
 		n.log.Warnf("no peers to advertise tx to")
@@ -113,7 +122,7 @@ func (n *Node) advertiseTxToPeer(ctx context.Context, peerID peer.ID, txHash typ
 		return fmt.Errorf("failed to open stream to peer: %w", err)
 	}
 
-	roundTripDeadline := time.Now().Add(txAnnTimeout)
+	roundTripDeadline := time.Now().Add(n.timeouts.TxAnn)
 	s.SetWriteDeadline(roundTripDeadline)
 
 	// Send a lightweight advertisement with the object ID
@@ -128,7 +137,7 @@ func (n *Node) advertiseTxToPeer(ctx context.Context, peerID peer.ID, txHash typ
 	go func() {
 		defer s.Close()
 
-		s.SetReadDeadline(time.Now().Add(txAnnRespTimeout))
+		s.SetReadDeadline(time.Now().Add(n.timeouts.TxAnnResp))
 
 		req := make([]byte, len(getMsg))
 		nr, err := s.Read(req)
@@ -144,68 +153,83 @@ func (n *Node) advertiseTxToPeer(ctx context.Context, peerID peer.ID, txHash typ
 			return
 		}
 
-		s.SetWriteDeadline(time.Now().Add(txGetTimeout))
+		s.SetWriteDeadline(time.Now().Add(n.timeouts.TxGet))
 		s.Write(rawTx)
 	}()
 
 	return nil
 }
 
-/*func randomTx(size int, signer auth.Signer) ([]byte, error) {
-	payload := &ktypes.KVPayload{
-		Key:   randBytes(32),
-		Value: randBytes(size),
-	}
-
-	tx, err := ktypes.CreateTransaction(payload, "test-chain", 1)
-	if err != nil {
-		return nil, err
+func randomTx(size int, nonce uint64, chainID string, signer auth.Signer) (*ktypes.Transaction, error) {
+	tx := &ktypes.Transaction{
+		Body: &ktypes.TransactionBody{
+			Description: "dummy",
+			Payload:     randBytes(size),
+			PayloadType: ktypes.PayloadTypeExecute,
+			Fee:         big.NewInt(0),
+			Nonce:       nonce,
+			ChainID:     chainID,
+		},
+		Serialization: ktypes.SignedMsgConcat,
 	}
 
 	if err := tx.Sign(signer); err != nil {
 		return nil, err
 	}
 
-	return tx.MarshalBinary()
+	return tx, nil
 }
 
 func randBytes(n int) []byte {
 	b := make([]byte, n)
 	rand.Read(b)
 	return b
-}*/
+}
 
-// startTxAnns handles periodic reannouncement. It can also be modified to
-// regularly create dummy transactions.
+// startTxAnns handles periodic reannouncement. In devnet mode, it also
+// regularly creates and announces dummy transactions.
 func (n *Node) startTxAnns(ctx context.Context, reannouncePeriod time.Duration) {
 	signer := secp256k1Signer()
 	if signer == nil {
 		panic("failed to create secp256k1 signer")
 	}
 
-	/*n.wg.Add(1)
-	go func() {
-		defer n.wg.Done()
+	if dummyTxs := n.dummyTxs; dummyTxs != nil {
+		n.wg.Add(1)
+		go func() {
+			defer n.wg.Done()
+
+			var nonce uint64
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(dummyTxs.Interval):
+				}
 
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-time.After(newPeriod):
-			}
+				for range dummyTxs.BlockTxCount {
+					nonce++
+					tx, err := randomTx(dummyTxs.TxSize, nonce, n.chainID, signer)
+					if err != nil {
+						n.log.Warnf("failed to create random tx: %v", err)
+						continue
+					}
+					rawTx, err := tx.MarshalBinary()
+					if err != nil {
+						n.log.Warnf("failed to marshal random tx: %v", err)
+						continue
+					}
+					txHash := types.HashBytes(rawTx)
+					if err = n.mp.Store(txHash, tx); err != nil {
+						n.log.Warnf("failed to store random tx: %v", err)
+						continue
+					}
 
-			rawTx, err := randomTx(sz, signer)
-			if err != nil {
-				n.log.Warnf("failed to create random tx: %v", err)
-				continue
+					n.announceTx(ctx, txHash, rawTx, n.host.ID())
+				}
 			}
-			txHash := types.HashBytes(rawTx)
-			n.mp.Store(txHash, rawTx)
-
-			// n.log.Infof("announcing txid %v", txid)
-			n.announceTx(ctx, txHash, rawTx, n.host.ID())
-		}
-	}()*/
+		}()
+	}
 
 	n.wg.Add(1)
 	go func() {
@@ -1,6 +1,9 @@
 package node
 
 import (
+	"math/rand/v2"
+	"time"
+
 	"github.com/libp2p/go-libp2p/core/host"
 )
 
@@ -10,6 +13,16 @@ type options struct {
 	// bs   types.BlockStore
 	// mp   types.MemPool
 	// ce   ConsensusEngine
+
+	dummyTxs *DummyTxConfig    // devnet mode if non-nil
+	psk      []byte            // private network if non-nil
+	randSrc  rand.Source       // crypto/rand if nil
+	timeouts *ProtocolTimeouts // defaults if nil
+
+	peerRateLimit   *PeerRateLimit // no limit if nil
+	saveMempool     bool
+	archive         bool
+	maxLeaderSigLen int // DefaultMaxLeaderSigLen if zero
 }
 
 type Option func(*options)
@@ -20,6 +33,155 @@ func WithHost(host host.Host) Option {
 	}
 }
 
+// WithPSK makes the node's host a member of a private network in which only
+// nodes with the same 32-byte pre-shared key can connect. This has no effect if
+// the host is provided with WithHost. See LoadPSK to read a key file.
+func WithPSK(psk []byte) Option {
+	return func(o *options) {
+		o.psk = psk
+	}
+}
+
+// WithRandSource sets the source of randomness used to select peers for
+// requests, such as when fetching blocks and transactions. By default,
+// crypto/rand is used. A seeded source makes peer selection reproducible, which
+// is only intended for testing.
+func WithRandSource(src rand.Source) Option {
+	return func(o *options) {
+		o.randSrc = src
+	}
+}
+
+// WithArchive makes the node an archive node, which follows the chain without
+// taking part in consensus. It syncs, stores, and serves all blocks, but it
+// does not join ACK gossip, never sends ACKs or block proposals, and ignores
+// the proposals announced to it. The node fails to start if its consensus
+// engine makes it a validator or the leader, and logs an error if it becomes
+// one later, since it still does not take part in consensus.
+func WithArchive() Option {
+	return func(o *options) {
+		o.archive = true
+	}
+}
+
+// DummyTxConfig configures the dummy transactions that are created by a node
+// in devnet mode. Zero values are replaced by the defaults.
+type DummyTxConfig struct {
+	// BlockTxCount is the number of dummy transactions created each interval.
+	BlockTxCount int
+	// TxSize is the size in bytes of the payload of each dummy transaction.
+	TxSize int
+	// Interval is the time between each batch of dummy transactions.
+	Interval time.Duration
+}
+
+// WithDevnet enables devnet mode, in which the node regularly creates and
+// announces dummy transactions to simulate load. Many small transactions or
+// few large ones may be configured with the DummyTxConfig.
+func WithDevnet(dummyTxs DummyTxConfig) Option {
+	return func(o *options) {
+		o.dummyTxs = &dummyTxs
+	}
+}
+
+// ProtocolTimeouts are the deadlines used by the node's p2p protocols for
+// reading from and writing to peer streams. Zero values are replaced by the
+// defaults, which are returned by DefaultProtocolTimeouts. Nodes on
+// high-latency links may need longer timeouts.
+type ProtocolTimeouts struct {
+	// AnnWrite is the timeout for writing a content announcement.
+	AnnWrite time.Duration
+	// AnnResp is the timeout for a peer's response to a content announcement,
+	// such as a request for the content.
+	AnnResp time.Duration
+	// ReqRW is the timeout for reading a resource request, or for writing a
+	// short response such as "not found".
+	ReqRW time.Duration
+	// TxAnn is the timeout for writing a transaction announcement.
+	TxAnn time.Duration
+	// TxAnnResp is the timeout for a peer's response to a transaction
+	// announcement.
+	TxAnnResp time.Duration
+	// TxGet is the timeout for retrieving a transaction, and for any resource
+	// request made without a context deadline.
+	TxGet time.Duration
+	// BlkGet is the timeout for retrieving an announced block.
+	BlkGet time.Duration
+	// BlkSend is the timeout for writing a block to a peer.
+	BlkSend time.Duration
+}
+
+// DefaultProtocolTimeouts returns the default p2p protocol timeouts.
+func DefaultProtocolTimeouts() ProtocolTimeouts {
+	return ProtocolTimeouts{
+		AnnWrite:  annWriteTimeout,
+		AnnResp:   annRespTimeout,
+		ReqRW:     reqRWTimeout,
+		TxAnn:     txAnnTimeout,
+		TxAnnResp: txAnnRespTimeout,
+		TxGet:     txGetTimeout,
+		BlkGet:    blkGetTimeout,
+		BlkSend:   blkSendTimeout,
+	}
+}
+
+// withDefaults returns the timeouts with the zero values replaced by the
+// defaults.
+func (pt ProtocolTimeouts) withDefaults() ProtocolTimeouts {
+	def := DefaultProtocolTimeouts()
+	for _, f := range []struct{ v, def *time.Duration }{
+		{&pt.AnnWrite, &def.AnnWrite},
+		{&pt.AnnResp, &def.AnnResp},
+		{&pt.ReqRW, &def.ReqRW},
+		{&pt.TxAnn, &def.TxAnn},
+		{&pt.TxAnnResp, &def.TxAnnResp},
+		{&pt.TxGet, &def.TxGet},
+		{&pt.BlkGet, &def.BlkGet},
+		{&pt.BlkSend, &def.BlkSend},
+	} {
+		if *f.v <= 0 {
+			*f.v = *f.def
+		}
+	}
+	return pt
+}
+
+// WithProtocolTimeouts sets the deadlines used by the node's p2p protocols.
+// Zero fields use the defaults.
+func WithProtocolTimeouts(timeouts ProtocolTimeouts) Option {
+	return func(o *options) {
+		o.timeouts = &timeouts
+	}
+}
+
+// WithPeerRateLimit limits the rate of resource requests, such as for
+// transactions and blocks, that the node serves to each peer. By default there
+// is no limit.
+func WithPeerRateLimit(limit PeerRateLimit) Option {
+	return func(o *options) {
+		o.peerRateLimit = &limit
+	}
+}
+
+// WithMempoolPersistence saves the transactions in mempool to a file in the
+// node's root directory when it stops, and restores the ones that are
+// still valid when it is next started.
+func WithMempoolPersistence() Option {
+	return func(o *options) {
+		o.saveMempool = true
+	}
+}
+
+// WithMaxLeaderSigLen sets the maximum length of the leader's signature in the
+// block proposal and announcement messages that the node sends and accepts,
+// which is DefaultMaxLeaderSigLen by default. It must be the same on all nodes
+// of a network that uses a signature scheme with longer signatures.
+func WithMaxLeaderSigLen(maxLen int) Option {
+	return func(o *options) {
+		o.maxLeaderSigLen = maxLen
+	}
+}
+
 /*func WithBlockStore(bs types.BlockStore) Option {
 	return func(o *options) {
 		o.bs = bs
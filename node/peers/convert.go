@@ -25,6 +25,29 @@ func PeerIDFromPubKey(pubkey crypto.PublicKey) (string, error) {
 	return peerID.String(), nil // base58 encoding of identity multihash
 }
 
+// ParsePeerAddr parses a peer given either as a multiaddr that includes the
+// peer ID, such as /ip4/127.0.0.1/tcp/6600/p2p/16Uiu2..., or as a bare peer ID.
+// A bare peer ID gives an AddrInfo with no addresses.
+func ParsePeerAddr(addr string) (*peer.AddrInfo, error) {
+	if !strings.HasPrefix(addr, "/") {
+		peerID, err := peer.Decode(addr)
+		if err != nil {
+			return nil, fmt.Errorf("%q is neither a multiaddr nor a valid peer ID: %w", addr, err)
+		}
+		return &peer.AddrInfo{ID: peerID}, nil
+	}
+
+	maddr, err := multiaddr.NewMultiaddr(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid multiaddr %q: %w", addr, err)
+	}
+	info, err := peer.AddrInfoFromP2pAddr(maddr)
+	if err != nil {
+		return nil, fmt.Errorf("multiaddr %q must end with the peer ID (/p2p/<peer ID>): %w", addr, err)
+	}
+	return info, nil
+}
+
 // PubKeyFromPeerID tries to decode the pubkey from a peer ID string.
 // This will only work if the peer ID is an "identity" multihash.
 func PubKeyFromPeerID(peerID string) (crypto.PublicKey, error) {
@@ -76,10 +99,26 @@ func convertPubKey(pubkey crypto.PublicKey) (p2pcrypto.PubKey, peer.ID, error) {
 	return p2pPub, p2pAddr, nil
 }
 
-// ConvertPeersToMultiAddr convert a peer from pubkeyHex#keyTypeInt@ip:port to /ip4/ip/tcp/port/p2p/peerID
+// ConvertPeersToMultiAddr convert a peer from pubkeyHex#keyTypeInt@ip:port to
+// /ip4/ip/tcp/port/p2p/peerID. An IPv6 address or a DNS name may be used in
+// place of the IPv4 address, giving an /ip6 or /dns multiaddr. A peer that is
+// already a multiaddr with a peer ID, such as /dnsaddr/seed.example.com/p2p/peerID,
+// is used as is.
 func ConvertPeersToMultiAddr(peers []string) ([]string, error) {
 	addrs := make([]string, len(peers))
 	for i, peerAddr := range peers {
+		if strings.HasPrefix(peerAddr, "/") {
+			maddr, err := multiaddr.NewMultiaddr(peerAddr)
+			if err != nil {
+				return nil, err
+			}
+			if _, err = peer.AddrInfoFromP2pAddr(maddr); err != nil {
+				return nil, fmt.Errorf("invalid peer multiaddr %q: %w", peerAddr, err)
+			}
+			addrs[i] = peerAddr
+			continue
+		}
+
 		// split the pieces of pubkey#type@ip:port
 		parts := strings.Split(peerAddr, "@")
 		if len(parts) != 2 {
@@ -126,7 +165,15 @@ func ConvertPeersToMultiAddr(peers []string) ([]string, error) {
 			return nil, err
 		}
 
-		maStr := fmt.Sprintf("/ip4/%s/tcp/%d/p2p/%s", host, port, peerID)
+		hostProto := "dns"
+		if ip := net.ParseIP(host); ip != nil {
+			hostProto = "ip4"
+			if ip.To4() == nil {
+				hostProto = "ip6"
+			}
+		}
+
+		maStr := fmt.Sprintf("/%s/%s/tcp/%d/p2p/%s", hostProto, host, port, peerID)
 		// ensure the multiaddress string is parsable
 		_, err = multiaddr.NewMultiaddr(maStr)
 		if err != nil {
@@ -64,3 +64,39 @@ func TestPeerIDPubKeyRoundTrip(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertPeersToMultiAddrHosts(t *testing.T) {
+	const pubKeyHex = "0226b3ff29216dac187cea393f8af685ad419ac9644e55dce83d145c8b1af213bd"
+	const peerID = "16Uiu2HAkx2kfP117VnYnaQGprgXBoMpjfxGXCpizju3cX7ZUzRhv"
+
+	tests := []struct {
+		peer    string
+		want    string
+		wantErr bool
+	}{
+		{pubKeyHex + "#0@127.0.0.1:6600", "/ip4/127.0.0.1/tcp/6600/p2p/" + peerID, false},
+		{pubKeyHex + "#0@[::1]:6600", "/ip6/::1/tcp/6600/p2p/" + peerID, false},
+		{pubKeyHex + "#0@seed.example.com:6600", "/dns/seed.example.com/tcp/6600/p2p/" + peerID, false},
+		{"/dnsaddr/seed.example.com/p2p/" + peerID, "/dnsaddr/seed.example.com/p2p/" + peerID, false},
+		{"/dnsaddr/seed.example.com", "", true}, // no peer ID
+		{"/notaproto/1", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.peer, func(t *testing.T) {
+			addrs, err := ConvertPeersToMultiAddr([]string{tt.peer})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if addrs[0] != tt.want {
+				t.Errorf("got %v, want %v", addrs[0], tt.want)
+			}
+		})
+	}
+}
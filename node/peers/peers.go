@@ -1,12 +1,17 @@
 package peers
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/kwilteam/kwil-db/core/log"
@@ -23,7 +28,31 @@ import (
 const (
 	maxRetries         = 500
 	baseReconnectDelay = 2 * time.Second
-	disconnectLimit    = 7 * 24 * time.Hour // 1 week
+
+	// defaultDisconnectRetention is how long a disconnected peer is kept
+	// before it is removed.
+	defaultDisconnectRetention = 7 * 24 * time.Hour // 1 week
+	// defaultEvictionInterval is how often the disconnected peers are checked
+	// for removal.
+	defaultEvictionInterval = 10 * time.Minute
+
+	defaultTargetConnections = 20
+
+	// defaultMaxReconnects is the maximum number of peers that may be
+	// reconnected to concurrently after they disconnect. Beyond this, the
+	// connections are left to maintainMinPeers.
+	defaultMaxReconnects = 2 * defaultTargetConnections
+
+	// protectTag is the connection manager tag that protects the connections
+	// of the peers given to SetProtectedPeers from being trimmed.
+	protectTag = "kwil"
+
+	// ttlConnected is the effectively permanent TTL of the addresses of
+	// connected peers, which is lowered to the provisional TTL on disconnect.
+	// It is distinct from libp2p's ConnectedAddrTTL so that the identify
+	// service does not expire the addresses that a peer does not advertise,
+	// such as those from the address book.
+	ttlConnected = peerstore.ConnectedAddrTTL - 1
 )
 
 type Connector interface {
@@ -51,20 +80,64 @@ type PeerMan struct {
 
 	requiredProtocols []protocol.ID
 
-	pex               bool
-	addrBook          string
-	targetConnections int
+	pex                  bool
+	addrBook             string
+	targetConnections    int
+	findPeersConcurrency int
 
 	done  chan struct{}
 	close func()
 	wg    sync.WaitGroup
 
-	// TODO: revise address book file format as needed if these should persist
+	// event counters for Metrics
+	numConnects          atomic.Uint64
+	numDisconnects       atomic.Uint64
+	numReconnectAttempts atomic.Uint64
+	numFailedDials       atomic.Uint64
+	numEvictions         atomic.Uint64
+	numDeadConns         atomic.Uint64
+
 	mtx         sync.Mutex
 	disconnects map[peer.ID]time.Time // Track disconnection timestamps
-	noReconnect map[peer.ID]bool
+
+	disconnectRetention time.Duration         // how long a disconnected peer is kept
+	evictionInterval    time.Duration         // how often old peers are removed
+	bans                map[peer.ID]time.Time // banned peers and when the ban expires
+	protected           map[peer.ID]bool      // peers with connections that are not trimmed
+
+	maxReconnects int
+	reconnecting  map[peer.ID]struct{} // peers with an active reconnect routine
+
+	pingTimeout  time.Duration
+	pingFailures map[peer.ID]int // consecutive failed pings of connected peers
+
+	// provisionalTTL is the TTL of the addresses of known peers that are not
+	// connected, such as those from the address book. The addresses of a
+	// connected peer are kept with ttlConnected until it disconnects.
+	provisionalTTL time.Duration
+
+	dialMtx  sync.Mutex
+	dials    map[uint64]*dialAttempt // in-progress outbound dials
+	nextDial uint64
 }
 
+// dialAttempt is an outbound dial that is in progress.
+type dialAttempt struct {
+	peerID  peer.ID
+	started time.Time
+	cancel  context.CancelCauseFunc
+}
+
+// ErrDialCanceled is returned by a dial that was canceled with CancelDial.
+var ErrDialCanceled = errors.New("dial canceled")
+
+// ErrPeerExists is returned when adding a peer with addresses that are all
+// already known.
+var ErrPeerExists = errors.New("peer already exists")
+
+// errClosed is returned by a dial if the PeerMan is closed.
+var errClosed = errors.New("peer manager closed")
+
 func NewPeerMan(pex bool, addrBook string, logger log.Logger, h host.Host,
 	requestPeers RemotePeersFn, requiredProtocols []protocol.ID) (*PeerMan, error) {
 	if logger == nil {
@@ -72,33 +145,96 @@ func NewPeerMan(pex bool, addrBook string, logger log.Logger, h host.Host,
 	}
 	done := make(chan struct{})
 	pm := &PeerMan{
-		h:    h, // tmp
-		c:    h,
-		ps:   h.Peerstore(),
-		log:  logger,
-		done: done,
-		close: sync.OnceFunc(func() {
-			close(done)
-		}),
-		requiredProtocols: requiredProtocols,
-		pex:               pex,
-		requestPeers:      requestPeers,
-		addrBook:          addrBook,
-		targetConnections: 20, // TODO: configurable max(1, targetConnections)
-		disconnects:       make(map[peer.ID]time.Time),
-		noReconnect:       make(map[peer.ID]bool),
+		h:                    h, // tmp
+		c:                    h,
+		ps:                   h.Peerstore(),
+		log:                  logger,
+		done:                 done,
+		requiredProtocols:    requiredProtocols,
+		pex:                  pex,
+		requestPeers:         requestPeers,
+		addrBook:             addrBook,
+		targetConnections:    defaultTargetConnections,
+		findPeersConcurrency: defaultFindPeersConcurrency,
+		disconnects:          make(map[peer.ID]time.Time),
+		disconnectRetention:  defaultDisconnectRetention,
+		evictionInterval:     defaultEvictionInterval,
+		bans:                 make(map[peer.ID]time.Time),
+		protected:            make(map[peer.ID]bool),
+		maxReconnects:        defaultMaxReconnects,
+		reconnecting:         make(map[peer.ID]struct{}),
+		dials:                make(map[uint64]*dialAttempt),
+		pingTimeout:          defaultPingTimeout,
+		pingFailures:         make(map[peer.ID]int),
+		provisionalTTL:       peerstore.RecentlyConnectedAddrTTL,
 	}
+	pm.close = sync.OnceFunc(func() {
+		// Also cancel the in-progress dials since they may not otherwise
+		// return until they time out.
+		pm.dialMtx.Lock()
+		close(done)
+		for _, d := range pm.dials {
+			d.cancel(errClosed)
+		}
+		pm.dialMtx.Unlock()
+	})
 
 	peerInfo, err := loadPeers(pm.addrBook)
-	if err != nil && !errors.Is(err, os.ErrNotExist) {
-		return nil, fmt.Errorf("failed to load address book %s", pm.addrBook)
+	switch {
+	case errors.Is(err, errCorruptAddrBook):
+		// e.g. a partial write from before atomic saves, or a bad manual edit
+		logger.Warn("Ignoring corrupt address book, starting with no known peers", "file", pm.addrBook, "error", err)
+		peerInfo = nil
+	case err != nil && !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("failed to load address book %s: %w", pm.addrBook, err)
+	}
+	numPeers := pm.addPeers(peerInfo, pm.provisionalTTL)
+	logger.Info("Loaded address book", "peers", numPeers)
+
+	// Resume tracking of when the loaded peers were last seen so that stale
+	// entries are still eventually removed, and restore unexpired bans.
+	now := time.Now()
+	for _, pInfo := range peerInfo {
+		if !pInfo.LastSeen.IsZero() {
+			pm.disconnects[pInfo.ID] = pInfo.LastSeen
+		}
+		if pInfo.BannedUntil.After(now) {
+			pm.bans[pInfo.ID] = pInfo.BannedUntil
+		}
 	}
-	numPeers := pm.addPeers(peerInfo, peerstore.RecentlyConnectedAddrTTL)
-	logger.Infof("Loaded address book with %d peers", numPeers)
 
 	return pm, nil
 }
 
+// SetTargetConnections sets the number of connections that the PeerMan tries
+// to maintain by connecting to known peers. This should be the low watermark
+// of the host's connection manager, if it has one. It must be called before
+// Start.
+func (pm *PeerMan) SetTargetConnections(n int) {
+	pm.targetConnections = max(1, n)
+}
+
+// SetDisconnectRetention sets how long a peer may be disconnected before it is
+// removed from the peer store and address book. The default is one week. A
+// non-positive duration restores the default. It must be called before Start.
+func (pm *PeerMan) SetDisconnectRetention(d time.Duration) {
+	if d <= 0 {
+		d = defaultDisconnectRetention
+	}
+	pm.disconnectRetention = d
+}
+
+// SetEvictionInterval sets how often the peers that have been disconnected for
+// longer than the retention period are removed, and expired bans cleared. The
+// default is 10 minutes. A non-positive duration restores the default. It must
+// be called before Start.
+func (pm *PeerMan) SetEvictionInterval(d time.Duration) {
+	if d <= 0 {
+		d = defaultEvictionInterval
+	}
+	pm.evictionInterval = d
+}
+
 var _ discovery.Discoverer = (*PeerMan)(nil) // FindPeers method
 
 func (pm *PeerMan) Start(ctx context.Context) error {
@@ -142,6 +278,7 @@ func (pm *PeerMan) maintainMinPeers(ctx context.Context) {
 	ticker := time.NewTicker(urgentConnInterval)
 	defer ticker.Stop()
 
+	var lastPing time.Time
 	for {
 		select {
 		case <-ticker.C:
@@ -149,24 +286,37 @@ func (pm *PeerMan) maintainMinPeers(ctx context.Context) {
 			return
 		}
 
+		// Detect half-open connections that would otherwise linger until a
+		// stream fails, so they are not counted as active.
+		if time.Since(lastPing) >= pingInterval {
+			pm.pingPeers(ctx)
+			lastPing = time.Now()
+		}
+
 		_, activeConns, unconnectedPeers := pm.KnownPeers()
 		if numActive := len(activeConns); numActive < pm.targetConnections {
 			if numActive == 0 && len(unconnectedPeers) == 0 {
-				pm.log.Warnln("No connected peers and no known addresses to dial!")
+				pm.log.Warn("No connected peers and no known addresses to dial!")
 				continue
 			}
 
-			pm.log.Infof("Active connections: %d, below target: %d. Initiating new connections.",
-				numActive, pm.targetConnections)
+			pm.log.Info("Active connections below target. Initiating new connections.",
+				"active", numActive, "target", pm.targetConnections)
 
 			var added int
 			for _, peerInfo := range unconnectedPeers {
 				pid := peerInfo.ID
-				err := pm.h.Connect(ctx, peer.AddrInfo{ID: pid})
-				if err != nil {
-					pm.log.Warnf("Failed to connect to peer %s: %v", pid, CompressDialError(err))
+				if pm.IsBanned(pid) {
+					continue
+				}
+				err := pm.dial(ctx, peer.AddrInfo{ID: pid})
+				if errors.Is(err, ErrDialCanceled) {
+					pm.log.Info("Dial to peer canceled", "peer", pid)
+				} else if err != nil {
+					pm.numFailedDials.Add(1)
+					pm.log.Warn("Failed to connect to peer", "peer", pid, "error", CompressDialError(err))
 				} else {
-					pm.log.Infof("Connected to peer %s", pid)
+					pm.log.Info("Connected to peer", "peer", pid)
 					added++
 				}
 			}
@@ -179,7 +329,7 @@ func (pm *PeerMan) maintainMinPeers(ctx context.Context) {
 				ticker.Reset(normalConnInterval)
 			}
 		} else {
-			pm.log.Debugf("Have %d connections and %d candidates of %d target", numActive, len(unconnectedPeers), pm.targetConnections)
+			pm.log.Debug("Have enough connections", "active", numActive, "candidates", len(unconnectedPeers), "target", pm.targetConnections)
 		}
 
 	}
@@ -190,7 +340,7 @@ func (pm *PeerMan) startPex(ctx context.Context) {
 		// discover for this node
 		peerChan, err := pm.FindPeers(ctx, "kwil_namespace")
 		if err != nil {
-			pm.log.Errorf("FindPeers: %v", err)
+			pm.log.Error("FindPeers failed", "error", err)
 		} else {
 			go func() {
 				var count int
@@ -198,14 +348,14 @@ func (pm *PeerMan) startPex(ctx context.Context) {
 					if pm.addPeerAddrs(peer) {
 						// TODO: connection manager, with limits
 						if err = pm.c.Connect(ctx, peer); err != nil {
-							pm.log.Warnf("Failed to connect to %s: %v", peer.ID, CompressDialError(err))
+							pm.log.Warn("Failed to connect to peer", "peer", peer.ID, "error", CompressDialError(err))
 						}
 					}
 					count++
 				}
 				if count > 0 {
 					if err := pm.savePeers(); err != nil {
-						pm.log.Warnf("Failed to write address book: %v", err)
+						pm.log.Warn("Failed to write address book", "error", err)
 					}
 				}
 			}()
@@ -218,11 +368,25 @@ func (pm *PeerMan) startPex(ctx context.Context) {
 		}
 
 		if err := pm.savePeers(); err != nil {
-			pm.log.Warnf("Failed to write address book: %v", err)
+			pm.log.Warn("Failed to write address book", "error", err)
 		}
 	}
 }
 
+// defaultFindPeersConcurrency is the default number of peers that FindPeers
+// requests peers from at once.
+const defaultFindPeersConcurrency = 8
+
+// SetFindPeersConcurrency sets the number of connected peers that FindPeers
+// requests peers from at once. It should be set before Start.
+func (pm *PeerMan) SetFindPeersConcurrency(n int) {
+	pm.findPeersConcurrency = max(1, n)
+}
+
+// FindPeers requests peers from each connected peer, using a bounded number of
+// concurrent requests. Each discovered peer is sent on the returned channel
+// once, excluding this node and the peers that are already connected. The
+// channel is closed when all requests are complete or the context is canceled.
 func (pm *PeerMan) FindPeers(ctx context.Context, ns string, opts ...discovery.Option) (<-chan peer.AddrInfo, error) {
 	peerChan := make(chan peer.AddrInfo)
 
@@ -233,21 +397,59 @@ func (pm *PeerMan) FindPeers(ctx context.Context, ns string, opts ...discovery.O
 		return peerChan, nil
 	}
 
-	var wg sync.WaitGroup
-	wg.Add(len(peers))
+	// Skip ourself and the connected peers, and dedup the rest.
+	var mtx sync.Mutex
+	seen := make(map[peer.ID]bool, len(peers)+1)
+	seen[pm.h.ID()] = true
 	for _, peerID := range peers {
-		go func() {
-			defer wg.Done()
-			ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-			defer cancel()
-			peers, err := pm.requestPeers(ctx, peerID)
-			if err != nil {
-				pm.log.Warnf("Failed to get peers from %v: %v", peerID, err)
+		seen[peerID] = true
+	}
+	isNew := func(peerID peer.ID) bool {
+		mtx.Lock()
+		defer mtx.Unlock()
+		if seen[peerID] {
+			return false
+		}
+		seen[peerID] = true
+		return true
+	}
+
+	queue := make(chan peer.ID)
+	go func() {
+		defer close(queue)
+		for _, peerID := range peers {
+			select {
+			case queue <- peerID:
+			case <-ctx.Done():
 				return
 			}
+		}
+	}()
 
-			for _, p := range peers {
-				peerChan <- p
+	var wg sync.WaitGroup
+	for range min(pm.findPeersConcurrency, len(peers)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for peerID := range queue {
+				reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+				peers, err := pm.requestPeers(reqCtx, peerID)
+				cancel()
+				if err != nil {
+					pm.log.Warn("Failed to get peers", "peer", peerID, "error", err)
+					continue
+				}
+
+				for _, p := range peers {
+					if !isNew(p.ID) {
+						continue
+					}
+					select {
+					case peerChan <- p:
+					case <-ctx.Done():
+						return
+					}
+				}
 			}
 		}()
 	}
@@ -263,15 +465,17 @@ func (pm *PeerMan) FindPeers(ctx context.Context, ns string, opts ...discovery.O
 // ConnectedPeers returns a list of peer info for all connected peers.
 func (pm *PeerMan) ConnectedPeers() []PeerInfo {
 	var peers []PeerInfo
+	now := time.Now()
 	for _, peerID := range pm.h.Network().Peers() { // connected peers first
 		if peerID == pm.h.ID() { // me
 			continue
 		}
 		peerInfo, err := peerInfo(pm.ps, peerID)
 		if err != nil {
-			pm.log.Warnf("peerInfo for %v: %v", peerID, err)
+			pm.log.Warn("Failed to get peer info", "peer", peerID, "error", err)
 			continue
 		}
+		peerInfo.LastSeen = now
 
 		peers = append(peers, *peerInfo)
 	}
@@ -279,36 +483,238 @@ func (pm *PeerMan) ConnectedPeers() []PeerInfo {
 	return peers
 }
 
-// KnownPeers returns a list of peer info for all known peers (connected or just
-// in peer store).
+// Metrics returns the current peer counts and the connection event counters.
+func (pm *PeerMan) Metrics() Metrics {
+	self := pm.h.ID()
+	var connected, known int
+	for _, peerID := range pm.h.Network().Peers() {
+		if peerID != self {
+			connected++
+		}
+	}
+	for _, peerID := range pm.ps.Peers() {
+		if peerID != self {
+			known++
+		}
+	}
+	return Metrics{
+		ConnectedPeers:    connected,
+		KnownPeers:        known,
+		Connects:          pm.numConnects.Load(),
+		Disconnects:       pm.numDisconnects.Load(),
+		ReconnectAttempts: pm.numReconnectAttempts.Load(),
+		FailedDials:       pm.numFailedDials.Load(),
+		Evictions:         pm.numEvictions.Load(),
+		DeadConnections:   pm.numDeadConns.Load(),
+	}
+}
+
+// KnownPeers returns the peer info for all known peers, which are the
+// connected peers and the others in the peer store. The node itself is
+// excluded. The all slice lists the connected peers first, in the order of
+// connected, followed by the disconnected peers in the order of disconnected.
 func (pm *PeerMan) KnownPeers() (all, connected, disconnected []PeerInfo) {
-	// connected peers first
-	peers := pm.ConnectedPeers()
-	connectedPeers := make(map[peer.ID]bool)
-	for _, peerInfo := range peers {
-		connectedPeers[peerInfo.ID] = true
-		connected = append(connected, peerInfo)
+	connected = pm.ConnectedPeers()
+	isConnected := make(map[peer.ID]bool, len(connected))
+	for _, peerInfo := range connected {
+		isConnected[peerInfo.ID] = true
 	}
 
-	// all others in peer store
+	pm.mtx.Lock()
 	for _, peerID := range pm.ps.Peers() {
-		if peerID == pm.h.ID() { // me
+		if peerID == pm.h.ID() || isConnected[peerID] {
 			continue
 		}
-		if connectedPeers[peerID] {
-			continue // it is connected
-		}
 		peerInfo, err := peerInfo(pm.ps, peerID)
 		if err != nil {
-			pm.log.Warnf("peerInfo for %v: %v", peerID, err)
+			pm.log.Warn("Failed to get peer info", "peer", peerID, "error", err)
 			continue
 		}
-
+		peerInfo.LastSeen = pm.disconnects[peerID]
 		disconnected = append(disconnected, *peerInfo)
-		peers = append(peers, *peerInfo)
 	}
+	pm.mtx.Unlock()
 
-	return peers, connected, disconnected
+	all = make([]PeerInfo, 0, len(connected)+len(disconnected))
+	all = append(all, connected...)
+	all = append(all, disconnected...)
+	return all, connected, disconnected
+}
+
+// AddPeer adds the addresses of a peer to the peer store, and persists the
+// address book. Unlike peers found with discovery, the addresses do not expire,
+// including any that were already known with an expiry. It returns false if all
+// of the addresses were already known, so adding a peer again is not an error.
+// If no addresses are given, the peer's already known addresses are kept
+// permanently, and there must be some.
+func (pm *PeerMan) AddPeer(p peer.AddrInfo) (bool, error) {
+	if p.ID == pm.h.ID() {
+		return false, errors.New("cannot add self as a peer")
+	}
+	if len(p.Addrs) == 0 { // keep the addresses we already know, e.g. from PEX
+		p.Addrs = pm.ps.Addrs(p.ID)
+		if len(p.Addrs) == 0 {
+			return false, fmt.Errorf("no known addresses for peer %v", p.ID)
+		}
+		pm.ps.AddAddrs(p.ID, p.Addrs, peerstore.PermanentAddrTTL)
+		return false, pm.savePeers()
+	}
+	numAdded := pm.addPeers([]PeerInfo{{AddrInfo: AddrInfo(p)}}, peerstore.PermanentAddrTTL)
+	// The TTL of addresses that were already known, e.g. from PEX, is raised.
+	pm.ps.AddAddrs(p.ID, p.Addrs, peerstore.PermanentAddrTTL)
+	return numAdded > 0, pm.savePeers()
+}
+
+// RemovePeer disconnects from a peer, removes it from the peer store, and
+// persists the address book.
+func (pm *PeerMan) RemovePeer(peerID peer.ID) error {
+	pm.mtx.Lock()
+	delete(pm.disconnects, peerID)
+	pm.mtx.Unlock()
+
+	if err := pm.h.Network().ClosePeer(peerID); err != nil {
+		pm.log.Warn("Failed to disconnect from peer", "peer", peerID, "error", err)
+	}
+	pm.ps.ClearAddrs(peerID)
+	pm.ps.RemovePeer(peerID)
+
+	return pm.savePeers()
+}
+
+// BanPeer disconnects from a peer and prevents connections with it until the
+// given time. While banned, the peer is not dialed, and any connection it makes
+// is closed. The ban is persisted in the address book. An existing ban for the
+// peer is replaced.
+func (pm *PeerMan) BanPeer(peerID peer.ID, until time.Time) error {
+	if peerID == pm.h.ID() {
+		return errors.New("cannot ban self")
+	}
+	pm.mtx.Lock()
+	pm.bans[peerID] = until
+	pm.mtx.Unlock()
+
+	pm.log.Info("Banned peer", "peer", peerID, "until", until.Format(time.RFC3339))
+
+	pm.h.ConnManager().Unprotect(peerID, protectTag)
+	if err := pm.h.Network().ClosePeer(peerID); err != nil {
+		pm.log.Warn("Failed to disconnect from peer", "peer", peerID, "error", err)
+	}
+
+	return pm.savePeers()
+}
+
+// Unban removes any ban for a peer, allowing connections with it again. The
+// address book is persisted.
+func (pm *PeerMan) Unban(peerID peer.ID) error {
+	pm.mtx.Lock()
+	_, banned := pm.bans[peerID]
+	delete(pm.bans, peerID)
+	pm.mtx.Unlock()
+
+	if !banned {
+		return nil
+	}
+	pm.log.Info("Unbanned peer", "peer", peerID)
+
+	pm.mtx.Lock()
+	if pm.protected[peerID] {
+		pm.h.ConnManager().Protect(peerID, protectTag)
+	}
+	pm.mtx.Unlock()
+
+	return pm.savePeers()
+}
+
+// SetProtectedPeers sets the peers with connections that the host's connection
+// manager does not trim, such as the validators and the bootnodes, replacing
+// any that were set before. The connections of other peers may be trimmed. A
+// banned peer is not protected until it is unbanned.
+func (pm *PeerMan) SetProtectedPeers(peerIDs []peer.ID) {
+	protected := make(map[peer.ID]bool, len(peerIDs))
+	for _, peerID := range peerIDs {
+		if peerID != pm.h.ID() {
+			protected[peerID] = true
+		}
+	}
+
+	pm.mtx.Lock()
+	defer pm.mtx.Unlock()
+
+	cm := pm.h.ConnManager()
+	for peerID := range pm.protected {
+		if !protected[peerID] {
+			cm.Unprotect(peerID, protectTag)
+		}
+	}
+	now := time.Now()
+	for peerID := range protected {
+		if !pm.isBanned(peerID, now) {
+			cm.Protect(peerID, protectTag)
+		}
+	}
+	pm.protected = protected
+}
+
+// IsBanned indicates if a peer is banned. A ban that has expired is not
+// considered, even if it is not yet cleared.
+func (pm *PeerMan) IsBanned(peerID peer.ID) bool {
+	pm.mtx.Lock()
+	defer pm.mtx.Unlock()
+	return pm.isBanned(peerID, time.Now())
+}
+
+// isBanned requires pm.mtx to be locked.
+func (pm *PeerMan) isBanned(peerID peer.ID, now time.Time) bool {
+	until, ok := pm.bans[peerID]
+	return ok && now.Before(until)
+}
+
+// clearExpiredBans removes the bans that expired as of now, returning the
+// number removed.
+func (pm *PeerMan) clearExpiredBans(now time.Time) int {
+	pm.mtx.Lock()
+	defer pm.mtx.Unlock()
+	var cleared int
+	for peerID, until := range pm.bans {
+		if !now.Before(until) {
+			delete(pm.bans, peerID)
+			pm.log.Info("Ban expired for peer", "peer", peerID)
+			cleared++
+		}
+	}
+	return cleared
+}
+
+// ReloadAddrBook re-reads the address book file and merges any new peers or
+// peer addresses into the peer store. This allows peers to be added to the
+// address book file while the node is running. The number of peers with new
+// addresses is returned. The address book is validated before any peers are
+// added, and entries for this node are skipped.
+func (pm *PeerMan) ReloadAddrBook() (int, error) {
+	peerList, err := loadPeers(pm.addrBook)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, pInfo := range peerList {
+		if err := pInfo.ID.Validate(); err != nil {
+			return 0, fmt.Errorf("invalid peer ID in address book entry %d: %w", i, err)
+		}
+	}
+
+	var added int
+	for _, pInfo := range peerList {
+		if pInfo.ID == pm.h.ID() {
+			continue
+		}
+		if pm.addPeers([]PeerInfo{pInfo}, pm.provisionalTTL) > 0 {
+			added++
+		}
+	}
+
+	pm.log.Info("Reloaded address book", "new_peers", added)
+
+	return added, nil
 }
 
 func CheckProtocolSupport(_ context.Context, ps peerstore.Peerstore, peerID peer.ID, protoIDs ...protocol.ID) (bool, error) {
@@ -334,6 +740,22 @@ func CheckProtocolSupport(_ context.Context, ps peerstore.Peerstore, peerID peer
 	// return true, nil
 }
 
+// MissingProtocols returns the protocols in protoIDs that the peer is not known
+// to support.
+func MissingProtocols(ps peerstore.Peerstore, peerID peer.ID, protoIDs ...protocol.ID) ([]protocol.ID, error) {
+	supported, err := ps.SupportsProtocols(peerID, protoIDs...)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to check protocols for peer %v: %w", peerID, err)
+	}
+	var missing []protocol.ID
+	for _, pid := range protoIDs {
+		if !slices.Contains(supported, pid) {
+			missing = append(missing, pid)
+		}
+	}
+	return missing, nil
+}
+
 func RequirePeerProtos(ctx context.Context, ps peerstore.Peerstore, peer peer.ID, protoIDs ...protocol.ID) error {
 	for _, pid := range protoIDs {
 		ok, err := CheckProtocolSupport(ctx, ps, peer, pid)
@@ -358,6 +780,13 @@ func peerInfo(ps peerstore.Peerstore, peerID peer.ID) (*PeerInfo, error) {
 		return nil, fmt.Errorf("GetProtocols for %v: %w", peerID, err)
 	}
 
+	// The peer store's order is arbitrary, so sort for stable output, such as
+	// an unchanged address book file.
+	slices.SortFunc(addrs, func(a, b multiaddr.Multiaddr) int {
+		return bytes.Compare(a.Bytes(), b.Bytes())
+	})
+	slices.Sort(supportedProtos)
+
 	return &PeerInfo{
 		AddrInfo: AddrInfo{
 			ID:    peerID,
@@ -374,31 +803,94 @@ func (pm *PeerMan) PrintKnownPeers() {
 	}
 }
 
+// SavePeers persists all known peers to the address book file.
+func (pm *PeerMan) SavePeers() error {
+	return pm.savePeers()
+}
+
 func (pm *PeerMan) savePeers() error {
 	peerList, _, _ := pm.KnownPeers()
-	pm.log.Infof("saving %d peers to address book", len(peerList))
+	peerList = pm.withBans(peerList)
+	pm.log.Info("saving peers to address book", "peers", len(peerList))
 	if err := persistPeers(peerList, pm.addrBook); err != nil {
 		return err
 	}
 	return nil
 }
 
+// withBans sets the ban expiry for the peers in the list, and appends any
+// other banned peers so that their bans are persisted.
+func (pm *PeerMan) withBans(peerList []PeerInfo) []PeerInfo {
+	pm.mtx.Lock()
+	defer pm.mtx.Unlock()
+	now := time.Now()
+	listed := make(map[peer.ID]bool, len(peerList))
+	for i := range peerList {
+		listed[peerList[i].ID] = true
+		if pm.isBanned(peerList[i].ID, now) {
+			peerList[i].BannedUntil = pm.bans[peerList[i].ID]
+		}
+	}
+	for peerID, until := range pm.bans {
+		if !listed[peerID] && pm.isBanned(peerID, now) {
+			peerList = append(peerList, PeerInfo{
+				AddrInfo:    AddrInfo{ID: peerID},
+				BannedUntil: until,
+			})
+		}
+	}
+	return peerList
+}
+
 // persistPeers saves known peers to a JSON file
+// persistPeers writes the peers to the address book file. The file is written
+// atomically by writing a temporary file in the same directory and renaming it
+// over the target, so a crash mid-write never leaves a truncated address book.
+// Concurrent calls each produce a complete file; the last rename wins. The
+// peers are written in order of ID, so the same peers always give the same
+// file contents regardless of the order of the slice, which is not modified.
 func persistPeers(peers []PeerInfo, filePath string) error {
+	peers = slices.Clone(peers)
+	slices.SortFunc(peers, func(a, b PeerInfo) int {
+		return strings.Compare(a.ID.String(), b.ID.String())
+	})
+
 	// Marshal peerList to JSON
 	data, err := json.MarshalIndent(peers, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshaling peers to JSON: %v", err)
 	}
 
-	// Write to file
-	err = os.WriteFile(filePath, data, 0644)
+	f, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("creating temporary peers file: %w", err)
+	}
+	tmpName := f.Name()
+	defer os.Remove(tmpName) // fails harmlessly after a successful rename
+
+	if _, err = f.Write(data); err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
 	if err != nil {
 		return fmt.Errorf("writing peers to file: %w", err)
 	}
+	if err = os.Chmod(tmpName, 0644); err != nil {
+		return fmt.Errorf("setting peers file permissions: %w", err)
+	}
+
+	if err = os.Rename(tmpName, filePath); err != nil {
+		return fmt.Errorf("replacing peers file: %w", err)
+	}
 	return nil
 }
 
+// errCorruptAddrBook is returned by loadPeers when the address book file exists
+// but does not contain a valid JSON peer list.
+var errCorruptAddrBook = errors.New("corrupt address book")
+
 func loadPeers(filePath string) ([]PeerInfo, error) {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
@@ -407,7 +899,7 @@ func loadPeers(filePath string) ([]PeerInfo, error) {
 
 	var peerList []PeerInfo
 	if err := json.Unmarshal(data, &peerList); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal peerstore data: %w", err)
+		return nil, fmt.Errorf("%w: %w", errCorruptAddrBook, err)
 	}
 	return peerList, nil
 }
@@ -422,15 +914,14 @@ func (pm *PeerMan) addPeers(peerList []PeerInfo, ttl time.Duration) int {
 		for _, addr := range pInfo.Addrs {
 			if !multiaddr.Contains(addrs, addr) {
 				pm.ps.AddAddr(pInfo.ID, addr, ttl)
-				pm.log.Infof("Added new peer address to store: %v @ %v", pInfo.ID, addr)
+				pm.log.Info("Added new peer address to store", "peer", pInfo.ID, "addr", addr)
 				count++
 			}
-			// TODO: we need a connect hook to change to forever on connect
 		}
 		//addPeerAddrs(ps, peer.AddrInfo(pInfo.AddrInfo))
 		for _, proto := range pInfo.Protos {
 			if err := pm.ps.AddProtocols(pInfo.ID, proto); err != nil {
-				pm.log.Warnf("Error adding protocol %s for peer %s: %v", proto, pInfo.ID, err)
+				pm.log.Warn("Error adding protocol for peer", "protocol", proto, "peer", pInfo.ID, "error", err)
 			}
 		}
 	}
@@ -453,9 +944,14 @@ func (pm *PeerMan) addPeerAddrs(p peer.AddrInfo) (added bool) {
 func (pm *PeerMan) Connected(net network.Network, conn network.Conn) {
 	peerID := conn.RemotePeer()
 	addr := conn.RemoteMultiaddr()
-	pm.log.Infof("Connected to peer (%s) %s @ %v", conn.Stat().Direction, peerID, addr.String())
+	pm.log.Info("Connected to peer", "peer", peerID, "direction", conn.Stat().Direction.String(), "addr", addr.String())
+	pm.numConnects.Add(1)
 
-	// pm.ps.UpdateAddrs(peerID, ttlProvisional, ttlKnown)
+	// Keep the addresses of a peer we have connected to while it remains
+	// connected, rather than letting them expire.
+	for _, ttl := range []time.Duration{peerstore.TempAddrTTL, pm.provisionalTTL} {
+		pm.ps.UpdateAddrs(peerID, ttl, ttlConnected)
+	}
 
 	go func() {
 		// Particularly for inbound, there seems to be a race condition with
@@ -469,12 +965,8 @@ func (pm *PeerMan) Connected(net network.Network, conn network.Conn) {
 			return
 		}
 		if err := RequirePeerProtos(context.TODO(), pm.ps, peerID, pm.requiredProtocols...); err != nil {
-			pm.log.Warnf("Peer %v does not support required protocols: %v", peerID, err)
-			// pm.mtx.Lock()
-			// pm.noReconnect[peerID] = true
-			// pm.mtx.Unlock()
+			pm.log.Warn("Peer does not support required protocols", "peer", peerID, "error", err)
 			// conn.Close()
-			return
 		}
 	}()
 
@@ -482,20 +974,33 @@ func (pm *PeerMan) Connected(net network.Network, conn network.Conn) {
 	pm.mtx.Lock()
 	defer pm.mtx.Unlock()
 	delete(pm.disconnects, peerID)
+
+	if pm.isBanned(peerID, time.Now()) {
+		pm.log.Info("Closing connection from banned peer", "peer", peerID)
+		go conn.Close() // not from the notifiee callback
+	}
 }
 
 // Disconnected is triggered when a peer disconnects
 func (pm *PeerMan) Disconnected(net network.Network, conn network.Conn) {
 	peerID := conn.RemotePeer()
-	pm.log.Infof("Disconnected from peer %v", peerID)
+	pm.log.Info("Disconnected from peer", "peer", peerID)
+	pm.numDisconnects.Add(1)
 	// Store disconnection timestamp
 	pm.mtx.Lock()
 	defer pm.mtx.Unlock()
-	// if pm.noReconnect[peerID] {
-	// 	delete(pm.noReconnect, peerID)
-	// 	return
-	// }
 	pm.disconnects[peerID] = time.Now()
+	delete(pm.pingFailures, peerID)
+
+	// With the last connection to the peer closed, its addresses may expire
+	// again if we do not reconnect.
+	if net.Connectedness(peerID) != network.Connected {
+		pm.ps.UpdateAddrs(peerID, ttlConnected, pm.provisionalTTL)
+	}
+
+	if pm.isBanned(peerID, time.Now()) {
+		return // no reconnect
+	}
 
 	select {
 	case <-pm.done:
@@ -503,33 +1008,35 @@ func (pm *PeerMan) Disconnected(net network.Network, conn network.Conn) {
 	default:
 	}
 
-	// Create a context that is canceled if pm.done is closed (Notifiee
-	// interface doesn't pass one).
-	ctx, cancel := context.WithCancel(context.Background())
-	go func() {
-		defer cancel()
-		select {
-		case <-ctx.Done():
-			return
-		case <-pm.done:
-			return
-		}
-	}()
+	// Only one reconnect routine per peer, since a peer may disconnect again
+	// while it is still being reconnected to.
+	if _, ok := pm.reconnecting[peerID]; ok {
+		return
+	}
+	if len(pm.reconnecting) >= pm.maxReconnects {
+		pm.log.Info("Too many reconnects in progress, not reconnecting to peer", "peer", peerID)
+		return
+	}
+	pm.reconnecting[peerID] = struct{}{}
 
 	pm.wg.Add(1)
 	go func() {
 		defer pm.wg.Done()
-		defer cancel()
+		defer func() {
+			pm.mtx.Lock()
+			delete(pm.reconnecting, peerID)
+			pm.mtx.Unlock()
+		}()
 		var delay = time.Second
 		if time.Since(conn.Stat().Opened) < time.Second {
 			delay *= 3 // ugh, but what was the reason
 		}
 		select {
-		case <-ctx.Done(): // pm.done closed (shutdown)
+		case <-pm.done: // shutdown
 			return
 		case <-time.After(delay):
 		}
-		pm.reconnectWithRetry(ctx, peerID)
+		pm.reconnectWithRetry(peerID)
 	}()
 }
 
@@ -538,9 +1045,15 @@ func (pm *PeerMan) ListenClose(network.Network, multiaddr.Multiaddr) {}
 
 // Reconnect logic with retry
 
-// Reconnect logic with exponential backoff and capped retries
-func (pm *PeerMan) reconnectWithRetry(ctx context.Context, peerID peer.ID) {
+// Reconnect logic with exponential backoff and capped retries. It returns
+// early if the PeerMan is closed.
+func (pm *PeerMan) reconnectWithRetry(peerID peer.ID) {
 	for attempt := range maxRetries {
+		if pm.IsBanned(peerID) {
+			pm.log.Info("Not reconnecting to banned peer", "peer", peerID)
+			return
+		}
+
 		addrInfo := peer.AddrInfo{
 			ID:    peerID,
 			Addrs: pm.ps.Addrs(peerID),
@@ -551,15 +1064,24 @@ func (pm *PeerMan) reconnectWithRetry(ctx context.Context, peerID peer.ID) {
 			delay = 1 * time.Minute // Cap delay at 1 minute
 		}
 
-		pm.log.Infof("Attempting reconnection to peer %s (attempt %d/%d)", peerID, attempt+1, maxRetries)
-		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
-		if err := pm.c.Connect(ctx, addrInfo); err != nil {
+		pm.log.Info("Attempting reconnection to peer", "peer", peerID, "attempt", attempt+1, "max_attempts", maxRetries)
+		pm.numReconnectAttempts.Add(1)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := pm.dial(ctx, addrInfo); errors.Is(err, ErrDialCanceled) {
 			cancel()
-			err = CompressDialError(err)
-			pm.log.Infof("Failed to reconnect to peer %s (trying again in %v): %v", peerID, delay, err)
+			pm.log.Info("Reconnection to peer canceled. Giving up.", "peer", peerID)
+			return
+		} else if errors.Is(err, errClosed) {
+			cancel()
+			return
+		} else if err != nil {
+			cancel()
+			pm.numFailedDials.Add(1)
+			pm.log.Info("Failed to reconnect to peer", "peer", peerID, "attempt", attempt+1,
+				"retry_in", delay, "error", CompressDialError(err))
 		} else {
 			cancel()
-			pm.log.Infof("Successfully reconnected to peer %s", peerID)
+			pm.log.Info("Successfully reconnected to peer", "peer", peerID, "attempt", attempt+1)
 			return
 		}
 
@@ -569,12 +1091,130 @@ func (pm *PeerMan) reconnectWithRetry(ctx context.Context, peerID peer.ID) {
 		case <-time.After(delay):
 		}
 	}
-	pm.log.Infof("Exceeded max retries for peer %s. Giving up.", peerID)
+	pm.log.Info("Exceeded max retries for peer. Giving up.", "peer", peerID, "max_attempts", maxRetries)
 }
 
-// Periodically remove peers disconnected for over a week
+// dial connects to a peer. While it is in progress, the dial is listed by
+// PendingDials, and it may be canceled with CancelDial, in which case the
+// error is ErrDialCanceled. If the PeerMan is closed, the error is errClosed.
+func (pm *PeerMan) dial(ctx context.Context, addrInfo peer.AddrInfo) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	pm.dialMtx.Lock()
+	select {
+	case <-pm.done:
+		pm.dialMtx.Unlock()
+		return errClosed
+	default:
+	}
+	id := pm.nextDial
+	pm.nextDial++
+	pm.dials[id] = &dialAttempt{
+		peerID:  addrInfo.ID,
+		started: time.Now(),
+		cancel:  cancel,
+	}
+	pm.dialMtx.Unlock()
+
+	defer func() {
+		pm.dialMtx.Lock()
+		delete(pm.dials, id)
+		pm.dialMtx.Unlock()
+	}()
+
+	err := pm.c.Connect(ctx, addrInfo)
+	if err != nil {
+		if cause := context.Cause(ctx); errors.Is(cause, ErrDialCanceled) || errors.Is(cause, errClosed) {
+			return cause
+		}
+	}
+	return err
+}
+
+// PendingDials returns the outbound dials that are in progress, oldest first.
+// A peer may be listed more than once if it is being dialed concurrently, such
+// as to reconnect and to maintain the target number of connections.
+func (pm *PeerMan) PendingDials() []DialInfo {
+	pm.dialMtx.Lock()
+	defer pm.dialMtx.Unlock()
+	dials := make([]DialInfo, 0, len(pm.dials))
+	for _, d := range pm.dials {
+		dials = append(dials, DialInfo{ID: d.peerID, Started: d.started})
+	}
+	slices.SortFunc(dials, func(a, b DialInfo) int {
+		return a.Started.Compare(b.Started)
+	})
+	return dials
+}
+
+// CancelDial cancels the in-progress dials to a peer. A canceled reconnect
+// dial is not retried. It returns false if the peer was not being dialed.
+func (pm *PeerMan) CancelDial(peerID peer.ID) bool {
+	pm.dialMtx.Lock()
+	defer pm.dialMtx.Unlock()
+	var canceled bool
+	for _, d := range pm.dials {
+		if d.peerID == peerID {
+			d.cancel(ErrDialCanceled)
+			canceled = true
+		}
+	}
+	return canceled
+}
+
+// Reconnect closes any existing connection to a peer and immediately dials it
+// again, bypassing the reconnect backoff, such as to force the renegotiation of
+// protocols after the peer is upgraded. The peer must have known addresses and
+// must not be banned. It returns the peer's connectedness after the dial.
+func (pm *PeerMan) Reconnect(ctx context.Context, peerID peer.ID) (network.Connectedness, error) {
+	if peerID == pm.h.ID() {
+		return network.NotConnected, errors.New("cannot reconnect to self")
+	}
+	if pm.IsBanned(peerID) {
+		return pm.h.Network().Connectedness(peerID), fmt.Errorf("peer %v is banned", peerID)
+	}
+	addrs := pm.ps.Addrs(peerID)
+	if len(addrs) == 0 {
+		return pm.h.Network().Connectedness(peerID), fmt.Errorf("no known addresses for peer %v", peerID)
+	}
+
+	// Claim the peer's reconnect routine so that closing the connection does
+	// not also start a delayed reconnect with retries.
+	pm.mtx.Lock()
+	_, reconnecting := pm.reconnecting[peerID]
+	if !reconnecting {
+		pm.reconnecting[peerID] = struct{}{}
+		defer func() {
+			pm.mtx.Lock()
+			delete(pm.reconnecting, peerID)
+			pm.mtx.Unlock()
+		}()
+	}
+	pm.mtx.Unlock()
+
+	if err := pm.h.Network().ClosePeer(peerID); err != nil {
+		pm.log.Warn("Failed to disconnect from peer", "peer", peerID, "error", err)
+	}
+
+	pm.log.Info("Reconnecting to peer", "peer", peerID)
+	pm.numReconnectAttempts.Add(1)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	ctx = network.WithForceDirectDial(ctx, "reconnect") // skip the dial backoff
+	if err := pm.dial(ctx, peer.AddrInfo{ID: peerID, Addrs: addrs}); err != nil {
+		if !errors.Is(err, ErrDialCanceled) && !errors.Is(err, errClosed) {
+			pm.numFailedDials.Add(1)
+		}
+		return pm.h.Network().Connectedness(peerID), fmt.Errorf("failed to reconnect: %w", CompressDialError(err))
+	}
+	return pm.h.Network().Connectedness(peerID), nil
+}
+
+// Periodically remove peers disconnected for longer than the retention period,
+// and clear expired peer bans.
 func (pm *PeerMan) removeOldPeers() {
-	ticker := time.NewTicker(10 * time.Minute)
+	ticker := time.NewTicker(pm.evictionInterval)
 	defer ticker.Stop()
 
 	for {
@@ -585,14 +1225,20 @@ func (pm *PeerMan) removeOldPeers() {
 		}
 
 		now := time.Now()
+		if pm.clearExpiredBans(now) > 0 {
+			if err := pm.savePeers(); err != nil {
+				pm.log.Warn("Failed to save address book", "error", err)
+			}
+		}
 		func() {
 			pm.mtx.Lock()
 			defer pm.mtx.Unlock()
 			for peerID, disconnectTime := range pm.disconnects {
-				if now.Sub(disconnectTime) > disconnectLimit {
+				if now.Sub(disconnectTime) > pm.disconnectRetention {
 					pm.ps.RemovePeer(peerID)
 					delete(pm.disconnects, peerID) // Remove from tracking map
-					pm.log.Infof("Removed peer %s last connected %v ago", peerID, time.Since(disconnectTime))
+					pm.numEvictions.Add(1)
+					pm.log.Info("Removed peer", "peer", peerID, "disconnected_for", time.Since(disconnectTime))
 				}
 			}
 		}()
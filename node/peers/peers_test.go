@@ -1,12 +1,29 @@
 package peers
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/kwilteam/kwil-db/core/log"
+	adminTypes "github.com/kwilteam/kwil-db/core/types/admin"
+	"github.com/libp2p/go-libp2p/core/connmgr"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
 	"github.com/libp2p/go-libp2p/core/protocol"
+	p2pconnmgr "github.com/libp2p/go-libp2p/p2p/net/connmgr"
+	mock "github.com/libp2p/go-libp2p/p2p/net/mock"
 	ma "github.com/multiformats/go-multiaddr"
 	"github.com/stretchr/testify/require"
 )
@@ -44,7 +61,23 @@ func TestPersistAndLoadPeers(t *testing.T) {
 
 		loadedPeers, err := loadPeers(testFile)
 		require.NoError(t, err)
-		require.Equal(t, testPeers, loadedPeers)
+		// The peers are saved in order of ID.
+		require.Equal(t, []PeerInfo{testPeers[1], testPeers[0]}, loadedPeers)
+	})
+
+	t.Run("order of peers does not change the file", func(t *testing.T) {
+		shuffled := slices.Clone(testPeers)
+		slices.Reverse(shuffled)
+		shuffledFile := filepath.Join(tempDir, "shuffled_peers.json")
+		require.NoError(t, persistPeers(shuffled, shuffledFile))
+		require.NoError(t, persistPeers(testPeers, testFile))
+
+		want, err := os.ReadFile(testFile)
+		require.NoError(t, err)
+		got, err := os.ReadFile(shuffledFile)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+		require.Equal(t, pid1, testPeers[0].ID) // the input is not sorted in place
 	})
 
 	t.Run("persist empty peer list", func(t *testing.T) {
@@ -69,7 +102,7 @@ func TestPersistAndLoadPeers(t *testing.T) {
 		require.NoError(t, err)
 
 		_, err = loadPeers(invalidFile)
-		require.Error(t, err)
+		require.ErrorIs(t, err, errCorruptAddrBook)
 	})
 
 	t.Run("persist to read-only directory", func(t *testing.T) {
@@ -81,3 +114,935 @@ func TestPersistAndLoadPeers(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestNewPeerManCorruptAddrBook(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+	h, err := mn.GenPeer()
+	require.NoError(t, err)
+
+	addrBook := filepath.Join(t.TempDir(), "peers.json")
+	require.NoError(t, os.WriteFile(addrBook, []byte(`[{"id":"16Uiu2HAm8iRUsTzYe`), 0644)) // truncated
+
+	pm, err := NewPeerMan(false, addrBook, nil, h, nil, nil)
+	require.NoError(t, err)
+	require.Zero(t, pm.Metrics().KnownPeers)
+
+	// The next save replaces the corrupt file.
+	require.NoError(t, pm.savePeers())
+	loaded, err := loadPeers(addrBook)
+	require.NoError(t, err)
+	require.Empty(t, loaded)
+}
+
+func TestPersistPeersConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	addrBook := filepath.Join(dir, "peers.json")
+
+	pid, err := peer.Decode("16Uiu2HAm8iRUsTzYepLP8pdJL3645ACP7VBfZQ7yFbLfdb7WvkL7")
+	require.NoError(t, err)
+
+	// Each writer persists a list of a different length, so a file with
+	// interleaved writes would either fail to parse or have a length that
+	// does not match its contents.
+	const numWriters = 8
+	lists := make([][]PeerInfo, numWriters)
+	for i := range lists {
+		for j := range 10 * (i + 1) {
+			addr, err := ma.NewMultiaddr(fmt.Sprintf("/ip4/127.0.0.1/tcp/%d", 1000*(i+1)+j))
+			require.NoError(t, err)
+			lists[i] = append(lists[i], PeerInfo{
+				AddrInfo: AddrInfo{ID: pid, Addrs: []ma.Multiaddr{addr}},
+			})
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := range numWriters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				if err := persistPeers(lists[i], addrBook); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	check := func() {
+		loaded, err := loadPeers(addrBook)
+		if errors.Is(err, os.ErrNotExist) {
+			return // no rename yet
+		}
+		require.NoError(t, err)
+		require.NotEmpty(t, loaded)
+		require.Contains(t, lists, loaded)
+	}
+	for {
+		select {
+		case <-done:
+			check()
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			require.Len(t, entries, 1) // no leftover temporary files
+			return
+		default:
+			check()
+		}
+	}
+}
+
+func TestPeerInfoKnownPeerRoundTrip(t *testing.T) {
+	ma1, _ := ma.NewMultiaddr("/ip4/127.0.0.1/tcp/4001")
+	ma2, _ := ma.NewMultiaddr("/ip6/::1/tcp/4001")
+	pid, _ := peer.Decode("16Uiu2HAm8iRUsTzYepLP8pdJL3645ACP7VBfZQ7yFbLfdb7WvkL7")
+
+	tests := []struct {
+		name string
+		pi   PeerInfo
+	}{
+		{
+			name: "seen peer",
+			pi: PeerInfo{
+				AddrInfo: AddrInfo{
+					ID:    pid,
+					Addrs: []ma.Multiaddr{ma1, ma2},
+				},
+				Protos:   []protocol.ID{"ProtocolWhatever", "ProtocolOther"},
+				LastSeen: time.Unix(1700000000, 0),
+			},
+		},
+		{
+			name: "never seen peer",
+			pi: PeerInfo{
+				AddrInfo: AddrInfo{
+					ID:    pid,
+					Addrs: []ma.Multiaddr{ma1},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bts, err := json.Marshal(tt.pi)
+			require.NoError(t, err)
+
+			// The admin export format must decode the address book format...
+			var kp adminTypes.KnownPeer
+			require.NoError(t, json.Unmarshal(bts, &kp))
+			require.Equal(t, tt.pi.ID.String(), kp.ID)
+			require.Len(t, kp.Addrs, len(tt.pi.Addrs))
+			for i, addr := range tt.pi.Addrs {
+				require.Equal(t, addr.String(), kp.Addrs[i])
+			}
+			require.Len(t, kp.Protos, len(tt.pi.Protos))
+			if tt.pi.LastSeen.IsZero() {
+				require.Zero(t, kp.LastSeen)
+			} else {
+				require.Equal(t, tt.pi.LastSeen.Unix(), kp.LastSeen)
+			}
+
+			// ...and the address book format must decode the export format.
+			bts, err = json.Marshal(kp)
+			require.NoError(t, err)
+			var pi PeerInfo
+			require.NoError(t, json.Unmarshal(bts, &pi))
+			require.Equal(t, tt.pi, pi)
+		})
+	}
+}
+
+// mockProtoStore is a peerstore that only knows the protocols of peers.
+type mockProtoStore struct {
+	peerstore.Peerstore
+	protos map[peer.ID][]protocol.ID
+}
+
+func (m *mockProtoStore) GetProtocols(p peer.ID) ([]protocol.ID, error) {
+	return m.protos[p], nil
+}
+
+func (m *mockProtoStore) SupportsProtocols(p peer.ID, protos ...protocol.ID) ([]protocol.ID, error) {
+	var supported []protocol.ID
+	for _, proto := range protos {
+		if slices.Contains(m.protos[p], proto) {
+			supported = append(supported, proto)
+		}
+	}
+	return supported, nil
+}
+
+func TestMissingProtocols(t *testing.T) {
+	pid, _ := peer.Decode("16Uiu2HAm8iRUsTzYepLP8pdJL3645ACP7VBfZQ7yFbLfdb7WvkL7")
+	ps := &mockProtoStore{
+		protos: map[peer.ID][]protocol.ID{
+			pid: {"/kwil/tx/1.0.0", "/kwil/blk/1.0.0", "/ipfs/id/1.0.0"},
+		},
+	}
+
+	missing, err := MissingProtocols(ps, pid, "/kwil/tx/1.0.0", "/kwil/blk/1.0.0")
+	require.NoError(t, err)
+	require.Empty(t, missing)
+
+	missing, err = MissingProtocols(ps, pid, "/kwil/tx/1.0.0", "/kwil/blkprop/1.0.0", "/kwil/blk/1.0.0", "/kwil/discovery/1.0.0")
+	require.NoError(t, err)
+	require.Equal(t, []protocol.ID{"/kwil/blkprop/1.0.0", "/kwil/discovery/1.0.0"}, missing)
+
+	unknown, _ := peer.Decode("16Uiu2HAkx2kfP117VnYnaQGprgXBoMpjfxGXCpizju3cX7ZUzRhv")
+	missing, err = MissingProtocols(ps, unknown, "/kwil/tx/1.0.0")
+	require.NoError(t, err)
+	require.Equal(t, []protocol.ID{"/kwil/tx/1.0.0"}, missing)
+}
+
+func TestReloadAddrBook(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+	h, err := mn.GenPeer()
+	require.NoError(t, err)
+
+	pid1, _ := peer.Decode("16Uiu2HAm8iRUsTzYepLP8pdJL3645ACP7VBfZQ7yFbLfdb7WvkL7")
+	pid2, _ := peer.Decode("16Uiu2HAkx2kfP117VnYnaQGprgXBoMpjfxGXCpizju3cX7ZUzRhv")
+	ma1, _ := ma.NewMultiaddr("/ip4/127.0.0.1/tcp/4001")
+	ma2, _ := ma.NewMultiaddr("/ip4/127.0.0.1/tcp/4002")
+	maSelf, _ := ma.NewMultiaddr("/ip4/127.0.0.1/tcp/4003")
+
+	addrBook := filepath.Join(t.TempDir(), "peers.json")
+	peer1 := PeerInfo{AddrInfo: AddrInfo{ID: pid1, Addrs: []ma.Multiaddr{ma1}}}
+	require.NoError(t, persistPeers([]PeerInfo{peer1}, addrBook))
+
+	pm, err := NewPeerMan(false, addrBook, nil, h, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, []ma.Multiaddr{ma1}, h.Peerstore().Addrs(pid1))
+
+	// Edit the address book to add a new peer and this node.
+	peer2 := PeerInfo{AddrInfo: AddrInfo{ID: pid2, Addrs: []ma.Multiaddr{ma2}}}
+	self := PeerInfo{AddrInfo: AddrInfo{ID: h.ID(), Addrs: []ma.Multiaddr{maSelf}}}
+	require.NoError(t, persistPeers([]PeerInfo{peer1, peer2, self}, addrBook))
+
+	added, err := pm.ReloadAddrBook()
+	require.NoError(t, err)
+	require.Equal(t, 1, added)
+	require.Equal(t, []ma.Multiaddr{ma2}, h.Peerstore().Addrs(pid2))
+	require.NotContains(t, h.Peerstore().Addrs(h.ID()), maSelf)
+
+	// Nothing new on a second reload.
+	added, err = pm.ReloadAddrBook()
+	require.NoError(t, err)
+	require.Zero(t, added)
+
+	// An invalid address book is rejected.
+	require.NoError(t, os.WriteFile(addrBook, []byte(`[{"id":"not a peer id"}]`), 0644))
+	_, err = pm.ReloadAddrBook()
+	require.Error(t, err)
+}
+
+func TestFindPeersDedup(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+	h, err := mn.GenPeer()
+	require.NoError(t, err)
+
+	// connect h to several peers
+	const numConnected = 5
+	var connected []peer.ID
+	for range numConnected {
+		p, err := mn.GenPeer()
+		require.NoError(t, err)
+		connected = append(connected, p.ID())
+	}
+	require.NoError(t, mn.LinkAll())
+	for _, pid := range connected {
+		_, err := mn.ConnectPeers(h.ID(), pid)
+		require.NoError(t, err)
+	}
+
+	// unknown peers to be discovered
+	newPeerID := func() peer.ID {
+		p, err := mn.GenPeer()
+		require.NoError(t, err)
+		return p.ID()
+	}
+	unknownA, unknownB, unknownC := newPeerID(), newPeerID(), newPeerID()
+
+	// Each connected peer returns an overlapping set, including this node and
+	// other connected peers.
+	var mtx sync.Mutex
+	var active, maxActive int
+	requestPeers := func(ctx context.Context, peerID peer.ID) ([]peer.AddrInfo, error) {
+		mtx.Lock()
+		active++
+		maxActive = max(maxActive, active)
+		mtx.Unlock()
+		defer func() {
+			mtx.Lock()
+			active--
+			mtx.Unlock()
+		}()
+		time.Sleep(10 * time.Millisecond)
+
+		return []peer.AddrInfo{
+			{ID: unknownA},
+			{ID: unknownB},
+			{ID: h.ID()},
+			{ID: connected[0]},
+			{ID: peerID},
+			{ID: unknownC},
+			{ID: unknownA},
+		}, nil
+	}
+
+	pm, err := NewPeerMan(false, filepath.Join(t.TempDir(), "peers.json"), nil, h, requestPeers, nil)
+	require.NoError(t, err)
+	pm.SetFindPeersConcurrency(2)
+
+	peerChan, err := pm.FindPeers(context.Background(), "ns")
+	require.NoError(t, err)
+
+	var found []peer.ID
+	for p := range peerChan { // closed when all workers are done
+		found = append(found, p.ID)
+	}
+
+	require.ElementsMatch(t, []peer.ID{unknownA, unknownB, unknownC}, found)
+	require.LessOrEqual(t, maxActive, 2)
+	require.Positive(t, maxActive)
+}
+
+func TestBanPeer(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+	h, err := mn.GenPeer()
+	require.NoError(t, err)
+	p1, err := mn.GenPeer()
+	require.NoError(t, err)
+	p2, err := mn.GenPeer()
+	require.NoError(t, err)
+	require.NoError(t, mn.LinkAll())
+
+	addrBook := filepath.Join(t.TempDir(), "peers.json")
+	pm, err := NewPeerMan(false, addrBook, nil, h, nil, nil)
+	require.NoError(t, err)
+	h.Network().Notify(pm)
+	defer pm.close()
+
+	_, err = mn.ConnectPeers(h.ID(), p1.ID())
+	require.NoError(t, err)
+	require.Equal(t, network.Connected, h.Network().Connectedness(p1.ID()))
+
+	t.Run("ban disconnects", func(t *testing.T) {
+		require.NoError(t, pm.BanPeer(p1.ID(), time.Now().Add(time.Hour)))
+		require.True(t, pm.IsBanned(p1.ID()))
+		require.NotEqual(t, network.Connected, h.Network().Connectedness(p1.ID()))
+		require.Error(t, pm.BanPeer(h.ID(), time.Now().Add(time.Hour)))
+	})
+
+	t.Run("banned peer connection closed", func(t *testing.T) {
+		_, err = mn.ConnectPeers(p1.ID(), h.ID())
+		require.NoError(t, err)
+		require.Eventually(t, func() bool {
+			return h.Network().Connectedness(p1.ID()) != network.Connected
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("persisted", func(t *testing.T) {
+		h2, err := mn.GenPeer()
+		require.NoError(t, err)
+		pm2, err := NewPeerMan(false, addrBook, nil, h2, nil, nil)
+		require.NoError(t, err)
+		require.True(t, pm2.IsBanned(p1.ID()))
+		require.False(t, pm2.IsBanned(p2.ID()))
+	})
+
+	t.Run("unban", func(t *testing.T) {
+		require.NoError(t, pm.Unban(p1.ID()))
+		require.False(t, pm.IsBanned(p1.ID()))
+		_, err = mn.ConnectPeers(h.ID(), p1.ID())
+		require.NoError(t, err)
+		require.Equal(t, network.Connected, h.Network().Connectedness(p1.ID()))
+
+		loaded, err := loadPeers(addrBook)
+		require.NoError(t, err)
+		for _, pi := range loaded {
+			require.Zero(t, pi.BannedUntil)
+		}
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		require.NoError(t, pm.BanPeer(p2.ID(), time.Now().Add(50*time.Millisecond)))
+		require.True(t, pm.IsBanned(p2.ID()))
+		require.Zero(t, pm.clearExpiredBans(time.Now()))
+
+		time.Sleep(60 * time.Millisecond)
+		require.False(t, pm.IsBanned(p2.ID())) // expired, though not yet cleared
+		require.Equal(t, 1, pm.clearExpiredBans(time.Now()))
+		require.Empty(t, pm.bans)
+	})
+}
+
+func TestPeerManMetrics(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+	h, err := mn.GenPeer()
+	require.NoError(t, err)
+	p1, err := mn.GenPeer()
+	require.NoError(t, err)
+	p2, err := mn.GenPeer()
+	require.NoError(t, err)
+	require.NoError(t, mn.LinkAll())
+
+	pm, err := NewPeerMan(false, filepath.Join(t.TempDir(), "peers.json"), nil, h, nil, nil)
+	require.NoError(t, err)
+	h.Network().Notify(pm)
+	defer pm.close()
+
+	require.Equal(t, Metrics{}, pm.Metrics())
+
+	for _, p := range []peer.ID{p1.ID(), p2.ID()} {
+		h.Peerstore().AddAddrs(p, mn.Host(p).Addrs(), peerstore.PermanentAddrTTL)
+	}
+	_, err = mn.ConnectPeers(h.ID(), p1.ID())
+	require.NoError(t, err)
+	_, err = mn.ConnectPeers(h.ID(), p2.ID())
+	require.NoError(t, err)
+
+	m := pm.Metrics()
+	require.Equal(t, 2, m.ConnectedPeers)
+	require.Equal(t, 2, m.KnownPeers)
+	require.EqualValues(t, 2, m.Connects)
+	require.Zero(t, m.Disconnects)
+
+	// Unlink p1 so that any redial fails, then drop the connection.
+	require.NoError(t, mn.UnlinkPeers(h.ID(), p1.ID()))
+	require.NoError(t, h.Network().ClosePeer(p1.ID()))
+	require.Eventually(t, func() bool {
+		return pm.Metrics().Disconnects == 1
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, 1, pm.Metrics().ConnectedPeers)
+
+	// The first attempt fails right away, and the next is after a backoff.
+	pm.wg.Add(1)
+	go func() {
+		defer pm.wg.Done()
+		pm.reconnectWithRetry(p1.ID())
+	}()
+	require.Eventually(t, func() bool {
+		return pm.Metrics().FailedDials == 1
+	}, time.Second, 10*time.Millisecond)
+
+	m = pm.Metrics()
+	require.Equal(t, 1, m.ConnectedPeers)
+	require.Equal(t, 2, m.KnownPeers)
+	require.EqualValues(t, 2, m.Connects)
+	require.EqualValues(t, 1, m.Disconnects)
+	require.EqualValues(t, 1, m.ReconnectAttempts)
+	require.EqualValues(t, 1, m.FailedDials)
+	require.Zero(t, m.Evictions)
+}
+
+// syncBuffer is a bytes.Buffer that is safe for concurrent log writes.
+type syncBuffer struct {
+	mtx sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.Write(p)
+}
+
+// entries decodes the JSON log entries written so far.
+func (b *syncBuffer) entries(t *testing.T) []map[string]any {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	var entries []map[string]any
+	sc := bufio.NewScanner(bytes.NewReader(b.buf.Bytes()))
+	for sc.Scan() {
+		entry := map[string]any{}
+		require.NoError(t, json.Unmarshal(sc.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestReconnectLogFields(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+	h, err := mn.GenPeer()
+	require.NoError(t, err)
+	p1, err := mn.GenPeer()
+	require.NoError(t, err)
+	require.NoError(t, mn.LinkAll())
+
+	var logs syncBuffer
+	logger := log.New(log.WithWriter(&logs), log.WithFormat(log.FormatJSON))
+	pm, err := NewPeerMan(false, filepath.Join(t.TempDir(), "peers.json"), logger, h, nil, nil)
+	require.NoError(t, err)
+	defer pm.close()
+
+	h.Peerstore().AddAddrs(p1.ID(), p1.Addrs(), peerstore.PermanentAddrTTL)
+	pm.reconnectWithRetry(p1.ID())
+
+	find := func(msg string) map[string]any {
+		for _, entry := range logs.entries(t) {
+			if entry["msg"] == msg {
+				return entry
+			}
+		}
+		t.Fatalf("no %q log entry", msg)
+		return nil
+	}
+
+	attempt := find("Attempting reconnection to peer")
+	require.Equal(t, p1.ID().String(), attempt["peer"])
+	require.EqualValues(t, 1, attempt["attempt"])
+	require.EqualValues(t, maxRetries, attempt["max_attempts"])
+
+	success := find("Successfully reconnected to peer")
+	require.Equal(t, p1.ID().String(), success["peer"])
+	require.EqualValues(t, 1, success["attempt"])
+}
+
+func TestReconnectPerPeer(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+	h, err := mn.GenPeer()
+	require.NoError(t, err)
+	p1, err := mn.GenPeer()
+	require.NoError(t, err)
+	p2, err := mn.GenPeer()
+	require.NoError(t, err)
+	require.NoError(t, mn.LinkAll())
+
+	pm, err := NewPeerMan(false, filepath.Join(t.TempDir(), "peers.json"), nil, h, nil, nil)
+	require.NoError(t, err)
+	pm.maxReconnects = 1
+	h.Network().Notify(pm)
+
+	numReconnecting := func() int {
+		pm.mtx.Lock()
+		defer pm.mtx.Unlock()
+		return len(pm.reconnecting)
+	}
+
+	// Churn the connection to p1. Each disconnect would start a reconnect,
+	// but only the first does while it is still active.
+	const churn = 20
+	for i := range churn {
+		_, err = mn.ConnectPeers(h.ID(), p1.ID())
+		require.NoError(t, err)
+		require.NoError(t, h.Network().ClosePeer(p1.ID()))
+		require.Eventually(t, func() bool {
+			return pm.Metrics().Disconnects == uint64(i+1)
+		}, time.Second, time.Millisecond)
+		require.Equal(t, 1, numReconnecting())
+	}
+	pm.mtx.Lock()
+	require.Contains(t, pm.reconnecting, p1.ID())
+	pm.mtx.Unlock()
+
+	// The limit on concurrent reconnects is reached.
+	_, err = mn.ConnectPeers(h.ID(), p2.ID())
+	require.NoError(t, err)
+	require.NoError(t, h.Network().ClosePeer(p2.ID()))
+	require.Eventually(t, func() bool {
+		return pm.Metrics().Disconnects == churn+1
+	}, time.Second, time.Millisecond)
+	pm.mtx.Lock()
+	require.NotContains(t, pm.reconnecting, p2.ID())
+	pm.mtx.Unlock()
+
+	// Closing the PeerMan stops the reconnect without waiting for its delay.
+	stopped := make(chan struct{})
+	go func() {
+		pm.close()
+		pm.wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("reconnect did not stop when the peer manager was closed")
+	}
+	require.Zero(t, numReconnecting())
+}
+
+func TestAddPeer(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+	h, err := mn.GenPeer()
+	require.NoError(t, err)
+	p1, err := mn.GenPeer()
+	require.NoError(t, err)
+	p2, err := mn.GenPeer()
+	require.NoError(t, err)
+
+	addrBook := filepath.Join(t.TempDir(), "peers.json")
+	pm, err := NewPeerMan(false, addrBook, nil, h, nil, nil)
+	require.NoError(t, err)
+
+	added, err := pm.AddPeer(peer.AddrInfo{ID: p1.ID(), Addrs: p1.Addrs()})
+	require.NoError(t, err)
+	require.True(t, added)
+
+	// again is a no-op
+	added, err = pm.AddPeer(peer.AddrInfo{ID: p1.ID(), Addrs: p1.Addrs()})
+	require.NoError(t, err)
+	require.False(t, added)
+
+	// a bare peer ID requires known addresses
+	_, err = pm.AddPeer(peer.AddrInfo{ID: p2.ID()})
+	require.Error(t, err)
+	h.Peerstore().AddAddrs(p2.ID(), p2.Addrs(), peerstore.TempAddrTTL)
+	_, err = pm.AddPeer(peer.AddrInfo{ID: p2.ID()})
+	require.NoError(t, err)
+
+	loaded, err := loadPeers(addrBook)
+	require.NoError(t, err)
+	var ids []peer.ID
+	for _, pi := range loaded {
+		ids = append(ids, pi.ID)
+	}
+	require.ElementsMatch(t, []peer.ID{p1.ID(), p2.ID()}, ids)
+}
+
+func TestAddPeerKnownAddrs(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+	h, err := mn.GenPeer()
+	require.NoError(t, err)
+	p1, err := mn.GenPeer()
+	require.NoError(t, err)
+
+	addrBook := filepath.Join(t.TempDir(), "peers.json")
+	pm, err := NewPeerMan(false, addrBook, nil, h, nil, nil)
+	require.NoError(t, err)
+
+	// The peer is known with expiring addresses, e.g. from discovery.
+	const ttl = 200 * time.Millisecond
+	pm.addPeers([]PeerInfo{{AddrInfo: AddrInfo{ID: p1.ID(), Addrs: p1.Addrs()}}}, ttl)
+
+	added, err := pm.AddPeer(peer.AddrInfo{ID: p1.ID(), Addrs: p1.Addrs()})
+	require.NoError(t, err)
+	require.False(t, added)
+
+	time.Sleep(2 * ttl)
+	require.ElementsMatch(t, p1.Addrs(), h.Peerstore().Addrs(p1.ID()))
+
+	loaded, err := loadPeers(addrBook)
+	require.NoError(t, err)
+	require.Len(t, loaded, 1)
+	require.Equal(t, p1.ID(), loaded[0].ID)
+}
+
+// blockingConnector is a Connector that blocks until the dial's context is
+// done.
+type blockingConnector struct{}
+
+func (blockingConnector) Connect(ctx context.Context, _ peer.AddrInfo) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestPendingDials(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+	h, err := mn.GenPeer()
+	require.NoError(t, err)
+
+	pm, err := NewPeerMan(false, filepath.Join(t.TempDir(), "peers.json"), nil, h, nil, nil)
+	require.NoError(t, err)
+	pm.c = blockingConnector{}
+	defer pm.close()
+
+	pid1, _ := peer.Decode("16Uiu2HAm8iRUsTzYepLP8pdJL3645ACP7VBfZQ7yFbLfdb7WvkL7")
+	pid2, _ := peer.Decode("16Uiu2HAkx2kfP117VnYnaQGprgXBoMpjfxGXCpizju3cX7ZUzRhv")
+
+	waitDials := func(n int) []DialInfo {
+		var dials []DialInfo
+		require.Eventually(t, func() bool {
+			dials = pm.PendingDials()
+			return len(dials) == n
+		}, time.Second, 5*time.Millisecond)
+		return dials
+	}
+
+	require.Empty(t, pm.PendingDials())
+	require.False(t, pm.CancelDial(pid1))
+
+	t.Run("dial", func(t *testing.T) {
+		start := time.Now()
+		errs := make(chan error, 2)
+		go func() { errs <- pm.dial(context.Background(), peer.AddrInfo{ID: pid1}) }()
+		waitDials(1)
+		go func() { errs <- pm.dial(context.Background(), peer.AddrInfo{ID: pid2}) }()
+
+		dials := waitDials(2)
+		require.Equal(t, pid1, dials[0].ID) // oldest first
+		require.Equal(t, pid2, dials[1].ID)
+		require.False(t, dials[0].Started.Before(start))
+
+		require.True(t, pm.CancelDial(pid1))
+		require.ErrorIs(t, <-errs, ErrDialCanceled)
+		dials = waitDials(1)
+		require.Equal(t, pid2, dials[0].ID)
+
+		require.True(t, pm.CancelDial(pid2))
+		require.ErrorIs(t, <-errs, ErrDialCanceled)
+		waitDials(0)
+		require.False(t, pm.CancelDial(pid2))
+	})
+
+	t.Run("reconnect", func(t *testing.T) {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			pm.reconnectWithRetry(pid1)
+		}()
+		dials := waitDials(1)
+		require.Equal(t, pid1, dials[0].ID)
+
+		require.True(t, pm.CancelDial(pid1))
+		select {
+		case <-done: // not retried
+		case <-time.After(time.Second):
+			t.Fatal("reconnect was not stopped by canceling the dial")
+		}
+		require.Empty(t, pm.PendingDials())
+		require.EqualValues(t, 1, pm.Metrics().ReconnectAttempts)
+		require.Zero(t, pm.Metrics().FailedDials)
+	})
+}
+
+func TestKnownPeers(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+	h, err := mn.GenPeer()
+	require.NoError(t, err)
+	var others []peer.ID
+	for range 4 {
+		p, err := mn.GenPeer()
+		require.NoError(t, err)
+		others = append(others, p.ID())
+		h.Peerstore().AddAddrs(p.ID(), p.Addrs(), peerstore.PermanentAddrTTL)
+	}
+	require.NoError(t, mn.LinkAll())
+
+	pm, err := NewPeerMan(false, filepath.Join(t.TempDir(), "peers.json"), nil, h, nil, nil)
+	require.NoError(t, err)
+	defer pm.close()
+
+	_, err = mn.ConnectPeers(h.ID(), others[0])
+	require.NoError(t, err)
+	_, err = mn.ConnectPeers(h.ID(), others[2])
+	require.NoError(t, err)
+	require.Contains(t, h.Peerstore().Peers(), h.ID())
+
+	ids := func(peers []PeerInfo) []peer.ID {
+		var ids []peer.ID
+		for _, p := range peers {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+
+	all, connected, disconnected := pm.KnownPeers()
+	require.ElementsMatch(t, []peer.ID{others[0], others[2]}, ids(connected))
+	require.ElementsMatch(t, []peer.ID{others[1], others[3]}, ids(disconnected))
+	require.Equal(t, slices.Concat(connected, disconnected), all) // connected first
+
+	allIDs := ids(all)
+	require.NotContains(t, allIDs, h.ID())
+	slices.Sort(allIDs)
+	require.Len(t, slices.Compact(allIDs), len(others)) // no duplicates
+
+	for _, p := range connected {
+		require.False(t, p.LastSeen.IsZero())
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+	h, err := mn.GenPeer()
+	require.NoError(t, err)
+	other, err := mn.GenPeer()
+	require.NoError(t, err)
+	require.NoError(t, mn.LinkAll())
+
+	pm, err := NewPeerMan(false, filepath.Join(t.TempDir(), "peers.json"), nil, h, nil, nil)
+	require.NoError(t, err)
+	defer pm.close()
+	ctx := context.Background()
+
+	t.Run("connected peer", func(t *testing.T) {
+		oldConn, err := mn.ConnectPeers(h.ID(), other.ID())
+		require.NoError(t, err)
+		h.Peerstore().AddAddrs(other.ID(), other.Addrs(), peerstore.PermanentAddrTTL)
+
+		connectedness, err := pm.Reconnect(ctx, other.ID())
+		require.NoError(t, err)
+		require.Equal(t, network.Connected, connectedness)
+		require.True(t, oldConn.IsClosed())
+		conns := h.Network().ConnsToPeer(other.ID())
+		require.Len(t, conns, 1)
+		require.NotEqual(t, oldConn.ID(), conns[0].ID())
+		require.EqualValues(t, 1, pm.Metrics().ReconnectAttempts)
+	})
+
+	t.Run("unknown peer", func(t *testing.T) {
+		pid, _ := peer.Decode("16Uiu2HAm8iRUsTzYepLP8pdJL3645ACP7VBfZQ7yFbLfdb7WvkL7")
+		connectedness, err := pm.Reconnect(ctx, pid)
+		require.Error(t, err)
+		require.Equal(t, network.NotConnected, connectedness)
+		require.Empty(t, pm.PendingDials())
+	})
+
+	t.Run("self", func(t *testing.T) {
+		_, err := pm.Reconnect(ctx, h.ID())
+		require.Error(t, err)
+	})
+}
+
+func TestConnectedPeerAddrTTL(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+	h, err := mn.GenPeer()
+	require.NoError(t, err)
+	connected, err := mn.GenPeer()
+	require.NoError(t, err)
+	neverConnected, err := mn.GenPeer()
+	require.NoError(t, err)
+	require.NoError(t, mn.LinkAll())
+
+	pm, err := NewPeerMan(false, filepath.Join(t.TempDir(), "peers.json"), nil, h, nil, nil)
+	require.NoError(t, err)
+	pm.provisionalTTL = 200 * time.Millisecond
+	h.Network().Notify(pm)
+	defer pm.close()
+
+	// Addresses that the peers do not advertise, so they are not also added
+	// by the identify service when connected.
+	addr := ma.StringCast("/ip4/192.0.2.1/tcp/6600")
+	for _, p := range []peer.ID{connected.ID(), neverConnected.ID()} {
+		pm.addPeers([]PeerInfo{{AddrInfo: AddrInfo{ID: p, Addrs: []ma.Multiaddr{addr}}}}, pm.provisionalTTL)
+		require.Equal(t, []ma.Multiaddr{addr}, h.Peerstore().Addrs(p))
+	}
+
+	_, err = mn.ConnectPeers(h.ID(), connected.ID())
+	require.NoError(t, err)
+
+	time.Sleep(2 * pm.provisionalTTL)
+	require.Contains(t, h.Peerstore().Addrs(connected.ID()), addr)
+	require.Empty(t, h.Peerstore().Addrs(neverConnected.ID()))
+}
+
+func TestRemoveOldPeers(t *testing.T) {
+	mn := mock.New()
+	h, err := mn.GenPeer()
+	require.NoError(t, err)
+	old, err := mn.GenPeer()
+	require.NoError(t, err)
+	recent, err := mn.GenPeer()
+	require.NoError(t, err)
+
+	pm, err := NewPeerMan(false, filepath.Join(t.TempDir(), "peers.json"), nil, h, nil, nil)
+	require.NoError(t, err)
+	pm.SetDisconnectRetention(time.Hour)
+	pm.SetEvictionInterval(10 * time.Millisecond)
+
+	now := time.Now()
+	pm.mtx.Lock()
+	for pid, disconnected := range map[peer.ID]time.Time{
+		old.ID():    now.Add(-2 * time.Hour),
+		recent.ID(): now.Add(-time.Minute),
+	} {
+		h.Peerstore().AddAddrs(pid, mn.Host(pid).Addrs(), time.Hour)
+		pm.disconnects[pid] = disconnected
+	}
+	pm.mtx.Unlock()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		pm.removeOldPeers()
+	}()
+	defer func() {
+		pm.close()
+		wg.Wait()
+	}()
+
+	require.Eventually(t, func() bool {
+		return pm.Metrics().Evictions == 1
+	}, time.Second, 5*time.Millisecond)
+	time.Sleep(30 * time.Millisecond) // a few more scans
+
+	pm.mtx.Lock()
+	_, haveOld := pm.disconnects[old.ID()]
+	_, haveRecent := pm.disconnects[recent.ID()]
+	pm.mtx.Unlock()
+	require.False(t, haveOld)
+	require.True(t, haveRecent)
+	require.EqualValues(t, 1, pm.Metrics().Evictions)
+}
+
+// connMgrHost is a host with a connection manager, unlike a mocknet host.
+type connMgrHost struct {
+	host.Host
+	cm connmgr.ConnManager
+}
+
+func (h *connMgrHost) ConnManager() connmgr.ConnManager {
+	return h.cm
+}
+
+func TestSetProtectedPeers(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+	mh, err := mn.GenPeer()
+	require.NoError(t, err)
+	cm, err := p2pconnmgr.NewConnManager(1, 2)
+	require.NoError(t, err)
+	defer cm.Close()
+	h := &connMgrHost{Host: mh, cm: cm}
+
+	pm, err := NewPeerMan(false, filepath.Join(t.TempDir(), "peers.json"), nil, h, nil, nil)
+	require.NoError(t, err)
+	defer pm.close()
+
+	var ids []peer.ID
+	for range 3 {
+		p, err := mn.GenPeer()
+		require.NoError(t, err)
+		ids = append(ids, p.ID())
+	}
+
+	pm.SetProtectedPeers(ids[:2])
+	require.True(t, cm.IsProtected(ids[0], protectTag))
+	require.True(t, cm.IsProtected(ids[1], protectTag))
+	require.False(t, cm.IsProtected(ids[2], protectTag))
+
+	// Replacing the peers unprotects the ones no longer given.
+	pm.SetProtectedPeers(ids[1:])
+	require.False(t, cm.IsProtected(ids[0], protectTag))
+	require.True(t, cm.IsProtected(ids[1], protectTag))
+	require.True(t, cm.IsProtected(ids[2], protectTag))
+
+	// A banned peer is protected again only once it is unbanned.
+	require.NoError(t, pm.BanPeer(ids[1], time.Now().Add(time.Hour)))
+	require.False(t, cm.IsProtected(ids[1], protectTag))
+	pm.SetProtectedPeers(ids[1:])
+	require.False(t, cm.IsProtected(ids[1], protectTag))
+	require.NoError(t, pm.Unban(ids[1]))
+	require.True(t, cm.IsProtected(ids[1], protectTag))
+}
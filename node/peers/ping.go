@@ -0,0 +1,176 @@
+package peers
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// ProtocolIDPing is the protocol for probing the liveness and latency of a
+// connected peer. The requester sends a random nonce, which the peer echoes.
+const ProtocolIDPing protocol.ID = "/kwil/ping/1.0.0"
+
+const (
+	pingNonceLen = 8
+
+	// defaultPingTimeout is how long to wait for a peer to echo a ping.
+	defaultPingTimeout = 5 * time.Second
+
+	// pingInterval is the minimum time between pings of the connected peers
+	// by maintainMinPeers.
+	pingInterval = normalConnInterval
+
+	// maxPingFailures is the number of consecutive failed pings after which a
+	// peer's connection is considered dead and is closed.
+	maxPingFailures = 3
+
+	// unresponsiveTag is the connection manager tag for peers that have
+	// failed pings. Its value is lowered with each consecutive failure so
+	// that the connections of unresponsive peers are trimmed first.
+	unresponsiveTag   = "kwil-unresponsive"
+	pingFailurePoints = 10
+)
+
+// PingStreamHandler handles a ProtocolIDPing stream by echoing the nonce.
+func PingStreamHandler(s network.Stream) {
+	defer s.Close()
+
+	s.SetDeadline(time.Now().Add(defaultPingTimeout))
+
+	var nonce [pingNonceLen]byte
+	if _, err := io.ReadFull(s, nonce[:]); err != nil {
+		return
+	}
+	s.Write(nonce[:])
+}
+
+// Ping measures the round trip time to a connected peer with the ping
+// protocol, and records it in the peer store's latency metrics. The peer is
+// not dialed if it is not connected.
+func (pm *PeerMan) Ping(ctx context.Context, peerID peer.ID) (time.Duration, error) {
+	if pm.h.Network().Connectedness(peerID) != network.Connected {
+		return 0, fmt.Errorf("peer %v is not connected", peerID)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, pm.pingTimeout)
+	defer cancel()
+
+	s, err := pm.h.NewStream(network.WithNoDial(ctx, "ping"), peerID, ProtocolIDPing)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open ping stream: %w", err)
+	}
+	defer s.Close()
+
+	// Not all transports support deadlines, so also reset the stream if the
+	// context is done first.
+	deadline, _ := ctx.Deadline()
+	s.SetDeadline(deadline)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Reset()
+		case <-done:
+		}
+	}()
+
+	var nonce, echo [pingNonceLen]byte
+	rand.Read(nonce[:])
+
+	start := time.Now()
+	if _, err = s.Write(nonce[:]); err != nil {
+		return 0, fmt.Errorf("failed to send ping: %w", errors.Join(err, ctx.Err()))
+	}
+	if _, err = io.ReadFull(s, echo[:]); err != nil {
+		return 0, fmt.Errorf("no ping response: %w", errors.Join(err, ctx.Err()))
+	}
+	rtt := time.Since(start)
+	if echo != nonce {
+		return 0, errors.New("ping response does not match the nonce")
+	}
+
+	pm.ps.RecordLatency(peerID, rtt)
+	return rtt, nil
+}
+
+// pingPeers concurrently pings the connected peers that support the ping
+// protocol. A peer that fails maxPingFailures consecutive pings has its
+// connection closed, and each failure lowers the peer's value to the
+// connection manager. It returns the peers whose connections were closed.
+func (pm *PeerMan) pingPeers(ctx context.Context) []peer.ID {
+	var (
+		wg     sync.WaitGroup
+		mtx    sync.Mutex
+		closed []peer.ID
+	)
+	for _, peerID := range pm.h.Network().Peers() {
+		if peerID == pm.h.ID() {
+			continue
+		}
+		if ok, _ := CheckProtocolSupport(ctx, pm.ps, peerID, ProtocolIDPing); !ok {
+			continue // e.g. an older node
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if pm.pingPeer(ctx, peerID) {
+				mtx.Lock()
+				closed = append(closed, peerID)
+				mtx.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return closed
+}
+
+// pingPeer pings a peer and updates its consecutive failure count, closing
+// its connection if the count reaches maxPingFailures. It returns true if the
+// connection was closed.
+func (pm *PeerMan) pingPeer(ctx context.Context, peerID peer.ID) bool {
+	rtt, err := pm.Ping(ctx, peerID)
+
+	pm.mtx.Lock()
+	if err == nil {
+		delete(pm.pingFailures, peerID)
+		pm.mtx.Unlock()
+		pm.h.ConnManager().UntagPeer(peerID, unresponsiveTag)
+		pm.log.Debug("Pinged peer", "peer", peerID, "rtt", rtt)
+		return false
+	}
+	if ctx.Err() != nil { // shutting down, not the peer's fault
+		pm.mtx.Unlock()
+		return false
+	}
+	pm.pingFailures[peerID]++
+	failures := pm.pingFailures[peerID]
+	dead := failures >= maxPingFailures
+	if dead {
+		delete(pm.pingFailures, peerID)
+	}
+	pm.mtx.Unlock()
+
+	pm.log.Info("Failed to ping peer", "peer", peerID, "failures", failures, "error", err)
+
+	if !dead {
+		pm.h.ConnManager().TagPeer(peerID, unresponsiveTag, -pingFailurePoints*failures)
+		return false
+	}
+
+	pm.log.Warn("Closing unresponsive connection to peer", "peer", peerID)
+	pm.h.ConnManager().UntagPeer(peerID, unresponsiveTag)
+	if err := pm.h.Network().ClosePeer(peerID); err != nil {
+		pm.log.Warn("Failed to disconnect from peer", "peer", peerID, "error", err)
+	}
+	pm.numDeadConns.Add(1)
+	return true
+}
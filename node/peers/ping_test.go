@@ -0,0 +1,87 @@
+package peers
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	mock "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPing(t *testing.T) {
+	mn := mock.New()
+	defer mn.Close()
+	h, err := mn.GenPeer()
+	require.NoError(t, err)
+	responsive, err := mn.GenPeer()
+	require.NoError(t, err)
+	unresponsive, err := mn.GenPeer()
+	require.NoError(t, err)
+	require.NoError(t, mn.LinkAll())
+
+	responsive.SetStreamHandler(ProtocolIDPing, PingStreamHandler)
+	unresponsive.SetStreamHandler(ProtocolIDPing, func(s network.Stream) {
+		defer s.Close()
+		io.Copy(io.Discard, s) // never respond, until the stream is reset
+	})
+
+	pm, err := NewPeerMan(false, filepath.Join(t.TempDir(), "peers.json"), nil, h, nil, nil)
+	require.NoError(t, err)
+	pm.pingTimeout = 100 * time.Millisecond
+	defer pm.close()
+
+	for _, p := range []peer.ID{responsive.ID(), unresponsive.ID()} {
+		_, err = mn.ConnectPeers(h.ID(), p)
+		require.NoError(t, err)
+		require.NoError(t, h.Peerstore().AddProtocols(p, ProtocolIDPing))
+	}
+	ctx := context.Background()
+
+	t.Run("responsive", func(t *testing.T) {
+		rtt, err := pm.Ping(ctx, responsive.ID())
+		require.NoError(t, err)
+		require.Positive(t, rtt)
+		require.Positive(t, h.Peerstore().LatencyEWMA(responsive.ID()))
+	})
+
+	t.Run("unresponsive", func(t *testing.T) {
+		_, err := pm.Ping(ctx, unresponsive.ID())
+		require.Error(t, err)
+		require.Zero(t, h.Peerstore().LatencyEWMA(unresponsive.ID()))
+	})
+
+	t.Run("not connected", func(t *testing.T) {
+		other, err := mn.GenPeer()
+		require.NoError(t, err)
+		require.NoError(t, mn.LinkAll())
+		_, err = pm.Ping(ctx, other.ID())
+		require.Error(t, err)
+		require.NotEqual(t, network.Connected, h.Network().Connectedness(other.ID()))
+	})
+
+	t.Run("dead connection closed", func(t *testing.T) {
+		for i := 1; i < maxPingFailures; i++ {
+			require.Empty(t, pm.pingPeers(ctx))
+			require.Equal(t, network.Connected, h.Network().Connectedness(unresponsive.ID()))
+		}
+		require.Equal(t, []peer.ID{unresponsive.ID()}, pm.pingPeers(ctx))
+		require.NotEqual(t, network.Connected, h.Network().Connectedness(unresponsive.ID()))
+		require.Equal(t, network.Connected, h.Network().Connectedness(responsive.ID()))
+		require.EqualValues(t, 1, pm.Metrics().DeadConnections)
+	})
+
+	t.Run("failures reset by a response", func(t *testing.T) {
+		pm.mtx.Lock()
+		pm.pingFailures[responsive.ID()] = maxPingFailures - 1
+		pm.mtx.Unlock()
+		require.Empty(t, pm.pingPeers(ctx))
+		pm.mtx.Lock()
+		defer pm.mtx.Unlock()
+		require.NotContains(t, pm.pingFailures, responsive.ID())
+	})
+}
@@ -2,6 +2,7 @@ package peers
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/protocol"
@@ -13,9 +14,35 @@ type AddrInfo struct {
 	Addrs []multiaddr.Multiaddr `json:"addrs"`
 }
 
+// Metrics is a snapshot of the PeerMan's peer counts and connection event
+// counters. The counters are totals since the PeerMan was created.
+type Metrics struct {
+	ConnectedPeers    int
+	KnownPeers        int
+	Connects          uint64
+	Disconnects       uint64
+	ReconnectAttempts uint64
+	FailedDials       uint64 // from reconnect attempts and maintaining the target connections
+	Evictions         uint64 // stale peers removed from the peer store
+	DeadConnections   uint64 // connections closed after repeated failed pings
+}
+
+// DialInfo describes an outbound dial to a peer that is in progress.
+type DialInfo struct {
+	ID      peer.ID
+	Started time.Time
+}
+
 type PeerInfo struct {
 	AddrInfo
 	Protos []protocol.ID `json:"protos"`
+	// LastSeen is when the peer was last known to be connected. It is the zero
+	// time if the peer has not been seen. It is serialized with second
+	// precision.
+	LastSeen time.Time `json:"last_seen"`
+	// BannedUntil is when a ban on the peer expires. It is the zero time if
+	// the peer is not banned. It is serialized with second precision.
+	BannedUntil time.Time `json:"banned_until"`
 }
 
 func (p PeerInfo) MarshalJSON() ([]byte, error) {
@@ -27,22 +54,35 @@ func (p PeerInfo) MarshalJSON() ([]byte, error) {
 	for _, proto := range p.Protos {
 		protoStrs = append(protoStrs, string(proto))
 	}
+	var lastSeen, bannedUntil int64
+	if !p.LastSeen.IsZero() {
+		lastSeen = p.LastSeen.Unix()
+	}
+	if !p.BannedUntil.IsZero() {
+		bannedUntil = p.BannedUntil.Unix()
+	}
 	return json.Marshal(struct {
-		ID     string   `json:"id"`
-		Addrs  []string `json:"addrs"`
-		Protos []string `json:"protos"`
+		ID          string   `json:"id"`
+		Addrs       []string `json:"addrs"`
+		Protos      []string `json:"protos"`
+		LastSeen    int64    `json:"last_seen,omitempty"`
+		BannedUntil int64    `json:"banned_until,omitempty"`
 	}{
-		ID:     p.ID.String(),
-		Addrs:  addrStrs,
-		Protos: protoStrs,
+		ID:          p.ID.String(),
+		Addrs:       addrStrs,
+		Protos:      protoStrs,
+		LastSeen:    lastSeen,
+		BannedUntil: bannedUntil,
 	})
 }
 
 func (p *PeerInfo) UnmarshalJSON(data []byte) error {
 	aux := struct {
-		ID     string   `json:"id"`
-		Addrs  []string `json:"addrs"`
-		Protos []string `json:"protos"`
+		ID          string   `json:"id"`
+		Addrs       []string `json:"addrs"`
+		Protos      []string `json:"protos"`
+		LastSeen    int64    `json:"last_seen"`
+		BannedUntil int64    `json:"banned_until"`
 	}{}
 	if err := json.Unmarshal(data, &aux); err != nil {
 		return err
@@ -62,5 +102,11 @@ func (p *PeerInfo) UnmarshalJSON(data []byte) error {
 	for _, protoStr := range aux.Protos {
 		p.Protos = append(p.Protos, protocol.ID(protoStr))
 	}
+	if aux.LastSeen != 0 {
+		p.LastSeen = time.Unix(aux.LastSeen, 0)
+	}
+	if aux.BannedUntil != 0 {
+		p.BannedUntil = time.Unix(aux.BannedUntil, 0)
+	}
 	return nil
 }
@@ -2,6 +2,7 @@ package node
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding"
 	"encoding/binary"
@@ -9,11 +10,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"time"
 
 	"github.com/kwilteam/kwil-db/node/types"
 
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/protocol"
 )
@@ -23,6 +26,7 @@ const (
 	ProtocolIDTx          protocol.ID = "/kwil/tx/1.0.0"
 	ProtocolIDTxAnn       protocol.ID = "/kwil/txann/1.0.0"
 	ProtocolIDBlockHeight protocol.ID = "/kwil/blkheight/1.0.0"
+	ProtocolIDBlockRange  protocol.ID = "/kwil/blkrange/1.0.0"
 	ProtocolIDBlock       protocol.ID = "/kwil/blk/1.0.0"
 	ProtocolIDBlkAnn      protocol.ID = "/kwil/blkann/1.0.0"
 	// ProtocolIDBlockHeader protocol.ID = "/kwil/blkhdr/1.0.0"
@@ -30,6 +34,19 @@ const (
 	ProtocolIDBlockPropose protocol.ID = "/kwil/blkprop/1.0.0"
 	// ProtocolIDACKProposal  protocol.ID = "/kwil/blkack/1.0.0"
 
+	// The typed versions of the advertisement protocols prefix the
+	// advertisement with an annHeader. They are preferred by advertiseToPeer,
+	// which falls back to the original protocols for older peers.
+	ProtocolIDBlkAnnTyped       protocol.ID = "/kwil/blkann/1.1.0"
+	ProtocolIDBlockProposeTyped protocol.ID = "/kwil/blkprop/1.1.0"
+
+	// The gzip versions of the block request protocols have a gzip compressed
+	// response. They are preferred by requestFrom, which falls back to the
+	// original protocols for older peers.
+	ProtocolIDBlockGzip       protocol.ID = ProtocolIDBlock + "+gzip"
+	ProtocolIDBlockHeightGzip protocol.ID = ProtocolIDBlockHeight + "+gzip"
+	ProtocolIDBlockRangeGzip  protocol.ID = ProtocolIDBlockRange + "+gzip"
+
 	ProtocolIDSnapshotCatalog protocol.ID = "/kwil/snapcat/1.0.0"
 	ProtocolIDSnapshotChunk   protocol.ID = "/kwil/snapchunk/1.0.0"
 	ProtocolIDSnapshotMeta    protocol.ID = "/kwil/snapmeta/1.0.0"
@@ -39,9 +56,15 @@ const (
 	discoverPeersMsg = "discover_peers" // ProtocolIDDiscover
 )
 
+// requestFrom requests a resource from a peer. The stream deadline is the
+// context's deadline, or timeout from now if it has none.
 func requestFrom(ctx context.Context, host host.Host, peer peer.ID, resID []byte,
-	proto protocol.ID, readLimit int64) ([]byte, error) {
-	txStream, err := host.NewStream(ctx, peer, proto)
+	proto protocol.ID, readLimit int64, timeout time.Duration) ([]byte, error) {
+	protos := []protocol.ID{proto}
+	if compressed, ok := compressedProtocols[proto]; ok {
+		protos = []protocol.ID{compressed, proto}
+	}
+	txStream, err := host.NewStream(ctx, peer, protos...)
 	if err != nil {
 		return nil, err
 	}
@@ -49,12 +72,58 @@ func requestFrom(ctx context.Context, host host.Host, peer peer.ID, resID []byte
 
 	deadline, ok := ctx.Deadline()
 	if !ok {
-		deadline = time.Now().Add(txGetTimeout)
+		deadline = time.Now().Add(timeout)
 	}
 
 	txStream.SetDeadline(deadline)
 
-	return request(txStream, resID, readLimit)
+	resp, err := request(txStream, resID, readLimit)
+	if err != nil || !isCompressedProtocol(txStream.Protocol()) {
+		return resp, err
+	}
+	return decompressResp(resp, readLimit)
+}
+
+// requestFromPeers requests a resource from each of the peers in turn until one
+// provides it, and returns the response and the peer that sent it. A peer is
+// skipped if it does not have the resource, if the request fails, or if the
+// optional check function rejects its response. If no peer provides the
+// resource, the error is ErrNotFound, or the context's error if it is done
+// before every peer is tried. If any peer rejected the request because of its
+// rate limit, the error is ErrPeerBusy instead of ErrNotFound, since the
+// resource may exist.
+func (n *Node) requestFromPeers(ctx context.Context, peers []peer.ID, resID []byte,
+	proto protocol.ID, readLimit int64, check func(resp []byte) error) ([]byte, peer.ID, error) {
+	var busy bool
+	for _, peer := range peers {
+		if err := ctx.Err(); err != nil {
+			return nil, "", err
+		}
+		resp, err := requestFrom(ctx, n.host, peer, resID, proto, readLimit, n.timeouts.TxGet)
+		if err == nil && check != nil {
+			err = check(resp)
+		}
+		switch {
+		case err == nil:
+			return resp, peer, nil
+		case errors.Is(err, ErrNotFound):
+			n.log.Debug("resource not available", "peer", peer, "protocol", proto)
+		case errors.Is(err, ErrNoResponse):
+			n.log.Info("no response to resource request", "peer", peer, "protocol", proto)
+		case errors.Is(err, ErrPeerBusy):
+			n.log.Info("peer rate limited resource request", "peer", peer, "protocol", proto)
+			busy = true
+		default:
+			n.log.Warn("resource request failed", "peer", peer, "protocol", proto, "error", err)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+	if busy {
+		return nil, "", ErrPeerBusy
+	}
+	return nil, "", ErrNotFound
 }
 
 func request(rw io.ReadWriter, reqMsg []byte, readLimit int64) ([]byte, error) {
@@ -70,8 +139,61 @@ func request(rw io.ReadWriter, reqMsg []byte, readLimit int64) ([]byte, error) {
 	return rawTx, nil
 }
 
+// noData is the response to a resource request for a resource that the peer
+// does not have. A peer that rejects a request because of its rate limit
+// responds with busyData instead, so that the requester does not conclude that
+// the resource does not exist.
 var noData = []byte{0}
 
+// compressedProtocols maps the resource request protocols to their versions
+// with a gzip compressed response. Only the content of a response is
+// compressed, not the noData or busyData responses. Snapshot chunks are not
+// compressed since they are already pieces of a gzipped snapshot.
+var compressedProtocols = map[protocol.ID]protocol.ID{
+	ProtocolIDBlock:       ProtocolIDBlockGzip,
+	ProtocolIDBlockHeight: ProtocolIDBlockHeightGzip,
+	ProtocolIDBlockRange:  ProtocolIDBlockRangeGzip,
+}
+
+func isCompressedProtocol(proto protocol.ID) bool {
+	switch proto {
+	case ProtocolIDBlockGzip, ProtocolIDBlockHeightGzip, ProtocolIDBlockRangeGzip:
+		return true
+	}
+	return false
+}
+
+// respWriter returns the writer for the content of a response to a resource
+// request, which compresses it if the stream's protocol is a compressed one.
+// The returned function flushes the compressed content, and must be called
+// after writing it. Nothing is written to the stream until the content is, so
+// noData may still be written directly to the stream instead.
+func respWriter(s network.Stream) (io.Writer, func() error) {
+	if !isCompressedProtocol(s.Protocol()) {
+		return s, func() error { return nil }
+	}
+	zw := gzip.NewWriter(s)
+	return zw, zw.Close
+}
+
+// decompressResp decompresses the response to a request on a compressed
+// protocol. Like the compressed response, the decompressed content is limited
+// to limit bytes.
+func decompressResp(resp []byte, limit int64) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(resp))
+	if err != nil {
+		return nil, fmt.Errorf("invalid compressed response: %w", err)
+	}
+	content, err := io.ReadAll(io.LimitReader(zr, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("invalid compressed response: %w", err)
+	}
+	if int64(len(content)) > limit {
+		return nil, fmt.Errorf("decompressed response exceeds %d bytes", limit)
+	}
+	return content, nil
+}
+
 // readResp reads a response of unknown length until an EOF is reached when
 // reading. As such, this is the end of a protocol.
 func readResp(rd io.Reader, limit int64) ([]byte, error) {
@@ -86,9 +208,13 @@ func readResp(rd io.Reader, limit int64) ([]byte, error) {
 	if bytes.Equal(resp, noData) {
 		return nil, ErrNotFound
 	}
+	if bytes.Equal(resp, busyData) {
+		return nil, ErrPeerBusy
+	}
 	return resp, nil
 }
 
+// The default p2p protocol timeouts. See ProtocolTimeouts.
 const (
 	// annWriteTimeout the content announcement write timeout when sending
 	// the resource identifier, which is very small.
@@ -103,9 +229,25 @@ const (
 	annRespTimeout = 5 * time.Second
 )
 
+// typedAnnProtocols maps the advertisement protocols to their typed versions.
+var typedAnnProtocols = map[protocol.ID]protocol.ID{
+	ProtocolIDBlkAnn:       ProtocolIDBlkAnnTyped,
+	ProtocolIDBlockPropose: ProtocolIDBlockProposeTyped,
+}
+
+func isTypedAnnProtocol(proto protocol.ID) bool {
+	return proto == ProtocolIDBlkAnnTyped || proto == ProtocolIDBlockProposeTyped
+}
+
+// The content types of advertisements, as sent in an annHeader.
+const (
+	annTypeBlock         = "block"
+	annTypeBlockProposal = "block_proposal"
+)
+
 type contentAnn struct {
-	cType   string
-	ann     []byte // may be cType if self-describing
+	cType   string // one of the annType constants
+	ann     []byte // the content ID, e.g. a marshalled blockAnnMsg
 	content []byte
 }
 
@@ -114,35 +256,46 @@ func (ca contentAnn) String() string {
 }
 
 // advertiseToPeer sends a lightweight advertisement to a connected peer.
-// The stream remains open in case the peer wants to request the content .
+// The stream remains open in case the peer wants to request the content. If
+// the peer supports the typed version of the protocol, the advertisement is
+// prefixed with an annHeader.
 func (n *Node) advertiseToPeer(ctx context.Context, peerID peer.ID, proto protocol.ID,
 	ann contentAnn, contentWriteTimeout time.Duration) error {
-	s, err := n.host.NewStream(ctx, peerID, proto)
+	protos := []protocol.ID{proto}
+	if typed, ok := typedAnnProtocols[proto]; ok {
+		protos = []protocol.ID{typed, proto}
+	}
+	s, err := n.host.NewStream(ctx, peerID, protos...)
 	if err != nil {
 		return fmt.Errorf("failed to open stream to peer: %w", err)
 	}
 
-	s.SetWriteDeadline(time.Now().Add(annWriteTimeout))
+	s.SetWriteDeadline(time.Now().Add(n.timeouts.AnnWrite))
 
 	// Send a lightweight advertisement with the object ID
-	_, err = s.Write(ann.ann)
+	if isTypedAnnProtocol(s.Protocol()) {
+		_, err = annHeader{ContentType: ann.cType, ID: ann.ann}.WriteTo(s)
+	} else {
+		_, err = s.Write(ann.ann)
+	}
 	if err != nil {
-		return fmt.Errorf("send content ID failed: %w", err) // TODO: close stream?
+		s.Reset()
+		return fmt.Errorf("send content ID failed: %w", err)
 	}
 
 	// Keep the stream open for potential content requests
 	go func() {
 		defer s.Close()
 
-		s.SetReadDeadline(time.Now().Add(annRespTimeout))
+		s.SetReadDeadline(time.Now().Add(n.timeouts.AnnResp))
 
 		req := make([]byte, len(getMsg))
-		nr, err := s.Read(req)
-		if err != nil && !errors.Is(err, io.EOF) {
-			n.log.Warn("bad advertise response", "error", err)
+		nr, err := io.ReadFull(s, req)
+		if nr == 0 && errors.Is(err, io.EOF) { // they didn't want it
 			return
 		}
-		if nr == 0 { // they didn't want it
+		if err != nil {
+			n.log.Warn("bad advertise response", "error", err)
 			return
 		}
 		if getMsg != string(req) {
@@ -156,12 +309,173 @@ func (n *Node) advertiseToPeer(ctx context.Context, peerID peer.ID, proto protoc
 	return nil
 }
 
+// errUnwantedAnn is returned by readAnn for an advertisement of an
+// unexpected content type.
+var errUnwantedAnn = errors.New("unwanted advertisement content type")
+
+// readAnn reads an advertisement's content ID from a stream into id. On a
+// typed advertisement protocol, the annHeader is read first, and
+// errUnwantedAnn is returned without decoding the ID if the content type is
+// not cType.
+func readAnn(s network.Stream, cType string, id io.ReaderFrom) error {
+	if !isTypedAnnProtocol(s.Protocol()) {
+		_, err := id.ReadFrom(s)
+		return err
+	}
+
+	var hdr annHeader
+	if _, err := hdr.ReadFrom(s); err != nil {
+		return err
+	}
+	if hdr.ContentType != cType {
+		return fmt.Errorf("%w: %q", errUnwantedAnn, hdr.ContentType)
+	}
+	rd := bytes.NewReader(hdr.ID)
+	if _, err := id.ReadFrom(rd); err != nil {
+		return err
+	}
+	if rd.Len() != 0 {
+		return errors.New("unexpected data after advertisement ID")
+	}
+	return nil
+}
+
+// annHeader is the self-describing prefix of an advertisement on the typed
+// advertisement protocols, which lets the receiver decide from the content
+// type whether it wants the content before decoding the ID or requesting the
+// content. It is encoded as the content type with a uint8 length prefix,
+// followed by the ID with a uint16 little endian length prefix.
+type annHeader struct {
+	ContentType string
+	ID          []byte
+}
+
+const annIDLimit = math.MaxUint16
+
+var _ encoding.BinaryMarshaler = annHeader{}
+
+func (h annHeader) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	_, err := h.WriteTo(&buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var _ encoding.BinaryUnmarshaler = (*annHeader)(nil)
+
+func (h *annHeader) UnmarshalBinary(data []byte) error {
+	rd := bytes.NewReader(data)
+	if _, err := h.ReadFrom(rd); err != nil {
+		return err
+	}
+	if rd.Len() != 0 {
+		return errors.New("unexpected data after advertisement header")
+	}
+	return nil
+}
+
+var _ io.WriterTo = annHeader{}
+
+func (h annHeader) WriteTo(w io.Writer) (int64, error) {
+	if len(h.ContentType) > math.MaxUint8 {
+		return 0, errors.New("advertisement content type too long")
+	}
+	if len(h.ID) > annIDLimit {
+		return 0, errors.New("advertisement ID too long")
+	}
+	// Write the header with a single call so it is not split into several
+	// packets on the stream.
+	buf := make([]byte, 0, 1+len(h.ContentType)+2+len(h.ID))
+	buf = append(buf, uint8(len(h.ContentType)))
+	buf = append(buf, h.ContentType...)
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(len(h.ID)))
+	buf = append(buf, h.ID...)
+	nw, err := w.Write(buf)
+	return int64(nw), err
+}
+
+var _ io.ReaderFrom = (*annHeader)(nil)
+
+func (h *annHeader) ReadFrom(r io.Reader) (int64, error) {
+	var n int64
+	var u8 [1]byte
+	nr, err := io.ReadFull(r, u8[:])
+	n += int64(nr)
+	if err != nil {
+		return n, err
+	}
+	cType := make([]byte, u8[0])
+	nr, err = io.ReadFull(r, cType)
+	n += int64(nr)
+	if err != nil {
+		return n, err
+	}
+	h.ContentType = string(cType)
+	var u16 [2]byte
+	nr, err = io.ReadFull(r, u16[:])
+	n += int64(nr)
+	if err != nil {
+		return n, err
+	}
+	h.ID = make([]byte, binary.LittleEndian.Uint16(u16[:]))
+	nr, err = io.ReadFull(r, h.ID)
+	n += int64(nr)
+	return n, err
+}
+
+// DefaultMaxLeaderSigLen is the default maximum length of the leader's
+// signature in block proposal and announcement messages. The signatures of the
+// supported leader key types are much shorter, 65 bytes for secp256k1 and 64
+// bytes for ed25519, leaving room for larger schemes such as aggregates. See
+// WithMaxLeaderSigLen.
+const DefaultMaxLeaderSigLen = 1000
+
+// ErrLeaderSigTooLong is returned when a block proposal or announcement has a
+// leader signature longer than the maximum length.
+var ErrLeaderSigTooLong = errors.New("leader signature too long")
+
+// checkLeaderSigLen returns ErrLeaderSigTooLong if a leader signature of the
+// given length exceeds maxLen, or DefaultMaxLeaderSigLen if maxLen is zero.
+func checkLeaderSigLen(sigLen uint64, maxLen int) error {
+	if maxLen <= 0 {
+		maxLen = DefaultMaxLeaderSigLen
+	}
+	if sigLen > uint64(maxLen) {
+		return fmt.Errorf("%w: %d bytes, maximum is %d", ErrLeaderSigTooLong, sigLen, maxLen)
+	}
+	return nil
+}
+
+// readLeaderSig reads a leader signature prefixed by its length as a little
+// endian uint64. The length is checked against maxLen before the signature is
+// allocated. The returned count includes the bytes of a partially read length
+// or signature.
+func readLeaderSig(r io.Reader, maxLen int) ([]byte, int64, error) {
+	var lenBts [8]byte
+	nr, err := io.ReadFull(r, lenBts[:])
+	n := int64(nr)
+	if err != nil {
+		return nil, n, err
+	}
+	sigLen := binary.LittleEndian.Uint64(lenBts[:])
+	if err := checkLeaderSigLen(sigLen, maxLen); err != nil {
+		return nil, n, err
+	}
+	sig := make([]byte, sigLen)
+	nr, err = io.ReadFull(r, sig)
+	return sig, n + int64(nr), err
+}
+
 // blockAnnMsg is for ProtocolIDBlkAnn "/kwil/blkann/1.0.0"
 type blockAnnMsg struct {
 	Hash      types.Hash
 	Height    int64
 	AppHash   types.Hash // could be in the content/response
 	LeaderSig []byte     // to avoid having to get the block to realize if it is fake (spam)
+
+	maxSigLen int // of LeaderSig, DefaultMaxLeaderSigLen if zero
 }
 
 var _ encoding.BinaryMarshaler = blockAnnMsg{}
@@ -186,6 +500,10 @@ func (m *blockAnnMsg) UnmarshalBinary(data []byte) error {
 var _ io.WriterTo = (*blockAnnMsg)(nil)
 
 func (m *blockAnnMsg) WriteTo(w io.Writer) (int64, error) {
+	if err := checkLeaderSigLen(uint64(len(m.LeaderSig)), m.maxSigLen); err != nil {
+		return 0, err
+	}
+
 	var n int
 	nw, err := w.Write(m.Hash[:])
 	if err != nil {
@@ -226,32 +544,32 @@ func (m *blockAnnMsg) WriteTo(w io.Writer) (int64, error) {
 var _ io.ReaderFrom = (*blockAnnMsg)(nil)
 
 func (m *blockAnnMsg) ReadFrom(r io.Reader) (int64, error) {
+	// Each field is read with io.ReadFull so that the returned count includes
+	// the bytes of a partially read field.
+	var n int64
 	nr, err := io.ReadFull(r, m.Hash[:])
+	n += int64(nr)
 	if err != nil {
-		return int64(nr), err
-	}
-	n := int64(nr)
-	if err := binary.Read(r, binary.LittleEndian, &m.Height); err != nil {
 		return n, err
 	}
-	n += 8
-	if nr, err := io.ReadFull(r, m.AppHash[:]); err != nil {
-		return n + int64(nr), err
-	}
+	var u64 [8]byte
+	nr, err = io.ReadFull(r, u64[:])
 	n += int64(nr)
-	var sigLen uint64
-	if err := binary.Read(r, binary.LittleEndian, &sigLen); err != nil {
+	if err != nil {
 		return n, err
 	}
-	n += 8
-	if sigLen > 1000 {
-		return n, errors.New("unexpected leader sig length")
+	m.Height = int64(binary.LittleEndian.Uint64(u64[:]))
+	nr, err = io.ReadFull(r, m.AppHash[:])
+	n += int64(nr)
+	if err != nil {
+		return n, err
 	}
-	m.LeaderSig = make([]byte, sigLen)
-	if nr, err := io.ReadFull(r, m.LeaderSig); err != nil {
-		return n + int64(nr), err
+	sig, nSig, err := readLeaderSig(r, m.maxSigLen)
+	n += nSig
+	if err != nil {
+		return n, err
 	}
-	n += int64(nr)
+	m.LeaderSig = sig
 	return n, nil
 }
 
@@ -295,6 +613,91 @@ func (r *blockHeightReq) ReadFrom(rd io.Reader) (int64, error) {
 	return int64(n), err
 }
 
+// blockRangeReq is for ProtocolIDBlockRange "/kwil/blkrange/1.0.0"
+type blockRangeReq struct {
+	Start int64
+	Count uint32
+}
+
+var _ encoding.BinaryMarshaler = blockRangeReq{}
+var _ encoding.BinaryMarshaler = (*blockRangeReq)(nil)
+
+func (r blockRangeReq) MarshalBinary() ([]byte, error) {
+	bts := binary.LittleEndian.AppendUint64(nil, uint64(r.Start))
+	return binary.LittleEndian.AppendUint32(bts, r.Count), nil
+}
+
+func (r *blockRangeReq) UnmarshalBinary(data []byte) error {
+	if len(data) != 12 {
+		return errors.New("unexpected data length")
+	}
+	r.Start = int64(binary.LittleEndian.Uint64(data))
+	r.Count = binary.LittleEndian.Uint32(data[8:])
+	return nil
+}
+
+var _ io.WriterTo = (*blockRangeReq)(nil)
+
+func (r blockRangeReq) WriteTo(w io.Writer) (int64, error) {
+	bts, _ := r.MarshalBinary()
+	n, err := w.Write(bts)
+	return int64(n), err
+}
+
+var _ io.ReaderFrom = (*blockRangeReq)(nil)
+
+func (r *blockRangeReq) ReadFrom(rd io.Reader) (int64, error) {
+	bts := make([]byte, 12)
+	n, err := io.ReadFull(rd, bts)
+	if err != nil {
+		return int64(n), err
+	}
+	return int64(n), r.UnmarshalBinary(bts)
+}
+
+// rangeBlk is one of the blocks in a ProtocolIDBlockRange response, which is
+// a sequence of these, each encoded as the block hash, the app hash, and the
+// raw block with a uint32 little endian length prefix.
+type rangeBlk struct {
+	Hash    types.Hash
+	AppHash types.Hash
+	Raw     []byte
+}
+
+const rangeBlkOverhead = 2*types.HashLen + 4
+
+func (b rangeBlk) WriteTo(w io.Writer) (int64, error) {
+	buf := make([]byte, 0, rangeBlkOverhead+len(b.Raw))
+	buf = append(buf, b.Hash[:]...)
+	buf = append(buf, b.AppHash[:]...)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(b.Raw)))
+	buf = append(buf, b.Raw...)
+	n, err := w.Write(buf)
+	return int64(n), err
+}
+
+// decodeBlkRange decodes a ProtocolIDBlockRange response.
+func decodeBlkRange(resp []byte) ([]rangeBlk, error) {
+	var blks []rangeBlk
+	for len(resp) > 0 {
+		if len(resp) < rangeBlkOverhead {
+			return nil, errors.New("block range response too short")
+		}
+		var blk rangeBlk
+		copy(blk.Hash[:], resp)
+		copy(blk.AppHash[:], resp[types.HashLen:])
+		size := binary.LittleEndian.Uint32(resp[2*types.HashLen:])
+		resp = resp[rangeBlkOverhead:]
+		if uint64(size) > uint64(len(resp)) {
+			return nil, errors.New("block range response truncated")
+		}
+		blk.Raw = resp[:size]
+		resp = resp[size:]
+		blks = append(blks, blk)
+	}
+	return blks, nil
+}
+
 // blockHashReq is for ProtocolIDBlock "/kwil/blk/1.0.0"
 type blockHashReq struct {
 	Hash types.Hash
@@ -362,18 +765,19 @@ func (r snapshotChunkReq) MarshalBinary() ([]byte, error) {
 	buf := make([]byte, 8+4+4+types.HashLen)
 	binary.LittleEndian.PutUint64(buf[:8], r.Height)
 	binary.LittleEndian.PutUint32(buf[8:12], r.Format)
-	binary.LittleEndian.PutUint32(buf[8:12], r.Index)
-	copy(buf[12:], r.Hash[:])
+	binary.LittleEndian.PutUint32(buf[12:16], r.Index)
+	copy(buf[16:], r.Hash[:])
 	return buf, nil
 }
 
 func (r *snapshotChunkReq) UnmarshalBinary(data []byte) error {
-	if len(data) != 8+4+types.HashLen {
+	if len(data) != 8+4+4+types.HashLen {
 		return errors.New("unexpected data length")
 	}
 	r.Height = binary.LittleEndian.Uint64(data[:8])
-	r.Index = binary.LittleEndian.Uint32(data[8:12])
-	copy(r.Hash[:], data[12:])
+	r.Format = binary.LittleEndian.Uint32(data[8:12])
+	r.Index = binary.LittleEndian.Uint32(data[12:16])
+	copy(r.Hash[:], data[16:])
 	return nil
 }
 
@@ -394,6 +798,11 @@ func (r *snapshotChunkReq) ReadFrom(rd io.Reader) (int64, error) {
 	}
 	nr += 8
 
+	if err := binary.Read(rd, binary.LittleEndian, &r.Format); err != nil {
+		return int64(nr), err
+	}
+	nr += 4
+
 	if err := binary.Read(rd, binary.LittleEndian, &r.Index); err != nil {
 		return int64(nr), err
 	}
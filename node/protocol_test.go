@@ -2,8 +2,11 @@ package node
 
 import (
 	"bytes"
+	"encoding/binary"
 	"errors"
+	"io"
 	"math"
+	"strings"
 	"testing"
 
 	"github.com/kwilteam/kwil-db/node/types"
@@ -92,6 +95,54 @@ func TestBlockAnnMsg_UnmarshalInvalidData(t *testing.T) {
 		})
 	}
 }
+
+func TestBlockAnnMsg_ReadFromTruncated(t *testing.T) {
+	msg := &blockAnnMsg{
+		Height:    100,
+		Hash:      [32]byte{1, 2, 3},
+		AppHash:   [32]byte{4, 5, 6},
+		LeaderSig: []byte{7, 8, 9},
+	}
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 83 {
+		t.Fatalf("unexpected message length %d", len(data))
+	}
+
+	tests := []struct {
+		name    string
+		length  int
+		wantErr error
+	}{
+		{"empty", 0, io.EOF},
+		{"partial hash", 16, io.ErrUnexpectedEOF},
+		{"no height", 32, io.EOF},
+		{"partial height", 36, io.ErrUnexpectedEOF},
+		{"no app hash", 40, io.EOF},
+		{"partial app hash", 50, io.ErrUnexpectedEOF},
+		{"no sig length", 72, io.EOF},
+		{"partial sig length", 76, io.ErrUnexpectedEOF},
+		{"no sig", 80, io.EOF},
+		{"partial sig", 82, io.ErrUnexpectedEOF},
+		{"complete", 83, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m blockAnnMsg
+			n, err := m.ReadFrom(bytes.NewReader(data[:tt.length]))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ReadFrom() error = %v, want %v", err, tt.wantErr)
+			}
+			if n != int64(tt.length) {
+				t.Errorf("ReadFrom() read %d bytes, want %d", n, tt.length)
+			}
+		})
+	}
+}
+
 func TestBlockHeightReq_MarshalUnmarshal(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -271,6 +322,43 @@ func TestBlockHashReq_ReadWriteTo(t *testing.T) {
 	}
 }
 
+func TestSnapshotChunkReq_Format(t *testing.T) {
+	req := snapshotChunkReq{
+		Height: 100,
+		Format: 2,
+		Index:  7,
+		Hash:   types.Hash{1, 2, 3},
+	}
+
+	bts, err := req.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var unmarshaled snapshotChunkReq
+	if err := unmarshaled.UnmarshalBinary(bts); err != nil {
+		t.Fatalf("UnmarshalBinary() error = %v", err)
+	}
+	if unmarshaled != req {
+		t.Errorf("UnmarshalBinary() got %+v, want %+v", unmarshaled, req)
+	}
+
+	buf := new(bytes.Buffer)
+	if _, err := req.WriteTo(buf); err != nil {
+		t.Fatal(err)
+	}
+	var read snapshotChunkReq
+	n, err := read.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("ReadFrom() error = %v", err)
+	}
+	if n != int64(len(bts)) {
+		t.Errorf("ReadFrom() read %d bytes, want %d", n, len(bts))
+	}
+	if read != req {
+		t.Errorf("ReadFrom() got %+v, want %+v", read, req)
+	}
+}
+
 func TestBlockHeightReq_UnmarshalInvalidData(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -418,3 +506,206 @@ type brokenReader struct {
 func (br *brokenReader) Read(p []byte) (n int, err error) {
 	return 0, br.err
 }
+
+func TestAnnHeader_MarshalUnmarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		hdr  annHeader
+	}{
+		{"empty", annHeader{}},
+		{"type only", annHeader{ContentType: annTypeBlock}},
+		{"type and id", annHeader{ContentType: annTypeBlockProposal, ID: []byte{1, 2, 3}}},
+		{"max lengths", annHeader{ContentType: strings.Repeat("t", math.MaxUint8),
+			ID: bytes.Repeat([]byte{9}, annIDLimit)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.hdr.MarshalBinary()
+			if err != nil {
+				t.Fatalf("MarshalBinary() error = %v", err)
+			}
+			if want := 1 + len(tt.hdr.ContentType) + 2 + len(tt.hdr.ID); len(data) != want {
+				t.Errorf("encoded length %d, want %d", len(data), want)
+			}
+
+			var got annHeader
+			if err = got.UnmarshalBinary(data); err != nil {
+				t.Fatalf("UnmarshalBinary() error = %v", err)
+			}
+			if got.ContentType != tt.hdr.ContentType {
+				t.Errorf("ContentType mismatch: got %q, want %q", got.ContentType, tt.hdr.ContentType)
+			}
+			if !bytes.Equal(got.ID, tt.hdr.ID) {
+				t.Errorf("ID mismatch: got %x, want %x", got.ID, tt.hdr.ID)
+			}
+
+			// ReadFrom stops at the end of the header.
+			n, err := got.ReadFrom(bytes.NewReader(append(data, getMsg...)))
+			if err != nil {
+				t.Fatalf("ReadFrom() error = %v", err)
+			}
+			if n != int64(len(data)) {
+				t.Errorf("ReadFrom() read %d bytes, want %d", n, len(data))
+			}
+		})
+	}
+}
+
+func TestAnnHeader_Invalid(t *testing.T) {
+	if _, err := (annHeader{ContentType: strings.Repeat("t", math.MaxUint8+1)}).MarshalBinary(); err == nil {
+		t.Error("expected error for a long content type")
+	}
+	if _, err := (annHeader{ID: make([]byte, annIDLimit+1)}).MarshalBinary(); err == nil {
+		t.Error("expected error for a long ID")
+	}
+
+	data, err := annHeader{ContentType: annTypeBlock, ID: []byte{1, 2, 3}}.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range len(data) {
+		var hdr annHeader
+		if err := hdr.UnmarshalBinary(data[:i]); !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("truncated to %d bytes: got error %v", i, err)
+		}
+	}
+	var hdr annHeader
+	if err := hdr.UnmarshalBinary(append(data, 0)); err == nil {
+		t.Error("expected error for trailing data")
+	}
+}
+
+func TestBlockRangeReq_MarshalUnmarshal(t *testing.T) {
+	req := blockRangeReq{Start: 12, Count: 34}
+	data, err := req.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got blockRangeReq
+	if _, err = got.ReadFrom(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	if got != req {
+		t.Errorf("got %+v, want %+v", got, req)
+	}
+	if err = got.UnmarshalBinary(data[:11]); err == nil {
+		t.Error("expected error for short data")
+	}
+}
+
+func TestDecodeBlkRange(t *testing.T) {
+	blks := []rangeBlk{
+		{Hash: types.Hash{1}, AppHash: types.Hash{2}, Raw: []byte("block 1")},
+		{Hash: types.Hash{3}, AppHash: types.Hash{4}, Raw: []byte{}},
+	}
+	var buf bytes.Buffer
+	for _, blk := range blks {
+		if _, err := blk.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data := buf.Bytes()
+
+	got, err := decodeBlkRange(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(blks) {
+		t.Fatalf("got %d blocks, want %d", len(got), len(blks))
+	}
+	for i := range blks {
+		if got[i].Hash != blks[i].Hash || got[i].AppHash != blks[i].AppHash ||
+			!bytes.Equal(got[i].Raw, blks[i].Raw) {
+			t.Errorf("block %d mismatch: got %+v, want %+v", i, got[i], blks[i])
+		}
+	}
+
+	for _, n := range []int{1, rangeBlkOverhead, len(data) - 1} {
+		if _, err := decodeBlkRange(data[:n]); err == nil {
+			t.Errorf("expected error for data truncated to %d bytes", n)
+		}
+	}
+}
+
+func TestLeaderSigLenLimit(t *testing.T) {
+	// encodeAnn encodes a block announcement without the length check of
+	// WriteTo, as a peer with a different limit might.
+	encodeAnn := func(sig []byte) []byte {
+		var buf bytes.Buffer
+		buf.Write(make([]byte, types.HashLen+8+types.HashLen))
+		binary.Write(&buf, binary.LittleEndian, uint64(len(sig)))
+		buf.Write(sig)
+		return buf.Bytes()
+	}
+	encodeProp := func(sig []byte) []byte {
+		var buf bytes.Buffer
+		buf.Write(make([]byte, 8+2*types.HashLen+8))
+		binary.Write(&buf, binary.LittleEndian, uint64(len(sig)))
+		buf.Write(sig)
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name    string
+		sigLen  int
+		wantErr error
+	}{
+		{"at limit", DefaultMaxLeaderSigLen, nil},
+		{"over limit", DefaultMaxLeaderSigLen + 1, ErrLeaderSigTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig := bytes.Repeat([]byte{1}, tt.sigLen)
+
+			var ann blockAnnMsg
+			if err := ann.UnmarshalBinary(encodeAnn(sig)); !errors.Is(err, tt.wantErr) {
+				t.Errorf("blockAnnMsg.UnmarshalBinary() error = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := (blockAnnMsg{LeaderSig: sig}).MarshalBinary(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("blockAnnMsg.MarshalBinary() error = %v, want %v", err, tt.wantErr)
+			}
+
+			var prop blockProp
+			if err := prop.UnmarshalBinary(encodeProp(sig)); !errors.Is(err, tt.wantErr) {
+				t.Errorf("blockProp.UnmarshalBinary() error = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := (blockProp{LeaderSig: sig}).MarshalBinary(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("blockProp.MarshalBinary() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil && !bytes.Equal(ann.LeaderSig, sig) {
+				t.Errorf("blockAnnMsg leader sig length %d, want %d", len(ann.LeaderSig), len(sig))
+			}
+			if tt.wantErr == nil && !bytes.Equal(prop.LeaderSig, sig) {
+				t.Errorf("blockProp leader sig length %d, want %d", len(prop.LeaderSig), len(sig))
+			}
+		})
+	}
+
+	t.Run("raised limit", func(t *testing.T) {
+		sig := bytes.Repeat([]byte{1}, DefaultMaxLeaderSigLen+1)
+
+		ann := blockAnnMsg{maxSigLen: len(sig)}
+		if err := ann.UnmarshalBinary(encodeAnn(sig)); err != nil {
+			t.Errorf("blockAnnMsg.UnmarshalBinary() error = %v", err)
+		}
+		prop := blockProp{maxSigLen: len(sig)}
+		if err := prop.UnmarshalBinary(encodeProp(sig)); err != nil {
+			t.Errorf("blockProp.UnmarshalBinary() error = %v", err)
+		}
+		if _, err := (blockProp{LeaderSig: sig, maxSigLen: len(sig)}).MarshalBinary(); err != nil {
+			t.Errorf("blockProp.MarshalBinary() error = %v", err)
+		}
+	})
+
+	t.Run("huge length", func(t *testing.T) {
+		data := encodeAnn(nil)
+		binary.LittleEndian.PutUint64(data[len(data)-8:], math.MaxUint64)
+		var ann blockAnnMsg
+		if err := ann.UnmarshalBinary(data); !errors.Is(err, ErrLeaderSigTooLong) {
+			t.Errorf("blockAnnMsg.UnmarshalBinary() error = %v, want %v", err, ErrLeaderSigTooLong)
+		}
+	})
+}
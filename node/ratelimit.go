@@ -0,0 +1,102 @@
+package node
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"golang.org/x/time/rate"
+)
+
+// busyData is the response to a resource request that is rejected because the
+// requesting peer has exceeded its rate limit.
+var busyData = []byte{1}
+
+// maxTrackedLimiters is the number of per-peer limiters above which the
+// limiters of peers that have not made any recent requests are dropped.
+const maxTrackedLimiters = 1024
+
+// PeerRateLimit configures the limit on the rate of resource requests, such as
+// for transactions and blocks, that the node serves to each peer. Requests in
+// excess of the limit are rejected, and the peer receives ErrPeerBusy.
+type PeerRateLimit struct {
+	// Rate is the sustained number of requests per second allowed from a peer.
+	Rate float64
+	// Burst is the number of requests that a peer may make at once.
+	Burst int
+	// Exempt are peers that are not rate limited, such as known validators.
+	Exempt []peer.ID
+}
+
+// peerLimiter is a token bucket rate limiter for each peer. A nil *peerLimiter
+// allows all requests.
+type peerLimiter struct {
+	rate   rate.Limit
+	burst  int
+	exempt map[peer.ID]bool
+
+	mtx      sync.Mutex
+	limiters map[peer.ID]*rate.Limiter
+}
+
+func newPeerLimiter(cfg PeerRateLimit) *peerLimiter {
+	exempt := make(map[peer.ID]bool, len(cfg.Exempt))
+	for _, p := range cfg.Exempt {
+		exempt[p] = true
+	}
+	return &peerLimiter{
+		rate:     rate.Limit(cfg.Rate),
+		burst:    cfg.Burst,
+		exempt:   exempt,
+		limiters: make(map[peer.ID]*rate.Limiter),
+	}
+}
+
+// allow reports whether a request from the peer is within its rate limit.
+func (pl *peerLimiter) allow(peerID peer.ID) bool {
+	if pl == nil || pl.exempt[peerID] {
+		return true
+	}
+
+	pl.mtx.Lock()
+	defer pl.mtx.Unlock()
+
+	lim, ok := pl.limiters[peerID]
+	if !ok {
+		if len(pl.limiters) >= maxTrackedLimiters {
+			pl.prune()
+		}
+		lim = rate.NewLimiter(pl.rate, pl.burst)
+		pl.limiters[peerID] = lim
+	}
+	return lim.Allow()
+}
+
+// prune drops the limiters with full buckets, which are the same as new ones.
+func (pl *peerLimiter) prune() {
+	for p, lim := range pl.limiters {
+		if lim.Tokens() >= float64(pl.burst) {
+			delete(pl.limiters, p)
+		}
+	}
+}
+
+// limitRequests wraps a resource request stream handler so that a request
+// from a peer that has exceeded its rate limit is rejected with busyData.
+func (n *Node) limitRequests(handler network.StreamHandler) network.StreamHandler {
+	if n.reqLimiter == nil {
+		return handler
+	}
+	return func(s network.Stream) {
+		peerID := s.Conn().RemotePeer()
+		if n.reqLimiter.allow(peerID) {
+			handler(s)
+			return
+		}
+		defer s.Close()
+		n.log.Debug("peer exceeded request rate limit", "peer", peerID, "protocol", s.Protocol())
+		s.SetWriteDeadline(time.Now().Add(n.timeouts.ReqRW))
+		s.Write(busyData)
+	}
+}
@@ -0,0 +1,67 @@
+package adminsvc
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	jsonrpc "github.com/kwilteam/kwil-db/core/rpc/json"
+	rpcserver "github.com/kwilteam/kwil-db/node/services/jsonrpc"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "kwil_admin_rpc"
+
+// rpcMetrics records the calls to each admin RPC method, their latencies, and
+// the error codes they return.
+type rpcMetrics struct {
+	calls   *prometheus.CounterVec   // by method
+	latency *prometheus.HistogramVec // by method
+	errors  *prometheus.CounterVec   // by method and error code
+}
+
+// newRPCMetrics creates the admin RPC metrics and registers them with reg. It
+// panics if they are already registered, so each registry may be used by only
+// one Service.
+func newRPCMetrics(reg prometheus.Registerer) *rpcMetrics {
+	m := &rpcMetrics{
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "calls_total",
+			Help:      "Number of admin RPC method calls.",
+		}, []string{"method"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "duration_seconds",
+			Help:      "Time to handle admin RPC method calls.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "errors_total",
+			Help:      "Number of admin RPC method calls that returned an error, by error code.",
+		}, []string{"method", "code"}),
+	}
+	reg.MustRegister(m.calls, m.latency, m.errors)
+	return m
+}
+
+// instrument wraps a MethodHandler so that each call of the method is recorded.
+// The latency is that of the handler, not decoding the request.
+func (m *rpcMetrics) instrument(method jsonrpc.Method, h rpcserver.MethodHandler) rpcserver.MethodHandler {
+	name := string(method)
+	return func(ctx context.Context, s *rpcserver.Server) (any, func() (any, *jsonrpc.Error)) {
+		argsPtr, handler := h(ctx, s)
+		return argsPtr, func() (any, *jsonrpc.Error) {
+			start := time.Now()
+			resp, jsonErr := handler()
+			m.latency.WithLabelValues(name).Observe(time.Since(start).Seconds())
+			m.calls.WithLabelValues(name).Inc()
+			if jsonErr != nil {
+				m.errors.WithLabelValues(name, strconv.Itoa(int(jsonErr.Code))).Inc()
+			}
+			return resp, jsonErr
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package adminsvc
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/kwilteam/kwil-db/core/crypto"
+	"github.com/kwilteam/kwil-db/core/log"
+	jsonrpc "github.com/kwilteam/kwil-db/core/rpc/json"
+	adminjson "github.com/kwilteam/kwil-db/core/rpc/json/admin"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	nodeKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+
+	reg := prometheus.NewRegistry()
+	svc := NewService(nil, &mockNode{}, nil, nil, nil, nil, nil, "kwil-test-chain", log.DiscardLogger,
+		WithSignedRequests(nodeKey.Public()), WithOpenReads(), WithMetrics(reg))
+
+	call := func(method jsonrpc.Method) *jsonrpc.Error {
+		_, handler := svc.Handlers()[method](context.Background(), nil)
+		_, jsonErr := handler()
+		return jsonErr
+	}
+
+	// The version method is open, and the config method requires a signature.
+	for range 2 {
+		require.Nil(t, call(adminjson.MethodVersion))
+	}
+	jsonErr := call(adminjson.MethodConfig)
+	require.NotNil(t, jsonErr)
+
+	version, config := string(adminjson.MethodVersion), string(adminjson.MethodConfig)
+	require.Equal(t, 2.0, testutil.ToFloat64(svc.metrics.calls.WithLabelValues(version)))
+	require.Equal(t, 1.0, testutil.ToFloat64(svc.metrics.calls.WithLabelValues(config)))
+	require.Equal(t, 1, testutil.CollectAndCount(svc.metrics.errors)) // only the config error
+	require.Equal(t, 1.0, testutil.ToFloat64(svc.metrics.errors.WithLabelValues(config,
+		strconv.Itoa(int(jsonrpc.ErrorUnauthorized)))))
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	var found bool
+	for _, mf := range families {
+		if mf.GetName() != metricsNamespace+"_duration_seconds" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			if m.GetLabel()[0].GetValue() != version {
+				continue
+			}
+			found = true
+			require.EqualValues(t, 2, m.GetHistogram().GetSampleCount())
+			require.Positive(t, m.GetHistogram().GetSampleSum())
+		}
+	}
+	require.True(t, found, "no latency recorded for the version method")
+
+	t.Run("disabled", func(t *testing.T) {
+		svc := NewService(nil, &mockNode{}, nil, nil, nil, nil, nil, "kwil-test-chain", log.DiscardLogger)
+		require.Nil(t, svc.metrics)
+		_, handler := svc.Handlers()[adminjson.MethodVersion](context.Background(), nil)
+		_, jsonErr := handler()
+		require.Nil(t, jsonErr)
+	})
+}
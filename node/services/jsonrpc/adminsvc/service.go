@@ -1,12 +1,21 @@
 package adminsvc
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/kwilteam/kwil-db/config"
+	"github.com/kwilteam/kwil-db/core/crypto"
 	"github.com/kwilteam/kwil-db/core/crypto/auth"
 	"github.com/kwilteam/kwil-db/core/log"
 	jsonrpc "github.com/kwilteam/kwil-db/core/rpc/json"
@@ -15,11 +24,14 @@ import (
 	ktypes "github.com/kwilteam/kwil-db/core/types"
 	types "github.com/kwilteam/kwil-db/core/types/admin"
 	"github.com/kwilteam/kwil-db/extensions/resolutions"
+	"github.com/kwilteam/kwil-db/node/peers"
 	rpcserver "github.com/kwilteam/kwil-db/node/services/jsonrpc"
 	nodetypes "github.com/kwilteam/kwil-db/node/types"
 	"github.com/kwilteam/kwil-db/node/types/sql"
 	"github.com/kwilteam/kwil-db/node/voting"
 	"github.com/kwilteam/kwil-db/version"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // BlockchainTransactor specifies the methods required for the admin service to
@@ -28,10 +40,20 @@ type Node interface {
 	Status(context.Context) (*types.Status, error)
 	Peers(context.Context) ([]*types.PeerInfo, error)
 	BroadcastTx(ctx context.Context, tx *ktypes.Transaction, sync uint8) (*ktypes.ResultBroadcastTx, error)
+	// Shutdown gracefully stops the node, returning when it has stopped.
+	Shutdown(ctx context.Context) error
+	// ResetToHeight resets the node to the given height, which must not be
+	// above its best block. Below the best block, the node stops and removes
+	// the blocks above the height.
+	ResetToHeight(ctx context.Context, height int64) error
 }
 
 type P2P interface {
-	// AddPeer adds a peer to the node's peer list and persists it.
+	// ID returns the node's own peer ID.
+	ID() string
+
+	// AddPeer adds a peer to the node's peer list and persists it. Adding a
+	// peer that is already in the list returns peers.ErrPeerExists.
 	AddPeer(ctx context.Context, nodeID string) error
 
 	// RemovePeer removes a peer from the node's peer list permanently.
@@ -39,19 +61,54 @@ type P2P interface {
 
 	// ListPeers returns the list of peers in the node's whitelist.
 	ListPeers(ctx context.Context) []string
+
+	// KnownPeers returns all peers in the node's address book.
+	KnownPeers(ctx context.Context) []*types.KnownPeer
+
+	// PeerProtocols returns all of the protocols that a peer supports, and the
+	// protocols required by the node that the peer does not support.
+	PeerProtocols(ctx context.Context, peerID string) (supported, missing []string, err error)
+
+	// ReloadAddrBook re-reads the address book file, adding any new peers,
+	// and returns the number of peers that were added.
+	ReloadAddrBook(ctx context.Context) (int, error)
+
+	// PeerMetrics returns the node's peer connection statistics.
+	PeerMetrics(ctx context.Context) *types.PeerMetrics
+
+	// PendingDials returns the node's in-progress outbound dials, oldest
+	// first.
+	PendingDials(ctx context.Context) []*types.PendingDial
+
+	// CancelDial cancels the in-progress dials to a peer, returning false if
+	// the peer was not being dialed.
+	CancelDial(ctx context.Context, peerID string) (bool, error)
+
+	// ReconnectPeer closes any connection to a peer and dials it again,
+	// bypassing the reconnect backoff. It returns the peer's resulting
+	// connectedness.
+	ReconnectPeer(ctx context.Context, peerID string) (string, error)
+
+	// Protocols returns the protocols that the node requires of its peers,
+	// and all of the protocols that it supports.
+	Protocols(ctx context.Context) (required, supported []string)
 }
 
 type App interface {
 	// AccountInfo returns the unconfirmed account info for the given identifier.
 	// If unconfirmed is true, the account found in the mempool is returned.
-	// Otherwise, the account found in the blockchain is returned.
-	AccountInfo(ctx context.Context, db sql.DB, identifier []byte, unconfirmed bool) (balance *big.Int, nonce int64, err error)
+	// Otherwise, the account found in the blockchain is returned. An account
+	// that does not exist has a zero balance and nonce, and exists is false.
+	AccountInfo(ctx context.Context, db sql.DB, identifier []byte, unconfirmed bool) (balance *big.Int, nonce int64, exists bool, err error)
 	Price(ctx context.Context, db sql.DB, tx *ktypes.Transaction) (*big.Int, error)
 }
 
 type Validators interface {
 	SetValidatorPower(ctx context.Context, tx sql.Executor, pubKey []byte, power int64) error
 	GetValidatorPower(ctx context.Context, pubKey []byte) (int64, error)
+	// GetValidatorPowers gets the powers of several validators at once, keyed
+	// by string(pubKey). Non-validators are omitted.
+	GetValidatorPowers(ctx context.Context, pubKeys [][]byte) (map[string]int64, error)
 	GetValidators() []*ktypes.Validator
 }
 
@@ -67,20 +124,148 @@ type Service struct {
 	cfg     *config.Config
 	chainID string
 	signer  auth.Signer // ed25519 signer derived from the node's private key
+
+	// signers are the keys that may sign requests. If empty, requests need not
+	// be signed.
+	signers []crypto.PublicKey
+	// openReads exempts the read-only methods from signature verification.
+	openReads bool
+	// leader is the leader's public key, used to set the validator roles.
+	leader []byte
+	// metrics records the method calls, or nil if metrics are disabled.
+	metrics *rpcMetrics
+	// timeout is the time limit of each read-only method call, or zero for
+	// no limit.
+	timeout time.Duration
+	// keyFile is where RotateKey saves the new key, or empty if key rotation
+	// is disabled. keyType is the type of key that it generates.
+	keyFile string
+	keyType crypto.KeyType
+}
+
+type serviceCfg struct {
+	signers    []crypto.PublicKey
+	openReads  bool
+	leader     []byte
+	metricsReg prometheus.Registerer
+	timeout    time.Duration
+	keyFile    string
+	keyType    crypto.KeyType
+}
+
+// Opt is a Service option.
+type Opt func(*serviceCfg)
+
+// WithSignedRequests requires that all requests be signed by either the node's
+// own key or one of the allowed ed25519 keys. Requests that are not signed by
+// one of these keys are rejected with jsonrpc.ErrorUnauthorized.
+func WithSignedRequests(nodeKey crypto.PublicKey, allowed ...*crypto.Ed25519PublicKey) Opt {
+	return func(cfg *serviceCfg) {
+		cfg.signers = append(cfg.signers, nodeKey)
+		for _, key := range allowed {
+			cfg.signers = append(cfg.signers, key)
+		}
+	}
+}
+
+// WithOpenReads exempts the read-only methods, such as status and health, from
+// the signature requirement of WithSignedRequests, so that monitoring does not
+// need an authorized key. Methods that change the node's validator, peer, or
+// other state, or that reveal its configuration, still require a signature.
+func WithOpenReads() Opt {
+	return func(cfg *serviceCfg) {
+		cfg.openReads = true
+	}
+}
+
+// WithMetrics records the number of calls, latency, and error codes of each
+// method in Prometheus metrics registered with reg. By default, no metrics are
+// recorded.
+func WithMetrics(reg prometheus.Registerer) Opt {
+	return func(cfg *serviceCfg) {
+		cfg.metricsReg = reg
+	}
+}
+
+// WithLeader sets the leader's public key, which is used to report the role of
+// each validator in the validator list.
+func WithLeader(leader []byte) Opt {
+	return func(cfg *serviceCfg) {
+		cfg.leader = leader
+	}
+}
+
+// WithTimeout sets the time limit of each read-only method call, after which
+// the handler's context is canceled and a timeout error is returned. Methods
+// that make changes, such as broadcasting a transaction, are not limited. A
+// zero timeout disables the limit. The default is DefaultTimeout.
+func WithTimeout(timeout time.Duration) Opt {
+	return func(cfg *serviceCfg) {
+		cfg.timeout = timeout
+	}
+}
+
+// WithKeyRotation enables the val_rotate_key method, which saves the new key
+// of the given type to keyFile. The node does not use the new key until it is
+// restarted with it. By default, key rotation is disabled.
+func WithKeyRotation(keyFile string, keyType crypto.KeyType) Opt {
+	return func(cfg *serviceCfg) {
+		cfg.keyFile = keyFile
+		cfg.keyType = keyType
+	}
+}
+
+// readOnlyMethods are the methods that are exempt from signature verification
+// with WithOpenReads, and the methods that WithTimeout applies to. The config and address book export methods are excluded
+// since they may reveal sensitive information.
+var readOnlyMethods = map[jsonrpc.Method]bool{
+	adminjson.MethodVersion:          true,
+	adminjson.MethodStatus:           true,
+	adminjson.MethodHealth:           true,
+	adminjson.MethodPeers:            true,
+	adminjson.MethodValJoinStatus:    true,
+	adminjson.MethodValListJoins:     true,
+	adminjson.MethodValList:          true,
+	adminjson.MethodListPeers:        true,
+	adminjson.MethodResolutionStatus: true,
+	adminjson.MethodPeerMetrics:      true,
+	adminjson.MethodPendingDials:     true,
+	adminjson.MethodProtocols:        true,
 }
 
 const (
 	apiVerMajor = 0
-	apiVerMinor = 2
+	apiVerMinor = 8
 	apiVerPatch = 0
 
 	serviceName = "admin"
+
+	// DefaultTimeout is the default time limit of each read-only method call.
+	DefaultTimeout = 30 * time.Second
+
+	// maxSignatureAge is how far the timestamp of a signed request may be from
+	// the node's clock, limiting the time in which the request may be replayed.
+	maxSignatureAge = 2 * time.Minute
 )
 
 // API version log
 //
 // apiVerMinor = 2 indicates the presence of the peer whitelist, resolution, and
 // health methods added in Kwil v0.9
+//
+// apiVerMinor = 3 indicates the presence of the transfer, peer_metrics, and
+// config_json methods
+//
+// apiVerMinor = 4 indicates the presence of the pending_dials and cancel_dial
+// methods
+//
+// apiVerMinor = 5 indicates the presence of the protocols method
+//
+// apiVerMinor = 6 indicates the presence of the reconnect_peer method
+//
+// apiVerMinor = 7 indicates the presence of the reset_to_height method
+//
+// apiVerMinor = 8 indicates the presence of the val_rotate_key method
 
 var (
 	apiSemver = fmt.Sprintf("%d.%d.%d", apiVerMajor, apiVerMinor, apiVerPatch)
@@ -148,7 +333,7 @@ func (svc *Service) HealthMethod(ctx context.Context, _ *userjson.HealthRequest)
 }
 
 func (svc *Service) Methods() map[jsonrpc.Method]rpcserver.MethodDef {
-	return map[jsonrpc.Method]rpcserver.MethodDef{
+	methods := map[jsonrpc.Method]rpcserver.MethodDef{
 		adminjson.MethodVersion: rpcserver.MakeMethodDef(verHandler,
 			"retrieve the API version of the admin service",    // method description
 			"service info including semver and kwild version"), // return value description
@@ -161,6 +346,15 @@ func (svc *Service) Methods() map[jsonrpc.Method]rpcserver.MethodDef {
 		adminjson.MethodConfig: rpcserver.MakeMethodDef(svc.GetConfig,
 			"retrieve the current effective node config",
 			"the raw bytes of the effective config TOML document"),
+		adminjson.MethodConfigJSON: rpcserver.MakeMethodDef(svc.GetConfigJSON,
+			"retrieve the current effective node config as canonical JSON",
+			"the effective config as JSON with sorted keys and stable formatting, for comparison between nodes"),
+		adminjson.MethodShutdown: rpcserver.MakeMethodDef(svc.Shutdown,
+			"gracefully shut down the node",
+			"acknowledgement that the node is shutting down"),
+		adminjson.MethodResetToHeight: rpcserver.MakeMethodDef(svc.ResetToHeight,
+			"abort any block in progress and return to the last committed block, which must be at the given height",
+			"the height of the block that the node's state was reset to"),
 		adminjson.MethodValApprove: rpcserver.MakeMethodDef(svc.Approve,
 			"approve a validator join request",
 			"the hash of the broadcasted validator approve transaction"),
@@ -169,7 +363,7 @@ func (svc *Service) Methods() map[jsonrpc.Method]rpcserver.MethodDef {
 			"the hash of the broadcasted validator join transaction"),
 		adminjson.MethodValJoinStatus: rpcserver.MakeMethodDef(svc.JoinStatus,
 			"query for the status of a validator join request",
-			"the pending join request details, if it exists"),
+			"the pending join request details, or the status of the latest resolved join request"),
 		adminjson.MethodValListJoins: rpcserver.MakeMethodDef(svc.ListPendingJoins,
 			"list active validator join requests",
 			"all pending join requests including the current approvals and the join expiry"),
@@ -179,17 +373,47 @@ func (svc *Service) Methods() map[jsonrpc.Method]rpcserver.MethodDef {
 		adminjson.MethodValLeave: rpcserver.MakeMethodDef(svc.Leave,
 			"leave the validator set",
 			"the hash of the broadcasted validator leave transaction"),
+		adminjson.MethodValRotateKey: rpcserver.MakeMethodDef(svc.RotateKey,
+			"generate a new validator key and request that it replace the node's current key",
+			"the hash of the broadcasted validator key update transaction, the new public key, and the file where the new key was saved"),
 		adminjson.MethodValRemove: rpcserver.MakeMethodDef(svc.Remove,
 			"vote to remote a validator",
 			"the hash of the broadcasted validator remove transaction"),
 		adminjson.MethodAddPeer: rpcserver.MakeMethodDef(svc.AddPeer,
-			"add a peer to the network", ""),
+			"add a peer to the network by its p2p multiaddr or peer ID", ""),
 		adminjson.MethodRemovePeer: rpcserver.MakeMethodDef(svc.RemovePeer,
 			"add a peer to the network",
 			""),
 		adminjson.MethodListPeers: rpcserver.MakeMethodDef(svc.ListPeers,
 			"list the peers from the node's whitelist",
 			"the list of peers from which the node can accept connections from."),
+		adminjson.MethodExportPeers: rpcserver.MakeMethodDef(svc.ExportPeers,
+			"export the node's address book",
+			"all known peers including their addresses, protocols, and when they were last seen"),
+		adminjson.MethodImportPeers: rpcserver.MakeMethodDef(svc.ImportPeers,
+			"add peers to the node's address book",
+			"the number of peers added and skipped"),
+		adminjson.MethodPeerProtocols: rpcserver.MakeMethodDef(svc.PeerProtocols,
+			"list the protocols supported by a peer",
+			"all protocols supported by the peer, and the protocols required by this node that it is missing"),
+		adminjson.MethodReloadAddrBook: rpcserver.MakeMethodDef(svc.ReloadAddrBook,
+			"reload the node's address book file",
+			"the number of new peers added from the address book"),
+		adminjson.MethodPeerMetrics: rpcserver.MakeMethodDef(svc.PeerMetrics,
+			"get the node's peer connection statistics",
+			"the number of connected and known peers, and counts of connection events"),
+		adminjson.MethodPendingDials: rpcserver.MakeMethodDef(svc.PendingDials,
+			"list the node's outbound dials to peers that are in progress",
+			"the ID of each peer being dialed and when the dial started"),
+		adminjson.MethodCancelDial: rpcserver.MakeMethodDef(svc.CancelDial,
+			"cancel the node's in-progress dials to a peer",
+			"whether any dials to the peer were canceled"),
+		adminjson.MethodReconnectPeer: rpcserver.MakeMethodDef(svc.ReconnectPeer,
+			"close the node's connection to a peer and dial it again",
+			"the connectedness of the peer after the dial"),
+		adminjson.MethodProtocols: rpcserver.MakeMethodDef(svc.Protocols,
+			"list the node's required and supported peer protocols",
+			"the protocols that peers must support, and all protocols supported by this node"),
 		adminjson.MethodCreateResolution: rpcserver.MakeMethodDef(svc.CreateResolution,
 			"create a resolution",
 			"the hash of the broadcasted create resolution transaction",
@@ -205,11 +429,43 @@ func (svc *Service) Methods() map[jsonrpc.Method]rpcserver.MethodDef {
 		adminjson.MethodResolutionStatus: rpcserver.MakeMethodDef(svc.ResolutionStatus,
 			"get the status of a resolution",
 			"the status of the resolution"),
+		adminjson.MethodTransfer: rpcserver.MakeMethodDef(svc.Transfer,
+			"transfer an amount from the node's account to another account",
+			"the hash of the broadcasted transfer transaction"),
 		adminjson.MethodHealth: rpcserver.MakeMethodDef(svc.HealthMethod,
 			"check the admin service health",
 			"the health status and other relevant of the services health",
 		),
 	}
+
+	if svc.timeout > 0 {
+		for method, def := range methods {
+			if !readOnlyMethods[method] {
+				continue // must not return while a change is still being made
+			}
+			def.Handler = withTimeout(svc.timeout, def.Handler)
+			methods[method] = def
+		}
+	}
+
+	if len(svc.signers) > 0 {
+		for method, def := range methods {
+			if svc.openReads && readOnlyMethods[method] {
+				continue
+			}
+			def.Handler = svc.authorize(def.Handler)
+			methods[method] = def
+		}
+	}
+
+	if svc.metrics != nil {
+		for method, def := range methods {
+			def.Handler = svc.metrics.instrument(method, def.Handler)
+			methods[method] = def
+		}
+	}
+
+	return methods
 }
 
 func (svc *Service) Handlers() map[jsonrpc.Method]rpcserver.MethodHandler {
@@ -220,18 +476,139 @@ func (svc *Service) Handlers() map[jsonrpc.Method]rpcserver.MethodHandler {
 	return handlers
 }
 
+// authorize wraps a MethodHandler so that the request signature is verified
+// before the handler is called.
+func (svc *Service) authorize(h rpcserver.MethodHandler) rpcserver.MethodHandler {
+	return func(ctx context.Context, s *rpcserver.Server) (any, func() (any, *jsonrpc.Error)) {
+		argsPtr, handler := h(ctx, s)
+		return argsPtr, func() (any, *jsonrpc.Error) {
+			if jsonErr := svc.verifySignature(ctx); jsonErr != nil {
+				return nil, jsonErr
+			}
+			return handler()
+		}
+	}
+}
+
+// withTimeout wraps a MethodHandler so that the handler's context is canceled
+// once the handler has run for the timeout. If the handler has not returned by
+// then, an error is returned without waiting for it, so a hung DB transaction
+// cannot block the request indefinitely. Since the handler may keep running,
+// this is only for read-only methods.
+//
+// The context passed to h must be cancelable since h captures it, but the
+// timer only starts when the handler is called, after the params are decoded.
+func withTimeout(timeout time.Duration, h rpcserver.MethodHandler) rpcserver.MethodHandler {
+	return func(ctx context.Context, s *rpcserver.Server) (any, func() (any, *jsonrpc.Error)) {
+		ctx, cancel := context.WithCancelCause(ctx)
+		argsPtr, handler := h(ctx, s)
+		return argsPtr, func() (any, *jsonrpc.Error) {
+			defer cancel(nil)
+			timer := time.AfterFunc(timeout, func() { cancel(errTimeout) })
+			defer timer.Stop()
+
+			type result struct {
+				resp any
+				err  *jsonrpc.Error
+			}
+			done := make(chan result, 1)
+			go func() {
+				resp, jsonErr := handler()
+				done <- result{resp, jsonErr}
+			}()
+
+			select {
+			case res := <-done:
+				// A handler that failed because of the timeout may report
+				// it as some other error, such as a DB error.
+				if res.err != nil && errors.Is(context.Cause(ctx), errTimeout) {
+					return nil, timeoutError(timeout)
+				}
+				return res.resp, res.err
+			case <-ctx.Done():
+				if errors.Is(context.Cause(ctx), errTimeout) {
+					return nil, timeoutError(timeout)
+				}
+				return nil, jsonrpc.NewError(jsonrpc.ErrorNodeInternal, "request canceled", nil)
+			}
+		}
+	}
+}
+
+// errTimeout is the cause of a handler's context cancellation by withTimeout.
+var errTimeout = errors.New("request timed out")
+
+func timeoutError(timeout time.Duration) *jsonrpc.Error {
+	return jsonrpc.NewError(jsonrpc.ErrorNodeInternal, fmt.Sprintf("request timed out after %v", timeout), nil)
+}
+
+// rollback rolls back a read transaction. The context's cancellation is
+// ignored so that the transaction is released even after the request times
+// out.
+func rollback(ctx context.Context, tx sql.OuterReadTx) {
+	tx.Rollback(context.WithoutCancel(ctx))
+}
+
+// verifySignature checks that the request was recently signed by one of the
+// allowed signers.
+func (svc *Service) verifySignature(ctx context.Context) *jsonrpc.Error {
+	sig := rpcserver.SignatureFromContext(ctx)
+	if sig == nil {
+		return jsonrpc.NewError(jsonrpc.ErrorUnauthorized, "request signature required", nil)
+	}
+
+	idx := slices.IndexFunc(svc.signers, func(key crypto.PublicKey) bool {
+		return bytes.Equal(key.Bytes(), sig.Signer)
+	})
+	if idx == -1 {
+		svc.log.Warn("request from unauthorized signer", "signer", hex.EncodeToString(sig.Signer))
+		return jsonrpc.NewError(jsonrpc.ErrorUnauthorized, "signer not authorized", nil)
+	}
+
+	age := time.Since(time.UnixMilli(sig.Timestamp))
+	if age > maxSignatureAge || age < -maxSignatureAge {
+		return jsonrpc.NewError(jsonrpc.ErrorUnauthorized, "request signature expired", nil)
+	}
+
+	ok, err := svc.signers[idx].Verify(sig.Message(), sig.Signature)
+	if err != nil || !ok {
+		return jsonrpc.NewError(jsonrpc.ErrorUnauthorized, "invalid request signature", nil)
+	}
+
+	return nil
+}
+
 // NewService constructs a new Service.
 func NewService(db sql.DelayedReadTxMaker, blockchain Node, app App,
-	vs Validators, p2p P2P, txSigner auth.Signer, cfg *config.Config,
-	chainID string, logger log.Logger) *Service {
+	vs Validators, p2p P2P, txSigner auth.Signer, nodeCfg *config.Config,
+	chainID string, logger log.Logger, opts ...Opt) *Service {
+	cfg := &serviceCfg{
+		timeout: DefaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	var metrics *rpcMetrics
+	if cfg.metricsReg != nil {
+		metrics = newRPCMetrics(cfg.metricsReg)
+	}
+
 	return &Service{
+		metrics:    metrics,
+		signers:    cfg.signers,
+		openReads:  cfg.openReads,
+		leader:     cfg.leader,
+		timeout:    cfg.timeout,
+		keyFile:    cfg.keyFile,
+		keyType:    cfg.keyType,
 		blockchain: blockchain,
 		p2p:        p2p,
 		app:        app,
 		voting:     vs,
 		signer:     txSigner,
 		chainID:    chainID,
-		cfg:        cfg,
+		cfg:        nodeCfg,
 		log:        logger,
 		db:         db,
 	}
@@ -256,7 +633,19 @@ func (svc *Service) Status(ctx context.Context, req *adminjson.StatusRequest) (*
 	var power int64
 	switch status.Validator.Role {
 	case nodetypes.RoleLeader.String(), nodetypes.RoleValidator.String():
-		power, _ = svc.voting.GetValidatorPower(ctx, status.Validator.PubKey)
+		powers, _ := svc.voting.GetValidatorPowers(ctx, [][]byte{status.Validator.PubKey})
+		power = powers[string(status.Validator.PubKey)]
+	}
+
+	peers, err := svc.blockchain.Peers(ctx)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorNodeInternal, "node peers unavailable", nil)
+	}
+
+	var startTime, uptime int64
+	if !status.StartTime.IsZero() {
+		startTime = status.StartTime.UnixMilli()
+		uptime = time.Since(status.StartTime).Milliseconds()
 	}
 
 	return &adminjson.StatusResponse{
@@ -267,6 +656,9 @@ func (svc *Service) Status(ctx context.Context, req *adminjson.StatusRequest) (*
 			PubKey: status.Validator.PubKey,
 			Power:  power,
 		},
+		NumPeers:  len(peers),
+		StartTime: startTime,
+		Uptime:    uptime,
 	}, nil
 }
 
@@ -275,29 +667,68 @@ func (svc *Service) Peers(ctx context.Context, _ *adminjson.PeersRequest) (*admi
 	if err != nil {
 		return nil, jsonrpc.NewError(jsonrpc.ErrorNodeInternal, "node peers unavailable", nil)
 	}
-	// pbPeers := make([]*types.PeerInfo, len(peers))
-	// for i, p := range peers {
-	// 	pbPeers[i] = &types.PeerInfo{
-	// 		NodeInfo:   p.NodeInfo,
-	// 		Inbound:    p.Inbound,
-	// 		RemoteAddr: p.RemoteAddr,
-	// 	}
-	// }
+	now := time.Now().UnixMilli()
+	peerInfos := make([]*types.PeerInfo, len(peers))
+	for i, p := range peers {
+		peerInfo := *p
+		if peerInfo.ConnectedAt > 0 {
+			peerInfo.ConnAge = max(now-peerInfo.ConnectedAt, 0)
+		}
+		peerInfos[i] = &peerInfo
+	}
 	return &adminjson.PeersResponse{
-		Peers: peers,
+		Peers: peerInfos,
 	}, nil
 }
 
 // sendTx makes a transaction and sends it to the local node.
+// Shutdown begins a graceful shutdown of the node, returning without waiting
+// for the shutdown to complete.
+func (svc *Service) Shutdown(ctx context.Context, req *adminjson.ShutdownRequest) (*adminjson.ShutdownResponse, *jsonrpc.Error) {
+	svc.log.Info("shutdown requested")
+	go func() {
+		if err := svc.blockchain.Shutdown(context.Background()); err != nil {
+			svc.log.Error("failed to shut down node", "error", err)
+		}
+	}()
+	return &adminjson.ShutdownResponse{
+		ShuttingDown: true,
+	}, nil
+}
+
+// ResetToHeight resets the node to the requested height. At the node's best
+// block, any block that it is executing is aborted. Below it, the node stops
+// and removes the blocks above the height. The request must be confirmed.
+func (svc *Service) ResetToHeight(ctx context.Context, req *adminjson.ResetToHeightRequest) (*adminjson.ResetToHeightResponse, *jsonrpc.Error) {
+	if !req.Confirm {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidParams, "reset must be confirmed", nil)
+	}
+	if req.Height < 0 {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidParams, "height must not be negative", nil)
+	}
+	svc.log.Warn("reset requested", "height", req.Height)
+	if err := svc.blockchain.ResetToHeight(ctx, req.Height); err != nil {
+		svc.log.Warn("failed to reset", "height", req.Height, "error", err)
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInternal, "failed to reset: "+err.Error(), nil)
+	}
+	return &adminjson.ResetToHeightResponse{
+		Height: req.Height,
+	}, nil
+}
+
 func (svc *Service) sendTx(ctx context.Context, payload ktypes.Payload) (*userjson.BroadcastResponse, *jsonrpc.Error) {
 	readTx := svc.db.BeginDelayedReadTx()
-	defer readTx.Rollback(ctx)
+	defer rollback(ctx, readTx)
 
-	// Get the latest nonce for the account, if it exists.
-	_, nonce, err := svc.app.AccountInfo(ctx, readTx, svc.signer.Identity(), true)
+	// Get the latest nonce for the account. The first transaction from an
+	// account that does not exist yet uses nonce 1.
+	_, nonce, exists, err := svc.app.AccountInfo(ctx, readTx, svc.signer.Identity(), true)
 	if err != nil {
 		return nil, jsonrpc.NewError(jsonrpc.ErrorAccountInternal, "account info error", nil)
 	}
+	if !exists {
+		svc.log.Info("node account does not exist yet, using nonce 1")
+	}
 
 	tx, err := ktypes.CreateNodeTransaction(payload, svc.chainID, uint64(nonce+1))
 	if err != nil {
@@ -360,19 +791,29 @@ func (svc *Service) Remove(ctx context.Context, req *adminjson.RemoveRequest) (*
 	})
 }
 
+// voting functions used by the JoinStatus handler, variables for testing
+var (
+	getResolutionIDsByTypeAndProposer = voting.GetResolutionIDsByTypeAndProposer
+	getResolutionInfo                 = voting.GetResolutionInfo
+	getLatestOutcome                  = voting.GetLatestOutcomeByTypeAndProposer
+)
+
+// JoinStatus returns the active join request for the candidate, or the most
+// recently resolved one if there is no active request. The outcomes of resolved
+// requests are only kept for a limited number of blocks.
 func (svc *Service) JoinStatus(ctx context.Context, req *adminjson.JoinStatusRequest) (*adminjson.JoinStatusResponse, *jsonrpc.Error) {
 	readTx := svc.db.BeginDelayedReadTx()
-	defer readTx.Rollback(ctx)
-	ids, err := voting.GetResolutionIDsByTypeAndProposer(ctx, readTx, voting.ValidatorJoinEventType, req.PubKey)
+	defer rollback(ctx, readTx)
+	ids, err := getResolutionIDsByTypeAndProposer(ctx, readTx, voting.ValidatorJoinEventType, req.PubKey)
 	if err != nil {
 		svc.log.Error("failed to retrieve join request", "error", err)
 		return nil, jsonrpc.NewError(jsonrpc.ErrorDBInternal, "failed to retrieve join request", nil)
 	}
 	if len(ids) == 0 {
-		return nil, jsonrpc.NewError(jsonrpc.ErrorValidatorNotFound, "no active join request", nil)
+		return svc.resolvedJoinStatus(ctx, readTx, req.PubKey)
 	}
 
-	resolution, err := voting.GetResolutionInfo(ctx, readTx, ids[0])
+	resolution, err := getResolutionInfo(ctx, readTx, ids[0])
 	if err != nil {
 		svc.log.Error("failed to retrieve join request", "error", err)
 		return nil, jsonrpc.NewError(jsonrpc.ErrorDBInternal, "failed to retrieve join request details", nil)
@@ -391,17 +832,158 @@ func (svc *Service) JoinStatus(ctx context.Context, req *adminjson.JoinStatusReq
 	}, nil
 }
 
+// resolvedJoinStatus gets the outcome of the candidate's most recently resolved
+// join request.
+func (svc *Service) resolvedJoinStatus(ctx context.Context, db sql.Executor, candidate []byte) (*adminjson.JoinStatusResponse, *jsonrpc.Error) {
+	outcome, err := getLatestOutcome(ctx, db, voting.ValidatorJoinEventType, candidate)
+	if err != nil {
+		svc.log.Error("failed to retrieve join request outcome", "error", err)
+		return nil, jsonrpc.NewError(jsonrpc.ErrorDBInternal, "failed to retrieve join request", nil)
+	}
+	if outcome == nil {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorValidatorNotFound, "no join request", nil)
+	}
+
+	body := &voting.UpdatePowerRequest{}
+	if err := body.UnmarshalBinary(outcome.Body); err != nil {
+		svc.log.Error("failed to unmarshal join request", "error", err)
+		return nil, jsonrpc.NewError(jsonrpc.ErrorResultEncoding, "failed to convert join request", nil)
+	}
+
+	var status ktypes.JoinRequestStatus
+	switch outcome.Outcome {
+	case voting.OutcomeApproved:
+		status = ktypes.JoinRequestApproved
+	case voting.OutcomeRejected:
+		status = ktypes.JoinRequestRejected
+	case voting.OutcomeExpired:
+		status = ktypes.JoinRequestExpired
+	default:
+		svc.log.Error("unknown join request outcome", "outcome", outcome.Outcome)
+		return nil, jsonrpc.NewError(jsonrpc.ErrorResultEncoding, "failed to convert join request", nil)
+	}
+
+	return &adminjson.JoinStatusResponse{
+		JoinRequest: &adminjson.PendingJoin{
+			Candidate: body.PubKey,
+			Power:     body.Power,
+			ExpiresAt: outcome.ExpirationHeight,
+			Status:    status,
+		},
+	}, nil
+}
+
 func (svc *Service) Leave(ctx context.Context, req *adminjson.LeaveRequest) (*userjson.BroadcastResponse, *jsonrpc.Error) {
 	return svc.sendTx(ctx, &ktypes.ValidatorLeave{})
 }
 
+// RotateKey generates a new key for the node and broadcasts a transaction that
+// requests the other validators to replace the node's validator key with it.
+// The new key is saved to the configured key file, which must not already
+// exist, so the operator must move the key file from an earlier rotation
+// before requesting another. The node keeps signing with its current key, so the operator should
+// restart it with the new key once the update is approved. The request must be
+// confirmed.
+func (svc *Service) RotateKey(ctx context.Context, req *adminjson.RotateKeyRequest) (*adminjson.RotateKeyResponse, *jsonrpc.Error) {
+	if !req.Confirm {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidParams, "key rotation must be confirmed", nil)
+	}
+	if svc.keyFile == "" {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidRequest, "key rotation is not enabled", nil)
+	}
+
+	status, err := svc.blockchain.Status(ctx)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorNodeInternal, "node status unavailable", nil)
+	}
+	switch status.Validator.Role {
+	case nodetypes.RoleValidator.String():
+	case nodetypes.RoleLeader.String():
+		// The leader is identified by its key in the genesis config.
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidRequest, "the leader's key cannot be rotated", nil)
+	default:
+		return nil, jsonrpc.NewError(jsonrpc.ErrorValidatorNotFound, "node is not a validator", nil)
+	}
+
+	newKey, err := generateKey(svc.keyType)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInternal, "failed to generate key: "+err.Error(), nil)
+	}
+	payload, err := ktypes.NewValidatorKeyUpdate(svc.signer.Identity(), newKey)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInternal, "failed to sign key update: "+err.Error(), nil)
+	}
+
+	// Save the key before broadcasting so that an approved update never
+	// replaces the validator key with one that was lost.
+	if err = writeKeyFile(svc.keyFile, newKey); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidRequest,
+				fmt.Sprintf("key file %s already exists from an earlier rotation, move it elsewhere before rotating the key again", svc.keyFile), nil)
+		}
+		svc.log.Error("failed to save new key", "file", svc.keyFile, "error", err)
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInternal, "failed to save new key: "+err.Error(), nil)
+	}
+
+	res, jsonErr := svc.sendTx(ctx, payload)
+	if jsonErr != nil {
+		if err = os.Remove(svc.keyFile); err != nil {
+			svc.log.Warn("failed to remove unused key file", "file", svc.keyFile, "error", err)
+		}
+		return nil, jsonErr
+	}
+
+	svc.log.Info("requested validator key update", "new_pubkey", hex.EncodeToString(newKey.Public().Bytes()),
+		"file", svc.keyFile, "tx", res.TxHash.String())
+	return &adminjson.RotateKeyResponse{
+		TxHash:    res.TxHash,
+		NewPubKey: newKey.Public().Bytes(),
+		KeyFile:   svc.keyFile,
+	}, nil
+}
+
+func generateKey(keyType crypto.KeyType) (crypto.PrivateKey, error) {
+	switch keyType {
+	case crypto.KeyTypeSecp256k1:
+		privKey, _, err := crypto.GenerateSecp256k1Key(rand.Reader)
+		return privKey, err
+	case crypto.KeyTypeEd25519:
+		privKey, _, err := crypto.GenerateEd25519Key(rand.Reader)
+		return privKey, err
+	default:
+		return nil, fmt.Errorf("unsupported key type %d", keyType)
+	}
+}
+
+// writeKeyFile saves a private key as hex, in the same format as the key gen
+// command. It will not overwrite an existing file, which may hold a key from
+// an earlier rotation that is not yet in use.
+func writeKeyFile(file string, key crypto.PrivateKey) error {
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.WriteString(hex.EncodeToString(key.Bytes())); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
 func (svc *Service) ListValidators(ctx context.Context, req *adminjson.ListValidatorsRequest) (*adminjson.ListValidatorsResponse, *jsonrpc.Error) {
 	vals := svc.voting.GetValidators()
 
 	pbValidators := make([]*adminjson.Validator, len(vals))
 	for i, vi := range vals {
+		role := vi.Role
+		if role == "" && svc.leader != nil {
+			role = nodetypes.RoleValidator.String()
+			if bytes.Equal(vi.PubKey, svc.leader) {
+				role = nodetypes.RoleLeader.String()
+			}
+		}
 		pbValidators[i] = &adminjson.Validator{
-			Role:   vi.Role,
+			Role:   role,
 			PubKey: vi.PubKey,
 			Power:  vi.Power,
 		}
@@ -414,7 +996,7 @@ func (svc *Service) ListValidators(ctx context.Context, req *adminjson.ListValid
 
 func (svc *Service) ListPendingJoins(ctx context.Context, req *adminjson.ListJoinRequestsRequest) (*adminjson.ListJoinRequestsResponse, *jsonrpc.Error) {
 	readTx := svc.db.BeginDelayedReadTx()
-	defer readTx.Rollback(ctx)
+	defer rollback(ctx, readTx)
 
 	activeJoins, err := voting.GetResolutionsByType(ctx, readTx, voting.ValidatorJoinEventType)
 	if err != nil {
@@ -476,6 +1058,7 @@ func toPendingInfo(resolution *resolutions.Resolution, allVoters []*ktypes.Valid
 		ExpiresAt: resolution.ExpirationHeight,
 		Board:     board,
 		Approved:  approvals,
+		Status:    ktypes.JoinRequestPending,
 	}, nil
 }
 
@@ -490,9 +1073,23 @@ func (svc *Service) GetConfig(ctx context.Context, req *adminjson.GetConfigReque
 	}, nil
 }
 
+func (svc *Service) GetConfigJSON(ctx context.Context, req *adminjson.GetConfigJSONRequest) (*adminjson.GetConfigJSONResponse, *jsonrpc.Error) {
+	bts, err := svc.cfg.ToCanonicalJSON()
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorResultEncoding, "failed to encode node config", nil)
+	}
+
+	return &adminjson.GetConfigJSONResponse{
+		Config: bts,
+	}, nil
+}
+
 func (svc *Service) AddPeer(ctx context.Context, req *adminjson.PeerRequest) (*adminjson.PeerResponse, *jsonrpc.Error) {
+	if _, err := peers.ParsePeerAddr(req.PeerID); err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidParams, "invalid peer: "+err.Error(), nil)
+	}
 	err := svc.p2p.AddPeer(ctx, req.PeerID)
-	if err != nil {
+	if err != nil && !errors.Is(err, peers.ErrPeerExists) { // adding again is not an error
 		return nil, jsonrpc.NewError(jsonrpc.ErrorInternal, "failed to add a peer. Reason: "+err.Error(), nil)
 	}
 	return &adminjson.PeerResponse{}, nil
@@ -513,6 +1110,127 @@ func (svc *Service) ListPeers(ctx context.Context, req *adminjson.PeersRequest)
 	}, nil
 }
 
+func (svc *Service) ExportPeers(ctx context.Context, req *adminjson.ExportPeersRequest) (*adminjson.ExportPeersResponse, *jsonrpc.Error) {
+	return &adminjson.ExportPeersResponse{
+		Peers: svc.p2p.KnownPeers(ctx),
+	}, nil
+}
+
+// ImportPeers adds the peers in the request to the node's address book. Peers
+// that are already known and the node's own ID are skipped.
+func (svc *Service) ImportPeers(ctx context.Context, req *adminjson.ImportPeersRequest) (*adminjson.ImportPeersResponse, *jsonrpc.Error) {
+	self := svc.p2p.ID()
+	known := make(map[string]bool)
+	for _, p := range svc.p2p.KnownPeers(ctx) {
+		known[p.ID] = true
+	}
+
+	var added, skipped int
+	for _, p := range req.Peers {
+		if p == nil || p.ID == self || known[p.ID] || len(p.Addrs) == 0 {
+			skipped++
+			continue
+		}
+		known[p.ID] = true // also dedupe within the request
+
+		var ok bool
+		for _, addr := range p.Addrs {
+			if !strings.Contains(addr, "/p2p/") {
+				addr += "/p2p/" + p.ID
+			}
+			if err := svc.p2p.AddPeer(ctx, addr); err != nil && !errors.Is(err, peers.ErrPeerExists) {
+				svc.log.Warn("failed to import peer address", "peer", p.ID, "addr", addr, "error", err)
+				continue
+			}
+			ok = true
+		}
+		if ok {
+			added++
+		} else {
+			skipped++
+		}
+	}
+
+	return &adminjson.ImportPeersResponse{
+		Added:   added,
+		Skipped: skipped,
+	}, nil
+}
+
+func (svc *Service) PeerProtocols(ctx context.Context, req *adminjson.PeerRequest) (*adminjson.PeerProtocolsResponse, *jsonrpc.Error) {
+	supported, missing, err := svc.p2p.PeerProtocols(ctx, req.PeerID)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidParams, "failed to get peer protocols: "+err.Error(), nil)
+	}
+	return &adminjson.PeerProtocolsResponse{
+		Protocols: supported,
+		Missing:   missing,
+	}, nil
+}
+
+// ReloadAddrBook adds any new peers in the node's address book file, which may
+// have been edited while the node is running.
+func (svc *Service) ReloadAddrBook(ctx context.Context, req *adminjson.ReloadAddrBookRequest) (*adminjson.ReloadAddrBookResponse, *jsonrpc.Error) {
+	added, err := svc.p2p.ReloadAddrBook(ctx)
+	if err != nil {
+		svc.log.Error("failed to reload address book", "error", err)
+		return nil, jsonrpc.NewError(jsonrpc.ErrorNodeInternal, "failed to reload address book: "+err.Error(), nil)
+	}
+	return &adminjson.ReloadAddrBookResponse{
+		Added: added,
+	}, nil
+}
+
+// PeerMetrics returns the node's peer connection statistics.
+func (svc *Service) PeerMetrics(ctx context.Context, req *adminjson.PeerMetricsRequest) (*adminjson.PeerMetricsResponse, *jsonrpc.Error) {
+	return &adminjson.PeerMetricsResponse{
+		Metrics: svc.p2p.PeerMetrics(ctx),
+	}, nil
+}
+
+// PendingDials lists the node's in-progress outbound dials to peers.
+func (svc *Service) PendingDials(ctx context.Context, req *adminjson.PendingDialsRequest) (*adminjson.PendingDialsResponse, *jsonrpc.Error) {
+	return &adminjson.PendingDialsResponse{
+		Dials: svc.p2p.PendingDials(ctx),
+	}, nil
+}
+
+// CancelDial cancels the node's in-progress dials to a peer.
+func (svc *Service) CancelDial(ctx context.Context, req *adminjson.PeerRequest) (*adminjson.CancelDialResponse, *jsonrpc.Error) {
+	canceled, err := svc.p2p.CancelDial(ctx, req.PeerID)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidParams, "failed to cancel dial: "+err.Error(), nil)
+	}
+	return &adminjson.CancelDialResponse{
+		Canceled: canceled,
+	}, nil
+}
+
+// ReconnectPeer closes the node's connection to a peer and dials it again.
+func (svc *Service) ReconnectPeer(ctx context.Context, req *adminjson.PeerRequest) (*adminjson.ReconnectPeerResponse, *jsonrpc.Error) {
+	if _, err := peers.PubKeyFromPeerID(req.PeerID); err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidParams, "invalid peer ID: "+err.Error(), nil)
+	}
+	connectedness, err := svc.p2p.ReconnectPeer(ctx, req.PeerID)
+	if err != nil {
+		svc.log.Warn("failed to reconnect peer", "peer", req.PeerID, "connectedness", connectedness, "error", err)
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInternal, "failed to reconnect peer: "+err.Error(), nil)
+	}
+	return &adminjson.ReconnectPeerResponse{
+		Connectedness: connectedness,
+	}, nil
+}
+
+// Protocols lists the protocols that the node requires of its peers, and all
+// of the protocols that it supports.
+func (svc *Service) Protocols(ctx context.Context, req *adminjson.ProtocolsRequest) (*adminjson.ProtocolsResponse, *jsonrpc.Error) {
+	required, supported := svc.p2p.Protocols(ctx)
+	return &adminjson.ProtocolsResponse{
+		Required:  required,
+		Supported: supported,
+	}, nil
+}
+
 func (svc *Service) CreateResolution(ctx context.Context, req *adminjson.CreateResolutionRequest) (*userjson.BroadcastResponse, *jsonrpc.Error) {
 	res := &ktypes.CreateResolution{
 		Resolution: &ktypes.VotableEvent{
@@ -532,6 +1250,26 @@ func (svc *Service) ApproveResolution(ctx context.Context, req *adminjson.Approv
 	return svc.sendTx(ctx, res)
 }
 
+// Transfer transfers an amount from the node's account, which may be used by
+// an operator to fund accounts on a network without any other source of tokens.
+func (svc *Service) Transfer(ctx context.Context, req *adminjson.TransferRequest) (*userjson.BroadcastResponse, *jsonrpc.Error) {
+	amt, ok := new(big.Int).SetString(req.Amount, 10)
+	if !ok || amt.Sign() <= 0 {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidParams, "invalid transfer amount", nil)
+	}
+	if len(req.To) == 0 {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidParams, "missing recipient", nil)
+	}
+	if bytes.Equal(req.To, svc.signer.Identity()) {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidParams, "cannot transfer to the node's own account", nil)
+	}
+
+	return svc.sendTx(ctx, &ktypes.Transfer{
+		To:     req.To,
+		Amount: amt.String(),
+	})
+}
+
 /* disabled until the tx route is tested
 func (svc *Service) DeleteResolution(ctx context.Context, req *adminjson.DeleteResolutionRequest) (*userjson.BroadcastResponse, *jsonrpc.Error) {
 	res := &ktypes.DeleteResolution{
@@ -544,7 +1282,7 @@ func (svc *Service) DeleteResolution(ctx context.Context, req *adminjson.DeleteR
 
 func (svc *Service) ResolutionStatus(ctx context.Context, req *adminjson.ResolutionStatusRequest) (*adminjson.ResolutionStatusResponse, *jsonrpc.Error) {
 	readTx := svc.db.BeginDelayedReadTx()
-	defer readTx.Rollback(ctx)
+	defer rollback(ctx, readTx)
 
 	svc.voting.GetValidators()
 	uuid := req.ResolutionID
@@ -0,0 +1,762 @@
+package adminsvc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kwilteam/kwil-db/core/crypto"
+	"github.com/kwilteam/kwil-db/core/crypto/auth"
+	"github.com/kwilteam/kwil-db/core/log"
+	jsonrpc "github.com/kwilteam/kwil-db/core/rpc/json"
+	adminjson "github.com/kwilteam/kwil-db/core/rpc/json/admin"
+	ktypes "github.com/kwilteam/kwil-db/core/types"
+	types "github.com/kwilteam/kwil-db/core/types/admin"
+	"github.com/kwilteam/kwil-db/extensions/resolutions"
+	"github.com/kwilteam/kwil-db/node/peers"
+	rpcserver "github.com/kwilteam/kwil-db/node/services/jsonrpc"
+	nodetypes "github.com/kwilteam/kwil-db/node/types"
+	"github.com/kwilteam/kwil-db/node/types/sql"
+	"github.com/kwilteam/kwil-db/node/voting"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mockNode struct {
+	status *types.Status
+	peers  []*types.PeerInfo
+	txs    []*ktypes.Transaction // broadcasted
+
+	best        int64   // for ResetToHeight
+	resetHeight []int64 // from ResetToHeight
+}
+
+func (m *mockNode) Status(context.Context) (*types.Status, error) {
+	return m.status, nil
+}
+
+func (m *mockNode) Peers(context.Context) ([]*types.PeerInfo, error) {
+	return m.peers, nil
+}
+
+func (m *mockNode) BroadcastTx(ctx context.Context, tx *ktypes.Transaction, sync uint8) (*ktypes.ResultBroadcastTx, error) {
+	m.txs = append(m.txs, tx)
+	hash, err := tx.Hash()
+	if err != nil {
+		return nil, err
+	}
+	return &ktypes.ResultBroadcastTx{Hash: hash}, nil
+}
+
+func (m *mockNode) Shutdown(context.Context) error {
+	return nil
+}
+
+func (m *mockNode) ResetToHeight(_ context.Context, height int64) error {
+	if height > m.best {
+		return errors.New("height is in the future")
+	}
+	m.resetHeight = append(m.resetHeight, height)
+	return nil
+}
+
+type mockApp struct {
+	nonce int64
+}
+
+func (m *mockApp) AccountInfo(ctx context.Context, db sql.DB, identifier []byte, unconfirmed bool) (*big.Int, int64, bool, error) {
+	return big.NewInt(1000), m.nonce, m.nonce > 0, nil
+}
+
+func (m *mockApp) Price(ctx context.Context, db sql.DB, tx *ktypes.Transaction) (*big.Int, error) {
+	return big.NewInt(10), nil
+}
+
+type mockDB struct{}
+
+func (mockDB) BeginDelayedReadTx() sql.OuterReadTx {
+	return mockReadTx{}
+}
+
+// mockReadTx is a read transaction that is never used by the mock app.
+type mockReadTx struct {
+	sql.OuterReadTx
+}
+
+func (mockReadTx) Rollback(context.Context) error { return nil }
+
+func TestStatus(t *testing.T) {
+	startTime := time.Now().Add(-time.Hour)
+	node := &mockNode{
+		status: &types.Status{
+			Node: &types.NodeInfo{ChainID: "kwil-test-chain"},
+			Sync: &types.SyncInfo{BestBlockHeight: 42},
+			Validator: &types.ValidatorInfo{
+				Role: nodetypes.RoleSentry.String(),
+			},
+			StartTime: startTime,
+		},
+		peers: []*types.PeerInfo{
+			{NodeInfo: &types.NodeInfo{}, RemoteAddr: "127.0.0.1:6600"},
+			{NodeInfo: &types.NodeInfo{}, RemoteAddr: "127.0.0.2:6600"},
+		},
+	}
+
+	svc := NewService(nil, node, nil, nil, nil, nil, nil, "kwil-test-chain", log.DiscardLogger)
+
+	resp, jsonErr := svc.Status(context.Background(), &adminjson.StatusRequest{})
+	require.Nil(t, jsonErr)
+
+	require.Equal(t, "kwil-test-chain", resp.Node.ChainID)
+	require.Equal(t, int64(42), resp.Sync.BestBlockHeight)
+	require.Equal(t, 2, resp.NumPeers)
+	require.Equal(t, startTime.UnixMilli(), resp.StartTime)
+	require.GreaterOrEqual(t, resp.Uptime, time.Hour.Milliseconds())
+}
+
+func TestStatusValidatorPower(t *testing.T) {
+	pubKey := []byte("validator 1")
+	node := &mockNode{
+		status: &types.Status{
+			Node: &types.NodeInfo{ChainID: "kwil-test-chain"},
+			Sync: &types.SyncInfo{},
+			Validator: &types.ValidatorInfo{
+				Role:   nodetypes.RoleValidator.String(),
+				PubKey: pubKey,
+			},
+		},
+	}
+	vals := &mockValidators{vals: []*ktypes.Validator{{PubKey: []byte("validator 0"), Power: 1}, {PubKey: pubKey, Power: 3}}}
+	svc := NewService(nil, node, nil, vals, nil, nil, nil, "kwil-test-chain", log.DiscardLogger)
+
+	resp, jsonErr := svc.Status(context.Background(), &adminjson.StatusRequest{})
+	require.Nil(t, jsonErr)
+	require.Equal(t, int64(3), resp.Validator.Power)
+	require.Equal(t, 1, vals.bulkLookups)
+
+	// Listing validators and the health check read the whole set, without
+	// any lookups by key.
+	list, jsonErr := svc.ListValidators(context.Background(), &adminjson.ListValidatorsRequest{})
+	require.Nil(t, jsonErr)
+	require.Len(t, list.Validators, 2)
+	health, jsonErr := svc.HealthMethod(context.Background(), nil)
+	require.Nil(t, jsonErr)
+	require.Equal(t, 2, health.NumValidators)
+	require.Equal(t, 1, vals.bulkLookups)
+}
+
+func TestPeersConnAge(t *testing.T) {
+	connectedAt := time.Now().Add(-time.Minute)
+	node := &mockNode{
+		peers: []*types.PeerInfo{
+			{NodeInfo: &types.NodeInfo{}, RemoteAddr: "127.0.0.1:6600", ConnectedAt: connectedAt.UnixMilli()},
+			{NodeInfo: &types.NodeInfo{}, RemoteAddr: "127.0.0.2:6600"}, // not known
+		},
+	}
+
+	svc := NewService(nil, node, nil, nil, nil, nil, nil, "kwil-test-chain", log.DiscardLogger)
+
+	resp, jsonErr := svc.Peers(context.Background(), &adminjson.PeersRequest{})
+	require.Nil(t, jsonErr)
+	require.Len(t, resp.Peers, 2)
+	require.Equal(t, connectedAt.UnixMilli(), resp.Peers[0].ConnectedAt)
+	age := resp.Peers[0].ConnAge
+	require.GreaterOrEqual(t, age, time.Minute.Milliseconds())
+	require.Zero(t, resp.Peers[1].ConnAge)
+
+	// The age increases while the connection stays open.
+	time.Sleep(5 * time.Millisecond)
+	resp, jsonErr = svc.Peers(context.Background(), &adminjson.PeersRequest{})
+	require.Nil(t, jsonErr)
+	require.Equal(t, connectedAt.UnixMilli(), resp.Peers[0].ConnectedAt)
+	require.Greater(t, resp.Peers[0].ConnAge, age)
+	require.Zero(t, node.peers[0].ConnAge, "node's peer info modified")
+}
+
+func TestSignedRequests(t *testing.T) {
+	node := &mockNode{
+		status: &types.Status{
+			Node:      &types.NodeInfo{ChainID: "kwil-test-chain"},
+			Sync:      &types.SyncInfo{},
+			Validator: &types.ValidatorInfo{Role: nodetypes.RoleSentry.String()},
+		},
+	}
+
+	nodeKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+	allowedKey, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	otherKey, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+
+	svc := NewService(nil, node, nil, nil, nil, nil, nil, "kwil-test-chain", log.DiscardLogger,
+		WithSignedRequests(nodeKey.Public(), allowedKey.Public().(*crypto.Ed25519PublicKey)))
+
+	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"admin.status","params":{}}`)
+	sign := func(t *testing.T, key crypto.PrivateKey) *rpcserver.RequestSignature {
+		timestamp := time.Now().UnixMilli()
+		sig, err := key.Sign(jsonrpc.SignedRequestMessage(timestamp, body))
+		require.NoError(t, err)
+		return &rpcserver.RequestSignature{
+			Signer:    key.Public().Bytes(),
+			Signature: sig,
+			Timestamp: timestamp,
+			Body:      body,
+		}
+	}
+
+	callStatus := func(sig *rpcserver.RequestSignature) *jsonrpc.Error {
+		ctx := context.Background()
+		if sig != nil {
+			ctx = context.WithValue(ctx, rpcserver.RequestSignatureCtx, sig)
+		}
+		_, handler := svc.Methods()[adminjson.MethodStatus].Handler(ctx, nil)
+		_, jsonErr := handler()
+		return jsonErr
+	}
+
+	t.Run("allowed signer", func(t *testing.T) {
+		require.Nil(t, callStatus(sign(t, allowedKey)))
+	})
+
+	t.Run("node key", func(t *testing.T) {
+		require.Nil(t, callStatus(sign(t, nodeKey)))
+	})
+
+	t.Run("disallowed signer", func(t *testing.T) {
+		jsonErr := callStatus(sign(t, otherKey))
+		require.NotNil(t, jsonErr)
+		require.Equal(t, jsonrpc.ErrorUnauthorized, jsonErr.Code)
+	})
+
+	t.Run("missing signature", func(t *testing.T) {
+		jsonErr := callStatus(nil)
+		require.NotNil(t, jsonErr)
+		require.Equal(t, jsonrpc.ErrorUnauthorized, jsonErr.Code)
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		sig := sign(t, allowedKey)
+		sig.Body = []byte(`{"jsonrpc":"2.0","id":1,"method":"admin.join","params":{}}`)
+		jsonErr := callStatus(sig)
+		require.NotNil(t, jsonErr)
+		require.Equal(t, jsonrpc.ErrorUnauthorized, jsonErr.Code)
+	})
+}
+
+func TestOpenReads(t *testing.T) {
+	node := &mockNode{
+		status: &types.Status{
+			Node:      &types.NodeInfo{ChainID: "kwil-test-chain"},
+			Sync:      &types.SyncInfo{},
+			Validator: &types.ValidatorInfo{Role: nodetypes.RoleSentry.String()},
+		},
+	}
+
+	nodeKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+	allowedKey, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	otherKey, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+
+	svc := NewService(nil, node, nil, nil, nil, nil, nil, "kwil-test-chain", log.DiscardLogger,
+		WithSignedRequests(nodeKey.Public(), allowedKey.Public().(*crypto.Ed25519PublicKey)),
+		WithOpenReads())
+
+	call := func(t *testing.T, method jsonrpc.Method, key crypto.PrivateKey) *jsonrpc.Error {
+		ctx := context.Background()
+		if key != nil {
+			body := []byte(`{"jsonrpc":"2.0","id":1,"method":"` + string(method) + `","params":{}}`)
+			timestamp := time.Now().UnixMilli()
+			sig, err := key.Sign(jsonrpc.SignedRequestMessage(timestamp, body))
+			require.NoError(t, err)
+			ctx = context.WithValue(ctx, rpcserver.RequestSignatureCtx, &rpcserver.RequestSignature{
+				Signer:    key.Public().Bytes(),
+				Signature: sig,
+				Timestamp: timestamp,
+				Body:      body,
+			})
+		}
+		_, handler := svc.Methods()[method].Handler(ctx, nil)
+		_, jsonErr := handler()
+		return jsonErr
+	}
+
+	t.Run("read-only method is open", func(t *testing.T) {
+		require.Nil(t, call(t, adminjson.MethodStatus, nil))
+		require.Nil(t, call(t, adminjson.MethodStatus, otherKey))
+	})
+
+	t.Run("mutating method authorized", func(t *testing.T) {
+		require.Nil(t, call(t, adminjson.MethodShutdown, allowedKey))
+	})
+
+	t.Run("mutating method unauthorized", func(t *testing.T) {
+		jsonErr := call(t, adminjson.MethodShutdown, otherKey)
+		require.NotNil(t, jsonErr)
+		require.Equal(t, jsonrpc.ErrorUnauthorized, jsonErr.Code)
+
+		jsonErr = call(t, adminjson.MethodShutdown, nil)
+		require.NotNil(t, jsonErr)
+		require.Equal(t, jsonrpc.ErrorUnauthorized, jsonErr.Code)
+	})
+
+	t.Run("config is not open", func(t *testing.T) {
+		jsonErr := call(t, adminjson.MethodConfig, nil)
+		require.NotNil(t, jsonErr)
+		require.Equal(t, jsonrpc.ErrorUnauthorized, jsonErr.Code)
+	})
+}
+
+func TestTransfer(t *testing.T) {
+	node := &mockNode{}
+	nodeKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+	signer := auth.GetNodeSigner(nodeKey)
+
+	svc := NewService(mockDB{}, node, &mockApp{nonce: 4}, nil, nil, signer, nil, "kwil-test-chain", log.DiscardLogger)
+	ctx := context.Background()
+	to := []byte("recipient")
+
+	resp, jsonErr := svc.Transfer(ctx, &adminjson.TransferRequest{To: to, Amount: "100"})
+	require.Nil(t, jsonErr)
+	require.Len(t, node.txs, 1)
+	tx := node.txs[0]
+	hash, err := tx.Hash()
+	require.NoError(t, err)
+	require.Equal(t, hash, resp.TxHash)
+	require.Equal(t, ktypes.PayloadTypeTransfer, tx.Body.PayloadType)
+	require.EqualValues(t, 5, tx.Body.Nonce)
+	require.Equal(t, big.NewInt(10), tx.Body.Fee)
+	require.Equal(t, "kwil-test-chain", tx.Body.ChainID)
+	require.EqualValues(t, signer.Identity(), tx.Sender)
+
+	var transfer ktypes.Transfer
+	require.NoError(t, transfer.UnmarshalBinary(tx.Body.Payload))
+	require.Equal(t, to, transfer.To)
+	require.Equal(t, "100", transfer.Amount)
+
+	for _, req := range []*adminjson.TransferRequest{
+		{To: to, Amount: "0"},
+		{To: to, Amount: "-1"},
+		{To: to, Amount: "abc"},
+		{Amount: "100"},
+		{To: signer.Identity(), Amount: "100"},
+	} {
+		_, jsonErr = svc.Transfer(ctx, req)
+		require.NotNil(t, jsonErr)
+		require.Equal(t, jsonrpc.ErrorInvalidParams, jsonErr.Code)
+	}
+	require.Len(t, node.txs, 1)
+}
+
+// mockP2P is a P2P that keeps a set of added peers. Methods other than AddPeer
+// will panic.
+type mockP2P struct {
+	P2P
+	peers  map[string]bool
+	addErr error // returned by AddPeer if set
+}
+
+func (m *mockP2P) AddPeer(_ context.Context, nodeID string) error {
+	if m.addErr != nil {
+		return m.addErr
+	}
+	if m.peers[nodeID] {
+		return peers.ErrPeerExists
+	}
+	m.peers[nodeID] = true
+	return nil
+}
+
+func TestAddPeer(t *testing.T) {
+	p2p := &mockP2P{peers: make(map[string]bool)}
+	svc := NewService(mockDB{}, &mockNode{}, nil, nil, p2p, nil, nil, "kwil-test-chain", log.DiscardLogger)
+	ctx := context.Background()
+
+	const peerID = "16Uiu2HAm8iRUsTzYepLP8pdJL3645ACP7VBfZQ7yFbLfdb7WvkL7"
+	maddr := "/ip4/127.0.0.1/tcp/6600/p2p/" + peerID
+
+	t.Run("multiaddr", func(t *testing.T) {
+		_, jsonErr := svc.AddPeer(ctx, &adminjson.PeerRequest{PeerID: maddr})
+		require.Nil(t, jsonErr)
+		require.True(t, p2p.peers[maddr])
+	})
+
+	t.Run("peer ID", func(t *testing.T) {
+		_, jsonErr := svc.AddPeer(ctx, &adminjson.PeerRequest{PeerID: peerID})
+		require.Nil(t, jsonErr)
+		require.True(t, p2p.peers[peerID])
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		for _, addr := range []string{
+			"",
+			"not-a-peer",
+			"/ip4/127.0.0.1/tcp/6600",              // no peer ID
+			"/ip4/127.0.0.1/tcp/abc/p2p/" + peerID, // bad port
+			peerID[:20],
+		} {
+			_, jsonErr := svc.AddPeer(ctx, &adminjson.PeerRequest{PeerID: addr})
+			require.NotNil(t, jsonErr, addr)
+			require.Equal(t, jsonrpc.ErrorInvalidParams, jsonErr.Code, addr)
+			require.False(t, p2p.peers[addr])
+		}
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		require.ErrorIs(t, p2p.AddPeer(ctx, maddr), peers.ErrPeerExists)
+		_, jsonErr := svc.AddPeer(ctx, &adminjson.PeerRequest{PeerID: maddr})
+		require.Nil(t, jsonErr)
+		require.Len(t, p2p.peers, 2)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		p2p.addErr = errors.New("address book not writable")
+		defer func() { p2p.addErr = nil }()
+		_, jsonErr := svc.AddPeer(ctx, &adminjson.PeerRequest{PeerID: peerID})
+		require.NotNil(t, jsonErr)
+		require.Equal(t, jsonrpc.ErrorInternal, jsonErr.Code)
+	})
+}
+
+func TestReconnectPeerMalformed(t *testing.T) {
+	// mockP2P panics on ReconnectPeer, so the peer ID must be rejected first.
+	svc := NewService(mockDB{}, &mockNode{}, nil, nil, &mockP2P{}, nil, nil, "kwil-test-chain", log.DiscardLogger)
+
+	const peerID = "16Uiu2HAm8iRUsTzYepLP8pdJL3645ACP7VBfZQ7yFbLfdb7WvkL7"
+	for _, id := range []string{"", "not-a-peer", peerID[:20], "/ip4/127.0.0.1/tcp/6600/p2p/" + peerID} {
+		_, jsonErr := svc.ReconnectPeer(context.Background(), &adminjson.PeerRequest{PeerID: id})
+		require.NotNil(t, jsonErr, id)
+		require.Equal(t, jsonrpc.ErrorInvalidParams, jsonErr.Code, id)
+	}
+}
+
+// mockValidators panics on GetValidatorPower, so tests fail if the service
+// looks up validators one at a time.
+type mockValidators struct {
+	Validators
+	vals []*ktypes.Validator
+
+	bulkLookups int // GetValidatorPowers calls
+}
+
+func (m *mockValidators) GetValidators() []*ktypes.Validator {
+	return m.vals
+}
+
+func (m *mockValidators) GetValidatorPowers(_ context.Context, pubKeys [][]byte) (map[string]int64, error) {
+	m.bulkLookups++
+	powers := make(map[string]int64)
+	for _, pubKey := range pubKeys {
+		for _, v := range m.vals {
+			if bytes.Equal(v.PubKey, pubKey) {
+				powers[string(pubKey)] = v.Power
+			}
+		}
+	}
+	return powers, nil
+}
+
+func TestJoinStatus(t *testing.T) {
+	pending := []byte("pending candidate")
+	expired := []byte("expired candidate")
+	val1, val2 := []byte("validator 1"), []byte("validator 2")
+	resID := ktypes.NewUUIDV5([]byte("join"))
+
+	joinBody := func(candidate []byte, power int64) []byte {
+		body, err := (&voting.UpdatePowerRequest{PubKey: candidate, Power: power}).MarshalBinary()
+		require.NoError(t, err)
+		return body
+	}
+
+	origIDs, origInfo, origLatest := getResolutionIDsByTypeAndProposer, getResolutionInfo, getLatestOutcome
+	defer func() {
+		getResolutionIDsByTypeAndProposer, getResolutionInfo, getLatestOutcome = origIDs, origInfo, origLatest
+	}()
+
+	getResolutionIDsByTypeAndProposer = func(_ context.Context, _ sql.Executor, resType string, proposer []byte) ([]*ktypes.UUID, error) {
+		require.Equal(t, voting.ValidatorJoinEventType, resType)
+		if string(proposer) == string(pending) {
+			return []*ktypes.UUID{resID}, nil
+		}
+		return nil, nil
+	}
+	getResolutionInfo = func(_ context.Context, _ sql.Executor, id *ktypes.UUID) (*resolutions.Resolution, error) {
+		require.Equal(t, resID, id)
+		return &resolutions.Resolution{
+			ID:               id,
+			Body:             joinBody(pending, 10),
+			Type:             voting.ValidatorJoinEventType,
+			ExpirationHeight: 100,
+			Voters:           []*ktypes.Validator{{PubKey: val2, Power: 1}},
+			Proposer:         pending,
+		}, nil
+	}
+	getLatestOutcome = func(_ context.Context, _ sql.Executor, resType string, proposer []byte) (*voting.ResolutionOutcome, error) {
+		require.Equal(t, voting.ValidatorJoinEventType, resType)
+		if string(proposer) == string(expired) {
+			return &voting.ResolutionOutcome{
+				ID:               resID,
+				Body:             joinBody(expired, 20),
+				ExpirationHeight: 50,
+				Outcome:          voting.OutcomeExpired,
+				Height:           50,
+			}, nil
+		}
+		return nil, nil
+	}
+
+	vals := &mockValidators{vals: []*ktypes.Validator{{PubKey: val1, Power: 1}, {PubKey: val2, Power: 1}}}
+	svc := NewService(mockDB{}, &mockNode{}, nil, vals, nil, nil, nil, "kwil-test-chain", log.DiscardLogger)
+	ctx := context.Background()
+
+	t.Run("pending", func(t *testing.T) {
+		resp, jsonErr := svc.JoinStatus(ctx, &adminjson.JoinStatusRequest{PubKey: pending})
+		require.Nil(t, jsonErr)
+		require.Equal(t, &adminjson.PendingJoin{
+			Candidate: pending,
+			Power:     10,
+			ExpiresAt: 100,
+			Board:     [][]byte{val2, val1},
+			Approved:  []bool{true, false},
+			Status:    ktypes.JoinRequestPending,
+		}, resp.JoinRequest)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		resp, jsonErr := svc.JoinStatus(ctx, &adminjson.JoinStatusRequest{PubKey: expired})
+		require.Nil(t, jsonErr)
+		require.Equal(t, &adminjson.PendingJoin{
+			Candidate: expired,
+			Power:     20,
+			ExpiresAt: 50,
+			Status:    ktypes.JoinRequestExpired,
+		}, resp.JoinRequest)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, jsonErr := svc.JoinStatus(ctx, &adminjson.JoinStatusRequest{PubKey: []byte("unknown")})
+		require.NotNil(t, jsonErr)
+		require.Equal(t, jsonrpc.ErrorValidatorNotFound, jsonErr.Code)
+	})
+}
+
+// slowDB is a DB with read transactions that block until their context is
+// canceled.
+type slowDB struct{}
+
+func (slowDB) BeginDelayedReadTx() sql.OuterReadTx {
+	return slowReadTx{}
+}
+
+type slowReadTx struct {
+	mockReadTx
+}
+
+func (slowReadTx) Execute(ctx context.Context, _ string, _ ...any) (*sql.ResultSet, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// slowNode is a mockNode that takes a while to reset.
+type slowNode struct {
+	mockNode
+	delay time.Duration
+}
+
+func (m *slowNode) ResetToHeight(ctx context.Context, height int64) error {
+	time.Sleep(m.delay)
+	return m.mockNode.ResetToHeight(ctx, height)
+}
+
+func TestTimeout(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+	svc := NewService(slowDB{}, nil, nil, nil, nil, nil, nil, "kwil-test-chain",
+		log.DiscardLogger, WithTimeout(timeout))
+
+	_, handler := svc.Methods()[adminjson.MethodValListJoins].Handler(context.Background(), nil)
+	start := time.Now()
+	_, jsonErr := handler()
+	require.NotNil(t, jsonErr)
+	require.Equal(t, jsonrpc.ErrorNodeInternal, jsonErr.Code)
+	require.Contains(t, jsonErr.Message, "timed out")
+	require.Less(t, time.Since(start), 10*timeout)
+
+	t.Run("hung handler", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		h := withTimeout(timeout, rpcserver.MakeMethodHandler(func(context.Context, *adminjson.StatusRequest) (*adminjson.StatusResponse, *jsonrpc.Error) {
+			<-release // ignores the context
+			return &adminjson.StatusResponse{}, nil
+		}))
+		_, handler := h(context.Background(), nil)
+		_, jsonErr := handler()
+		require.NotNil(t, jsonErr)
+		require.Equal(t, jsonrpc.ErrorNodeInternal, jsonErr.Code)
+	})
+
+	t.Run("starts with the handler", func(t *testing.T) {
+		h := withTimeout(timeout, rpcserver.MakeMethodHandler(func(ctx context.Context, _ *adminjson.StatusRequest) (*adminjson.StatusResponse, *jsonrpc.Error) {
+			if ctx.Err() != nil {
+				return nil, jsonrpc.NewError(jsonrpc.ErrorNodeInternal, ctx.Err().Error(), nil)
+			}
+			return &adminjson.StatusResponse{}, nil
+		}))
+		_, handler := h(context.Background(), nil)
+		time.Sleep(2 * timeout) // e.g. decoding params
+		resp, jsonErr := handler()
+		require.Nil(t, jsonErr)
+		require.NotNil(t, resp)
+	})
+
+	t.Run("write methods", func(t *testing.T) {
+		node := &slowNode{delay: 3 * timeout}
+		svc := NewService(slowDB{}, node, nil, nil, nil, nil, nil, "kwil-test-chain",
+			log.DiscardLogger, WithTimeout(timeout))
+		argsPtr, handler := svc.Methods()[adminjson.MethodResetToHeight].Handler(context.Background(), nil)
+		argsPtr.(*adminjson.ResetToHeightRequest).Confirm = true
+		_, jsonErr := handler()
+		require.Nil(t, jsonErr) // not canceled while resetting
+		require.Equal(t, []int64{0}, node.resetHeight)
+	})
+
+	t.Run("no timeout", func(t *testing.T) {
+		svc := NewService(slowDB{}, nil, nil, nil, nil, nil, nil, "kwil-test-chain",
+			log.DiscardLogger, WithTimeout(0))
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		_, handler := svc.Methods()[adminjson.MethodValListJoins].Handler(ctx, nil)
+		_, jsonErr := handler()
+		require.NotNil(t, jsonErr)
+		require.Equal(t, jsonrpc.ErrorDBInternal, jsonErr.Code) // not wrapped
+	})
+}
+
+func TestResetToHeight(t *testing.T) {
+	node := &mockNode{best: 10}
+	svc := NewService(nil, node, nil, nil, nil, nil, nil, "kwil-test-chain", log.DiscardLogger)
+	ctx := context.Background()
+
+	t.Run("not confirmed", func(t *testing.T) {
+		_, jsonErr := svc.ResetToHeight(ctx, &adminjson.ResetToHeightRequest{Height: 10})
+		require.NotNil(t, jsonErr)
+		require.Equal(t, jsonrpc.ErrorInvalidParams, jsonErr.Code)
+		require.Empty(t, node.resetHeight)
+	})
+
+	t.Run("future height", func(t *testing.T) {
+		_, jsonErr := svc.ResetToHeight(ctx, &adminjson.ResetToHeightRequest{Height: 11, Confirm: true})
+		require.NotNil(t, jsonErr)
+		require.Contains(t, jsonErr.Message, "future")
+		require.Empty(t, node.resetHeight)
+	})
+
+	t.Run("valid reset", func(t *testing.T) {
+		resp, jsonErr := svc.ResetToHeight(ctx, &adminjson.ResetToHeightRequest{Height: 10, Confirm: true})
+		require.Nil(t, jsonErr)
+		require.Equal(t, int64(10), resp.Height)
+		require.Equal(t, []int64{10}, node.resetHeight)
+	})
+
+	t.Run("earlier height", func(t *testing.T) {
+		resp, jsonErr := svc.ResetToHeight(ctx, &adminjson.ResetToHeightRequest{Height: 8, Confirm: true})
+		require.Nil(t, jsonErr)
+		require.Equal(t, int64(8), resp.Height)
+		require.Equal(t, []int64{10, 8}, node.resetHeight)
+	})
+}
+
+func TestRotateKey(t *testing.T) {
+	nodeKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+	signer := auth.GetNodeSigner(nodeKey)
+	ctx := context.Background()
+
+	newSvc := func(role nodetypes.Role, keyFile string) (*Service, *mockNode) {
+		node := &mockNode{
+			status: &types.Status{
+				Validator: &types.ValidatorInfo{
+					Role:   role.String(),
+					PubKey: signer.Identity(),
+				},
+			},
+		}
+		svc := NewService(mockDB{}, node, &mockApp{nonce: 1}, nil, nil, signer, nil, "kwil-test-chain",
+			log.DiscardLogger, WithKeyRotation(keyFile, crypto.KeyTypeEd25519))
+		return svc, node
+	}
+
+	t.Run("validator", func(t *testing.T) {
+		keyFile := filepath.Join(t.TempDir(), "rotated_key")
+		svc, node := newSvc(nodetypes.RoleValidator, keyFile)
+
+		resp, jsonErr := svc.RotateKey(ctx, &adminjson.RotateKeyRequest{Confirm: true})
+		require.Nil(t, jsonErr)
+		require.Equal(t, keyFile, resp.KeyFile)
+
+		keyHex, err := os.ReadFile(keyFile)
+		require.NoError(t, err)
+		keyBts, err := hex.DecodeString(string(keyHex))
+		require.NoError(t, err)
+		newKey, err := crypto.UnmarshalEd25519PrivateKey(keyBts)
+		require.NoError(t, err)
+		require.EqualValues(t, newKey.Public().Bytes(), resp.NewPubKey)
+
+		require.Len(t, node.txs, 1)
+		tx := node.txs[0]
+		require.Equal(t, ktypes.PayloadTypeValidatorKeyUpdate, tx.Body.PayloadType)
+		require.EqualValues(t, signer.Identity(), tx.Sender)
+		var update ktypes.ValidatorKeyUpdate
+		require.NoError(t, update.UnmarshalBinary(tx.Body.Payload))
+		require.EqualValues(t, resp.NewPubKey, update.NewPubKey)
+		require.NoError(t, update.Verify(signer.Identity()))
+
+		// The unused key from the first rotation is not overwritten.
+		_, jsonErr = svc.RotateKey(ctx, &adminjson.RotateKeyRequest{Confirm: true})
+		require.NotNil(t, jsonErr)
+		require.Equal(t, jsonrpc.ErrorInvalidRequest, jsonErr.Code)
+		require.Contains(t, jsonErr.Message, "already exists")
+		keyHex2, err := os.ReadFile(keyFile)
+		require.NoError(t, err)
+		require.Equal(t, keyHex, keyHex2)
+		require.Len(t, node.txs, 1)
+	})
+
+	for _, tc := range []struct {
+		name    string
+		role    nodetypes.Role
+		confirm bool
+		code    jsonrpc.ErrorCode
+	}{
+		{"not confirmed", nodetypes.RoleValidator, false, jsonrpc.ErrorInvalidParams},
+		{"not a validator", nodetypes.RoleSentry, true, jsonrpc.ErrorValidatorNotFound},
+		{"leader", nodetypes.RoleLeader, true, jsonrpc.ErrorInvalidRequest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			keyFile := filepath.Join(t.TempDir(), "rotated_key")
+			svc, node := newSvc(tc.role, keyFile)
+
+			_, jsonErr := svc.RotateKey(ctx, &adminjson.RotateKeyRequest{Confirm: tc.confirm})
+			require.NotNil(t, jsonErr)
+			require.Equal(t, tc.code, jsonErr.Code)
+			require.Empty(t, node.txs)
+			require.NoFileExists(t, keyFile)
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		svc, node := newSvc(nodetypes.RoleValidator, "")
+		_, jsonErr := svc.RotateKey(ctx, &adminjson.RotateKeyRequest{Confirm: true})
+		require.NotNil(t, jsonErr)
+		require.Equal(t, jsonrpc.ErrorInvalidRequest, jsonErr.Code)
+		require.Empty(t, node.txs)
+	})
+}
@@ -0,0 +1,67 @@
+package rpcserver
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	jsonrpc "github.com/kwilteam/kwil-db/core/rpc/json"
+)
+
+// RequestSignature is the signature of a JSON-RPC request as provided in the
+// HTTP request headers. The Server does not verify the signature. A service
+// that requires signed requests should retrieve it from the request context
+// with SignatureFromContext and verify it against the keys it trusts.
+type RequestSignature struct {
+	Signer    []byte // public key of the signer
+	Signature []byte
+	Timestamp int64 // unix milliseconds
+	Body      []byte
+}
+
+// Message returns the message that was signed.
+func (rs *RequestSignature) Message() []byte {
+	return jsonrpc.SignedRequestMessage(rs.Timestamp, rs.Body)
+}
+
+// SignatureFromContext returns the signature of the JSON-RPC request, or nil
+// if the request was not signed.
+func SignatureFromContext(ctx context.Context) *RequestSignature {
+	sig, _ := ctx.Value(RequestSignatureCtx).(*RequestSignature)
+	return sig
+}
+
+// requestSignature parses the signature headers of a request. If the request
+// is not signed, a nil signature and error are returned.
+func requestSignature(hdr http.Header, body []byte) (*RequestSignature, error) {
+	signerHex, sigHex := hdr.Get(jsonrpc.HeaderSigner), hdr.Get(jsonrpc.HeaderSignature)
+	if signerHex == "" && sigHex == "" {
+		return nil, nil
+	}
+	if signerHex == "" || sigHex == "" {
+		return nil, errors.New("incomplete request signature")
+	}
+
+	signer, err := hex.DecodeString(signerHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signer: %w", err)
+	}
+	sig, err := hex.DecodeString(sigHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+	timestamp, err := strconv.ParseInt(hdr.Get(jsonrpc.HeaderTimestamp), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature timestamp: %w", err)
+	}
+
+	return &RequestSignature{
+		Signer:    signer,
+		Signature: sig,
+		Timestamp: timestamp,
+		Body:      body,
+	}, nil
+}
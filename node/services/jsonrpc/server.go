@@ -25,6 +25,7 @@ import (
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/kwilteam/kwil-db/core/log"
 	jsonrpc "github.com/kwilteam/kwil-db/core/rpc/json"
@@ -39,12 +40,15 @@ const (
 
 	pathRPCV1  = "/rpc/v1"
 	pathSpecV1 = "/spec/v1"
+
+	pathMetrics = "/metrics"
 )
 
 type contextRPCKey string
 
 const (
-	RequestIPCtx contextRPCKey = "clientIP"
+	RequestIPCtx        contextRPCKey = "clientIP"
+	RequestSignatureCtx contextRPCKey = "signature"
 )
 
 // Server is a JSON-RPC server.
@@ -78,6 +82,7 @@ type serverConfig struct {
 	reqSzLimit int
 	proxyCount int
 	namespace  string
+	gatherer   prometheus.Gatherer
 }
 
 type Opt func(*serverConfig)
@@ -112,6 +117,15 @@ func WithMetricsNamespace(namespace string) Opt {
 	}
 }
 
+// WithMetricsEndpoint serves the metrics from the gatherer, such as a
+// prometheus.Registry, in the Prometheus text format at the /metrics path. If
+// the server requires a password with WithPass, so does the endpoint.
+func WithMetricsEndpoint(gatherer prometheus.Gatherer) Opt {
+	return func(c *serverConfig) {
+		c.gatherer = gatherer
+	}
+}
+
 // WithServerInfo sets the OpenRPC "info" section to use when serving the
 // OpenRPC JSON specification either via a spec REST endpoint or the
 // rpc.discover JSON-RPC method.
@@ -176,6 +190,8 @@ const (
 	defaultWriteTimeout = 45 * time.Second
 	// 4 MiB + overhead request size limit
 	defaultSzLimit = 1<<22 + 1<<14
+	// maxBatchSize is the maximum number of requests in a batch request.
+	maxBatchSize = 50
 )
 
 var (
@@ -329,6 +345,15 @@ func NewServer(addr string, log log.Logger, opts ...Opt) (*Server, error) {
 	healthHandler = recoverer(healthHandler, log)
 	mux.Handle(pathHealthV1, healthHandler)
 
+	// Prometheus metrics handler (GET)
+	if cfg.gatherer != nil {
+		var metricsHandler http.Handler
+		metricsHandler = promhttp.HandlerFor(cfg.gatherer, promhttp.HandlerOpts{})
+		metricsHandler = s.passHandler(metricsHandler)
+		metricsHandler = recoverer(metricsHandler, log)
+		mux.Handle(pathMetrics, metricsHandler)
+	}
+
 	// service specific health endpoint handler with wild card for service
 	var userHealthHandler http.Handler
 	userHealthHandler = http.HandlerFunc(s.handleSvcHealth)
@@ -425,6 +450,32 @@ func corsHandler(h http.Handler) http.Handler {
 	})
 }
 
+// checkPass reports whether the request has the password required by WithPass
+// in its basic auth header. It is true if no password is required.
+func (s *Server) checkPass(r *http.Request) bool {
+	if s.authSHA == nil {
+		return true
+	}
+	_, pass, haveAuth := r.BasicAuth() // r.Header.Get("Authorization")
+	if !haveAuth {
+		return false
+	}
+	// Reveal nothing about the configured pass in verification time.
+	authSHA := sha256.Sum256([]byte(pass))
+	return subtle.ConstantTimeCompare(s.authSHA, authSHA[:]) == 1
+}
+
+// passHandler rejects requests without the password required by WithPass.
+func (s *Server) passHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.checkPass(r) {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
 func reqCounter(h http.Handler, counter Metrics) http.Handler {
 	if counter == nil {
 		return h
@@ -623,18 +674,9 @@ func (s *Server) handlerJSONRPCV1(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	r.Close = true
 
-	if s.authSHA != nil {
-		_, pass, haveAuth := r.BasicAuth() // r.Header.Get("Authorization")
-		if !haveAuth {
-			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
-			return
-		}
-		// Reveal nothing about the configured pass in verification time.
-		authSHA := sha256.Sum256([]byte(pass))
-		if subtle.ConstantTimeCompare(s.authSHA, authSHA[:]) != 1 {
-			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
-			return
-		}
+	if !s.checkPass(r) {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
 	}
 
 	/* stricter and inline decoding
@@ -659,6 +701,12 @@ func (s *Server) handlerJSONRPCV1(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "error reading request body", http.StatusBadRequest)
 		return
 	}
+
+	if isBatch(body) {
+		s.processJSONRPCBatch(r, w, body)
+		return
+	}
+
 	req := new(jsonrpc.Request)
 	err = json.Unmarshal(body, req)
 	if err != nil {
@@ -667,7 +715,67 @@ func (s *Server) handlerJSONRPCV1(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.processJSONRPCRequest(r.Context(), w, req)
+	ctx := r.Context()
+	sig, err := requestSignature(r.Header, body)
+	if err != nil {
+		resp := jsonrpc.NewErrorResponse(req.ID, jsonrpc.NewError(jsonrpc.ErrorInvalidRequest, err.Error(), nil))
+		s.writeJSON(w, resp, http.StatusBadRequest)
+		return
+	}
+	if sig != nil {
+		ctx = context.WithValue(ctx, RequestSignatureCtx, sig)
+	}
+
+	s.processJSONRPCRequest(ctx, w, req)
+}
+
+// isBatch reports if a request body is a JSON array, which is a batch of
+// requests.
+func isBatch(body []byte) bool {
+	body = bytes.TrimLeft(body, " \t\r\n")
+	return len(body) > 0 && body[0] == '['
+}
+
+// processJSONRPCBatch handles a batch request, which is an array of requests
+// in one http request. The requests are handled in order, and the array of
+// their responses is written with an http OK status, since each response has
+// its own error. A signature applies to the whole batch.
+func (s *Server) processJSONRPCBatch(r *http.Request, w http.ResponseWriter, body []byte) {
+	var reqs []*jsonrpc.Request
+	if err := json.Unmarshal(body, &reqs); err != nil {
+		resp := jsonrpc.NewErrorResponse(-1, jsonrpc.NewError(jsonrpc.ErrorParse, "invalid batch request", nil))
+		s.writeJSON(w, resp, http.StatusBadRequest)
+		return
+	}
+	if len(reqs) == 0 || len(reqs) > maxBatchSize {
+		msg := fmt.Sprintf("batch must have 1 to %d requests", maxBatchSize)
+		resp := jsonrpc.NewErrorResponse(-1, jsonrpc.NewError(jsonrpc.ErrorInvalidRequest, msg, nil))
+		s.writeJSON(w, resp, http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	sig, err := requestSignature(r.Header, body)
+	if err != nil {
+		resp := jsonrpc.NewErrorResponse(-1, jsonrpc.NewError(jsonrpc.ErrorInvalidRequest, err.Error(), nil))
+		s.writeJSON(w, resp, http.StatusBadRequest)
+		return
+	}
+	if sig != nil {
+		ctx = context.WithValue(ctx, RequestSignatureCtx, sig)
+	}
+
+	resps := make([]*jsonrpc.Response, len(reqs))
+	for i, req := range reqs {
+		if req == nil { // a null element
+			rpcErr := jsonrpc.NewError(jsonrpc.ErrorInvalidRequest, "invalid json-rpc request object", nil)
+			resps[i] = jsonrpc.NewErrorResponse(nil, rpcErr)
+			continue
+		}
+		resps[i] = s.handleJSONRPCRequest(ctx, req)
+	}
+
+	s.writeJSON(w, resps, http.StatusOK)
 }
 
 // processRequest handles the jsonrpc.Request with handleRequest to call the
@@ -687,6 +795,8 @@ func (s *Server) processJSONRPCRequest(ctx context.Context, w http.ResponseWrite
 			statusCode = http.StatusNotFound // 404
 		case jsonrpc.ErrorInvalidParams, jsonrpc.ErrorInvalidRequest, jsonrpc.ErrorParse:
 			statusCode = http.StatusBadRequest // 400
+		case jsonrpc.ErrorUnauthorized:
+			statusCode = http.StatusUnauthorized // 401
 		case jsonrpc.ErrorInternal:
 			statusCode = http.StatusInternalServerError // 500
 		}
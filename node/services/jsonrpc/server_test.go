@@ -12,6 +12,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -219,3 +220,88 @@ func Test_options(t *testing.T) {
 		})
 	}
 }
+
+func Test_metricsEndpoint(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_counter"})
+	reg.MustRegister(counter)
+	counter.Inc()
+
+	get := func(t *testing.T, srv *Server, pass string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, pathMetrics, nil)
+		if pass != "" {
+			r.SetBasicAuth("", pass)
+		}
+		w := httptest.NewRecorder()
+		srv.srv.Handler.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		srv, err := NewServer("127.0.0.1:", log.DiscardLogger)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, get(t, srv, "").Code)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		srv, err := NewServer("127.0.0.1:", log.DiscardLogger, WithMetricsEndpoint(reg))
+		require.NoError(t, err)
+		w := get(t, srv, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "test_counter 1")
+	})
+
+	t.Run("password", func(t *testing.T) {
+		srv, err := NewServer("127.0.0.1:", log.DiscardLogger, WithMetricsEndpoint(reg), WithPass("secret"))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, get(t, srv, "").Code)
+		assert.Equal(t, http.StatusUnauthorized, get(t, srv, "wrong").Code)
+		assert.Equal(t, http.StatusOK, get(t, srv, "secret").Code)
+	})
+}
+
+func Test_batch(t *testing.T) {
+	srv, err := NewServer("127.0.0.1:", log.DiscardLogger)
+	require.NoError(t, err)
+	srv.RegisterMethodHandler(
+		"rpc.echo",
+		MakeMethodHandler(func(_ context.Context, req *string) (*string, *jsonrpc.Error) {
+			return req, nil
+		}),
+	)
+
+	post := func(body string) (int, []byte) {
+		r := httptest.NewRequest(http.MethodPost, pathRPCV1, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		srv.srv.Handler.ServeHTTP(w, r)
+		return w.Code, w.Body.Bytes()
+	}
+
+	code, body := post(` [{"jsonrpc":"2.0","id":1,"method":"rpc.echo","params":"a"},
+		{"jsonrpc":"2.0","id":2,"method":"rpc.nope"},
+		null,
+		{"jsonrpc":"2.0","id":"3","method":"rpc.echo","params":"c"}]`)
+	require.Equal(t, http.StatusOK, code)
+	var resps []*jsonrpc.Response
+	require.NoError(t, json.Unmarshal(body, &resps))
+	require.Len(t, resps, 4)
+	assert.Equal(t, float64(1), resps[0].ID)
+	assert.JSONEq(t, `"a"`, string(resps[0].Result))
+	require.NotNil(t, resps[1].Error)
+	assert.Equal(t, jsonrpc.ErrorUnknownMethod, resps[1].Error.Code)
+	require.NotNil(t, resps[2].Error)
+	assert.Equal(t, jsonrpc.ErrorInvalidRequest, resps[2].Error.Code)
+	assert.Equal(t, "3", resps[3].ID)
+	assert.JSONEq(t, `"c"`, string(resps[3].Result))
+
+	code, _ = post(`[]`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	tooMany := "[" + strings.Repeat(`{"jsonrpc":"2.0","id":1,"method":"rpc.echo","params":"a"},`, maxBatchSize) +
+		`{"jsonrpc":"2.0","id":1,"method":"rpc.echo","params":"a"}]`
+	code, _ = post(tooMany)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, _ = post(`[{"jsonrpc":"2.0",`)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
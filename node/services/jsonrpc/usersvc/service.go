@@ -7,6 +7,8 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -38,10 +40,12 @@ type BlockchainTransactor interface {
 	Peers(context.Context) ([]*adminTypes.PeerInfo, error)
 	BroadcastTx(ctx context.Context, tx *types.Transaction, sync uint8) (*types.ResultBroadcastTx, error)
 	TxQuery(ctx context.Context, hash types.Hash, prove bool) (*types.TxQueryResponse, error)
+	BlockByHeight(height int64) (types.Hash, *types.Block, types.Hash, error)
+	ContiguousNonce(acctID []byte, confirmed int64) int64
 }
 
 type NodeApp interface {
-	AccountInfo(ctx context.Context, db sql.DB, identifier []byte, pending bool) (balance *big.Int, nonce int64, err error)
+	AccountInfo(ctx context.Context, db sql.DB, identifier []byte, pending bool) (balance *big.Int, nonce int64, exists bool, err error)
 	Price(ctx context.Context, dbTx sql.DB, tx *types.Transaction) (*big.Int, error)
 	// GetMigrationMetadata(ctx context.Context) (*types.MigrationMetadata, error)
 }
@@ -136,6 +140,9 @@ const (
 	defaultChallengeExpiry    = 10 * time.Second // TODO: or maybe more?
 	defaultChallengeRateLimit = 10.0
 	defaultAgeThreshMilli     = 129_000 // two minutes
+
+	maxBlockHeaderWait      = 10 * time.Second
+	blockHeaderPollInterval = 100 * time.Millisecond
 )
 
 // NewService creates a new instance of the user RPC service.
@@ -187,7 +194,7 @@ func NewService(db DB, engine EngineReader, chainClient BlockchainTransactor,
 // or any other breaking changes.
 const (
 	apiVerMajor = 0
-	apiVerMinor = 2
+	apiVerMinor = 6
 	apiVerPatch = 0
 
 	serviceName = "user"
@@ -197,6 +204,14 @@ const (
 //
 // apiVerMinor = 2 indicates the presence of the migration, challenge, and
 // health methods added in Kwil v0.9
+//
+// apiVerMinor = 3 indicates the presence of the block_header method
+//
+// apiVerMinor = 4 indicates the presence of the account_nonces method
+//
+// apiVerMinor = 5 indicates the presence of the block method
+//
+// apiVerMinor = 6 indicates the presence of the databases_detailed method
 
 var (
 	apiVerSemver = fmt.Sprintf("%d.%d.%d", apiVerMajor, apiVerMinor, apiVerPatch)
@@ -290,6 +305,11 @@ func (svc *Service) Methods() map[jsonrpc.Method]rpcserver.MethodDef {
 			"get an account's status",
 			"balance and nonce of an accounts",
 		),
+		userjson.MethodAccountNonces: rpcserver.MakeMethodDef(
+			svc.AccountNonces,
+			"get an account's confirmed nonce and highest gapless pending nonce",
+			"the confirmed nonce and the highest pending nonce that follows it without a gap",
+		),
 		userjson.MethodBroadcast: rpcserver.MakeMethodDef(
 			svc.Broadcast,
 			"broadcast a transaction",
@@ -310,6 +330,11 @@ func (svc *Service) Methods() map[jsonrpc.Method]rpcserver.MethodDef {
 			"list databases",
 			"an array of matching databases",
 		),
+		userjson.MethodDatabasesDetailed: rpcserver.MakeMethodDef(
+			svc.ListDatabasesDetailed,
+			"list a page of databases with a summary of their schemas",
+			"a page of matching databases ordered by DBID, and the total number of matching databases",
+		),
 		userjson.MethodPing: rpcserver.MakeMethodDef(
 			svc.Ping,
 			"ping the server",
@@ -335,6 +360,16 @@ func (svc *Service) Methods() map[jsonrpc.Method]rpcserver.MethodDef {
 			"query for the status of a transaction",
 			"the execution status of a transaction",
 		),
+		userjson.MethodBlockHeader: rpcserver.MakeMethodDef(
+			svc.BlockHeader,
+			"get the header of the block at a height, optionally waiting for it to be committed",
+			"the block hash and header",
+		),
+		userjson.MethodBlock: rpcserver.MakeMethodDef(
+			svc.Block,
+			"get the full block at a height",
+			"the block hash, serialized block, and app hash",
+		),
 
 		// Migration methods
 		userjson.MethodListMigrations: rpcserver.MakeMethodDef(svc.ListPendingMigrations,
@@ -423,8 +458,32 @@ func (svc *Service) Broadcast(ctx context.Context, req *userjson.BroadcastReques
 	if req.Sync != nil {
 		sync = *req.Sync
 	}
+
+	// A conditional broadcast is rejected if the sender's confirmed nonce has
+	// advanced past what the client expected, so that it can refetch and
+	// retry rather than waste a signed transaction on a certain nonce error.
+	if req.ExpectedNonce != nil {
+		readTx := svc.db.BeginDelayedReadTx()
+		_, nonce, _, err := svc.nodeApp.AccountInfo(ctx, readTx, req.Tx.Sender, false)
+		readTx.Rollback(ctx)
+		if err != nil {
+			logger.Error("failed to get account info", "error", err)
+			return nil, jsonrpc.NewError(jsonrpc.ErrorAccountInternal, "account info error", nil)
+		}
+		if nonce > *req.ExpectedNonce {
+			return nil, jsonrpc.NewError(jsonrpc.ErrorTxNonceRaced,
+				fmt.Sprintf("account nonce %d advanced past expected nonce %d", nonce, *req.ExpectedNonce), nil)
+		}
+	}
+
 	res, err := svc.chainClient.BroadcastTx(ctx, req.Tx, uint8(sync))
 	if err != nil {
+		if txCode, ok := rejectionCode(err); ok {
+			// Rejected by mempool, e.g. for an insufficient fee.
+			logger.Info("transaction rejected", "error", err)
+			txHash, _ := req.Tx.Hash()
+			return nil, broadcastError(txCode, txHash, err.Error())
+		}
 		logger.Error("failed to broadcast tx", "error", err)
 		return nil, jsonrpc.NewError(jsonrpc.ErrorTxInternal, "failed to broadcast transaction", nil)
 	}
@@ -432,13 +491,7 @@ func (svc *Service) Broadcast(ctx context.Context, req *userjson.BroadcastReques
 	code, txHash := res.Code, res.Hash
 
 	if txCode := types.TxCode(code); txCode != types.CodeOk {
-		errData := &userjson.BroadcastError{
-			TxCode:  uint32(txCode), // e.g. invalid nonce, wrong chain, etc.
-			Hash:    txHash.String(),
-			Message: res.Log,
-		}
-		data, _ := json.Marshal(errData)
-		return nil, jsonrpc.NewError(jsonrpc.ErrorTxExecFailure, "broadcast error", data)
+		return nil, broadcastError(txCode, txHash, res.Log)
 	}
 
 	logger.Info("broadcast transaction", "TxHash", txHash.String(),
@@ -448,6 +501,42 @@ func (svc *Service) Broadcast(ctx context.Context, req *userjson.BroadcastReques
 	}, nil
 }
 
+// rejectionCodes are the transaction result codes for the errors with which a
+// transaction may be rejected before it enters mempool.
+var rejectionCodes = []struct {
+	err  error
+	code types.TxCode
+}{
+	{types.ErrWrongChain, types.CodeWrongChain},
+	{types.ErrInvalidNonce, types.CodeInvalidNonce},
+	{types.ErrInvalidAmount, types.CodeInvalidAmount},
+	{types.ErrInsufficientBalance, types.CodeInsufficientBalance},
+	{types.ErrInsufficientFee, types.CodeInsufficientFee},
+}
+
+// rejectionCode returns the transaction result code for an error from
+// BroadcastTx, if it is one of the rejectionCodes.
+func rejectionCode(err error) (types.TxCode, bool) {
+	for _, rc := range rejectionCodes {
+		if errors.Is(err, rc.err) {
+			return rc.code, true
+		}
+	}
+	return 0, false
+}
+
+// broadcastError is the error for a broadcast that was rejected with the given
+// transaction result code, e.g. invalid nonce, wrong chain, etc.
+func broadcastError(txCode types.TxCode, txHash types.Hash, msg string) *jsonrpc.Error {
+	errData := &userjson.BroadcastError{
+		TxCode:  uint32(txCode),
+		Hash:    txHash.String(),
+		Message: msg,
+	}
+	data, _ := json.Marshal(errData)
+	return jsonrpc.NewError(jsonrpc.ErrorTxExecFailure, "broadcast error", data)
+}
+
 /* Most broadcast capabilities are bytes, not an object. We should support the following:
 
 type BroadcastRawRequest struct {
@@ -554,13 +643,13 @@ func (svc *Service) Account(ctx context.Context, req *userjson.AccountRequest) (
 	readTx := svc.db.BeginDelayedReadTx()
 	defer readTx.Rollback(ctx)
 
-	balance, nonce, err := svc.nodeApp.AccountInfo(ctx, readTx, req.Identifier, uncommitted)
+	balance, nonce, exists, err := svc.nodeApp.AccountInfo(ctx, readTx, req.Identifier, uncommitted)
 	if err != nil {
 		return nil, jsonrpc.NewError(jsonrpc.ErrorAccountInternal, "account info error", nil)
 	}
 
 	ident := []byte(nil)
-	if nonce > 0 { // return nil pubkey for non-existent account
+	if exists { // return nil pubkey for non-existent account
 		ident = req.Identifier
 	}
 
@@ -571,6 +660,25 @@ func (svc *Service) Account(ctx context.Context, req *userjson.AccountRequest) (
 	}, nil
 }
 
+func (svc *Service) AccountNonces(ctx context.Context, req *userjson.AccountNoncesRequest) (*userjson.AccountNoncesResponse, *jsonrpc.Error) {
+	if len(req.Identifier) == 0 {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidParams, "missing account identifier", nil)
+	}
+
+	readTx := svc.db.BeginDelayedReadTx()
+	defer readTx.Rollback(ctx)
+
+	_, confirmed, _, err := svc.nodeApp.AccountInfo(ctx, readTx, req.Identifier, false)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorAccountInternal, "account info error", nil)
+	}
+
+	return &userjson.AccountNoncesResponse{
+		Confirmed: confirmed,
+		Pending:   svc.chainClient.ContiguousNonce(req.Identifier, confirmed),
+	}, nil
+}
+
 func (svc *Service) Ping(ctx context.Context, req *userjson.PingRequest) (*userjson.PingResponse, *jsonrpc.Error) {
 	return &userjson.PingResponse{
 		Message: "pong",
@@ -598,6 +706,55 @@ func (svc *Service) ListDatabases(ctx context.Context, req *userjson.ListDatabas
 	}, nil
 }
 
+// ListDatabasesDetailed lists a page of the databases with the sizes of their
+// schemas. A zero limit returns all databases after the offset. If a schema
+// cannot be read, such as for a database dropped since it was listed, the
+// request fails rather than returning a page that is short of Total.
+func (svc *Service) ListDatabasesDetailed(ctx context.Context, req *userjson.ListDatabasesDetailedRequest) (*userjson.ListDatabasesDetailedResponse, *jsonrpc.Error) {
+	if req.Limit < 0 || req.Offset < 0 {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidParams, "limit and offset must not be negative", nil)
+	}
+
+	dbs, err := svc.engine.ListDatasets(req.Owner)
+	if err != nil {
+		svc.log.Error("ListDatasets failed", "error", err)
+		return nil, engineError(err)
+	}
+
+	// The datasets are unordered, so sort them for stable pages.
+	slices.SortFunc(dbs, func(a, b *types.DatasetIdentifier) int {
+		return strings.Compare(a.DBID, b.DBID)
+	})
+
+	total := int64(len(dbs))
+	page := dbs[min(req.Offset, total):]
+	if req.Limit > 0 && req.Limit < int64(len(page)) {
+		page = page[:req.Limit]
+	}
+
+	details := make([]*types.DatasetDetails, 0, len(page))
+	for _, db := range page {
+		schema, err := svc.engine.GetSchema(db.DBID)
+		if err != nil {
+			svc.log.Warn("GetSchema failed", "dbid", db.DBID, "error", err)
+			return nil, engineError(err)
+		}
+		details = append(details, &types.DatasetDetails{
+			Name:       db.Name,
+			Owner:      db.Owner,
+			DBID:       db.DBID,
+			Tables:     len(schema.Tables),
+			Actions:    len(schema.Actions),
+			Procedures: len(schema.Procedures),
+		})
+	}
+
+	return &userjson.ListDatabasesDetailedResponse{
+		Databases: details,
+		Total:     total,
+	}, nil
+}
+
 func checkEngineError(err error) (jsonrpc.ErrorCode, string) {
 	if err == nil {
 		return 0, "" // would not be constructing a jsonrpc.Error
@@ -852,6 +1009,54 @@ func (svc *Service) TxQuery(ctx context.Context, req *userjson.TxQueryRequest) (
 	return txResult, nil
 }
 
+// BlockHeader returns the header of the block at the requested height. If the
+// block does not exist yet, it polls for up to the requested wait duration,
+// which is capped at maxBlockHeaderWait, so that clients can long-poll for new
+// blocks.
+func (svc *Service) BlockHeader(ctx context.Context, req *userjson.BlockHeaderRequest) (*userjson.BlockHeaderResponse, *jsonrpc.Error) {
+	wait := min(time.Duration(req.Wait)*time.Millisecond, maxBlockHeaderWait)
+	ctx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+
+	for {
+		hash, blk, _, err := svc.chainClient.BlockByHeight(req.Height)
+		if err == nil {
+			return &userjson.BlockHeaderResponse{
+				Hash:   hash,
+				Header: blk.Header,
+			}, nil
+		}
+		if !errors.Is(err, types.ErrNotFound) {
+			svc.log.Warn("failed to get block", "height", req.Height, "error", err)
+			return nil, jsonrpc.NewError(jsonrpc.ErrorNodeInternal, "failed to get block", nil)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, jsonrpc.NewError(jsonrpc.ErrorBlockNotFound, "block not found", nil)
+		case <-time.After(blockHeaderPollInterval):
+		}
+	}
+}
+
+// Block gets the full block at a height, with the app hash after the block was
+// executed.
+func (svc *Service) Block(ctx context.Context, req *userjson.BlockRequest) (*userjson.BlockResponse, *jsonrpc.Error) {
+	hash, blk, appHash, err := svc.chainClient.BlockByHeight(req.Height)
+	if errors.Is(err, types.ErrNotFound) {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorBlockNotFound, "block not found", nil)
+	}
+	if err != nil {
+		svc.log.Warn("failed to get block", "height", req.Height, "error", err)
+		return nil, jsonrpc.NewError(jsonrpc.ErrorNodeInternal, "failed to get block", nil)
+	}
+	return &userjson.BlockResponse{
+		Hash:    hash,
+		Block:   types.EncodeBlock(blk),
+		AppHash: appHash,
+	}, nil
+}
+
 func (svc *Service) LoadChangeset(ctx context.Context, req *userjson.ChangesetRequest) (*userjson.ChangesetsResponse, *jsonrpc.Error) {
 	/*bts, err := svc.migrator.GetChangeset(req.Height, req.Index)
 	if err != nil {
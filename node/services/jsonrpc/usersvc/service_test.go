@@ -0,0 +1,128 @@
+package usersvc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/kwilteam/kwil-db/core/log"
+	jsonrpc "github.com/kwilteam/kwil-db/core/rpc/json"
+	userjson "github.com/kwilteam/kwil-db/core/rpc/json/user"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/engine/execution"
+
+	"github.com/stretchr/testify/require"
+)
+
+// mockEngine is an EngineReader with a fixed set of schemas.
+type mockEngine struct {
+	EngineReader
+	schemas map[string]*types.Schema
+	dropped map[string]bool // listed, but without a schema
+}
+
+func (m *mockEngine) ListDatasets(owner []byte) ([]*types.DatasetIdentifier, error) {
+	var dbs []*types.DatasetIdentifier
+	for dbid, schema := range m.schemas {
+		dbs = append(dbs, &types.DatasetIdentifier{Name: schema.Name, Owner: schema.Owner, DBID: dbid})
+	}
+	return dbs, nil
+}
+
+func (m *mockEngine) GetSchema(dbid string) (*types.Schema, error) {
+	if m.dropped[dbid] {
+		return nil, execution.ErrDatasetNotFound
+	}
+	return m.schemas[dbid], nil
+}
+
+func TestListDatabasesDetailed(t *testing.T) {
+	engine := &mockEngine{schemas: map[string]*types.Schema{
+		"x03": {Name: "c"},
+		"x01": {Name: "a", Tables: make([]*types.Table, 2)},
+		"x02": {Name: "b", Actions: make([]*types.Action, 1)},
+	}}
+	svc := NewService(nil, engine, nil, nil, nil, log.DiscardLogger)
+	ctx := context.Background()
+
+	list := func(limit, offset int64) ([]string, int64) {
+		resp, jsonErr := svc.ListDatabasesDetailed(ctx, &userjson.ListDatabasesDetailedRequest{
+			Limit:  limit,
+			Offset: offset,
+		})
+		require.Nil(t, jsonErr)
+		var dbids []string
+		for _, db := range resp.Databases {
+			dbids = append(dbids, db.DBID)
+		}
+		return dbids, resp.Total
+	}
+
+	dbids, total := list(2, 0)
+	require.Equal(t, []string{"x01", "x02"}, dbids)
+	require.EqualValues(t, 3, total)
+
+	dbids, _ = list(2, 2)
+	require.Equal(t, []string{"x03"}, dbids)
+
+	dbids, total = list(0, 5)
+	require.Empty(t, dbids)
+	require.EqualValues(t, 3, total)
+
+	resp, jsonErr := svc.ListDatabasesDetailed(ctx, &userjson.ListDatabasesDetailedRequest{Limit: 1})
+	require.Nil(t, jsonErr)
+	require.Equal(t, 2, resp.Databases[0].Tables)
+
+	_, jsonErr = svc.ListDatabasesDetailed(ctx, &userjson.ListDatabasesDetailedRequest{Offset: -1})
+	require.NotNil(t, jsonErr)
+	require.Equal(t, jsonrpc.ErrorInvalidParams, jsonErr.Code)
+
+	// A database dropped since it was listed fails the request instead of
+	// being left out of a page that would then be short of the total.
+	engine.dropped = map[string]bool{"x02": true}
+	_, jsonErr = svc.ListDatabasesDetailed(ctx, &userjson.ListDatabasesDetailedRequest{})
+	require.NotNil(t, jsonErr)
+	require.Equal(t, jsonrpc.ErrorEngineDatasetNotFound, jsonErr.Code)
+
+	// Pages without it are unaffected.
+	dbids, total = list(1, 2)
+	require.Equal(t, []string{"x03"}, dbids)
+	require.EqualValues(t, 3, total)
+}
+
+// mockChain is a BlockchainTransactor that rejects all transactions.
+type mockChain struct {
+	BlockchainTransactor
+	err error
+}
+
+func (m *mockChain) BroadcastTx(ctx context.Context, tx *types.Transaction, sync uint8) (*types.ResultBroadcastTx, error) {
+	return nil, m.err
+}
+
+func TestBroadcastRejected(t *testing.T) {
+	tx, err := types.CreateTransaction(&types.Transfer{}, "kwil-test-chain", 1)
+	require.NoError(t, err)
+	txHash, err := tx.Hash()
+	require.NoError(t, err)
+
+	chain := &mockChain{}
+	svc := NewService(nil, nil, chain, nil, nil, log.DiscardLogger)
+
+	chain.err = fmt.Errorf("%w: transaction fee 1 is below the minimum fee 2", types.ErrInsufficientFee)
+	_, jsonErr := svc.Broadcast(context.Background(), &userjson.BroadcastRequest{Tx: tx})
+	require.NotNil(t, jsonErr)
+	require.Equal(t, jsonrpc.ErrorTxExecFailure, jsonErr.Code)
+	var errData userjson.BroadcastError
+	require.NoError(t, json.Unmarshal(jsonErr.Data, &errData))
+	require.EqualValues(t, types.CodeInsufficientFee, errData.TxCode)
+	require.Equal(t, txHash.String(), errData.Hash)
+	require.Equal(t, chain.err.Error(), errData.Message)
+
+	chain.err = errors.New("mempool is full")
+	_, jsonErr = svc.Broadcast(context.Background(), &userjson.BroadcastRequest{Tx: tx})
+	require.NotNil(t, jsonErr)
+	require.Equal(t, jsonrpc.ErrorTxInternal, jsonErr.Code)
+}
@@ -19,7 +19,9 @@ import (
 )
 
 const (
-	chunkSize int64 = 16e6 - 4096 // 16 MB
+	// DefaultChunkSize is the size of the snapshot chunks if the snapshotter
+	// is not configured with a chunk size.
+	DefaultChunkSize int64 = 16e6 - 4096 // 16 MB
 
 	DefaultSnapshotFormat = 0
 
@@ -40,57 +42,70 @@ const (
 //   - Sorting the COPY blocks of data based on the hash of the row-data
 //
 // STAGE3: Compressing the sanitized dump file
-// STAGE4: Splitting the compressed dump file into chunks of fixed size (16MB)
+// STAGE4: Splitting the compressed dump file into chunks of the configured size
 // TODO: STAGE2 could be optimized by sorting based on the first column,
 // but it might not work if the first column is not unique.
 
 type Snapshotter struct {
 	dbConfig    *config.DBConfig
 	snapshotDir string
+	chunkSize   int64
+	format      uint32
 	log         log.Logger
 }
 
-func NewSnapshotter(cfg *config.DBConfig, dir string, logger log.Logger) *Snapshotter {
+// NewSnapshotter creates a snapshotter that splits the snapshots into chunks of
+// chunkSize bytes, and identifies them with the given format. If chunkSize is
+// not positive, DefaultChunkSize is used. The chunk hashes depend on the chunk
+// size, so snapshotters with different chunk sizes should use distinct formats
+// for nodes to be able to tell their snapshots apart.
+func NewSnapshotter(cfg *config.DBConfig, dir string, chunkSize int64, format uint32, logger log.Logger) *Snapshotter {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
 	return &Snapshotter{
 		dbConfig:    cfg,
 		snapshotDir: dir,
+		chunkSize:   chunkSize,
+		format:      format,
 		log:         logger,
 	}
 }
 
-// CreateSnapshot creates a snapshot at the given height and snapshotID
+// CreateSnapshot creates a snapshot at the given height and snapshotID in the
+// snapshotter's format.
 func (s *Snapshotter) CreateSnapshot(ctx context.Context, height uint64, snapshotID string, schemas, excludeTables []string, excludeTableData []string) (*Snapshot, error) {
 	// create snapshot directory
-	snapshotDir := snapshotHeightDir(s.snapshotDir, height)
-	chunkDir := snapshotChunkDir(s.snapshotDir, height, DefaultSnapshotFormat)
+	snapshotDir := snapshotFormatDir(s.snapshotDir, height, s.format)
+	chunkDir := snapshotChunkDir(s.snapshotDir, height, s.format)
 	err := os.MkdirAll(chunkDir, 0755)
 	if err != nil {
 		return nil, err
 	}
 
 	// Stage1: Dump the database at the given height and snapshot ID
-	err = s.dbSnapshot(ctx, height, DefaultSnapshotFormat, snapshotID, schemas, excludeTables, excludeTableData)
+	err = s.dbSnapshot(ctx, height, s.format, snapshotID, schemas, excludeTables, excludeTableData)
 	if err != nil {
 		os.RemoveAll(snapshotDir)
 		return nil, err
 	}
 
 	// Stage2: Sanitize the dump
-	hash, err := s.sanitizeDump(height, DefaultSnapshotFormat)
+	hash, err := s.sanitizeDump(height, s.format)
 	if err != nil {
 		os.RemoveAll(snapshotDir)
 		return nil, err
 	}
 
 	// Stage3: Compress the dump
-	err = s.compressDump(height, DefaultSnapshotFormat)
+	err = s.compressDump(height, s.format)
 	if err != nil {
 		os.RemoveAll(snapshotDir)
 		return nil, err
 	}
 
 	// Stage4: Split the dump into chunks
-	snapshot, err := s.splitDumpIntoChunks(height, DefaultSnapshotFormat, hash)
+	snapshot, err := s.splitDumpIntoChunks(height, s.format, hash)
 	if err != nil {
 		os.RemoveAll(snapshotDir)
 		return nil, err
@@ -398,7 +413,7 @@ func (s *Snapshotter) compressDump(height uint64, format uint32) error {
 }
 
 // SplitDumpIntoChunks is the STAGE4 of the snapshot creation process
-// This method splits the compressed dump file into chunks of fixed size (16MB)
+// This method splits the compressed dump file into chunks of the configured size
 // The chunks are stored in the height/format/chunks directory
 // The snapshot header is created and stored in the height/format/header.json file
 func (s *Snapshotter) splitDumpIntoChunks(height uint64, format uint32, sqlDumpHash []byte) (*Snapshot, error) {
@@ -425,11 +440,17 @@ func (s *Snapshotter) splitDumpIntoChunks(height uint64, format uint32, sqlDumpH
 		defer chunkFile.Close()
 
 		// write the chunk to the file
-		written, err := io.CopyN(chunkFile, inputFile, chunkSize)
+		written, err := io.CopyN(chunkFile, inputFile, s.chunkSize)
 		if err != nil && err != io.EOF {
 			return nil, fmt.Errorf("failed to write chunk to file: %w", err)
 		}
 		chunkFile.Close() // chunkFile.Sync() probably
+		if written == 0 && chunkIndex > 0 {
+			// The dump size is a multiple of the chunk size, and the previous
+			// chunk was the last one.
+			os.Remove(chunkFileName)
+			break
+		}
 
 		// calculate the hash of the chunk
 		var chunkHash [HashLen]byte
@@ -446,7 +467,7 @@ func (s *Snapshotter) splitDumpIntoChunks(height uint64, format uint32, sqlDumpH
 
 		s.log.Info("Chunk created", "index", chunkIndex, "chunkfile", chunkFileName, "size", written)
 
-		if err == io.EOF || written < chunkSize {
+		if err == io.EOF || written < s.chunkSize {
 			break // EOF, Last chunk
 		}
 
@@ -2,6 +2,7 @@ package snapshotter
 
 import (
 	"bufio"
+	"bytes"
 	"os"
 	"path/filepath"
 	"testing"
@@ -20,7 +21,7 @@ func TestSanitizeLogicalDump(t *testing.T) {
 	dir := t.TempDir()
 	logger := log.DiscardLogger
 	// Create a snapshotter
-	snapshotter := NewSnapshotter(nil, dir, logger)
+	snapshotter := NewSnapshotter(nil, dir, 0, DefaultSnapshotFormat, logger)
 
 	// Create snapshot directory
 	height := uint64(1)
@@ -61,3 +62,59 @@ func TestSanitizeLogicalDump(t *testing.T) {
 	err = scanner.Err()
 	require.NoError(t, err)
 }
+
+func TestSplitDumpIntoChunks(t *testing.T) {
+	const chunkSize = 100
+	tests := []struct {
+		name       string
+		dumpSize   int
+		wantChunks uint32
+	}{
+		{"smaller than a chunk", 40, 1},
+		{"partial last chunk", 250, 3},
+		{"multiple of chunk size", 300, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			format := uint32(1)
+			snapshotter := NewSnapshotter(nil, dir, chunkSize, format, log.DiscardLogger)
+
+			height := uint64(1)
+			err := os.MkdirAll(snapshotChunkDir(dir, height, format), 0755)
+			require.NoError(t, err)
+			dump := bytes.Repeat([]byte{'a'}, tt.dumpSize)
+			err = os.WriteFile(filepath.Join(snapshotFormatDir(dir, height, format), stage3output), dump, 0644)
+			require.NoError(t, err)
+
+			snapshot, err := snapshotter.splitDumpIntoChunks(height, format, []byte("hash"))
+			require.NoError(t, err)
+			require.Equal(t, tt.wantChunks, snapshot.ChunkCount)
+			require.Len(t, snapshot.ChunkHashes, int(tt.wantChunks))
+			require.Equal(t, uint64(tt.dumpSize), snapshot.SnapshotSize)
+			require.Equal(t, format, snapshot.Format)
+
+			// The store lists the snapshot from disk under its format only.
+			store, err := NewSnapshotStore(&SnapshotConfig{
+				SnapshotDir:  dir,
+				MaxSnapshots: 1,
+				ChunkSize:    chunkSize,
+				Format:       format,
+			}, log.DiscardLogger)
+			require.NoError(t, err)
+
+			meta := store.GetSnapshot(height, format)
+			require.NotNil(t, meta)
+			require.Equal(t, tt.wantChunks, meta.ChunkCount)
+			require.Nil(t, store.GetSnapshot(height, DefaultSnapshotFormat))
+
+			chunk, err := store.LoadSnapshotChunk(height, format, tt.wantChunks-1)
+			require.NoError(t, err)
+			require.Equal(t, dump[chunkSize*(tt.wantChunks-1):], chunk)
+
+			_, err = store.LoadSnapshotChunk(height, DefaultSnapshotFormat, 0)
+			require.ErrorIs(t, err, ErrSnapshotNotFound)
+		})
+	}
+}
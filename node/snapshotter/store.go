@@ -41,10 +41,15 @@ import (
 					...
 					chunk-n.sql.gz
 
-	Currently we only support snapshots of format plain sql dump compressed with gzip.
-	This can be extended to support other formats in the future.
+	Snapshots are created as plain sql dumps compressed with gzip, split into
+	chunks of the configured size. A height may have snapshots of several
+	formats, e.g. with different chunk sizes, and each is served separately.
 */
 
+// ErrSnapshotNotFound is returned when there is no snapshot of the requested
+// height and format.
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
 type SnapshotConfig struct {
 	// Snapshot store configuration
 	Enable          bool
@@ -52,6 +57,12 @@ type SnapshotConfig struct {
 	MaxSnapshots    int
 	RecurringHeight uint64
 	DBConfig        *config.DBConfig
+
+	// ChunkSize is the size in bytes of the chunks of the created snapshots.
+	// DefaultChunkSize is used if it is zero.
+	ChunkSize int64
+	// Format identifies the snapshots created by the store.
+	Format uint32
 }
 
 type SnapshotStore struct {
@@ -59,9 +70,9 @@ type SnapshotStore struct {
 	cfg *SnapshotConfig
 
 	// Snapshot Store
-	snapshots       map[uint64]*Snapshot // Map of snapshot height to snapshot header
-	snapshotHeights []uint64             // List of snapshot heights
-	snapshotsMtx    sync.RWMutex         // Protects access to snapshots and snapshotHeights
+	snapshots       map[uint64]map[uint32]*Snapshot // Map of snapshot height and format to snapshot header
+	snapshotHeights []uint64                        // List of snapshot heights
+	snapshotsMtx    sync.RWMutex                    // Protects access to snapshots and snapshotHeights
 
 	// Snapshotter
 	snapshotter DBSnapshotter
@@ -75,10 +86,10 @@ type DBSnapshotter interface {
 }
 
 func NewSnapshotStore(cfg *SnapshotConfig, logger log.Logger) (*SnapshotStore, error) {
-	snapshotter := NewSnapshotter(cfg.DBConfig, cfg.SnapshotDir, logger)
+	snapshotter := NewSnapshotter(cfg.DBConfig, cfg.SnapshotDir, cfg.ChunkSize, cfg.Format, logger)
 	ss := &SnapshotStore{
 		cfg:         cfg,
-		snapshots:   make(map[uint64]*Snapshot),
+		snapshots:   make(map[uint64]map[uint32]*Snapshot),
 		snapshotter: snapshotter,
 		log:         logger,
 	}
@@ -110,21 +121,25 @@ func (s *SnapshotStore) ListSnapshots() []*Snapshot {
 	defer s.snapshotsMtx.RUnlock()
 
 	snaps := make([]*Snapshot, 0, len(s.snapshots))
-	for _, snap := range s.snapshots {
-		snaps = append(snaps, snap)
+	for _, formats := range s.snapshots {
+		for _, snap := range formats {
+			snaps = append(snaps, snap)
+		}
 	}
 
 	return snaps
 }
 
-func (s *SnapshotStore) GetSnapshot(height uint64, _ uint32) *Snapshot {
+// GetSnapshot returns the snapshot at the given height and format, or nil if
+// there is no such snapshot.
+func (s *SnapshotStore) GetSnapshot(height uint64, format uint32) *Snapshot {
 	s.snapshotsMtx.RLock()
 	defer s.snapshotsMtx.RUnlock()
 
-	return s.snapshots[height]
+	return s.snapshots[height][format]
 }
 
-// CreateSnapshot creates a new snapshot at the given height and snapshot ID.
+// CreateSnapshot creates a new snapshot of the configured format at the given height and snapshot ID.
 // SnapshotStore ensures that the number of snapshots does not exceed the maximum configured snapshots.
 // If exceeds, it deletes the oldest snapshot.
 // It takes a list of schemas, excludedTables and excludeTableData args to specify the contents of the snapshot.
@@ -135,14 +150,14 @@ func (s *SnapshotStore) CreateSnapshot(ctx context.Context, height uint64, snaps
 	// Create a snapshot of the database at the given height
 	snapshot, err := s.snapshotter.CreateSnapshot(ctx, height, snapshotID, schemas, excludedTables, excludeTableData)
 	if err != nil {
-		os.RemoveAll(snapshotHeightDir(s.cfg.SnapshotDir, height))
+		os.RemoveAll(snapshotFormatDir(s.cfg.SnapshotDir, height, s.cfg.Format))
 		return fmt.Errorf("failed to create snapshot at height %d: %w", height, err)
 	}
 
 	// Register the snapshot
 	err = s.RegisterSnapshot(snapshot)
 	if err != nil {
-		os.RemoveAll(snapshotHeightDir(s.cfg.SnapshotDir, height))
+		os.RemoveAll(snapshotFormatDir(s.cfg.SnapshotDir, height, snapshot.Format))
 		return fmt.Errorf("failed to register snapshot at height %d: %w", height, err)
 	}
 
@@ -160,13 +175,18 @@ func (s *SnapshotStore) RegisterSnapshot(snapshot *Snapshot) error {
 		return nil // no snapshot to register
 	}
 
-	if _, ok := s.snapshots[snapshot.Height]; ok { // snapshot already exists at the given height
+	formats, ok := s.snapshots[snapshot.Height]
+	if _, exists := formats[snapshot.Format]; exists { // snapshot already exists at the given height and format
 		return nil
 	}
 
 	// Register the snapshot
-	s.snapshots[snapshot.Height] = snapshot
-	s.snapshotHeights = append(s.snapshotHeights, snapshot.Height)
+	if !ok {
+		formats = make(map[uint32]*Snapshot)
+		s.snapshots[snapshot.Height] = formats
+		s.snapshotHeights = append(s.snapshotHeights, snapshot.Height)
+	}
+	formats[snapshot.Format] = snapshot
 
 	// Sort the snapshot heights in ascending order
 	slices.Sort(s.snapshotHeights)
@@ -180,15 +200,15 @@ func (s *SnapshotStore) RegisterSnapshot(snapshot *Snapshot) error {
 }
 
 // DeleteOldestSnapshot deletes the oldest snapshot.
-// Deletes the internal and fs snapshot files and references corresponding to the oldest snapshot.
+// Deletes the internal and fs snapshot files and references corresponding to the oldest snapshot,
+// in all of its formats.
 func (s *SnapshotStore) deleteOldestSnapshot() error {
 	if len(s.snapshotHeights) == 0 {
 		return nil
 	}
 
 	oldHeight := s.snapshotHeights[0]
-	snapshot := s.snapshots[oldHeight]
-	snapshotDir := snapshotHeightDir(s.cfg.SnapshotDir, snapshot.Height)
+	snapshotDir := snapshotHeightDir(s.cfg.SnapshotDir, oldHeight)
 
 	os.RemoveAll(snapshotDir) // Delete the oldest snapshot directory
 
@@ -198,21 +218,17 @@ func (s *SnapshotStore) deleteOldestSnapshot() error {
 }
 
 // LoadSnapshotChunk loads a snapshot chunk at the given height and chunk index of given format.
-// It returns the snapshot chunk as a byte slice of max size 16MB.
+// It returns the snapshot chunk as a byte slice of at most the snapshot's chunk size.
 // errors if the chunk of chunkIndex corresponding to snapshot at given height and format does not exist.
+// If there is no snapshot of the format at the height, the error is ErrSnapshotNotFound.
 func (s *SnapshotStore) LoadSnapshotChunk(height uint64, format uint32, chunkIdx uint32) ([]byte, error) {
 	s.snapshotsMtx.RLock()
 	defer s.snapshotsMtx.RUnlock()
 
-	// Check if snapshot format is supported
-	if format != DefaultSnapshotFormat {
-		return nil, fmt.Errorf("unsupported snapshot format %d", format)
-	}
-
-	// Check if snapshot exists
-	snapshot, ok := s.snapshots[height]
+	// Check if snapshot exists in the requested format
+	snapshot, ok := s.snapshots[height][format]
 	if !ok {
-		return nil, fmt.Errorf("snapshot at height %d does not exist", height)
+		return nil, fmt.Errorf("%w: height %d, format %d", ErrSnapshotNotFound, height, format)
 	}
 
 	// Check if chunk exists
@@ -264,24 +280,12 @@ func (s *SnapshotStore) loadSnapshots() error {
 			continue
 		}
 
-		// Load snapshot header
-		headerFile := snapshotHeaderFile(s.cfg.SnapshotDir, heightInt, DefaultSnapshotFormat)
-		header, err := loadSnapshot(headerFile)
-		if err != nil {
-			s.log.Warn("Invalid snapshot header file, ignoring the snapshot", "height", height, "err", err)
+		formats := s.loadSnapshotFormats(heightInt)
+		if len(formats) == 0 {
 			continue
 		}
 
-		// Ensure that the chunk files exist
-		for i := range header.ChunkCount {
-			chunkFile := snapshotChunkFile(s.cfg.SnapshotDir, heightInt, DefaultSnapshotFormat, i)
-			if _, err := os.Stat(chunkFile); err != nil { // chunk file doesn't exist
-				s.log.Warn("Invalid snapshot chunk file, ignoring the snapshot", "chunk_file", chunkFile, "err", err)
-				continue
-			}
-		}
-
-		s.snapshots[heightInt] = header
+		s.snapshots[heightInt] = formats
 		s.snapshotHeights = append(s.snapshotHeights, heightInt)
 	}
 
@@ -300,6 +304,53 @@ func (s *SnapshotStore) loadSnapshots() error {
 	return nil
 }
 
+// loadSnapshotFormats loads the headers of the snapshots of each format at the
+// given height. Snapshots with a missing header or chunk file are ignored.
+func (s *SnapshotStore) loadSnapshotFormats(height uint64) map[uint32]*Snapshot {
+	dirs, err := os.ReadDir(snapshotHeightDir(s.cfg.SnapshotDir, height))
+	if err != nil {
+		s.log.Warn("failed to read snapshot directory, ignoring the snapshot", "height", height, "err", err)
+		return nil
+	}
+
+	formats := make(map[uint32]*Snapshot)
+	for _, dir := range dirs {
+		fmtStr, ok := strings.CutPrefix(dir.Name(), "format-")
+		if !dir.IsDir() || !ok {
+			continue
+		}
+		format, err := strconv.ParseUint(fmtStr, 10, 32)
+		if err != nil {
+			s.log.Warn("invalid snapshot format, ignoring the snapshot", "height", height, "format", fmtStr, "err", err)
+			continue
+		}
+
+		// Load snapshot header
+		headerFile := snapshotHeaderFile(s.cfg.SnapshotDir, height, uint32(format))
+		header, err := loadSnapshot(headerFile)
+		if err != nil {
+			s.log.Warn("Invalid snapshot header file, ignoring the snapshot", "height", height, "format", format, "err", err)
+			continue
+		}
+
+		// Ensure that the chunk files exist
+		complete := true
+		for i := range header.ChunkCount {
+			chunkFile := snapshotChunkFile(s.cfg.SnapshotDir, height, uint32(format), i)
+			if _, err := os.Stat(chunkFile); err != nil { // chunk file doesn't exist
+				s.log.Warn("Invalid snapshot chunk file, ignoring the snapshot", "chunk_file", chunkFile, "err", err)
+				complete = false
+				break
+			}
+		}
+		if complete {
+			formats[uint32(format)] = header
+		}
+	}
+
+	return formats
+}
+
 // utility functions
 func snapshotHeightDir(snapshotDir string, height uint64) string {
 	return filepath.Join(snapshotDir, fmt.Sprintf("block-%d", height))
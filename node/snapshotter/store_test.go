@@ -62,7 +62,7 @@ func NewMockSnapshotStore(dir string, cfg *SnapshotConfig, logger log.Logger) (*
 	snapshotter := NewMockSnapshotter(dir)
 	store := &SnapshotStore{
 		cfg:             cfg,
-		snapshots:       make(map[uint64]*Snapshot),
+		snapshots:       make(map[uint64]map[uint32]*Snapshot),
 		snapshotHeights: make([]uint64, 0),
 		snapshotter:     snapshotter,
 		log:             logger,
@@ -235,9 +235,9 @@ func TestLoadSnapshotChunk(t *testing.T) {
 	require.Error(t, err)
 	require.Nil(t, data)
 
-	// Load the snapshot chunk of unsupported format
+	// Load the snapshot chunk of unavailable format
 	data, err = store.LoadSnapshotChunk(height, 1, 0)
-	require.Error(t, err)
+	require.ErrorIs(t, err, ErrSnapshotNotFound)
 	require.Nil(t, data)
 
 	// Load the snapshot chunk that doesn't exist at a given height
@@ -247,3 +247,31 @@ func TestLoadSnapshotChunk(t *testing.T) {
 	require.Nil(t, data)
 
 }
+
+func TestRegisterSnapshotFormats(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &SnapshotConfig{
+		SnapshotDir:  dir,
+		MaxSnapshots: 1,
+	}
+	store, err := NewMockSnapshotStore(dir, cfg, log.DiscardLogger)
+	require.NoError(t, err)
+
+	height := uint64(1)
+	for format := range uint32(2) {
+		err = store.RegisterSnapshot(&Snapshot{Height: height, Format: format, ChunkCount: format + 1})
+		require.NoError(t, err)
+	}
+
+	// Both formats are kept for the one height.
+	require.Len(t, store.ListSnapshots(), 2)
+	require.Equal(t, uint32(1), store.GetSnapshot(height, 0).ChunkCount)
+	require.Equal(t, uint32(2), store.GetSnapshot(height, 1).ChunkCount)
+	require.Nil(t, store.GetSnapshot(height, 2))
+
+	// A newer height replaces all the formats of the oldest.
+	err = store.RegisterSnapshot(&Snapshot{Height: 2, Format: 0, ChunkCount: 1})
+	require.NoError(t, err)
+	require.Len(t, store.ListSnapshots(), 1)
+	require.Nil(t, store.GetSnapshot(height, 1))
+}
@@ -2,12 +2,14 @@ package node
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
 	"sync"
 	"time"
 
@@ -29,6 +31,14 @@ const (
 	chunkGetTimeout    = 45 * time.Second
 	snapshotGetTimeout = 45 * time.Second
 
+	// maxConcurrentChunkFetches limits the number of snapshot chunks that are
+	// downloaded at once.
+	maxConcurrentChunkFetches = 8
+
+	// minSnapshotProviders is the number of providers a snapshot needs to be
+	// preferred over later snapshots with fewer providers.
+	minSnapshotProviders = 2
+
 	snapshotCatalogNS    = "snapshot-catalog" // namespace on which snapshot catalogs are advertised
 	discoverSnapshotsMsg = "discover_snapshots"
 )
@@ -395,6 +405,80 @@ func (sp *snapshotPool) blacklistSnapshot(snap *snapshotMetadata) {
 	delete(sp.providers, key)
 }
 
+// addSnapshots adds the snapshots in a provider's catalog to the pool, and
+// returns those that the provider was not already known to have. A provider
+// is recorded once per snapshot however many times its catalog is received.
+// Blacklisted snapshots are ignored.
+func (sp *snapshotPool) addSnapshots(provider peer.AddrInfo, snapshots []*snapshotMetadata) []*snapshotMetadata {
+	sp.mtx.Lock()
+	defer sp.mtx.Unlock()
+
+	var added []*snapshotMetadata
+	for _, snap := range snapshots {
+		key := snap.Key()
+		if _, blacklisted := sp.blacklist[key]; blacklisted {
+			continue
+		}
+		if slices.ContainsFunc(sp.providers[key], func(p peer.AddrInfo) bool { return p.ID == provider.ID }) {
+			continue
+		}
+		if _, have := sp.snapshots[key]; !have {
+			sp.snapshots[key] = snap
+		}
+		sp.providers[key] = append(sp.providers[key], provider)
+		added = append(added, snap)
+	}
+	return added
+}
+
+// best selects the snapshot to sync from, or returns nil if the pool is empty.
+// Snapshots are told apart by their full metadata hash (snapshotMetadata.Key),
+// not just their height and format, since providers may disagree on the
+// contents of the snapshot at a height and format. A snapshot with at least
+// minSnapshotProviders providers is preferred over any with fewer, so that a
+// single provider advertising a later, possibly faulty, snapshot is not chosen
+// over one that several providers agree on. Otherwise the latest snapshot is
+// preferred, and of the snapshots at the same height, the one advertised by the
+// most providers, which has more providers to download chunks from.
+func (sp *snapshotPool) best() *snapshotMetadata {
+	sp.mtx.Lock()
+	defer sp.mtx.Unlock()
+
+	var best *snapshotMetadata
+	var bestKey snapshotKey
+	for key, snap := range sp.snapshots {
+		if best == nil || compareSnapshots(snap, len(sp.providers[key]), key,
+			best, len(sp.providers[bestKey]), bestKey) > 0 {
+			best, bestKey = snap, key
+		}
+	}
+	return best
+}
+
+// compareSnapshots orders snapshots by whether they have minSnapshotProviders
+// providers, then height, then number of providers, then lowest format. The snapshot key breaks any remaining tie so that the
+// selection does not depend on map iteration order.
+func compareSnapshots(a *snapshotMetadata, aProviders int, aKey snapshotKey,
+	b *snapshotMetadata, bProviders int, bKey snapshotKey) int {
+	aEnough, bEnough := aProviders >= minSnapshotProviders, bProviders >= minSnapshotProviders
+	if aEnough != bEnough {
+		if aEnough {
+			return 1
+		}
+		return -1
+	}
+	if c := cmp.Compare(a.Height, b.Height); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(aProviders, bProviders); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(b.Format, a.Format); c != 0 {
+		return c
+	}
+	return bytes.Compare(bKey[:], aKey[:])
+}
+
 func (sp *snapshotPool) updatePeers(peers []peer.AddrInfo) {
 	sp.mtx.Lock()
 	defer sp.mtx.Unlock()
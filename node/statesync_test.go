@@ -4,6 +4,8 @@ import (
 	"context"
 	"crypto/sha256"
 	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
@@ -17,6 +19,7 @@ import (
 	dht "github.com/libp2p/go-libp2p-kad-dht"
 	"github.com/libp2p/go-libp2p/core/discovery"
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
 	mock "github.com/libp2p/go-libp2p/p2p/net/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -53,11 +56,13 @@ func (m *mockBS) GetByHeight(height int64) (types.Hash, *ktypes.Block, types.Has
 
 type snapshotStore struct {
 	snapshots map[uint64]*snapshotMetadata
+	chunks    map[uint64][][]byte // optional chunk contents by height
 }
 
 func newSnapshotStore() *snapshotStore {
 	return &snapshotStore{
 		snapshots: make(map[uint64]*snapshotMetadata),
+		chunks:    make(map[uint64][][]byte),
 	}
 }
 
@@ -96,6 +101,10 @@ func (s *snapshotStore) LoadSnapshotChunk(height uint64, format uint32, index ui
 		return nil, errors.New("chunk not found")
 	}
 
+	if chunks, ok := s.chunks[height]; ok {
+		return chunks[index], nil
+	}
+
 	return []byte("snapshot"), nil
 }
 
@@ -254,7 +263,19 @@ func TestStateSyncService(t *testing.T) {
 	// add snap2 to the trusted provider
 	st1.addSnapshot(snap2)
 
-	// best snapshot should be snap2
+	// best snapshot should still be snap1, which has more providers
+	for _, p := range peers {
+		err = ss3.requestSnapshotCatalogs(ctx, p)
+		require.NoError(t, err)
+	}
+
+	bestSnap, err = ss3.bestSnapshot()
+	require.NoError(t, err)
+	assert.Equal(t, snap1.Height, bestSnap.Height)
+
+	// add snap2 to h2, so that it has enough providers to be the best
+	st2.addSnapshot(snap2)
+
 	for _, p := range peers {
 		err = ss3.requestSnapshotCatalogs(ctx, p)
 		require.NoError(t, err)
@@ -267,3 +288,159 @@ func TestStateSyncService(t *testing.T) {
 	valid, _ = ss3.VerifySnapshot(ctx, bestSnap)
 	assert.True(t, valid)
 }
+
+func TestSnapshotSelection(t *testing.T) {
+	ctx := context.Background()
+	mn := mock.New()
+	tempDir := t.TempDir()
+
+	newSnap := func(height uint64, hash string) *snapshotMetadata {
+		return &snapshotMetadata{
+			Height:      height,
+			Format:      1,
+			Chunks:      1,
+			Hash:        []byte(hash),
+			Size:        100,
+			ChunkHashes: [][32]byte{data},
+		}
+	}
+	snapA := newSnap(10, "a")
+	snapB := newSnap(20, "b")
+	snapB2 := newSnap(20, "b2") // same height and format as snapB, different contents
+	snapD := newSnap(5, "d")
+
+	// the catalogs of each provider overlap at heights 10 and 20, and only
+	// the third provider has height 5
+	catalogs := [][]*snapshotMetadata{
+		{snapA, snapB},
+		{snapB},
+		{snapB2, snapD},
+		{snapA},
+	}
+	var providers []peer.AddrInfo
+	for i, catalog := range catalogs {
+		h, _, st, _, err := newTestStatesyncer(ctx, t, mn, filepath.Join(tempDir, fmt.Sprint("p", i)), testSSConfig(false, nil))
+		require.NoError(t, err)
+		for _, snap := range catalog {
+			st.addSnapshot(snap)
+		}
+		providers = append(providers, peer.AddrInfo{ID: h.ID(), Addrs: h.Addrs()})
+	}
+	_, _, _, ss, err := newTestStatesyncer(ctx, t, mn, filepath.Join(tempDir, "n"), testSSConfig(false, nil))
+	require.NoError(t, err)
+
+	require.NoError(t, mn.LinkAll())
+	require.NoError(t, mn.ConnectAllButSelf())
+
+	_, err = ss.bestSnapshot()
+	require.ErrorIs(t, err, ErrNoSnapshotsDiscovered)
+
+	// request each catalog twice, which must not count a provider twice
+	for range 2 {
+		for _, p := range providers {
+			require.NoError(t, ss.requestSnapshotCatalogs(ctx, p))
+		}
+	}
+
+	require.Len(t, ss.snapshotPool.listSnapshots(), 4)
+	providerIDs := func(snap *snapshotMetadata) []peer.ID {
+		var ids []peer.ID
+		for _, p := range ss.snapshotPool.keyProviders(snap.Key()) {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+	assert.ElementsMatch(t, []peer.ID{providers[0].ID, providers[3].ID}, providerIDs(snapA))
+	assert.ElementsMatch(t, []peer.ID{providers[0].ID, providers[1].ID}, providerIDs(snapB))
+	assert.ElementsMatch(t, []peer.ID{providers[2].ID}, providerIDs(snapB2))
+	assert.ElementsMatch(t, []peer.ID{providers[2].ID}, providerIDs(snapD))
+
+	// the latest height, from the most providers
+	best, err := ss.bestSnapshot()
+	require.NoError(t, err)
+	assert.Equal(t, snapB.Hash, best.Hash)
+
+	// an earlier snapshot with enough providers is preferred over the other
+	// snapshot at the same height, which has only one provider
+	ss.snapshotPool.blacklistSnapshot(best)
+	best, err = ss.bestSnapshot()
+	require.NoError(t, err)
+	assert.Equal(t, snapA.Hash, best.Hash)
+
+	// without enough providers for any snapshot, the latest is preferred
+	ss.snapshotPool.blacklistSnapshot(best)
+	best, err = ss.bestSnapshot()
+	require.NoError(t, err)
+	assert.Equal(t, snapB2.Hash, best.Hash)
+
+	// rediscovery does not restore blacklisted snapshots
+	for _, p := range providers {
+		require.NoError(t, ss.requestSnapshotCatalogs(ctx, p))
+	}
+	require.Len(t, ss.snapshotPool.listSnapshots(), 2)
+	best, err = ss.bestSnapshot()
+	require.NoError(t, err)
+	assert.Equal(t, snapB2.Hash, best.Hash)
+}
+
+func TestChunkFetcher(t *testing.T) {
+	ctx := context.Background()
+	mn := mock.New()
+	tempDir := t.TempDir()
+
+	// good and corrupt providers, and the node fetching the snapshot
+	h1, _, st1, _, err := newTestStatesyncer(ctx, t, mn, filepath.Join(tempDir, "n1"), testSSConfig(false, nil))
+	require.NoError(t, err)
+	h2, _, st2, _, err := newTestStatesyncer(ctx, t, mn, filepath.Join(tempDir, "n2"), testSSConfig(false, nil))
+	require.NoError(t, err)
+	_, _, _, ss3, err := newTestStatesyncer(ctx, t, mn, filepath.Join(tempDir, "n3"), testSSConfig(false, nil))
+	require.NoError(t, err)
+
+	require.NoError(t, mn.LinkAll())
+	require.NoError(t, mn.ConnectAllButSelf())
+
+	const numChunks = 20 // more than maxConcurrentChunkFetches
+	var chunks, corrupt [][]byte
+	var all []byte
+	snap := &snapshotMetadata{
+		Height: 5,
+		Format: 1,
+		Chunks: numChunks,
+		Hash:   []byte("snap"),
+	}
+	for i := range numChunks {
+		chunk := []byte(fmt.Sprintf("chunk %d contents", i))
+		chunks = append(chunks, chunk)
+		corrupt = append(corrupt, []byte(fmt.Sprintf("chunk %d garbage", i)))
+		all = append(all, chunk...)
+		snap.ChunkHashes = append(snap.ChunkHashes, sha256.Sum256(chunk))
+	}
+
+	st1.addSnapshot(snap)
+	st1.chunks[snap.Height] = chunks
+	st2.addSnapshot(snap)
+	st2.chunks[snap.Height] = corrupt
+
+	key := snap.Key()
+	good := peer.AddrInfo{ID: h1.ID(), Addrs: h1.Addrs()}
+	bad := peer.AddrInfo{ID: h2.ID(), Addrs: h2.Addrs()}
+
+	t.Run("only corrupt provider", func(t *testing.T) {
+		ss3.snapshotPool.providers[key] = []peer.AddrInfo{bad}
+		err := ss3.chunkFetcher(ctx, snap)
+		require.Error(t, err)
+	})
+
+	t.Run("retry from good provider", func(t *testing.T) {
+		// Half the chunks are first requested from the corrupt provider.
+		ss3.snapshotPool.providers[key] = []peer.AddrInfo{bad, good}
+		err := ss3.chunkFetcher(ctx, snap)
+		require.NoError(t, err)
+
+		streamer := NewStreamer(snap.Chunks, ss3.snapshotDir, log.DiscardLogger)
+		defer streamer.Close()
+		got, err := io.ReadAll(streamer)
+		require.NoError(t, err)
+		require.Equal(t, all, got)
+	})
+}
@@ -132,46 +132,58 @@ func (s *StateSyncService) downloadSnapshot(ctx context.Context) (synced bool, s
 	}
 }
 
-// chunkFetcher fetches snapshot chunks from the snapshot providers
-// It returns if any of the chunk fetches fail
+// chunkFetcher fetches snapshot chunks from the snapshot providers. Up to
+// maxConcurrentChunkFetches chunks are downloaded at once. Each chunk is
+// verified against its hash in the snapshot metadata, and a chunk that fails to
+// download or verify is retried from the next provider. Chunk i is first
+// requested from provider i (mod the number of providers) to spread the load.
+// The chunks are written to their own files in the snapshot directory, from
+// which they are streamed in order by a Streamer. It returns if any chunk could
+// not be fetched from any provider.
 func (s *StateSyncService) chunkFetcher(ctx context.Context, snapshot *snapshotMetadata) error {
-	// fetch snapshot chunks and write them to the snapshot directory
-	var wg sync.WaitGroup
-	// errCh := make(chan error, snapshot.Chunks)
+	if len(snapshot.ChunkHashes) != int(snapshot.Chunks) {
+		return fmt.Errorf("snapshot has %d chunks but %d chunk hashes", snapshot.Chunks, len(snapshot.ChunkHashes))
+	}
 
 	key := snapshot.Key()
 	providers := s.snapshotPool.keyProviders(key)
 	if len(providers) == 0 {
 		providers = append(providers, s.snapshotPool.getPeers()...)
 	}
+	if len(providers) == 0 {
+		return errors.New("no providers for snapshot")
+	}
 
-	errChan := make(chan error, snapshot.Chunks)
 	chunkCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	for i := range snapshot.Chunks {
+	indexes := make(chan uint32)
+	go func() {
+		defer close(indexes)
+		for i := range snapshot.Chunks {
+			select {
+			case indexes <- i:
+			case <-chunkCtx.Done():
+				return
+			}
+		}
+	}()
+
+	numWorkers := min(maxConcurrentChunkFetches, int(snapshot.Chunks))
+	errChan := make(chan error, numWorkers)
+	var wg sync.WaitGroup
+	for range numWorkers {
 		wg.Add(1)
-		go func(idx uint32) {
+		go func() {
 			defer wg.Done()
-			for _, provider := range providers {
-				select {
-				case <-chunkCtx.Done():
-					// Exit early if the context is cancelled
+			for idx := range indexes {
+				if err := s.fetchChunk(chunkCtx, snapshot, providers, idx); err != nil {
+					errChan <- err
+					cancel() // no point fetching the rest
 					return
-				default:
-				}
-				if err := s.requestSnapshotChunk(chunkCtx, snapshot, provider, idx); err != nil {
-					s.log.Warn("failed to request snapshot chunk %d from peer %s: %v", idx, provider.ID, err)
-					continue
 				}
-				// successfully fetched the chunk
-				s.log.Info("Received snapshot chunk", "height", snapshot.Height, "index", idx, "provider", provider.ID)
-				return
 			}
-			// failed to fetch the chunk from all providers
-			errChan <- fmt.Errorf("failed to fetch snapshot chunk index %d", idx)
-			cancel()
-		}(i)
+		}()
 	}
 
 	wg.Wait()
@@ -181,8 +193,28 @@ func (s *StateSyncService) chunkFetcher(ctx context.Context, snapshot *snapshotM
 	case err := <-errChan:
 		return err
 	default:
+		return ctx.Err()
+	}
+}
+
+// fetchChunk fetches and verifies one snapshot chunk, trying each provider in
+// turn starting with the one at index mod len(providers).
+func (s *StateSyncService) fetchChunk(ctx context.Context, snapshot *snapshotMetadata, providers []peer.AddrInfo, idx uint32) error {
+	for i := range providers {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		provider := providers[(int(idx)+i)%len(providers)]
+		if err := s.requestSnapshotChunk(ctx, snapshot, provider, idx); err != nil {
+			s.log.Warn("failed to request snapshot chunk", "index", idx, "provider", provider.ID, "error", err)
+			continue
+		}
+		// successfully fetched the chunk
+		s.log.Info("Received snapshot chunk", "height", snapshot.Height, "index", idx, "provider", provider.ID)
 		return nil
 	}
+	// failed to fetch the chunk from all providers
+	return fmt.Errorf("failed to fetch snapshot chunk index %d", idx)
 }
 
 // requestSnapshotChunk requests a snapshot chunk from a specified provider.
@@ -228,6 +260,7 @@ func (s *StateSyncService) requestSnapshotChunk(ctx context.Context, snap *snaps
 	hasher := sha256.New()
 	writer := io.MultiWriter(file, hasher)
 	if _, err := io.Copy(writer, stream); err != nil {
+		os.Remove(chunkFile)
 		return fmt.Errorf("failed to read snapshot chunk: %w", err)
 	}
 
@@ -266,31 +299,17 @@ func (s *StateSyncService) requestSnapshotCatalogs(ctx context.Context, peer pee
 	}
 
 	// add the snapshots to the pool
-	s.snapshotPool.mtx.Lock()
-	defer s.snapshotPool.mtx.Unlock()
-	for _, snap := range snapshots {
-		key := snap.Key()
-		s.snapshotPool.snapshots[key] = snap
-		s.snapshotPool.providers[key] = append(s.snapshotPool.providers[key], peer)
+	for _, snap := range s.snapshotPool.addSnapshots(peer, snapshots) {
 		s.log.Info("Discovered snapshot", "height", snap.Height, "snapshotHash", snap.Hash, "provider", peer.ID)
 	}
 
 	return nil
 }
 
-// bestSnapshot returns the latest snapshot from the discovered snapshots.
+// bestSnapshot returns the best of the discovered snapshots. See
+// snapshotPool.best.
 func (s *StateSyncService) bestSnapshot() (*snapshotMetadata, error) {
-	s.snapshotPool.mtx.Lock()
-	defer s.snapshotPool.mtx.Unlock()
-
-	// select the best snapshot
-	var best *snapshotMetadata
-	for _, snap := range s.snapshotPool.snapshots {
-		if best == nil || snap.Height > best.Height {
-			best = snap
-		}
-	}
-
+	best := s.snapshotPool.best()
 	if best == nil {
 		return nil, ErrNoSnapshotsDiscovered
 	}
@@ -3,7 +3,10 @@
 package memstore
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"sync"
 
 	ktypes "github.com/kwilteam/kwil-db/core/types"
@@ -23,10 +26,44 @@ type MemBS struct {
 	txResults map[types.Hash][]ktypes.TxResult
 	txIds     map[types.Hash]types.Hash // tx hash -> block hash
 	fetching  map[types.Hash]bool       // TODO: remove, app concern
+	best      int64                     // height of the highest stored block
+
+	contiguous bool // Store requires the next height after best
 }
 
-func NewMemBS() *MemBS {
-	return &MemBS{
+var (
+	// ErrDuplicateHeight is returned by Store in contiguous mode for a block
+	// at a height that is already stored.
+	ErrDuplicateHeight = errors.New("block height already stored")
+	// ErrHeightGap is returned by Store in contiguous mode for a block that
+	// is not at the height after the best block.
+	ErrHeightGap = errors.New("block height is not contiguous")
+)
+
+// Option is a MemBS option.
+type Option func(*MemBS)
+
+// WithContiguousHeights makes Store reject a block that is not at the height
+// after the best stored block, as a disk-based block store would. The first
+// block may be at any height, such as after a state sync. Without this option
+// blocks may be stored in any order, such as when they are synced out of
+// order.
+func WithContiguousHeights() Option {
+	return func(bs *MemBS) {
+		bs.contiguous = true
+	}
+}
+
+// WithGenesis seeds the store with a genesis block, which is returned by
+// Genesis. Its height should be 0 or 1.
+func WithGenesis(block *ktypes.Block, appHash types.Hash) Option {
+	return func(bs *MemBS) {
+		bs.store(block, appHash)
+	}
+}
+
+func NewMemBS(opts ...Option) *MemBS {
+	bs := &MemBS{
 		idx:       make(map[types.Hash]int64),
 		hashes:    make(map[int64]blockHashes),
 		blocks:    make(map[types.Hash]*ktypes.Block),
@@ -34,9 +71,94 @@ func NewMemBS() *MemBS {
 		txIds:     make(map[types.Hash]types.Hash),
 		fetching:  make(map[types.Hash]bool),
 	}
+	for _, opt := range opts {
+		opt(bs)
+	}
+	return bs
+}
+
+// Clone returns an independent copy of the store. The copy has its own copies
+// of the blocks and results, so that a test may modify either store, or the
+// blocks and results that they return, without affecting the other. Blocks that
+// are being fetched (see PreFetch) are not copied.
+func (bs *MemBS) Clone() *MemBS {
+	bs.mtx.RLock()
+	defer bs.mtx.RUnlock()
+	clone := &MemBS{
+		fetching:   make(map[types.Hash]bool),
+		contiguous: bs.contiguous,
+	}
+	clone.copyFrom(bs)
+	return clone
+}
+
+// Snapshot is a saved state of a MemBS. It may be restored any number of times.
+type Snapshot struct {
+	bs *MemBS
+}
+
+// Snapshot saves the stored blocks and results so that they may be restored
+// with Restore, such as to reset a test fixture between subtests.
+func (bs *MemBS) Snapshot() *Snapshot {
+	return &Snapshot{bs: bs.Clone()}
+}
+
+// Restore replaces the stored blocks and results with those saved by Snapshot,
+// discarding everything stored since.
+func (bs *MemBS) Restore(snap *Snapshot) {
+	snap.bs.mtx.RLock()
+	defer snap.bs.mtx.RUnlock()
+	bs.mtx.Lock()
+	defer bs.mtx.Unlock()
+	bs.copyFrom(snap.bs)
+}
+
+// copyFrom replaces the stored blocks and results with deep copies of those in
+// src. src.mtx must be read locked, and bs.mtx must be locked unless the MemBS
+// is being constructed.
+func (bs *MemBS) copyFrom(src *MemBS) {
+	bs.idx = maps.Clone(src.idx)
+	bs.hashes = maps.Clone(src.hashes)
+	bs.txIds = maps.Clone(src.txIds)
+	bs.blocks = make(map[types.Hash]*ktypes.Block, len(src.blocks))
+	for hash, blk := range src.blocks {
+		bs.blocks[hash] = cloneBlock(blk)
+	}
+	bs.txResults = make(map[types.Hash][]ktypes.TxResult, len(src.txResults))
+	for hash, results := range src.txResults {
+		bs.txResults[hash] = cloneResults(results)
+	}
+	bs.best = src.best
+}
+
+func cloneBlock(blk *ktypes.Block) *ktypes.Block {
+	header := *blk.Header
+	txns := slices.Clone(blk.Txns)
+	for i := range txns {
+		txns[i] = slices.Clone(txns[i])
+	}
+	return &ktypes.Block{
+		Header:    &header,
+		Txns:      txns,
+		Signature: slices.Clone(blk.Signature),
+	}
+}
+
+func cloneResults(results []ktypes.TxResult) []ktypes.TxResult {
+	results = slices.Clone(results)
+	for i := range results {
+		events := slices.Clone(results[i].Events)
+		for j := range events {
+			events[j].Attributes = slices.Clone(events[j].Attributes)
+		}
+		results[i].Events = events
+	}
+	return results
 }
 
 var _ types.BlockStore = &MemBS{}
+var _ types.TxConfirmer = &MemBS{}
+var _ types.TxResultGetter = &MemBS{}
 
 func (bs *MemBS) Get(hash types.Hash) (*ktypes.Block, types.Hash, error) {
 	bs.mtx.RLock()
@@ -74,9 +196,27 @@ func (bs *MemBS) Have(blkid types.Hash) bool {
 	return have
 }
 
+// Store stores a block and its app hash. With WithContiguousHeights, the
+// block must be at the height after the best block, unless the store is empty.
 func (bs *MemBS) Store(block *ktypes.Block, appHash types.Hash) error {
 	bs.mtx.Lock()
 	defer bs.mtx.Unlock()
+	if bs.contiguous && len(bs.hashes) > 0 {
+		height := block.Header.Height
+		if _, have := bs.hashes[height]; have {
+			return fmt.Errorf("%w: height %d", ErrDuplicateHeight, height)
+		}
+		if height != bs.best+1 {
+			return fmt.Errorf("%w: height %d does not follow best height %d",
+				ErrHeightGap, height, bs.best)
+		}
+	}
+	bs.store(block, appHash)
+	return nil
+}
+
+// store requires bs.mtx to be locked, unless the MemBS is being constructed.
+func (bs *MemBS) store(block *ktypes.Block, appHash types.Hash) {
 	blkHash := block.Hash()
 	bs.blocks[blkHash] = block
 	bs.idx[blkHash] = block.Header.Height
@@ -84,11 +224,23 @@ func (bs *MemBS) Store(block *ktypes.Block, appHash types.Hash) error {
 		hash:    blkHash,
 		appHash: appHash,
 	}
+	if block.Header.Height > bs.best {
+		bs.best = block.Header.Height
+	}
 	for _, tx := range block.Txns {
 		txHash := types.HashBytes(tx)
 		bs.txIds[txHash] = blkHash
 	}
-	return nil
+}
+
+// Genesis returns the block at the initial height, which is 0 or 1, with its
+// hash and app hash. The error is ErrNotFound if it is not stored.
+func (bs *MemBS) Genesis() (types.Hash, *ktypes.Block, types.Hash, error) {
+	hash, blk, appHash, err := bs.GetByHeight(0)
+	if errors.Is(err, types.ErrNotFound) {
+		return bs.GetByHeight(1)
+	}
+	return hash, blk, appHash, err
 }
 
 func (bs *MemBS) StoreResults(hash types.Hash, results []ktypes.TxResult) error {
@@ -125,16 +277,33 @@ func (bs *MemBS) Result(hash types.Hash, idx uint32) (*ktypes.TxResult, error) {
 func (bs *MemBS) Best() (int64, types.Hash, types.Hash) {
 	bs.mtx.RLock()
 	defer bs.mtx.RUnlock()
-	var bestHeight int64
-	var bestHash, bestAppHash types.Hash
-	for height, hashes := range bs.hashes {
-		if height >= bestHeight {
-			bestHeight = height
-			bestHash = hashes.hash
-			bestAppHash = hashes.appHash
+	hashes := bs.hashes[bs.best]
+	return bs.best, hashes.hash, hashes.appHash
+}
+
+// Truncate removes the blocks above the given height, with their results and
+// transaction index entries.
+func (bs *MemBS) Truncate(height int64) error {
+	bs.mtx.Lock()
+	defer bs.mtx.Unlock()
+	var best int64
+	for h, hashes := range bs.hashes {
+		if h <= height {
+			best = max(best, h)
+			continue
 		}
+		if blk, have := bs.blocks[hashes.hash]; have {
+			for _, tx := range blk.Txns {
+				delete(bs.txIds, types.HashBytes(tx))
+			}
+		}
+		delete(bs.blocks, hashes.hash)
+		delete(bs.txResults, hashes.hash)
+		delete(bs.idx, hashes.hash)
+		delete(bs.hashes, h)
 	}
-	return bestHeight, bestHash, bestAppHash
+	bs.best = best
+	return nil
 }
 
 func (bs *MemBS) PreFetch(blkid types.Hash) (bool, func()) {
@@ -182,9 +351,55 @@ func (bs *MemBS) GetTx(txHash types.Hash) (tx *ktypes.Transaction, height int64,
 	return nil, 0, types.Hash{}, 0, types.ErrNotFound
 }
 
+// TxResultByHash returns the execution result of the transaction, using the
+// transaction index to find its block and its position in the block.
+func (bs *MemBS) TxResultByHash(txHash types.Hash) (*ktypes.TxResult, error) {
+	bs.mtx.RLock()
+	defer bs.mtx.RUnlock()
+	blkHash, have := bs.txIds[txHash]
+	if !have {
+		return nil, types.ErrNotFound
+	}
+	blk, have := bs.blocks[blkHash]
+	if !have {
+		return nil, types.ErrNotFound
+	}
+	res, have := bs.txResults[blkHash]
+	if !have {
+		return nil, fmt.Errorf("%w: no results for block %v", types.ErrNotFound, blkHash)
+	}
+	for idx, rawTx := range blk.Txns {
+		if types.HashBytes(rawTx) != txHash {
+			continue
+		}
+		if idx >= len(res) {
+			return nil, fmt.Errorf("%w: invalid block index", types.ErrNotFound)
+		}
+		r := res[idx]
+		return &r, nil
+	}
+	return nil, types.ErrNotFound
+}
+
 func (bs *MemBS) HaveTx(txHash types.Hash) bool {
 	bs.mtx.RLock()
 	defer bs.mtx.RUnlock()
 	_, have := bs.txIds[txHash]
 	return have
 }
+
+// TxConfirmations returns the number of confirmations of the transaction,
+// which is one for a transaction in the best block.
+func (bs *MemBS) TxConfirmations(txHash types.Hash) (int64, error) {
+	bs.mtx.RLock()
+	defer bs.mtx.RUnlock()
+	blkHash, have := bs.txIds[txHash]
+	if !have {
+		return 0, types.ErrNotFound
+	}
+	height, have := bs.idx[blkHash]
+	if !have {
+		return 0, types.ErrNotFound
+	}
+	return bs.best - height + 1, nil
+}
@@ -3,6 +3,7 @@ package memstore
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"math/big"
 	"strings"
 	"testing"
@@ -210,3 +211,307 @@ func TestMemBS_PreFetch(t *testing.T) {
 		t.Error("expected block to be unmarked as fetching after cleanup")
 	}
 }
+
+func TestMemBS_TxConfirmations(t *testing.T) {
+	bs := NewMemBS()
+
+	// One tx per block so the nonces, and thus the tx hashes, are unique.
+	var txHashes []types.Hash
+	for height := int64(1); height <= 5; height++ {
+		block, appHash, _ := createTestBlock(height, 1)
+		if err := bs.Store(block, appHash); err != nil {
+			t.Fatal(err)
+		}
+		txHashes = append(txHashes, types.HashBytes(block.Txns[0]))
+	}
+
+	tests := []struct {
+		name string
+		tx   types.Hash
+		want int64
+	}{
+		{"best block", txHashes[4], 1},
+		{"one block back", txHashes[3], 2},
+		{"several blocks back", txHashes[0], 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bs.TxConfirmations(tt.tx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %d confirmations, want %d", got, tt.want)
+			}
+		})
+	}
+
+	_, err := bs.TxConfirmations(types.Hash{1})
+	if !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for unknown tx, got %v", err)
+	}
+}
+
+func TestMemBS_TxResultByHash(t *testing.T) {
+	bs := NewMemBS()
+
+	block, appHash, _ := createTestBlock(1, 2)
+	if err := bs.Store(block, appHash); err != nil {
+		t.Fatal(err)
+	}
+	txHash := types.HashBytes(block.Txns[1])
+
+	// The tx is stored, but not its block's results.
+	_, err := bs.TxResultByHash(txHash)
+	if !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("expected ErrNotFound without results, got %v", err)
+	}
+
+	results := []ktypes.TxResult{
+		{Code: 0, Gas: 10, Log: "first"},
+		{Code: 1, Gas: 20, Log: "second"},
+	}
+	if err := bs.StoreResults(block.Hash(), results); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := bs.TxResultByHash(txHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Gas != 20 || res.Code != 1 || res.Log != "second" {
+		t.Errorf("got result %+v, want %+v", *res, results[1])
+	}
+
+	_, err = bs.TxResultByHash(types.Hash{1})
+	if !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for unknown tx, got %v", err)
+	}
+}
+
+func TestMemBS_ContiguousHeights(t *testing.T) {
+	genesis, genesisAppHash, _ := createTestBlock(1, 0)
+
+	t.Run("contiguous", func(t *testing.T) {
+		bs := NewMemBS(WithContiguousHeights(), WithGenesis(genesis, genesisAppHash))
+		for height := int64(2); height <= 4; height++ {
+			block, appHash, _ := createTestBlock(height, 1)
+			if err := bs.Store(block, appHash); err != nil {
+				t.Fatalf("store height %d: %v", height, err)
+			}
+		}
+		if height, _, _ := bs.Best(); height != 4 {
+			t.Errorf("got best height %d, want 4", height)
+		}
+
+		hash, blk, appHash, err := bs.Genesis()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hash != genesis.Hash() || blk != genesis || appHash != genesisAppHash {
+			t.Errorf("unexpected genesis block %v at height %d", hash, blk.Header.Height)
+		}
+	})
+
+	t.Run("gap", func(t *testing.T) {
+		bs := NewMemBS(WithContiguousHeights(), WithGenesis(genesis, genesisAppHash))
+		block, appHash, _ := createTestBlock(3, 1)
+		err := bs.Store(block, appHash)
+		if !errors.Is(err, ErrHeightGap) {
+			t.Fatalf("expected ErrHeightGap, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "height 3 does not follow best height 1") {
+			t.Errorf("undescriptive error: %v", err)
+		}
+		if bs.Have(block.Hash()) {
+			t.Error("rejected block was stored")
+		}
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		bs := NewMemBS(WithContiguousHeights())
+		for height := int64(5); height <= 6; height++ { // any first height
+			block, appHash, _ := createTestBlock(height, 1)
+			if err := bs.Store(block, appHash); err != nil {
+				t.Fatal(err)
+			}
+		}
+		block, appHash, _ := createTestBlock(5, 2) // different block, same height
+		if err := bs.Store(block, appHash); !errors.Is(err, ErrDuplicateHeight) {
+			t.Fatalf("expected ErrDuplicateHeight, got %v", err)
+		}
+		if _, _, _, err := bs.Genesis(); !errors.Is(err, types.ErrNotFound) {
+			t.Errorf("expected ErrNotFound for genesis, got %v", err)
+		}
+	})
+
+	t.Run("permissive", func(t *testing.T) {
+		bs := NewMemBS()
+		for _, height := range []int64{3, 1, 3} {
+			block, appHash, _ := createTestBlock(height, 1)
+			if err := bs.Store(block, appHash); err != nil {
+				t.Fatalf("store height %d: %v", height, err)
+			}
+		}
+	})
+}
+
+func TestMemBS_Clone(t *testing.T) {
+	bs := NewMemBS(WithContiguousHeights())
+	var blocks []*ktypes.Block
+	for height := int64(1); height <= 3; height++ {
+		block, appHash, _ := createTestBlock(height, 2)
+		if err := bs.Store(block, appHash); err != nil {
+			t.Fatal(err)
+		}
+		results := []ktypes.TxResult{{Log: "ok", Events: []ktypes.Event{{
+			Type:       "transfer",
+			Attributes: []ktypes.EventAttribute{{Key: "amount", Value: "1"}},
+		}}}, {Log: "ok"}}
+		if err := bs.StoreResults(block.Hash(), results); err != nil {
+			t.Fatal(err)
+		}
+		blocks = append(blocks, block)
+	}
+	origTx := bytes.Clone(blocks[0].Txns[0])
+
+	clone := bs.Clone()
+
+	// Mutate the clone: store another block, modify a stored block and its
+	// results in place, and replace results.
+	block4, appHash4, _ := createTestBlock(4, 1)
+	if err := clone.Store(block4, appHash4); err != nil {
+		t.Fatal(err)
+	}
+	if err := clone.Store(blocks[0], appHash4); !errors.Is(err, ErrDuplicateHeight) {
+		t.Fatalf("clone is not contiguous, got %v", err)
+	}
+	cloneBlk, _, err := clone.Get(blocks[0].Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cloneBlk == blocks[0] {
+		t.Fatal("clone shares a block with the original")
+	}
+	cloneBlk.Txns[0][0]++
+	cloneBlk.Header.NumTxns = 99
+	cloneBlk.Signature = append(cloneBlk.Signature, 1)
+	cloneRes, err := clone.Results(blocks[1].Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloneRes[0].Log = "changed"
+	cloneRes[0].Events[0].Attributes[0].Value = "1000"
+	if err := clone.StoreResults(blocks[2].Hash(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// The original is unchanged.
+	if height, hash, _ := bs.Best(); height != 3 || hash != blocks[2].Hash() {
+		t.Errorf("original best block changed to %v at height %d", hash, height)
+	}
+	if bs.Have(block4.Hash()) {
+		t.Error("block stored in the clone is in the original")
+	}
+	blk, _, err := bs.Get(blocks[0].Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(blk.Txns[0], origTx) || blk.Header.NumTxns != 2 || len(blk.Signature) != len(blocks[0].Signature) {
+		t.Error("original block was modified through the clone")
+	}
+	res, err := bs.Results(blocks[1].Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res[0].Log != "ok" || res[0].Events[0].Attributes[0].Value != "1" {
+		t.Errorf("original results were modified through the clone: %+v", res[0])
+	}
+	if res, err = bs.Results(blocks[2].Hash()); err != nil || len(res) != 2 {
+		t.Errorf("original results were replaced through the clone: %v, %v", res, err)
+	}
+
+	// The clone still has everything that was stored in the original.
+	for _, block := range blocks {
+		if tx := types.HashBytes(block.Txns[0]); !clone.HaveTx(tx) {
+			t.Errorf("clone is missing tx %v", tx)
+		}
+	}
+	if height, hash, _ := clone.Best(); height != 4 || hash != block4.Hash() {
+		t.Errorf("got clone best block %v at height %d", hash, height)
+	}
+}
+
+func TestMemBS_SnapshotRestore(t *testing.T) {
+	bs := NewMemBS(WithContiguousHeights())
+	block1, appHash1, _ := createTestBlock(1, 1)
+	if err := bs.Store(block1, appHash1); err != nil {
+		t.Fatal(err)
+	}
+	snap := bs.Snapshot()
+
+	// Each subtest starts from the snapshot, so it can store the same block.
+	for _, name := range []string{"first", "second"} {
+		t.Run(name, func(t *testing.T) {
+			defer bs.Restore(snap)
+
+			block2, appHash2, _ := createTestBlock(2, 1)
+			if err := bs.Store(block2, appHash2); err != nil {
+				t.Fatal(err)
+			}
+			if err := bs.StoreResults(block1.Hash(), []ktypes.TxResult{{Log: name}}); err != nil {
+				t.Fatal(err)
+			}
+			if height, _, _ := bs.Best(); height != 2 {
+				t.Errorf("got best height %d, want 2", height)
+			}
+		})
+
+		if height, hash, appHash := bs.Best(); height != 1 || hash != block1.Hash() || appHash != appHash1 {
+			t.Errorf("after %s subtest, got best block %v at height %d", name, hash, height)
+		}
+		if _, err := bs.Results(block1.Hash()); !errors.Is(err, types.ErrNotFound) {
+			t.Errorf("after %s subtest, results were not discarded: %v", name, err)
+		}
+	}
+}
+
+func TestMemBS_Truncate(t *testing.T) {
+	bs := NewMemBS(WithContiguousHeights())
+	var blocks []*ktypes.Block
+	for height := int64(1); height <= 4; height++ {
+		block, appHash, _ := createTestBlock(height, 1)
+		if err := bs.Store(block, appHash); err != nil {
+			t.Fatal(err)
+		}
+		if err := bs.StoreResults(block.Hash(), []ktypes.TxResult{{Log: "ok"}}); err != nil {
+			t.Fatal(err)
+		}
+		blocks = append(blocks, block)
+	}
+
+	if err := bs.Truncate(2); err != nil {
+		t.Fatal(err)
+	}
+
+	if height, hash, _ := bs.Best(); height != 2 || hash != blocks[1].Hash() {
+		t.Errorf("got best block %v at height %d, want height 2", hash, height)
+	}
+	for i, block := range blocks {
+		kept := block.Header.Height <= 2
+		if bs.Have(block.Hash()) != kept {
+			t.Errorf("block %d: Have = %v, want %v", i+1, !kept, kept)
+		}
+		if bs.HaveTx(types.HashBytes(block.Txns[0])) != kept {
+			t.Errorf("block %d: HaveTx = %v, want %v", i+1, !kept, kept)
+		}
+		if _, err := bs.Results(block.Hash()); (err == nil) != kept {
+			t.Errorf("block %d: unexpected Results error %v", i+1, err)
+		}
+	}
+
+	// The next block may be stored again.
+	if err := bs.Store(blocks[2], fakeAppHash(3)); err != nil {
+		t.Fatal(err)
+	}
+}
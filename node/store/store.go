@@ -361,6 +361,92 @@ func (bki *BlockStore) PreFetch(blkid types.Hash) (bool, func()) { // TODO: remo
 	} // go get it
 }
 
+// Truncate removes the blocks above the given height, with their app hashes,
+// results, and transaction index entries. The highest blocks are removed
+// first, so if it fails, the remaining blocks are still contiguous. It must not
+// be used while blocks are being stored.
+func (bki *BlockStore) Truncate(height int64) error {
+	bki.mtx.Lock()
+	defer bki.mtx.Unlock()
+
+	var heights []int64
+	for h := range bki.hashes {
+		if h > height {
+			heights = append(heights, h)
+		}
+	}
+	slices.Sort(heights)
+	slices.Reverse(heights)
+
+	for _, h := range heights {
+		hash := bki.hashes[h].hash
+		if err := bki.deleteBlock(hash); err != nil {
+			return fmt.Errorf("failed to remove block %d: %w", h, err)
+		}
+		delete(bki.idx, hash)
+		delete(bki.hashes, h)
+	}
+
+	if len(heights) > 0 {
+		bki.log.Infof("removed %d blocks above height %d", len(heights), height)
+	}
+	return nil
+}
+
+// deleteBlock deletes the block with the given hash from the db, with its app
+// hash, results, and transaction index entries.
+func (bki *BlockStore) deleteBlock(hash types.Hash) error {
+	txn := bki.db.NewTransaction(true)
+	defer txn.Discard()
+
+	var blk *ktypes.Block
+	item, err := txn.Get(slices.Concat(nsBlock, hash[:]))
+	if err != nil {
+		return err
+	}
+	err = item.Value(func(val []byte) error {
+		blk, err = ktypes.DecodeBlock(val)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	keys := [][]byte{
+		slices.Concat(nsHeader, hash[:]),
+		slices.Concat(nsBlock, hash[:]),
+		slices.Concat(nsAppHash, hash[:]),
+	}
+	for _, tx := range blk.Txns {
+		txHash := types.HashBytes(tx)
+		keys = append(keys, slices.Concat(nsTxn, txHash[:]))
+	}
+
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = slices.Concat(nsResults, hash[:])
+	opts.PrefetchValues = false
+	it := txn.NewIterator(opts)
+	for it.Rewind(); it.Valid(); it.Next() {
+		keys = append(keys, it.Item().KeyCopy(nil))
+	}
+	it.Close()
+
+	for _, key := range keys {
+		if err := txn.Delete(key); err != nil {
+			txn, err = bki.mayReplaceTx(txn, err)
+			if err != nil {
+				return err
+			} // else we recovered, so retry in the new txn
+			defer txn.Discard()
+			if err = txn.Delete(key); err != nil {
+				return err
+			}
+		}
+	}
+
+	return txn.Commit()
+}
+
 // Best returns the best block's height, hash, and appHash. The appHash is a
 // result of executing the block, not the appHash stored in the header, which is
 // from the execution of the previous block.
@@ -794,3 +794,59 @@ func (dr *deterministicPRNG) Read(p []byte) (n int, err error) {
 	}
 	return n, nil
 }
+
+func TestBlockStore_Truncate(t *testing.T) {
+	bs, dir := setupTestBlockStore(t)
+
+	var blocks []*ktypes.Block
+	for height := int64(1); height <= 4; height++ {
+		block, appHash, _ := createTestBlock(height, 1)
+		if err := bs.Store(block, appHash); err != nil {
+			t.Fatal(err)
+		}
+		if err := bs.StoreResults(block.Hash(), []ktypes.TxResult{{Log: "ok"}}); err != nil {
+			t.Fatal(err)
+		}
+		blocks = append(blocks, block)
+	}
+
+	if err := bs.Truncate(2); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(t *testing.T, bs *BlockStore) {
+		if height, hash, _ := bs.Best(); height != 2 || hash != blocks[1].Hash() {
+			t.Errorf("got best block %v at height %d, want height 2", hash, height)
+		}
+		for i, block := range blocks {
+			kept := block.Header.Height <= 2
+			if bs.Have(block.Hash()) != kept {
+				t.Errorf("block %d: Have = %v, want %v", i+1, !kept, kept)
+			}
+			if bs.HaveTx(types.HashBytes(block.Txns[0])) != kept {
+				t.Errorf("block %d: HaveTx = %v, want %v", i+1, !kept, kept)
+			}
+			if _, err := bs.Result(block.Hash(), 0); (err == nil) != kept {
+				t.Errorf("block %d: unexpected Result error %v", i+1, err)
+			}
+		}
+	}
+	check(t, bs)
+
+	// The blocks are also gone from the index that is loaded from the db.
+	bs.Close()
+	bs, err := NewBlockStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bs.Close()
+	check(t, bs)
+
+	// Nothing above the best block.
+	if err := bs.Truncate(5); err != nil {
+		t.Fatal(err)
+	}
+	if height, _, _ := bs.Best(); height != 2 {
+		t.Errorf("got best height %d, want 2", height)
+	}
+}
@@ -1,17 +1,20 @@
 package node
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"slices"
 	"time"
 
+	ktypes "github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/mempool"
 	"github.com/kwilteam/kwil-db/node/types"
 
-	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/network"
-	"github.com/libp2p/go-libp2p/core/peer"
 )
 
 var (
@@ -19,6 +22,11 @@ var (
 	ErrTxNotFound  = errors.New("tx not available")
 	ErrBlkNotFound = errors.New("block not available")
 	ErrNoResponse  = errors.New("stream closed without response")
+	ErrPeerBusy    = errors.New("peer is busy")
+
+	ErrShuttingDown = errors.New("node is shutting down")
+	ErrFutureHeight = errors.New("height is in the future")
+	ErrResetting    = errors.New("node is resetting")
 )
 
 const (
@@ -26,19 +34,11 @@ const (
 	txAnnTimeout     = 5 * time.Second // time to Write tx ann to peer
 	txAnnRespTimeout = txAnnTimeout    // time to wait for get response or a hangup
 	txGetTimeout     = 20 * time.Second
-)
 
-func getTx(ctx context.Context, txHash types.Hash, peer peer.ID, host host.Host) ([]byte, error) {
-	resID, _ := newTxHashReq(txHash).MarshalBinary()
-	rawTx, err := requestFrom(ctx, host, peer, resID, ProtocolIDTx, txReadLimit)
-	if err != nil {
-		if errors.Is(err, ErrNotFound) {
-			return nil, errors.Join(err, ErrTxNotFound)
-		}
-		return nil, fmt.Errorf("tx get request failed: %v", err)
-	}
-	return rawTx, nil
-}
+	// mempoolFileName is the file in the node's root directory where mempool
+	// is saved with WithMempoolPersistence.
+	mempoolFileName = "mempool.bin"
+)
 
 func requestTx(rw io.ReadWriter, reqMsg []byte) ([]byte, error) {
 	content, err := request(rw, reqMsg, txReadLimit)
@@ -52,24 +52,16 @@ func requestTx(rw io.ReadWriter, reqMsg []byte) ([]byte, error) {
 }
 
 func (n *Node) getTx(ctx context.Context, txHash types.Hash) ([]byte, error) {
-	for _, peer := range n.peers() {
-		n.log.Info("requesting tx", "hash", txHash, "peer", peer)
-		raw, err := getTx(ctx, txHash, peer, n.host)
-		if errors.Is(err, ErrTxNotFound) {
-			n.log.Warnf("transaction not available on %v", peer)
-			continue
-		}
-		if errors.Is(err, ErrNoResponse) {
-			n.log.Warnf("no response to tx request to %v", peer)
-			continue
-		}
-		if err != nil {
-			n.log.Warnf("unexpected error from %v: %v", peer, err)
-			continue
+	resID, _ := newTxHashReq(txHash).MarshalBinary()
+	raw, peer, err := n.requestFromPeers(ctx, n.peers(), resID, ProtocolIDTx, txReadLimit, nil)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, errors.Join(err, ErrTxNotFound)
 		}
-		return raw, nil
+		return nil, err
 	}
-	return nil, ErrTxNotFound
+	n.log.Info("obtained tx", "hash", txHash, "peer", peer)
+	return raw, nil
 }
 
 func (n *Node) getTxWithRetry(ctx context.Context, txHash types.Hash, baseDelay time.Duration,
@@ -124,3 +116,79 @@ func (n *Node) txGetStreamHandler(s network.Stream) {
 
 	// NOTE: response could also include conf/unconf or block height (-1 or N)
 }
+
+// saveMempool writes the transactions in mempool to the mempool file, if
+// mempool persistence is enabled. Start does this when the node stops, after
+// the protocol handlers have returned so that mempool is not changing.
+func (n *Node) saveMempool() {
+	if n.mempoolFile == "" {
+		return
+	}
+	txns := n.mp.PeekN(n.mp.Size())
+	if err := mempool.SaveTxs(n.mempoolFile, txns); err != nil {
+		n.log.Warn("Failed to save mempool", "error", err)
+		return
+	}
+	n.log.Info("Saved mempool", "txns", len(txns))
+}
+
+// admitTx checks a transaction and adds it to mempool. Checking it applies it
+// to the sender's unconfirmed nonce and balance, so if mempool then rejects it,
+// or evicts other transactions to make room for it, the affected accounts'
+// transactions in mempool are rechecked to undo the changes.
+func (n *Node) admitTx(ctx context.Context, txHash types.Hash, tx *ktypes.Transaction) error {
+	n.admitMtx.Lock()
+	defer n.admitMtx.Unlock()
+
+	if err := n.ce.CheckTx(ctx, tx); err != nil {
+		return err
+	}
+
+	evicted, err := n.mp.Add(txHash, tx)
+	var senders [][]byte
+	if err != nil {
+		senders = append(senders, tx.Sender)
+	}
+	for _, etx := range evicted {
+		if !slices.ContainsFunc(senders, func(sender []byte) bool {
+			return bytes.Equal(sender, etx.Tx.Sender)
+		}) {
+			senders = append(senders, etx.Tx.Sender)
+		}
+	}
+	if len(senders) > 0 {
+		n.ce.RecheckAccounts(ctx, senders)
+	}
+
+	return err
+}
+
+// restoreMempool adds the transactions from the mempool file, if mempool
+// persistence is enabled, to mempool. Each transaction is checked as if it were
+// newly received, so those that are no longer valid, such as those with nonces
+// that have since been confirmed, are dropped. The file is then removed. Start
+// does this once the consensus engine has caught up with the network.
+func (n *Node) restoreMempool(ctx context.Context) {
+	if n.mempoolFile == "" {
+		return
+	}
+	txns, err := mempool.LoadTxs(n.mempoolFile)
+	if err != nil {
+		n.log.Warn("Failed to load saved mempool", "error", err)
+		return
+	}
+	var restored int
+	for _, tx := range txns {
+		if err := n.admitTx(ctx, tx.Hash, tx.Tx); err != nil {
+			n.log.Debug("Dropping saved mempool transaction", "tx", tx.Hash, "error", err)
+			continue
+		}
+		restored++
+	}
+	if err = os.Remove(n.mempoolFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		n.log.Warn("Failed to remove saved mempool file", "error", err)
+	}
+	if len(txns) > 0 {
+		n.log.Info("Restored saved mempool", "restored", restored, "dropped", len(txns)-restored)
+	}
+}
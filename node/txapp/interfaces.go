@@ -15,6 +15,7 @@ type Accounts interface {
 	Credit(ctx context.Context, tx sql.Executor, acctID []byte, amount *big.Int) error
 	Transfer(ctx context.Context, tx sql.TxMaker, from, to []byte, amount *big.Int) error
 	GetAccount(ctx context.Context, tx sql.Executor, acctID []byte) (*types.Account, error)
+	AccountExists(ctx context.Context, tx sql.Executor, acctID []byte) (bool, error)
 	ApplySpend(ctx context.Context, tx sql.Executor, acctID []byte, amount *big.Int, nonce int64) error
 	Commit() error
 	Rollback()
@@ -59,6 +60,8 @@ var (
 	getResolutionsByThresholdAndType = voting.GetResolutionsByThresholdAndType // called from RW consensus tx
 	deleteResolutions                = voting.DeleteResolutions
 	markProcessed                    = voting.MarkProcessed
+	recordResolutionOutcome          = voting.RecordOutcome
+	pruneOutcomes                    = voting.PruneOutcomes
 	getExpired                       = voting.GetExpired
 	requiredPower                    = voting.RequiredPower
 	getResolutionsByTypeAndProposer  = voting.GetResolutionIDsByTypeAndProposer
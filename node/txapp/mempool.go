@@ -54,7 +54,14 @@ func (m *mempool) accountInfoSafe(ctx context.Context, tx sql.Executor, acctID [
 }
 
 // applyTransaction validates account specific info and applies valid transactions to the mempool state.
-func (m *mempool) applyTransaction(ctx *common.TxContext, tx *types.Transaction, dbTx sql.Executor, rebroadcaster Rebroadcaster) error {
+// When recheck is true, the transaction was already in mempool and is being
+// validated against the account states updated by a committed block. A
+// transaction with a nonce that has already been consumed is rejected in
+// either case, but when gas is enabled a recheck also rejects transactions
+// that the sender can no longer afford along with its earlier transactions in
+// mempool. The local validator's vote ID transactions that pass a recheck are
+// marked for rebroadcast, since they were not included in the block.
+func (m *mempool) applyTransaction(ctx *common.TxContext, tx *types.Transaction, dbTx sql.Executor, rebroadcaster Rebroadcaster, recheck bool) error {
 	m.acctsMtx.Lock()
 	defer m.acctsMtx.Unlock()
 
@@ -145,6 +152,16 @@ func (m *mempool) applyTransaction(ctx *common.TxContext, tx *types.Transaction,
 		return errors.New("validator vote bodies can not enter the mempool, and can only be submitted during block proposal")
 	}
 
+	gasEnabled := !ctx.BlockContext.ChainContext.NetworkParameters.DisabledGasCosts
+
+	// reject transactions that do not offer the network's minimum fee, before
+	// considering whether the sender can afford it
+	if minFee := ctx.BlockContext.ChainContext.NetworkParameters.MinFee; gasEnabled && minFee != nil &&
+		(tx.Body.Fee == nil || tx.Body.Fee.Cmp(minFee) < 0) {
+		return fmt.Errorf("%w: transaction fee %v is below the minimum fee %v", types.ErrInsufficientFee,
+			tx.Body.Fee, minFee)
+	}
+
 	// get account info from mempool state or account store
 	acct, err := m.accountInfo(ctx.Ctx, dbTx, tx.Sender)
 	if err != nil {
@@ -152,7 +169,7 @@ func (m *mempool) applyTransaction(ctx *common.TxContext, tx *types.Transaction,
 	}
 
 	// reject the transactions from unfunded user accounts in gasEnabled mode
-	if !ctx.BlockContext.ChainContext.NetworkParameters.DisabledGasCosts && acct.Nonce == 0 && acct.Balance.Sign() == 0 {
+	if gasEnabled && acct.Nonce == 0 && acct.Balance.Sign() == 0 {
 		delete(m.accounts, string(tx.Sender))
 		return types.ErrInsufficientBalance
 	}
@@ -166,20 +183,8 @@ func (m *mempool) applyTransaction(ctx *common.TxContext, tx *types.Transaction,
 		// then mark the events for rebroadcast before discarding the transaction
 		// as the votes for these events are not yet received by the network.
 
-		fromLocalValidator := bytes.Equal(tx.Sender, m.nodeAddr) // Check if the transaction is from the local node
-
-		if tx.Body.PayloadType == types.PayloadTypeValidatorVoteIDs && fromLocalValidator {
-			// Mark these ids for rebroadcast
-			voteID := &types.ValidatorVoteIDs{}
-			err = voteID.UnmarshalBinary(tx.Body.Payload)
-			if err != nil {
-				return err
-			}
-
-			err = rebroadcaster.MarkRebroadcast(ctx.Ctx, voteID.ResolutionIDs)
-			if err != nil {
-				return err
-			}
+		if err = m.markRebroadcast(ctx.Ctx, tx, rebroadcaster); err != nil {
+			return err
 		}
 		return fmt.Errorf("%w for account %s: got %d, expected %d", types.ErrInvalidNonce,
 			hex.EncodeToString(tx.Sender), tx.Body.Nonce, acct.Nonce+1)
@@ -204,6 +209,10 @@ func (m *mempool) applyTransaction(ctx *common.TxContext, tx *types.Transaction,
 			return errors.Join(types.ErrInvalidAmount, errors.New("negative transfer not permitted"))
 		}
 
+		if bytes.Equal(transfer.To, tx.Sender) {
+			return errors.New("transfer to self not permitted")
+		}
+
 		if amt.Cmp(acct.Balance) > 0 {
 			return types.ErrInsufficientBalance
 		}
@@ -211,6 +220,16 @@ func (m *mempool) applyTransaction(ctx *common.TxContext, tx *types.Transaction,
 		spend.Add(spend, amt)
 	}
 
+	// A transaction that was admitted to mempool may no longer be affordable
+	// after the sender's balance is updated by a block. The sender's earlier
+	// transactions in mempool were rechecked first and their spends deducted
+	// from the pending balance, so this compares the total queued spend with
+	// the confirmed balance.
+	if recheck && gasEnabled && spend.Cmp(acct.Balance) > 0 {
+		return fmt.Errorf("%w for account %s: spend %v, pending balance %v", types.ErrInsufficientBalance,
+			hex.EncodeToString(tx.Sender), spend, acct.Balance)
+	}
+
 	// We'd check balance against the total spend (fees plus value sent) if we
 	// know gas is enabled. Transfers must be funded regardless of transaction
 	// gas requirement:
@@ -237,9 +256,28 @@ func (m *mempool) applyTransaction(ctx *common.TxContext, tx *types.Transaction,
 
 	m.log.Info("applied transaction to mempool state", "account", hex.EncodeToString(tx.Sender), "nonce", acct.Nonce, "balance", acct.Balance)
 
+	if recheck {
+		return m.markRebroadcast(ctx.Ctx, tx, rebroadcaster)
+	}
+
 	return nil
 }
 
+// markRebroadcast marks the resolutions voted for by a vote ID transaction from
+// the local validator for rebroadcast. Other transactions are ignored.
+func (m *mempool) markRebroadcast(ctx context.Context, tx *types.Transaction, rebroadcaster Rebroadcaster) error {
+	if tx.Body.PayloadType != types.PayloadTypeValidatorVoteIDs || !bytes.Equal(tx.Sender, m.nodeAddr) {
+		return nil
+	}
+
+	voteID := &types.ValidatorVoteIDs{}
+	if err := voteID.UnmarshalBinary(tx.Body.Payload); err != nil {
+		return err
+	}
+
+	return rebroadcaster.MarkRebroadcast(ctx, voteID.ResolutionIDs)
+}
+
 // reset clears the in-memory unconfirmed account states.
 // This should be done at the end of block commit.
 func (m *mempool) reset() {
@@ -248,3 +286,14 @@ func (m *mempool) reset() {
 
 	m.accounts = make(map[string]*types.Account)
 }
+
+// resetAccounts clears the in-memory unconfirmed states of the given accounts,
+// so they are loaded from the account store when next needed.
+func (m *mempool) resetAccounts(acctIDs [][]byte) {
+	m.acctsMtx.Lock()
+	defer m.acctsMtx.Unlock()
+
+	for _, acctID := range acctIDs {
+		delete(m.accounts, string(acctID))
+	}
+}
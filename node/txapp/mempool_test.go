@@ -9,6 +9,8 @@ import (
 	"github.com/kwilteam/kwil-db/core/crypto/auth"
 	"github.com/kwilteam/kwil-db/core/log"
 	"github.com/kwilteam/kwil-db/core/types"
+	nodemempool "github.com/kwilteam/kwil-db/node/mempool"
+	nodetypes "github.com/kwilteam/kwil-db/node/types"
 	"github.com/kwilteam/kwil-db/node/types/sql"
 
 	"github.com/stretchr/testify/assert"
@@ -38,31 +40,31 @@ func Test_MempoolWithoutGas(t *testing.T) {
 	}
 
 	// Successful transaction A: 1
-	err := m.applyTransaction(txCtx, newTx(t, 1, "A"), db, rebroadcast)
+	err := m.applyTransaction(txCtx, newTx(t, 1, "A"), db, rebroadcast, false)
 	assert.NoError(t, err)
 	assert.EqualValues(t, m.accounts["A"].Nonce, 1)
 
 	// Successful transaction A: 2
-	err = m.applyTransaction(txCtx, newTx(t, 2, "A"), db, rebroadcast)
+	err = m.applyTransaction(txCtx, newTx(t, 2, "A"), db, rebroadcast, false)
 	assert.NoError(t, err)
 	assert.EqualValues(t, m.accounts["A"].Nonce, 2)
 
 	// Duplicate nonce failure
-	err = m.applyTransaction(txCtx, newTx(t, 2, "A"), db, rebroadcast)
+	err = m.applyTransaction(txCtx, newTx(t, 2, "A"), db, rebroadcast, false)
 	assert.Error(t, err)
 	assert.EqualValues(t, m.accounts["A"].Nonce, 2)
 
 	// Invalid order
-	err = m.applyTransaction(txCtx, newTx(t, 4, "A"), db, rebroadcast)
+	err = m.applyTransaction(txCtx, newTx(t, 4, "A"), db, rebroadcast, false)
 	assert.Error(t, err)
 	assert.EqualValues(t, m.accounts["A"].Nonce, 2)
 
-	err = m.applyTransaction(txCtx, newTx(t, 3, "A"), db, rebroadcast)
+	err = m.applyTransaction(txCtx, newTx(t, 3, "A"), db, rebroadcast, false)
 	assert.NoError(t, err)
 	assert.EqualValues(t, m.accounts["A"].Nonce, 3)
 
 	// Recheck nonce 4 transaction
-	err = m.applyTransaction(txCtx, newTx(t, 4, "A"), db, rebroadcast)
+	err = m.applyTransaction(txCtx, newTx(t, 4, "A"), db, rebroadcast, false)
 	assert.NoError(t, err)
 	assert.EqualValues(t, m.accounts["A"].Nonce, 4)
 }
@@ -90,11 +92,11 @@ func Test_MempoolWithGas(t *testing.T) {
 
 	// Transaction from Unknown sender should fail
 	tx := newTx(t, 1, "A")
-	err := m.applyTransaction(txCtx, tx, db, rebroadcast)
+	err := m.applyTransaction(txCtx, tx, db, rebroadcast, false)
 	assert.Error(t, err)
 
 	// Resubmitting the same transaction should fail
-	err = m.applyTransaction(txCtx, tx, db, rebroadcast)
+	err = m.applyTransaction(txCtx, tx, db, rebroadcast, false)
 	assert.Error(t, err)
 
 	// Credit account A
@@ -105,10 +107,253 @@ func Test_MempoolWithGas(t *testing.T) {
 	}
 
 	// Successful transaction A: 1
-	err = m.applyTransaction(txCtx, tx, db, rebroadcast)
+	err = m.applyTransaction(txCtx, tx, db, rebroadcast, false)
 	assert.NoError(t, err)
 }
 
+func Test_MempoolMinFee(t *testing.T) {
+	txCtx := func(disabledGas bool) *common.TxContext {
+		return &common.TxContext{
+			Ctx: context.Background(),
+			BlockContext: &common.BlockContext{
+				ChainContext: &common.ChainContext{
+					NetworkParameters: &common.NetworkParameters{
+						DisabledGasCosts: disabledGas,
+						MinFee:           big.NewInt(10),
+					},
+				},
+			},
+		}
+	}
+	db := &mockDb{}
+	rebroadcast := &mockRebroadcast{}
+
+	newFeeTx := func(nonce uint64, fee int64) *types.Transaction {
+		tx := newTx(t, nonce, "A")
+		tx.Body.Fee = big.NewInt(fee)
+		return tx
+	}
+
+	t.Run("below floor", func(t *testing.T) {
+		// The fee check comes before the balance check, so the error is not
+		// an insufficient balance although the account is unfunded.
+		m := &mempool{
+			accounts:   make(map[string]*types.Account),
+			accountMgr: &mockAccount{},
+			log:        log.DiscardLogger,
+		}
+		err := m.applyTransaction(txCtx(false), newFeeTx(1, 9), db, rebroadcast, false)
+		assert.ErrorIs(t, err, types.ErrInsufficientFee)
+		assert.NotErrorIs(t, err, types.ErrInsufficientBalance)
+	})
+
+	t.Run("at floor", func(t *testing.T) {
+		m := &mempool{
+			accounts: map[string]*types.Account{
+				"A": {Identifier: []byte("A"), Balance: big.NewInt(100)},
+			},
+			accountMgr: &mockAccount{},
+			log:        log.DiscardLogger,
+		}
+		err := m.applyTransaction(txCtx(false), newFeeTx(1, 10), db, rebroadcast, false)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, m.accounts["A"].Nonce)
+	})
+
+	t.Run("gas disabled", func(t *testing.T) {
+		m := &mempool{
+			accounts:   make(map[string]*types.Account),
+			accountMgr: &mockAccount{},
+			log:        log.DiscardLogger,
+		}
+		err := m.applyTransaction(txCtx(true), newFeeTx(1, 0), db, rebroadcast, false)
+		assert.NoError(t, err)
+	})
+}
+
+func Test_MempoolRecheck(t *testing.T) {
+	// The confirmed account states, updated when a block is committed.
+	accounts := &storedAccounts{accts: map[string]*types.Account{
+		"A": {Identifier: []byte("A"), Balance: big.NewInt(100)},
+		"B": {Identifier: []byte("B"), Balance: big.NewInt(100)},
+		"V": {Identifier: []byte("V"), Balance: big.NewInt(100)},
+	}}
+	m := &mempool{
+		accounts:   make(map[string]*types.Account),
+		accountMgr: accounts,
+		nodeAddr:   []byte("V"),
+		validatorMgr: &mockValidator{
+			getVoterFn: func() (int64, error) { return 1, nil },
+		},
+		log: log.DiscardLogger,
+	}
+
+	txCtx := &common.TxContext{
+		Ctx: context.Background(),
+		BlockContext: &common.BlockContext{
+			ChainContext: &common.ChainContext{
+				NetworkParameters: &common.NetworkParameters{
+					DisabledGasCosts: false,
+					MaxVotesPerTx:    10,
+				},
+			},
+		},
+	}
+	db := &mockDb{}
+	rebroadcast := &mockRebroadcast{}
+
+	newFeeTx := func(nonce uint64, sender string, fee int64) *types.Transaction {
+		tx := newTx(t, nonce, sender)
+		tx.Body.Fee = big.NewInt(fee)
+		return tx
+	}
+
+	// A vote ID tx from the local validator.
+	voteID := types.NewUUIDV5([]byte("resolution"))
+	voteTx := newFeeTx(1, "V", 10)
+	voteTx.Body.PayloadType = types.PayloadTypeValidatorVoteIDs
+	payload, err := (&types.ValidatorVoteIDs{ResolutionIDs: []*types.UUID{voteID}}).MarshalBinary()
+	assert.NoError(t, err)
+	voteTx.Body.Payload = payload
+
+	mp := nodemempool.New()
+	for i, tx := range []*types.Transaction{
+		newFeeTx(1, "A", 10),
+		newFeeTx(2, "A", 10),
+		newFeeTx(3, "A", 10),
+		newFeeTx(4, "A", 10),
+		newFeeTx(1, "B", 10),
+		newFeeTx(2, "B", 10),
+		newFeeTx(3, "B", 10),
+		voteTx,
+	} {
+		err := m.applyTransaction(txCtx, tx, db, rebroadcast, false)
+		assert.NoError(t, err)
+		assert.NoError(t, mp.Store(nodetypes.Hash{byte(i)}, tx))
+	}
+
+	// A different tx with A's nonce 2 (e.g. broadcast to another node) is
+	// committed, along with A's nonce 1 and B's nonce 1. B is left with a
+	// balance that can afford either of its remaining txs, but not both.
+	accounts.accts["A"] = &types.Account{Identifier: []byte("A"), Balance: big.NewInt(100), Nonce: 2}
+	accounts.accts["B"] = &types.Account{Identifier: []byte("B"), Balance: big.NewInt(15), Nonce: 1}
+	mp.Remove(nodetypes.Hash{0}) // A1
+	mp.Remove(nodetypes.Hash{4}) // B1
+	m.reset()
+
+	mp.RecheckTxs(context.Background(), func(_ context.Context, tx *types.Transaction, recheck bool) error {
+		assert.True(t, recheck)
+		return m.applyTransaction(txCtx, tx, db, rebroadcast, recheck)
+	})
+
+	// A's duplicate nonce 2 is dropped, while 3 and 4 remain. B can afford
+	// nonce 2, but not nonce 3 after it.
+	assert.Equal(t, 4, mp.Size())
+	assert.False(t, mp.Have(nodetypes.Hash{1}))
+	assert.True(t, mp.Have(nodetypes.Hash{2}))
+	assert.True(t, mp.Have(nodetypes.Hash{3}))
+	assert.True(t, mp.Have(nodetypes.Hash{5}))
+	assert.False(t, mp.Have(nodetypes.Hash{6}))
+	assert.True(t, mp.Have(nodetypes.Hash{7}))
+	assert.EqualValues(t, 4, m.accounts["A"].Nonce)
+	assert.EqualValues(t, 2, m.accounts["B"].Nonce)
+	assert.EqualValues(t, 5, m.accounts["B"].Balance.Int64())
+
+	// The local validator's vote IDs that are still in mempool are marked
+	// for rebroadcast.
+	assert.Equal(t, []*types.UUID{voteID}, rebroadcast.marked)
+
+	// The next tx for A is still accepted.
+	err = m.applyTransaction(txCtx, newFeeTx(5, "A", 10), db, rebroadcast, false)
+	assert.NoError(t, err)
+}
+
+func Test_MempoolTransfer(t *testing.T) {
+	accounts := &storedAccounts{accts: map[string]*types.Account{
+		"A": {Identifier: []byte("A"), Balance: big.NewInt(100)},
+	}}
+	m := &mempool{
+		accounts:   make(map[string]*types.Account),
+		accountMgr: accounts,
+		log:        log.DiscardLogger,
+	}
+
+	txCtx := &common.TxContext{
+		Ctx: context.Background(),
+		BlockContext: &common.BlockContext{
+			ChainContext: &common.ChainContext{
+				NetworkParameters: &common.NetworkParameters{},
+			},
+		},
+	}
+	db := &mockDb{}
+	rebroadcast := &mockRebroadcast{}
+
+	newTransferTx := func(nonce uint64, to string, amt int64) *types.Transaction {
+		tx := newTx(t, nonce, "A")
+		tx.Body.PayloadType = types.PayloadTypeTransfer
+		payload, err := (&types.Transfer{To: []byte(to), Amount: big.NewInt(amt).String()}).MarshalBinary()
+		assert.NoError(t, err)
+		tx.Body.Payload = payload
+		return tx
+	}
+
+	// Successful transfer reduces the pending balance.
+	err := m.applyTransaction(txCtx, newTransferTx(1, "B", 60), db, rebroadcast, false)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, m.accounts["A"].Nonce)
+	assert.EqualValues(t, 40, m.accounts["A"].Balance.Int64())
+
+	// Insufficient pending balance, and the nonce is not consumed.
+	err = m.applyTransaction(txCtx, newTransferTx(2, "B", 50), db, rebroadcast, false)
+	assert.ErrorIs(t, err, types.ErrInsufficientBalance)
+	assert.EqualValues(t, 1, m.accounts["A"].Nonce)
+	assert.EqualValues(t, 40, m.accounts["A"].Balance.Int64())
+
+	// Transfer to self is rejected.
+	err = m.applyTransaction(txCtx, newTransferTx(2, "A", 10), db, rebroadcast, false)
+	assert.Error(t, err)
+	assert.EqualValues(t, 1, m.accounts["A"].Nonce)
+
+	// Reused and skipped nonces are rejected.
+	err = m.applyTransaction(txCtx, newTransferTx(1, "B", 10), db, rebroadcast, false)
+	assert.ErrorIs(t, err, types.ErrInvalidNonce)
+	err = m.applyTransaction(txCtx, newTransferTx(3, "B", 10), db, rebroadcast, false)
+	assert.ErrorIs(t, err, types.ErrInvalidNonce)
+
+	// The next nonce is still available.
+	err = m.applyTransaction(txCtx, newTransferTx(2, "B", 40), db, rebroadcast, false)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, m.accounts["A"].Nonce)
+	assert.Zero(t, m.accounts["A"].Balance.Sign())
+}
+
+// storedAccounts is a mockAccount that returns the accounts in a map.
+type storedAccounts struct {
+	mockAccount
+	accts map[string]*types.Account
+}
+
+func (a *storedAccounts) GetAccount(_ context.Context, _ sql.Executor, acctID []byte) (*types.Account, error) {
+	acct, ok := a.accts[string(acctID)]
+	if !ok {
+		return &types.Account{
+			Identifier: acctID,
+			Balance:    big.NewInt(0),
+		}, nil
+	}
+	return &types.Account{
+		Identifier: acct.Identifier,
+		Balance:    new(big.Int).Set(acct.Balance),
+		Nonce:      acct.Nonce,
+	}, nil
+}
+
+func (a *storedAccounts) AccountExists(_ context.Context, _ sql.Executor, acctID []byte) (bool, error) {
+	_, ok := a.accts[string(acctID)]
+	return ok, nil
+}
+
 func newTx(_ *testing.T, nonce uint64, sender string) *types.Transaction {
 	return &types.Transaction{
 		Signature: &auth.Signature{},
@@ -144,8 +389,11 @@ func (m *mockTx) Rollback(ctx context.Context) error {
 	return nil
 }
 
-type mockRebroadcast struct{}
+type mockRebroadcast struct {
+	marked []*types.UUID
+}
 
 func (m *mockRebroadcast) MarkRebroadcast(ctx context.Context, ids []*types.UUID) error {
+	m.marked = append(m.marked, ids...)
 	return nil
 }
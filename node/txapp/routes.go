@@ -29,6 +29,7 @@ func init() {
 		RegisterRoute(types.PayloadTypeValidatorApprove, NewRoute(&validatorApproveRoute{})),
 		RegisterRoute(types.PayloadTypeValidatorRemove, NewRoute(&validatorRemoveRoute{})),
 		RegisterRoute(types.PayloadTypeValidatorLeave, NewRoute(&validatorLeaveRoute{})),
+		RegisterRoute(types.PayloadTypeValidatorKeyUpdate, NewRoute(&validatorKeyUpdateRoute{})),
 		RegisterRoute(types.PayloadTypeValidatorVoteIDs, NewRoute(&validatorVoteIDsRoute{})),
 		RegisterRoute(types.PayloadTypeValidatorVoteBodies, NewRoute(&validatorVoteBodiesRoute{})),
 		RegisterRoute(types.PayloadTypeCreateResolution, NewRoute(&createResolutionRoute{})),
@@ -405,6 +406,10 @@ func (d *transferRoute) PreTx(ctx *common.TxContext, svc *common.Service, tx *ty
 		return types.CodeInvalidAmount, fmt.Errorf("invalid transfer amount: %s", transferBody.Amount)
 	}
 
+	if bytes.Equal(transferBody.To, tx.Sender) {
+		return types.CodeInvalidReceiver, errors.New("cannot transfer to self")
+	}
+
 	d.to = transferBody.To
 	d.amt = bigAmt
 	return 0, nil
@@ -419,6 +424,9 @@ func (d *transferRoute) InTx(ctx *common.TxContext, app *common.App, tx *types.T
 		if errors.Is(err, accounts.ErrNegativeBalance) {
 			return types.CodeInvalidAmount, err
 		}
+		if errors.Is(err, accounts.ErrSelfTransfer) {
+			return types.CodeInvalidReceiver, err
+		}
 		return types.CodeUnknownError, err
 	}
 	return 0, nil
@@ -690,6 +698,96 @@ func (d *validatorLeaveRoute) InTx(ctx *common.TxContext, app *common.App, tx *t
 	return 0, nil
 }
 
+// validatorKeyUpdateRoute is a route for a validator to request that its key be
+// replaced. It creates a resolution that the proposing validator approves, and
+// the other validators may approve with approve_resolution.
+type validatorKeyUpdateRoute struct {
+	newPubKey []byte
+}
+
+var _ consensus.Route = (*validatorKeyUpdateRoute)(nil)
+
+func (d *validatorKeyUpdateRoute) Name() string {
+	return types.PayloadTypeValidatorKeyUpdate.String()
+}
+
+func (d *validatorKeyUpdateRoute) Price(ctx context.Context, app *common.App, tx *types.Transaction) (*big.Int, error) {
+	return big.NewInt(10000000000000), nil
+}
+
+func (d *validatorKeyUpdateRoute) PreTx(ctx *common.TxContext, svc *common.Service, tx *types.Transaction) (types.TxCode, error) {
+	if ctx.BlockContext.ChainContext.NetworkParameters.MigrationStatus == types.MigrationInProgress ||
+		ctx.BlockContext.ChainContext.NetworkParameters.MigrationStatus == types.MigrationCompleted {
+		return types.CodeNetworkInMigration, errors.New("cannot update validator key during migration")
+	}
+
+	update := &types.ValidatorKeyUpdate{}
+	err := update.UnmarshalBinary(tx.Body.Payload)
+	if err != nil {
+		return types.CodeEncodingError, err
+	}
+
+	if err = update.Verify(tx.Sender); err != nil {
+		return types.CodeInvalidSignature, fmt.Errorf("invalid key update: %w", err)
+	}
+
+	d.newPubKey = update.NewPubKey
+	return 0, nil
+}
+
+func (d *validatorKeyUpdateRoute) InTx(ctx *common.TxContext, app *common.App, tx *types.Transaction) (types.TxCode, error) {
+	power, err := app.Validators.GetValidatorPower(ctx.Ctx, tx.Sender)
+	if err != nil {
+		return types.CodeUnknownError, err
+	}
+	if power <= 0 {
+		return types.CodeInvalidSender, ErrCallerNotValidator
+	}
+
+	// the new key must not be a validator already, in which case the update
+	// would merge two validators
+	for _, val := range app.Validators.GetValidators() {
+		if bytes.Equal(val.PubKey, d.newPubKey) {
+			return types.CodeInvalidSender, errors.New("new key is already a validator")
+		}
+	}
+
+	pending, err := getResolutionsByTypeAndProposer(ctx.Ctx, app.DB, voting.ValidatorKeyUpdateEventType, tx.Sender)
+	if err != nil {
+		return types.CodeUnknownError, err
+	}
+	if len(pending) > 0 {
+		return types.CodeInvalidSender, errors.New("validator already has a pending key update")
+	}
+
+	updateReq := &voting.KeyUpdateRequest{
+		OldPubKey: tx.Sender,
+		NewPubKey: d.newPubKey,
+	}
+	bts, err := updateReq.MarshalBinary()
+	if err != nil {
+		return types.CodeUnknownError, err
+	}
+
+	event := &types.VotableEvent{
+		Body: bts,
+		Type: voting.ValidatorKeyUpdateEventType,
+	}
+
+	err = createResolution(ctx.Ctx, app.DB, event, ctx.BlockContext.Height+ctx.BlockContext.ChainContext.NetworkParameters.JoinExpiry, tx.Sender)
+	if err != nil {
+		return types.CodeUnknownError, err
+	}
+
+	// the validator votes for its own key update
+	err = approveResolution(ctx.Ctx, app.DB, event.ID(), tx.Sender)
+	if err != nil {
+		return types.CodeUnknownError, err
+	}
+
+	return 0, nil
+}
+
 // validatorVoteIDsRoute is a route for approving a set of votes based on their IDs.
 type validatorVoteIDsRoute struct{}
 
@@ -223,6 +223,59 @@ func Test_Routes(t *testing.T) {
 			from: signer2,
 			err:  ErrCallerNotProposer,
 		},
+		{
+			// a key update from a non-validator should fail
+			name: "validator_key_update, as non-validator",
+			fee:  10000000000000,
+			getVoterPower: func() (int64, error) {
+				return 0, nil
+			},
+			fn: func(t *testing.T, callback func()) {
+				createCount := 0
+				createResolution = func(_ context.Context, _ sql.TxMaker, _ *types.VotableEvent, _ int64, _ []byte) error {
+					createCount++
+					return nil
+				}
+
+				callback()
+				assert.Equal(t, 0, createCount)
+			},
+			payload: newKeyUpdate(signer1),
+			from:    signer1,
+			err:     ErrCallerNotValidator,
+		},
+		{
+			// a key update from a validator creates a resolution, and votes for it
+			name: "validator_key_update, as validator",
+			fee:  10000000000000,
+			getVoterPower: func() (int64, error) {
+				return 1, nil
+			},
+			fn: func(t *testing.T, callback func()) {
+				createCount := 0
+				approveCount := 0
+
+				getResolutionsByTypeAndProposer = func(_ context.Context, _ sql.Executor, _ string, _ []byte) ([]*types.UUID, error) {
+					return nil, nil
+				}
+				createResolution = func(_ context.Context, _ sql.TxMaker, event *types.VotableEvent, _ int64, _ []byte) error {
+					assert.Equal(t, voting.ValidatorKeyUpdateEventType, event.Type)
+					createCount++
+					return nil
+				}
+				approveResolution = func(_ context.Context, _ sql.TxMaker, _ *types.UUID, from []byte) error {
+					assert.Equal(t, signer2.Identity(), from)
+					approveCount++
+					return nil
+				}
+
+				callback()
+				assert.Equal(t, 1, createCount)
+				assert.Equal(t, 1, approveCount)
+			},
+			payload: newKeyUpdate(signer2),
+			from:    signer2,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -309,6 +362,10 @@ func (a *mockAccount) GetAccount(_ context.Context, _ sql.Executor, acctID []byt
 	}, nil
 }
 
+func (a *mockAccount) AccountExists(_ context.Context, _ sql.Executor, acctID []byte) (bool, error) {
+	return false, nil
+}
+
 func (a *mockAccount) Spend(_ context.Context, _ sql.Executor, acctID []byte, amount *big.Int, nonce int64) error {
 	return nil
 }
@@ -352,6 +409,20 @@ func (v *mockValidator) Commit() error {
 
 func (v *mockValidator) Rollback() {}
 
+// newKeyUpdate creates a payload to replace the key of the signer with a new
+// key.
+func newKeyUpdate(signer auth.Signer) *types.ValidatorKeyUpdate {
+	newKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	if err != nil {
+		panic(err)
+	}
+	update, err := types.NewValidatorKeyUpdate(signer.Identity(), newKey)
+	if err != nil {
+		panic(err)
+	}
+	return update
+}
+
 func getSigner(hexPrivKey string) auth.Signer {
 	//pk, _, err := crypto.GenerateSecp256k1Key(nil)
 	bts, err := hex.DecodeString(hexPrivKey)
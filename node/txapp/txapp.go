@@ -176,6 +176,21 @@ func (r *TxApp) Rollback() {
 	r.mempool.reset() // will issue recheck before next block
 }
 
+// outcomeRetention is the number of blocks for which the outcome of a validator
+// join or remove resolution is kept after it is resolved. This affects the app
+// state, so it must be the same on all nodes.
+const outcomeRetention = 100_000
+
+// recordOutcome records the outcome of validator join and remove resolutions,
+// which are not marked as processed, so that their status may be queried after
+// they are deleted.
+func recordOutcome(ctx context.Context, db sql.Executor, resolution *resolutions.Resolution, outcome voting.Outcome, height int64) error {
+	if resolution.Type != voting.ValidatorJoinEventType && resolution.Type != voting.ValidatorRemoveEventType {
+		return nil
+	}
+	return recordResolutionOutcome(ctx, db, resolution, outcome, height)
+}
+
 // processVotes confirms resolutions that have been approved by the network,
 // expires resolutions that have expired, and properly credits proposers and voters.
 func (r *TxApp) processVotes(ctx context.Context, db sql.DB, block *common.BlockContext) error {
@@ -252,6 +267,9 @@ func (r *TxApp) processVotes(ctx context.Context, db sql.DB, block *common.Block
 			// if the resolveFunc fails, we should still continue on, since it simply means
 			// some business logic failed in a deployed schema.
 			r.service.Logger.Warn("error resolving resolution", "type", resolveFunc.Resolution.Type, "id", resolveFunc.Resolution.ID.String(), "error", err)
+			if err = recordOutcome(ctx, db, resolveFunc.Resolution, voting.OutcomeRejected, block.Height); err != nil {
+				return err
+			}
 			continue
 		}
 
@@ -259,6 +277,10 @@ func (r *TxApp) processVotes(ctx context.Context, db sql.DB, block *common.Block
 		if err != nil {
 			return err
 		}
+
+		if err = recordOutcome(ctx, db, resolveFunc.Resolution, voting.OutcomeApproved, block.Height); err != nil {
+			return err
+		}
 	}
 
 	// now we will expire resolutions
@@ -293,6 +315,10 @@ func (r *TxApp) processVotes(ctx context.Context, db sql.DB, block *common.Block
 		}
 
 		r.service.Logger.Debug("expiring resolution", "type", resolution.Type, "id", resolution.ID.String(), "refunded", refunded)
+
+		if err = recordOutcome(ctx, db, resolution, voting.OutcomeExpired, block.Height); err != nil {
+			return err
+		}
 	}
 
 	allIDs := append(finalizedIDs, expiredIDs...)
@@ -314,6 +340,13 @@ func (r *TxApp) processVotes(ctx context.Context, db sql.DB, block *common.Block
 		return err
 	}
 
+	if block.Height > outcomeRetention {
+		err = pruneOutcomes(ctx, db, block.Height-outcomeRetention)
+		if err != nil {
+			return err
+		}
+	}
+
 	// now we will apply credits if gas is enabled.
 	// Since it is a map, we need to order it for deterministic results.
 	if !block.ChainContext.NetworkParameters.DisabledGasCosts {
@@ -528,19 +561,31 @@ func (r *TxApp) GetBlockSpends() []*Spend { // If we track spends in the account
 }
 
 // ApplyMempool applies the transactions in the mempool.
-// If it returns an error, then the transaction is invalid.
-func (r *TxApp) ApplyMempool(ctx *common.TxContext, db sql.DB, tx *types.Transaction) error {
+// If it returns an error, then the transaction is invalid. A recheck is done
+// for transactions that remain in mempool after a block is committed, and it
+// also drops transactions with fees the sender can no longer afford.
+func (r *TxApp) ApplyMempool(ctx *common.TxContext, db sql.DB, tx *types.Transaction, recheck bool) error {
 	// check that payload type is valid
 	if getRoute(tx.Body.PayloadType.String()) == nil {
 		return fmt.Errorf("unknown payload type: %s", tx.Body.PayloadType.String())
 	}
 
-	return r.mempool.applyTransaction(ctx, tx, db, r.events)
+	return r.mempool.applyTransaction(ctx, tx, db, r.events, recheck)
+}
+
+// ResetMempoolAccounts discards the unconfirmed states of the given accounts
+// that were built by ApplyMempool. This is used when transactions that were
+// applied do not remain in mempool. The accounts' remaining transactions in
+// mempool must then be rechecked to rebuild their states.
+func (r *TxApp) ResetMempoolAccounts(acctIDs [][]byte) {
+	r.mempool.resetAccounts(acctIDs)
 }
 
 // AccountInfo gets account info from either the mempool or the account store.
-// It takes a flag to indicate whether it should check the mempool first.
-func (r *TxApp) AccountInfo(ctx context.Context, db sql.DB, acctID []byte, getUnconfirmed bool) (balance *big.Int, nonce int64, err error) {
+// It takes a flag to indicate whether it should check the mempool first. An
+// account that does not exist has a zero balance and nonce, and exists is
+// false. With getUnconfirmed, an account with transactions in mempool exists.
+func (r *TxApp) AccountInfo(ctx context.Context, db sql.DB, acctID []byte, getUnconfirmed bool) (balance *big.Int, nonce int64, exists bool, err error) {
 	var a *types.Account
 	if getUnconfirmed {
 		a, err = r.mempool.accountInfoSafe(ctx, db, acctID)
@@ -548,10 +593,20 @@ func (r *TxApp) AccountInfo(ctx context.Context, db sql.DB, acctID []byte, getUn
 		a, err = r.Accounts.GetAccount(ctx, db, acctID)
 	}
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, false, err
+	}
+
+	// Only an account with a zero nonce may not exist, such as an account that
+	// was credited but has not sent a transaction.
+	exists = a.Nonce > 0
+	if !exists {
+		exists, err = r.Accounts.AccountExists(ctx, db, acctID)
+		if err != nil {
+			return nil, 0, false, err
+		}
 	}
 
-	return a.Balance, a.Nonce, nil
+	return a.Balance, a.Nonce, exists, nil
 }
 
 // UpdateValidator updates a validator's power.
@@ -0,0 +1,51 @@
+package txapp
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/kwilteam/kwil-db/core/log"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountInfo(t *testing.T) {
+	accts := &storedAccounts{
+		accts: map[string]*types.Account{
+			"empty":  {Identifier: []byte("empty"), Balance: big.NewInt(0)},
+			"funded": {Identifier: []byte("funded"), Balance: big.NewInt(100), Nonce: 2},
+		},
+	}
+	app := &TxApp{
+		Accounts: accts,
+		mempool: &mempool{
+			accounts:   make(map[string]*types.Account),
+			accountMgr: accts,
+			log:        log.DiscardLogger,
+		},
+	}
+	ctx := context.Background()
+	db := &mockDb{}
+
+	for _, unconfirmed := range []bool{false, true} {
+		balance, nonce, exists, err := app.AccountInfo(ctx, db, []byte("unknown"), unconfirmed)
+		require.NoError(t, err)
+		assert.False(t, exists)
+		assert.Zero(t, balance.Sign())
+		assert.Zero(t, nonce)
+
+		balance, nonce, exists, err = app.AccountInfo(ctx, db, []byte("empty"), unconfirmed)
+		require.NoError(t, err)
+		assert.True(t, exists)
+		assert.Zero(t, balance.Sign())
+		assert.Zero(t, nonce)
+
+		balance, nonce, exists, err = app.AccountInfo(ctx, db, []byte("funded"), unconfirmed)
+		require.NoError(t, err)
+		assert.True(t, exists)
+		assert.EqualValues(t, 100, balance.Int64())
+		assert.EqualValues(t, 2, nonce)
+	}
+}
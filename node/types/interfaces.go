@@ -21,6 +21,10 @@ type BlockStore interface {
 
 	PreFetch(Hash) (bool, func()) // should be app level instead (TODO: remove)
 
+	// Truncate removes the blocks above the given height, with their results
+	// and transaction index entries.
+	Truncate(height int64) error
+
 	Close() error
 }
 
@@ -50,13 +54,36 @@ type TxGetter interface {
 	HaveTx(Hash) bool
 }
 
+// TxConfirmer is an optional companion to TxGetter for block stores that can
+// report how deeply a transaction is buried without the caller also needing
+// to call Best.
+type TxConfirmer interface {
+	// TxConfirmations returns the number of blocks, including the one
+	// containing the transaction, from the transaction's block to the best
+	// block. A transaction in the best block has one confirmation. If the
+	// transaction is not in a stored block, ErrNotFound is returned.
+	TxConfirmations(txHash Hash) (int64, error)
+}
+
+// TxResultGetter is an optional companion to TxGetter and BlockResultsStorer
+// for block stores that can look up the execution result of a transaction
+// from its hash alone.
+type TxResultGetter interface {
+	// TxResultByHash returns the execution result of the transaction. If the
+	// transaction is not in a stored block, or the results of its block are
+	// not stored, ErrNotFound is returned.
+	TxResultByHash(txHash Hash) (*types.TxResult, error)
+}
+
 type MemPool interface {
 	Size() int
 	ReapN(int) []NamedTx
 	Get(Hash) *types.Transaction
 	Remove(Hash)
-	Store(Hash, *types.Transaction)
+	Store(Hash, *types.Transaction) error
+	Add(Hash, *types.Transaction) (evicted []NamedTx, err error)
 	PeekN(n int) []NamedTx
+	ContiguousNonce(sender []byte, confirmed uint64) uint64
 	// Check([]byte)
 	PreFetch(txid Hash) bool // should be app level instead
 }
@@ -32,7 +32,7 @@ processed:
 const (
 	votingSchemaName = `kwild_voting`
 
-	voteStoreVersion = 2
+	voteStoreVersion = 3
 
 	// tableResolutions is the sql table used to store resolutions that can be voted on.
 	// the vote_body_proposer is the BYTEA of the public key of the submitter, NOT the UUID
@@ -239,6 +239,43 @@ const (
 	dropExtraVoteID = `ALTER TABLE ` + votingSchemaName + `.resolutions DROP COLUMN extra_vote_id;`
 )
 
+// upgrades V2 -> V3
+const (
+	// tableOutcomes records how validator join and remove resolutions were
+	// resolved. Only the latest outcome of a resolution is kept, so a
+	// repeated resolution with the same ID (e.g. a validator requesting to
+	// join again with the same power) replaces the earlier row.
+	tableOutcomes = `CREATE TABLE IF NOT EXISTS ` + votingSchemaName + `.outcomes (
+		id BYTEA PRIMARY KEY, -- id is the resolution's id
+		type TEXT NOT NULL, -- type is the name of the resolution type
+		vote_body_proposer BYTEA NOT NULL, -- vote_body_proposer is the identifier of the node that supplied the vote body
+		body BYTEA NOT NULL, -- body is the resolution info
+		expiration INT8 NOT NULL, -- expiration is the blockheight at which the resolution was set to expire
+		outcome INT8 NOT NULL, -- outcome is how the resolution was resolved (approved, rejected, or expired)
+		height INT8 NOT NULL -- height is the blockheight at which the resolution was resolved
+	);`
+
+	outcomesProposerIndex = `CREATE INDEX IF NOT EXISTS outcomes_proposer_index ON ` + votingSchemaName + `.outcomes (type, vote_body_proposer);`
+
+	outcomesHeightIndex = `CREATE INDEX IF NOT EXISTS outcomes_height_index ON ` + votingSchemaName + `.outcomes (height);`
+
+	upsertOutcome = `INSERT INTO ` + votingSchemaName + `.outcomes (id, type, vote_body_proposer, body, expiration, outcome, height)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT(id) DO UPDATE SET expiration = $5, outcome = $6, height = $7;`
+
+	// deleteOutcomesBefore deletes the outcomes of resolutions that were
+	// resolved before the given height.
+	deleteOutcomesBefore = `DELETE FROM ` + votingSchemaName + `.outcomes WHERE height < $1;`
+
+	// getLatestOutcomeByTypeAndProposer gets the most recent outcome of the
+	// resolutions of a type with the given body proposer.
+	getLatestOutcomeByTypeAndProposer = `SELECT id, body, expiration, outcome, height
+	FROM ` + votingSchemaName + `.outcomes
+	WHERE type = $1 AND vote_body_proposer = $2
+	ORDER BY height DESC, id
+	LIMIT 1;`
+)
+
 // registered resolution types
 const (
 	// ummm.. import cycle issues, so moving them here from migrations pkg.
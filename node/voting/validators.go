@@ -1,6 +1,7 @@
 package voting
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
 	"errors"
@@ -14,8 +15,9 @@ import (
 // this file implements the voting logic for validator approvals
 
 const (
-	ValidatorJoinEventType   = "validator_join"
-	ValidatorRemoveEventType = "validator_remove"
+	ValidatorJoinEventType      = "validator_join"
+	ValidatorRemoveEventType    = "validator_remove"
+	ValidatorKeyUpdateEventType = "validator_key_update"
 )
 
 func init() {
@@ -53,6 +55,43 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
+
+	err = resolutions.RegisterResolution(ValidatorKeyUpdateEventType, resolutions.ModAdd, resolutions.ResolutionConfig{
+		ConfirmationThreshold: big.NewRat(2, 3),
+		ResolveFunc: func(ctx context.Context, app *common.App, resolution *resolutions.Resolution, block *common.BlockContext) error {
+			updateReq := &KeyUpdateRequest{}
+			if err := updateReq.UnmarshalBinary(resolution.Body); err != nil {
+				return fmt.Errorf("failed to unmarshal key update request: %w", err)
+			}
+			return updateValidatorKey(ctx, app, updateReq)
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// updateValidatorKey moves the power of a validator to its new key. The
+// validator set may have changed since the update was requested, so nothing is
+// done if the old key is no longer a validator or the new key already is.
+func updateValidatorKey(ctx context.Context, app *common.App, req *KeyUpdateRequest) error {
+	var oldPower int64
+	for _, val := range app.Validators.GetValidators() {
+		switch {
+		case bytes.Equal(val.PubKey, req.NewPubKey):
+			return nil
+		case bytes.Equal(val.PubKey, req.OldPubKey):
+			oldPower = val.Power
+		}
+	}
+	if oldPower == 0 {
+		return nil
+	}
+
+	if err := app.Validators.SetValidatorPower(ctx, app.DB, req.NewPubKey, oldPower); err != nil {
+		return err
+	}
+	return app.Validators.SetValidatorPower(ctx, app.DB, req.OldPubKey, 0)
 }
 
 // UpdatePowerRequest is a request to update a validator's power.
@@ -78,3 +117,33 @@ func (j *UpdatePowerRequest) UnmarshalBinary(data []byte) error {
 	j.Power = int64(binary.BigEndian.Uint64(data[len(data)-8:]))
 	return nil
 }
+
+// KeyUpdateRequest is a request to replace a validator's key with a new key.
+type KeyUpdateRequest struct {
+	OldPubKey []byte
+	NewPubKey []byte
+}
+
+// MarshalBinary returns the binary representation of the key update request,
+// which is the length prefixed old key followed by the new key. It is
+// deterministic.
+func (k *KeyUpdateRequest) MarshalBinary() ([]byte, error) {
+	b := binary.BigEndian.AppendUint32(nil, uint32(len(k.OldPubKey)))
+	b = append(b, k.OldPubKey...)
+	return append(b, k.NewPubKey...), nil
+}
+
+// UnmarshalBinary unmarshals the key update request from its binary
+// representation.
+func (k *KeyUpdateRequest) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("data too short")
+	}
+	oldLen := binary.BigEndian.Uint32(data)
+	if uint64(len(data)-4) < uint64(oldLen) {
+		return errors.New("data too short")
+	}
+	k.OldPubKey = data[4 : 4+oldLen]
+	k.NewPubKey = data[4+oldLen:]
+	return nil
+}
@@ -289,6 +289,60 @@ func Test_Voting(t *testing.T) {
 				assert.Equal(t, len(notProcessed), 0)
 			},
 		},
+		{
+			name: "resolution outcomes",
+			startingPower: map[string]int64{
+				"a": 100,
+			},
+			fn: func(t *testing.T, db sql.DB, v *VoteStore) {
+				ctx := context.Background()
+
+				outcome, err := GetLatestOutcomeByTypeAndProposer(ctx, db, testType, []byte("a"))
+				require.NoError(t, err)
+				require.Nil(t, outcome)
+
+				res := &resolutions.Resolution{
+					ID:               testEvent.ID(),
+					Body:             testEvent.Body,
+					Type:             testType,
+					ExpirationHeight: 10,
+					Proposer:         []byte("a"),
+				}
+				err = RecordOutcome(ctx, db, res, OutcomeExpired, 10)
+				require.NoError(t, err)
+				err = RecordOutcome(ctx, db, res, OutcomeApproved, 20) // same id, later
+				require.NoError(t, err)
+
+				count, err := db.Execute(ctx, `SELECT COUNT(*) FROM `+votingSchemaName+`.outcomes;`)
+				require.NoError(t, err)
+				require.Equal(t, int64(1), count.Rows[0][0]) // replaced, not added
+
+				outcome, err = GetLatestOutcomeByTypeAndProposer(ctx, db, testType, []byte("a"))
+				require.NoError(t, err)
+				require.Equal(t, &ResolutionOutcome{
+					ID:               testEvent.ID(),
+					Body:             testEvent.Body,
+					ExpirationHeight: 10,
+					Outcome:          OutcomeApproved,
+					Height:           20,
+				}, outcome)
+
+				outcome, err = GetLatestOutcomeByTypeAndProposer(ctx, db, testType, []byte("b"))
+				require.NoError(t, err)
+				require.Nil(t, outcome)
+
+				// Outcomes resolved before the pruning height are deleted.
+				require.NoError(t, PruneOutcomes(ctx, db, 20))
+				outcome, err = GetLatestOutcomeByTypeAndProposer(ctx, db, testType, []byte("a"))
+				require.NoError(t, err)
+				require.NotNil(t, outcome)
+
+				require.NoError(t, PruneOutcomes(ctx, db, 21))
+				outcome, err = GetLatestOutcomeByTypeAndProposer(ctx, db, testType, []byte("a"))
+				require.NoError(t, err)
+				require.Nil(t, outcome)
+			},
+		},
 		{
 			name: "no resolutions",
 			startingPower: map[string]int64{
@@ -59,6 +59,7 @@ func initializeVoteStore(ctx context.Context, db sql.TxMaker) (*VoteStore, error
 		0: initVotingTables,
 		1: dropHeight,
 		2: dropExtraVoteIDColumn,
+		3: addOutcomesTable,
 	}
 
 	err := versioning.Upgrade(ctx, db, votingSchemaName, upgradeFns, voteStoreVersion)
@@ -172,6 +173,15 @@ func dropExtraVoteIDColumn(ctx context.Context, db sql.DB) error {
 	return err
 }
 
+func addOutcomesTable(ctx context.Context, db sql.DB) error {
+	for _, stmt := range []string{tableOutcomes, outcomesProposerIndex, outcomesHeightIndex} {
+		if _, err := db.Execute(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // ApproveResolution approves a resolution from a voter.
 // If the resolution does not yet exist, it will be errored,
 // Validators should only vote on existing resolutions.
@@ -597,6 +607,22 @@ func (v *VoteStore) GetValidatorPower(ctx context.Context, identifier []byte) (p
 	return val.Power, nil
 }
 
+// GetValidatorPowers gets the powers of multiple voters with one lookup. The
+// returned map is keyed by string(pubkey), and voters that do not exist are
+// omitted from it.
+func (v *VoteStore) GetValidatorPowers(ctx context.Context, pubkeys [][]byte) (map[string]int64, error) {
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+
+	powers := make(map[string]int64, len(pubkeys))
+	for _, pubkey := range pubkeys {
+		if val, ok := v.validatorSet[string(pubkey)]; ok {
+			powers[string(pubkey)] = val.Power
+		}
+	}
+	return powers, nil
+}
+
 func (v *VoteStore) GetValidators() []*types.Validator {
 	v.mtx.Lock()
 	defer v.mtx.Unlock()
@@ -679,3 +705,99 @@ func (v *VoteStore) Rollback() {
 
 	v.valUpdates = make(map[string]*types.Validator)
 }
+
+// Outcome is how a resolution was resolved.
+type Outcome int64
+
+const (
+	OutcomeApproved Outcome = iota + 1 // confirmed, and resolved successfully
+	OutcomeRejected                    // confirmed, but resolving it failed
+	OutcomeExpired                     // expired without being confirmed
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeApproved:
+		return "approved"
+	case OutcomeRejected:
+		return "rejected"
+	case OutcomeExpired:
+		return "expired"
+	default:
+		return fmt.Sprintf("unknown outcome %d", o)
+	}
+}
+
+// ResolutionOutcome is the record of a resolved resolution.
+type ResolutionOutcome struct {
+	ID               *types.UUID
+	Body             []byte
+	ExpirationHeight int64
+	Outcome          Outcome
+	Height           int64 // the block height at which it was resolved
+}
+
+// RecordOutcome records how a resolution was resolved at the given height,
+// replacing any earlier outcome of a resolution with the same ID. This is only
+// done for the validator join and remove resolutions, which are not marked as
+// processed (see MarkProcessed), so that their outcomes may be reported after
+// the resolutions are deleted.
+func RecordOutcome(ctx context.Context, db sql.Executor, resolution *resolutions.Resolution, outcome Outcome, height int64) error {
+	_, err := db.Execute(ctx, upsertOutcome, resolution.ID[:], resolution.Type, resolution.Proposer,
+		resolution.Body, resolution.ExpirationHeight, int64(outcome), height)
+	return err
+}
+
+// PruneOutcomes deletes the outcomes of the resolutions that were resolved
+// before the given height.
+func PruneOutcomes(ctx context.Context, db sql.Executor, height int64) error {
+	_, err := db.Execute(ctx, deleteOutcomesBefore, height)
+	return err
+}
+
+// GetLatestOutcomeByTypeAndProposer gets the outcome of the most recently
+// resolved resolution of a type with the given body proposer. It returns nil
+// if there is none.
+func GetLatestOutcomeByTypeAndProposer(ctx context.Context, db sql.Executor, resType string, proposer []byte) (*ResolutionOutcome, error) {
+	res, err := db.Execute(ctx, getLatestOutcomeByTypeAndProposer, resType, proposer)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Rows) == 0 {
+		return nil, nil
+	}
+
+	row := res.Rows[0]
+	if len(row) != 5 {
+		return nil, fmt.Errorf("expected 5 columns, got %d", len(row))
+	}
+	id, ok := row[0].([]byte)
+	if !ok || len(id) != 16 {
+		return nil, fmt.Errorf("internal bug: invalid id (%T)", row[0])
+	}
+	body, ok := row[1].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("invalid type for body (%T)", row[1])
+	}
+	expiration, ok := sql.Int64(row[2])
+	if !ok {
+		return nil, fmt.Errorf("invalid type for expiration (%T)", row[2])
+	}
+	outcome, ok := sql.Int64(row[3])
+	if !ok {
+		return nil, fmt.Errorf("invalid type for outcome (%T)", row[3])
+	}
+	height, ok := sql.Int64(row[4])
+	if !ok {
+		return nil, fmt.Errorf("invalid type for height (%T)", row[4])
+	}
+
+	uuid := types.UUID(slices.Clone(id))
+	return &ResolutionOutcome{
+		ID:               &uuid,
+		Body:             slices.Clone(body),
+		ExpirationHeight: expiration,
+		Outcome:          Outcome(outcome),
+		Height:           height,
+	}, nil
+}
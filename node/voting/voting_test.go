@@ -1,8 +1,15 @@
 package voting
 
 import (
+	"context"
 	"math"
 	"testing"
+
+	"github.com/kwilteam/kwil-db/common"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/types/sql"
+
+	"github.com/stretchr/testify/require"
 )
 
 func Test_intDivUpFraction(t *testing.T) {
@@ -37,3 +44,73 @@ func Test_intDivUpFraction(t *testing.T) {
 		})
 	}
 }
+
+func TestGetValidatorPowers(t *testing.T) {
+	v := &VoteStore{
+		validatorSet: map[string]*types.Validator{
+			"a": {PubKey: []byte("a"), Power: 1},
+			"b": {PubKey: []byte("b"), Power: 2},
+		},
+	}
+
+	powers, err := v.GetValidatorPowers(context.Background(), [][]byte{[]byte("a"), []byte("b"), []byte("c")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(powers) != 2 || powers["a"] != 1 || powers["b"] != 2 {
+		t.Errorf("unexpected powers %v", powers)
+	}
+	if _, ok := powers["c"]; ok {
+		t.Error("non-validator should be omitted")
+	}
+}
+
+// mapValidators is a common.Validators backed by a map of powers.
+type mapValidators map[string]int64
+
+func (m mapValidators) GetValidatorPower(_ context.Context, pubKey []byte) (int64, error) {
+	return m[string(pubKey)], nil
+}
+
+func (m mapValidators) GetValidators() []*types.Validator {
+	var vals []*types.Validator
+	for pubKey, power := range m {
+		vals = append(vals, &types.Validator{PubKey: []byte(pubKey), Power: power})
+	}
+	return vals
+}
+
+func (m mapValidators) SetValidatorPower(_ context.Context, _ sql.Executor, pubKey []byte, power int64) error {
+	if power == 0 {
+		delete(m, string(pubKey))
+	} else {
+		m[string(pubKey)] = power
+	}
+	return nil
+}
+
+func TestUpdateValidatorKey(t *testing.T) {
+	req := &KeyUpdateRequest{OldPubKey: []byte("old"), NewPubKey: []byte("new key")}
+	bts, err := req.MarshalBinary()
+	require.NoError(t, err)
+	var req2 KeyUpdateRequest
+	require.NoError(t, req2.UnmarshalBinary(bts))
+	require.Equal(t, req, &req2)
+	require.Error(t, req2.UnmarshalBinary(bts[:5]))
+
+	ctx := context.Background()
+
+	vals := mapValidators{"old": 3, "other": 1}
+	require.NoError(t, updateValidatorKey(ctx, &common.App{Validators: vals}, req))
+	require.Equal(t, mapValidators{"new key": 3, "other": 1}, vals)
+
+	// The old key is no longer a validator.
+	vals = mapValidators{"other": 1}
+	require.NoError(t, updateValidatorKey(ctx, &common.App{Validators: vals}, req))
+	require.Equal(t, mapValidators{"other": 1}, vals)
+
+	// The new key became a validator since the update was requested.
+	vals = mapValidators{"old": 3, "new key": 1}
+	require.NoError(t, updateValidatorKey(ctx, &common.App{Validators: vals}, req))
+	require.Equal(t, mapValidators{"old": 3, "new key": 1}, vals)
+}